@@ -4,7 +4,12 @@
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/setting"
@@ -19,6 +24,7 @@ var (
 		Usage: "Manage Gitea Actions",
 		Subcommands: []*cli.Command{
 			subcmdActionsGenRunnerToken,
+			subcmdActionsVerifyAttestation,
 		},
 	}
 
@@ -36,6 +42,30 @@ var (
 			},
 		},
 	}
+
+	subcmdActionsVerifyAttestation = &cli.Command{
+		Name:   "verify-attestation",
+		Usage:  "Verify an artifact against the build provenance attestations signed by the server",
+		Action: runVerifyActionsAttestation,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "repo",
+				Aliases:  []string{"r"},
+				Usage:    "{owner}/{repo} - the repository which built the artifact",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:    "file",
+				Aliases: []string{"f"},
+				Usage:   "the artifact file to verify",
+			},
+			&cli.StringFlag{
+				Name:    "digest",
+				Aliases: []string{"d"},
+				Usage:   "the digest of the artifact like sha256:{hex}, instead of the file",
+			},
+		},
+	}
 )
 
 func runGenerateActionsRunnerToken(c *cli.Context) error {
@@ -53,3 +83,44 @@ func runGenerateActionsRunnerToken(c *cli.Context) error {
 	_, _ = fmt.Printf("%s\n", respText.Text)
 	return nil
 }
+
+func runVerifyActionsAttestation(c *cli.Context) error {
+	ctx, cancel := installSignals()
+	defer cancel()
+
+	setting.MustInstalled()
+
+	digest := c.String("digest")
+	if file := c.String("file"); file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		digest = "sha256:" + hex.EncodeToString(h.Sum(nil))
+	}
+	if digest == "" {
+		return errors.New("either --file or --digest is required")
+	}
+
+	verification, extra := private.VerifyActionsAttestation(ctx, c.String("repo"), digest)
+	if extra.HasError() {
+		return handleCliResponseExtra(extra)
+	}
+	for _, result := range verification.Results {
+		if result.Verified {
+			_, _ = fmt.Printf("Attestation %d: verified, built by %s of %s at %s (%s) in run %d attempt %d\n",
+				result.AttestationID, result.Workflow, result.Repository, result.Ref, result.CommitSHA, result.RunID, result.RunAttempt)
+		} else {
+			_, _ = fmt.Printf("Attestation %d: not verified, %s\n", result.AttestationID, result.Error)
+		}
+	}
+	if !verification.Verified {
+		return fmt.Errorf("no attestation of %s is verified", verification.SubjectDigest)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionAttestation))
+}
+
+// ActionAttestation is a signed in-toto attestation of an artifact built by a task, there is one for each subject
+type ActionAttestation struct {
+	ID            int64              `xorm:"pk autoincr"`
+	RepoID        int64              `xorm:"index"`
+	RunID         int64              `xorm:"index"`
+	TaskID        int64              `xorm:"index"`
+	SubjectName   string             `xorm:"VARCHAR(255)"`
+	SubjectDigest string             `xorm:"VARCHAR(255) index"` // the digest of the subject like "sha256:{hex}"
+	PredicateType string             `xorm:"VARCHAR(255)"`
+	KeyID         string             `xorm:"VARCHAR(255)"` // the id of the key which signed the attestation
+	Envelope      string             `xorm:"LONGTEXT"`     // the DSSE envelope of the in-toto statement in JSON
+	Created       timeutil.TimeStamp `xorm:"created"`
+}
+
+type FindAttestationsOptions struct {
+	db.ListOptions
+	RepoID        int64
+	RunID         int64
+	SubjectDigest string
+}
+
+func (opts FindAttestationsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.RunID > 0 {
+		cond = cond.And(builder.Eq{"run_id": opts.RunID})
+	}
+	if opts.SubjectDigest != "" {
+		cond = cond.And(builder.Eq{"subject_digest": opts.SubjectDigest})
+	}
+	return cond
+}
+
+func (opts FindAttestationsOptions) ToOrders() string {
+	return "`id` DESC"
+}
+
+// InsertAttestations inserts the attestations of the subjects of a task
+func InsertAttestations(ctx context.Context, attestations []*ActionAttestation) error {
+	if len(attestations) == 0 {
+		return nil
+	}
+	return db.Insert(ctx, attestations)
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionCache))
+}
+
+// ActionCache is a cache entry saved by `actions/cache`, it's scoped to a repository and a ref.
+// A cache could be restored by the runs of the same ref, or the runs of the refs which can read the caches of the ref,
+// like the runs of a pull request can read the caches of the base branch and the default branch.
+type ActionCache struct {
+	ID          int64              `xorm:"pk autoincr"`
+	RepoID      int64              `xorm:"index"`
+	Ref         string             `xorm:"index"` // the ref which the cache is saved by, like "refs/heads/main"
+	CacheKey    string             `xorm:"VARCHAR(512)"`
+	Version     string             // the hash of the paths and the compression method, calculated by `actions/cache`
+	Size        int64              // the size of the cache archive in bytes
+	StoragePath string             // the path to the cache archive in the storage
+	RunID       int64              // the run which saves the cache
+	Complete    bool               `xorm:"index"` // whether the cache archive has been uploaded completely
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	UsedUnix    timeutil.TimeStamp `xorm:"index"` // the last time when the cache is saved or restored, used to evict the least recently used caches
+}
+
+// CreateCache reserves a cache with the key and version for the ref,
+// it returns ErrAlreadyExist if there's already a cache with the same key and version, no matter if it's complete.
+func CreateCache(ctx context.Context, cache *ActionCache) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		has, err := db.GetEngine(ctx).Where(builder.Eq{
+			"repo_id":   cache.RepoID,
+			"ref":       cache.Ref,
+			"cache_key": cache.CacheKey,
+			"version":   cache.Version,
+		}).Exist(&ActionCache{})
+		if err != nil {
+			return err
+		} else if has {
+			return util.NewAlreadyExistErrorf("cache %q of version %q already exists", cache.CacheKey, cache.Version)
+		}
+		cache.Complete = false
+		cache.UsedUnix = timeutil.TimeStampNow()
+		return db.Insert(ctx, cache)
+	})
+}
+
+// GetCacheByID returns the cache by id
+func GetCacheByID(ctx context.Context, id int64) (*ActionCache, error) {
+	var cache ActionCache
+	has, err := db.GetEngine(ctx).ID(id).Get(&cache)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("cache with id %d: %w", id, util.ErrNotExist)
+	}
+	return &cache, nil
+}
+
+// UpdateCache updates the given columns of a cache
+func UpdateCache(ctx context.Context, cache *ActionCache, cols ...string) error {
+	_, err := db.GetEngine(ctx).ID(cache.ID).Cols(cols...).Update(cache)
+	return err
+}
+
+// MatchCache returns the cache to restore for the keys and the version.
+// The refs are searched in order, and for each ref, the keys are searched in order, an exact match of a key is preferred,
+// or the latest cache whose key has the prefix of the key is used.
+func MatchCache(ctx context.Context, repoID int64, refs, keys []string, version string) (*ActionCache, error) {
+	if len(refs) == 0 || len(keys) == 0 {
+		return nil, fmt.Errorf("cache: %w", util.ErrNotExist)
+	}
+	caches := make([]*ActionCache, 0, 10)
+	if err := db.GetEngine(ctx).Where(builder.Eq{
+		"repo_id":  repoID,
+		"version":  version,
+		"complete": true,
+	}.And(builder.In("ref", refs))).Desc("created_unix", "id").Find(&caches); err != nil {
+		return nil, err
+	}
+
+	for _, ref := range refs {
+		for _, key := range keys {
+			var prefixMatched *ActionCache
+			for _, cache := range caches {
+				if cache.Ref != ref {
+					continue
+				}
+				if cache.CacheKey == key {
+					return cache, nil
+				}
+				if prefixMatched == nil && strings.HasPrefix(cache.CacheKey, key) {
+					prefixMatched = cache
+				}
+			}
+			if prefixMatched != nil {
+				return prefixMatched, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cache: %w", util.ErrNotExist)
+}
+
+// FindCachesOptions represents the options to find caches
+type FindCachesOptions struct {
+	db.ListOptions
+	RepoID        int64
+	Complete      optional.Option[bool]
+	UsedBefore    timeutil.TimeStamp
+	CreatedBefore timeutil.TimeStamp
+}
+
+func (opts FindCachesOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.Complete.Has() {
+		cond = cond.And(builder.Eq{"complete": opts.Complete.Value()})
+	}
+	if opts.UsedBefore > 0 {
+		cond = cond.And(builder.Lt{"used_unix": opts.UsedBefore})
+	}
+	if opts.CreatedBefore > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.CreatedBefore})
+	}
+	return cond
+}
+
+// ToOrders sorts the least recently used caches first
+func (opts FindCachesOptions) ToOrders() string {
+	return "used_unix ASC, id ASC"
+}
+
+// GetRepoCacheSize returns the total size of the complete caches of the repository
+func GetRepoCacheSize(ctx context.Context, repoID int64) (int64, error) {
+	return db.GetEngine(ctx).Where(builder.Eq{"repo_id": repoID, "complete": true}).SumInt(&ActionCache{}, "size")
+}
+
+// GetReposOverCacheSize returns the total sizes of the complete caches of the repositories
+// whose total sizes are greater than the limit, keyed by the repository ids
+func GetReposOverCacheSize(ctx context.Context, limit int64) (map[int64]int64, error) {
+	type repoCacheSize struct {
+		RepoID int64
+		Size   int64
+	}
+	sizes := make([]*repoCacheSize, 0, 10)
+	if err := db.GetEngine(ctx).Table("action_cache").
+		Select("repo_id, SUM(size) AS size").
+		Where(builder.Eq{"complete": true}).
+		GroupBy("repo_id").
+		Having(fmt.Sprintf("SUM(size) > %d", limit)).
+		Find(&sizes); err != nil {
+		return nil, err
+	}
+	ret := make(map[int64]int64, len(sizes))
+	for _, s := range sizes {
+		ret[s.RepoID] = s.Size
+	}
+	return ret, nil
+}
+
+// DeleteCacheByID deletes the record of a cache, the caller should delete the cache archive in the storage
+func DeleteCacheByID(ctx context.Context, id int64) error {
+	_, err := db.GetEngine(ctx).ID(id).Delete(&ActionCache{})
+	return err
+}
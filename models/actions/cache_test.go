@@ -0,0 +1,59 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchCache(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	create := func(ref, key string, complete bool) *ActionCache {
+		cache := &ActionCache{RepoID: 1, Ref: ref, CacheKey: key, Version: "v1", Size: 1}
+		require.NoError(t, CreateCache(db.DefaultContext, cache))
+		cache.Complete = complete
+		require.NoError(t, UpdateCache(db.DefaultContext, cache, "complete"))
+		return cache
+	}
+	mainLinux := create("refs/heads/main", "linux-deps-1", true)
+	mainLinuxNewer := create("refs/heads/main", "linux-deps-2", true)
+	featureLinux := create("refs/heads/feature", "linux-deps-3", true)
+	create("refs/heads/feature", "windows-deps-1", false)
+
+	// a cache with the same key and version can't be reserved again
+	err := CreateCache(db.DefaultContext, &ActionCache{RepoID: 1, Ref: "refs/heads/main", CacheKey: "linux-deps-1", Version: "v1"})
+	assert.ErrorIs(t, err, util.ErrAlreadyExist)
+
+	match := func(refs, keys []string, version string) int64 {
+		cache, err := MatchCache(db.DefaultContext, 1, refs, keys, version)
+		if err != nil {
+			assert.ErrorIs(t, err, util.ErrNotExist)
+			return 0
+		}
+		return cache.ID
+	}
+
+	// exact match is preferred to prefix match
+	assert.Equal(t, mainLinux.ID, match([]string{"refs/heads/main"}, []string{"linux-deps-1"}, "v1"))
+	// the latest cache is used for prefix match
+	assert.Equal(t, mainLinuxNewer.ID, match([]string{"refs/heads/main"}, []string{"linux-deps-"}, "v1"))
+	// the caches of the current ref are preferred
+	assert.Equal(t, featureLinux.ID, match([]string{"refs/heads/feature", "refs/heads/main"}, []string{"linux-deps-1", "linux-"}, "v1"))
+	// fallback to the caches of other readable refs
+	assert.Equal(t, mainLinux.ID, match([]string{"refs/heads/feature", "refs/heads/main"}, []string{"linux-deps-1"}, "v1"))
+	// the caches of unreadable refs can't be restored
+	assert.Zero(t, match([]string{"refs/heads/other"}, []string{"linux-"}, "v1"))
+	// the version must match
+	assert.Zero(t, match([]string{"refs/heads/main"}, []string{"linux-"}, "v2"))
+	// incomplete caches can't be restored
+	assert.Zero(t, match([]string{"refs/heads/feature"}, []string{"windows-"}, "v1"))
+}
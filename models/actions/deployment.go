@@ -0,0 +1,247 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionDeployment))
+	db.RegisterModel(new(ActionDeploymentStatus))
+}
+
+// DeploymentState is the state of a deployment, the values are the same as GitHub's
+type DeploymentState string
+
+const (
+	DeploymentStateWaiting    DeploymentState = "waiting"     // waiting for the approval or the wait timer
+	DeploymentStateQueued     DeploymentState = "queued"      // the job is waiting for a runner
+	DeploymentStateInProgress DeploymentState = "in_progress" // the job is running
+	DeploymentStateSuccess    DeploymentState = "success"
+	DeploymentStateFailure    DeploymentState = "failure" // the job failed, or the deployment was rejected
+	DeploymentStateError      DeploymentState = "error"   // the job was cancelled
+	DeploymentStateInactive   DeploymentState = "inactive"
+)
+
+// IsDone returns whether the deployment has been finished
+func (s DeploymentState) IsDone() bool {
+	switch s {
+	case DeploymentStateSuccess, DeploymentStateFailure, DeploymentStateError, DeploymentStateInactive:
+		return true
+	}
+	return false
+}
+
+// ActionDeployment is a deployment of a job to an environment, it's created when the job is ready to run
+type ActionDeployment struct {
+	ID            int64           `xorm:"pk autoincr"`
+	RepoID        int64           `xorm:"index"`
+	EnvironmentID int64           `xorm:"index"`
+	RunID         int64           `xorm:"index"`
+	JobID         int64           // the id of the ActionRunJob, not the job id in the workflow
+	Ref           string          `xorm:"VARCHAR(255)"`
+	CommitSHA     string          `xorm:"VARCHAR(64)"`
+	CreatorID     int64           // the user who triggers the run
+	State         DeploymentState `xorm:"VARCHAR(20) index"`
+	NeedApproval  bool            // whether the deployment is waiting for the approval of the reviewers
+	ReviewerID    int64           // the user who approved or rejected the deployment
+	WaitUntil     timeutil.TimeStamp
+	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+
+	Environment *ActionEnvironment `xorm:"-"`
+	Creator     *user_model.User   `xorm:"-"`
+}
+
+// ActionDeploymentStatus is a record in the status history of a deployment
+type ActionDeploymentStatus struct {
+	ID           int64              `xorm:"pk autoincr"`
+	RepoID       int64              `xorm:"index"`
+	DeploymentID int64              `xorm:"index"`
+	State        DeploymentState    `xorm:"VARCHAR(20)"`
+	Description  string             `xorm:"TEXT"`
+	CreatorID    int64              // the user who changes the state, 0 if it's changed by the system
+	CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+
+	Creator *user_model.User `xorm:"-"`
+}
+
+// IsPending returns whether the deployment is waiting for the approval or the wait timer
+func (d *ActionDeployment) IsPending() bool {
+	return d.State == DeploymentStateWaiting
+}
+
+// LoadAttributes loads the environment and the creator of the deployment
+func (d *ActionDeployment) LoadAttributes(ctx context.Context) error {
+	if d.Environment == nil {
+		env, err := GetEnvironmentByID(ctx, d.RepoID, d.EnvironmentID)
+		if err != nil {
+			return err
+		}
+		d.Environment = env
+	}
+	if d.Creator == nil {
+		creator, err := user_model.GetPossibleUserByID(ctx, d.CreatorID)
+		if err != nil {
+			return err
+		}
+		d.Creator = creator
+	}
+	return nil
+}
+
+// LoadCreator loads the user who changes the state, it's the ghost user if the user has been deleted
+func (s *ActionDeploymentStatus) LoadCreator(ctx context.Context) error {
+	if s.Creator != nil || s.CreatorID == 0 {
+		return nil
+	}
+	creator, err := user_model.GetPossibleUserByID(ctx, s.CreatorID)
+	if err != nil {
+		return err
+	}
+	s.Creator = creator
+	return nil
+}
+
+// CreateDeployment creates a deployment and the first record of its status history
+func CreateDeployment(ctx context.Context, d *ActionDeployment, description string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := db.Insert(ctx, d); err != nil {
+			return err
+		}
+		return db.Insert(ctx, &ActionDeploymentStatus{
+			RepoID:       d.RepoID,
+			DeploymentID: d.ID,
+			State:        d.State,
+			Description:  description,
+			CreatorID:    d.CreatorID,
+		})
+	})
+}
+
+// UpdateDeploymentState changes the state of a deployment and records it in the status history,
+// the other given columns are updated as well. It returns false if the state has been changed by others.
+func UpdateDeploymentState(ctx context.Context, d *ActionDeployment, state DeploymentState, doerID int64, description string, cols ...string) (bool, error) {
+	var updated bool
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		oldState := d.State
+		d.State = state
+		n, err := db.GetEngine(ctx).ID(d.ID).Where(builder.Eq{"state": oldState}).Cols(append(cols, "state")...).Update(d)
+		if err != nil {
+			return err
+		} else if n == 0 {
+			d.State = oldState
+			return nil
+		}
+		updated = true
+		return db.Insert(ctx, &ActionDeploymentStatus{
+			RepoID:       d.RepoID,
+			DeploymentID: d.ID,
+			State:        state,
+			Description:  description,
+			CreatorID:    doerID,
+		})
+	})
+	return updated, err
+}
+
+// AddDeploymentStatus records a status of a deployment without changing its state, like the approval of a reviewer
+func AddDeploymentStatus(ctx context.Context, d *ActionDeployment, doerID int64, description string, cols ...string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if len(cols) > 0 {
+			if _, err := db.GetEngine(ctx).ID(d.ID).Cols(cols...).Update(d); err != nil {
+				return err
+			}
+		}
+		return db.Insert(ctx, &ActionDeploymentStatus{
+			RepoID:       d.RepoID,
+			DeploymentID: d.ID,
+			State:        d.State,
+			Description:  description,
+			CreatorID:    doerID,
+		})
+	})
+}
+
+// GetDeploymentByID returns the deployment by id
+func GetDeploymentByID(ctx context.Context, id int64) (*ActionDeployment, error) {
+	var d ActionDeployment
+	has, err := db.GetEngine(ctx).ID(id).Get(&d)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("deployment with id %d: %w", id, util.ErrNotExist)
+	}
+	return &d, nil
+}
+
+// GetDeploymentByRepoAndID returns the deployment of the repository by id
+func GetDeploymentByRepoAndID(ctx context.Context, repoID, id int64) (*ActionDeployment, error) {
+	d, err := GetDeploymentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if d.RepoID != repoID {
+		return nil, fmt.Errorf("deployment with id %d: %w", id, util.ErrNotExist)
+	}
+	return d, nil
+}
+
+// FindDeploymentsOptions represents the options to find deployments
+type FindDeploymentsOptions struct {
+	db.ListOptions
+	RepoID          int64
+	EnvironmentID   int64
+	RunID           int64
+	States          []DeploymentState
+	NeedApproval    optional.Option[bool]
+	WaitUntilBefore timeutil.TimeStamp
+	ExcludeID       int64
+}
+
+func (opts FindDeploymentsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.EnvironmentID > 0 {
+		cond = cond.And(builder.Eq{"environment_id": opts.EnvironmentID})
+	}
+	if opts.RunID > 0 {
+		cond = cond.And(builder.Eq{"run_id": opts.RunID})
+	}
+	if len(opts.States) > 0 {
+		cond = cond.And(builder.In("state", opts.States))
+	}
+	if opts.NeedApproval.Has() {
+		cond = cond.And(builder.Eq{"need_approval": opts.NeedApproval.Value()})
+	}
+	if opts.WaitUntilBefore > 0 {
+		cond = cond.And(builder.Lte{"wait_until": opts.WaitUntilBefore})
+	}
+	if opts.ExcludeID > 0 {
+		cond = cond.And(builder.Neq{"id": opts.ExcludeID})
+	}
+	return cond
+}
+
+func (opts FindDeploymentsOptions) ToOrders() string {
+	return "id DESC"
+}
+
+// FindDeploymentStatuses returns the status history of a deployment, the latest one first
+func FindDeploymentStatuses(ctx context.Context, deploymentID int64) ([]*ActionDeploymentStatus, error) {
+	statuses := make([]*ActionDeploymentStatus, 0, 5)
+	return statuses, db.GetEngine(ctx).Where(builder.Eq{"deployment_id": deploymentID}).Desc("id").Find(&statuses)
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionEnvironment))
+}
+
+// ActionEnvironment is a deployment environment of a repository, like "production" or "staging".
+// The jobs targeting an environment with `environment:` are protected by its rules before they are picked by runners,
+// and they could use the secrets and variables of the environment.
+type ActionEnvironment struct {
+	ID             int64              `xorm:"pk autoincr"`
+	RepoID         int64              `xorm:"UNIQUE(repo_name) NOT NULL"`
+	Name           string             `xorm:"VARCHAR(255) NOT NULL"`
+	LowerName      string             `xorm:"UNIQUE(repo_name) VARCHAR(255) NOT NULL"`
+	WaitTimer      int64              // the minutes to wait before the jobs targeting the environment are picked
+	ReviewerIDs    []int64            `xorm:"JSON TEXT"` // the users who could approve or reject the deployments, no approval is required if it's empty
+	BranchPatterns []string           `xorm:"JSON TEXT"` // the glob patterns of the branches or tags which could deploy to the environment, any ref is allowed if it's empty
+	CreatedUnix    timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+
+	Reviewers []*user_model.User `xorm:"-"`
+}
+
+// maxEnvironmentNameLength is the max length of the name of an environment
+const maxEnvironmentNameLength = 255
+
+// ValidateEnvironmentName checks the name of an environment, it's case-insensitive and can't contain control characters
+func ValidateEnvironmentName(name string) error {
+	if strings.TrimSpace(name) != name || name == "" {
+		return util.NewInvalidArgumentErrorf("environment name can't be empty or have leading or trailing spaces")
+	}
+	if len(name) > maxEnvironmentNameLength {
+		return util.NewInvalidArgumentErrorf("environment name can't be longer than %d characters", maxEnvironmentNameLength)
+	}
+	if strings.ContainsFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return util.NewInvalidArgumentErrorf("environment name can't contain control characters")
+	}
+	return nil
+}
+
+// NeedApproval returns whether the deployments to the environment should be approved by the reviewers
+func (env *ActionEnvironment) NeedApproval() bool {
+	return len(env.ReviewerIDs) > 0
+}
+
+// IsReviewer returns whether the user could approve or reject the deployments to the environment
+func (env *ActionEnvironment) IsReviewer(userID int64) bool {
+	return slices.Contains(env.ReviewerIDs, userID)
+}
+
+// IsRefAllowed returns whether the run of the ref could deploy to the environment,
+// the patterns are matched against the short name of the branch or the tag.
+func (env *ActionEnvironment) IsRefAllowed(ref string) bool {
+	if len(env.BranchPatterns) == 0 {
+		return true
+	}
+	refName := git.RefName(ref)
+	if !refName.IsBranch() && !refName.IsTag() {
+		return false
+	}
+	shortName := refName.ShortName()
+	for _, pattern := range env.BranchPatterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			g = glob.MustCompile(glob.QuoteMeta(pattern), '/')
+		}
+		if g.Match(shortName) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadReviewers loads the reviewers of the environment, the deleted users are ignored
+func (env *ActionEnvironment) LoadReviewers(ctx context.Context) error {
+	if env.Reviewers != nil {
+		return nil
+	}
+	users, err := user_model.GetUsersByIDs(ctx, env.ReviewerIDs)
+	if err != nil {
+		return err
+	}
+	env.Reviewers = users
+	return nil
+}
+
+// CreateEnvironment creates an environment, it returns ErrAlreadyExist if there's an environment with the same name
+func CreateEnvironment(ctx context.Context, env *ActionEnvironment) error {
+	if err := ValidateEnvironmentName(env.Name); err != nil {
+		return err
+	}
+	env.LowerName = strings.ToLower(env.Name)
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		has, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": env.RepoID, "lower_name": env.LowerName}).Exist(&ActionEnvironment{})
+		if err != nil {
+			return err
+		} else if has {
+			return util.NewAlreadyExistErrorf("environment %q already exists", env.Name)
+		}
+		return db.Insert(ctx, env)
+	})
+}
+
+// GetEnvironmentByName returns the environment of the repository by its case-insensitive name
+func GetEnvironmentByName(ctx context.Context, repoID int64, name string) (*ActionEnvironment, error) {
+	var env ActionEnvironment
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": repoID, "lower_name": strings.ToLower(name)}).Get(&env)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("environment %q: %w", name, util.ErrNotExist)
+	}
+	return &env, nil
+}
+
+// GetEnvironmentByID returns the environment of the repository by id
+func GetEnvironmentByID(ctx context.Context, repoID, id int64) (*ActionEnvironment, error) {
+	var env ActionEnvironment
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": repoID, "id": id}).Get(&env)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("environment with id %d: %w", id, util.ErrNotExist)
+	}
+	return &env, nil
+}
+
+// GetOrCreateEnvironment returns the environment by name, it's created without protection rules if it doesn't exist,
+// like the environments referenced by workflows are created automatically
+func GetOrCreateEnvironment(ctx context.Context, repoID int64, name string) (*ActionEnvironment, error) {
+	env, err := GetEnvironmentByName(ctx, repoID, name)
+	if err == nil || !errors.Is(err, util.ErrNotExist) {
+		return env, err
+	}
+	env = &ActionEnvironment{RepoID: repoID, Name: name}
+	if err := CreateEnvironment(ctx, env); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// UpdateEnvironment updates the given columns of an environment
+func UpdateEnvironment(ctx context.Context, env *ActionEnvironment, cols ...string) error {
+	_, err := db.GetEngine(ctx).ID(env.ID).Cols(cols...).Update(env)
+	return err
+}
+
+// FindEnvironmentsOptions represents the options to find environments
+type FindEnvironmentsOptions struct {
+	db.ListOptions
+	RepoID int64
+	IDs    []int64
+}
+
+func (opts FindEnvironmentsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if len(opts.IDs) > 0 {
+		cond = cond.And(builder.In("id", opts.IDs))
+	}
+	return cond
+}
+
+func (opts FindEnvironmentsOptions) ToOrders() string {
+	return "lower_name ASC"
+}
+
+// DeleteEnvironment deletes an environment with its deployments and variables,
+// the caller should delete the secrets of the environment in the same transaction
+func DeleteEnvironment(ctx context.Context, env *ActionEnvironment) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		deploymentIDs := make([]int64, 0, 10)
+		if err := db.GetEngine(ctx).Table("action_deployment").Where(builder.Eq{"environment_id": env.ID}).Cols("id").Find(&deploymentIDs); err != nil {
+			return err
+		}
+		if len(deploymentIDs) > 0 {
+			if _, err := db.GetEngine(ctx).In("deployment_id", deploymentIDs).Delete(&ActionDeploymentStatus{}); err != nil {
+				return err
+			}
+		}
+		if _, err := db.GetEngine(ctx).Where(builder.Eq{"environment_id": env.ID}).Delete(&ActionDeployment{}); err != nil {
+			return err
+		}
+		if _, err := db.GetEngine(ctx).Where(builder.Eq{"environment_id": env.ID}).Delete(&ActionVariable{}); err != nil {
+			return err
+		}
+		_, err := db.DeleteByID[ActionEnvironment](ctx, env.ID)
+		return err
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionEnvironment_IsRefAllowed(t *testing.T) {
+	env := &ActionEnvironment{}
+	assert.True(t, env.IsRefAllowed("refs/heads/main"))
+	assert.True(t, env.IsRefAllowed("refs/pull/1/head"))
+
+	env.BranchPatterns = []string{"main", "release/*", "v*"}
+	assert.True(t, env.IsRefAllowed("refs/heads/main"))
+	assert.True(t, env.IsRefAllowed("refs/heads/release/1.0"))
+	assert.False(t, env.IsRefAllowed("refs/heads/release/1.0/hotfix"))
+	assert.True(t, env.IsRefAllowed("refs/tags/v1.0.0"))
+	assert.False(t, env.IsRefAllowed("refs/heads/dev"))
+	assert.False(t, env.IsRefAllowed("refs/pull/1/head"))
+}
+
+func TestCreateEnvironment(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	env := &ActionEnvironment{RepoID: 1, Name: "Production"}
+	require.NoError(t, CreateEnvironment(db.DefaultContext, env))
+	assert.Equal(t, "production", env.LowerName)
+
+	err := CreateEnvironment(db.DefaultContext, &ActionEnvironment{RepoID: 1, Name: "production"})
+	assert.ErrorIs(t, err, util.ErrAlreadyExist)
+	err = CreateEnvironment(db.DefaultContext, &ActionEnvironment{RepoID: 1, Name: " staging"})
+	assert.ErrorIs(t, err, util.ErrInvalidArgument)
+
+	got, err := GetOrCreateEnvironment(db.DefaultContext, 1, "PRODUCTION")
+	require.NoError(t, err)
+	assert.Equal(t, env.ID, got.ID)
+	got, err = GetOrCreateEnvironment(db.DefaultContext, 1, "staging")
+	require.NoError(t, err)
+	assert.NotEqual(t, env.ID, got.ID)
+}
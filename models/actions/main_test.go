@@ -13,6 +13,9 @@ func TestMain(m *testing.M) {
 	unittest.MainTest(m, &unittest.TestOptions{
 		FixtureFiles: []string{
 			"action_runner_token.yml",
+			"repository.yml",
+			"repo_unit.yml",
+			"user.yml",
 		},
 	})
 }
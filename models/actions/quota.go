@@ -0,0 +1,154 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionQuota))
+}
+
+// ActionQuota limits the actions resources used by the repositories of an owner, a zero limit means unlimited
+type ActionQuota struct {
+	ID                int64              `xorm:"pk autoincr"`
+	OwnerID           int64              `xorm:"UNIQUE NOT NULL"`
+	MaxMonthlyMinutes int64              `xorm:"NOT NULL DEFAULT 0"` // the new runs are refused when the billable minutes of the month reach it
+	MaxConcurrentJobs int64              `xorm:"NOT NULL DEFAULT 0"` // the waiting jobs are queued when the running jobs reach it
+	MaxArtifactBytes  int64              `xorm:"NOT NULL DEFAULT 0"` // the new artifacts are refused when the size of the stored artifacts reaches it
+	CreatedUnix       timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix       timeutil.TimeStamp `xorm:"updated"`
+}
+
+// GetQuotaByOwnerID returns the quota of the owner, it returns ErrNotExist if the owner has no quota
+func GetQuotaByOwnerID(ctx context.Context, ownerID int64) (*ActionQuota, error) {
+	var q ActionQuota
+	has, err := db.GetEngine(ctx).Where("owner_id=?", ownerID).Get(&q)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("actions quota of owner %d: %w", ownerID, util.ErrNotExist)
+	}
+	return &q, nil
+}
+
+// SetQuota creates or updates the quota of the owner
+func SetQuota(ctx context.Context, q *ActionQuota) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := GetQuotaByOwnerID(ctx, q.OwnerID)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+		if existing == nil {
+			return db.Insert(ctx, q)
+		}
+		q.ID = existing.ID
+		q.CreatedUnix = existing.CreatedUnix
+		_, err = db.GetEngine(ctx).ID(q.ID).Cols("max_monthly_minutes", "max_concurrent_jobs", "max_artifact_bytes").Update(q)
+		return err
+	})
+}
+
+// DeleteQuota deletes the quota of the owner, so the owner could use unlimited resources
+func DeleteQuota(ctx context.Context, ownerID int64) error {
+	n, err := db.GetEngine(ctx).Where("owner_id=?", ownerID).Delete(&ActionQuota{})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("actions quota of owner %d: %w", ownerID, util.ErrNotExist)
+	}
+	return nil
+}
+
+// QuotaUsage is the current usage of the resources limited by the quota of an owner
+type QuotaUsage struct {
+	MonthlyMinutes int64
+	ConcurrentJobs int64
+	ArtifactBytes  int64
+}
+
+// CountRunningTasks returns the number of the running tasks of the owner
+func CountRunningTasks(ctx context.Context, ownerID int64) (int64, error) {
+	return db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID, "status": StatusRunning}).Count(&ActionTask{})
+}
+
+// GetArtifactBytes returns the size of the artifacts of the owner which are stored or being uploaded
+func GetArtifactBytes(ctx context.Context, ownerID int64) (int64, error) {
+	return db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID}.
+		And(builder.In("status", ArtifactStatusUploadPending, ArtifactStatusUploadConfirmed))).
+		SumInt(&ActionArtifact{}, "file_compressed_size")
+}
+
+// GetQuotaUsage returns the current usage of the resources of the owner
+func GetQuotaUsage(ctx context.Context, ownerID int64) (*QuotaUsage, error) {
+	var u QuotaUsage
+	var err error
+	if u.MonthlyMinutes, err = GetMonthlyMinutes(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	if u.ConcurrentJobs, err = CountRunningTasks(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	if u.ArtifactBytes, err = GetArtifactBytes(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// getQuota returns the quota of the owner, it returns nil if the owner has no quota
+func getQuota(ctx context.Context, ownerID int64) (*ActionQuota, error) {
+	q, err := GetQuotaByOwnerID(ctx, ownerID)
+	if errors.Is(err, util.ErrNotExist) {
+		return nil, nil
+	}
+	return q, err
+}
+
+// IsMonthlyMinutesQuotaExceeded returns whether the owner has used up the billable minutes of the month
+func IsMonthlyMinutesQuotaExceeded(ctx context.Context, ownerID int64) (bool, error) {
+	q, err := getQuota(ctx, ownerID)
+	if err != nil || q == nil || q.MaxMonthlyMinutes <= 0 {
+		return false, err
+	}
+	minutes, err := GetMonthlyMinutes(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+	return minutes >= q.MaxMonthlyMinutes, nil
+}
+
+// IsConcurrentJobsQuotaReached returns whether the running jobs of the owner have reached the limit, so no more jobs could start
+func IsConcurrentJobsQuotaReached(ctx context.Context, ownerID int64) (bool, error) {
+	q, err := getQuota(ctx, ownerID)
+	if err != nil || q == nil || q.MaxConcurrentJobs <= 0 {
+		return false, err
+	}
+	running, err := CountRunningTasks(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+	return running >= q.MaxConcurrentJobs, nil
+}
+
+// IsArtifactQuotaExceeded returns whether the artifacts of the owner have used up the storage
+func IsArtifactQuotaExceeded(ctx context.Context, ownerID int64) (bool, error) {
+	q, err := getQuota(ctx, ownerID)
+	if err != nil || q == nil || q.MaxArtifactBytes <= 0 {
+		return false, err
+	}
+	size, err := GetArtifactBytes(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+	return size >= q.MaxArtifactBytes, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRequiredWorkflow))
+}
+
+// ActionRequiredWorkflow is a workflow registered by an organization to run on its repositories,
+// the workflow file is read from a central repository of the organization instead of the repositories it runs on.
+type ActionRequiredWorkflow struct {
+	ID                 int64              `xorm:"pk autoincr"`
+	OrgID              int64              `xorm:"UNIQUE(org_workflow) NOT NULL"`
+	RepoID             int64              `xorm:"UNIQUE(org_workflow) NOT NULL"` // the central repository which has the workflow file
+	WorkflowPath       string             `xorm:"UNIQUE(org_workflow) VARCHAR(255) NOT NULL"`
+	Ref                string             // the branch to read the workflow file from, the default branch of the central repository is used if it's empty
+	RepoPatterns       []string           `xorm:"JSON TEXT"` // the glob patterns of the names of the repositories to run on, all repositories are matched if it's empty
+	WorkflowName       string             // the name of the workflow, it's the prefix of the commit status contexts created by its runs
+	EnforceStatusCheck bool               `xorm:"NOT NULL DEFAULT false"` // whether the commit statuses are required by the protected branches of the matched repositories
+	CreatorID          int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix        timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix        timeutil.TimeStamp `xorm:"updated"`
+
+	Repo *repo_model.Repository `xorm:"-"`
+}
+
+// LoadRepo loads the central repository of the required workflow
+func (rw *ActionRequiredWorkflow) LoadRepo(ctx context.Context) error {
+	if rw.Repo != nil {
+		return nil
+	}
+	repo, err := repo_model.GetRepositoryByID(ctx, rw.RepoID)
+	if err != nil {
+		return err
+	}
+	rw.Repo = repo
+	return nil
+}
+
+// RunWorkflowID returns the workflow id of the runs of the required workflow,
+// it contains the full name of the central repository so it never conflicts with the workflows of the repositories it runs on.
+func (rw *ActionRequiredWorkflow) RunWorkflowID() string {
+	if rw.Repo == nil {
+		return path.Join(fmt.Sprint(rw.RepoID), rw.WorkflowPath)
+	}
+	return path.Join(rw.Repo.FullName(), rw.WorkflowPath)
+}
+
+// StatusCheckContext returns the glob pattern which matches all the commit status contexts created by the runs of the required workflow
+func (rw *ActionRequiredWorkflow) StatusCheckContext() string {
+	return glob.QuoteMeta(rw.WorkflowName) + " / *"
+}
+
+// MatchRepo returns whether the required workflow should run on the repository,
+// the patterns are matched against the lower name of the repository.
+func (rw *ActionRequiredWorkflow) MatchRepo(repo *repo_model.Repository) bool {
+	if repo.OwnerID != rw.OrgID || repo.ID == rw.RepoID {
+		return false
+	}
+	if len(rw.RepoPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range rw.RepoPatterns {
+		g, err := glob.Compile(strings.ToLower(pattern))
+		if err != nil {
+			g = glob.MustCompile(glob.QuoteMeta(strings.ToLower(pattern)))
+		}
+		if g.Match(repo.LowerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRequiredWorkflowPath checks the path of a required workflow, it should be a yaml file in the workflows directory
+func ValidateRequiredWorkflowPath(workflowPath string) error {
+	if workflowPath != path.Clean(workflowPath) || strings.HasPrefix(workflowPath, "/") {
+		return util.NewInvalidArgumentErrorf("invalid workflow path %q", workflowPath)
+	}
+	if !strings.HasSuffix(workflowPath, ".yml") && !strings.HasSuffix(workflowPath, ".yaml") {
+		return util.NewInvalidArgumentErrorf("workflow path %q is not a yaml file", workflowPath)
+	}
+	if !strings.HasPrefix(workflowPath, ".gitea/workflows/") && !strings.HasPrefix(workflowPath, ".github/workflows/") {
+		return util.NewInvalidArgumentErrorf("workflow path %q is not in the workflows directory", workflowPath)
+	}
+	return nil
+}
+
+// CreateRequiredWorkflow creates a required workflow, it returns ErrAlreadyExist if the workflow of the central repository has been registered
+func CreateRequiredWorkflow(ctx context.Context, rw *ActionRequiredWorkflow) error {
+	if err := ValidateRequiredWorkflowPath(rw.WorkflowPath); err != nil {
+		return err
+	}
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		has, err := db.GetEngine(ctx).Where(builder.Eq{"org_id": rw.OrgID, "repo_id": rw.RepoID, "workflow_path": rw.WorkflowPath}).Exist(&ActionRequiredWorkflow{})
+		if err != nil {
+			return err
+		} else if has {
+			return util.NewAlreadyExistErrorf("required workflow %q already exists", rw.WorkflowPath)
+		}
+		return db.Insert(ctx, rw)
+	})
+}
+
+// GetRequiredWorkflowByID returns the required workflow of the organization by id
+func GetRequiredWorkflowByID(ctx context.Context, orgID, id int64) (*ActionRequiredWorkflow, error) {
+	var rw ActionRequiredWorkflow
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"org_id": orgID, "id": id}).Get(&rw)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("required workflow with id %d: %w", id, util.ErrNotExist)
+	}
+	return &rw, nil
+}
+
+// UpdateRequiredWorkflow updates the given columns of a required workflow
+func UpdateRequiredWorkflow(ctx context.Context, rw *ActionRequiredWorkflow, cols ...string) error {
+	_, err := db.GetEngine(ctx).ID(rw.ID).Cols(cols...).Update(rw)
+	return err
+}
+
+// DeleteRequiredWorkflow deletes a required workflow, the runs created by it are kept
+func DeleteRequiredWorkflow(ctx context.Context, orgID, id int64) error {
+	n, err := db.GetEngine(ctx).Where(builder.Eq{"org_id": orgID, "id": id}).Delete(&ActionRequiredWorkflow{})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("required workflow with id %d: %w", id, util.ErrNotExist)
+	}
+	return nil
+}
+
+// FindRequiredWorkflowsOptions represents the options to find required workflows
+type FindRequiredWorkflowsOptions struct {
+	db.ListOptions
+	OrgID  int64
+	RepoID int64
+}
+
+func (opts FindRequiredWorkflowsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OrgID > 0 {
+		cond = cond.And(builder.Eq{"org_id": opts.OrgID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	return cond
+}
+
+func (opts FindRequiredWorkflowsOptions) ToOrders() string {
+	return "id ASC"
+}
+
+// GetRequiredWorkflowsForRepo returns the required workflows of the owner which should run on the repository
+func GetRequiredWorkflowsForRepo(ctx context.Context, repo *repo_model.Repository) ([]*ActionRequiredWorkflow, error) {
+	rws, err := db.Find[ActionRequiredWorkflow](ctx, FindRequiredWorkflowsOptions{OrgID: repo.OwnerID})
+	if err != nil {
+		return nil, err
+	}
+	matched := make([]*ActionRequiredWorkflow, 0, len(rws))
+	for _, rw := range rws {
+		if rw.MatchRepo(repo) {
+			matched = append(matched, rw)
+		}
+	}
+	return matched, nil
+}
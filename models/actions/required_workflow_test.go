@@ -0,0 +1,41 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRequiredWorkflow_MatchRepo(t *testing.T) {
+	rw := &ActionRequiredWorkflow{OrgID: 3, RepoID: 3}
+	assert.True(t, rw.MatchRepo(&repo_model.Repository{ID: 5, OwnerID: 3, LowerName: "repo5"}))
+	// the central repository and the repositories of other owners are never matched
+	assert.False(t, rw.MatchRepo(&repo_model.Repository{ID: 3, OwnerID: 3, LowerName: "repo3"}))
+	assert.False(t, rw.MatchRepo(&repo_model.Repository{ID: 1, OwnerID: 2, LowerName: "repo1"}))
+
+	rw.RepoPatterns = []string{"service-*", "Web"}
+	assert.True(t, rw.MatchRepo(&repo_model.Repository{ID: 6, OwnerID: 3, LowerName: "service-api"}))
+	assert.True(t, rw.MatchRepo(&repo_model.Repository{ID: 7, OwnerID: 3, LowerName: "web"}))
+	assert.False(t, rw.MatchRepo(&repo_model.Repository{ID: 8, OwnerID: 3, LowerName: "docs"}))
+}
+
+func TestActionRequiredWorkflow_StatusCheckContext(t *testing.T) {
+	rw := &ActionRequiredWorkflow{WorkflowName: "scan"}
+	assert.Equal(t, "scan / *", rw.StatusCheckContext())
+	rw.WorkflowName = "scan [secrets]"
+	assert.Equal(t, `scan \[secrets\] / *`, rw.StatusCheckContext())
+}
+
+func TestValidateRequiredWorkflowPath(t *testing.T) {
+	assert.NoError(t, ValidateRequiredWorkflowPath(".gitea/workflows/scan.yml"))
+	assert.NoError(t, ValidateRequiredWorkflowPath(".github/workflows/scan/licenses.yaml"))
+	assert.Error(t, ValidateRequiredWorkflowPath("scan.yml"))
+	assert.Error(t, ValidateRequiredWorkflowPath(".gitea/workflows/scan.txt"))
+	assert.Error(t, ValidateRequiredWorkflowPath(".gitea/workflows/../../scan.yml"))
+	assert.Error(t, ValidateRequiredWorkflowPath("/.gitea/workflows/scan.yml"))
+}
@@ -46,6 +46,10 @@ type ActionRun struct {
 	TriggerEvent      string                       // the trigger event defined in the `on` configuration of the triggered workflow
 	Status            Status                       `xorm:"index"`
 	Version           int                          `xorm:"version default 0"` // Status could be updated concomitantly, so an optimistic lock is needed
+	RawConcurrency    string                       `xorm:"TEXT"`              // the raw `concurrency` configuration of the workflow
+	ConcurrencyGroup  string                       `xorm:"index"`             // the evaluated concurrency group of the run, runs in the same group of a repository won't run concurrently
+	ConcurrencyCancel bool                         // whether to cancel the other runs in the same concurrency group
+	DoneNotified      bool                         // whether the completion of the run has been notified, it's reset when the run is rerun
 	// Started and Stopped is used for recording last run time, if rerun happened, they will be reset to 0
 	Started timeutil.TimeStamp
 	Stopped timeutil.TimeStamp
@@ -430,4 +434,17 @@ func UpdateRun(ctx context.Context, run *ActionRun, cols ...string) error {
 	return nil
 }
 
+// SetRunDoneNotified marks the completion of a done run as notified,
+// it returns false if the completion has been notified by others or the run isn't done yet.
+func SetRunDoneNotified(ctx context.Context, runID int64) (bool, error) {
+	// update without the optimistic lock, since the column isn't related to the status of the run
+	affected, err := db.GetEngine(ctx).Table("action_run").
+		Where(builder.Eq{"id": runID, "done_notified": false}.And(builder.In("status", StatusSuccess, StatusFailure, StatusCancelled, StatusSkipped))).
+		Update(map[string]any{"done_notified": true})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
 type ActionRunIndex db.ResourceIndex
@@ -37,6 +37,18 @@ type ActionRunJob struct {
 	Stopped           timeutil.TimeStamp
 	Created           timeutil.TimeStamp `xorm:"created"`
 	Updated           timeutil.TimeStamp `xorm:"updated index"`
+
+	RawConcurrency         string `xorm:"TEXT"` // the raw `concurrency` configuration of the job
+	IsConcurrencyEvaluated bool   // whether RawConcurrency has been evaluated, it's evaluated when the job is ready to run
+	ConcurrencyGroup       string `xorm:"index"` // the evaluated concurrency group of the job
+	ConcurrencyCancel      bool   // whether to cancel the other jobs in the same concurrency group
+
+	CallerID int64 `xorm:"index"` // the id of the job which calls the reusable workflow defining this job, 0 if the job isn't in a called workflow
+
+	RawEnvironment string `xorm:"TEXT"` // the raw `environment` configuration of the job
+	DeploymentID   int64  // the deployment created when the job targeting an environment is ready to run, 0 if it hasn't been created
+
+	RawPermissions string `xorm:"TEXT"` // the raw `permissions` of the job, it's inherited from the workflow or the caller if the job doesn't have its own
 }
 
 func init() {
@@ -155,6 +167,7 @@ func UpdateRunJob(ctx context.Context, job *ActionRunJob, cond builder.Cond, col
 func aggregateJobStatus(jobs []*ActionRunJob) Status {
 	allDone := true
 	allWaiting := true
+	allBlocked := true
 	hasFailure := false
 	for _, job := range jobs {
 		if !job.Status.IsDone() {
@@ -163,6 +176,9 @@ func aggregateJobStatus(jobs []*ActionRunJob) Status {
 		if job.Status != StatusWaiting && !job.Status.IsDone() {
 			allWaiting = false
 		}
+		if job.Status != StatusBlocked && !job.Status.IsDone() {
+			allBlocked = false
+		}
 		if job.Status == StatusFailure || job.Status == StatusCancelled {
 			hasFailure = true
 		}
@@ -176,5 +192,9 @@ func aggregateJobStatus(jobs []*ActionRunJob) Status {
 	if allWaiting {
 		return StatusWaiting
 	}
+	if allBlocked {
+		// the jobs are waiting for approval or for the concurrency groups
+		return StatusBlocked
+	}
 	return StatusRunning
 }
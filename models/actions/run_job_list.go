@@ -48,12 +48,13 @@ func (jobs ActionJobList) LoadAttributes(ctx context.Context, withRepo bool) err
 
 type FindRunJobOptions struct {
 	db.ListOptions
-	RunID         int64
-	RepoID        int64
-	OwnerID       int64
-	CommitSHA     string
-	Statuses      []Status
-	UpdatedBefore timeutil.TimeStamp
+	RunID            int64
+	RepoID           int64
+	OwnerID          int64
+	CommitSHA        string
+	Statuses         []Status
+	ConcurrencyGroup string
+	UpdatedBefore    timeutil.TimeStamp
 }
 
 func (opts FindRunJobOptions) ToConds() builder.Cond {
@@ -73,6 +74,9 @@ func (opts FindRunJobOptions) ToConds() builder.Cond {
 	if len(opts.Statuses) > 0 {
 		cond = cond.And(builder.In("status", opts.Statuses))
 	}
+	if opts.ConcurrencyGroup != "" {
+		cond = cond.And(builder.Eq{"concurrency_group": opts.ConcurrencyGroup})
+	}
 	if opts.UpdatedBefore > 0 {
 		cond = cond.And(builder.Lt{"updated": opts.UpdatedBefore})
 	}
@@ -46,6 +46,9 @@ type ActionRunner struct {
 	Description string                 `xorm:"TEXT"`
 	Base        int                    // 0 native 1 docker 2 virtual machine
 	RepoRange   string                 // glob match which repositories could use this runner
+	Ephemeral   bool                   `xorm:"NOT NULL DEFAULT false"`   // an ephemeral runner runs only one task and is deleted after the task is done
+	JobID       int64                  `xorm:"index NOT NULL DEFAULT 0"` // the job which a just-in-time runner is bound to, the runner could only run this job
+	GroupID     int64                  `xorm:"index NOT NULL DEFAULT 0"` // the runner group of an org level runner
 
 	Token     string `xorm:"-"`
 	TokenHash string `xorm:"UNIQUE"` // sha256 of token
@@ -173,6 +176,7 @@ type FindRunnerOptions struct {
 	Filter        string
 	IsOnline      optional.Option[bool]
 	WithAvailable bool // not only runners belong to, but also runners can be used
+	GroupID       int64
 }
 
 func (opts FindRunnerOptions) ToConds() builder.Cond {
@@ -193,6 +197,10 @@ func (opts FindRunnerOptions) ToConds() builder.Cond {
 		cond = cond.And(c)
 	}
 
+	if opts.GroupID > 0 {
+		cond = cond.And(builder.Eq{"group_id": opts.GroupID})
+	}
+
 	if opts.Filter != "" {
 		cond = cond.And(builder.Like{"name", opts.Filter})
 	}
@@ -271,6 +279,19 @@ func DeleteRunner(ctx context.Context, id int64) error {
 	return err
 }
 
+// HasRunnerRunTask returns whether the runner has been assigned any task
+func HasRunnerRunTask(ctx context.Context, runnerID int64) (bool, error) {
+	return db.GetEngine(ctx).Where("runner_id=?", runnerID).Exist(&ActionTask{})
+}
+
+// DeleteStaleEphemeralRunners deletes the ephemeral runners created before the time which haven't been online since then
+func DeleteStaleEphemeralRunners(ctx context.Context, olderThan timeutil.TimeStamp) (int64, error) {
+	return db.GetEngine(ctx).Where(builder.Eq{"ephemeral": true}.
+		And(builder.Lt{"created": olderThan}).
+		And(builder.Lt{"last_online": olderThan})).
+		Delete(&ActionRunner{})
+}
+
 // CreateRunner creates new runner.
 func CreateRunner(ctx context.Context, t *ActionRunner) error {
 	if t.OwnerID != 0 && t.RepoID != 0 {
@@ -0,0 +1,146 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionRunnerGroup))
+}
+
+// ActionRunnerGroup is a group of the runners of an organization,
+// it limits the repositories and the workflows which could use the runners in it.
+type ActionRunnerGroup struct {
+	ID               int64              `xorm:"pk autoincr"`
+	OwnerID          int64              `xorm:"UNIQUE(owner_name) NOT NULL"`
+	Name             string             `xorm:"UNIQUE(owner_name) VARCHAR(255) NOT NULL"`
+	RepoPatterns     []string           `xorm:"JSON TEXT"` // the glob patterns of the names of the repositories which could use the runners, all repositories are matched if it's empty
+	WorkflowPatterns []string           `xorm:"JSON TEXT"` // the glob patterns of the workflow ids which could use the runners, all workflows are matched if it's empty
+	CreatedUnix      timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix      timeutil.TimeStamp `xorm:"updated"`
+}
+
+// matchPatterns returns whether the name matches one of the glob patterns, an empty pattern list matches everything
+func matchPatterns(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern)
+		if err != nil {
+			g = glob.MustCompile(glob.QuoteMeta(pattern))
+		}
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRepo returns whether the runners in the group could be used by the repository,
+// the patterns are matched against the lower name of the repository.
+func (g *ActionRunnerGroup) MatchRepo(repo *repo_model.Repository) bool {
+	if repo.OwnerID != g.OwnerID {
+		return false
+	}
+	lowerPatterns := make([]string, 0, len(g.RepoPatterns))
+	for _, pattern := range g.RepoPatterns {
+		lowerPatterns = append(lowerPatterns, strings.ToLower(pattern))
+	}
+	return matchPatterns(lowerPatterns, repo.LowerName)
+}
+
+// MatchWorkflow returns whether the runners in the group could be used by the runs of the workflow,
+// the patterns are matched against the workflow id, like "build.yml".
+func (g *ActionRunnerGroup) MatchWorkflow(workflowID string) bool {
+	return matchPatterns(g.WorkflowPatterns, workflowID)
+}
+
+// CanRunJob returns whether the runners in the group could run the job, the run and the repository of the job should be loaded
+func (g *ActionRunnerGroup) CanRunJob(job *ActionRunJob) bool {
+	return g.MatchRepo(job.Run.Repo) && g.MatchWorkflow(job.Run.WorkflowID)
+}
+
+// CreateRunnerGroup creates a runner group, it returns ErrAlreadyExist if the name has been used by the owner
+func CreateRunnerGroup(ctx context.Context, g *ActionRunnerGroup) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		has, err := db.GetEngine(ctx).Where(builder.Eq{"owner_id": g.OwnerID, "name": g.Name}).Exist(&ActionRunnerGroup{})
+		if err != nil {
+			return err
+		} else if has {
+			return util.NewAlreadyExistErrorf("runner group %q already exists", g.Name)
+		}
+		return db.Insert(ctx, g)
+	})
+}
+
+// GetRunnerGroupByID returns the runner group of the owner by id
+func GetRunnerGroupByID(ctx context.Context, ownerID, id int64) (*ActionRunnerGroup, error) {
+	var g ActionRunnerGroup
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID, "id": id}).Get(&g)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("runner group with id %d: %w", id, util.ErrNotExist)
+	}
+	return &g, nil
+}
+
+// UpdateRunnerGroup updates the given columns of a runner group, it returns ErrAlreadyExist if the new name has been used by the owner
+func UpdateRunnerGroup(ctx context.Context, g *ActionRunnerGroup, cols ...string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		has, err := db.GetEngine(ctx).Where(builder.Eq{"owner_id": g.OwnerID, "name": g.Name}.And(builder.Neq{"id": g.ID})).Exist(&ActionRunnerGroup{})
+		if err != nil {
+			return err
+		} else if has {
+			return util.NewAlreadyExistErrorf("runner group %q already exists", g.Name)
+		}
+		_, err = db.GetEngine(ctx).ID(g.ID).Cols(cols...).Update(g)
+		return err
+	})
+}
+
+// DeleteRunnerGroup deletes a runner group, the runners in it are kept and could be used by all repositories of the owner again
+func DeleteRunnerGroup(ctx context.Context, ownerID, id int64) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		n, err := db.GetEngine(ctx).Where(builder.Eq{"owner_id": ownerID, "id": id}).Delete(&ActionRunnerGroup{})
+		if err != nil {
+			return err
+		} else if n == 0 {
+			return fmt.Errorf("runner group with id %d: %w", id, util.ErrNotExist)
+		}
+		_, err = db.GetEngine(ctx).Where(builder.Eq{"group_id": id}).Cols("group_id").Update(&ActionRunner{GroupID: 0})
+		return err
+	})
+}
+
+// FindRunnerGroupsOptions represents the options to find runner groups
+type FindRunnerGroupsOptions struct {
+	db.ListOptions
+	OwnerID int64
+}
+
+func (opts FindRunnerGroupsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	return cond
+}
+
+func (opts FindRunnerGroupsOptions) ToOrders() string {
+	return "id ASC"
+}
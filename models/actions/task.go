@@ -6,6 +6,7 @@ package actions
 import (
 	"context"
 	"crypto/subtle"
+	"errors"
 	"fmt"
 	"time"
 
@@ -46,6 +47,8 @@ type ActionTask struct {
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
 	TokenLastEight string `xorm:"index token_last_eight"`
+	// the access modes of the units granted to the token, it's nil for the tasks created before the permissions were stored
+	TokenPermissions TokenPermissions `xorm:"JSON TEXT"`
 
 	LogFilename  string     // file name of log
 	LogInStorage bool       // read log from database or from storage
@@ -215,7 +218,9 @@ func GetRunningTaskByToken(ctx context.Context, token string) (*ActionTask, erro
 	return nil, errNotExist
 }
 
-func CreateTaskForRunner(ctx context.Context, runner *ActionRunner) (*ActionTask, bool, error) {
+// CreateTaskForRunner picks a waiting job for the runner and creates a task of it,
+// the permissions of the task's token are got by getTokenPermissions and stored with the task in the same transaction.
+func CreateTaskForRunner(ctx context.Context, runner *ActionRunner, getTokenPermissions func(ctx context.Context, task *ActionTask) (TokenPermissions, error)) (*ActionTask, bool, error) {
 	ctx, committer, err := db.TxContext(ctx)
 	if err != nil {
 		return nil, false, err
@@ -224,6 +229,15 @@ func CreateTaskForRunner(ctx context.Context, runner *ActionRunner) (*ActionTask
 
 	e := db.GetEngine(ctx)
 
+	if runner.Ephemeral {
+		// an ephemeral runner could run only one task
+		if has, err := HasRunnerRunTask(ctx, runner.ID); err != nil {
+			return nil, false, err
+		} else if has {
+			return nil, false, nil
+		}
+	}
+
 	jobCond := builder.NewCond()
 	if runner.RepoID != 0 {
 		jobCond = builder.Eq{"repo_id": runner.RepoID}
@@ -235,6 +249,25 @@ func CreateTaskForRunner(ctx context.Context, runner *ActionRunner) (*ActionTask
 	if jobCond.IsValid() {
 		jobCond = builder.In("run_id", builder.Select("id").From("action_run").Where(jobCond))
 	}
+	if runner.JobID > 0 {
+		// a just-in-time runner could only run the job it's bound to
+		jobCond = jobCond.And(builder.Eq{"id": runner.JobID})
+	} else {
+		// the jobs bound to just-in-time runners are reserved for them
+		jobCond = jobCond.And(builder.NotIn("id", builder.Select("job_id").From("action_runner").
+			Where(builder.Gt{"job_id": 0}.And(builder.Or(builder.IsNull{"deleted"}, builder.Eq{"deleted": 0})))))
+	}
+
+	// the runners in a runner group could only run the jobs of the repositories and workflows allowed by the group
+	var group *ActionRunnerGroup
+	if runner.GroupID > 0 && runner.OwnerID > 0 && runner.RepoID == 0 {
+		group, err = GetRunnerGroupByID(ctx, runner.OwnerID, runner.GroupID)
+		if errors.Is(err, util.ErrNotExist) {
+			group = nil
+		} else if err != nil {
+			return nil, false, err
+		}
+	}
 
 	var jobs []*ActionRunJob
 	if err := e.Where("task_id=? AND status=?", 0, StatusWaiting).And(jobCond).Asc("updated", "id").Find(&jobs); err != nil {
@@ -244,11 +277,32 @@ func CreateTaskForRunner(ctx context.Context, runner *ActionRunner) (*ActionTask
 	// TODO: a more efficient way to filter labels
 	var job *ActionRunJob
 	log.Trace("runner labels: %v", runner.AgentLabels)
+	// the jobs of the owners whose running jobs have reached the quota are queued
+	quotaReached := make(map[int64]bool)
 	for _, v := range jobs {
-		if isSubset(runner.AgentLabels, v.RunsOn) {
-			job = v
-			break
+		if !isSubset(runner.AgentLabels, v.RunsOn) {
+			continue
+		}
+		reached, ok := quotaReached[v.OwnerID]
+		if !ok {
+			if reached, err = IsConcurrentJobsQuotaReached(ctx, v.OwnerID); err != nil {
+				return nil, false, err
+			}
+			quotaReached[v.OwnerID] = reached
+		}
+		if reached {
+			continue
 		}
+		if group != nil {
+			if err := v.LoadAttributes(ctx); err != nil {
+				return nil, false, err
+			}
+			if !group.CanRunJob(v) {
+				continue
+			}
+		}
+		job = v
+		break
 	}
 	if job == nil {
 		return nil, false, nil
@@ -286,6 +340,13 @@ func CreateTaskForRunner(ctx context.Context, runner *ActionRunner) (*ActionTask
 		_, workflowJob = gots[0].Job()
 	}
 
+	if getTokenPermissions != nil {
+		task.Job = job
+		if task.TokenPermissions, err = getTokenPermissions(ctx, task); err != nil {
+			return nil, false, err
+		}
+	}
+
 	if _, err := e.Insert(task); err != nil {
 		return nil, false, err
 	}
@@ -374,6 +435,9 @@ func UpdateTaskByState(ctx context.Context, state *runnerv1.TaskState) (*ActionT
 		if err := UpdateTask(ctx, task, "status", "stopped"); err != nil {
 			return nil, err
 		}
+		if err := recordTaskUsage(ctx, task); err != nil {
+			return nil, err
+		}
 		if _, err := UpdateRunJob(ctx, &ActionRunJob{
 			ID:      task.JobID,
 			Status:  task.Status,
@@ -449,6 +513,9 @@ func StopTask(ctx context.Context, taskID int64, status Status) error {
 	if err := UpdateTask(ctx, task, "status", "stopped"); err != nil {
 		return err
 	}
+	if err := recordTaskUsage(ctx, task); err != nil {
+		return err
+	}
 
 	if err := task.LoadAttributes(ctx); err != nil {
 		return err
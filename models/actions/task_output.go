@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
 )
 
 // ActionTaskOutput represents an output of ActionTask.
@@ -22,6 +23,7 @@ type ActionTaskOutput struct {
 
 func init() {
 	db.RegisterModel(new(ActionTaskOutput))
+	db.RegisterModel(new(ActionTaskStepSummary))
 }
 
 // FindTaskOutputByTaskID returns the outputs of the task.
@@ -53,3 +55,49 @@ func InsertTaskOutputIfNotExist(ctx context.Context, taskID int64, key, value st
 		return err
 	})
 }
+
+// ActionTaskStepSummary represents the markdown summary written by a step of ActionTask to $GITHUB_STEP_SUMMARY.
+// Like the outputs, the summaries are bound to a task, so the summaries of a rerun job are reset.
+type ActionTaskStepSummary struct {
+	ID        int64
+	TaskID    int64              `xorm:"UNIQUE(task_id_step_index)"`
+	RepoID    int64              `xorm:"index"`
+	StepIndex int64              `xorm:"UNIQUE(task_id_step_index)"`
+	Content   string             `xorm:"LONGTEXT"`
+	Created   timeutil.TimeStamp `xorm:"created"`
+	Updated   timeutil.TimeStamp `xorm:"updated"`
+}
+
+// FindTaskStepSummariesByTaskID returns the summaries of the steps of the task in the order of the steps.
+func FindTaskStepSummariesByTaskID(ctx context.Context, taskID int64) ([]*ActionTaskStepSummary, error) {
+	var summaries []*ActionTaskStepSummary
+	return summaries, db.GetEngine(ctx).Where("task_id=?", taskID).OrderBy("step_index").Find(&summaries)
+}
+
+// UpsertTaskStepSummary inserts the summary of a step or replaces the content if the step has a summary,
+// since the runner may resend the summary when the step writes more to it.
+func UpsertTaskStepSummary(ctx context.Context, task *ActionTask, stepIndex int64, content string) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		sess := db.GetEngine(ctx)
+		summary := &ActionTaskStepSummary{}
+		has, err := sess.Where("task_id=? AND step_index=?", task.ID, stepIndex).Get(summary)
+		if err != nil {
+			return err
+		}
+		if !has {
+			_, err = sess.Insert(&ActionTaskStepSummary{
+				TaskID:    task.ID,
+				RepoID:    task.RepoID,
+				StepIndex: stepIndex,
+				Content:   content,
+			})
+			return err
+		}
+		if summary.Content == content {
+			return nil
+		}
+		summary.Content = content
+		_, err = sess.ID(summary.ID).Cols("content").Update(summary)
+		return err
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTaskWorkflowPayload = `name: test
+on: push
+jobs:
+  test:
+    runs-on: linux
+    steps:
+      - run: echo test
+`
+
+// insertWaitingJob inserts a run of the workflow with a job waiting for a runner labeled "linux" in repo1
+func insertWaitingJob(t *testing.T, workflowID string) *ActionRunJob {
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 1})
+	var maxIndex int64
+	_, err := db.GetEngine(db.DefaultContext).Table("action_run").Where("repo_id=?", repo.ID).Select("MAX(`index`)").Get(&maxIndex)
+	require.NoError(t, err)
+
+	run := &ActionRun{
+		Title:         "test",
+		RepoID:        repo.ID,
+		OwnerID:       repo.OwnerID,
+		WorkflowID:    workflowID,
+		Index:         maxIndex + 1,
+		TriggerUserID: 2,
+		Ref:           "refs/heads/master",
+		Event:         "push",
+		Status:        StatusWaiting,
+	}
+	require.NoError(t, db.Insert(db.DefaultContext, run))
+	job := &ActionRunJob{
+		RunID:           run.ID,
+		RepoID:          repo.ID,
+		OwnerID:         repo.OwnerID,
+		Name:            "test",
+		JobID:           "test",
+		RunsOn:          []string{"linux"},
+		Status:          StatusWaiting,
+		WorkflowPayload: []byte(testTaskWorkflowPayload),
+	}
+	require.NoError(t, db.Insert(db.DefaultContext, job))
+	return job
+}
+
+func insertRunner(t *testing.T, runner *ActionRunner) *ActionRunner {
+	runner.AgentLabels = []string{"linux"}
+	require.NoError(t, runner.GenerateToken())
+	require.NoError(t, CreateRunner(db.DefaultContext, runner))
+	return runner
+}
+
+func TestCreateTaskForRunnerInGroup(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	group := &ActionRunnerGroup{OwnerID: 2, Name: "deploy", RepoPatterns: []string{"repo*"}, WorkflowPatterns: []string{"deploy-*.yml"}}
+	require.NoError(t, CreateRunnerGroup(db.DefaultContext, group))
+	runner := insertRunner(t, &ActionRunner{UUID: "5b0f5d2b-4a4e-4cc6-9c3b-2f3f3c1b7a01", Name: "grouped", OwnerID: 2, GroupID: group.ID})
+
+	insertWaitingJob(t, "build.yml")
+	_, ok, err := CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "the workflow is not allowed by the group")
+
+	deployJob := insertWaitingJob(t, "deploy-prod.yml")
+	task, ok, err := CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, deployJob.ID, task.JobID)
+
+	// the runner could run any job of its owner after the group is deleted
+	require.NoError(t, DeleteRunnerGroup(db.DefaultContext, group.OwnerID, group.ID))
+	runner = unittest.AssertExistsAndLoadBean(t, &ActionRunner{ID: runner.ID})
+	assert.EqualValues(t, 0, runner.GroupID)
+	_, ok, err = CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCreateTaskForJITRunner(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	job := insertWaitingJob(t, "build.yml")
+	jitRunner := insertRunner(t, &ActionRunner{UUID: "5b0f5d2b-4a4e-4cc6-9c3b-2f3f3c1b7a02", Name: "jit", OwnerID: 2, Ephemeral: true, JobID: job.ID})
+	runner := insertRunner(t, &ActionRunner{UUID: "5b0f5d2b-4a4e-4cc6-9c3b-2f3f3c1b7a03", Name: "normal", OwnerID: 2})
+
+	// the job is reserved for the just-in-time runner
+	_, ok, err := CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	task, ok, err := CreateTaskForRunner(db.DefaultContext, jitRunner, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, job.ID, task.JobID)
+
+	// an ephemeral runner runs only one task
+	insertWaitingJob(t, "build.yml")
+	jitRunner.JobID = 0
+	_, ok, err = CreateTaskForRunner(db.DefaultContext, jitRunner, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCreateTaskForRunnerTokenPermissions(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	job := insertWaitingJob(t, "build.yml")
+	runner := insertRunner(t, &ActionRunner{UUID: "5b0f5d2b-4a4e-4cc6-9c3b-2f3f3c1b7a04", Name: "permissions", OwnerID: 2})
+
+	// the task isn't created if its permissions can't be got
+	_, _, err := CreateTaskForRunner(db.DefaultContext, runner, func(ctx context.Context, task *ActionTask) (TokenPermissions, error) {
+		return nil, errors.New("no permissions")
+	})
+	require.Error(t, err)
+	unittest.AssertNotExistsBean(t, &ActionTask{JobID: job.ID})
+
+	task, ok, err := CreateTaskForRunner(db.DefaultContext, runner, func(ctx context.Context, task *ActionTask) (TokenPermissions, error) {
+		assert.Equal(t, job.ID, task.Job.ID)
+		return TokenPermissions{unit.TypeCode: perm.AccessModeRead}, nil
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	task = unittest.AssertExistsAndLoadBean(t, &ActionTask{ID: task.ID})
+	assert.Equal(t, perm.AccessModeRead, task.UnitAccessMode(unit.TypeCode))
+	assert.Equal(t, perm.AccessModeNone, task.UnitAccessMode(unit.TypeIssues))
+
+	// the tasks without stored permissions have no access
+	task.TokenPermissions = nil
+	assert.Equal(t, perm.AccessModeNone, task.UnitAccessMode(unit.TypeCode))
+}
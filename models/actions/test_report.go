@@ -0,0 +1,257 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionTestReport))
+	db.RegisterModel(new(ActionTestCase))
+}
+
+const (
+	// MaxTestCasesPerReport is the max number of test cases stored for a report, the counts of the report include all the test cases
+	MaxTestCasesPerReport = 10000
+	// flakyTestRunsWindow is the number of the recent runs of a workflow used to detect flaky tests
+	flakyTestRunsWindow = 10
+	// maxFlakyTestCandidates is the max number of the failed test cases checked for flakiness
+	maxFlakyTestCandidates = 500
+)
+
+// TestCaseStatus represents the result of a test case
+type TestCaseStatus string
+
+const (
+	TestCaseStatusPassed  TestCaseStatus = "passed"
+	TestCaseStatusFailed  TestCaseStatus = "failed"
+	TestCaseStatusSkipped TestCaseStatus = "skipped"
+)
+
+// TestCounts represents the numbers of the test cases of reports
+type TestCounts struct {
+	Total    int64
+	Passed   int64
+	Failed   int64
+	Skipped  int64
+	Duration time.Duration
+}
+
+// Add adds the counts of another report
+func (c *TestCounts) Add(other TestCounts) {
+	c.Total += other.Total
+	c.Passed += other.Passed
+	c.Failed += other.Failed
+	c.Skipped += other.Skipped
+	c.Duration += other.Duration
+}
+
+// ActionTestReport represents a test report file in an artifact of a run
+type ActionTestReport struct {
+	ID         int64  `xorm:"pk autoincr"`
+	RepoID     int64  `xorm:"index"`
+	RunID      int64  `xorm:"index"`
+	ArtifactID int64  `xorm:"index"`
+	Name       string `xorm:"TEXT"` // the path of the report file in the artifact
+	TestCounts `xorm:"extends"`
+	Created    timeutil.TimeStamp `xorm:"created"`
+
+	Cases []*ActionTestCase `xorm:"-"`
+}
+
+// ActionTestCase represents a test case of a test report
+type ActionTestCase struct {
+	ID        int64          `xorm:"pk autoincr"`
+	RepoID    int64          `xorm:"index"`
+	RunID     int64          `xorm:"index"`
+	ReportID  int64          `xorm:"index"`
+	Suite     string         `xorm:"VARCHAR(255)"`
+	ClassName string         `xorm:"VARCHAR(255)"`
+	Name      string         `xorm:"TEXT"`
+	Status    TestCaseStatus `xorm:"VARCHAR(16) index"`
+	Duration  time.Duration
+	Message   string `xorm:"TEXT"`
+	Details   string `xorm:"LONGTEXT"`
+}
+
+// Key returns the key to identify the test case across runs
+func (c *ActionTestCase) Key() string {
+	return c.Suite + "\x00" + c.ClassName + "\x00" + c.Name
+}
+
+// FullName returns the name of the test case with its class name
+func (c *ActionTestCase) FullName() string {
+	if c.ClassName == "" || c.ClassName == c.Name {
+		return c.Name
+	}
+	return c.ClassName + "." + c.Name
+}
+
+// ReplaceTestReports replaces the test reports of the artifact, the old ones are removed if the artifact is uploaded again by a re-run job
+func ReplaceTestReports(ctx context.Context, artifact *ActionArtifact, reports []*ActionTestReport) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.GetEngine(ctx).Where(builder.In("report_id",
+			builder.Select("id").From("action_test_report").Where(builder.Eq{"artifact_id": artifact.ID}),
+		)).Delete(new(ActionTestCase)); err != nil {
+			return err
+		}
+		if _, err := db.DeleteByBean(ctx, &ActionTestReport{ArtifactID: artifact.ID}); err != nil {
+			return err
+		}
+
+		for _, report := range reports {
+			report.RepoID = artifact.RepoID
+			report.RunID = artifact.RunID
+			report.ArtifactID = artifact.ID
+			if err := db.Insert(ctx, report); err != nil {
+				return err
+			}
+
+			cases := report.Cases
+			if len(cases) > MaxTestCasesPerReport {
+				cases = cases[:MaxTestCasesPerReport]
+			}
+			for _, c := range cases {
+				c.RepoID = report.RepoID
+				c.RunID = report.RunID
+				c.ReportID = report.ID
+			}
+			// insert in batches to avoid exceeding the limit of the number of parameters of a statement
+			for len(cases) > 0 {
+				n := min(len(cases), 100)
+				if err := db.Insert(ctx, cases[:n]); err != nil {
+					return err
+				}
+				cases = cases[n:]
+			}
+		}
+		return nil
+	})
+}
+
+// FindTestReportsOptions represents the options to find test reports
+type FindTestReportsOptions struct {
+	db.ListOptions
+	RepoID int64
+	RunIDs []int64
+}
+
+func (opts FindTestReportsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if len(opts.RunIDs) > 0 {
+		cond = cond.And(builder.In("run_id", opts.RunIDs))
+	}
+	return cond
+}
+
+func (opts FindTestReportsOptions) ToOrders() string {
+	return "id ASC"
+}
+
+// FindTestCasesOptions represents the options to find test cases
+type FindTestCasesOptions struct {
+	db.ListOptions
+	RunIDs []int64
+	Status TestCaseStatus
+}
+
+func (opts FindTestCasesOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if len(opts.RunIDs) > 0 {
+		cond = cond.And(builder.In("run_id", opts.RunIDs))
+	}
+	if opts.Status != "" {
+		cond = cond.And(builder.Eq{"status": opts.Status})
+	}
+	return cond
+}
+
+func (opts FindTestCasesOptions) ToOrders() string {
+	return "id ASC"
+}
+
+// SumTestReports returns the total counts of the reports
+func SumTestReports(reports []*ActionTestReport) TestCounts {
+	var counts TestCounts
+	for _, r := range reports {
+		counts.Add(r.TestCounts)
+	}
+	return counts
+}
+
+// GetFlakyTestCaseKeys returns the keys of the failed test cases of the run which are flaky.
+// A test is treated as flaky if it failed in the run and it has recovered from failures before,
+// that is, its result changed at least twice in the recent runs of the same workflow.
+func GetFlakyTestCaseKeys(ctx context.Context, run *ActionRun, failedCases []*ActionTestCase) (container.Set[string], error) {
+	flaky := make(container.Set[string])
+	names := make(container.Set[string])
+	for _, c := range failedCases {
+		if c.Status == TestCaseStatusFailed && len(names) < maxFlakyTestCandidates {
+			names.Add(c.Name)
+		}
+	}
+	if len(names) == 0 {
+		return flaky, nil
+	}
+
+	var runIDs []int64
+	if err := db.GetEngine(ctx).Table("action_run").Cols("id").
+		Where(builder.Eq{"repo_id": run.RepoID, "workflow_id": run.WorkflowID}.And(builder.Lte{"id": run.ID})).
+		Desc("id").Limit(flakyTestRunsWindow).Find(&runIDs); err != nil {
+		return nil, err
+	}
+	if len(runIDs) < 3 {
+		// a test must have failed, passed and failed again to be flaky
+		return flaky, nil
+	}
+
+	var cases []*ActionTestCase
+	if err := db.GetEngine(ctx).Cols("run_id", "suite", "class_name", "name", "status").
+		Where(builder.In("run_id", runIDs)).
+		And(builder.In("name", names.Values())).
+		And(builder.In("status", TestCaseStatusPassed, TestCaseStatusFailed)).
+		Asc("run_id").Find(&cases); err != nil {
+		return nil, err
+	}
+
+	// the result of a test in a run is failed if it failed in any report of the run
+	type result struct {
+		runID  int64
+		failed bool
+	}
+	histories := make(map[string][]*result)
+	for _, c := range cases {
+		history := histories[c.Key()]
+		if len(history) == 0 || history[len(history)-1].runID != c.RunID {
+			history = append(history, &result{runID: c.RunID})
+			histories[c.Key()] = history
+		}
+		if c.Status == TestCaseStatusFailed {
+			history[len(history)-1].failed = true
+		}
+	}
+	for key, history := range histories {
+		changes := 0
+		for i := 1; i < len(history); i++ {
+			if history[i].failed != history[i-1].failed {
+				changes++
+			}
+		}
+		if changes >= 2 && history[len(history)-1].runID == run.ID && history[len(history)-1].failed {
+			flaky.Add(key)
+		}
+	}
+	return flaky, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceTestReports(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	artifact := &ActionArtifact{ID: 1000, RepoID: 4, RunID: 791}
+	newReport := func(statuses ...TestCaseStatus) *ActionTestReport {
+		report := &ActionTestReport{Name: "junit.xml"}
+		for i, status := range statuses {
+			report.Cases = append(report.Cases, &ActionTestCase{Name: string(rune('A' + i)), Status: status})
+		}
+		report.Total = int64(len(statuses))
+		return report
+	}
+
+	require.NoError(t, ReplaceTestReports(db.DefaultContext, artifact, []*ActionTestReport{
+		newReport(TestCaseStatusPassed, TestCaseStatusFailed),
+		newReport(TestCaseStatusSkipped),
+	}))
+	unittest.AssertCount(t, &ActionTestReport{ArtifactID: artifact.ID}, 2)
+	unittest.AssertCount(t, &ActionTestCase{RunID: artifact.RunID}, 3)
+
+	// the reports are replaced when the artifact is uploaded again
+	require.NoError(t, ReplaceTestReports(db.DefaultContext, artifact, []*ActionTestReport{
+		newReport(TestCaseStatusPassed),
+	}))
+	reports, err := db.Find[ActionTestReport](db.DefaultContext, FindTestReportsOptions{RunIDs: []int64{artifact.RunID}})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.EqualValues(t, 1, SumTestReports(reports).Total)
+	unittest.AssertCount(t, &ActionTestCase{RunID: artifact.RunID}, 1)
+}
+
+func TestGetFlakyTestCaseKeys(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	// the results of the tests in 4 runs, TestFlaky recovered and failed again, TestBroken failed only in the last run
+	histories := map[string][]TestCaseStatus{
+		"TestFlaky":  {TestCaseStatusFailed, TestCaseStatusPassed, TestCaseStatusPassed, TestCaseStatusFailed},
+		"TestBroken": {TestCaseStatusPassed, TestCaseStatusPassed, TestCaseStatusPassed, TestCaseStatusFailed},
+	}
+	var run *ActionRun
+	for i := 0; i < 4; i++ {
+		run = &ActionRun{RepoID: 4, OwnerID: 1, WorkflowID: "test.yaml", Index: int64(1000 + i)}
+		require.NoError(t, db.Insert(db.DefaultContext, run))
+		report := &ActionTestReport{Name: "junit.xml"}
+		for name, history := range histories {
+			report.Cases = append(report.Cases, &ActionTestCase{Suite: "pkg", ClassName: "pkg", Name: name, Status: history[i]})
+		}
+		require.NoError(t, ReplaceTestReports(db.DefaultContext, &ActionArtifact{ID: int64(1000 + i), RepoID: 4, RunID: run.ID}, []*ActionTestReport{report}))
+	}
+
+	failed, err := db.Find[ActionTestCase](db.DefaultContext, FindTestCasesOptions{RunIDs: []int64{run.ID}, Status: TestCaseStatusFailed})
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+
+	flaky, err := GetFlakyTestCaseKeys(db.DefaultContext, run, failed)
+	require.NoError(t, err)
+	assert.Len(t, flaky, 1)
+	for _, c := range failed {
+		assert.Equal(t, c.Name == "TestFlaky", flaky.Contains(c.Key()), c.Name)
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
+)
+
+// TokenPermissions are the access modes of the units of the repository granted to the token of a task,
+// the units not in the map are not accessible with the token.
+type TokenPermissions map[unit.Type]perm.AccessMode
+
+// UnitAccessMode returns the access mode of the unit granted to the token of the task,
+// the tasks without stored permissions have no access, the permissions of the tasks created before they were stored are set by the migration.
+func (task *ActionTask) UnitAccessMode(unitType unit.Type) perm.AccessMode {
+	return task.TokenPermissions[unitType]
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(ActionTaskUsage))
+}
+
+// ActionTaskUsage records the execution time of a finished task,
+// it's kept after the task and its run are deleted, so the usage of the owners could be accounted.
+type ActionTaskUsage struct {
+	ID            int64              `xorm:"pk autoincr"`
+	TaskID        int64              `xorm:"UNIQUE NOT NULL"`
+	RepoID        int64              `xorm:"index NOT NULL"`
+	OwnerID       int64              `xorm:"index(owner_stopped) NOT NULL"`
+	TriggerUserID int64              `xorm:"index NOT NULL DEFAULT 0"`
+	RunID         int64              `xorm:"index NOT NULL"`
+	JobID         int64              `xorm:"NOT NULL"`
+	JobName       string             `xorm:"VARCHAR(255)"`
+	RunnerID      int64              `xorm:"NOT NULL DEFAULT 0"`
+	Labels        string             `xorm:"VARCHAR(255)"` // the runs-on labels of the job, sorted and joined with commas
+	Status        Status             `xorm:"NOT NULL"`
+	Started       timeutil.TimeStamp `xorm:"NOT NULL"`
+	Stopped       timeutil.TimeStamp `xorm:"index(owner_stopped) NOT NULL"`
+	Seconds       int64              `xorm:"NOT NULL"` // the execution time of the task
+	Minutes       int64              `xorm:"NOT NULL"` // the billable minutes, the execution time of each task is rounded up to the whole minute
+}
+
+// usageLabels returns the labels of the job to account the usage by
+func usageLabels(runsOn []string) string {
+	labels := slices.Clone(runsOn)
+	slices.Sort(labels)
+	s := strings.Join(labels, ",")
+	if len(s) > 255 {
+		s = s[:255]
+	}
+	return s
+}
+
+// recordTaskUsage records the usage of a stopped task, it does nothing if the usage has been recorded
+func recordTaskUsage(ctx context.Context, task *ActionTask) error {
+	if !task.IsStopped() {
+		return nil
+	}
+	if has, err := db.GetEngine(ctx).Where("task_id=?", task.ID).Exist(&ActionTaskUsage{}); err != nil {
+		return err
+	} else if has {
+		return nil
+	}
+
+	job, err := GetRunJobByID(ctx, task.JobID)
+	if err != nil {
+		return err
+	}
+	if err := job.LoadRun(ctx); err != nil {
+		return err
+	}
+
+	var seconds int64
+	if task.Started > 0 && task.Stopped > task.Started {
+		seconds = int64(task.Stopped - task.Started)
+	}
+	return db.Insert(ctx, &ActionTaskUsage{
+		TaskID:        task.ID,
+		RepoID:        task.RepoID,
+		OwnerID:       task.OwnerID,
+		TriggerUserID: job.Run.TriggerUserID,
+		RunID:         job.RunID,
+		JobID:         job.ID,
+		JobName:       job.Name,
+		RunnerID:      task.RunnerID,
+		Labels:        usageLabels(job.RunsOn),
+		Status:        task.Status,
+		Started:       task.Started,
+		Stopped:       task.Stopped,
+		Seconds:       seconds,
+		Minutes:       (seconds + 59) / 60,
+	})
+}
+
+// FindTaskUsageOptions represents the options to find the usage of tasks
+type FindTaskUsageOptions struct {
+	db.ListOptions
+	OwnerID int64
+	RepoID  int64
+	Since   timeutil.TimeStamp // the tasks stopped at or after the time
+	Before  timeutil.TimeStamp // the tasks stopped before the time
+}
+
+func (opts FindTaskUsageOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"stopped": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"stopped": opts.Before})
+	}
+	return cond
+}
+
+func (opts FindTaskUsageOptions) ToOrders() string {
+	return "stopped ASC, id ASC"
+}
+
+// UsageGroupBy is the field to aggregate the usage of tasks by
+type UsageGroupBy string
+
+const (
+	UsageGroupByRepo  UsageGroupBy = "repo"
+	UsageGroupByOwner UsageGroupBy = "owner"
+	UsageGroupByUser  UsageGroupBy = "user" // the user who triggered the runs
+	UsageGroupByLabel UsageGroupBy = "label"
+)
+
+// column returns the column of the group, it returns an empty string if the group is unknown
+func (g UsageGroupBy) column() string {
+	switch g {
+	case UsageGroupByRepo:
+		return "repo_id"
+	case UsageGroupByOwner:
+		return "owner_id"
+	case UsageGroupByUser:
+		return "trigger_user_id"
+	case UsageGroupByLabel:
+		return "labels"
+	}
+	return ""
+}
+
+// IsValid returns whether the usage could be aggregated by the group
+func (g UsageGroupBy) IsValid() bool {
+	return g.column() != ""
+}
+
+// UsageSummary is the aggregated usage of a group of tasks
+type UsageSummary struct {
+	ID      int64  // the id of the repository, the owner or the user, it's 0 if the usage is grouped by labels
+	Labels  string // the labels if the usage is grouped by labels
+	Jobs    int64
+	Seconds int64
+	Minutes int64
+}
+
+// SumTaskUsage aggregates the usage of the tasks by the group, the summaries are in descending order of minutes
+func SumTaskUsage(ctx context.Context, opts FindTaskUsageOptions, groupBy UsageGroupBy) ([]*UsageSummary, error) {
+	column := groupBy.column()
+	if column == "" {
+		return nil, fmt.Errorf("unknown usage group %q", groupBy)
+	}
+
+	var rows []struct {
+		Key     string `xorm:"usage_key"`
+		Jobs    int64  `xorm:"usage_jobs"`
+		Seconds int64  `xorm:"usage_seconds"`
+		Minutes int64  `xorm:"usage_minutes"`
+	}
+	if err := db.GetEngine(ctx).Table("action_task_usage").
+		Select(column + " AS usage_key, COUNT(*) AS usage_jobs, SUM(seconds) AS usage_seconds, SUM(minutes) AS usage_minutes").
+		Where(opts.ToConds()).
+		GroupBy(column).
+		OrderBy("usage_minutes DESC, usage_key ASC").
+		Find(&rows); err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*UsageSummary, 0, len(rows))
+	for _, row := range rows {
+		s := &UsageSummary{Jobs: row.Jobs, Seconds: row.Seconds, Minutes: row.Minutes}
+		if groupBy == UsageGroupByLabel {
+			s.Labels = row.Key
+		} else if _, err := fmt.Sscan(row.Key, &s.ID); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+// MonthStart returns the beginning of the month of the time, the monthly minutes quotas are reset at it
+func MonthStart(t time.Time) timeutil.TimeStamp {
+	return timeutil.TimeStamp(time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).Unix())
+}
+
+// GetMonthlyMinutes returns the billable minutes used by the owner in the current month
+func GetMonthlyMinutes(ctx context.Context, ownerID int64) (int64, error) {
+	return db.GetEngine(ctx).Where(FindTaskUsageOptions{OwnerID: ownerID, Since: MonthStart(time.Now())}.ToConds()).
+		SumInt(&ActionTaskUsage{}, "minutes")
+}
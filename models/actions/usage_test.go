@@ -0,0 +1,100 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskUsage(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	runner := insertRunner(t, &ActionRunner{UUID: "0c7b8f3e-2d4c-4b1e-8a8e-7f0a6d1c9e11", Name: "usage", OwnerID: 2})
+	job := insertWaitingJob(t, "build.yml")
+	task, ok, err := CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, job.ID, task.JobID)
+
+	// pretend the task has been running for 90 seconds
+	task.Started -= 90
+	require.NoError(t, UpdateTask(db.DefaultContext, task, "started"))
+	require.NoError(t, StopTask(db.DefaultContext, task.ID, StatusSuccess))
+	// stopping a task twice records the usage once
+	require.NoError(t, recordTaskUsage(db.DefaultContext, unittest.AssertExistsAndLoadBean(t, &ActionTask{ID: task.ID})))
+
+	usage := unittest.AssertExistsAndLoadBean(t, &ActionTaskUsage{TaskID: task.ID})
+	assert.EqualValues(t, 1, usage.RepoID)
+	assert.EqualValues(t, 2, usage.OwnerID)
+	assert.EqualValues(t, 2, usage.TriggerUserID)
+	assert.Equal(t, runner.ID, usage.RunnerID)
+	assert.Equal(t, "linux", usage.Labels)
+	assert.Equal(t, StatusSuccess, usage.Status)
+	assert.GreaterOrEqual(t, usage.Seconds, int64(90))
+	assert.EqualValues(t, 2, usage.Minutes)
+
+	summaries, err := SumTaskUsage(db.DefaultContext, FindTaskUsageOptions{OwnerID: 2}, UsageGroupByRepo)
+	require.NoError(t, err)
+	if assert.Len(t, summaries, 1) {
+		assert.EqualValues(t, 1, summaries[0].ID)
+		assert.EqualValues(t, 1, summaries[0].Jobs)
+		assert.EqualValues(t, 2, summaries[0].Minutes)
+	}
+	summaries, err = SumTaskUsage(db.DefaultContext, FindTaskUsageOptions{}, UsageGroupByLabel)
+	require.NoError(t, err)
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, "linux", summaries[0].Labels)
+	}
+	summaries, err = SumTaskUsage(db.DefaultContext, FindTaskUsageOptions{Before: usage.Stopped}, UsageGroupByOwner)
+	require.NoError(t, err)
+	assert.Empty(t, summaries)
+
+	minutes, err := GetMonthlyMinutes(db.DefaultContext, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, minutes)
+}
+
+func TestActionQuota(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	exceeded, err := IsMonthlyMinutesQuotaExceeded(db.DefaultContext, 2)
+	require.NoError(t, err)
+	assert.False(t, exceeded, "the usage is unlimited without a quota")
+
+	// the tasks of the other tests may be still running, the quota allows one more job
+	running, err := CountRunningTasks(db.DefaultContext, 2)
+	require.NoError(t, err)
+	require.NoError(t, SetQuota(db.DefaultContext, &ActionQuota{OwnerID: 2, MaxMonthlyMinutes: 10, MaxConcurrentJobs: running + 1}))
+	require.NoError(t, db.Insert(db.DefaultContext, &ActionTaskUsage{TaskID: 1000, RepoID: 1, OwnerID: 2, Stopped: timeutil.TimeStampNow(), Seconds: 600, Minutes: 10}))
+	exceeded, err = IsMonthlyMinutesQuotaExceeded(db.DefaultContext, 2)
+	require.NoError(t, err)
+	assert.True(t, exceeded)
+
+	// the second job is queued while the first one is running
+	runner := insertRunner(t, &ActionRunner{UUID: "6a1d2c9f-8e3b-4f7a-b5c4-3d2e1f0a9b22", Name: "quota", OwnerID: 2})
+	insertWaitingJob(t, "build.yml")
+	insertWaitingJob(t, "build.yml")
+	_, ok, err := CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// the limits are lifted after the quota is deleted
+	require.NoError(t, DeleteQuota(db.DefaultContext, 2))
+	_, ok, err = CreateTaskForRunner(db.DefaultContext, runner, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	exceeded, err = IsMonthlyMinutesQuotaExceeded(db.DefaultContext, 2)
+	require.NoError(t, err)
+	assert.False(t, exceeded)
+}
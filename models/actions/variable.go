@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
 
 	"xorm.io/builder"
 )
@@ -20,6 +21,7 @@ import (
 //  1. global variable, OwnerID is 0 and RepoID is 0
 //  2. org/user level variable, OwnerID is org/user ID and RepoID is 0
 //  3. repo level variable, OwnerID is 0 and RepoID is repo ID
+//  4. environment level variable, OwnerID is 0, RepoID is repo ID and EnvironmentID is the ID of an environment of the repo
 //
 // Please note that it's not acceptable to have both OwnerID and RepoID to be non-zero,
 // or it will be complicated to find variables belonging to a specific owner.
@@ -27,13 +29,14 @@ import (
 // but it's a repo level variable, not an org/user level variable.
 // To avoid this, make it clear with {OwnerID: 0, RepoID: 1} for repo level variables.
 type ActionVariable struct {
-	ID          int64              `xorm:"pk autoincr"`
-	OwnerID     int64              `xorm:"UNIQUE(owner_repo_name)"`
-	RepoID      int64              `xorm:"INDEX UNIQUE(owner_repo_name)"`
-	Name        string             `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
-	Data        string             `xorm:"LONGTEXT NOT NULL"`
-	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
-	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	ID            int64              `xorm:"pk autoincr"`
+	OwnerID       int64              `xorm:"UNIQUE(owner_repo_env_name)"`
+	RepoID        int64              `xorm:"INDEX UNIQUE(owner_repo_env_name)"`
+	EnvironmentID int64              `xorm:"UNIQUE(owner_repo_env_name) NOT NULL DEFAULT 0"`
+	Name          string             `xorm:"UNIQUE(owner_repo_env_name) NOT NULL"`
+	Data          string             `xorm:"LONGTEXT NOT NULL"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
 }
 
 func init() {
@@ -56,11 +59,26 @@ func InsertVariable(ctx context.Context, ownerID, repoID int64, name, data strin
 	return variable, db.Insert(ctx, variable)
 }
 
+// InsertEnvironmentVariable creates a new variable of an environment of the repository
+func InsertEnvironmentVariable(ctx context.Context, repoID, environmentID int64, name, data string) (*ActionVariable, error) {
+	if repoID == 0 || environmentID == 0 {
+		return nil, util.NewInvalidArgumentErrorf("repoID and environmentID cannot be zero for environment variables")
+	}
+	variable := &ActionVariable{
+		RepoID:        repoID,
+		EnvironmentID: environmentID,
+		Name:          strings.ToUpper(name),
+		Data:          data,
+	}
+	return variable, db.Insert(ctx, variable)
+}
+
 type FindVariablesOpts struct {
 	db.ListOptions
-	RepoID  int64
-	OwnerID int64 // it will be ignored if RepoID is set
-	Name    string
+	RepoID        int64
+	OwnerID       int64 // it will be ignored if RepoID is set
+	EnvironmentID int64 // the variables of the repo itself are found if it's 0
+	Name          string
 }
 
 func (opts FindVariablesOpts) ToConds() builder.Cond {
@@ -74,6 +92,7 @@ func (opts FindVariablesOpts) ToConds() builder.Cond {
 	} else {
 		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
 	}
+	cond = cond.And(builder.Eq{"environment_id": opts.EnvironmentID})
 
 	if opts.Name != "" {
 		cond = cond.And(builder.Eq{"name": strings.ToUpper(opts.Name)})
@@ -137,3 +156,32 @@ func GetVariablesOfRun(ctx context.Context, run *ActionRun) (map[string]string,
 
 	return variables, nil
 }
+
+// GetVariablesOfJob returns the variables of the run of the job,
+// and the variables of the environment which the job deploys to, they have the highest precedence.
+func GetVariablesOfJob(ctx context.Context, job *ActionRunJob) (map[string]string, error) {
+	if err := job.LoadRun(ctx); err != nil {
+		return nil, err
+	}
+	variables, err := GetVariablesOfRun(ctx, job.Run)
+	if err != nil {
+		return nil, err
+	}
+	if job.DeploymentID == 0 {
+		return variables, nil
+	}
+
+	deployment, err := GetDeploymentByID(ctx, job.DeploymentID)
+	if err != nil {
+		return nil, err
+	}
+	envVariables, err := db.Find[ActionVariable](ctx, FindVariablesOpts{RepoID: job.RepoID, EnvironmentID: deployment.EnvironmentID})
+	if err != nil {
+		log.Error("find variables of environment: %d, error: %v", deployment.EnvironmentID, err)
+		return nil, err
+	}
+	for _, v := range envVariables {
+		variables[v.Name] = v.Data
+	}
+	return variables, nil
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// CheckRunStatus represents the status of a check run
+type CheckRunStatus string
+
+const (
+	CheckRunStatusQueued     CheckRunStatus = "queued"
+	CheckRunStatusInProgress CheckRunStatus = "in_progress"
+	CheckRunStatusCompleted  CheckRunStatus = "completed"
+)
+
+// IsValid returns whether the status is a known one
+func (s CheckRunStatus) IsValid() bool {
+	switch s {
+	case CheckRunStatusQueued, CheckRunStatusInProgress, CheckRunStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// CheckRunConclusion represents the final result of a completed check run
+type CheckRunConclusion string
+
+const (
+	CheckRunConclusionSuccess        CheckRunConclusion = "success"
+	CheckRunConclusionFailure        CheckRunConclusion = "failure"
+	CheckRunConclusionNeutral        CheckRunConclusion = "neutral"
+	CheckRunConclusionCancelled      CheckRunConclusion = "cancelled"
+	CheckRunConclusionSkipped        CheckRunConclusion = "skipped"
+	CheckRunConclusionTimedOut       CheckRunConclusion = "timed_out"
+	CheckRunConclusionActionRequired CheckRunConclusion = "action_required"
+)
+
+// IsValid returns whether the conclusion is a known one
+func (c CheckRunConclusion) IsValid() bool {
+	switch c {
+	case CheckRunConclusionSuccess, CheckRunConclusionFailure, CheckRunConclusionNeutral, CheckRunConclusionCancelled,
+		CheckRunConclusionSkipped, CheckRunConclusionTimedOut, CheckRunConclusionActionRequired:
+		return true
+	}
+	return false
+}
+
+// CheckRunAnnotationLevel represents the level of an annotation
+type CheckRunAnnotationLevel string
+
+const (
+	CheckRunAnnotationLevelNotice  CheckRunAnnotationLevel = "notice"
+	CheckRunAnnotationLevelWarning CheckRunAnnotationLevel = "warning"
+	CheckRunAnnotationLevelFailure CheckRunAnnotationLevel = "failure"
+)
+
+// IsValid returns whether the level is a known one
+func (l CheckRunAnnotationLevel) IsValid() bool {
+	switch l {
+	case CheckRunAnnotationLevelNotice, CheckRunAnnotationLevelWarning, CheckRunAnnotationLevelFailure:
+		return true
+	}
+	return false
+}
+
+// SVGName returns the name of the icon of the level
+func (l CheckRunAnnotationLevel) SVGName() string {
+	switch l {
+	case CheckRunAnnotationLevelFailure:
+		return "octicon-x-circle"
+	case CheckRunAnnotationLevelWarning:
+		return "octicon-alert"
+	}
+	return "octicon-info"
+}
+
+// MaxAnnotationsPerCheckRun is the max number of annotations of a check run, the exceeded ones are dropped
+const MaxAnnotationsPerCheckRun = 1000
+
+// CheckRun is a check of a commit reported by a CI system or a linter,
+// unlike a commit status it has a markdown summary and annotations of the lines of the files.
+type CheckRun struct {
+	ID               int64              `xorm:"pk autoincr"`
+	RepoID           int64              `xorm:"INDEX(repo_sha) NOT NULL"`
+	HeadSHA          string             `xorm:"VARCHAR(64) INDEX(repo_sha) NOT NULL"`
+	Name             string             `xorm:"VARCHAR(255) NOT NULL"`
+	ExternalID       string             `xorm:"VARCHAR(255) INDEX"`
+	Status           CheckRunStatus     `xorm:"VARCHAR(20) NOT NULL"`
+	Conclusion       CheckRunConclusion `xorm:"VARCHAR(20)"`
+	DetailsURL       string             `xorm:"TEXT"`
+	Title            string             `xorm:"TEXT"`
+	Summary          string             `xorm:"LONGTEXT"`
+	Text             string             `xorm:"LONGTEXT"`
+	AnnotationsCount int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatorID        int64              `xorm:"NOT NULL DEFAULT 0"`
+	StartedUnix      timeutil.TimeStamp
+	CompletedUnix    timeutil.TimeStamp
+	CreatedUnix      timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix      timeutil.TimeStamp `xorm:"updated"`
+
+	Creator *user_model.User `xorm:"-"`
+}
+
+// CheckRunAnnotation is a finding of a check run on a range of lines of a file,
+// the lines are the lines of the file in the head commit of the check run.
+type CheckRunAnnotation struct {
+	ID          int64                   `xorm:"pk autoincr"`
+	CheckRunID  int64                   `xorm:"INDEX NOT NULL"`
+	RepoID      int64                   `xorm:"INDEX NOT NULL"`
+	Path        string                  `xorm:"TEXT NOT NULL"`
+	StartLine   int64                   `xorm:"NOT NULL DEFAULT 0"`
+	EndLine     int64                   `xorm:"NOT NULL DEFAULT 0"`
+	StartColumn int64                   `xorm:"NOT NULL DEFAULT 0"`
+	EndColumn   int64                   `xorm:"NOT NULL DEFAULT 0"`
+	Level       CheckRunAnnotationLevel `xorm:"VARCHAR(20) NOT NULL"`
+	Title       string                  `xorm:"TEXT"`
+	Message     string                  `xorm:"TEXT NOT NULL"`
+	RawDetails  string                  `xorm:"TEXT"`
+
+	CheckRun *CheckRun `xorm:"-"`
+}
+
+func init() {
+	db.RegisterModel(new(CheckRun))
+	db.RegisterModel(new(CheckRunAnnotation))
+}
+
+// LoadCreator loads the creator of the check run
+func (cr *CheckRun) LoadCreator(ctx context.Context) (err error) {
+	if cr.Creator != nil {
+		return nil
+	}
+	cr.Creator, err = user_model.GetPossibleUserByID(ctx, cr.CreatorID)
+	return err
+}
+
+// SetStatus sets the status and the conclusion of the check run and fills its timestamps,
+// the status is completed if a conclusion is given, and a completed check run must have a conclusion.
+func (cr *CheckRun) SetStatus(status CheckRunStatus, conclusion CheckRunConclusion) error {
+	if conclusion != "" {
+		if !conclusion.IsValid() {
+			return util.NewInvalidArgumentErrorf("invalid conclusion %q", conclusion)
+		}
+		if status == "" {
+			status = CheckRunStatusCompleted
+		}
+	}
+	if status == "" {
+		status = CheckRunStatusQueued
+	}
+	if !status.IsValid() {
+		return util.NewInvalidArgumentErrorf("invalid status %q", status)
+	}
+	if status == CheckRunStatusCompleted && conclusion == "" {
+		return util.NewInvalidArgumentErrorf("conclusion is required when the status is completed")
+	} else if status != CheckRunStatusCompleted && conclusion != "" {
+		return util.NewInvalidArgumentErrorf("conclusion can only be set when the status is completed")
+	}
+
+	cr.Status = status
+	cr.Conclusion = conclusion
+	if status != CheckRunStatusQueued && cr.StartedUnix.IsZero() {
+		cr.StartedUnix = timeutil.TimeStampNow()
+	}
+	if status == CheckRunStatusCompleted {
+		if cr.CompletedUnix.IsZero() {
+			cr.CompletedUnix = timeutil.TimeStampNow()
+		}
+	} else {
+		cr.CompletedUnix = 0
+	}
+	return nil
+}
+
+// Validate checks the annotation, the path and the message are required
+func (a *CheckRunAnnotation) Validate() error {
+	if a.Path == "" {
+		return util.NewInvalidArgumentErrorf("path of annotation is required")
+	}
+	if a.Message == "" {
+		return util.NewInvalidArgumentErrorf("message of annotation is required")
+	}
+	if !a.Level.IsValid() {
+		return util.NewInvalidArgumentErrorf("invalid annotation level %q", a.Level)
+	}
+	if a.StartLine < 1 || a.EndLine < a.StartLine {
+		return util.NewInvalidArgumentErrorf("invalid line range %d-%d of annotation", a.StartLine, a.EndLine)
+	}
+	return nil
+}
+
+// insertCheckRunAnnotations inserts the annotations of the check run, the ones exceeding MaxAnnotationsPerCheckRun are dropped
+func insertCheckRunAnnotations(ctx context.Context, cr *CheckRun, annotations []*CheckRunAnnotation) error {
+	if left := MaxAnnotationsPerCheckRun - cr.AnnotationsCount; int64(len(annotations)) > left {
+		annotations = annotations[:max(left, 0)]
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	for _, a := range annotations {
+		a.CheckRunID = cr.ID
+		a.RepoID = cr.RepoID
+		if a.EndLine < a.StartLine {
+			a.EndLine = a.StartLine
+		}
+	}
+	if err := db.Insert(ctx, annotations); err != nil {
+		return err
+	}
+	cr.AnnotationsCount += int64(len(annotations))
+	_, err := db.GetEngine(ctx).ID(cr.ID).Cols("annotations_count").NoAutoTime().Update(cr)
+	return err
+}
+
+// CreateCheckRun creates a check run with its annotations
+func CreateCheckRun(ctx context.Context, cr *CheckRun, annotations []*CheckRunAnnotation) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		cr.AnnotationsCount = 0
+		if err := db.Insert(ctx, cr); err != nil {
+			return err
+		}
+		return insertCheckRunAnnotations(ctx, cr, annotations)
+	})
+}
+
+// UpdateCheckRun updates the given columns of a check run and appends the annotations to it
+func UpdateCheckRun(ctx context.Context, cr *CheckRun, cols []string, annotations []*CheckRunAnnotation) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if len(cols) > 0 {
+			if _, err := db.GetEngine(ctx).ID(cr.ID).Cols(cols...).Update(cr); err != nil {
+				return err
+			}
+		}
+		return insertCheckRunAnnotations(ctx, cr, annotations)
+	})
+}
+
+// GetCheckRunByID returns the check run of the repository by id
+func GetCheckRunByID(ctx context.Context, repoID, id int64) (*CheckRun, error) {
+	var cr CheckRun
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": repoID, "id": id}).Get(&cr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("check run with id %d: %w", id, util.ErrNotExist)
+	}
+	return &cr, nil
+}
+
+// GetCheckRunByExternalID returns the latest check run of the repository with the external id
+func GetCheckRunByExternalID(ctx context.Context, repoID int64, externalID string) (*CheckRun, error) {
+	var cr CheckRun
+	has, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": repoID, "external_id": externalID}).Desc("id").Get(&cr)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("check run with external id %q: %w", externalID, util.ErrNotExist)
+	}
+	return &cr, nil
+}
+
+// FindCheckRunsOptions represents the options to find check runs
+type FindCheckRunsOptions struct {
+	db.ListOptions
+	RepoID  int64
+	HeadSHA string
+	Name    string
+	Status  CheckRunStatus
+}
+
+func (opts FindCheckRunsOptions) ToConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.HeadSHA != "" {
+		cond = cond.And(builder.Eq{"head_sha": opts.HeadSHA})
+	}
+	if opts.Name != "" {
+		cond = cond.And(builder.Eq{"name": opts.Name})
+	}
+	if opts.Status != "" {
+		cond = cond.And(builder.Eq{"status": opts.Status})
+	}
+	return cond
+}
+
+func (opts FindCheckRunsOptions) ToOrders() string {
+	return "id DESC"
+}
+
+// FindCheckRunAnnotationsOptions represents the options to find the annotations of check runs
+type FindCheckRunAnnotationsOptions struct {
+	db.ListOptions
+	CheckRunIDs []int64
+}
+
+func (opts FindCheckRunAnnotationsOptions) ToConds() builder.Cond {
+	return builder.In("check_run_id", opts.CheckRunIDs)
+}
+
+func (opts FindCheckRunAnnotationsOptions) ToOrders() string {
+	return "id ASC"
+}
+
+// GetLatestCheckRunAnnotations returns the annotations of the latest check runs of the commit,
+// only the latest check run is used if there are several check runs with the same name, like the re-run ones.
+func GetLatestCheckRunAnnotations(ctx context.Context, repoID int64, headSHA string) ([]*CheckRunAnnotation, error) {
+	checkRuns, err := db.Find[CheckRun](ctx, FindCheckRunsOptions{RepoID: repoID, HeadSHA: headSHA})
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int64]*CheckRun, len(checkRuns))
+	names := make(map[string]bool, len(checkRuns))
+	ids := make([]int64, 0, len(checkRuns))
+	for _, cr := range checkRuns {
+		// the check runs are in descending order of id
+		if names[cr.Name] {
+			continue
+		}
+		names[cr.Name] = true
+		if cr.AnnotationsCount > 0 {
+			latest[cr.ID] = cr
+			ids = append(ids, cr.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	annotations, err := db.Find[CheckRunAnnotation](ctx, FindCheckRunAnnotationsOptions{CheckRunIDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range annotations {
+		a.CheckRun = latest[a.CheckRunID]
+	}
+	return annotations, nil
+}
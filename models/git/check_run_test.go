@@ -0,0 +1,91 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package git_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	"code.gitea.io/gitea/models/unittest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRunSetStatus(t *testing.T) {
+	cr := &git_model.CheckRun{}
+	require.NoError(t, cr.SetStatus("", ""))
+	assert.Equal(t, git_model.CheckRunStatusQueued, cr.Status)
+	assert.True(t, cr.StartedUnix.IsZero())
+
+	require.NoError(t, cr.SetStatus(git_model.CheckRunStatusInProgress, ""))
+	assert.False(t, cr.StartedUnix.IsZero())
+	assert.True(t, cr.CompletedUnix.IsZero())
+
+	require.NoError(t, cr.SetStatus("", git_model.CheckRunConclusionFailure))
+	assert.Equal(t, git_model.CheckRunStatusCompleted, cr.Status)
+	assert.False(t, cr.CompletedUnix.IsZero())
+
+	assert.Error(t, cr.SetStatus(git_model.CheckRunStatusCompleted, ""))
+	assert.Error(t, cr.SetStatus(git_model.CheckRunStatusInProgress, git_model.CheckRunConclusionSuccess))
+	assert.Error(t, cr.SetStatus("unknown", ""))
+	assert.Error(t, cr.SetStatus("", "unknown"))
+}
+
+func TestCheckRunAnnotationValidate(t *testing.T) {
+	a := &git_model.CheckRunAnnotation{Path: "main.go", StartLine: 1, EndLine: 2, Level: git_model.CheckRunAnnotationLevelWarning, Message: "msg"}
+	assert.NoError(t, a.Validate())
+
+	for _, a := range []*git_model.CheckRunAnnotation{
+		{StartLine: 1, EndLine: 1, Level: git_model.CheckRunAnnotationLevelWarning, Message: "msg"},
+		{Path: "main.go", StartLine: 1, EndLine: 1, Level: git_model.CheckRunAnnotationLevelWarning},
+		{Path: "main.go", StartLine: 1, EndLine: 1, Level: "error", Message: "msg"},
+		{Path: "main.go", StartLine: 0, EndLine: 1, Level: git_model.CheckRunAnnotationLevelWarning, Message: "msg"},
+		{Path: "main.go", StartLine: 3, EndLine: 2, Level: git_model.CheckRunAnnotationLevelWarning, Message: "msg"},
+	} {
+		assert.Error(t, a.Validate())
+	}
+}
+
+func TestGetLatestCheckRunAnnotations(t *testing.T) {
+	require.NoError(t, unittest.PrepareTestDatabase())
+
+	sha := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	newCheckRun := func(name, message string) *git_model.CheckRun {
+		cr := &git_model.CheckRun{RepoID: 1, HeadSHA: sha, Name: name, CreatorID: 2}
+		require.NoError(t, cr.SetStatus(git_model.CheckRunStatusInProgress, ""))
+		require.NoError(t, git_model.CreateCheckRun(db.DefaultContext, cr, []*git_model.CheckRunAnnotation{
+			{Path: "README.md", StartLine: 1, Level: git_model.CheckRunAnnotationLevelFailure, Message: message},
+		}))
+		return cr
+	}
+
+	newCheckRun("lint", "old")
+	lint := newCheckRun("lint", "new")
+	test := newCheckRun("test", "test")
+	assert.EqualValues(t, 1, lint.AnnotationsCount)
+
+	annotations, err := git_model.GetLatestCheckRunAnnotations(db.DefaultContext, 1, sha)
+	require.NoError(t, err)
+	require.Len(t, annotations, 2)
+	messages := map[string]int64{}
+	for _, a := range annotations {
+		messages[a.Message] = a.CheckRun.ID
+		assert.EqualValues(t, 1, a.EndLine)
+	}
+	assert.Equal(t, map[string]int64{"new": lint.ID, "test": test.ID}, messages)
+
+	cr, err := git_model.GetCheckRunByID(db.DefaultContext, 1, lint.ID)
+	require.NoError(t, err)
+	require.NoError(t, git_model.UpdateCheckRun(db.DefaultContext, cr, nil, []*git_model.CheckRunAnnotation{
+		{Path: "README.md", StartLine: 2, Level: git_model.CheckRunAnnotationLevelNotice, Message: "more"},
+	}))
+	cr, err = git_model.GetCheckRunByID(db.DefaultContext, 1, lint.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, cr.AnnotationsCount)
+
+	_, err = git_model.GetCheckRunByID(db.DefaultContext, 2, lint.ID)
+	assert.Error(t, err)
+}
@@ -601,6 +601,42 @@ var migrations = []Migration{
 	NewMigration("Add metadata column for comment table", v1_23.AddCommentMetaDataColumn),
 	// v304 -> v305
 	NewMigration("Add index for release sha1", v1_23.AddIndexForReleaseSha1),
+	// v305 -> v306
+	NewMigration("Add concurrency columns to action_run and action_run_job tables", v1_23.AddActionsConcurrency),
+	// v306 -> v307
+	NewMigration("Add caller_id column to action_run_job table", v1_23.AddCallerIDToActionRunJob),
+	// v307 -> v308
+	NewMigration("Add done_notified column to action_run table", v1_23.AddDoneNotifiedToActionRun),
+	// v308 -> v309
+	NewMigration("Add action_cache table", v1_23.AddActionCacheTable),
+	// v309 -> v310
+	NewMigration("Add action environments and deployments", v1_23.AddActionEnvironments),
+	// v310 -> v311
+	NewMigration("Add raw_permissions column to action_run_job table", v1_23.AddRawPermissionsToActionRunJob),
+	// v311 -> v312
+	NewMigration("Add action_required_workflow table", v1_23.AddActionRequiredWorkflowTable),
+	// v312 -> v313
+	NewMigration("Add check_run and check_run_annotation tables", v1_23.AddCheckRunTables),
+	// v313 -> v314
+	NewMigration("Add action_test_report and action_test_case tables", v1_23.AddActionTestReportTables),
+	// v314 -> v315
+	NewMigration("Add action_runner_group table and ephemeral runners", v1_23.AddActionRunnerGroupAndEphemeralRunners),
+	// v315 -> v316
+	NewMigration("Add action_task_usage and action_quota tables", v1_23.AddActionTaskUsageAndQuotaTables),
+	// v316 -> v317
+	NewMigration("Add action_task_step_summary table", v1_23.AddActionTaskStepSummaryTable),
+	// v317 -> v318
+	NewMigration("Add action_attestation table", v1_23.AddActionAttestationTable),
+	// v318 -> v319
+	NewMigration("Add token_permissions column to action_task table", v1_23.AddTokenPermissionsToActionTask),
+	// v319 -> v320
+	NewMigration("Add quota tables", v1_23.AddQuotaTables),
+	// v320 -> v321
+	NewMigration("Add package vulnerability tables", v1_23.AddPackageVulnerabilityTables),
+	// v321 -> v322
+	NewMigration("Add package access and package repository tables", v1_23.AddPackageAccessAndRepositoryTables),
+	// v322 -> v323
+	NewMigration("Store the token permissions of the existing action tasks", v1_23.StoreTokenPermissionsOfActionTasks),
 }
 
 // GetCurrentDBVersion returns the current db version
@@ -0,0 +1,19 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import "xorm.io/xorm"
+
+func AddDoneNotifiedToActionRun(x *xorm.Engine) error {
+	type ActionRun struct {
+		DoneNotified bool
+	}
+	if err := x.Sync(new(ActionRun)); err != nil {
+		return err
+	}
+
+	// the runs which have been done are treated as notified, to avoid notifying them again when they are rechecked
+	_, err := x.Exec("UPDATE `action_run` SET done_notified = ? WHERE status IN (?, ?, ?, ?)", true, 1, 2, 3, 4)
+	return err
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionCacheTable(x *xorm.Engine) error {
+	type ActionCache struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RepoID      int64  `xorm:"index"`
+		Ref         string `xorm:"index"`
+		CacheKey    string `xorm:"VARCHAR(512)"`
+		Version     string
+		Size        int64
+		StoragePath string
+		RunID       int64
+		Complete    bool               `xorm:"index"`
+		CreatedUnix timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+		UsedUnix    timeutil.TimeStamp `xorm:"index"`
+	}
+	return x.Sync(new(ActionCache))
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionEnvironments(x *xorm.Engine) error {
+	type ActionEnvironment struct {
+		ID             int64  `xorm:"pk autoincr"`
+		RepoID         int64  `xorm:"UNIQUE(repo_name) NOT NULL"`
+		Name           string `xorm:"VARCHAR(255) NOT NULL"`
+		LowerName      string `xorm:"UNIQUE(repo_name) VARCHAR(255) NOT NULL"`
+		WaitTimer      int64
+		ReviewerIDs    []int64            `xorm:"JSON TEXT"`
+		BranchPatterns []string           `xorm:"JSON TEXT"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionDeployment struct {
+		ID            int64 `xorm:"pk autoincr"`
+		RepoID        int64 `xorm:"index"`
+		EnvironmentID int64 `xorm:"index"`
+		RunID         int64 `xorm:"index"`
+		JobID         int64
+		Ref           string `xorm:"VARCHAR(255)"`
+		CommitSHA     string `xorm:"VARCHAR(64)"`
+		CreatorID     int64
+		State         string `xorm:"VARCHAR(20) index"`
+		NeedApproval  bool
+		ReviewerID    int64
+		WaitUntil     timeutil.TimeStamp
+		CreatedUnix   timeutil.TimeStamp `xorm:"created"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionDeploymentStatus struct {
+		ID           int64  `xorm:"pk autoincr"`
+		RepoID       int64  `xorm:"index"`
+		DeploymentID int64  `xorm:"index"`
+		State        string `xorm:"VARCHAR(20)"`
+		Description  string `xorm:"TEXT"`
+		CreatorID    int64
+		CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type ActionRunJob struct {
+		RawEnvironment string `xorm:"TEXT"`
+		DeploymentID   int64
+	}
+
+	// the unique indexes of secrets and variables are replaced to include the environment
+	type Secret struct {
+		ID            int64
+		OwnerID       int64              `xorm:"INDEX UNIQUE(owner_repo_env_name) NOT NULL"`
+		RepoID        int64              `xorm:"INDEX UNIQUE(owner_repo_env_name) NOT NULL DEFAULT 0"`
+		EnvironmentID int64              `xorm:"UNIQUE(owner_repo_env_name) NOT NULL DEFAULT 0"`
+		Name          string             `xorm:"UNIQUE(owner_repo_env_name) NOT NULL"`
+		Data          string             `xorm:"LONGTEXT"`
+		CreatedUnix   timeutil.TimeStamp `xorm:"created NOT NULL"`
+	}
+
+	type ActionVariable struct {
+		ID            int64              `xorm:"pk autoincr"`
+		OwnerID       int64              `xorm:"UNIQUE(owner_repo_env_name)"`
+		RepoID        int64              `xorm:"INDEX UNIQUE(owner_repo_env_name)"`
+		EnvironmentID int64              `xorm:"UNIQUE(owner_repo_env_name) NOT NULL DEFAULT 0"`
+		Name          string             `xorm:"UNIQUE(owner_repo_env_name) NOT NULL"`
+		Data          string             `xorm:"LONGTEXT NOT NULL"`
+		CreatedUnix   timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync(new(ActionEnvironment), new(ActionDeployment), new(ActionDeploymentStatus), new(ActionRunJob), new(Secret), new(ActionVariable))
+}
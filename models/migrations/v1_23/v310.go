@@ -0,0 +1,13 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import "xorm.io/xorm"
+
+func AddRawPermissionsToActionRunJob(x *xorm.Engine) error {
+	type ActionRunJob struct {
+		RawPermissions string `xorm:"TEXT"`
+	}
+	return x.Sync(new(ActionRunJob))
+}
@@ -0,0 +1,27 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionRequiredWorkflowTable(x *xorm.Engine) error {
+	type ActionRequiredWorkflow struct {
+		ID                 int64  `xorm:"pk autoincr"`
+		OrgID              int64  `xorm:"UNIQUE(org_workflow) NOT NULL"`
+		RepoID             int64  `xorm:"UNIQUE(org_workflow) NOT NULL"`
+		WorkflowPath       string `xorm:"UNIQUE(org_workflow) VARCHAR(255) NOT NULL"`
+		Ref                string
+		RepoPatterns       []string `xorm:"JSON TEXT"`
+		WorkflowName       string
+		EnforceStatusCheck bool               `xorm:"NOT NULL DEFAULT false"`
+		CreatorID          int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix        timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix        timeutil.TimeStamp `xorm:"updated"`
+	}
+	return x.Sync(new(ActionRequiredWorkflow))
+}
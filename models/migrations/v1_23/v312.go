@@ -0,0 +1,49 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddCheckRunTables(x *xorm.Engine) error {
+	type CheckRun struct {
+		ID               int64  `xorm:"pk autoincr"`
+		RepoID           int64  `xorm:"INDEX(repo_sha) NOT NULL"`
+		HeadSHA          string `xorm:"VARCHAR(64) INDEX(repo_sha) NOT NULL"`
+		Name             string `xorm:"VARCHAR(255) NOT NULL"`
+		ExternalID       string `xorm:"VARCHAR(255) INDEX"`
+		Status           string `xorm:"VARCHAR(20) NOT NULL"`
+		Conclusion       string `xorm:"VARCHAR(20)"`
+		DetailsURL       string `xorm:"TEXT"`
+		Title            string `xorm:"TEXT"`
+		Summary          string `xorm:"LONGTEXT"`
+		Text             string `xorm:"LONGTEXT"`
+		AnnotationsCount int64  `xorm:"NOT NULL DEFAULT 0"`
+		CreatorID        int64  `xorm:"NOT NULL DEFAULT 0"`
+		StartedUnix      timeutil.TimeStamp
+		CompletedUnix    timeutil.TimeStamp
+		CreatedUnix      timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix      timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type CheckRunAnnotation struct {
+		ID          int64  `xorm:"pk autoincr"`
+		CheckRunID  int64  `xorm:"INDEX NOT NULL"`
+		RepoID      int64  `xorm:"INDEX NOT NULL"`
+		Path        string `xorm:"TEXT NOT NULL"`
+		StartLine   int64  `xorm:"NOT NULL DEFAULT 0"`
+		EndLine     int64  `xorm:"NOT NULL DEFAULT 0"`
+		StartColumn int64  `xorm:"NOT NULL DEFAULT 0"`
+		EndColumn   int64  `xorm:"NOT NULL DEFAULT 0"`
+		Level       string `xorm:"VARCHAR(20) NOT NULL"`
+		Title       string `xorm:"TEXT"`
+		Message     string `xorm:"TEXT NOT NULL"`
+		RawDetails  string `xorm:"TEXT"`
+	}
+
+	return x.Sync(new(CheckRun), new(CheckRunAnnotation))
+}
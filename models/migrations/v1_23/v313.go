@@ -0,0 +1,44 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionTestReportTables(x *xorm.Engine) error {
+	type ActionTestReport struct {
+		ID         int64  `xorm:"pk autoincr"`
+		RepoID     int64  `xorm:"index"`
+		RunID      int64  `xorm:"index"`
+		ArtifactID int64  `xorm:"index"`
+		Name       string `xorm:"TEXT"`
+		Total      int64
+		Passed     int64
+		Failed     int64
+		Skipped    int64
+		Duration   time.Duration
+		Created    timeutil.TimeStamp `xorm:"created"`
+	}
+
+	type ActionTestCase struct {
+		ID        int64  `xorm:"pk autoincr"`
+		RepoID    int64  `xorm:"index"`
+		RunID     int64  `xorm:"index"`
+		ReportID  int64  `xorm:"index"`
+		Suite     string `xorm:"VARCHAR(255)"`
+		ClassName string `xorm:"VARCHAR(255)"`
+		Name      string `xorm:"TEXT"`
+		Status    string `xorm:"VARCHAR(16) index"`
+		Duration  time.Duration
+		Message   string `xorm:"TEXT"`
+		Details   string `xorm:"LONGTEXT"`
+	}
+
+	return x.Sync(new(ActionTestReport), new(ActionTestCase))
+}
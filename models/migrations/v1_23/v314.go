@@ -0,0 +1,30 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionRunnerGroupAndEphemeralRunners(x *xorm.Engine) error {
+	type ActionRunner struct {
+		Ephemeral bool  `xorm:"NOT NULL DEFAULT false"`
+		JobID     int64 `xorm:"index NOT NULL DEFAULT 0"`
+		GroupID   int64 `xorm:"index NOT NULL DEFAULT 0"`
+	}
+
+	type ActionRunnerGroup struct {
+		ID               int64              `xorm:"pk autoincr"`
+		OwnerID          int64              `xorm:"UNIQUE(owner_name) NOT NULL"`
+		Name             string             `xorm:"UNIQUE(owner_name) VARCHAR(255) NOT NULL"`
+		RepoPatterns     []string           `xorm:"JSON TEXT"`
+		WorkflowPatterns []string           `xorm:"JSON TEXT"`
+		CreatedUnix      timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix      timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync(new(ActionRunner), new(ActionRunnerGroup))
+}
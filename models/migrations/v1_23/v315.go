@@ -0,0 +1,42 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionTaskUsageAndQuotaTables(x *xorm.Engine) error {
+	type ActionTaskUsage struct {
+		ID            int64              `xorm:"pk autoincr"`
+		TaskID        int64              `xorm:"UNIQUE NOT NULL"`
+		RepoID        int64              `xorm:"index NOT NULL"`
+		OwnerID       int64              `xorm:"index(owner_stopped) NOT NULL"`
+		TriggerUserID int64              `xorm:"index NOT NULL DEFAULT 0"`
+		RunID         int64              `xorm:"index NOT NULL"`
+		JobID         int64              `xorm:"NOT NULL"`
+		JobName       string             `xorm:"VARCHAR(255)"`
+		RunnerID      int64              `xorm:"NOT NULL DEFAULT 0"`
+		Labels        string             `xorm:"VARCHAR(255)"`
+		Status        int                `xorm:"NOT NULL"`
+		Started       timeutil.TimeStamp `xorm:"NOT NULL"`
+		Stopped       timeutil.TimeStamp `xorm:"index(owner_stopped) NOT NULL"`
+		Seconds       int64              `xorm:"NOT NULL"`
+		Minutes       int64              `xorm:"NOT NULL"`
+	}
+
+	type ActionQuota struct {
+		ID                int64              `xorm:"pk autoincr"`
+		OwnerID           int64              `xorm:"UNIQUE NOT NULL"`
+		MaxMonthlyMinutes int64              `xorm:"NOT NULL DEFAULT 0"`
+		MaxConcurrentJobs int64              `xorm:"NOT NULL DEFAULT 0"`
+		MaxArtifactBytes  int64              `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix       timeutil.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix       timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync(new(ActionTaskUsage), new(ActionQuota))
+}
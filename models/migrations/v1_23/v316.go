@@ -0,0 +1,23 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionTaskStepSummaryTable(x *xorm.Engine) error {
+	type ActionTaskStepSummary struct {
+		ID        int64
+		TaskID    int64              `xorm:"UNIQUE(task_id_step_index)"`
+		RepoID    int64              `xorm:"index"`
+		StepIndex int64              `xorm:"UNIQUE(task_id_step_index)"`
+		Content   string             `xorm:"LONGTEXT"`
+		Created   timeutil.TimeStamp `xorm:"created"`
+		Updated   timeutil.TimeStamp `xorm:"updated"`
+	}
+	return x.Sync(new(ActionTaskStepSummary))
+}
@@ -0,0 +1,26 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func AddActionAttestationTable(x *xorm.Engine) error {
+	type ActionAttestation struct {
+		ID            int64              `xorm:"pk autoincr"`
+		RepoID        int64              `xorm:"index"`
+		RunID         int64              `xorm:"index"`
+		TaskID        int64              `xorm:"index"`
+		SubjectName   string             `xorm:"VARCHAR(255)"`
+		SubjectDigest string             `xorm:"VARCHAR(255) index"`
+		PredicateType string             `xorm:"VARCHAR(255)"`
+		KeyID         string             `xorm:"VARCHAR(255)"`
+		Envelope      string             `xorm:"LONGTEXT"`
+		Created       timeutil.TimeStamp `xorm:"created"`
+	}
+	return x.Sync(new(ActionAttestation))
+}
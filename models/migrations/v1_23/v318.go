@@ -0,0 +1,13 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import "xorm.io/xorm"
+
+func AddTokenPermissionsToActionTask(x *xorm.Engine) error {
+	type ActionTask struct {
+		TokenPermissions map[int]int `xorm:"JSON TEXT"`
+	}
+	return x.Sync(new(ActionTask))
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type quotaGroup struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Name        string             `xorm:"UNIQUE NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+}
+
+func (quotaGroup) TableName() string {
+	return "quota_group"
+}
+
+type quotaGroupMember struct {
+	ID      int64 `xorm:"pk autoincr"`
+	GroupID int64 `xorm:"UNIQUE(s) NOT NULL"`
+	OwnerID int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+func (quotaGroupMember) TableName() string {
+	return "quota_group_member"
+}
+
+type quotaRule struct {
+	ID          int64              `xorm:"pk autoincr"`
+	GroupID     int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	OwnerID     int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	Subject     string             `xorm:"UNIQUE(s) VARCHAR(32) NOT NULL"`
+	MaxSize     int64              `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+func (quotaRule) TableName() string {
+	return "quota_rule"
+}
+
+func AddQuotaTables(x *xorm.Engine) error {
+	return x.Sync(new(quotaGroup), new(quotaGroupMember), new(quotaRule))
+}
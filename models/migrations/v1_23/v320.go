@@ -0,0 +1,55 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type packageVulnerabilityEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+type packageVulnerabilityRange struct {
+	Type   string                       `json:"type"`
+	Events []*packageVulnerabilityEvent `json:"events"`
+}
+
+type packageVulnerability struct {
+	ID            int64                        `xorm:"pk autoincr"`
+	VulnID        string                       `xorm:"UNIQUE(s) NOT NULL"`
+	Ecosystem     string                       `xorm:"UNIQUE(s) INDEX(p) NOT NULL"`
+	LowerName     string                       `xorm:"UNIQUE(s) INDEX(p) NOT NULL"`
+	Aliases       []string                     `xorm:"JSON TEXT"`
+	Summary       string                       `xorm:"TEXT"`
+	Severity      string                       `xorm:"VARCHAR(32)"`
+	Versions      []string                     `xorm:"JSON LONGTEXT"`
+	Ranges        []*packageVulnerabilityRange `xorm:"JSON TEXT"`
+	PublishedUnix timeutil.TimeStamp           `xorm:"NOT NULL DEFAULT 0"`
+	ModifiedUnix  timeutil.TimeStamp           `xorm:"NOT NULL DEFAULT 0"`
+}
+
+func (packageVulnerability) TableName() string {
+	return "package_vulnerability"
+}
+
+type packageVersionVulnerability struct {
+	ID              int64  `xorm:"pk autoincr"`
+	VersionID       int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	VulnerabilityID int64  `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Component       string `xorm:"UNIQUE(s) NOT NULL DEFAULT ''"`
+}
+
+func (packageVersionVulnerability) TableName() string {
+	return "package_version_vulnerability"
+}
+
+func AddPackageVulnerabilityTables(x *xorm.Engine) error {
+	return x.Sync(new(packageVulnerability), new(packageVersionVulnerability))
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type packageAccess struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Type        string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	TeamID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	Mode        int                `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL DEFAULT 0"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated NOT NULL DEFAULT 0"`
+}
+
+func (packageAccess) TableName() string {
+	return "package_access"
+}
+
+type packageRepository struct {
+	ID        int64 `xorm:"pk autoincr"`
+	PackageID int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID    int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+func (packageRepository) TableName() string {
+	return "package_repository"
+}
+
+func AddPackageAccessAndRepositoryTables(x *xorm.Engine) error {
+	return x.Sync(new(packageAccess), new(packageRepository))
+}
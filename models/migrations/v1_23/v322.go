@@ -0,0 +1,25 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package v1_23 //nolint
+
+import (
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// StoreTokenPermissionsOfActionTasks stores the permissions of the tokens of the tasks created before they were stored,
+// the tokens of the pull requests from forks could read the repository and the others could write it as before.
+func StoreTokenPermissionsOfActionTasks(x *xorm.Engine) error {
+	const (
+		readAll  = `{"1":1,"2":1,"3":1,"4":1,"5":1,"8":1,"9":1,"10":1}`
+		writeAll = `{"1":2,"2":2,"3":2,"4":2,"5":2,"8":2,"9":2,"10":2}`
+	)
+	if _, err := x.Exec(builder.Update(builder.Eq{"token_permissions": readAll}).From("action_task").
+		Where(builder.IsNull{"token_permissions"}.And(builder.Eq{"is_fork_pull_request": true}))); err != nil {
+		return err
+	}
+	_, err := x.Exec(builder.Update(builder.Eq{"token_permissions": writeAll}).From("action_task").
+		Where(builder.IsNull{"token_permissions"}))
+	return err
+}
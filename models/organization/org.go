@@ -405,6 +405,8 @@ func DeleteOrganization(ctx context.Context, org *Organization) error {
 		&user_model.Blocking{BlockerID: org.ID},
 		&actions_model.ActionRunner{OwnerID: org.ID},
 		&actions_model.ActionRunnerToken{OwnerID: org.ID},
+		&actions_model.ActionRunnerGroup{OwnerID: org.ID},
+		&actions_model.ActionQuota{OwnerID: org.ID},
 	); err != nil {
 		return fmt.Errorf("DeleteBeans: %w", err)
 	}
@@ -116,6 +116,51 @@ func GetManifestVersions(ctx context.Context, opts *BlobSearchOptions) ([]*packa
 		Find(&pvs)
 }
 
+type ReferrerSearchOptions struct {
+	OwnerID      int64
+	Image        string
+	Subject      string
+	ArtifactType string
+}
+
+func (opts *ReferrerSearchOptions) toConds() builder.Cond {
+	var cond builder.Cond = builder.Eq{
+		"package.type":                packages.TypeContainer,
+		"package.owner_id":            opts.OwnerID,
+		"package.lower_name":          strings.ToLower(opts.Image),
+		"package_version.is_internal": false,
+	}
+
+	props := map[string]string{
+		container_module.PropertyManifestSubject: opts.Subject,
+	}
+	if opts.ArtifactType != "" {
+		props[container_module.PropertyArtifactType] = opts.ArtifactType
+	}
+	for name, value := range props {
+		var propsCond builder.Cond = builder.Eq{
+			"package_property.ref_type": packages.PropertyTypeVersion,
+			"package_property.name":     name,
+			"package_property.value":    value,
+		}
+
+		cond = cond.And(builder.In("package_version.id", builder.Select("package_property.ref_id").Where(propsCond).From("package_property")))
+	}
+
+	return cond
+}
+
+// GetReferrerVersions gets all package versions representing a manifest which references the subject
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers
+func GetReferrerVersions(ctx context.Context, opts *ReferrerSearchOptions) ([]*packages.PackageVersion, error) {
+	pvs := make([]*packages.PackageVersion, 0, 10)
+	return pvs, db.GetEngine(ctx).
+		Join("INNER", "package", "package.id = package_version.package_id").
+		Where(opts.toConds()).
+		Asc("package_version.created_unix", "package_version.id").
+		Find(&pvs)
+}
+
 // GetImageTags gets a sorted list of the tags of an image
 // The result is suitable for the api call.
 func GetImageTags(ctx context.Context, ownerID int64, image string, n int, last string) ([]string, error) {
@@ -0,0 +1,114 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrPackageAccessNotExist indicates a package access entry not exist error
+var ErrPackageAccessNotExist = util.NewNotExistErrorf("package access does not exist")
+
+func init() {
+	db.RegisterModel(new(PackageAccess))
+}
+
+// PackageAccess grants a user or a team access to a single package of the owner.
+// The package is referenced by its name so that access can be granted before the first version is published.
+type PackageAccess struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Type        Type               `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	UserID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	TeamID      int64              `xorm:"UNIQUE(s) INDEX NOT NULL DEFAULT 0"`
+	Mode        perm.AccessMode    `xorm:"NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL DEFAULT 0"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated NOT NULL DEFAULT 0"`
+}
+
+// SetAccess inserts the access entry or updates the mode of an existing entry of the same user or team
+func SetAccess(ctx context.Context, pa *PackageAccess) error {
+	pa.LowerName = strings.ToLower(pa.LowerName)
+
+	e := db.GetEngine(ctx)
+
+	existing := &PackageAccess{}
+	has, err := e.Where(builder.Eq{
+		"owner_id":   pa.OwnerID,
+		"type":       pa.Type,
+		"lower_name": pa.LowerName,
+		"user_id":    pa.UserID,
+		"team_id":    pa.TeamID,
+	}).Get(existing)
+	if err != nil {
+		return err
+	}
+	if has {
+		pa.ID = existing.ID
+		pa.CreatedUnix = existing.CreatedUnix
+		_, err = e.ID(pa.ID).Cols("mode").Update(pa)
+		return err
+	}
+	_, err = e.Insert(pa)
+	return err
+}
+
+// DeleteAccess deletes the access entry of the user or team. If no entry exists, ErrPackageAccessNotExist is returned
+func DeleteAccess(ctx context.Context, ownerID int64, packageType Type, name string, userID, teamID int64) error {
+	n, err := db.GetEngine(ctx).Where(builder.Eq{
+		"owner_id":   ownerID,
+		"type":       packageType,
+		"lower_name": strings.ToLower(name),
+		"user_id":    userID,
+		"team_id":    teamID,
+	}).Delete(&PackageAccess{})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPackageAccessNotExist
+	}
+	return nil
+}
+
+// GetAccessList gets the access entries of the package ordered by users before teams
+func GetAccessList(ctx context.Context, ownerID int64, packageType Type, name string) ([]*PackageAccess, error) {
+	pas := make([]*PackageAccess, 0, 10)
+	return pas, db.GetEngine(ctx).
+		Where(builder.Eq{
+			"owner_id":   ownerID,
+			"type":       packageType,
+			"lower_name": strings.ToLower(name),
+		}).
+		OrderBy("team_id ASC, user_id ASC").
+		Find(&pas)
+}
+
+// GetAccessEntriesForUser gets the access entries of the packages of the owner which grant access to the user or one of the teams
+func GetAccessEntriesForUser(ctx context.Context, ownerID, userID int64, teamIDs []int64) ([]*PackageAccess, error) {
+	cond := builder.Eq{"user_id": userID}.Or(builder.In("team_id", teamIDs))
+	if len(teamIDs) == 0 {
+		cond = builder.Eq{"user_id": userID}
+	}
+
+	pas := make([]*PackageAccess, 0, 10)
+	return pas, db.GetEngine(ctx).
+		Where(builder.Eq{"owner_id": ownerID}.And(cond)).
+		Find(&pas)
+}
+
+// DeleteAccessByOwnerID deletes all access entries of the packages of the owner
+func DeleteAccessByOwnerID(ctx context.Context, ownerID int64) error {
+	_, err := db.GetEngine(ctx).Where("owner_id = ?", ownerID).Delete(&PackageAccess{})
+	return err
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageAccess(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.NoError(t, packages_model.SetAccess(db.DefaultContext, &packages_model.PackageAccess{
+		OwnerID:   3,
+		Type:      packages_model.TypeGeneric,
+		LowerName: "Test-Package",
+		UserID:    4,
+		Mode:      perm.AccessModeRead,
+	}))
+	assert.NoError(t, packages_model.SetAccess(db.DefaultContext, &packages_model.PackageAccess{
+		OwnerID:   3,
+		Type:      packages_model.TypeGeneric,
+		LowerName: "test-package",
+		TeamID:    2,
+		Mode:      perm.AccessModeAdmin,
+	}))
+	// updates the existing entry of the user
+	assert.NoError(t, packages_model.SetAccess(db.DefaultContext, &packages_model.PackageAccess{
+		OwnerID:   3,
+		Type:      packages_model.TypeGeneric,
+		LowerName: "test-package",
+		UserID:    4,
+		Mode:      perm.AccessModeWrite,
+	}))
+
+	pas, err := packages_model.GetAccessList(db.DefaultContext, 3, packages_model.TypeGeneric, "TEST-PACKAGE")
+	assert.NoError(t, err)
+	assert.Len(t, pas, 2)
+	assert.EqualValues(t, 4, pas[0].UserID)
+	assert.Equal(t, perm.AccessModeWrite, pas[0].Mode)
+	assert.EqualValues(t, 2, pas[1].TeamID)
+	assert.Equal(t, perm.AccessModeAdmin, pas[1].Mode)
+
+	pas, err = packages_model.GetAccessEntriesForUser(db.DefaultContext, 3, 4, nil)
+	assert.NoError(t, err)
+	assert.Len(t, pas, 1)
+
+	pas, err = packages_model.GetAccessEntriesForUser(db.DefaultContext, 3, 5, []int64{2})
+	assert.NoError(t, err)
+	assert.Len(t, pas, 1)
+	assert.EqualValues(t, 2, pas[0].TeamID)
+
+	pas, err = packages_model.GetAccessEntriesForUser(db.DefaultContext, 2, 4, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, pas)
+
+	assert.NoError(t, packages_model.DeleteAccess(db.DefaultContext, 3, packages_model.TypeGeneric, "test-package", 4, 0))
+	err = packages_model.DeleteAccess(db.DefaultContext, 3, packages_model.TypeGeneric, "test-package", 4, 0)
+	assert.ErrorIs(t, err, util.ErrNotExist)
+
+	assert.NoError(t, packages_model.DeleteAccessByOwnerID(db.DefaultContext, 3))
+	pas, err = packages_model.GetAccessList(db.DefaultContext, 3, packages_model.TypeGeneric, "test-package")
+	assert.NoError(t, err)
+	assert.Empty(t, pas)
+}
+
+func TestPackageRepositoryLinks(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	p, err := packages_model.TryInsertPackage(db.DefaultContext, &packages_model.Package{
+		OwnerID:   2,
+		Type:      packages_model.TypeGeneric,
+		LowerName: "linked",
+		RepoID:    1,
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, packages_model.AddRepositoryLink(db.DefaultContext, p.ID, 2))
+	assert.NoError(t, packages_model.AddRepositoryLink(db.DefaultContext, p.ID, 2))
+	assert.NoError(t, packages_model.AddRepositoryLink(db.DefaultContext, p.ID, 3))
+
+	repoIDs, err := packages_model.GetLinkedRepositoryIDs(db.DefaultContext, p.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, repoIDs)
+
+	for _, repoID := range []int64{1, 2, 3} {
+		ps, err := packages_model.GetPackagesLinkedToRepository(db.DefaultContext, 2, repoID)
+		assert.NoError(t, err)
+		assert.Len(t, ps, 1)
+		assert.Equal(t, p.ID, ps[0].ID)
+	}
+
+	ps, err := packages_model.GetPackagesLinkedToRepository(db.DefaultContext, 3, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, ps)
+
+	assert.NoError(t, packages_model.RemoveRepositoryLink(db.DefaultContext, p.ID, 2))
+	err = packages_model.RemoveRepositoryLink(db.DefaultContext, p.ID, 2)
+	assert.ErrorIs(t, err, util.ErrNotExist)
+
+	assert.NoError(t, packages_model.UnlinkRepositoryFromAllPackages(db.DefaultContext, 3))
+	repoIDs, err = packages_model.GetLinkedRepositoryIDs(db.DefaultContext, p.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, repoIDs)
+
+	assert.NoError(t, packages_model.DeletePackageByID(db.DefaultContext, p.ID))
+}
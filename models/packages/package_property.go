@@ -60,6 +60,16 @@ func GetPropertiesByName(ctx context.Context, refType PropertyType, refID int64,
 	return pps, db.GetEngine(ctx).Where("ref_type = ? AND ref_id = ? AND name = ?", refType, refID, name).Find(&pps)
 }
 
+// GetPropertyValuesByName gets the distinct values of all properties of the reference type with a specific name
+func GetPropertyValuesByName(ctx context.Context, refType PropertyType, name string) ([]string, error) {
+	values := make([]string, 0, 10)
+	return values, db.GetEngine(ctx).
+		Table("package_property").
+		Distinct("value").
+		Where("ref_type = ? AND name = ?", refType, name).
+		Find(&values)
+}
+
 // UpdateProperty updates a property
 func UpdateProperty(ctx context.Context, pp *PackageProperty) error {
 	_, err := db.GetEngine(ctx).ID(pp.ID).Update(pp)
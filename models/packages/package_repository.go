@@ -0,0 +1,83 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+// ErrPackageRepositoryNotExist indicates a package repository link not exist error
+var ErrPackageRepositoryNotExist = util.NewNotExistErrorf("package repository link does not exist")
+
+func init() {
+	db.RegisterModel(new(PackageRepository))
+}
+
+// PackageRepository links a package to a repository in addition to the repository stored in Package.RepoID
+type PackageRepository struct {
+	ID        int64 `xorm:"pk autoincr"`
+	PackageID int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	RepoID    int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+// AddRepositoryLink links the package to an additional repository
+func AddRepositoryLink(ctx context.Context, packageID, repoID int64) error {
+	e := db.GetEngine(ctx)
+
+	has, err := e.Where("package_id = ? AND repo_id = ?", packageID, repoID).Exist(&PackageRepository{})
+	if err != nil || has {
+		return err
+	}
+	_, err = e.Insert(&PackageRepository{PackageID: packageID, RepoID: repoID})
+	return err
+}
+
+// RemoveRepositoryLink removes the additional link between the package and the repository. If the link does not exist, ErrPackageRepositoryNotExist is returned
+func RemoveRepositoryLink(ctx context.Context, packageID, repoID int64) error {
+	n, err := db.GetEngine(ctx).Where("package_id = ? AND repo_id = ?", packageID, repoID).Delete(&PackageRepository{})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPackageRepositoryNotExist
+	}
+	return nil
+}
+
+// GetLinkedRepositoryIDs gets the ids of the repositories linked to the package. The repository stored in Package.RepoID is not included.
+func GetLinkedRepositoryIDs(ctx context.Context, packageID int64) ([]int64, error) {
+	repoIDs := make([]int64, 0, 5)
+	return repoIDs, db.GetEngine(ctx).
+		Table("package_repository").
+		Where("package_id = ?", packageID).
+		OrderBy("id ASC").
+		Cols("repo_id").
+		Find(&repoIDs)
+}
+
+// GetPackagesLinkedToRepository gets the packages of the owner which are linked to the repository by Package.RepoID or an additional link
+func GetPackagesLinkedToRepository(ctx context.Context, ownerID, repoID int64) ([]*Package, error) {
+	ps := make([]*Package, 0, 10)
+	return ps, db.GetEngine(ctx).
+		Where(builder.Eq{"package.owner_id": ownerID}.And(repositoryCond(repoID))).
+		Find(&ps)
+}
+
+// DeleteRepositoryLinksByPackageID deletes the additional repository links of the package
+func DeleteRepositoryLinksByPackageID(ctx context.Context, packageID int64) error {
+	_, err := db.GetEngine(ctx).Where("package_id = ?", packageID).Delete(&PackageRepository{})
+	return err
+}
+
+// repositoryCond matches the packages linked to the repository
+func repositoryCond(repoID int64) builder.Cond {
+	return builder.Eq{"package.repo_id": repoID}.Or(
+		builder.In("package.id", builder.Select("package_id").From("package_repository").Where(builder.Eq{"repo_id": repoID})),
+	)
+}
@@ -202,7 +202,7 @@ func (opts *PackageSearchOptions) ToConds() builder.Cond {
 		cond = cond.And(builder.Eq{"package.owner_id": opts.OwnerID})
 	}
 	if opts.RepoID != 0 {
-		cond = cond.And(builder.Eq{"package.repo_id": opts.RepoID})
+		cond = cond.And(repositoryCond(opts.RepoID))
 	}
 	if opts.Type != "" && opts.Type != "all" {
 		cond = cond.And(builder.Eq{"package.type": opts.Type})
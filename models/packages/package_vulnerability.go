@@ -0,0 +1,145 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+	"net/url"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/packages/osv"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(PackageVulnerability))
+	db.RegisterModel(new(PackageVersionVulnerability))
+}
+
+// PackageVulnerability is an imported vulnerability of a package in an ecosystem
+type PackageVulnerability struct {
+	ID            int64              `xorm:"pk autoincr"`
+	VulnID        string             `xorm:"UNIQUE(s) NOT NULL"`
+	Ecosystem     string             `xorm:"UNIQUE(s) INDEX(p) NOT NULL"`
+	LowerName     string             `xorm:"UNIQUE(s) INDEX(p) NOT NULL"`
+	Aliases       []string           `xorm:"JSON TEXT"`
+	Summary       string             `xorm:"TEXT"`
+	Severity      string             `xorm:"VARCHAR(32)"`
+	Versions      []string           `xorm:"JSON LONGTEXT"` // the explicitly listed affected versions
+	Ranges        []*osv.Range       `xorm:"JSON TEXT"`     // the ranges of affected versions
+	PublishedUnix timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	ModifiedUnix  timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+}
+
+// IsAffected checks if the version of the package is affected by the vulnerability
+func (v *PackageVulnerability) IsAffected(version string) bool {
+	return osv.IsAffected(v.Versions, v.Ranges, version)
+}
+
+// URL returns the link to the vulnerability in the OSV database
+func (v *PackageVulnerability) URL() string {
+	return "https://osv.dev/vulnerability/" + url.PathEscape(v.VulnID)
+}
+
+// PackageVersionVulnerability links a package version to a vulnerability of the package itself or of a component listed in its SBOMs
+type PackageVersionVulnerability struct {
+	ID              int64 `xorm:"pk autoincr"`
+	VersionID       int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	VulnerabilityID int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	// Component is the affected component of the SBOM, it's empty if the package version is affected itself
+	Component string `xorm:"UNIQUE(s) NOT NULL DEFAULT ''"`
+}
+
+// DeleteAllVulnerabilities deletes all imported vulnerabilities and their matches
+func DeleteAllVulnerabilities(ctx context.Context) error {
+	if _, err := db.GetEngine(ctx).Where("1=1").Delete(&PackageVersionVulnerability{}); err != nil {
+		return err
+	}
+	_, err := db.GetEngine(ctx).Where("1=1").Delete(&PackageVulnerability{})
+	return err
+}
+
+// InsertVulnerabilities inserts imported vulnerabilities
+func InsertVulnerabilities(ctx context.Context, vulnerabilities []*PackageVulnerability) error {
+	if len(vulnerabilities) == 0 {
+		return nil
+	}
+	_, err := db.GetEngine(ctx).Insert(vulnerabilities)
+	return err
+}
+
+// GetVulnerabilitiesByPackage gets the vulnerabilities of a package of an ecosystem
+func GetVulnerabilitiesByPackage(ctx context.Context, ecosystem, lowerName string) ([]*PackageVulnerability, error) {
+	vulnerabilities := make([]*PackageVulnerability, 0, 5)
+	return vulnerabilities, db.GetEngine(ctx).Where(builder.Eq{"ecosystem": ecosystem, "lower_name": lowerName}).Find(&vulnerabilities)
+}
+
+// SetVersionVulnerabilities replaces the vulnerabilities matched to the package version
+func SetVersionVulnerabilities(ctx context.Context, versionID int64, matches []*PackageVersionVulnerability) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if err := DeleteVersionVulnerabilities(ctx, versionID); err != nil {
+			return err
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		for _, m := range matches {
+			m.ID = 0
+			m.VersionID = versionID
+		}
+		_, err := db.GetEngine(ctx).Insert(matches)
+		return err
+	})
+}
+
+// DeleteVersionVulnerabilities deletes the vulnerabilities matched to the package version
+func DeleteVersionVulnerabilities(ctx context.Context, versionID int64) error {
+	_, err := db.GetEngine(ctx).Where("version_id = ?", versionID).Delete(&PackageVersionVulnerability{})
+	return err
+}
+
+// VersionVulnerability is a vulnerability matched to a package version
+type VersionVulnerability struct {
+	PackageVulnerability        `xorm:"extends"`
+	PackageVersionVulnerability `xorm:"extends"`
+}
+
+// GetVersionVulnerabilities gets the vulnerabilities matched to the package version
+func GetVersionVulnerabilities(ctx context.Context, versionID int64) ([]*VersionVulnerability, error) {
+	vulnerabilities := make([]*VersionVulnerability, 0, 5)
+	return vulnerabilities, db.GetEngine(ctx).
+		Table("package_version_vulnerability").
+		Join("INNER", "package_vulnerability", "package_vulnerability.id = package_version_vulnerability.vulnerability_id").
+		Where("package_version_vulnerability.version_id = ?", versionID).
+		OrderBy("package_vulnerability.vuln_id, package_version_vulnerability.component").
+		Find(&vulnerabilities)
+}
+
+// CountVersionVulnerabilities counts the vulnerabilities matched to the package versions by version id
+func CountVersionVulnerabilities(ctx context.Context, versionIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(versionIDs))
+	if len(versionIDs) == 0 {
+		return counts, nil
+	}
+
+	type versionCount struct {
+		VersionID int64
+		Count     int64
+	}
+	results := make([]*versionCount, 0, len(versionIDs))
+	if err := db.GetEngine(ctx).
+		Table("package_version_vulnerability").
+		Select("version_id, COUNT(DISTINCT vulnerability_id) AS count").
+		In("version_id", versionIDs).
+		GroupBy("version_id").
+		Find(&results); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		counts[r.VersionID] = r.Count
+	}
+	return counts, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/packages/osv"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionVulnerabilities(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	v1 := &packages_model.PackageVulnerability{
+		VulnID:    "GHSA-1",
+		Ecosystem: osv.EcosystemNpm,
+		LowerName: "left-pad",
+		Aliases:   []string{"CVE-2024-1"},
+		Ranges: []*osv.Range{
+			{Type: osv.RangeTypeSemver, Events: []*osv.Event{{Introduced: "0"}, {Fixed: "1.2.0"}}},
+		},
+	}
+	v2 := &packages_model.PackageVulnerability{
+		VulnID:    "GHSA-2",
+		Ecosystem: osv.EcosystemNpm,
+		LowerName: "left-pad",
+		Versions:  []string{"1.0.0"},
+	}
+	assert.NoError(t, packages_model.InsertVulnerabilities(db.DefaultContext, []*packages_model.PackageVulnerability{v1, v2}))
+
+	vulnerabilities, err := packages_model.GetVulnerabilitiesByPackage(db.DefaultContext, osv.EcosystemNpm, "left-pad")
+	assert.NoError(t, err)
+	assert.Len(t, vulnerabilities, 2)
+	for _, v := range vulnerabilities {
+		assert.True(t, v.IsAffected("1.0.0"))
+		assert.False(t, v.IsAffected("1.2.0"))
+	}
+	if vulnerabilities[0].VulnID == "GHSA-1" {
+		v1, v2 = vulnerabilities[0], vulnerabilities[1]
+	} else {
+		v1, v2 = vulnerabilities[1], vulnerabilities[0]
+	}
+
+	assert.NoError(t, packages_model.SetVersionVulnerabilities(db.DefaultContext, 1, []*packages_model.PackageVersionVulnerability{
+		{VulnerabilityID: v1.ID},
+		{VulnerabilityID: v1.ID, Component: "left-pad@1.0.0"},
+		{VulnerabilityID: v2.ID},
+	}))
+	assert.NoError(t, packages_model.SetVersionVulnerabilities(db.DefaultContext, 2, []*packages_model.PackageVersionVulnerability{
+		{VulnerabilityID: v2.ID, Component: "left-pad@1.0.0"},
+	}))
+
+	matched, err := packages_model.GetVersionVulnerabilities(db.DefaultContext, 1)
+	assert.NoError(t, err)
+	assert.Len(t, matched, 3)
+	assert.Equal(t, "GHSA-1", matched[0].VulnID)
+	assert.Empty(t, matched[0].Component)
+	assert.Equal(t, []string{"CVE-2024-1"}, matched[0].Aliases)
+	assert.Equal(t, "GHSA-1", matched[1].VulnID)
+	assert.Equal(t, "left-pad@1.0.0", matched[1].Component)
+	assert.Equal(t, "GHSA-2", matched[2].VulnID)
+	assert.EqualValues(t, 1, matched[2].VersionID)
+	assert.Equal(t, "https://osv.dev/vulnerability/GHSA-2", matched[2].URL())
+
+	counts, err := packages_model.CountVersionVulnerabilities(db.DefaultContext, []int64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 2, 2: 1}, counts)
+
+	// replaces the previous matches
+	assert.NoError(t, packages_model.SetVersionVulnerabilities(db.DefaultContext, 1, nil))
+	matched, err = packages_model.GetVersionVulnerabilities(db.DefaultContext, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, matched)
+
+	assert.NoError(t, packages_model.DeleteAllVulnerabilities(db.DefaultContext))
+	counts, err = packages_model.CountVersionVulnerabilities(db.DefaultContext, []int64{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Empty(t, counts)
+	vulnerabilities, err = packages_model.GetVulnerabilitiesByPackage(db.DefaultContext, osv.EcosystemNpm, "left-pad")
+	assert.NoError(t, err)
+	assert.Empty(t, vulnerabilities)
+}
@@ -93,6 +93,15 @@ func (p *Permission) SetUnitsWithDefaultAccessMode(units []*repo_model.RepoUnit,
 	}
 }
 
+// SetUnitsWithAccessModes sets the units and the access mode of each unit returned by getMode
+func (p *Permission) SetUnitsWithAccessModes(units []*repo_model.RepoUnit, getMode func(unit.Type) perm_model.AccessMode) {
+	p.units = units
+	p.unitsMode = make(map[unit.Type]perm_model.AccessMode)
+	for _, u := range p.units {
+		p.unitsMode[u.Type] = getMode(u.Type)
+	}
+}
+
 // CanAccess returns true if user has mode access to the unit of the repository
 func (p *Permission) CanAccess(mode perm_model.AccessMode, unitType unit.Type) bool {
 	return p.UnitAccessMode(unitType) >= mode
@@ -0,0 +1,293 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"xorm.io/builder"
+)
+
+func init() {
+	db.RegisterModel(new(Group))
+	db.RegisterModel(new(GroupMember))
+	db.RegisterModel(new(Rule))
+}
+
+// ErrQuotaExceeded indicates that storing more data would exceed the quota of the owner
+var ErrQuotaExceeded = util.NewPermissionDeniedErrorf("storage quota exceeded")
+
+// Subject is the kind of storage a quota rule limits
+type Subject string
+
+const (
+	SubjectTotal       Subject = "total" // the sum of all the other subjects
+	SubjectGit         Subject = "git"
+	SubjectLFS         Subject = "lfs"
+	SubjectPackages    Subject = "packages"
+	SubjectAttachments Subject = "attachments"
+	SubjectArtifacts   Subject = "artifacts"
+)
+
+// Subjects are all the subjects which can be limited
+var Subjects = []Subject{SubjectTotal, SubjectGit, SubjectLFS, SubjectPackages, SubjectAttachments, SubjectArtifacts}
+
+// IsValid returns whether the subject is known
+func (s Subject) IsValid() bool {
+	for _, subject := range Subjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// Group is a named set of quota rules shared by its member users and organizations
+type Group struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Name        string             `xorm:"UNIQUE NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+}
+
+// TableName sets the table name of the quota group
+func (Group) TableName() string {
+	return "quota_group"
+}
+
+// GroupMember maps a user or an organization to a quota group
+type GroupMember struct {
+	ID      int64 `xorm:"pk autoincr"`
+	GroupID int64 `xorm:"UNIQUE(s) NOT NULL"`
+	OwnerID int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+}
+
+// TableName sets the table name of the quota group member
+func (GroupMember) TableName() string {
+	return "quota_group_member"
+}
+
+// Rule limits the storage of a subject, it belongs either to a group or to a single owner
+type Rule struct {
+	ID          int64              `xorm:"pk autoincr"`
+	GroupID     int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	OwnerID     int64              `xorm:"UNIQUE(s) NOT NULL DEFAULT 0"`
+	Subject     Subject            `xorm:"UNIQUE(s) VARCHAR(32) NOT NULL"`
+	MaxSize     int64              `xorm:"NOT NULL"` // in bytes, a negative size means unlimited
+	CreatedUnix timeutil.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name of the quota rule
+func (Rule) TableName() string {
+	return "quota_rule"
+}
+
+// CreateGroup creates a quota group with the unique name
+func CreateGroup(ctx context.Context, name string) (*Group, error) {
+	if _, err := GetGroupByName(ctx, name); err == nil {
+		return nil, fmt.Errorf("quota group %q: %w", name, util.ErrAlreadyExist)
+	} else if !errors.Is(err, util.ErrNotExist) {
+		return nil, err
+	}
+	g := &Group{Name: name}
+	return g, db.Insert(ctx, g)
+}
+
+// GetGroupByName returns the quota group with the name
+func GetGroupByName(ctx context.Context, name string) (*Group, error) {
+	var g Group
+	has, err := db.GetEngine(ctx).Where("name=?", name).Get(&g)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, fmt.Errorf("quota group %q: %w", name, util.ErrNotExist)
+	}
+	return &g, nil
+}
+
+// GetAllGroups returns all the quota groups ordered by name
+func GetAllGroups(ctx context.Context) ([]*Group, error) {
+	groups := make([]*Group, 0, 10)
+	return groups, db.GetEngine(ctx).OrderBy("name").Find(&groups)
+}
+
+// DeleteGroup deletes the quota group with its rules and memberships
+func DeleteGroup(ctx context.Context, groupID int64) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.GetEngine(ctx).Where("group_id=?", groupID).Delete(&GroupMember{}); err != nil {
+			return err
+		}
+		if _, err := db.GetEngine(ctx).Where("group_id=?", groupID).Delete(&Rule{}); err != nil {
+			return err
+		}
+		_, err := db.GetEngine(ctx).ID(groupID).Delete(&Group{})
+		return err
+	})
+}
+
+// AddGroupMember adds the user or the organization to the quota group
+func AddGroupMember(ctx context.Context, groupID, ownerID int64) error {
+	has, err := db.GetEngine(ctx).Where("group_id=? AND owner_id=?", groupID, ownerID).Exist(&GroupMember{})
+	if err != nil || has {
+		return err
+	}
+	return db.Insert(ctx, &GroupMember{GroupID: groupID, OwnerID: ownerID})
+}
+
+// RemoveGroupMember removes the user or the organization from the quota group
+func RemoveGroupMember(ctx context.Context, groupID, ownerID int64) error {
+	n, err := db.GetEngine(ctx).Where("group_id=? AND owner_id=?", groupID, ownerID).Delete(&GroupMember{})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("member %d of quota group %d: %w", ownerID, groupID, util.ErrNotExist)
+	}
+	return nil
+}
+
+// GetGroupMemberIDs returns the ids of the users and organizations of the quota group
+func GetGroupMemberIDs(ctx context.Context, groupID int64) ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, db.GetEngine(ctx).Table("quota_group_member").Where("group_id=?", groupID).OrderBy("owner_id").Cols("owner_id").Find(&ids)
+}
+
+// GetOwnerGroups returns the quota groups which apply to the owner,
+// these are the default groups if the owner isn't member of any group.
+func GetOwnerGroups(ctx context.Context, ownerID int64) ([]*Group, error) {
+	groups := make([]*Group, 0, 5)
+	if err := db.GetEngine(ctx).
+		Join("INNER", "quota_group_member", "quota_group_member.group_id = quota_group.id").
+		Where("quota_group_member.owner_id=?", ownerID).
+		OrderBy("quota_group.name").
+		Find(&groups); err != nil {
+		return nil, err
+	}
+	if len(groups) > 0 || len(setting.Quota.DefaultGroups) == 0 {
+		return groups, nil
+	}
+	return groups, db.GetEngine(ctx).In("name", setting.Quota.DefaultGroups).OrderBy("name").Find(&groups)
+}
+
+// SetRule creates or updates the rule of the subject of a group or an owner
+func SetRule(ctx context.Context, r *Rule) error {
+	if (r.GroupID == 0) == (r.OwnerID == 0) {
+		return util.NewInvalidArgumentErrorf("a quota rule must belong to either a group or an owner")
+	}
+	if !r.Subject.IsValid() {
+		return util.NewInvalidArgumentErrorf("unknown quota subject %q", r.Subject)
+	}
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		var existing Rule
+		has, err := db.GetEngine(ctx).Where(builder.Eq{"group_id": r.GroupID, "owner_id": r.OwnerID, "subject": r.Subject}).Get(&existing)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return db.Insert(ctx, r)
+		}
+		r.ID = existing.ID
+		r.CreatedUnix = existing.CreatedUnix
+		_, err = db.GetEngine(ctx).ID(r.ID).Cols("max_size").Update(r)
+		return err
+	})
+}
+
+// DeleteRule deletes the rule of the subject of a group or an owner
+func DeleteRule(ctx context.Context, groupID, ownerID int64, subject Subject) error {
+	n, err := db.GetEngine(ctx).Where(builder.Eq{"group_id": groupID, "owner_id": ownerID, "subject": subject}).Delete(&Rule{})
+	if err != nil {
+		return err
+	} else if n == 0 {
+		return fmt.Errorf("quota rule %s: %w", subject, util.ErrNotExist)
+	}
+	return nil
+}
+
+// GetRules returns the rules of a group or an owner
+func GetRules(ctx context.Context, groupID, ownerID int64) ([]*Rule, error) {
+	rules := make([]*Rule, 0, len(Subjects))
+	return rules, db.GetEngine(ctx).Where(builder.Eq{"group_id": groupID, "owner_id": ownerID}).OrderBy("subject").Find(&rules)
+}
+
+// GetLimits returns the effective limits of the owner by subject, the subjects without limit are missing.
+// The rules of the owner override the rules of its groups, the most generous rule wins among the groups.
+func GetLimits(ctx context.Context, ownerID int64) (map[Subject]int64, error) {
+	groups, err := GetOwnerGroups(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make(map[Subject]int64)
+	if len(groups) > 0 {
+		groupIDs := make([]int64, 0, len(groups))
+		for _, g := range groups {
+			groupIDs = append(groupIDs, g.ID)
+		}
+		rules := make([]*Rule, 0, len(Subjects))
+		if err := db.GetEngine(ctx).In("group_id", groupIDs).Find(&rules); err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			if limit, ok := limits[r.Subject]; !ok || (limit >= 0 && (r.MaxSize < 0 || r.MaxSize > limit)) {
+				limits[r.Subject] = r.MaxSize
+			}
+		}
+	}
+
+	rules, err := GetRules(ctx, 0, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		limits[r.Subject] = r.MaxSize
+	}
+
+	for subject, limit := range limits {
+		if limit < 0 {
+			delete(limits, subject)
+		}
+	}
+	return limits, nil
+}
+
+// CheckQuota returns ErrQuotaExceeded if storing size more bytes of the subject would exceed a limit of the owner.
+// Unknown sizes are passed as 0, then it fails only if the usage is already over the limit.
+func CheckQuota(ctx context.Context, ownerID int64, subject Subject, size int64) error {
+	if !setting.Quota.Enabled {
+		return nil
+	}
+
+	limits, err := GetLimits(ctx, ownerID)
+	if err != nil || len(limits) == 0 {
+		return err
+	}
+	usage, err := GetUsage(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range []Subject{subject, SubjectTotal} {
+		if limit, ok := limits[s]; ok && usage.Size(s)+size > limit {
+			return fmt.Errorf("%w: the %s storage would use %s of %s", ErrQuotaExceeded, s, base.FileSize(usage.Size(s)+size), base.FileSize(limit))
+		}
+	}
+	return nil
+}
+
+// DeleteOwnerQuota deletes the rules and the group memberships of the owner
+func DeleteOwnerQuota(ctx context.Context, ownerID int64) error {
+	if _, err := db.GetEngine(ctx).Where("owner_id=?", ownerID).Delete(&GroupMember{}); err != nil {
+		return err
+	}
+	_, err := db.GetEngine(ctx).Where("owner_id=? AND group_id=0", ownerID).Delete(&Rule{})
+	return err
+}
@@ -0,0 +1,143 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package quota_test
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	quota_model "code.gitea.io/gitea/models/quota"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	g, err := quota_model.CreateGroup(db.DefaultContext, "small")
+	assert.NoError(t, err)
+
+	_, err = quota_model.CreateGroup(db.DefaultContext, "small")
+	assert.ErrorIs(t, err, util.ErrAlreadyExist)
+
+	assert.NoError(t, quota_model.AddGroupMember(db.DefaultContext, g.ID, 2))
+	assert.NoError(t, quota_model.AddGroupMember(db.DefaultContext, g.ID, 2))
+	assert.NoError(t, quota_model.AddGroupMember(db.DefaultContext, g.ID, 3))
+
+	ids, err := quota_model.GetGroupMemberIDs(db.DefaultContext, g.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, ids)
+
+	assert.NoError(t, quota_model.RemoveGroupMember(db.DefaultContext, g.ID, 3))
+	assert.ErrorIs(t, quota_model.RemoveGroupMember(db.DefaultContext, g.ID, 3), util.ErrNotExist)
+
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: g.ID, Subject: quota_model.SubjectLFS, MaxSize: 100}))
+
+	assert.NoError(t, quota_model.DeleteGroup(db.DefaultContext, g.ID))
+	_, err = quota_model.GetGroupByName(db.DefaultContext, "small")
+	assert.ErrorIs(t, err, util.ErrNotExist)
+	unittest.AssertNotExistsBean(t, &quota_model.GroupMember{GroupID: g.ID})
+	unittest.AssertNotExistsBean(t, &quota_model.Rule{GroupID: g.ID})
+}
+
+func TestSetRule(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	assert.ErrorIs(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{Subject: quota_model.SubjectGit}), util.ErrInvalidArgument)
+	assert.ErrorIs(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: 1, OwnerID: 2, Subject: quota_model.SubjectGit}), util.ErrInvalidArgument)
+	assert.ErrorIs(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: "unknown"}), util.ErrInvalidArgument)
+
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectGit, MaxSize: 10}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectGit, MaxSize: 20}))
+
+	rules, err := quota_model.GetRules(db.DefaultContext, 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.EqualValues(t, 20, rules[0].MaxSize)
+
+	assert.NoError(t, quota_model.DeleteRule(db.DefaultContext, 0, 2, quota_model.SubjectGit))
+	assert.ErrorIs(t, quota_model.DeleteRule(db.DefaultContext, 0, 2, quota_model.SubjectGit), util.ErrNotExist)
+}
+
+func TestGetLimits(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	small, err := quota_model.CreateGroup(db.DefaultContext, "small")
+	assert.NoError(t, err)
+	large, err := quota_model.CreateGroup(db.DefaultContext, "large")
+	assert.NoError(t, err)
+
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: small.ID, Subject: quota_model.SubjectTotal, MaxSize: 100}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: small.ID, Subject: quota_model.SubjectLFS, MaxSize: 10}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: small.ID, Subject: quota_model.SubjectGit, MaxSize: -1}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: large.ID, Subject: quota_model.SubjectTotal, MaxSize: 1000}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{GroupID: large.ID, Subject: quota_model.SubjectGit, MaxSize: 50}))
+
+	limits, err := quota_model.GetLimits(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, limits)
+
+	defer test.MockVariableValue(&setting.Quota.DefaultGroups, []string{"small"})()
+
+	limits, err = quota_model.GetLimits(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[quota_model.Subject]int64{quota_model.SubjectTotal: 100, quota_model.SubjectLFS: 10}, limits)
+
+	// the default groups don't apply to members of other groups
+	assert.NoError(t, quota_model.AddGroupMember(db.DefaultContext, large.ID, 2))
+
+	limits, err = quota_model.GetLimits(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[quota_model.Subject]int64{quota_model.SubjectTotal: 1000, quota_model.SubjectGit: 50}, limits)
+
+	// the most generous rule wins among the groups
+	assert.NoError(t, quota_model.AddGroupMember(db.DefaultContext, small.ID, 2))
+
+	limits, err = quota_model.GetLimits(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[quota_model.Subject]int64{quota_model.SubjectTotal: 1000, quota_model.SubjectLFS: 10}, limits)
+
+	// the rules of the owner override the group rules
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectTotal, MaxSize: -1}))
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectLFS, MaxSize: 500}))
+
+	limits, err = quota_model.GetLimits(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, map[quota_model.Subject]int64{quota_model.SubjectLFS: 500}, limits)
+
+	assert.NoError(t, quota_model.DeleteOwnerQuota(db.DefaultContext, 2))
+	unittest.AssertNotExistsBean(t, &quota_model.GroupMember{OwnerID: 2})
+	unittest.AssertNotExistsBean(t, &quota_model.Rule{OwnerID: 2})
+	unittest.AssertExistsAndLoadBean(t, &quota_model.Rule{GroupID: small.ID, Subject: quota_model.SubjectLFS})
+}
+
+func TestCheckQuota(t *testing.T) {
+	assert.NoError(t, unittest.PrepareTestDatabase())
+
+	usage, err := quota_model.GetUsage(db.DefaultContext, 2)
+	assert.NoError(t, err)
+	assert.Positive(t, usage.LFS)
+	assert.Equal(t, usage.Git+usage.LFS+usage.Packages+usage.Attachments+usage.Artifacts, usage.Size(quota_model.SubjectTotal))
+
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectLFS, MaxSize: usage.LFS + 10}))
+
+	// the quota is only enforced if enabled
+	assert.NoError(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectLFS, 100))
+
+	defer test.MockVariableValue(&setting.Quota.Enabled, true)()
+
+	assert.NoError(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectLFS, 10))
+	assert.ErrorIs(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectLFS, 11), quota_model.ErrQuotaExceeded)
+	assert.NoError(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectPackages, 11))
+	assert.NoError(t, quota_model.CheckQuota(db.DefaultContext, 3, quota_model.SubjectLFS, 11))
+
+	assert.NoError(t, quota_model.SetRule(db.DefaultContext, &quota_model.Rule{OwnerID: 2, Subject: quota_model.SubjectTotal, MaxSize: usage.Size(quota_model.SubjectTotal)}))
+
+	assert.NoError(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectPackages, 0))
+	assert.ErrorIs(t, quota_model.CheckQuota(db.DefaultContext, 2, quota_model.SubjectPackages, 1), quota_model.ErrQuotaExceeded)
+}
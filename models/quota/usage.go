@@ -0,0 +1,72 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package quota
+
+import (
+	"context"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	packages_model "code.gitea.io/gitea/models/packages"
+	repo_model "code.gitea.io/gitea/models/repo"
+)
+
+// Usage is the storage used by the repositories, packages and actions of an owner in bytes
+type Usage struct {
+	Git         int64
+	LFS         int64
+	Packages    int64
+	Attachments int64
+	Artifacts   int64
+}
+
+// Size returns the storage used by the subject
+func (u *Usage) Size(subject Subject) int64 {
+	switch subject {
+	case SubjectGit:
+		return u.Git
+	case SubjectLFS:
+		return u.LFS
+	case SubjectPackages:
+		return u.Packages
+	case SubjectAttachments:
+		return u.Attachments
+	case SubjectArtifacts:
+		return u.Artifacts
+	case SubjectTotal:
+		return u.Git + u.LFS + u.Packages + u.Attachments + u.Artifacts
+	}
+	return 0
+}
+
+// GetUsage returns the storage used by the owner
+func GetUsage(ctx context.Context, ownerID int64) (*Usage, error) {
+	var u Usage
+	var err error
+
+	// the git size of the repositories is updated after every push
+	if u.Git, err = db.GetEngine(ctx).Where("owner_id=?", ownerID).SumInt(new(repo_model.Repository), "git_size"); err != nil {
+		return nil, err
+	}
+	if u.LFS, err = db.GetEngine(ctx).
+		Join("INNER", "repository", "repository.id = lfs_meta_object.repository_id").
+		Where("repository.owner_id=?", ownerID).
+		SumInt(new(git_model.LFSMetaObject), "lfs_meta_object.size"); err != nil {
+		return nil, err
+	}
+	if u.Packages, err = packages_model.CalculateFileSize(ctx, &packages_model.PackageFileSearchOptions{OwnerID: ownerID}); err != nil {
+		return nil, err
+	}
+	if u.Attachments, err = db.GetEngine(ctx).
+		Join("INNER", "repository", "repository.id = attachment.repo_id").
+		Where("repository.owner_id=?", ownerID).
+		SumInt(new(repo_model.Attachment), "attachment.size"); err != nil {
+		return nil, err
+	}
+	if u.Artifacts, err = actions_model.GetArtifactBytes(ctx, ownerID); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
@@ -170,6 +170,23 @@ func (cfg *PullRequestsConfig) GetDefaultMergeStyle() MergeStyle {
 
 type ActionsConfig struct {
 	DisabledWorkflows []string
+	// DefaultWorkflowPermissions is the access of the tokens of the jobs which don't declare `permissions`, it's empty to use the default of the owner
+	DefaultWorkflowPermissions WorkflowPermissions `json:",omitempty"`
+}
+
+// WorkflowPermissions is the default access of the tokens of the actions jobs to the repository
+type WorkflowPermissions string
+
+const (
+	// WorkflowPermissionsRead allows the tokens to read the code and the packages only
+	WorkflowPermissionsRead WorkflowPermissions = "read"
+	// WorkflowPermissionsWrite allows the tokens to write all the units
+	WorkflowPermissionsWrite WorkflowPermissions = "write"
+)
+
+// IsValid returns whether it's a known value
+func (p WorkflowPermissions) IsValid() bool {
+	return p == WorkflowPermissionsRead || p == WorkflowPermissionsWrite
 }
 
 func (cfg *ActionsConfig) EnableWorkflow(file string) {
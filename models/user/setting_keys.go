@@ -10,6 +10,8 @@ const (
 	SettingsKeyDiffWhitespaceBehavior = "diff.whitespace_behaviour"
 	// SettingsKeyShowOutdatedComments is the setting key wether or not to show outdated comments in PRs
 	SettingsKeyShowOutdatedComments = "comment_code.show_outdated"
+	// SettingsKeyActionsDefaultWorkflowPermissions is the setting key for the default access of the tokens of the actions jobs in the repositories of the owner
+	SettingsKeyActionsDefaultWorkflowPermissions = "actions.default_workflow_permissions"
 	// UserActivityPubPrivPem is user's private key
 	UserActivityPubPrivPem = "activitypub.priv_pem"
 	// UserActivityPubPubPem is user's public key
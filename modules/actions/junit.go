@@ -0,0 +1,112 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotJUnitReport is returned when a xml file is not a JUnit test report
+var ErrNotJUnitReport = errors.New("not a JUnit test report")
+
+// JUnitTestCase is a test case of a JUnit test report, the nested test suites are flattened
+type JUnitTestCase struct {
+	Suite     string
+	ClassName string
+	Name      string
+	Duration  time.Duration
+	Failed    bool
+	Skipped   bool
+	// Message and Details are the message and the content of the failure, the error or the skipped element
+	Message string
+	Details string
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string       `xml:"name,attr"`
+	ClassName string       `xml:"classname,attr"`
+	Time      string       `xml:"time,attr"`
+	Failure   *junitResult `xml:"failure"`
+	Error     *junitResult `xml:"error"`
+	Skipped   *junitResult `xml:"skipped"`
+}
+
+type junitTestSuite struct {
+	XMLName    xml.Name
+	Name       string            `xml:"name,attr"`
+	TestSuites []*junitTestSuite `xml:"testsuite"`
+	TestCases  []*junitTestCase  `xml:"testcase"`
+}
+
+// ParseJUnitReport parses a JUnit XML test report, the root element could be either <testsuites> or <testsuite>.
+// It returns ErrNotJUnitReport if the content is a xml file with other root elements.
+func ParseJUnitReport(r io.Reader) ([]*JUnitTestCase, error) {
+	var root junitTestSuite
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotJUnitReport, err)
+	}
+	if root.XMLName.Local != "testsuites" && root.XMLName.Local != "testsuite" {
+		return nil, ErrNotJUnitReport
+	}
+
+	var cases []*JUnitTestCase
+	var walk func(suite *junitTestSuite, suiteName string)
+	walk = func(suite *junitTestSuite, suiteName string) {
+		if suite.Name != "" {
+			suiteName = suite.Name
+		}
+		for _, c := range suite.TestCases {
+			tc := &JUnitTestCase{
+				Suite:     suiteName,
+				ClassName: c.ClassName,
+				Name:      c.Name,
+				Duration:  parseJUnitTime(c.Time),
+			}
+			result := c.Failure
+			if result == nil {
+				result = c.Error
+			}
+			if result != nil {
+				tc.Failed = true
+			} else if c.Skipped != nil {
+				tc.Skipped = true
+				result = c.Skipped
+			}
+			if result != nil {
+				tc.Message = result.Message
+				if tc.Message == "" {
+					tc.Message = result.Type
+				}
+				tc.Details = strings.TrimSpace(result.Text)
+			}
+			cases = append(cases, tc)
+		}
+		for _, s := range suite.TestSuites {
+			walk(s, suiteName)
+		}
+	}
+	walk(&root, "")
+	return cases, nil
+}
+
+// parseJUnitTime parses the time attribute in seconds, some tools write it with thousands separators like "1,234.5"
+func parseJUnitTime(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
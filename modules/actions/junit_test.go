@@ -0,0 +1,54 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJUnitReport(t *testing.T) {
+	cases, err := ParseJUnitReport(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="all" tests="4">
+  <testsuite name="pkg/a" tests="3" time="1.5">
+    <testcase classname="pkg/a" name="TestPass" time="0.5"/>
+    <testcase classname="pkg/a" name="TestFail" time="1,000.25">
+      <failure message="expected 1, got 2" type="assert">
+        a_test.go:10: expected 1, got 2
+      </failure>
+    </testcase>
+    <testcase classname="pkg/a" name="TestSkip">
+      <skipped message="not on windows"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="pkg/b">
+    <testsuite>
+      <testcase classname="pkg/b" name="TestError" time="abc">
+        <error type="panic">stack</error>
+      </testcase>
+    </testsuite>
+  </testsuite>
+</testsuites>`))
+	require.NoError(t, err)
+	assert.Equal(t, []*JUnitTestCase{
+		{Suite: "pkg/a", ClassName: "pkg/a", Name: "TestPass", Duration: 500 * time.Millisecond},
+		{Suite: "pkg/a", ClassName: "pkg/a", Name: "TestFail", Duration: 1000250 * time.Millisecond, Failed: true, Message: "expected 1, got 2", Details: "a_test.go:10: expected 1, got 2"},
+		{Suite: "pkg/a", ClassName: "pkg/a", Name: "TestSkip", Skipped: true, Message: "not on windows"},
+		{Suite: "pkg/b", ClassName: "pkg/b", Name: "TestError", Failed: true, Message: "panic", Details: "stack"},
+	}, cases)
+
+	cases, err = ParseJUnitReport(strings.NewReader(`<testsuite name="single"><testcase name="t1"/></testsuite>`))
+	require.NoError(t, err)
+	assert.Equal(t, []*JUnitTestCase{{Suite: "single", Name: "t1"}}, cases)
+
+	_, err = ParseJUnitReport(strings.NewReader(`<project><modelVersion>4.0.0</modelVersion></project>`))
+	assert.ErrorIs(t, err, ErrNotJUnitReport)
+
+	_, err = ParseJUnitReport(strings.NewReader(`not xml`))
+	assert.ErrorIs(t, err, ErrNotJUnitReport)
+}
@@ -24,6 +24,17 @@ type DetectedWorkflow struct {
 	EntryName    string
 	TriggerEvent *jobparser.Event
 	Content      []byte
+
+	// RequiredWorkflowID is the id of the required workflow of the organization if the workflow is not from the repository
+	RequiredWorkflowID int64
+}
+
+// RequiredWorkflow is a workflow required by the organization to run on the repository,
+// its content is read from a central repository, but its events are matched against the repository being detected.
+type RequiredWorkflow struct {
+	ID        int64
+	EntryName string
+	Content   []byte
 }
 
 func init() {
@@ -101,6 +112,7 @@ func DetectWorkflows(
 	triggedEvent webhook_module.HookEventType,
 	payload api.Payloader,
 	detectSchedule bool,
+	requiredWorkflows ...*RequiredWorkflow,
 ) ([]*DetectedWorkflow, []*DetectedWorkflow, error) {
 	entries, err := ListWorkflows(commit)
 	if err != nil {
@@ -143,6 +155,26 @@ func DetectWorkflows(
 		}
 	}
 
+	// the schedules of required workflows are ignored, they only run for the events of the repository
+	for _, rw := range requiredWorkflows {
+		events, err := GetEventsFromContent(rw.Content)
+		if err != nil {
+			log.Warn("ignore invalid required workflow %q: %v", rw.EntryName, err)
+			continue
+		}
+		for _, evt := range events {
+			log.Trace("detect required workflow %q for event %#v matching %q", rw.EntryName, evt, triggedEvent)
+			if !evt.IsSchedule() && detectMatched(gitRepo, commit, triggedEvent, payload, evt) {
+				workflows = append(workflows, &DetectedWorkflow{
+					EntryName:          rw.EntryName,
+					TriggerEvent:       evt,
+					Content:            rw.Content,
+					RequiredWorkflowID: rw.ID,
+				})
+			}
+		}
+	}
+
 	return workflows, schedules, nil
 }
 
@@ -243,6 +275,10 @@ func detectMatched(gitRepo *git.Repository, commit *git.Commit, triggedEvent web
 		webhook_module.HookEventPackage:
 		return matchPackageEvent(payload.(*api.PackagePayload), evt)
 
+	case // workflow_run
+		webhook_module.HookEventWorkflowRun:
+		return matchWorkflowRunEvent(payload.(*api.WorkflowRunPayload), evt)
+
 	default:
 		log.Warn("unsupported event %q", triggedEvent)
 		return false
@@ -698,3 +734,57 @@ func matchPackageEvent(payload *api.PackagePayload, evt *jobparser.Event) bool {
 	}
 	return matchTimes == len(evt.Acts())
 }
+
+func matchWorkflowRunEvent(payload *api.WorkflowRunPayload, evt *jobparser.Event) bool {
+	// with no special filter parameters
+	if len(evt.Acts()) == 0 {
+		return true
+	}
+
+	matchTimes := 0
+	// all acts conditions should be satisfied
+	for cond, vals := range evt.Acts() {
+		switch cond {
+		case "types":
+			// See https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows#workflow_run
+			// Activity types with the same name:
+			// completed
+			// Unsupported activity types:
+			// requested, in_progress
+			for _, val := range vals {
+				if glob.MustCompile(val, '/').Match(string(payload.Action)) {
+					matchTimes++
+					break
+				}
+			}
+		case "workflows":
+			// the workflows could be specified by their names or their file names
+			for _, val := range vals {
+				g := glob.MustCompile(val, '/')
+				if g.Match(payload.Workflow.Name) || g.Match(payload.Workflow.ID) {
+					matchTimes++
+					break
+				}
+			}
+		case "branches":
+			patterns, err := workflowpattern.CompilePatterns(vals...)
+			if err != nil {
+				break
+			}
+			if !workflowpattern.Skip(patterns, []string{payload.WorkflowRun.HeadBranch}, &workflowpattern.EmptyTraceWriter{}) {
+				matchTimes++
+			}
+		case "branches-ignore":
+			patterns, err := workflowpattern.CompilePatterns(vals...)
+			if err != nil {
+				break
+			}
+			if !workflowpattern.Filter(patterns, []string{payload.WorkflowRun.HeadBranch}, &workflowpattern.EmptyTraceWriter{}) {
+				matchTimes++
+			}
+		default:
+			log.Warn("workflow run event unsupported condition %q", cond)
+		}
+	}
+	return matchTimes == len(evt.Acts())
+}
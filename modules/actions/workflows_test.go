@@ -111,6 +111,72 @@ func TestDetectMatched(t *testing.T) {
 			yamlOn:       "on:\n  registry_package:\n    types: [updated]",
 			expected:     false,
 		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) `completed` action matches GithubEventWorkflowRun(workflow_run) with `workflows` and `types`",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "main", Conclusion: "success"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [Build]\n    types: [completed]",
+			expected: true,
+		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) matches GithubEventWorkflowRun(workflow_run) with the file name of the workflow",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "main", Conclusion: "success"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [build.yml]",
+			expected: true,
+		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) doesn't match GithubEventWorkflowRun(workflow_run) with other workflows",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "main", Conclusion: "success"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [Test]",
+			expected: false,
+		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) `completed` action doesn't match GithubEventWorkflowRun(workflow_run) with `requested` activity type",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "main", Conclusion: "success"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [Build]\n    types: [requested]",
+			expected: false,
+		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) matches GithubEventWorkflowRun(workflow_run) with `branches`",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "release/v1", Conclusion: "failure"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [Build]\n    branches: ['release/**']",
+			expected: true,
+		},
+		{
+			desc:         "HookEventWorkflowRun(workflow_run) doesn't match GithubEventWorkflowRun(workflow_run) with `branches-ignore`",
+			triggedEvent: webhook_module.HookEventWorkflowRun,
+			payload: &api.WorkflowRunPayload{
+				Action:      api.HookWorkflowRunCompleted,
+				Workflow:    &api.ActionWorkflow{ID: "build.yml", Name: "Build"},
+				WorkflowRun: &api.ActionWorkflowRun{HeadBranch: "main", Conclusion: "success"},
+			},
+			yamlOn:   "on:\n  workflow_run:\n    workflows: [Build]\n    branches-ignore: [main]",
+			expected: false,
+		},
 		{
 			desc:         "HookEventWiki(wiki) matches GithubEventGollum(gollum)",
 			triggedEvent: webhook_module.HookEventWiki,
@@ -18,7 +18,13 @@ type Manager struct {
 var manager *Manager
 
 func init() {
-	manager = &Manager{
+	manager = NewManager()
+}
+
+// NewManager creates a Manager, the messengers could be keyed by the ids of anything other than users,
+// e.g. the ids of the actions tasks whose logs are streamed
+func NewManager() *Manager {
+	return &Manager{
 		messengers: make(map[int64]*Messenger),
 		connection: make(chan struct{}, 1),
 	}
@@ -18,6 +18,9 @@ type Encoder interface {
 	Encode(v any) error
 }
 
+// RawMessage is a raw encoded JSON value which is decoded later
+type RawMessage = json.RawMessage
+
 // Decoder represents a decoder for json
 type Decoder interface {
 	Decode(v any) error
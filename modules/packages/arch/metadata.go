@@ -0,0 +1,256 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/modules/zstd"
+
+	"github.com/ulikunitz/xz"
+)
+
+var (
+	ErrMissingPKGINFOFile  = util.NewInvalidArgumentErrorf(".PKGINFO file is missing")
+	ErrUnsupportedFormat   = util.NewInvalidArgumentErrorf("package format is not supported")
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidVersion      = util.NewInvalidArgumentErrorf("package version is invalid")
+	ErrInvalidArchitecture = util.NewInvalidArgumentErrorf("package architecture is invalid")
+
+	// https://man.archlinux.org/man/PKGBUILD.5
+	namePattern = regexp.MustCompile(`\A[a-zA-Z0-9@_+][a-zA-Z0-9@._+-]*\z`)
+	// (epoch:)pkgver-pkgrel
+	versionPattern      = regexp.MustCompile(`\A(?:\d+:)?[a-zA-Z0-9._+]+-\d+(?:\.\d+)?\z`)
+	architecturePattern = regexp.MustCompile(`\A[a-zA-Z0-9_]+\z`)
+)
+
+const (
+	PropertyRepository   = "arch.repository"
+	PropertyArchitecture = "arch.architecture"
+	PropertyMetadata     = "arch.metadata"
+	PropertySignature    = "arch.signature"
+
+	SettingKeyPrivate = "arch.key.private"
+	SettingKeyPublic  = "arch.key.public"
+
+	RepositoryPackage = "_arch"
+	RepositoryVersion = "_repository"
+
+	// AnyArch is the architecture of the packages which could be installed on all architectures
+	AnyArch = "any"
+)
+
+// https://wiki.archlinux.org/title/Arch_package_guidelines
+
+// Package represents an Arch package
+type Package struct {
+	Name            string
+	Version         string
+	VersionMetadata VersionMetadata
+	FileMetadata    FileMetadata
+}
+
+// VersionMetadata of an Arch package
+type VersionMetadata struct {
+	Base        string   `json:"base,omitempty"`
+	Description string   `json:"description,omitempty"`
+	ProjectURL  string   `json:"project_url,omitempty"`
+	Licenses    []string `json:"licenses,omitempty"`
+	Groups      []string `json:"groups,omitempty"`
+}
+
+// FileMetadata of an Arch package file
+type FileMetadata struct {
+	Architecture  string   `json:"architecture"`
+	Compression   string   `json:"compression"`
+	Packager      string   `json:"packager,omitempty"`
+	BuildDate     int64    `json:"build_date,omitempty"`
+	InstalledSize int64    `json:"installed_size,omitempty"`
+	Provides      []string `json:"provides,omitempty"`
+	Conflicts     []string `json:"conflicts,omitempty"`
+	Replaces      []string `json:"replaces,omitempty"`
+	Depends       []string `json:"depends,omitempty"`
+	OptDepends    []string `json:"opt_depends,omitempty"`
+	MakeDepends   []string `json:"make_depends,omitempty"`
+	CheckDepends  []string `json:"check_depends,omitempty"`
+	Backup        []string `json:"backup,omitempty"`
+	Files         []string `json:"files,omitempty"`
+}
+
+// Filename returns the name of the package file like "{name}-{version}-{architecture}.pkg.tar.zst"
+func (p *Package) Filename() string {
+	return p.Name + "-" + p.Version + "-" + p.FileMetadata.Architecture + ".pkg.tar." + p.FileMetadata.Compression
+}
+
+// ParsePackage parses an Arch package file, the archive could be compressed with zstd, xz or gzip
+func ParsePackage(r io.Reader) (*Package, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(6)
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	var compression string
+	var cr io.Reader
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		compression, cr = "zst", zr
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xzr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		compression, cr = "xz", xzr
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gzr.Close()
+		compression, cr = "gz", gzr
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	var p *Package
+	var files []string
+	tr := tar.NewReader(cr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimPrefix(hd.Name, "./")
+		if name == ".PKGINFO" {
+			if p, err = ParsePackageInfo(tr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		// the other metadata files like .BUILDINFO, .MTREE and .INSTALL aren't installed
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if hd.Typeflag == tar.TypeDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+		files = append(files, name)
+	}
+
+	if p == nil {
+		return nil, ErrMissingPKGINFOFile
+	}
+	p.FileMetadata.Compression = compression
+	p.FileMetadata.Files = files
+	return p, nil
+}
+
+// ParsePackageInfo parses a .PKGINFO file to retrieve the metadata of an Arch package
+func ParsePackageInfo(r io.Reader) (*Package, error) {
+	p := &Package{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		switch key {
+		case "pkgname":
+			p.Name = value
+		case "pkgbase":
+			p.VersionMetadata.Base = value
+		case "pkgver":
+			p.Version = value
+		case "pkgdesc":
+			p.VersionMetadata.Description = value
+		case "url":
+			p.VersionMetadata.ProjectURL = value
+		case "license":
+			p.VersionMetadata.Licenses = append(p.VersionMetadata.Licenses, value)
+		case "group":
+			p.VersionMetadata.Groups = append(p.VersionMetadata.Groups, value)
+		case "arch":
+			p.FileMetadata.Architecture = value
+		case "packager":
+			p.FileMetadata.Packager = value
+		case "builddate":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				p.FileMetadata.BuildDate = n
+			}
+		case "size":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				p.FileMetadata.InstalledSize = n
+			}
+		case "provides":
+			p.FileMetadata.Provides = append(p.FileMetadata.Provides, value)
+		case "conflict":
+			p.FileMetadata.Conflicts = append(p.FileMetadata.Conflicts, value)
+		case "replaces":
+			p.FileMetadata.Replaces = append(p.FileMetadata.Replaces, value)
+		case "depend":
+			p.FileMetadata.Depends = append(p.FileMetadata.Depends, value)
+		case "optdepend":
+			p.FileMetadata.OptDepends = append(p.FileMetadata.OptDepends, value)
+		case "makedepend":
+			p.FileMetadata.MakeDepends = append(p.FileMetadata.MakeDepends, value)
+		case "checkdepend":
+			p.FileMetadata.CheckDepends = append(p.FileMetadata.CheckDepends, value)
+		case "backup":
+			p.FileMetadata.Backup = append(p.FileMetadata.Backup, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !namePattern.MatchString(p.Name) {
+		return nil, ErrInvalidName
+	}
+	if !versionPattern.MatchString(p.Version) {
+		return nil, ErrInvalidVersion
+	}
+	if !architecturePattern.MatchString(p.FileMetadata.Architecture) {
+		return nil, ErrInvalidArchitecture
+	}
+	if p.VersionMetadata.Base == "" {
+		p.VersionMetadata.Base = p.Name
+	}
+
+	if !validation.IsValidURL(p.VersionMetadata.ProjectURL) {
+		p.VersionMetadata.ProjectURL = ""
+	}
+
+	return p, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"code.gitea.io/gitea/modules/zstd"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	packageName        = "gitea"
+	packageVersion     = "1.0.1-1"
+	packageDescription = "Package Description"
+	packageProjectURL  = "https://gitea.io"
+	packagePackager    = "KN4CK3R <dummy@gitea.io>"
+)
+
+func createPKGINFOContent(name, version, arch string) []byte {
+	return []byte(`# Generated by makepkg
+pkgname = ` + name + `
+pkgbase = ` + name + `-base
+pkgver = ` + version + `
+pkgdesc = ` + packageDescription + `
+url = ` + packageProjectURL + `
+builddate = 1678834800
+packager = ` + packagePackager + `
+size = 123456
+arch = ` + arch + `
+license = MIT
+group = group
+provides = common
+conflict = conflict
+replaces = replaces
+depend = glibc
+depend = git
+optdepend = git-lfs: large files
+makedepend = go
+checkdepend = curl
+backup = etc/gitea/app.ini`)
+}
+
+func createPackageArchive(t *testing.T, files map[string][]byte, compress func(io.Writer) io.WriteCloser) *bytes.Buffer {
+	var buf bytes.Buffer
+	cw := compress(&buf)
+	tw := tar.NewWriter(cw)
+	for _, name := range []string{".PKGINFO", ".MTREE", "usr/", "usr/bin/gitea", "dummy.txt"} {
+		content, ok := files[name]
+		if !ok {
+			continue
+		}
+		hdr := &tar.Header{
+			Name: name,
+			Mode: 0o644,
+			Size: int64(len(content)),
+		}
+		if name[len(name)-1] == '/' {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name = name[:len(name)-1]
+			hdr.Mode = 0o755
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, cw.Close())
+	return &buf
+}
+
+func gzipCompress(w io.Writer) io.WriteCloser {
+	return gzip.NewWriter(w)
+}
+
+func zstdCompress(w io.Writer) io.WriteCloser {
+	zw, _ := zstd.NewWriter(w)
+	return zw
+}
+
+func TestParsePackage(t *testing.T) {
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		pp, err := ParsePackage(bytes.NewReader([]byte("not a package archive")))
+		assert.Nil(t, pp)
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("MissingPKGINFOFile", func(t *testing.T) {
+		data := createPackageArchive(t, map[string][]byte{"dummy.txt": {}}, gzipCompress)
+
+		pp, err := ParsePackage(data)
+		assert.Nil(t, pp)
+		assert.ErrorIs(t, err, ErrMissingPKGINFOFile)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		for _, name := range []string{"", "-gitea", ".gitea", "gi tea"} {
+			data := createPackageArchive(t, map[string][]byte{".PKGINFO": createPKGINFOContent(name, packageVersion, "x86_64")}, gzipCompress)
+
+			pp, err := ParsePackage(data)
+			assert.Nil(t, pp)
+			assert.ErrorIs(t, err, ErrInvalidName)
+		}
+	})
+
+	t.Run("InvalidVersion", func(t *testing.T) {
+		for _, version := range []string{"", "1.0.1", "1.0-1-1", "1:1.0:1-1"} {
+			data := createPackageArchive(t, map[string][]byte{".PKGINFO": createPKGINFOContent(packageName, version, "x86_64")}, gzipCompress)
+
+			pp, err := ParsePackage(data)
+			assert.Nil(t, pp)
+			assert.ErrorIs(t, err, ErrInvalidVersion)
+		}
+	})
+
+	t.Run("InvalidArchitecture", func(t *testing.T) {
+		data := createPackageArchive(t, map[string][]byte{".PKGINFO": createPKGINFOContent(packageName, packageVersion, "x86/64")}, gzipCompress)
+
+		pp, err := ParsePackage(data)
+		assert.Nil(t, pp)
+		assert.ErrorIs(t, err, ErrInvalidArchitecture)
+	})
+
+	t.Run("Valid", func(t *testing.T) {
+		for compression, compress := range map[string]func(io.Writer) io.WriteCloser{"gz": gzipCompress, "zst": zstdCompress} {
+			t.Run(compression, func(t *testing.T) {
+				data := createPackageArchive(t, map[string][]byte{
+					".PKGINFO":      createPKGINFOContent(packageName, packageVersion, "x86_64"),
+					".MTREE":        {},
+					"usr/":          {},
+					"usr/bin/gitea": []byte("binary"),
+				}, compress)
+
+				pp, err := ParsePackage(data)
+				require.NoError(t, err)
+				require.NotNil(t, pp)
+
+				assert.Equal(t, packageName, pp.Name)
+				assert.Equal(t, packageVersion, pp.Version)
+				assert.Equal(t, packageName+"-base", pp.VersionMetadata.Base)
+				assert.Equal(t, packageDescription, pp.VersionMetadata.Description)
+				assert.Equal(t, packageProjectURL, pp.VersionMetadata.ProjectURL)
+				assert.ElementsMatch(t, []string{"MIT"}, pp.VersionMetadata.Licenses)
+				assert.ElementsMatch(t, []string{"group"}, pp.VersionMetadata.Groups)
+				assert.Equal(t, "x86_64", pp.FileMetadata.Architecture)
+				assert.Equal(t, compression, pp.FileMetadata.Compression)
+				assert.Equal(t, packagePackager, pp.FileMetadata.Packager)
+				assert.EqualValues(t, 1678834800, pp.FileMetadata.BuildDate)
+				assert.EqualValues(t, 123456, pp.FileMetadata.InstalledSize)
+				assert.ElementsMatch(t, []string{"common"}, pp.FileMetadata.Provides)
+				assert.ElementsMatch(t, []string{"conflict"}, pp.FileMetadata.Conflicts)
+				assert.ElementsMatch(t, []string{"replaces"}, pp.FileMetadata.Replaces)
+				assert.ElementsMatch(t, []string{"glibc", "git"}, pp.FileMetadata.Depends)
+				assert.ElementsMatch(t, []string{"git-lfs: large files"}, pp.FileMetadata.OptDepends)
+				assert.ElementsMatch(t, []string{"go"}, pp.FileMetadata.MakeDepends)
+				assert.ElementsMatch(t, []string{"curl"}, pp.FileMetadata.CheckDepends)
+				assert.ElementsMatch(t, []string{"etc/gitea/app.ini"}, pp.FileMetadata.Backup)
+				assert.Equal(t, []string{"usr/", "usr/bin/gitea"}, pp.FileMetadata.Files)
+				assert.Equal(t, "gitea-1.0.1-1-x86_64.pkg.tar."+compression, pp.Filename())
+			})
+		}
+	})
+}
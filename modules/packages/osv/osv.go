@@ -0,0 +1,188 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package osv
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Ecosystems known by the OSV schema which are matched against packages
+// https://ossf.github.io/osv-schema/#affectedpackage-field
+const (
+	EcosystemAlpine    = "Alpine"
+	EcosystemCratesIO  = "crates.io"
+	EcosystemDebian    = "Debian"
+	EcosystemGo        = "Go"
+	EcosystemHex       = "Hex"
+	EcosystemMaven     = "Maven"
+	EcosystemNpm       = "npm"
+	EcosystemNuGet     = "NuGet"
+	EcosystemPackagist = "Packagist"
+	EcosystemPub       = "Pub"
+	EcosystemPyPI      = "PyPI"
+	EcosystemRubyGems  = "RubyGems"
+)
+
+// Range types of the affected versions
+const (
+	RangeTypeSemver    = "SEMVER"
+	RangeTypeEcosystem = "ECOSYSTEM"
+	RangeTypeGit       = "GIT"
+)
+
+var ErrInvalidVulnerability = util.NewInvalidArgumentErrorf("vulnerability is invalid")
+
+// Vulnerability is an entry of an OSV database
+// https://ossf.github.io/osv-schema/
+type Vulnerability struct {
+	ID               string           `json:"id"`
+	Summary          string           `json:"summary"`
+	Details          string           `json:"details"`
+	Aliases          []string         `json:"aliases"`
+	Published        time.Time        `json:"published"`
+	Modified         time.Time        `json:"modified"`
+	Withdrawn        *time.Time       `json:"withdrawn"`
+	Affected         []*Affected      `json:"affected"`
+	DatabaseSpecific DatabaseSpecific `json:"database_specific"`
+}
+
+// DatabaseSpecific contains the fields of the database which are not part of the schema
+type DatabaseSpecific struct {
+	// Severity is provided by the GitHub advisory database, for example "HIGH" or "MODERATE"
+	Severity string `json:"severity"`
+}
+
+// Affected describes the affected versions of a package
+type Affected struct {
+	Package  Package  `json:"package"`
+	Ranges   []*Range `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// Package identifies the affected package
+type Package struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+	PURL      string `json:"purl"`
+}
+
+// Range is a range of affected versions, defined by the events which change the state
+type Range struct {
+	Type   string   `json:"type"`
+	Events []*Event `json:"events"`
+}
+
+// Event introduces or fixes the vulnerability at a version
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// ParseVulnerability parses an OSV JSON document
+func ParseVulnerability(r io.Reader) (*Vulnerability, error) {
+	var v *Vulnerability
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+	if v == nil || v.ID == "" {
+		return nil, ErrInvalidVulnerability
+	}
+	return v, nil
+}
+
+// BaseEcosystem strips the release of ecosystems like "Debian:12" or "Alpine:v3.20"
+func BaseEcosystem(ecosystem string) string {
+	base, _, _ := strings.Cut(ecosystem, ":")
+	return base
+}
+
+// IsAffected checks if the version is listed in the affected versions or contained in one of the ranges.
+// Ranges of the ecosystem type are only evaluated if the versions can be compared like semantic versions,
+// the databases usually list the affected versions explicitly for the other ecosystems.
+func IsAffected(versions []string, ranges []*Range, v string) bool {
+	if slices.Contains(versions, v) {
+		return true
+	}
+
+	target, err := version.NewVersion(v)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if r.Type != RangeTypeSemver && r.Type != RangeTypeEcosystem {
+			continue
+		}
+		if isInRange(r.Events, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isInRange evaluates the events of a range in version order
+// https://ossf.github.io/osv-schema/#evaluation
+func isInRange(events []*Event, target *version.Version) bool {
+	type parsedEvent struct {
+		event   *Event
+		version *version.Version // nil for the introduced version "0"
+	}
+
+	parsed := make([]parsedEvent, 0, len(events))
+	for _, e := range events {
+		v := e.Introduced + e.Fixed + e.LastAffected + e.Limit
+		if e.Introduced == "0" {
+			parsed = append(parsed, parsedEvent{event: e})
+			continue
+		}
+		pv, err := version.NewVersion(v)
+		if err != nil {
+			return false
+		}
+		parsed = append(parsed, parsedEvent{event: e, version: pv})
+	}
+	slices.SortStableFunc(parsed, func(a, b parsedEvent) int {
+		if a.version == nil || b.version == nil {
+			if a.version == b.version {
+				return 0
+			} else if a.version == nil {
+				return -1
+			}
+			return 1
+		}
+		return a.version.Compare(b.version)
+	})
+
+	affected := false
+	for _, pe := range parsed {
+		switch {
+		case pe.event.Introduced != "":
+			if pe.version == nil || !target.LessThan(pe.version) {
+				affected = true
+			}
+		case pe.event.Fixed != "":
+			if !target.LessThan(pe.version) {
+				affected = false
+			}
+		case pe.event.LastAffected != "":
+			if target.GreaterThan(pe.version) {
+				affected = false
+			}
+		case pe.event.Limit != "":
+			if !target.LessThan(pe.version) {
+				affected = false
+			}
+		}
+	}
+	return affected
+}
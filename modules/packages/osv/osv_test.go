@@ -0,0 +1,69 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package osv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const vulnerabilityContent = `{
+  "id": "GHSA-test-1234-5678",
+  "summary": "Prototype pollution",
+  "aliases": ["CVE-2024-0001"],
+  "modified": "2024-05-01T10:00:00Z",
+  "published": "2024-04-01T10:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}],
+    "versions": ["4.17.20"]
+  }],
+  "database_specific": {"severity": "HIGH"}
+}`
+
+func TestParseVulnerability(t *testing.T) {
+	v, err := ParseVulnerability(strings.NewReader(vulnerabilityContent))
+	assert.NoError(t, err)
+	assert.Equal(t, "GHSA-test-1234-5678", v.ID)
+	assert.Equal(t, []string{"CVE-2024-0001"}, v.Aliases)
+	assert.Equal(t, "HIGH", v.DatabaseSpecific.Severity)
+	assert.Len(t, v.Affected, 1)
+	assert.Equal(t, "lodash", v.Affected[0].Package.Name)
+
+	_, err = ParseVulnerability(strings.NewReader(`{"summary":"no id"}`))
+	assert.ErrorIs(t, err, ErrInvalidVulnerability)
+}
+
+func TestIsAffected(t *testing.T) {
+	ranges := []*Range{
+		{Type: RangeTypeSemver, Events: []*Event{{Fixed: "1.2.0"}, {Introduced: "1.0.0"}, {Introduced: "2.0.0"}, {LastAffected: "2.1.0"}}},
+		{Type: RangeTypeGit, Events: []*Event{{Introduced: "0"}}},
+	}
+
+	cases := map[string]bool{
+		"0.9.0":    false,
+		"1.0.0":    true,
+		"1.1.9":    true,
+		"1.2.0":    false,
+		"1.5.0":    false,
+		"2.0.0":    true,
+		"2.1.0":    true,
+		"2.1.1":    false,
+		"3.0.0":    true, // explicitly listed
+		"invalid!": false,
+	}
+	for v, expected := range cases {
+		assert.Equal(t, expected, IsAffected([]string{"3.0.0"}, ranges, v), v)
+	}
+
+	assert.True(t, IsAffected(nil, []*Range{{Type: RangeTypeEcosystem, Events: []*Event{{Introduced: "0"}, {Limit: "5.0"}}}}, "1.0"))
+	assert.False(t, IsAffected(nil, []*Range{{Type: RangeTypeEcosystem, Events: []*Event{{Introduced: "0"}, {Limit: "5.0"}}}}, "5.0"))
+}
+
+func TestBaseEcosystem(t *testing.T) {
+	assert.Equal(t, "Debian", BaseEcosystem("Debian:12"))
+	assert.Equal(t, "npm", BaseEcosystem("npm"))
+}
@@ -0,0 +1,314 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/packages/osv"
+	"code.gitea.io/gitea/modules/util"
+)
+
+const (
+	FormatCycloneDX = "cyclonedx"
+	FormatSPDX      = "spdx"
+
+	MediaTypeCycloneDX = "application/vnd.cyclonedx+json"
+	MediaTypeSPDX      = "application/spdx+json"
+	MediaTypeInToto    = "application/vnd.in-toto+json"
+
+	// the predicate types of in-toto attestations start with these prefixes, followed by an optional version
+	PredicateTypeCycloneDX = "https://cyclonedx.org/bom"
+	PredicateTypeSPDX      = "https://spdx.dev/Document"
+
+	// PackageName and PackageVersion identify the internal package version which stores the SBOM documents of an owner
+	PackageName    = "_sbom"
+	PackageVersion = "_sbom"
+
+	PropertyVersionID = "sbom.version_id"
+	PropertyFormat    = "sbom.format"
+
+	// MaxDocumentSize is the maximum size of a SBOM document
+	MaxDocumentSize = 32 * 1024 * 1024
+)
+
+var (
+	ErrUnknownFormat    = util.NewInvalidArgumentErrorf("unknown SBOM format")
+	ErrDocumentTooLarge = util.NewInvalidArgumentErrorf("SBOM document is too large")
+)
+
+// Component is a software component listed in a SBOM
+type Component struct {
+	Name    string
+	Version string
+	PURL    string
+	// Ecosystem and PackageName are derived from the package URL, they are empty if the type isn't known
+	Ecosystem   string
+	PackageName string
+}
+
+// Document is a parsed SBOM
+type Document struct {
+	Format      string
+	SpecVersion string
+	Components  []*Component
+}
+
+type cycloneDXComponent struct {
+	Name       string                `json:"name"`
+	Version    string                `json:"version"`
+	PURL       string                `json:"purl"`
+	Components []*cycloneDXComponent `json:"components"`
+}
+
+type rawDocument struct {
+	// CycloneDX
+	BOMFormat   string `json:"bomFormat"`
+	SpecVersion string `json:"specVersion"`
+	Metadata    struct {
+		Component *cycloneDXComponent `json:"component"`
+	} `json:"metadata"`
+	Components []*cycloneDXComponent `json:"components"`
+	// SPDX
+	SPDXVersion string `json:"spdxVersion"`
+	Packages    []struct {
+		Name         string `json:"name"`
+		VersionInfo  string `json:"versionInfo"`
+		ExternalRefs []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+// ParseDocument parses a CycloneDX or SPDX document in JSON format
+func ParseDocument(r io.Reader) (*Document, error) {
+	var raw rawDocument
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+	}
+
+	doc := &Document{}
+	switch {
+	case raw.BOMFormat == "CycloneDX":
+		doc.Format = FormatCycloneDX
+		doc.SpecVersion = raw.SpecVersion
+
+		var walk func([]*cycloneDXComponent)
+		walk = func(components []*cycloneDXComponent) {
+			for _, c := range components {
+				if c == nil {
+					continue
+				}
+				doc.Components = append(doc.Components, newComponent(c.Name, c.Version, c.PURL))
+				walk(c.Components)
+			}
+		}
+		walk([]*cycloneDXComponent{raw.Metadata.Component})
+		walk(raw.Components)
+	case strings.HasPrefix(raw.SPDXVersion, "SPDX-"):
+		doc.Format = FormatSPDX
+		doc.SpecVersion = strings.TrimPrefix(raw.SPDXVersion, "SPDX-")
+
+		for _, p := range raw.Packages {
+			purl := ""
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					purl = ref.ReferenceLocator
+					break
+				}
+			}
+			doc.Components = append(doc.Components, newComponent(p.Name, p.VersionInfo, purl))
+		}
+	default:
+		return nil, ErrUnknownFormat
+	}
+	return doc, nil
+}
+
+func newComponent(name, version, purl string) *Component {
+	c := &Component{
+		Name:    name,
+		Version: version,
+		PURL:    purl,
+	}
+	if ecosystem, packageName, packageVersion, ok := parsePackageURL(purl); ok {
+		c.Ecosystem = ecosystem
+		c.PackageName = packageName
+		if packageVersion != "" {
+			c.Version = packageVersion
+		}
+	}
+	return c
+}
+
+// parsePackageURL extracts the OSV ecosystem and the package name from a package URL
+// https://github.com/package-url/purl-spec
+func parsePackageURL(purl string) (ecosystem, name, version string, ok bool) {
+	rest, found := strings.CutPrefix(purl, "pkg:")
+	if !found {
+		return "", "", "", false
+	}
+	rest, _, _ = strings.Cut(rest, "#")
+	rest, _, _ = strings.Cut(rest, "?")
+	if pos := strings.LastIndex(rest, "@"); pos != -1 && pos > strings.LastIndex(rest, "/") {
+		version, _ = url.PathUnescape(rest[pos+1:])
+		rest = rest[:pos]
+	}
+
+	purlType, fullName, found := strings.Cut(rest, "/")
+	if !found {
+		return "", "", "", false
+	}
+	segments := strings.Split(fullName, "/")
+	for i, s := range segments {
+		segments[i], _ = url.PathUnescape(s)
+	}
+	namespace, name := strings.Join(segments[:len(segments)-1], "/"), segments[len(segments)-1]
+
+	switch strings.ToLower(purlType) {
+	case "npm":
+		ecosystem = osv.EcosystemNpm
+		if namespace != "" {
+			name = namespace + "/" + name
+		}
+	case "pypi":
+		ecosystem = osv.EcosystemPyPI
+	case "maven":
+		ecosystem = osv.EcosystemMaven
+		name = namespace + ":" + name
+	case "golang":
+		ecosystem = osv.EcosystemGo
+		if namespace != "" {
+			name = namespace + "/" + name
+		}
+	case "cargo":
+		ecosystem = osv.EcosystemCratesIO
+	case "gem":
+		ecosystem = osv.EcosystemRubyGems
+	case "nuget":
+		ecosystem = osv.EcosystemNuGet
+	case "composer":
+		ecosystem = osv.EcosystemPackagist
+		if namespace != "" {
+			name = namespace + "/" + name
+		}
+	case "pub":
+		ecosystem = osv.EcosystemPub
+	case "hex":
+		ecosystem = osv.EcosystemHex
+	case "deb":
+		ecosystem = osv.EcosystemDebian
+	case "apk":
+		ecosystem = osv.EcosystemAlpine
+	default:
+		return "", "", "", false
+	}
+	return ecosystem, name, version, name != ""
+}
+
+// ExtractFromInTotoStatement returns the SBOM predicate of an in-toto attestation statement
+// It returns nil if the predicate isn't a SBOM.
+func ExtractFromInTotoStatement(r io.Reader) ([]byte, error) {
+	var statement struct {
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r, MaxDocumentSize)).Decode(&statement); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(statement.PredicateType, PredicateTypeCycloneDX) && !strings.HasPrefix(statement.PredicateType, PredicateTypeSPDX) {
+		return nil, nil
+	}
+	return statement.Predicate, nil
+}
+
+// isNpmSBOMFile matches the SBOM files in the root of a npm package
+func isNpmSBOMFile(name string) bool {
+	dir, file := path.Split(strings.ToLower(name))
+	if dir != "package/" {
+		return false
+	}
+	return file == "bom.json" || file == "sbom.json" || strings.HasSuffix(file, ".cdx.json") || strings.HasSuffix(file, ".spdx.json")
+}
+
+// ExtractFromNpmTarball returns the SBOM documents included in a npm package tarball
+func ExtractFromNpmTarball(r io.Reader) ([][]byte, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var documents [][]byte
+
+	tr := tar.NewReader(zr)
+	for {
+		hd, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hd.Typeflag != tar.TypeReg || !isNpmSBOMFile(hd.Name) {
+			continue
+		}
+		data, err := readDocument(tr)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, data)
+	}
+	return documents, nil
+}
+
+// ExtractFromWheel returns the SBOM documents included in a Python wheel
+// https://peps.python.org/pep-0770/
+func ExtractFromWheel(r io.ReaderAt, size int64) ([][]byte, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var documents [][]byte
+	for _, file := range archive.File {
+		dir, name := path.Split(file.Name)
+		if !strings.HasSuffix(dir, ".dist-info/sboms/") || path.Ext(name) != ".json" {
+			continue
+		}
+		f, err := archive.Open(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readDocument(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, data)
+	}
+	return documents, nil
+}
+
+func readDocument(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r, MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > MaxDocumentSize {
+		return nil, ErrDocumentTooLarge
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/packages/osv"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	cycloneDXContent = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "metadata": {"component": {"name": "app", "version": "1.0.0"}},
+  "components": [
+    {"name": "lodash", "version": "4.17.20", "purl": "pkg:npm/lodash@4.17.20"},
+    {"name": "core", "version": "7.0.0", "purl": "pkg:npm/%40babel/core@7.0.0", "components": [
+      {"name": "jackson-databind", "version": "2.9.0", "purl": "pkg:maven/com.fasterxml.jackson.core/jackson-databind@2.9.0?type=jar"}
+    ]}
+  ]
+}`
+	spdxContent = `{
+  "spdxVersion": "SPDX-2.3",
+  "packages": [
+    {"name": "requests", "versionInfo": "2.19.0", "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:pypi/requests@2.19.0"}]},
+    {"name": "unknown", "versionInfo": "1.0"}
+  ]
+}`
+)
+
+func TestParseDocument(t *testing.T) {
+	t.Run("CycloneDX", func(t *testing.T) {
+		doc, err := ParseDocument(strings.NewReader(cycloneDXContent))
+		assert.NoError(t, err)
+		assert.Equal(t, FormatCycloneDX, doc.Format)
+		assert.Equal(t, "1.5", doc.SpecVersion)
+		assert.Len(t, doc.Components, 4)
+		assert.Equal(t, &Component{Name: "app", Version: "1.0.0"}, doc.Components[0])
+		assert.Equal(t, osv.EcosystemNpm, doc.Components[1].Ecosystem)
+		assert.Equal(t, "lodash", doc.Components[1].PackageName)
+		assert.Equal(t, "@babel/core", doc.Components[2].PackageName)
+		assert.Equal(t, osv.EcosystemMaven, doc.Components[3].Ecosystem)
+		assert.Equal(t, "com.fasterxml.jackson.core:jackson-databind", doc.Components[3].PackageName)
+		assert.Equal(t, "2.9.0", doc.Components[3].Version)
+	})
+
+	t.Run("SPDX", func(t *testing.T) {
+		doc, err := ParseDocument(strings.NewReader(spdxContent))
+		assert.NoError(t, err)
+		assert.Equal(t, FormatSPDX, doc.Format)
+		assert.Equal(t, "2.3", doc.SpecVersion)
+		assert.Len(t, doc.Components, 2)
+		assert.Equal(t, osv.EcosystemPyPI, doc.Components[0].Ecosystem)
+		assert.Equal(t, "requests", doc.Components[0].PackageName)
+		assert.Empty(t, doc.Components[1].Ecosystem)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := ParseDocument(strings.NewReader(`{"name":"package"}`))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+		_, err = ParseDocument(strings.NewReader(`invalid`))
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+}
+
+func TestExtractFromInTotoStatement(t *testing.T) {
+	data, err := ExtractFromInTotoStatement(strings.NewReader(`{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://spdx.dev/Document","predicate":` + spdxContent + `}`))
+	assert.NoError(t, err)
+	doc, err := ParseDocument(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, FormatSPDX, doc.Format)
+
+	data, err = ExtractFromInTotoStatement(strings.NewReader(`{"predicateType":"https://slsa.dev/provenance/v0.2","predicate":{}}`))
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+}
+
+func TestExtractFromArchives(t *testing.T) {
+	t.Run("Npm", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(zw)
+		for name, content := range map[string]string{
+			"package/package.json":      `{}`,
+			"package/bom.json":          cycloneDXContent,
+			"package/lib/sbom.json":     spdxContent,
+			"package/release.spdx.json": spdxContent,
+		} {
+			tw.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg})
+			tw.Write([]byte(content))
+		}
+		tw.Close()
+		zw.Close()
+
+		documents, err := ExtractFromNpmTarball(&buf)
+		assert.NoError(t, err)
+		assert.Len(t, documents, 2)
+	})
+
+	t.Run("Wheel", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range map[string]string{
+			"test/__init__.py":                      "",
+			"test-1.0.dist-info/METADATA":           "Name: test",
+			"test-1.0.dist-info/sboms/bom.cdx.json": cycloneDXContent,
+			"test/sboms/other.json":                 spdxContent,
+		} {
+			w, _ := zw.Create(name)
+			w.Write([]byte(content))
+		}
+		zw.Close()
+
+		documents, err := ExtractFromWheel(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.NoError(t, err)
+		if assert.Len(t, documents, 1) {
+			assert.Equal(t, cycloneDXContent, string(documents[0]))
+		}
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+var (
+	ErrInvalidName         = util.NewInvalidArgumentErrorf("package name is invalid")
+	ErrInvalidPlatform     = util.NewInvalidArgumentErrorf("package platform is invalid")
+	ErrInvalidProtocols    = util.NewInvalidArgumentErrorf("provider protocols are invalid")
+	ErrUnsupportedArchive  = util.NewInvalidArgumentErrorf("archive format is not supported")
+	ErrInvalidProviderFile = util.NewInvalidArgumentErrorf("provider package must be a zip archive")
+
+	// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#module-addresses
+	moduleNamePattern   = regexp.MustCompile(`\A[0-9A-Za-z](?:[0-9A-Za-z_-]{0,62}[0-9A-Za-z])?\z`)
+	moduleSystemPattern = regexp.MustCompile(`\A[0-9a-z]{1,64}\z`)
+	// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#provider-addresses
+	providerTypePattern = regexp.MustCompile(`\A[0-9a-z](?:[0-9a-z-]{0,62}[0-9a-z])?\z`)
+	platformPattern     = regexp.MustCompile(`\A[0-9a-z_]{1,32}\z`)
+	protocolPattern     = regexp.MustCompile(`\A\d+\.\d+\z`)
+)
+
+const (
+	PropertyOS   = "terraform.os"
+	PropertyArch = "terraform.arch"
+
+	SettingKeyPrivate = "terraform.key.private"
+	SettingKeyPublic  = "terraform.key.public"
+
+	KindModule   = "module"
+	KindProvider = "provider"
+
+	// DefaultProtocol is used if a provider is uploaded without protocol versions
+	DefaultProtocol = "5.0"
+)
+
+// Metadata represents the metadata of a Terraform module or provider version
+type Metadata struct {
+	Kind      string   `json:"kind"`
+	Protocols []string `json:"protocols,omitempty"`
+}
+
+// ModulePackageName returns the package name of a module, modules are addressed by name and target system
+func ModulePackageName(name, system string) (string, error) {
+	if !moduleNamePattern.MatchString(name) || !moduleSystemPattern.MatchString(system) {
+		return "", ErrInvalidName
+	}
+	return name + "/" + system, nil
+}
+
+// ProviderPackageName returns the package name of a provider which is its type
+func ProviderPackageName(providerType string) (string, error) {
+	if !providerTypePattern.MatchString(providerType) {
+		return "", ErrInvalidName
+	}
+	return providerType, nil
+}
+
+// ValidatePlatform checks the operating system and architecture of a provider package
+func ValidatePlatform(os, arch string) error {
+	if !platformPattern.MatchString(os) || !platformPattern.MatchString(arch) {
+		return ErrInvalidPlatform
+	}
+	return nil
+}
+
+// ParseProtocols parses a comma separated list of provider protocol versions like "5.0,6.0"
+func ParseProtocols(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return []string{DefaultProtocol}, nil
+	}
+
+	protocols := make([]string, 0, 2)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if !protocolPattern.MatchString(p) {
+			return nil, ErrInvalidProtocols
+		}
+		protocols = append(protocols, p)
+	}
+	return protocols, nil
+}
+
+// ModuleArchiveExtension detects the format of a module archive, Terraform only understands the extension of the download url
+func ModuleArchiveExtension(r io.Reader) (string, error) {
+	magic, err := bufio.NewReader(r).Peek(4)
+	if err != nil {
+		return "", ErrUnsupportedArchive
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case bytes.HasPrefix(magic, []byte{'P', 'K', 0x03, 0x04}):
+		return "zip", nil
+	}
+	return "", ErrUnsupportedArchive
+}
+
+// IsZipArchive checks if the content is a zip archive which is the only format of provider packages
+func IsZipArchive(r io.Reader) error {
+	ext, err := ModuleArchiveExtension(r)
+	if err != nil || ext != "zip" {
+		return ErrInvalidProviderFile
+	}
+	return nil
+}
+
+// ModuleFilename returns the name of the stored module archive
+func ModuleFilename(name, system, version, ext string) string {
+	return fmt.Sprintf("%s-%s-%s.%s", name, system, version, ext)
+}
+
+// ProviderFilename returns the name of a provider package like "terraform-provider-{type}_{version}_{os}_{arch}.zip"
+func ProviderFilename(providerType, version, os, arch string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_%s_%s.zip", providerType, version, os, arch)
+}
+
+// SHA256SumsFilename returns the name of the checksum file of a provider version
+func SHA256SumsFilename(providerType, version string) string {
+	return fmt.Sprintf("terraform-provider-%s_%s_SHA256SUMS", providerType, version)
+}
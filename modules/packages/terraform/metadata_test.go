@@ -0,0 +1,86 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageNames(t *testing.T) {
+	name, err := ModulePackageName("vpc", "aws")
+	assert.NoError(t, err)
+	assert.Equal(t, "vpc/aws", name)
+
+	for _, c := range [][2]string{{"", "aws"}, {"vpc", ""}, {"-vpc", "aws"}, {"vpc", "aws-cloud"}, {"vpc/x", "aws"}} {
+		_, err := ModulePackageName(c[0], c[1])
+		assert.ErrorIs(t, err, ErrInvalidName, "%v", c)
+	}
+
+	name, err = ProviderPackageName("random")
+	assert.NoError(t, err)
+	assert.Equal(t, "random", name)
+
+	for _, providerType := range []string{"", "Random", "random-", "ran/dom"} {
+		_, err := ProviderPackageName(providerType)
+		assert.ErrorIs(t, err, ErrInvalidName, providerType)
+	}
+}
+
+func TestValidatePlatform(t *testing.T) {
+	assert.NoError(t, ValidatePlatform("linux", "amd64"))
+	assert.NoError(t, ValidatePlatform("darwin", "arm64"))
+	assert.ErrorIs(t, ValidatePlatform("", "amd64"), ErrInvalidPlatform)
+	assert.ErrorIs(t, ValidatePlatform("linux", "../amd64"), ErrInvalidPlatform)
+}
+
+func TestParseProtocols(t *testing.T) {
+	protocols, err := ParseProtocols("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{DefaultProtocol}, protocols)
+
+	protocols, err = ParseProtocols("5.0, 6.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"5.0", "6.0"}, protocols)
+
+	_, err = ParseProtocols("5")
+	assert.ErrorIs(t, err, ErrInvalidProtocols)
+}
+
+func TestModuleArchiveExtension(t *testing.T) {
+	var gzBuf bytes.Buffer
+	gzw := gzip.NewWriter(&gzBuf)
+	gzw.Write([]byte("content"))
+	gzw.Close()
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	w, _ := zw.Create("main.tf")
+	w.Write([]byte("content"))
+	zw.Close()
+
+	ext, err := ModuleArchiveExtension(bytes.NewReader(gzBuf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, "tar.gz", ext)
+
+	ext, err = ModuleArchiveExtension(bytes.NewReader(zipBuf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, "zip", ext)
+
+	_, err = ModuleArchiveExtension(bytes.NewReader([]byte("plain text")))
+	assert.ErrorIs(t, err, ErrUnsupportedArchive)
+
+	assert.NoError(t, IsZipArchive(bytes.NewReader(zipBuf.Bytes())))
+	assert.ErrorIs(t, IsZipArchive(bytes.NewReader(gzBuf.Bytes())), ErrInvalidProviderFile)
+}
+
+func TestFilenames(t *testing.T) {
+	assert.Equal(t, "vpc-aws-1.0.0.tar.gz", ModuleFilename("vpc", "aws", "1.0.0", "tar.gz"))
+	assert.Equal(t, "terraform-provider-random_2.0.0_linux_amd64.zip", ProviderFilename("random", "2.0.0", "linux", "amd64"))
+	assert.Equal(t, "terraform-provider-random_2.0.0_SHA256SUMS", SHA256SumsFilename("random", "2.0.0"))
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
 )
 
 type GenerateTokenRequest struct {
@@ -23,3 +24,20 @@ func GenerateActionsRunnerToken(ctx context.Context, scope string) (*ResponseTex
 
 	return requestJSONResp(req, &ResponseText{})
 }
+
+type VerifyAttestationRequest struct {
+	Repo   string
+	Digest string
+}
+
+// VerifyActionsAttestation calls the internal VerifyActionsAttestation function
+func VerifyActionsAttestation(ctx context.Context, repo, digest string) (*api.ActionAttestationVerification, ResponseExtra) {
+	reqURL := setting.LocalURL + "api/internal/actions/verify_attestation"
+
+	req := newInternalRequest(ctx, reqURL, "POST", VerifyAttestationRequest{
+		Repo:   repo,
+		Digest: digest,
+	})
+
+	return requestJSONResp(req, &api.ActionAttestationVerification{})
+}
@@ -4,7 +4,9 @@
 package setting
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -20,11 +22,20 @@ var (
 		LogCompression        logCompression    `ini:"LOG_COMPRESSION"`
 		ArtifactStorage       *Storage          // how the created artifacts should be stored
 		ArtifactRetentionDays int64             `ini:"ARTIFACT_RETENTION_DAYS"`
+		CacheStorage          *Storage          // how the caches of `actions/cache` should be stored
+		CacheRetentionDays    int64             `ini:"CACHE_RETENTION_DAYS"`
+		CacheMaxSize          int64             `ini:"-"` // the max total size of the caches of a repository, -1 means no limit
+		CacheMaxEntrySize     int64             `ini:"-"` // the max size of a cache, -1 means no limit
 		DefaultActionsURL     defaultActionsURL `ini:"DEFAULT_ACTIONS_URL"`
 		ZombieTaskTimeout     time.Duration     `ini:"ZOMBIE_TASK_TIMEOUT"`
 		EndlessTaskTimeout    time.Duration     `ini:"ENDLESS_TASK_TIMEOUT"`
 		AbandonedJobTimeout   time.Duration     `ini:"ABANDONED_JOB_TIMEOUT"`
 		SkipWorkflowStrings   []string          `ìni:"SKIP_WORKFLOW_STRINGS"`
+		SecretProvider        secretProvider    `ini:"SECRET_PROVIDER"`       // where the secrets of the tasks are read from
+		SecretProviderURL     string            `ini:"SECRET_PROVIDER_URL"`   // the base URL of the key-value store of the "http" provider
+		SecretProviderToken   string            `ini:"SECRET_PROVIDER_TOKEN"` // the token sent to the key-value store of the "http" provider
+		SecretProviderFile    string            `ini:"SECRET_PROVIDER_FILE"`  // the JSON file of the "file" provider
+		SecretProviderTimeout time.Duration     `ini:"SECRET_PROVIDER_TIMEOUT"`
 	}{
 		Enabled:             true,
 		DefaultActionsURL:   defaultActionsURLGitHub,
@@ -55,6 +66,19 @@ const (
 	// please consider to use `uses: https://the_url_you_want_to_use/username/action_name@version` instead.
 )
 
+type secretProvider string
+
+const (
+	SecretProviderDB   secretProvider = "db"   // the secrets are encrypted and stored in the database
+	SecretProviderHTTP secretProvider = "http" // the secrets are read from a Vault-style key-value store over HTTP
+	SecretProviderFile secretProvider = "file" // the secrets are read from a JSON file, it's a stand-in of a key-value store for testing
+)
+
+// IsExternal returns whether the secrets are read from an external store instead of the database
+func (p secretProvider) IsExternal() bool {
+	return p != SecretProviderDB
+}
+
 type logCompression string
 
 func (c logCompression) IsValid() bool {
@@ -111,10 +135,50 @@ func loadActionsFrom(rootCfg ConfigProvider) error {
 		Actions.ArtifactRetentionDays = 90
 	}
 
+	Actions.CacheStorage, err = getStorage(rootCfg, "actions_cache", "", nil)
+	if err != nil {
+		return err
+	}
+
+	// default to 7 days in Github Actions
+	if Actions.CacheRetentionDays <= 0 {
+		Actions.CacheRetentionDays = 7
+	}
+	// default to 10 GiB in Github Actions
+	Actions.CacheMaxSize = 10 * 1024 * 1024 * 1024
+	if sec.HasKey("CACHE_MAX_SIZE") {
+		Actions.CacheMaxSize = mustBytes(sec, "CACHE_MAX_SIZE")
+	}
+	Actions.CacheMaxEntrySize = 10 * 1024 * 1024 * 1024
+	if sec.HasKey("CACHE_MAX_ENTRY_SIZE") {
+		Actions.CacheMaxEntrySize = mustBytes(sec, "CACHE_MAX_ENTRY_SIZE")
+	}
+
 	Actions.ZombieTaskTimeout = sec.Key("ZOMBIE_TASK_TIMEOUT").MustDuration(10 * time.Minute)
 	Actions.EndlessTaskTimeout = sec.Key("ENDLESS_TASK_TIMEOUT").MustDuration(3 * time.Hour)
 	Actions.AbandonedJobTimeout = sec.Key("ABANDONED_JOB_TIMEOUT").MustDuration(24 * time.Hour)
 
+	if Actions.SecretProvider == "" {
+		Actions.SecretProvider = SecretProviderDB
+	}
+	switch Actions.SecretProvider {
+	case SecretProviderDB:
+	case SecretProviderHTTP:
+		if Actions.SecretProviderURL == "" {
+			return errors.New("[actions] SECRET_PROVIDER_URL is required for the http secret provider")
+		}
+	case SecretProviderFile:
+		if Actions.SecretProviderFile == "" {
+			return errors.New("[actions] SECRET_PROVIDER_FILE is required for the file secret provider")
+		}
+		if !filepath.IsAbs(Actions.SecretProviderFile) {
+			Actions.SecretProviderFile = filepath.Join(AppDataPath, Actions.SecretProviderFile)
+		}
+	default:
+		return fmt.Errorf("unsupported [actions] SECRET_PROVIDER: %q", Actions.SecretProvider)
+	}
+	Actions.SecretProviderTimeout = sec.Key("SECRET_PROVIDER_TIMEOUT").MustDuration(10 * time.Second)
+
 	if !Actions.LogCompression.IsValid() {
 		return fmt.Errorf("invalid [actions] LOG_COMPRESSION: %q", Actions.LogCompression)
 	}
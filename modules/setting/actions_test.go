@@ -24,6 +24,8 @@ func Test_getStorageInheritNameSectionTypeForActions(t *testing.T) {
 	assert.EqualValues(t, "actions_log/", Actions.LogStorage.MinioConfig.BasePath)
 	assert.EqualValues(t, "minio", Actions.ArtifactStorage.Type)
 	assert.EqualValues(t, "actions_artifacts/", Actions.ArtifactStorage.MinioConfig.BasePath)
+	assert.EqualValues(t, "minio", Actions.CacheStorage.Type)
+	assert.EqualValues(t, "actions_cache/", Actions.CacheStorage.MinioConfig.BasePath)
 
 	iniStr = `
 [storage.actions_log]
@@ -97,6 +99,39 @@ STORAGE_TYPE = minio
 	assert.EqualValues(t, "actions_artifacts", filepath.Base(Actions.ArtifactStorage.Path))
 }
 
+func Test_getCacheSettingsForActions(t *testing.T) {
+	cfg, err := NewConfigProviderFromData(``)
+	require.NoError(t, err)
+	require.NoError(t, loadActionsFrom(cfg))
+	assert.EqualValues(t, "local", Actions.CacheStorage.Type)
+	assert.EqualValues(t, "actions_cache", filepath.Base(Actions.CacheStorage.Path))
+	assert.EqualValues(t, 7, Actions.CacheRetentionDays)
+	assert.EqualValues(t, 10*1024*1024*1024, Actions.CacheMaxSize)
+	assert.EqualValues(t, 10*1024*1024*1024, Actions.CacheMaxEntrySize)
+
+	cfg, err = NewConfigProviderFromData(`
+[actions]
+CACHE_RETENTION_DAYS = 3
+CACHE_MAX_SIZE = 1 GiB
+CACHE_MAX_ENTRY_SIZE = 100 MiB
+`)
+	require.NoError(t, err)
+	require.NoError(t, loadActionsFrom(cfg))
+	assert.EqualValues(t, 3, Actions.CacheRetentionDays)
+	assert.EqualValues(t, 1024*1024*1024, Actions.CacheMaxSize)
+	assert.EqualValues(t, 100*1024*1024, Actions.CacheMaxEntrySize)
+
+	cfg, err = NewConfigProviderFromData(`
+[actions]
+CACHE_MAX_SIZE = -1
+CACHE_MAX_ENTRY_SIZE = -1
+`)
+	require.NoError(t, err)
+	require.NoError(t, loadActionsFrom(cfg))
+	assert.EqualValues(t, -1, Actions.CacheMaxSize)
+	assert.EqualValues(t, -1, Actions.CacheMaxEntrySize)
+}
+
 func Test_getDefaultActionsURLForActions(t *testing.T) {
 	oldActions := Actions
 	oldAppURL := AppURL
@@ -6,8 +6,13 @@ package setting
 import (
 	"fmt"
 	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/dustin/go-humanize"
 )
@@ -22,6 +27,7 @@ var (
 		LimitTotalOwnerCount int64
 		LimitTotalOwnerSize  int64
 		LimitSizeAlpine      int64
+		LimitSizeArch        int64
 		LimitSizeCargo       int64
 		LimitSizeChef        int64
 		LimitSizeComposer    int64
@@ -41,16 +47,105 @@ var (
 		LimitSizeRpm         int64
 		LimitSizeRubyGems    int64
 		LimitSizeSwift       int64
+		LimitSizeTerraform   int64
 		LimitSizeVagrant     int64
 
 		DefaultRPMSignEnabled bool
+
+		ContainerProxyTagTTL    time.Duration             `ini:"-"`
+		ContainerProxyTimeout   time.Duration             `ini:"-"`
+		ContainerProxyUpstreams []*ContainerProxyUpstream `ini:"-"`
+
+		Upstreams map[string]*PackageUpstream `ini:"-"`
 	}{
 		Enabled:              true,
 		LimitTotalOwnerCount: -1,
 	}
 )
 
+// ContainerProxyUpstream is a registry which organizations can use as pull-through cache
+type ContainerProxyUpstream struct {
+	Name          string
+	URL           string
+	TokenURL      string // the authorization service which gets the credentials if it's not on the host of the registry
+	Username      string
+	Password      string
+	AllowedOwners []string // the lower names of the owners which can use the upstream if it has credentials
+}
+
+// HasCredentials returns whether the credentials of the upstream are sent to the registry
+func (u *ContainerProxyUpstream) HasCredentials() bool {
+	return u.Username != "" || u.Password != ""
+}
+
+// IsAllowedOwner returns whether the owner can use the upstream,
+// the upstreams with credentials can only be used by the owners allowed by the administrator.
+func (u *ContainerProxyUpstream) IsAllowedOwner(ownerName string) bool {
+	return !u.HasCredentials() || slices.Contains(u.AllowedOwners, strings.ToLower(ownerName))
+}
+
+// the name is used as first part of the image name and must be a valid path component
+var containerProxyNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*\z`)
+
+// GetContainerProxyUpstream returns the configured upstream registry with the given name
+func GetContainerProxyUpstream(name string) *ContainerProxyUpstream {
+	for _, upstream := range Packages.ContainerProxyUpstreams {
+		if upstream.Name == name {
+			return upstream
+		}
+	}
+	return nil
+}
+
+// PackageUpstream is a public registry which is merged into the registry of every owner
+// Packages missing in the registry of the owner are fetched from the upstream registry and cached.
+type PackageUpstream struct {
+	URL             string
+	Username        string
+	Password        string
+	AllowedHosts    string // the other hosts which the files linked to by the upstream index may be fetched from
+	Timeout         time.Duration
+	MaxFileSize     int64 // the max size of a file fetched from the upstream registry, -1 means no limit
+	AllowedPatterns []*GlobMatcher
+	BlockedPatterns []*GlobMatcher
+}
+
+// upstreamPackageTypes are the package types which support an upstream registry
+var upstreamPackageTypes = []string{"maven", "npm", "pypi"}
+
+// GetPackageUpstream returns the upstream registry configured for the package type
+func GetPackageUpstream(packageType string) *PackageUpstream {
+	return Packages.Upstreams[packageType]
+}
+
+// IsPackageAllowed checks if the package may be fetched from the upstream registry
+// A package is allowed if it matches no blocked pattern and, if allowed patterns are configured, one of them.
+func (u *PackageUpstream) IsPackageAllowed(name string) bool {
+	name = strings.ToLower(name)
+	for _, g := range u.BlockedPatterns {
+		if g.Match(name) {
+			return false
+		}
+	}
+	if len(u.AllowedPatterns) == 0 {
+		return true
+	}
+	for _, g := range u.AllowedPatterns {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
 func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
+	if err := loadContainerProxyFrom(rootCfg); err != nil {
+		return err
+	}
+	if err := loadPackageUpstreamsFrom(rootCfg); err != nil {
+		return err
+	}
+
 	sec, _ := rootCfg.GetSection("packages")
 	if sec == nil {
 		Packages.Storage, err = getStorage(rootCfg, "packages", "", nil)
@@ -79,6 +174,7 @@ func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
 
 	Packages.LimitTotalOwnerSize = mustBytes(sec, "LIMIT_TOTAL_OWNER_SIZE")
 	Packages.LimitSizeAlpine = mustBytes(sec, "LIMIT_SIZE_ALPINE")
+	Packages.LimitSizeArch = mustBytes(sec, "LIMIT_SIZE_ARCH")
 	Packages.LimitSizeCargo = mustBytes(sec, "LIMIT_SIZE_CARGO")
 	Packages.LimitSizeChef = mustBytes(sec, "LIMIT_SIZE_CHEF")
 	Packages.LimitSizeComposer = mustBytes(sec, "LIMIT_SIZE_COMPOSER")
@@ -98,11 +194,112 @@ func loadPackagesFrom(rootCfg ConfigProvider) (err error) {
 	Packages.LimitSizeRpm = mustBytes(sec, "LIMIT_SIZE_RPM")
 	Packages.LimitSizeRubyGems = mustBytes(sec, "LIMIT_SIZE_RUBYGEMS")
 	Packages.LimitSizeSwift = mustBytes(sec, "LIMIT_SIZE_SWIFT")
+	Packages.LimitSizeTerraform = mustBytes(sec, "LIMIT_SIZE_TERRAFORM")
 	Packages.LimitSizeVagrant = mustBytes(sec, "LIMIT_SIZE_VAGRANT")
 	Packages.DefaultRPMSignEnabled = sec.Key("DEFAULT_RPM_SIGN_ENABLED").MustBool(false)
 	return nil
 }
 
+func loadContainerProxyFrom(rootCfg ConfigProvider) error {
+	sec := rootCfg.Section("packages.container_proxy")
+
+	Packages.ContainerProxyTagTTL = sec.Key("TAG_TTL").MustDuration(24 * time.Hour)
+	Packages.ContainerProxyTimeout = sec.Key("TIMEOUT").MustDuration(10 * time.Minute)
+	Packages.ContainerProxyUpstreams = nil
+
+	for _, name := range sec.Key("UPSTREAMS").Strings(",") {
+		if !containerProxyNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid container proxy upstream name: %s", name)
+		}
+		if GetContainerProxyUpstream(name) != nil {
+			return fmt.Errorf("duplicate container proxy upstream: %s", name)
+		}
+
+		upstreamSec := rootCfg.Section("packages.container_proxy." + name)
+
+		upstreamURL := strings.TrimSuffix(upstreamSec.Key("URL").String(), "/")
+		if u, err := url.Parse(upstreamURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid URL for container proxy upstream %s: %q", name, upstreamURL)
+		}
+
+		tokenURL := upstreamSec.Key("TOKEN_URL").String()
+		if tokenURL != "" {
+			if u, err := url.Parse(tokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid token URL for container proxy upstream %s: %q", name, tokenURL)
+			}
+		}
+
+		allowedOwners := upstreamSec.Key("ALLOWED_OWNERS").Strings(",")
+		for i, owner := range allowedOwners {
+			allowedOwners[i] = strings.ToLower(owner)
+		}
+
+		Packages.ContainerProxyUpstreams = append(Packages.ContainerProxyUpstreams, &ContainerProxyUpstream{
+			Name:          name,
+			URL:           upstreamURL,
+			TokenURL:      tokenURL,
+			Username:      upstreamSec.Key("USERNAME").String(),
+			Password:      upstreamSec.Key("PASSWORD").String(),
+			AllowedOwners: allowedOwners,
+		})
+	}
+	return nil
+}
+
+func loadPackageUpstreamsFrom(rootCfg ConfigProvider) error {
+	Packages.Upstreams = make(map[string]*PackageUpstream)
+
+	for _, packageType := range upstreamPackageTypes {
+		sec, _ := rootCfg.GetSection("packages.upstream." + packageType)
+		if sec == nil {
+			continue
+		}
+
+		upstreamURL := strings.TrimSuffix(sec.Key("URL").String(), "/")
+		if upstreamURL == "" {
+			continue
+		}
+		if u, err := url.Parse(upstreamURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid URL for %s upstream registry: %q", packageType, upstreamURL)
+		}
+
+		upstream := &PackageUpstream{
+			URL:          upstreamURL,
+			Username:     sec.Key("USERNAME").String(),
+			Password:     sec.Key("PASSWORD").String(),
+			AllowedHosts: sec.Key("ALLOWED_HOSTS").String(),
+			Timeout:      sec.Key("TIMEOUT").MustDuration(10 * time.Minute),
+			MaxFileSize:  1024 * 1024 * 1024,
+		}
+		if sec.HasKey("MAX_FILE_SIZE") {
+			upstream.MaxFileSize = mustBytes(sec, "MAX_FILE_SIZE")
+		}
+
+		var err error
+		if upstream.AllowedPatterns, err = compilePackageNamePatterns(sec.Key("ALLOWED_PATTERNS").Strings(",")); err != nil {
+			return fmt.Errorf("invalid ALLOWED_PATTERNS for %s upstream registry: %w", packageType, err)
+		}
+		if upstream.BlockedPatterns, err = compilePackageNamePatterns(sec.Key("BLOCKED_PATTERNS").Strings(",")); err != nil {
+			return fmt.Errorf("invalid BLOCKED_PATTERNS for %s upstream registry: %w", packageType, err)
+		}
+
+		Packages.Upstreams[packageType] = upstream
+	}
+	return nil
+}
+
+func compilePackageNamePatterns(patterns []string) ([]*GlobMatcher, error) {
+	globs := make([]*GlobMatcher, 0, len(patterns))
+	for _, pattern := range patterns {
+		g, err := GlobMatcherCompile(strings.ToLower(pattern))
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
+
 func mustBytes(section ConfigSection, key string) int64 {
 	const noLimit = "-1"
 
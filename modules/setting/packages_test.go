@@ -5,6 +5,7 @@ package setting
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -196,3 +197,102 @@ MINIO_SECRET_ACCESS_KEY = correct_key
 	assert.EqualValues(t, "my_packages/", storage.MinioConfig.BasePath)
 	assert.True(t, storage.MinioConfig.ServeDirect)
 }
+
+func TestLoadContainerProxy(t *testing.T) {
+	cfg, err := NewConfigProviderFromData(`
+[packages.container_proxy]
+TAG_TTL = 1h
+UPSTREAMS = dockerhub, quay
+
+[packages.container_proxy.dockerhub]
+URL = https://registry-1.docker.io/
+TOKEN_URL = https://auth.docker.io/token
+USERNAME = user
+PASSWORD = secret
+ALLOWED_OWNERS = org3, User2
+
+[packages.container_proxy.quay]
+URL = https://quay.io
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, loadContainerProxyFrom(cfg))
+
+	assert.Equal(t, time.Hour, Packages.ContainerProxyTagTTL)
+	assert.Len(t, Packages.ContainerProxyUpstreams, 2)
+	assert.Equal(t, &ContainerProxyUpstream{Name: "dockerhub", URL: "https://registry-1.docker.io", TokenURL: "https://auth.docker.io/token", Username: "user", Password: "secret", AllowedOwners: []string{"org3", "user2"}}, GetContainerProxyUpstream("dockerhub"))
+	assert.Equal(t, "https://quay.io", GetContainerProxyUpstream("quay").URL)
+
+	// the upstreams with credentials can only be used by the allowed owners
+	assert.True(t, GetContainerProxyUpstream("dockerhub").IsAllowedOwner("User2"))
+	assert.False(t, GetContainerProxyUpstream("dockerhub").IsAllowedOwner("org17"))
+	assert.True(t, GetContainerProxyUpstream("quay").IsAllowedOwner("org17"))
+	assert.Nil(t, GetContainerProxyUpstream("ghcr"))
+
+	for _, data := range []string{
+		"[packages.container_proxy]\nUPSTREAMS = Docker\n[packages.container_proxy.Docker]\nURL = https://example.com",
+		"[packages.container_proxy]\nUPSTREAMS = docker\n[packages.container_proxy.docker]\nURL = ftp://example.com",
+		"[packages.container_proxy]\nUPSTREAMS = docker,docker\n[packages.container_proxy.docker]\nURL = https://example.com",
+		"[packages.container_proxy]\nUPSTREAMS = docker\n[packages.container_proxy.docker]\nURL = https://example.com\nTOKEN_URL = example.com/token",
+	} {
+		cfg, err := NewConfigProviderFromData(data)
+		assert.NoError(t, err)
+		assert.Error(t, loadContainerProxyFrom(cfg), data)
+	}
+
+	cfg, err = NewConfigProviderFromData("")
+	assert.NoError(t, err)
+	assert.NoError(t, loadContainerProxyFrom(cfg))
+	assert.Equal(t, 24*time.Hour, Packages.ContainerProxyTagTTL)
+	assert.Equal(t, 10*time.Minute, Packages.ContainerProxyTimeout)
+	assert.Empty(t, Packages.ContainerProxyUpstreams)
+}
+
+func TestLoadPackageUpstreams(t *testing.T) {
+	cfg, err := NewConfigProviderFromData(`
+[packages.upstream.npm]
+URL = https://registry.npmjs.org/
+BLOCKED_PATTERNS = @internal/*, evil-*
+
+[packages.upstream.pypi]
+URL = https://pypi.org
+ALLOWED_PATTERNS = requests, Django*
+ALLOWED_HOSTS = files.pythonhosted.org
+TIMEOUT = 1m
+MAX_FILE_SIZE = 100 MiB
+
+[packages.upstream.maven]
+URL =
+`)
+	assert.NoError(t, err)
+	assert.NoError(t, loadPackageUpstreamsFrom(cfg))
+
+	assert.Len(t, Packages.Upstreams, 2)
+	assert.Nil(t, GetPackageUpstream("maven"))
+
+	npm := GetPackageUpstream("npm")
+	assert.Equal(t, "https://registry.npmjs.org", npm.URL)
+	assert.True(t, npm.IsPackageAllowed("lodash"))
+	assert.True(t, npm.IsPackageAllowed("@types/node"))
+	assert.False(t, npm.IsPackageAllowed("@internal/secret"))
+	assert.False(t, npm.IsPackageAllowed("Evil-Package"))
+	assert.Empty(t, npm.AllowedHosts)
+	assert.Equal(t, 10*time.Minute, npm.Timeout)
+	assert.EqualValues(t, 1024*1024*1024, npm.MaxFileSize)
+
+	pypi := GetPackageUpstream("pypi")
+	assert.True(t, pypi.IsPackageAllowed("requests"))
+	assert.True(t, pypi.IsPackageAllowed("django-rest"))
+	assert.False(t, pypi.IsPackageAllowed("numpy"))
+	assert.Equal(t, "files.pythonhosted.org", pypi.AllowedHosts)
+	assert.Equal(t, time.Minute, pypi.Timeout)
+	assert.EqualValues(t, 100*1024*1024, pypi.MaxFileSize)
+
+	for _, data := range []string{
+		"[packages.upstream.npm]\nURL = ftp://example.com",
+		"[packages.upstream.npm]\nURL = https://example.com\nALLOWED_PATTERNS = [a",
+	} {
+		cfg, err := NewConfigProviderFromData(data)
+		assert.NoError(t, err)
+		assert.Error(t, loadPackageUpstreamsFrom(cfg), data)
+	}
+}
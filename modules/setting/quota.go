@@ -0,0 +1,20 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+// Quota settings
+var Quota = struct {
+	Enabled bool
+	// DefaultGroups are the quota groups of the users and organizations which are not member of any group
+	DefaultGroups []string
+}{
+	Enabled:       false,
+	DefaultGroups: []string{},
+}
+
+func loadQuotaFrom(rootCfg ConfigProvider) {
+	sec := rootCfg.Section("quota")
+	Quota.Enabled = sec.Key("ENABLED").MustBool(false)
+	Quota.DefaultGroups = sec.Key("DEFAULT_GROUPS").Strings(",")
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import (
+	"time"
+)
+
+// ActionAttestationSubject represents an artifact which an attestation is about
+type ActionAttestationSubject struct {
+	Name string `json:"name"`
+	// the digest of the artifact like "sha256:{hex}", sha256 and sha512 are supported
+	Digest string `json:"digest"`
+}
+
+// CreateActionAttestationOption options to attest the artifacts built by the running job
+type CreateActionAttestationOption struct {
+	// the artifacts with the digests computed by the job, like the files of the packages
+	Subjects []*ActionAttestationSubject `json:"subjects"`
+	// the names of the artifacts uploaded by the run, the digests of their files are computed by the server
+	Artifacts []string `json:"artifacts"`
+}
+
+// ActionAttestationSignature represents a signature of a DSSE envelope
+type ActionAttestationSignature struct {
+	KeyID string `json:"keyid"`
+	// the signature encoded in base64
+	Sig string `json:"sig"`
+}
+
+// ActionAttestationEnvelope represents a DSSE envelope of an in-toto statement,
+// see https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
+type ActionAttestationEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	// the in-toto statement encoded in base64
+	Payload    string                        `json:"payload"`
+	Signatures []*ActionAttestationSignature `json:"signatures"`
+}
+
+// ActionAttestation represents a signed build provenance attestation of an artifact
+type ActionAttestation struct {
+	ID            int64                      `json:"id"`
+	RunID         int64                      `json:"run_id"`
+	Subject       *ActionAttestationSubject  `json:"subject"`
+	PredicateType string                     `json:"predicate_type"`
+	Envelope      *ActionAttestationEnvelope `json:"envelope"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+}
+
+// ActionAttestationVerification represents the result of verifying the attestations of an artifact
+type ActionAttestationVerification struct {
+	SubjectDigest string `json:"subject_digest"`
+	// whether any attestation of the artifact is verified
+	Verified bool                                   `json:"verified"`
+	Results  []*ActionAttestationVerificationResult `json:"results"`
+}
+
+// ActionAttestationVerificationResult represents the result of verifying an attestation,
+// the provenance is only filled if the attestation is verified
+type ActionAttestationVerificationResult struct {
+	AttestationID int64  `json:"attestation_id"`
+	Verified      bool   `json:"verified"`
+	Error         string `json:"error,omitempty"`
+	Repository    string `json:"repository,omitempty"`
+	Workflow      string `json:"workflow,omitempty"`
+	Ref           string `json:"ref,omitempty"`
+	CommitSHA     string `json:"commit_sha,omitempty"`
+	RunID         int64  `json:"run_id,omitempty"`
+	RunAttempt    int64  `json:"run_attempt,omitempty"`
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// ActionUsage represents the aggregated actions usage of a group of jobs
+type ActionUsage struct {
+	// the id of the repository, the owner or the user, it's 0 if the usage is grouped by labels
+	ID int64 `json:"id"`
+	// the full name of the repository, the name of the owner or the user, or the runs-on labels of the jobs
+	Name    string `json:"name"`
+	Jobs    int64  `json:"jobs"`
+	Seconds int64  `json:"seconds"`
+	// the billable minutes, the execution time of each job is rounded up to the whole minute
+	Minutes int64 `json:"minutes"`
+}
+
+// ActionJobUsage represents the actions usage of a finished job
+type ActionJobUsage struct {
+	TaskID     int64    `json:"task_id"`
+	RepoID     int64    `json:"repo_id"`
+	Repository string   `json:"repository"`
+	RunID      int64    `json:"run_id"`
+	JobID      int64    `json:"job_id"`
+	JobName    string   `json:"job_name"`
+	Labels     []string `json:"labels"`
+	RunnerID   int64    `json:"runner_id"`
+	Status     string   `json:"status"`
+	// swagger:strfmt date-time
+	StartedAt time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	StoppedAt time.Time `json:"stopped_at"`
+	Seconds   int64     `json:"seconds"`
+	Minutes   int64     `json:"minutes"`
+}
+
+// ActionQuota represents the actions quota of an owner and the current usage, a zero limit means unlimited
+type ActionQuota struct {
+	MaxMonthlyMinutes int64 `json:"max_monthly_minutes"`
+	MaxConcurrentJobs int64 `json:"max_concurrent_jobs"`
+	MaxArtifactBytes  int64 `json:"max_artifact_bytes"`
+	// the billable minutes used in the current month
+	MonthlyMinutes int64 `json:"monthly_minutes"`
+	// the number of the running jobs
+	ConcurrentJobs int64 `json:"concurrent_jobs"`
+	// the size of the stored artifacts
+	ArtifactBytes int64 `json:"artifact_bytes"`
+}
+
+// SetActionQuotaOption options when setting the actions quota of an owner, a zero limit means unlimited
+// swagger:model
+type SetActionQuotaOption struct {
+	// the new runs are refused when the billable minutes of the month reach it
+	MaxMonthlyMinutes int64 `json:"max_monthly_minutes"`
+	// the waiting jobs are queued when the running jobs reach it
+	MaxConcurrentJobs int64 `json:"max_concurrent_jobs"`
+	// the new artifacts are refused when the size of the stored artifacts reaches it
+	MaxArtifactBytes int64 `json:"max_artifact_bytes"`
+}
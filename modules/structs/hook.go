@@ -508,3 +508,40 @@ type WorkflowDispatchPayload struct {
 func (p *WorkflowDispatchPayload) JSONPayload() ([]byte, error) {
 	return json.MarshalIndent(p, "", "  ")
 }
+
+// HookWorkflowRunAction an action that happens to a workflow run or a workflow job
+type HookWorkflowRunAction string
+
+const (
+	// HookWorkflowRunInProgress in progress
+	HookWorkflowRunInProgress HookWorkflowRunAction = "in_progress"
+	// HookWorkflowRunCompleted completed
+	HookWorkflowRunCompleted HookWorkflowRunAction = "completed"
+)
+
+// WorkflowRunPayload represents a workflow run payload
+type WorkflowRunPayload struct {
+	Action      HookWorkflowRunAction `json:"action"`
+	Workflow    *ActionWorkflow       `json:"workflow"`
+	WorkflowRun *ActionWorkflowRun    `json:"workflow_run"`
+	Repository  *Repository           `json:"repository"`
+	Sender      *User                 `json:"sender"`
+}
+
+// JSONPayload implements Payload
+func (p *WorkflowRunPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
+
+// WorkflowJobPayload represents a workflow job payload
+type WorkflowJobPayload struct {
+	Action      HookWorkflowRunAction `json:"action"`
+	WorkflowJob *ActionWorkflowJob    `json:"workflow_job"`
+	Repository  *Repository           `json:"repository"`
+	Sender      *User                 `json:"sender"`
+}
+
+// JSONPayload implements Payload
+func (p *WorkflowJobPayload) JSONPayload() ([]byte, error) {
+	return json.MarshalIndent(p, "", "  ")
+}
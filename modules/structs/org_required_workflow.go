@@ -0,0 +1,52 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// RequiredWorkflow represents a workflow required by an organization to run on its repositories
+type RequiredWorkflow struct {
+	ID int64 `json:"id"`
+	// the central repository which has the workflow file
+	Repository *Repository `json:"repository"`
+	// the path of the workflow file in the central repository, like ".gitea/workflows/scan.yml"
+	WorkflowPath string `json:"workflow_path"`
+	// the branch to read the workflow file from, the default branch is used if it's empty
+	Ref string `json:"ref"`
+	// the glob patterns of the names of the repositories to run on, all repositories are matched if it's empty
+	RepoPatterns []string `json:"repo_patterns"`
+	// the name of the workflow, the commit status contexts created by its runs start with it
+	WorkflowName string `json:"workflow_name"`
+	// whether the commit statuses of the workflow are required by the protected branches of the matched repositories
+	EnforceStatusCheck bool `json:"enforce_status_check"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateRequiredWorkflowOption options when registering a required workflow
+// swagger:model
+type CreateRequiredWorkflowOption struct {
+	// the name of the central repository of the organization
+	//
+	// required: true
+	Repository string `json:"repository" binding:"Required"`
+	// the path of the workflow file in the central repository
+	//
+	// required: true
+	WorkflowPath string   `json:"workflow_path" binding:"Required"`
+	Ref          string   `json:"ref"`
+	RepoPatterns []string `json:"repo_patterns"`
+	// whether the commit statuses of the workflow are required by the protected branches of the matched repositories
+	EnforceStatusCheck bool `json:"enforce_status_check"`
+}
+
+// EditRequiredWorkflowOption options when editing a required workflow
+// swagger:model
+type EditRequiredWorkflowOption struct {
+	Ref                *string   `json:"ref"`
+	RepoPatterns       *[]string `json:"repo_patterns"`
+	EnforceStatusCheck *bool     `json:"enforce_status_check"`
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// ActionRunnerGroup represents a group of the runners of an organization
+type ActionRunnerGroup struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// the glob patterns of the names of the repositories which could use the runners, all repositories are allowed if it's empty
+	RepoPatterns []string `json:"repo_patterns"`
+	// the glob patterns of the workflow files which could use the runners, like "deploy-*.yml", all workflows are allowed if it's empty
+	WorkflowPatterns []string `json:"workflow_patterns"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateActionRunnerGroupOption options when creating a runner group
+// swagger:model
+type CreateActionRunnerGroupOption struct {
+	// required: true
+	Name             string   `json:"name" binding:"Required;MaxSize(255)"`
+	RepoPatterns     []string `json:"repo_patterns"`
+	WorkflowPatterns []string `json:"workflow_patterns"`
+}
+
+// EditActionRunnerGroupOption options when editing a runner group
+// swagger:model
+type EditActionRunnerGroupOption struct {
+	Name             *string   `json:"name" binding:"MaxSize(255)"`
+	RepoPatterns     *[]string `json:"repo_patterns"`
+	WorkflowPatterns *[]string `json:"workflow_patterns"`
+}
@@ -17,6 +17,8 @@ type Package struct {
 	Name       string      `json:"name"`
 	Version    string      `json:"version"`
 	HTMLURL    string      `json:"html_url"`
+	// number of known vulnerabilities affecting the package version or the components listed in its SBOM documents
+	VulnerabilityCount int64 `json:"vulnerability_count"`
 	// swagger:strfmt date-time
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -31,3 +33,49 @@ type PackageFile struct {
 	HashSHA256 string `json:"sha256"`
 	HashSHA512 string `json:"sha512"`
 }
+
+// PackageSBOM represents a SBOM document attached to a package version
+type PackageSBOM struct {
+	// enum: cyclonedx,spdx
+	Format     string `json:"format"`
+	Size       int64  `json:"size"`
+	HashSHA256 string `json:"sha256"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PackageVulnerability represents a known vulnerability affecting a package version
+type PackageVulnerability struct {
+	// identifier of the vulnerability in the OSV database
+	ID      string   `json:"id"`
+	Aliases []string `json:"aliases"`
+	Summary string   `json:"summary"`
+	// severity as provided by the vulnerability database, may be empty
+	Severity string `json:"severity"`
+	// affected component listed in a SBOM document, empty if the package itself is affected
+	Component string `json:"component"`
+	URL       string `json:"url"`
+	// swagger:strfmt date-time
+	PublishedAt time.Time `json:"published_at"`
+	// swagger:strfmt date-time
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// PackageAccess represents the access of a user or a team to a package
+type PackageAccess struct {
+	User *User `json:"user,omitempty"`
+	Team *Team `json:"team,omitempty"`
+	// enum: read,write,admin
+	Permission string `json:"permission"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SetPackageAccessOption options for granting a user or a team access to a package
+type SetPackageAccessOption struct {
+	// required: true
+	// enum: read,write,admin
+	Permission string `json:"permission" binding:"Required;In(read,write,admin)"`
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+// QuotaInfo represents the storage quota of a user or an organization and its current usage
+type QuotaInfo struct {
+	// the names of the quota groups which apply to the owner
+	Groups []string `json:"groups"`
+	// the rules of the owner which override the rules of its groups
+	Rules []*QuotaRule `json:"rules"`
+	// the effective limits in bytes by subject, the subjects without limit are missing
+	Limits map[string]int64 `json:"limits"`
+	// the used storage in bytes by subject
+	Used map[string]int64 `json:"used"`
+}
+
+// QuotaRule limits the storage of a subject
+type QuotaRule struct {
+	// enum: total,git,lfs,packages,attachments,artifacts
+	Subject string `json:"subject"`
+	// the maximum size in bytes, a negative limit means unlimited
+	Limit int64 `json:"limit"`
+}
+
+// QuotaGroup represents a named set of quota rules shared by its members
+type QuotaGroup struct {
+	Name string `json:"name"`
+	// the names of the member users and organizations
+	Members []string     `json:"members"`
+	Rules   []*QuotaRule `json:"rules"`
+}
+
+// CreateQuotaGroupOption options when creating a quota group
+// swagger:model
+type CreateQuotaGroupOption struct {
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+}
+
+// SetQuotaRuleOption options when setting a quota rule
+// swagger:model
+type SetQuotaRuleOption struct {
+	// the maximum size in bytes, a negative limit means unlimited
+	Limit int64 `json:"limit"`
+}
@@ -32,3 +32,184 @@ type ActionTaskResponse struct {
 	Entries    []*ActionTask `json:"workflow_runs"`
 	TotalCount int64         `json:"total_count"`
 }
+
+// ActionWorkflow represents a workflow of a repository
+type ActionWorkflow struct {
+	// the file name of the workflow
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ActionWorkflowRun represents a run of a workflow
+type ActionWorkflowRun struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	DisplayTitle string `json:"display_title"`
+	HeadBranch   string `json:"head_branch"`
+	HeadSHA      string `json:"head_sha"`
+	RunNumber    int64  `json:"run_number"`
+	Event        string `json:"event"`
+	// enum: queued,waiting,in_progress,completed
+	Status string `json:"status"`
+	// enum: success,failure,cancelled,skipped
+	Conclusion      string `json:"conclusion,omitempty"`
+	URL             string `json:"url"`
+	HTMLURL         string `json:"html_url"`
+	JobsURL         string `json:"jobs_url"`
+	CancelURL       string `json:"cancel_url"`
+	RerunURL        string `json:"rerun_url"`
+	NeedApproval    bool   `json:"need_approval"`
+	Actor           *User  `json:"actor"`
+	TriggeringActor *User  `json:"triggering_actor"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	RunStartedAt *time.Time `json:"run_started_at"`
+}
+
+// ActionWorkflowRunsResponse returns ActionWorkflowRuns
+type ActionWorkflowRunsResponse struct {
+	Entries    []*ActionWorkflowRun `json:"workflow_runs"`
+	TotalCount int64                `json:"total_count"`
+}
+
+// ActionWorkflowStep represents a step of a ActionWorkflowJob
+type ActionWorkflowStep struct {
+	Name   string `json:"name"`
+	Number int64  `json:"number"`
+	// enum: queued,waiting,in_progress,completed
+	Status string `json:"status"`
+	// enum: success,failure,cancelled,skipped
+	Conclusion string `json:"conclusion,omitempty"`
+	// swagger:strfmt date-time
+	StartedAt *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// ActionWorkflowJob represents a job of a ActionWorkflowRun
+type ActionWorkflowJob struct {
+	ID         int64  `json:"id"`
+	RunID      int64  `json:"run_id"`
+	RunURL     string `json:"run_url"`
+	RunAttempt int64  `json:"run_attempt"`
+	HeadSHA    string `json:"head_sha"`
+	HeadBranch string `json:"head_branch"`
+	URL        string `json:"url"`
+	HTMLURL    string `json:"html_url"`
+	// enum: queued,waiting,in_progress,completed
+	Status string `json:"status"`
+	// enum: success,failure,cancelled,skipped
+	Conclusion   string                `json:"conclusion,omitempty"`
+	Name         string                `json:"name"`
+	WorkflowName string                `json:"workflow_name"`
+	Steps        []*ActionWorkflowStep `json:"steps"`
+	Labels       []string              `json:"labels"`
+	RunnerID     int64                 `json:"runner_id,omitempty"`
+	RunnerName   string                `json:"runner_name,omitempty"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	StartedAt *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// ActionJobStepSummary represents the summary written by a step of a job to $GITHUB_STEP_SUMMARY
+type ActionJobStepSummary struct {
+	StepIndex int64  `json:"step_index"`
+	StepName  string `json:"step_name"`
+	// the markdown written by the step
+	Content string `json:"content"`
+	// the sanitized HTML rendered from the markdown
+	ContentHTML string `json:"content_html"`
+}
+
+// ActionJobLogLine represents a line of the log of a job
+type ActionJobLogLine struct {
+	// the number of the line in the step, starting at 1
+	Index   int64  `json:"index"`
+	Message string `json:"message"`
+	// the unix time of the line in seconds
+	Timestamp float64 `json:"timestamp"`
+}
+
+// ActionJobStepLogs represents the log lines of a step of a job
+type ActionJobStepLogs struct {
+	// the index of the step, the first step is the "Set up job" step added by Gitea
+	Step int `json:"step"`
+	// the number of the lines of the step which have been sent
+	Cursor int64 `json:"cursor"`
+	// the unix time when the step started
+	Started int64               `json:"started"`
+	Lines   []*ActionJobLogLine `json:"lines"`
+}
+
+// ActionJobLogChunk represents the log lines sent by an event of the log stream of a job
+type ActionJobLogChunk struct {
+	// the number of the lines of the job which have been sent, the stream could be resumed from it
+	Offset int64                `json:"offset"`
+	Steps  []*ActionJobStepLogs `json:"steps"`
+}
+
+// ActionWorkflowJobsResponse returns ActionWorkflowJobs
+type ActionWorkflowJobsResponse struct {
+	Entries    []*ActionWorkflowJob `json:"jobs"`
+	TotalCount int64                `json:"total_count"`
+}
+
+// ActionRunner represents a runner of actions
+type ActionRunner struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// enum: offline,idle,active
+	Status  string   `json:"status"`
+	Version string   `json:"version"`
+	Labels  []string `json:"labels"`
+	// an ephemeral runner runs only one job and is deleted after the job is done
+	Ephemeral bool `json:"ephemeral"`
+	// the id of the job a just-in-time runner is bound to, it's 0 if the runner could run any job
+	JobID int64 `json:"job_id"`
+	// the id of the runner group of an organization runner, it's 0 if the runner is not in a group
+	RunnerGroupID int64 `json:"runner_group_id"`
+}
+
+// GenerateRunnerJITConfigOption options when generating the config of a just-in-time runner
+// swagger:model
+type GenerateRunnerJITConfigOption struct {
+	// the name of the runner
+	//
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+	// the labels of the runner, the labels of the job are used if it's empty and the runner is bound to a job
+	Labels []string `json:"labels"`
+	// the id of the waiting job to bind the runner to, the runner could only run this job
+	JobID int64 `json:"job_id"`
+	// the id of the runner group to add the runner to, only available for organization runners
+	RunnerGroupID int64 `json:"runner_group_id"`
+}
+
+// RunnerJITConfig represents the config of a just-in-time runner
+type RunnerJITConfig struct {
+	Runner *ActionRunner `json:"runner"`
+	// the base64 encoded registration file of the runner, decode it to the `.runner` file of act_runner and start the daemon
+	EncodedJITConfig string `json:"encoded_jit_config"`
+}
+
+// ActionWorkflowPermissions represents the default permissions of the tokens of the actions jobs
+type ActionWorkflowPermissions struct {
+	// the access of the tokens of the jobs which don't declare `permissions`,
+	// "read" allows reading the code and the packages only, and "write" allows writing all the units of the repository
+	// enum: read,write
+	DefaultWorkflowPermissions string `json:"default_workflow_permissions"`
+}
+
+// EditActionWorkflowPermissionsOption options when editing the default permissions of the tokens of the actions jobs
+// swagger:model
+type EditActionWorkflowPermissionsOption struct {
+	// the access of the tokens of the jobs which don't declare `permissions`, a repository uses the default of its owner if it's empty
+	// enum: read,write
+	DefaultWorkflowPermissions string `json:"default_workflow_permissions"`
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// CheckRun represents a check of a commit reported by a CI system or a linter
+type CheckRun struct {
+	ID         int64  `json:"id"`
+	HeadSHA    string `json:"head_sha"`
+	Name       string `json:"name"`
+	ExternalID string `json:"external_id"`
+	// enum: queued,in_progress,completed
+	Status string `json:"status"`
+	// enum: success,failure,neutral,cancelled,skipped,timed_out,action_required
+	Conclusion string          `json:"conclusion,omitempty"`
+	DetailsURL string          `json:"details_url"`
+	Output     *CheckRunOutput `json:"output"`
+	Creator    *User           `json:"creator"`
+	// swagger:strfmt date-time
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	// swagger:strfmt date-time
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CheckRunOutput represents the output of a check run
+type CheckRunOutput struct {
+	Title string `json:"title"`
+	// the summary of the check run in markdown
+	Summary          string `json:"summary"`
+	Text             string `json:"text"`
+	AnnotationsCount int64  `json:"annotations_count"`
+}
+
+// CheckRunAnnotation represents a finding of a check run on a range of lines of a file
+type CheckRunAnnotation struct {
+	Path        string `json:"path"`
+	StartLine   int64  `json:"start_line"`
+	EndLine     int64  `json:"end_line"`
+	StartColumn int64  `json:"start_column,omitempty"`
+	EndColumn   int64  `json:"end_column,omitempty"`
+	// enum: notice,warning,failure
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+	RawDetails      string `json:"raw_details"`
+}
+
+// CheckRunOutputOption options of the output of a check run, the annotations are appended to the existing ones
+type CheckRunOutputOption struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	Text    string `json:"text"`
+	// at most 50 annotations could be added by a request
+	Annotations []*CheckRunAnnotation `json:"annotations"`
+}
+
+// CreateCheckRunOption options when creating a check run
+// swagger:model
+type CreateCheckRunOption struct {
+	// required: true
+	Name string `json:"name" binding:"Required;MaxSize(255)"`
+	// required: true
+	HeadSHA    string `json:"head_sha" binding:"Required"`
+	DetailsURL string `json:"details_url" binding:"ValidUrl"`
+	ExternalID string `json:"external_id" binding:"MaxSize(255)"`
+	// enum: queued,in_progress,completed
+	Status string `json:"status"`
+	// required if the status is completed, the status is set to completed if it's provided
+	// enum: success,failure,neutral,cancelled,skipped,timed_out,action_required
+	Conclusion string `json:"conclusion"`
+	// swagger:strfmt date-time
+	StartedAt *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	CompletedAt *time.Time            `json:"completed_at"`
+	Output      *CheckRunOutputOption `json:"output"`
+}
+
+// UpdateCheckRunOption options when updating a check run
+// swagger:model
+type UpdateCheckRunOption struct {
+	Name       *string `json:"name" binding:"OmitEmpty;MaxSize(255)"`
+	DetailsURL *string `json:"details_url"`
+	ExternalID *string `json:"external_id" binding:"OmitEmpty;MaxSize(255)"`
+	// enum: queued,in_progress,completed
+	Status *string `json:"status"`
+	// enum: success,failure,neutral,cancelled,skipped,timed_out,action_required
+	Conclusion *string `json:"conclusion"`
+	// swagger:strfmt date-time
+	StartedAt *time.Time `json:"started_at"`
+	// swagger:strfmt date-time
+	CompletedAt *time.Time            `json:"completed_at"`
+	Output      *CheckRunOutputOption `json:"output"`
+}
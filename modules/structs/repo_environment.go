@@ -0,0 +1,83 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package structs
+
+import "time"
+
+// Environment represents a deployment environment of a repository
+type Environment struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	// the minutes to wait before the jobs targeting the environment are picked by runners
+	WaitTimer int64 `json:"wait_timer"`
+	// the users who could approve or reject the deployments, no approval is required if it's empty
+	Reviewers []*User `json:"reviewers"`
+	// the glob patterns of the branches or tags which could deploy to the environment, any ref is allowed if it's empty
+	BranchPatterns []string `json:"branch_patterns"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateOrUpdateEnvironmentOption options when creating or updating an environment
+// swagger:model
+type CreateOrUpdateEnvironmentOption struct {
+	// the minutes to wait before the jobs targeting the environment are picked by runners, at most 43200 (30 days)
+	WaitTimer *int64 `json:"wait_timer"`
+	// the usernames of the reviewers, they must have write access to the repository
+	Reviewers *[]string `json:"reviewers"`
+	// the glob patterns of the branches or tags which could deploy to the environment
+	BranchPatterns *[]string `json:"branch_patterns"`
+}
+
+// Deployment represents a deployment of a job to an environment
+type Deployment struct {
+	ID            int64  `json:"id"`
+	Environment   string `json:"environment"`
+	EnvironmentID int64  `json:"environment_id"`
+	RunID         int64  `json:"run_id"`
+	JobID         int64  `json:"job_id"`
+	Ref           string `json:"ref"`
+	SHA           string `json:"sha"`
+	// enum: waiting,queued,in_progress,success,failure,error,inactive
+	State string `json:"state"`
+	// whether the deployment is waiting for the approval of the reviewers
+	NeedApproval bool  `json:"need_approval"`
+	Creator      *User `json:"creator"`
+	// the user who approved or rejected the deployment
+	Reviewer *User `json:"reviewer,omitempty"`
+	// swagger:strfmt date-time
+	WaitUntil *time.Time `json:"wait_until,omitempty"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DeploymentStatus represents a record in the status history of a deployment
+type DeploymentStatus struct {
+	ID int64 `json:"id"`
+	// enum: waiting,queued,in_progress,success,failure,error,inactive
+	State       string `json:"state"`
+	Description string `json:"description"`
+	// the user who changed the state, it's empty if the state was changed by the system
+	Creator *User `json:"creator,omitempty"`
+	// swagger:strfmt date-time
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReviewPendingDeploymentsOption options when approving or rejecting the pending deployments of a workflow run
+// swagger:model
+type ReviewPendingDeploymentsOption struct {
+	// the ids of the environments to approve or reject
+	//
+	// required: true
+	EnvironmentIDs []int64 `json:"environment_ids" binding:"Required"`
+	// enum: approved,rejected
+	//
+	// required: true
+	State   string `json:"state" binding:"Required;In(approved,rejected)"`
+	Comment string `json:"comment"`
+}
@@ -26,6 +26,8 @@ type HookEvents struct {
 	Repository               bool `json:"repository"`
 	Release                  bool `json:"release"`
 	Package                  bool `json:"package"`
+	WorkflowRun              bool `json:"workflow_run"`
+	WorkflowJob              bool `json:"workflow_job"`
 }
 
 // HookEvent represents events that will delivery hook.
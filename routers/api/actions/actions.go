@@ -20,5 +20,7 @@ func Routes(prefix string) *web.Router {
 	path, handler = runner.NewRunnerServiceHandler()
 	m.Post(path+"*", http.StripPrefix(prefix, handler).ServeHTTP)
 
+	oidcRoutes(m)
+
 	return m
 }
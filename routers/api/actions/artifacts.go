@@ -71,6 +71,7 @@ import (
 
 	"code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
+	quota_model "code.gitea.io/gitea/models/quota"
 	"code.gitea.io/gitea/modules/httplib"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
@@ -258,6 +259,25 @@ func (ar artifactRoutes) uploadArtifact(ctx *ArtifactContext) {
 	log.Debug("[artifact] upload chunk, name: %s, path: %s, size: %d, retention days: %d",
 		artifactName, artifactPath, fileRealTotalSize, expiredDays)
 
+	if exceeded, err := actions.IsArtifactQuotaExceeded(ctx, task.OwnerID); err != nil {
+		log.Error("Error check artifact quota: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error check artifact quota")
+		return
+	} else if exceeded {
+		ctx.Error(http.StatusForbidden, "Artifact storage quota exceeded")
+		return
+	}
+	// the artifact size is unknown before all chunks are uploaded
+	if err := quota_model.CheckQuota(ctx, task.OwnerID, quota_model.SubjectArtifacts, 0); err != nil {
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			ctx.Error(http.StatusForbidden, err.Error())
+			return
+		}
+		log.Error("Error check storage quota: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error check storage quota")
+		return
+	}
+
 	// create or get artifact with name and path
 	artifact, err := actions.CreateArtifact(ctx, task, artifactName, artifactPath, expiredDays)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/storage"
+	actions_service "code.gitea.io/gitea/services/actions"
 )
 
 func saveUploadChunkBase(st storage.ObjectStorage, ctx *ArtifactContext,
@@ -297,5 +298,9 @@ func mergeChunksForArtifact(ctx *ArtifactContext, chunks []*chunkFileItem, st st
 		return fmt.Errorf("update artifact error: %v", err)
 	}
 
+	if err := actions_service.ParseArtifactTestReports(ctx, artifact); err != nil {
+		log.Error("Error parsing test reports of artifact %d: %v", artifact.ID, err)
+	}
+
 	return nil
 }
@@ -90,6 +90,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -100,6 +101,7 @@ import (
 
 	"code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
+	quota_model "code.gitea.io/gitea/models/quota"
 	"code.gitea.io/gitea/modules/httplib"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -270,6 +272,25 @@ func (r *artifactV4Routes) createArtifact(ctx *ArtifactContext) {
 
 	artifactName := req.Name
 
+	if exceeded, err := actions.IsArtifactQuotaExceeded(ctx, ctx.ActionTask.OwnerID); err != nil {
+		log.Error("Error check artifact quota: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error check artifact quota")
+		return
+	} else if exceeded {
+		ctx.Error(http.StatusForbidden, "Artifact storage quota exceeded")
+		return
+	}
+	// the artifact size is unknown before all chunks are uploaded
+	if err := quota_model.CheckQuota(ctx, ctx.ActionTask.OwnerID, quota_model.SubjectArtifacts, 0); err != nil {
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			ctx.Error(http.StatusForbidden, err.Error())
+			return
+		}
+		log.Error("Error check storage quota: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error check storage quota")
+		return
+	}
+
 	rententionDays := setting.Actions.ArtifactRetentionDays
 	if req.ExpiresAt != nil {
 		rententionDays = int64(time.Until(req.ExpiresAt.AsTime()).Hours() / 24)
@@ -0,0 +1,477 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+// GitHub Actions Cache API Simple Description
+//
+// The runners should set the `ACTIONS_CACHE_URL` to the `/api/actions_pipeline/` of Gitea, like the `ACTIONS_RUNTIME_URL`,
+// for act_runner, it could be done by setting `cache.external_server` in the config file.
+// All the requests except downloading are authenticated by the `ACTIONS_RUNTIME_TOKEN` of the task.
+//
+// 1. Restore cache
+// 1.1. Find the cache
+// GET: /api/actions_pipeline/_apis/artifactcache/cache?keys=primary-key,restore-key&version=hash-of-paths
+// Response (200 if found, or 204 if not):
+// {
+//   "result": "hit",
+//   "archiveLocation": "/api/actions_pipeline/_apis/artifactcache/artifacts/{cache_id}?sig=...&expires=...",
+//   "cacheKey": "primary-key-of-the-found-cache"
+// }
+// 1.2. Download the cache archive (unauthenticated request, the archive location is signed)
+// GET: /api/actions_pipeline/_apis/artifactcache/artifacts/{cache_id}?sig=...&expires=...
+//
+// 2. Save cache
+// 2.1. Reserve the cache
+// POST: /api/actions_pipeline/_apis/artifactcache/caches
+// Request:
+// {
+//   "key": "primary-key",
+//   "version": "hash-of-paths",
+//   "cacheSize": 1024
+// }
+// Response:
+// {
+//   "cacheId": 1
+// }
+// 2.2. Upload the chunks of the cache archive
+// PATCH: /api/actions_pipeline/_apis/artifactcache/caches/{cache_id}
+// with header:
+//    content-range: bytes 0-1023/*
+// 2.3. Commit the cache
+// POST: /api/actions_pipeline/_apis/artifactcache/caches/{cache_id}
+// Request:
+// {
+//   "size": 1024
+// }
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/httplib"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+const CacheRouteBase = "/api/actions_pipeline/_apis/artifactcache"
+
+type cacheRoutes struct {
+	prefix string
+	fs     storage.ObjectStorage
+}
+
+func CacheRoutes(prefix string) *web.Router {
+	m := web.NewRouter()
+
+	r := cacheRoutes{
+		prefix: prefix,
+		fs:     storage.ActionsCache,
+	}
+
+	m.Group("", func() {
+		m.Get("/cache", r.findCache)
+		m.Post("/caches", r.reserveCache)
+		m.Combo("/caches/{cache_id}").Patch(r.uploadCache).Post(r.commitCache)
+	}, ArtifactContexter())
+	m.Group("", func() {
+		m.Get("/artifacts/{cache_id}", r.downloadCache)
+	}, ArtifactV4Contexter())
+
+	return m
+}
+
+const cacheURLExpiresFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+func (r cacheRoutes) buildSignature(cacheID int64, expires string) []byte {
+	mac := hmac.New(sha256.New, setting.GetGeneralTokenSigningSecret())
+	mac.Write([]byte("actions_cache"))
+	mac.Write([]byte(fmt.Sprint(cacheID)))
+	mac.Write([]byte(expires))
+	return mac.Sum(nil)
+}
+
+func (r cacheRoutes) buildDownloadURL(ctx *ArtifactContext, cache *actions.ActionCache) string {
+	if setting.Actions.CacheStorage.ServeDirect() {
+		u, err := r.fs.URL(cache.StoragePath, cache.CacheKey)
+		if err != nil && !errors.Is(err, storage.ErrURLNotSupported) {
+			log.Error("Error getting serve direct url: %v", err)
+		}
+		if u != nil {
+			return u.String()
+		}
+	}
+
+	expires := time.Now().Add(60 * time.Minute).Format(cacheURLExpiresFormat)
+	return strings.TrimSuffix(httplib.GuessCurrentAppURL(ctx), "/") + strings.TrimSuffix(r.prefix, "/") +
+		"/artifacts/" + strconv.FormatInt(cache.ID, 10) +
+		"?sig=" + base64.URLEncoding.EncodeToString(r.buildSignature(cache.ID, expires)) + "&expires=" + url.QueryEscape(expires)
+}
+
+// getCacheScope returns the repository id and the refs whose caches could be read by the task
+func (r cacheRoutes) getCacheScope(ctx *ArtifactContext) (int64, []string, bool) {
+	task := ctx.ActionTask
+	if err := task.Job.LoadRun(ctx); err != nil {
+		log.Error("Error loading run: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error loading run")
+		return 0, nil, false
+	}
+	refs, err := actions_service.GetCacheReadableRefs(ctx, task.Job.Run)
+	if err != nil {
+		log.Error("Error getting readable refs of cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error getting readable refs of cache")
+		return 0, nil, false
+	}
+	return task.RepoID, refs, true
+}
+
+type findCacheResponse struct {
+	Result          string `json:"result"`
+	ArchiveLocation string `json:"archiveLocation"`
+	CacheKey        string `json:"cacheKey"`
+	Scope           string `json:"scope"`
+}
+
+func (r cacheRoutes) findCache(ctx *ArtifactContext) {
+	repoID, refs, ok := r.getCacheScope(ctx)
+	if !ok {
+		return
+	}
+	keys := strings.Split(ctx.Req.URL.Query().Get("keys"), ",")
+	version := ctx.Req.URL.Query().Get("version")
+
+	cache, err := actions.MatchCache(ctx, repoID, refs, keys, version)
+	if errors.Is(err, util.ErrNotExist) {
+		log.Debug("[cache] cache not found, keys: %v, version: %s", keys, version)
+		ctx.Status(http.StatusNoContent)
+		return
+	} else if err != nil {
+		log.Error("Error matching cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error matching cache")
+		return
+	}
+
+	cache.UsedUnix = timeutil.TimeStampNow()
+	if err := actions.UpdateCache(ctx, cache, "used_unix"); err != nil {
+		log.Error("Error updating cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error updating cache")
+		return
+	}
+
+	log.Debug("[cache] cache found, id: %d, key: %s, ref: %s", cache.ID, cache.CacheKey, cache.Ref)
+	ctx.JSON(http.StatusOK, findCacheResponse{
+		Result:          "hit",
+		ArchiveLocation: r.buildDownloadURL(ctx, cache),
+		CacheKey:        cache.CacheKey,
+		Scope:           cache.Ref,
+	})
+}
+
+type reserveCacheRequest struct {
+	Key       string `json:"key"`
+	Version   string `json:"version"`
+	CacheSize int64  `json:"cacheSize"`
+}
+
+type reserveCacheResponse struct {
+	CacheID int64 `json:"cacheId"`
+}
+
+func (r cacheRoutes) reserveCache(ctx *ArtifactContext) {
+	task := ctx.ActionTask
+	if err := task.Job.LoadRun(ctx); err != nil {
+		log.Error("Error loading run: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error loading run")
+		return
+	}
+
+	var req reserveCacheRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		log.Error("Error decode request body: %v", err)
+		ctx.Error(http.StatusBadRequest, "Error decode request body")
+		return
+	}
+	if req.Key == "" || req.Version == "" {
+		ctx.Error(http.StatusBadRequest, "Cache key and version are required")
+		return
+	}
+	if setting.Actions.CacheMaxEntrySize >= 0 && req.CacheSize > setting.Actions.CacheMaxEntrySize {
+		// actions/cache treats 400 as the cache size exceeding the limit
+		ctx.Error(http.StatusBadRequest, "Cache size exceeds the limit")
+		return
+	}
+	if !r.makeRoomForCache(ctx, task.RepoID, req.CacheSize) {
+		return
+	}
+
+	cache := &actions.ActionCache{
+		RepoID:   task.RepoID,
+		Ref:      task.Job.Run.Ref,
+		CacheKey: req.Key,
+		Version:  req.Version,
+		RunID:    task.Job.RunID,
+	}
+	if err := actions.CreateCache(ctx, cache); errors.Is(err, util.ErrAlreadyExist) {
+		log.Debug("[cache] cache already exists, key: %s, version: %s", req.Key, req.Version)
+		ctx.Error(http.StatusConflict, "Cache already exists")
+		return
+	} else if err != nil {
+		log.Error("Error creating cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error creating cache")
+		return
+	}
+
+	log.Debug("[cache] cache reserved, id: %d, key: %s, ref: %s", cache.ID, cache.CacheKey, cache.Ref)
+	ctx.JSON(http.StatusOK, reserveCacheResponse{CacheID: cache.ID})
+}
+
+// makeRoomForCache evicts the least recently used caches of the repository to keep its total size within the limit after adding a cache of the size,
+// it writes the error and returns false if the cache doesn't fit.
+func (r cacheRoutes) makeRoomForCache(ctx *ArtifactContext, repoID, size int64) bool {
+	ok, err := actions_service.MakeRoomForCache(ctx, repoID, size)
+	if err != nil {
+		log.Error("Error evicting caches: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error evicting caches")
+		return false
+	}
+	if !ok {
+		ctx.Error(http.StatusBadRequest, "Cache size exceeds the limit")
+		return false
+	}
+	return true
+}
+
+// getUploadingCache returns the cache reserved by the run of the task which hasn't been committed
+func (r cacheRoutes) getUploadingCache(ctx *ArtifactContext) (*actions.ActionCache, bool) {
+	task := ctx.ActionTask
+	cache, err := actions.GetCacheByID(ctx, ctx.PathParamInt64("cache_id"))
+	if errors.Is(err, util.ErrNotExist) || (err == nil && cache.RepoID != task.RepoID) {
+		ctx.Error(http.StatusNotFound, "Cache not found")
+		return nil, false
+	} else if err != nil {
+		log.Error("Error getting cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error getting cache")
+		return nil, false
+	}
+	if cache.RunID != task.Job.RunID {
+		ctx.Error(http.StatusForbidden, "Cache is reserved by another run")
+		return nil, false
+	}
+	if cache.Complete {
+		ctx.Error(http.StatusBadRequest, "Cache has been committed")
+		return nil, false
+	}
+	return cache, true
+}
+
+func (r cacheRoutes) uploadCache(ctx *ArtifactContext) {
+	cache, ok := r.getUploadingCache(ctx)
+	if !ok {
+		return
+	}
+
+	// parse content-range header, format: bytes 0-1023/*
+	var start, end int64
+	contentRange := ctx.Req.Header.Get("Content-Range")
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil || start < 0 || end < start {
+		log.Warn("parse content range error: %v, content-range: %s", err, contentRange)
+		ctx.Error(http.StatusBadRequest, "Invalid content range")
+		return
+	}
+	if setting.Actions.CacheMaxEntrySize >= 0 && end >= setting.Actions.CacheMaxEntrySize {
+		ctx.Error(http.StatusBadRequest, "Cache size exceeds the limit")
+		return
+	}
+
+	size := end - start + 1
+	chunkPath := fmt.Sprintf("%s/%d-%d.chunk", actions_service.CacheChunksDir(cache.ID), start, end)
+	written, err := r.fs.Save(chunkPath, ctx.Req.Body, size)
+	if err == nil && written != size {
+		err = fmt.Errorf("written size %d doesn't match content size %d", written, size)
+	}
+	if err != nil {
+		log.Error("Error saving cache chunk: %v", err)
+		if err := r.fs.Delete(chunkPath); err != nil {
+			log.Warn("Error deleting cache chunk: %s, %v", chunkPath, err)
+		}
+		ctx.Error(http.StatusInternalServerError, "Error saving cache chunk")
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+type commitCacheRequest struct {
+	Size int64 `json:"size"`
+}
+
+type cacheChunk struct {
+	Start int64
+	End   int64
+	Path  string
+}
+
+func (r cacheRoutes) listCacheChunks(cacheID int64) ([]*cacheChunk, error) {
+	storageDir := actions_service.CacheChunksDir(cacheID)
+	var chunks []*cacheChunk
+	if err := r.fs.IterateObjects(storageDir, func(fpath string, obj storage.Object) error {
+		baseName := filepath.Base(fpath)
+		// when read chunks from storage, it only contains storage dir and basename,
+		// no matter the subdirectory setting in storage config
+		chunk := cacheChunk{Path: storageDir + "/" + baseName}
+		if _, err := fmt.Sscanf(baseName, "%d-%d.chunk", &chunk.Start, &chunk.End); err != nil {
+			return fmt.Errorf("parse chunk name error: %v", err)
+		}
+		chunks = append(chunks, &chunk)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Start < chunks[j].Start
+	})
+	return chunks, nil
+}
+
+func (r cacheRoutes) commitCache(ctx *ArtifactContext) {
+	cache, ok := r.getUploadingCache(ctx)
+	if !ok {
+		return
+	}
+
+	var req commitCacheRequest
+	if err := json.NewDecoder(ctx.Req.Body).Decode(&req); err != nil {
+		log.Error("Error decode request body: %v", err)
+		ctx.Error(http.StatusBadRequest, "Error decode request body")
+		return
+	}
+
+	chunks, err := r.listCacheChunks(cache.ID)
+	if err != nil {
+		log.Error("Error listing cache chunks: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error listing cache chunks")
+		return
+	}
+	defer func() {
+		for _, c := range chunks {
+			if err := r.fs.Delete(c.Path); err != nil {
+				log.Warn("Error deleting cache chunk: %s, %v", c.Path, err)
+			}
+		}
+	}()
+
+	// the chunks could be uploaded repeatedly, but they must cover the whole archive in order
+	merged := make([]*cacheChunk, 0, len(chunks))
+	next := int64(0)
+	for _, c := range chunks {
+		if c.Start == next {
+			merged = append(merged, c)
+			next = c.End + 1
+		}
+	}
+	if next != req.Size {
+		log.Error("Error committing cache %d: chunks are not uploaded completely, uploaded: %d, size: %d", cache.ID, next, req.Size)
+		ctx.Error(http.StatusBadRequest, "Cache chunks are not uploaded completely")
+		return
+	}
+
+	// the size reserved by the client could be different, so the total size of the repository is checked again
+	if !r.makeRoomForCache(ctx, cache.RepoID, req.Size) {
+		return
+	}
+
+	readers := make([]io.Reader, 0, len(merged))
+	defer func() {
+		for _, r := range readers {
+			_ = r.(io.Closer).Close()
+		}
+	}()
+	for _, c := range merged {
+		obj, err := r.fs.Open(c.Path)
+		if err != nil {
+			log.Error("Error opening cache chunk: %s, %v", c.Path, err)
+			ctx.Error(http.StatusInternalServerError, "Error opening cache chunk")
+			return
+		}
+		readers = append(readers, obj)
+	}
+
+	storagePath := fmt.Sprintf("%d/%d/%d.cache", cache.RepoID%255, cache.ID%255, cache.ID)
+	written, err := r.fs.Save(storagePath, io.MultiReader(readers...), req.Size)
+	if err == nil && written != req.Size {
+		err = fmt.Errorf("written size %d doesn't match cache size %d", written, req.Size)
+	}
+	if err != nil {
+		log.Error("Error saving cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error saving cache")
+		return
+	}
+
+	cache.Size = req.Size
+	cache.StoragePath = storagePath
+	cache.Complete = true
+	cache.UsedUnix = timeutil.TimeStampNow()
+	if err := actions.UpdateCache(ctx, cache, "size", "storage_path", "complete", "used_unix"); err != nil {
+		log.Error("Error updating cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error updating cache")
+		return
+	}
+
+	log.Debug("[cache] cache committed, id: %d, key: %s, size: %d", cache.ID, cache.CacheKey, cache.Size)
+	ctx.Status(http.StatusNoContent)
+}
+
+func (r cacheRoutes) downloadCache(ctx *ArtifactContext) {
+	cacheID := ctx.PathParamInt64("cache_id")
+	sig, _ := base64.URLEncoding.DecodeString(ctx.Req.URL.Query().Get("sig"))
+	expires := ctx.Req.URL.Query().Get("expires")
+	if !hmac.Equal(sig, r.buildSignature(cacheID, expires)) {
+		ctx.Error(http.StatusUnauthorized, "Error unauthorized")
+		return
+	}
+	if t, err := time.Parse(cacheURLExpiresFormat, expires); err != nil || t.Before(time.Now()) {
+		ctx.Error(http.StatusUnauthorized, "Error link expired")
+		return
+	}
+
+	cache, err := actions.GetCacheByID(ctx, cacheID)
+	if errors.Is(err, util.ErrNotExist) || (err == nil && !cache.Complete) {
+		ctx.Error(http.StatusNotFound, "Cache not found")
+		return
+	} else if err != nil {
+		log.Error("Error getting cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error getting cache")
+		return
+	}
+
+	fd, err := r.fs.Open(cache.StoragePath)
+	if err != nil {
+		log.Error("Error opening cache: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error opening cache")
+		return
+	}
+	defer fd.Close()
+
+	ctx.ServeContent(fd, &context.ServeHeaderOptions{
+		Filename:     cache.CacheKey,
+		LastModified: cache.CreatedUnix.AsLocalTime(),
+	})
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+// GitHub Actions OIDC ID Token Simple Description
+//
+// Gitea acts as an OIDC issuer for the jobs granted `id-token: write`, so they could authenticate to other services without long-lived secrets.
+// The issuer is "{AppURL}api/actions/oidc", and the tokens are signed by the JWT signing key of OAuth2, which must be asymmetric.
+// The task context of such a job contains "gitea_id_token_request_url" and "gitea_id_token_request_token",
+// runners should set them as `ACTIONS_ID_TOKEN_REQUEST_URL` and `ACTIONS_ID_TOKEN_REQUEST_TOKEN`, like GitHub.
+//
+// 1. Discovery document
+// GET: /api/actions/oidc/.well-known/openid-configuration
+//
+// 2. JSON Web Key Set
+// GET: /api/actions/oidc/.well-known/jwks
+//
+// 3. Request an ID token, authenticated by the `ACTIONS_ID_TOKEN_REQUEST_TOKEN`
+// GET: /api/actions/oidc/token?api-version=2.0&audience=optional-audience
+// Response:
+// {
+//   "value": "the-signed-jwt"
+// }
+
+import (
+	"errors"
+	"net/http"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+)
+
+func oidcRoutes(m *web.Router) {
+	m.Group("/oidc", func() {
+		m.Get("/.well-known/openid-configuration", ArtifactV4Contexter(), oidcDiscovery)
+		m.Get("/.well-known/jwks", ArtifactV4Contexter(), oidcJWKS)
+		m.Get("/token", ArtifactContexter(), oidcToken)
+	})
+}
+
+type oidcDiscoveryResponse struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	ClaimsSupported                  []string `json:"claims_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                  []string `json:"scopes_supported"`
+}
+
+func oidcDiscovery(ctx *ArtifactContext) {
+	issuer := actions_service.IDTokenIssuer()
+	ctx.JSON(http.StatusOK, oidcDiscoveryResponse{
+		Issuer:                 issuer,
+		JWKSURI:                issuer + "/.well-known/jwks",
+		SubjectTypesSupported:  []string{"public"},
+		ResponseTypesSupported: []string{"id_token"},
+		ClaimsSupported: []string{
+			"sub", "aud", "exp", "iat", "iss", "jti", "nbf",
+			"ref", "ref_type", "sha", "repository", "repository_id", "repository_owner", "repository_owner_id", "repository_visibility",
+			"actor", "actor_id", "workflow", "event_name", "head_ref", "base_ref", "environment",
+			"run_id", "run_number", "run_attempt", "job_id",
+		},
+		IDTokenSigningAlgValuesSupported: []string{oauth2.DefaultSigningKey.SigningMethod().Alg()},
+		ScopesSupported:                  []string{"openid"},
+	})
+}
+
+func oidcJWKS(ctx *ArtifactContext) {
+	keys := make([]map[string]string, 0, 1)
+	// the secret of a symmetric key must not be published
+	if !oauth2.DefaultSigningKey.IsSymmetric() {
+		jwk, err := oauth2.DefaultSigningKey.ToJWK()
+		if err != nil {
+			log.Error("Error converting signing key to JWK: %v", err)
+			ctx.Error(http.StatusInternalServerError, "Error converting signing key to JWK")
+			return
+		}
+		jwk["use"] = "sig"
+		keys = append(keys, jwk)
+	}
+	ctx.JSON(http.StatusOK, map[string]any{"keys": keys})
+}
+
+type oidcTokenResponse struct {
+	Value string `json:"value"`
+}
+
+func oidcToken(ctx *ArtifactContext) {
+	token, err := actions_service.CreateIDToken(ctx, ctx.ActionTask, ctx.Req.URL.Query().Get("audience"))
+	if err != nil {
+		if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.Error(http.StatusForbidden, err.Error())
+			return
+		}
+		log.Error("Error creating ID token: %v", err)
+		ctx.Error(http.StatusInternalServerError, "Error creating ID token")
+		return
+	}
+	ctx.JSON(http.StatusOK, oidcTokenResponse{Value: token})
+}
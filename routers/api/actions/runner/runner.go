@@ -77,6 +77,7 @@ func (s *Service) Register(
 		RepoID:      runnerToken.RepoID,
 		Version:     req.Msg.Version,
 		AgentLabels: labels,
+		Ephemeral:   req.Msg.GetEphemeral(),
 	}
 	if err := runner.GenerateToken(); err != nil {
 		return nil, errors.New("can't generate token")
@@ -95,12 +96,13 @@ func (s *Service) Register(
 
 	res := connect.NewResponse(&runnerv1.RegisterResponse{
 		Runner: &runnerv1.Runner{
-			Id:      runner.ID,
-			Uuid:    runner.UUID,
-			Token:   runner.Token,
-			Name:    runner.Name,
-			Version: runner.Version,
-			Labels:  runner.AgentLabels,
+			Id:        runner.ID,
+			Uuid:      runner.UUID,
+			Token:     runner.Token,
+			Name:      runner.Name,
+			Version:   runner.Version,
+			Labels:    runner.AgentLabels,
+			Ephemeral: runner.Ephemeral,
 		},
 	})
 
@@ -120,12 +122,13 @@ func (s *Service) Declare(
 
 	return connect.NewResponse(&runnerv1.DeclareResponse{
 		Runner: &runnerv1.Runner{
-			Id:      runner.ID,
-			Uuid:    runner.UUID,
-			Token:   runner.Token,
-			Name:    runner.Name,
-			Version: runner.Version,
-			Labels:  runner.AgentLabels,
+			Id:        runner.ID,
+			Uuid:      runner.UUID,
+			Token:     runner.Token,
+			Name:      runner.Name,
+			Version:   runner.Version,
+			Labels:    runner.AgentLabels,
+			Ephemeral: runner.Ephemeral,
 		},
 	}), nil
 }
@@ -175,10 +178,20 @@ func (s *Service) UpdateTask(
 	ctx context.Context,
 	req *connect.Request[runnerv1.UpdateTaskRequest],
 ) (*connect.Response[runnerv1.UpdateTaskResponse], error) {
+	// the runner could report the final state more than once
+	wasDone := false
+	if req.Msg.State.Result != runnerv1.Result_RESULT_UNSPECIFIED {
+		if t, err := actions_model.GetTaskByID(ctx, req.Msg.State.Id); err == nil {
+			wasDone = t.Status.IsDone()
+		}
+	}
+
 	task, err := actions_model.UpdateTaskByState(ctx, req.Msg.State)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "update task: %v", err)
 	}
+	// the steps of the task are updated, so the logs of them could be streamed
+	actions_service.NotifyTaskLogUpdated(task.ID)
 
 	for k, v := range req.Msg.Outputs {
 		if len(k) > 255 {
@@ -199,6 +212,23 @@ func (s *Service) UpdateTask(
 			// It's ok not to return errors, the runner will resend the outputs.
 		}
 	}
+	for _, step := range req.Msg.State.Steps {
+		summary, ok := getStepSummary(step)
+		if !ok {
+			continue
+		}
+		// The summary of a step can be a maximum of 1 MiB, the same as GitHub
+		// See https://docs.github.com/en/actions/writing-workflows/choosing-what-your-workflow-does/workflow-commands-for-github-actions#step-isolation-and-limits
+		if l := len(summary); l > 1024*1024 {
+			log.Warn("Ignore the summary of step %d of task %d because it is too long: %v", step.Id, task.ID, l)
+			continue
+		}
+		if err := actions_model.UpsertTaskStepSummary(ctx, task, step.Id, summary); err != nil {
+			log.Warn("Failed to update the summary of step %d of task %d: %v", step.Id, task.ID, err)
+			// It's ok not to return errors, the runner will resend the summaries with the state.
+		}
+	}
+
 	sentOutputs, err := actions_model.FindTaskOutputKeyByTaskID(ctx, task.ID)
 	if err != nil {
 		log.Warn("Failed to find the sent outputs of task %d: %v", task.ID, err)
@@ -218,9 +248,18 @@ func (s *Service) UpdateTask(
 	}
 
 	if req.Msg.State.Result != runnerv1.Result_RESULT_UNSPECIFIED {
+		if !wasDone {
+			actions_service.NotifyWorkflowJobStatusUpdate(ctx, task.Job)
+		}
 		if err := actions_service.EmitJobsIfReady(task.Job.RunID); err != nil {
 			log.Error("Emit ready jobs of run %d: %v", task.Job.RunID, err)
 		}
+		// an ephemeral runner is deleted once its task is done, so it can't fetch any task again
+		if runner := GetRunner(ctx); runner.Ephemeral {
+			if err := actions_model.DeleteRunner(ctx, runner.ID); err != nil {
+				log.Error("Delete ephemeral runner %d: %v", runner.ID, err)
+			}
+		}
 	}
 
 	return connect.NewResponse(&runnerv1.UpdateTaskResponse{
@@ -264,6 +303,9 @@ func (s *Service) UpdateLog(
 		task.LogIndexes = append(task.LogIndexes, task.LogSize)
 		task.LogSize += int64(n)
 	}
+	if err := actions_service.CreateAnnotationsFromLogRows(ctx, task, rows); err != nil {
+		log.Error("Failed to create annotations from logs of task %d: %v", task.ID, err)
+	}
 
 	res.Msg.AckIndex = task.LogLength
 
@@ -279,6 +321,7 @@ func (s *Service) UpdateLog(
 	if err := actions_model.UpdateTask(ctx, task, "log_indexes", "log_length", "log_size", "log_in_storage"); err != nil {
 		return nil, status.Errorf(codes.Internal, "update task: %v", err)
 	}
+	actions_service.NotifyTaskLogUpdated(task.ID)
 	if remove != nil {
 		remove()
 	}
@@ -6,24 +6,24 @@ package runner
 import (
 	"context"
 	"fmt"
+	"maps"
 
 	actions_model "code.gitea.io/gitea/models/actions"
-	"code.gitea.io/gitea/models/db"
-	secret_model "code.gitea.io/gitea/models/secret"
 	actions_module "code.gitea.io/gitea/modules/actions"
-	"code.gitea.io/gitea/modules/container"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/actions"
+	secret_service "code.gitea.io/gitea/services/secrets"
 
 	runnerv1 "code.gitea.io/actions-proto-go/runner/v1"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv1.Task, bool, error) {
-	t, ok, err := actions_model.CreateTaskForRunner(ctx, runner)
+	t, ok, err := actions.CreateTaskForRunner(ctx, runner)
 	if err != nil {
 		return nil, false, fmt.Errorf("CreateTaskForRunner: %w", err)
 	}
@@ -31,22 +31,33 @@ func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv
 		return nil, false, nil
 	}
 
-	secrets, err := secret_model.GetSecretsOfTask(ctx, t)
+	secrets, err := secret_service.GetSecretsOfTask(ctx, t)
 	if err != nil {
 		return nil, false, fmt.Errorf("GetSecretsOfTask: %w", err)
 	}
+	// the jobs of called workflows can only use the secrets passed by their callers
+	if secrets, err = actions.GetWorkflowCallSecrets(ctx, t.Job, secrets); err != nil {
+		return nil, false, fmt.Errorf("GetWorkflowCallSecrets: %w", err)
+	}
+	// the secrets of the environment are always available to the job deploying to it, even in a called workflow
+	envSecrets, err := secret_service.GetEnvironmentSecretsOfTask(ctx, t)
+	if err != nil {
+		return nil, false, fmt.Errorf("GetEnvironmentSecretsOfTask: %w", err)
+	}
+	maps.Copy(secrets, envSecrets)
 
-	vars, err := actions_model.GetVariablesOfRun(ctx, t.Job.Run)
+	vars, err := actions_model.GetVariablesOfJob(ctx, t.Job)
 	if err != nil {
-		return nil, false, fmt.Errorf("GetVariablesOfRun: %w", err)
+		return nil, false, fmt.Errorf("GetVariablesOfJob: %w", err)
 	}
 
 	actions.CreateCommitStatus(ctx, t.Job)
+	actions.NotifyWorkflowJobStatusUpdate(ctx, t.Job)
 
 	task := &runnerv1.Task{
 		Id:              t.ID,
 		WorkflowPayload: t.Job.WorkflowPayload,
-		Context:         generateTaskContext(t),
+		Context:         generateTaskContext(ctx, t),
 		Secrets:         secrets,
 		Vars:            vars,
 	}
@@ -64,7 +75,7 @@ func pickTask(ctx context.Context, runner *actions_model.ActionRunner) (*runnerv
 	return task, true, nil
 }
 
-func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
+func generateTaskContext(ctx context.Context, t *actions_model.ActionTask) *structpb.Struct {
 	event := map[string]any{}
 	_ = json.Unmarshal([]byte(t.Job.Run.EventPayload), &event)
 
@@ -76,6 +87,20 @@ func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 		eventName = t.Job.Run.Event.Event()
 	}
 
+	// the jobs of called workflows read their inputs from the event, like act does
+	if t.Job.CallerID > 0 {
+		inputs, err := actions.GetWorkflowCallInputs(ctx, t.Job)
+		if err != nil {
+			log.Error("actions.GetWorkflowCallInputs failed: %v", err)
+		}
+		eventInputs := make(map[string]any, len(inputs))
+		for k, v := range inputs {
+			eventInputs[k] = fmt.Sprint(v)
+		}
+		event["inputs"] = eventInputs
+		eventName = actions_module.GithubEventWorkflowCall
+	}
+
 	baseRef := ""
 	headRef := ""
 	ref := t.Job.Run.Ref
@@ -100,6 +125,16 @@ func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 		log.Error("actions.CreateAuthorizationToken failed: %v", err)
 	}
 
+	// the runners should set them as ACTIONS_ID_TOKEN_REQUEST_URL and ACTIONS_ID_TOKEN_REQUEST_TOKEN,
+	// the actions toolkit appends "&audience=..." to the url, so it has a query already
+	idTokenRequestURL, idTokenRequestToken := "", ""
+	if ok, err := actions.CanRequestIDToken(ctx, t.Job); err != nil {
+		log.Error("actions.CanRequestIDToken failed: %v", err)
+	} else if ok {
+		idTokenRequestURL = actions.IDTokenIssuer() + "/token?api-version=2.0"
+		idTokenRequestToken = giteaRuntimeToken
+	}
+
 	taskContext, err := structpb.NewStruct(map[string]any{
 		// standard contexts, see https://docs.github.com/en/actions/learn-github-actions/contexts#github-context
 		"action":            "",                                                   // string, The name of the action currently running, or the id of a step. GitHub removes special characters, and uses the name __run when the current step runs a script without an id. If you use the same action more than once in the same job, the name will include a suffix with the sequence number with underscore before it. For example, the first script you run will have the name __run, and the second script will be named __run_2. Similarly, the second invocation of actions/checkout will be actionscheckout2.
@@ -138,8 +173,10 @@ func generateTaskContext(t *actions_model.ActionTask) *structpb.Struct {
 		"workspace":         "",                                                   // string, The default working directory on the runner for steps, and the default location of your repository when using the checkout action.
 
 		// additional contexts
-		"gitea_default_actions_url": setting.Actions.DefaultActionsURL.URL(),
-		"gitea_runtime_token":       giteaRuntimeToken,
+		"gitea_default_actions_url":    setting.Actions.DefaultActionsURL.URL(),
+		"gitea_runtime_token":          giteaRuntimeToken,
+		"gitea_id_token_request_url":   idTokenRequestURL,
+		"gitea_id_token_request_token": idTokenRequestToken,
 	})
 	if err != nil {
 		log.Error("structpb.NewStruct failed: %v", err)
@@ -152,38 +189,56 @@ func findTaskNeeds(ctx context.Context, task *actions_model.ActionTask) (map[str
 	if err := task.LoadAttributes(ctx); err != nil {
 		return nil, fmt.Errorf("LoadAttributes: %w", err)
 	}
-	if len(task.Job.Needs) == 0 {
-		return nil, nil
-	}
-	needs := container.SetOf(task.Job.Needs...)
-
-	jobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{RunID: task.Job.RunID})
+	needs, err := actions.FindJobNeeds(ctx, task.Job)
 	if err != nil {
-		return nil, fmt.Errorf("FindRunJobs: %w", err)
+		return nil, err
 	}
 
 	ret := make(map[string]*runnerv1.TaskNeed, len(needs))
-	for _, job := range jobs {
-		if !needs.Contains(job.JobID) {
-			continue
+	for id, need := range needs {
+		ret[id] = &runnerv1.TaskNeed{
+			Outputs: need.Outputs,
+			Result:  runnerv1.Result(need.Status),
 		}
-		if job.TaskID == 0 || !job.Status.IsDone() {
-			// it shouldn't happen, or the job has been rerun
-			continue
-		}
-		outputs := make(map[string]string)
-		got, err := actions_model.FindTaskOutputByTaskID(ctx, job.TaskID)
-		if err != nil {
-			return nil, fmt.Errorf("FindTaskOutputByTaskID: %w", err)
+	}
+	return ret, nil
+}
+
+// stepStateSummaryField is the number of the field of StepState which carries the markdown written to $GITHUB_STEP_SUMMARY,
+// it's sent by the newer runners but not declared by any released version of actions-proto-go yet.
+// TODO: replace it by the generated getter once the protocol declares the field. code.gitea.io/actions-proto-go v0.4.1
+// doesn't have it and the later releases moved to gitea.dev/actions-proto-go, the number must match the runner until then.
+const stepStateSummaryField protowire.Number = 7
+
+// getUnknownField returns the raw value of a field which is unknown to the version of the protocol used here
+func getUnknownField(b []byte, num protowire.Number, typ protowire.Type) ([]byte, bool) {
+	for len(b) > 0 {
+		n, t, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return nil, false
 		}
-		for _, v := range got {
-			outputs[v.OutputKey] = v.OutputValue
+		b = b[l:]
+		l = protowire.ConsumeFieldValue(n, t, b)
+		if l < 0 {
+			return nil, false
 		}
-		ret[job.JobID] = &runnerv1.TaskNeed{
-			Outputs: outputs,
-			Result:  runnerv1.Result(job.Status),
+		if n == num && t == typ {
+			return b[:l], true
 		}
+		b = b[l:]
 	}
+	return nil, false
+}
 
-	return ret, nil
+// getStepSummary returns the summary of the step reported by the runner, it returns false if the runner doesn't send it
+func getStepSummary(step *runnerv1.StepState) (string, bool) {
+	b, ok := getUnknownField(step.ProtoReflect().GetUnknown(), stepStateSummaryField, protowire.BytesType)
+	if !ok {
+		return "", false
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return "", false
+	}
+	return string(v), true
 }
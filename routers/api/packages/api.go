@@ -9,11 +9,13 @@ import (
 	"strings"
 
 	auth_model "code.gitea.io/gitea/models/auth"
+	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/models/perm"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/api/packages/alpine"
+	"code.gitea.io/gitea/routers/api/packages/arch"
 	"code.gitea.io/gitea/routers/api/packages/cargo"
 	"code.gitea.io/gitea/routers/api/packages/chef"
 	"code.gitea.io/gitea/routers/api/packages/composer"
@@ -33,6 +35,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/rpm"
 	"code.gitea.io/gitea/routers/api/packages/rubygems"
 	"code.gitea.io/gitea/routers/api/packages/swift"
+	"code.gitea.io/gitea/routers/api/packages/terraform"
 	"code.gitea.io/gitea/routers/api/packages/vagrant"
 	"code.gitea.io/gitea/services/auth"
 	"code.gitea.io/gitea/services/context"
@@ -74,6 +77,14 @@ func reqPackageAccess(accessMode perm.AccessMode) func(ctx *context.Context) {
 	}
 }
 
+// uploadRoute resolves the routes of the registries which serve repositories of the packages of the owner instead of single packages,
+// only the packages uploaded by the method are addressed, they are named by the uploaded files
+func uploadRoute(packageType packages_model.Type, method string) context.PackageRouteResolver {
+	return func(ctx *context.Base) context.PackageRoute {
+		return context.PackageRoute{Type: packageType, Upload: ctx.Req.Method == method}
+	}
+}
+
 func verifyAuth(r *web.Router, authMethods []auth.Method) {
 	if setting.Service.EnableReverseProxyAuth {
 		authMethods = append(authMethods, &auth.ReverseProxy{})
@@ -107,6 +118,18 @@ func CommonRoutes() *web.Router {
 		&chef.Auth{},
 	})
 
+	// The registry protocols of Terraform address the packages by namespace, so the owner follows the base urls of the service discovery
+	r.Group("/terraform", func() {
+		r.Group("/modules/v1/{username}/{name}/{system}", func() {
+			r.Get("/versions", terraform.EnumerateModuleVersions)
+			r.Get("/{version}/download", terraform.GetModuleDownloadURL)
+		})
+		r.Group("/providers/v1/{username}/{provider}", func() {
+			r.Get("/versions", terraform.EnumerateProviderVersions)
+			r.Get("/{version}/download/{os}/{arch}", terraform.GetProviderPackage)
+		})
+	}, context.UserAssignmentWeb(), context.PackageAssignment(terraform.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
+
 	r.Group("/{username}", func() {
 		r.Group("/alpine", func() {
 			r.Get("/key", alpine.GetRepositoryKey)
@@ -120,7 +143,17 @@ func CommonRoutes() *web.Router {
 					})
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeAlpine, http.MethodPut)), reqPackageAccess(perm.AccessModeRead))
+		r.Group("/arch", func() {
+			r.Get("/repository.key", arch.GetRepositoryKey)
+			r.Group("/{repository}", func() {
+				r.Put("", reqPackageAccess(perm.AccessModeWrite), arch.UploadPackageFile)
+				r.Group("/{architecture}/{filename}", func() {
+					r.Get("", arch.GetRepositoryFile)
+					r.Delete("", reqPackageAccess(perm.AccessModeWrite), arch.DeletePackageFile)
+				})
+			})
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeArch, http.MethodPut)), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/cargo", func() {
 			r.Group("/api/v1/crates", func() {
 				r.Get("", cargo.SearchPackages)
@@ -140,7 +173,7 @@ func CommonRoutes() *web.Router {
 			// Use dummy placeholders because these parts are not of interest
 			r.Get("/3/{_}/{package}", cargo.EnumeratePackageVersions)
 			r.Get("/{_}/{__}/{package}", cargo.EnumeratePackageVersions)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(cargo.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/chef", func() {
 			r.Group("/api/v1", func() {
 				r.Get("/universe", chef.PackagesUniverse)
@@ -159,7 +192,7 @@ func CommonRoutes() *web.Router {
 					})
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(chef.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/composer", func() {
 			r.Get("/packages.json", composer.ServiceIndex)
 			r.Get("/search.json", composer.SearchPackages)
@@ -168,7 +201,7 @@ func CommonRoutes() *web.Router {
 			r.Get("/p2/{vendorname}/{projectname}.json", composer.PackageMetadata)
 			r.Get("/files/{package}/{version}/{filename}", composer.DownloadPackageFile)
 			r.Put("", reqPackageAccess(perm.AccessModeWrite), composer.UploadPackage)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(composer.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/conan", func() {
 			r.Group("/v1", func() {
 				r.Get("/ping", conan.Ping)
@@ -256,7 +289,7 @@ func CommonRoutes() *web.Router {
 					}, conan.ExtractPathParameters)
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(conan.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/conda", func() {
 			var (
 				downloadPattern = regexp.MustCompile(`\A(.+/)?(.+)/((?:[^/]+(?:\.tar\.bz2|\.conda))|(?:current_)?repodata\.json(?:\.bz2)?)\z`)
@@ -293,7 +326,7 @@ func CommonRoutes() *web.Router {
 
 				conda.UploadPackageFile(ctx)
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeConda, http.MethodPut)), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/cran", func() {
 			r.Group("/src", func() {
 				r.Group("/contrib", func() {
@@ -311,7 +344,7 @@ func CommonRoutes() *web.Router {
 				})
 				r.Put("", reqPackageAccess(perm.AccessModeWrite), cran.UploadBinaryPackageFile)
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeCran, http.MethodPut)), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/debian", func() {
 			r.Get("/repository.key", debian.GetRepositoryKey)
 			r.Group("/dists/{distribution}", func() {
@@ -329,7 +362,7 @@ func CommonRoutes() *web.Router {
 					r.Delete("/{name}/{version}/{architecture}", debian.DeletePackageFile)
 				}, reqPackageAccess(perm.AccessModeWrite))
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(debian.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/go", func() {
 			r.Put("/upload", reqPackageAccess(perm.AccessModeWrite), goproxy.UploadPackage)
 			r.Get("/sumdb/sum.golang.org/supported", func(ctx *context.Context) {
@@ -387,7 +420,7 @@ func CommonRoutes() *web.Router {
 
 				ctx.Status(http.StatusNotFound)
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(goproxy.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/generic", func() {
 			r.Group("/{packagename}/{packageversion}", func() {
 				r.Delete("", reqPackageAccess(perm.AccessModeWrite), generic.DeletePackage)
@@ -399,17 +432,17 @@ func CommonRoutes() *web.Router {
 					}, reqPackageAccess(perm.AccessModeWrite))
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(generic.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/helm", func() {
 			r.Get("/index.yaml", helm.Index)
 			r.Get("/{filename}", helm.DownloadPackageFile)
 			r.Post("/api/charts", reqPackageAccess(perm.AccessModeWrite), helm.UploadPackage)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeHelm, http.MethodPost)), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/maven", func() {
 			r.Put("/*", reqPackageAccess(perm.AccessModeWrite), maven.UploadPackageFile)
 			r.Get("/*", maven.DownloadPackageFile)
 			r.Head("/*", maven.ProvidePackageFileHeader)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(maven.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/nuget", func() {
 			r.Group("", func() { // Needs to be unauthenticated for the NuGet client.
 				r.Get("/", nuget.ServiceIndexV2)
@@ -446,7 +479,7 @@ func CommonRoutes() *web.Router {
 					r.Get("/$count", nuget.SearchServiceV2Count)
 				})
 			}, reqPackageAccess(perm.AccessModeRead))
-		})
+		}, context.PackageAssignment(nuget.ResolvePackage))
 		r.Group("/npm", func() {
 			r.Group("/@{scope}/{id}", func() {
 				r.Get("", npm.PackageMetadata)
@@ -491,7 +524,7 @@ func CommonRoutes() *web.Router {
 			r.Group("/-/v1/search", func() {
 				r.Get("", npm.PackageSearch)
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(npm.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/pub", func() {
 			r.Group("/api/packages", func() {
 				r.Group("/versions/new", func() {
@@ -505,12 +538,12 @@ func CommonRoutes() *web.Router {
 					r.Get("/{version}", pub.PackageVersionMetadata)
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(pub.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/pypi", func() {
 			r.Post("/", reqPackageAccess(perm.AccessModeWrite), pypi.UploadPackageFile)
 			r.Get("/files/{id}/{version}/{filename}", pypi.DownloadPackageFile)
 			r.Get("/simple/{id}", pypi.PackageMetadata)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(pypi.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/rpm", func() {
 			r.Group("/repository.key", func() {
 				r.Head("", rpm.GetRepositoryKey)
@@ -581,7 +614,7 @@ func CommonRoutes() *web.Router {
 
 				ctx.Status(http.StatusNotFound)
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(uploadRoute(packages_model.TypeRpm, http.MethodPut)), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/rubygems", func() {
 			r.Get("/specs.4.8.gz", rubygems.EnumeratePackages)
 			r.Get("/latest_specs.4.8.gz", rubygems.EnumeratePackagesLatest)
@@ -594,7 +627,7 @@ func CommonRoutes() *web.Router {
 				r.Post("/", rubygems.UploadPackageFile)
 				r.Delete("/yank", rubygems.DeletePackage)
 			}, reqPackageAccess(perm.AccessModeWrite))
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(rubygems.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/swift", func() {
 			r.Group("/{scope}/{name}", func() {
 				r.Group("", func() {
@@ -629,7 +662,20 @@ func CommonRoutes() *web.Router {
 				})
 			})
 			r.Get("/identifiers", swift.CheckAcceptMediaType(swift.AcceptJSON), swift.LookupPackageIdentifiers)
-		}, reqPackageAccess(perm.AccessModeRead))
+		}, context.PackageAssignment(swift.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
+		r.Group("/terraform", func() {
+			r.Get("/key", terraform.GetRepositoryKey)
+			r.Group("/modules/{name}/{system}/{version}", func() {
+				r.Put("", reqPackageAccess(perm.AccessModeWrite), terraform.UploadModule)
+				r.Delete("", reqPackageAccess(perm.AccessModeWrite), terraform.DeleteModule)
+				r.Get("/{filename}", terraform.DownloadModuleFile)
+			})
+			r.Group("/providers/{provider}/{version}", func() {
+				r.Put("/{os}/{arch}", reqPackageAccess(perm.AccessModeWrite), terraform.UploadProvider)
+				r.Delete("", reqPackageAccess(perm.AccessModeWrite), terraform.DeleteProvider)
+				r.Get("/{filename}", terraform.DownloadProviderFile)
+			})
+		}, context.PackageAssignment(terraform.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 		r.Group("/vagrant", func() {
 			r.Group("/authenticate", func() {
 				r.Get("", vagrant.CheckAuthenticate)
@@ -642,8 +688,8 @@ func CommonRoutes() *web.Router {
 					r.Put("", reqPackageAccess(perm.AccessModeWrite), vagrant.UploadPackageFile)
 				})
 			})
-		}, reqPackageAccess(perm.AccessModeRead))
-	}, context.UserAssignmentWeb(), context.PackageAssignment())
+		}, context.PackageAssignment(vagrant.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
+	}, context.UserAssignmentWeb())
 
 	return r
 }
@@ -690,12 +736,14 @@ func ContainerRoutes() *web.Router {
 				r.Delete("", reqPackageAccess(perm.AccessModeWrite), container.DeleteManifest)
 			})
 			r.Get("/tags/list", container.GetTagList)
+			r.Get("/referrers/{digest}", container.GetReferrers)
 		}, container.VerifyImageName)
 
 		var (
 			blobsUploadsPattern = regexp.MustCompile(`\A(.+)/blobs/uploads/([a-zA-Z0-9-_.=]+)\z`)
 			blobsPattern        = regexp.MustCompile(`\A(.+)/blobs/([^/]+)\z`)
 			manifestsPattern    = regexp.MustCompile(`\A(.+)/manifests/([^/]+)\z`)
+			referrersPattern    = regexp.MustCompile(`\A(.+)/referrers/([^/]+)\z`)
 		)
 
 		// Manual mapping of routes because {image} can contain slashes which chi does not support
@@ -810,10 +858,23 @@ func ContainerRoutes() *web.Router {
 				}
 				return
 			}
+			m = referrersPattern.FindStringSubmatch(path)
+			if len(m) == 3 && isGet {
+				ctx.SetPathParam("image", m[1])
+				container.VerifyImageName(ctx)
+				if ctx.Written() {
+					return
+				}
+
+				ctx.SetPathParam("digest", m[2])
+
+				container.GetReferrers(ctx)
+				return
+			}
 
 			ctx.Status(http.StatusNotFound)
 		})
-	}, container.ReqContainerAccess, context.UserAssignmentWeb(), context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+	}, container.ReqContainerAccess, context.UserAssignmentWeb(), context.PackageAssignment(container.ResolvePackage), reqPackageAccess(perm.AccessModeRead))
 
 	return r
 }
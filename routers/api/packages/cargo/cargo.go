@@ -36,6 +36,12 @@ type StatusMessage struct {
 	Message string `json:"detail"`
 }
 
+// ResolvePackage resolves the package addressed by the route, the package published to "new" is named by its metadata
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	name := ctx.PathParam("package")
+	return context.PackageRoute{Type: packages_model.TypeCargo, Name: name, Upload: name == "" && ctx.Req.Method == http.MethodPut}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		ctx.JSON(status, StatusResponse{
@@ -243,7 +249,7 @@ func UploadPackage(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -274,6 +280,11 @@ func UnyankPackage(ctx *context.Context) {
 }
 
 func yankPackage(ctx *context.Context, yank bool) {
+	if err := packages_service.CheckPackageWriteAccess(ctx, ctx.Package.Owner.ID, packages_model.TypeCargo, ctx.PathParam("package")); err != nil {
+		apiError(ctx, http.StatusForbidden, err)
+		return
+	}
+
 	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeCargo, ctx.PathParam("package"), ctx.PathParam("version"))
 	if err != nil {
 		if err == packages_model.ErrPackageNotExist {
@@ -25,6 +25,11 @@ import (
 	packages_service "code.gitea.io/gitea/services/packages"
 )
 
+// ResolvePackage resolves the package addressed by the route, the uploaded package is named by its metadata
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	return context.PackageRoute{Type: packages_model.TypeChef, Name: ctx.PathParam("name"), Upload: ctx.Req.Method == http.MethodPost}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	type Error struct {
 		ErrorMessages []string `json:"error_messages"`
@@ -312,7 +317,7 @@ func UploadPackage(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
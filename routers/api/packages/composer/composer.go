@@ -27,6 +27,15 @@ import (
 	"github.com/hashicorp/go-version"
 )
 
+// ResolvePackage resolves the package addressed by the route, the uploaded package is named by its composer.json
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeComposer, Name: ctx.PathParam("package"), Upload: ctx.Req.Method == http.MethodPut}
+	if vendorName, projectName := ctx.PathParam("vendorname"), ctx.PathParam("projectname"); vendorName != "" && projectName != "" {
+		route.Name = vendorName + "/" + projectName
+	}
+	return route
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		type Error struct {
@@ -249,7 +258,7 @@ func UploadPackage(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -59,6 +59,11 @@ func jsonResponse(ctx *context.Context, status int, obj any) {
 	}
 }
 
+// ResolvePackage resolves the package addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	return context.PackageRoute{Type: packages_model.TypeConan, Name: ctx.PathParam("name")}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		jsonResponse(ctx, status, map[string]string{
@@ -132,7 +137,7 @@ func Authenticate(ctx *context.Context) {
 		return
 	}
 
-	token, err := packages_service.CreateAuthorizationToken(ctx.Doer, packageScope)
+	token, err := packages_service.CreateAuthorizationToken(ctx.Doer, packageScope, 0)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -445,7 +450,7 @@ func uploadFile(ctx *context.Context, fileFilter container.Set[string], fileKey
 		switch err {
 		case packages_model.ErrDuplicatePackageFile:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -91,6 +91,11 @@ func mountBlob(ctx context.Context, pi *packages_service.PackageInfo, pb *packag
 }
 
 func getOrCreateUploadVersion(ctx context.Context, pi *packages_service.PackageInfo) (*packages_model.PackageVersion, error) {
+	if err := packages_service.CheckPackageWriteAccess(ctx, pi.Owner.ID, packages_model.TypeContainer, pi.Name); err != nil {
+		return nil, err
+	}
+	repoID := packages_service.LinkedRepositoryID(ctx, pi.Owner.ID)
+
 	var uploadVersion *packages_model.PackageVersion
 
 	// FIXME: Replace usage of mutex with database transaction
@@ -100,6 +105,7 @@ func getOrCreateUploadVersion(ctx context.Context, pi *packages_service.PackageI
 		created := true
 		p := &packages_model.Package{
 			OwnerID:   pi.Owner.ID,
+			RepoID:    repoID,
 			Type:      packages_model.TypeContainer,
 			Name:      strings.ToLower(pi.Name),
 			LowerName: strings.ToLower(pi.Name),
@@ -32,6 +32,8 @@ import (
 	container_service "code.gitea.io/gitea/services/packages/container"
 
 	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 // maximum size of a container manifest
@@ -41,6 +43,8 @@ const maxManifestSize = 10 * 1024 * 1024
 var (
 	imageNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*\z`)
 	referencePattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+	// imageRoutePattern matches the paths of the manually mapped routes and captures the image name
+	imageRoutePattern = regexp.MustCompile(`\A(.+)/(?:blobs/uploads(?:/[^/]+)?|blobs/[^/]+|manifests/[^/]+|referrers/[^/]+|tags/list)\z`)
 )
 
 type containerHeaders struct {
@@ -88,6 +92,18 @@ func jsonResponse(ctx *context.Context, status int, obj any) {
 	}
 }
 
+// ResolvePackage resolves the image addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeContainer, Name: ctx.PathParam("image")}
+	if route.Name == "" {
+		// the routes of the images whose names contain slashes are mapped manually
+		if m := imageRoutePattern.FindStringSubmatch(ctx.PathParam("*")); m != nil {
+			route.Name = m[1]
+		}
+	}
+	return route
+}
+
 func apiError(ctx *context.Context, status int, err error) {
 	helper.LogAndProcessError(ctx, status, err, func(message string) {
 		setResponseHeaders(ctx.Resp, &containerHeaders{
@@ -172,7 +188,9 @@ func Authenticate(ctx *context.Context) {
 		}
 	}
 
-	token, err := packages_service.CreateAuthorizationToken(u, packageScope)
+	// the token of an actions task is exchanged for a token with the same permissions
+	actionsTaskID, _ := ctx.Data["ActionsTaskID"].(int64)
+	token, err := packages_service.CreateAuthorizationToken(u, packageScope, actionsTaskID)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
@@ -231,6 +249,10 @@ func GetRepositoryList(ctx *context.Context) {
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#single-post
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-a-blob-in-chunks
 func InitiateUploadBlob(ctx *context.Context) {
+	if rejectProxyNamespacePush(ctx) {
+		return
+	}
+
 	image := ctx.PathParam("image")
 
 	mount := ctx.FormTrim("mount")
@@ -249,7 +271,11 @@ func InitiateUploadBlob(ctx *context.Context) {
 
 			if accessible {
 				if err := mountBlob(ctx, &packages_service.PackageInfo{Owner: ctx.Package.Owner, Name: image}, blob.Blob); err != nil {
-					apiError(ctx, http.StatusInternalServerError, err)
+					if err == packages_service.ErrPackageAccessDenied {
+						apiError(ctx, http.StatusForbidden, err)
+					} else {
+						apiError(ctx, http.StatusInternalServerError, err)
+					}
 					return
 				}
 
@@ -288,7 +314,7 @@ func InitiateUploadBlob(ctx *context.Context) {
 			},
 		); err != nil {
 			switch err {
-			case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 				apiError(ctx, http.StatusForbidden, err)
 			default:
 				apiError(ctx, http.StatusInternalServerError, err)
@@ -433,7 +459,7 @@ func EndUploadBlob(ctx *context.Context) {
 		},
 	); err != nil {
 		switch err {
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -490,20 +516,33 @@ func getBlobFromContext(ctx *context.Context) (*packages_model.PackageFileDescri
 		return nil, container_model.ErrContainerBlobNotExist
 	}
 
-	return workaroundGetContainerBlob(ctx, &container_model.BlobSearchOptions{
+	opts := &container_model.BlobSearchOptions{
 		OwnerID: ctx.Package.Owner.ID,
 		Image:   ctx.PathParam("image"),
 		Digest:  d,
-	})
+	}
+
+	proxy, err := getImageProxy(ctx, ctx.Package.Owner, opts.Image)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		return proxy.getBlob(ctx, opts)
+	}
+
+	return workaroundGetContainerBlob(ctx, opts)
 }
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#checking-if-content-exists-in-the-registry
 func HeadBlob(ctx *context.Context) {
 	blob, err := getBlobFromContext(ctx)
 	if err != nil {
-		if err == container_model.ErrContainerBlobNotExist {
+		switch err {
+		case container_model.ErrContainerBlobNotExist:
 			apiErrorDefined(ctx, errBlobUnknown)
-		} else {
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
 			apiError(ctx, http.StatusInternalServerError, err)
 		}
 		return
@@ -520,9 +559,12 @@ func HeadBlob(ctx *context.Context) {
 func GetBlob(ctx *context.Context) {
 	blob, err := getBlobFromContext(ctx)
 	if err != nil {
-		if err == container_model.ErrContainerBlobNotExist {
+		switch err {
+		case container_model.ErrContainerBlobNotExist:
 			apiErrorDefined(ctx, errBlobUnknown)
-		} else {
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
 			apiError(ctx, http.StatusInternalServerError, err)
 		}
 		return
@@ -552,12 +594,17 @@ func DeleteBlob(ctx *context.Context) {
 
 // https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-manifests
 func UploadManifest(ctx *context.Context) {
+	if rejectProxyNamespacePush(ctx) {
+		return
+	}
+
 	reference := ctx.PathParam("reference")
 
 	mci := &manifestCreationInfo{
 		MediaType: ctx.Req.Header.Get("Content-Type"),
 		Owner:     ctx.Package.Owner,
 		Creator:   ctx.Doer,
+		RepoID:    packages_service.LinkedRepositoryID(ctx, ctx.Package.Owner.ID),
 		Image:     ctx.PathParam("image"),
 		Reference: reference,
 		IsTagged:  digest.Digest(reference).Validate() != nil,
@@ -590,7 +637,7 @@ func UploadManifest(ctx *context.Context) {
 			apiErrorDefined(ctx, errBlobUnknown)
 		} else {
 			switch err {
-			case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 				apiError(ctx, http.StatusForbidden, err)
 			default:
 				apiError(ctx, http.StatusInternalServerError, err)
@@ -599,6 +646,11 @@ func UploadManifest(ctx *context.Context) {
 		return
 	}
 
+	if mci.Subject != "" {
+		// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pushing-manifests-with-subject
+		ctx.Resp.Header().Set("OCI-Subject", mci.Subject)
+	}
+
 	setResponseHeaders(ctx.Resp, &containerHeaders{
 		Location:      fmt.Sprintf("/v2/%s/%s/manifests/%s", ctx.Package.Owner.LowerName, mci.Image, reference),
 		ContentDigest: digest,
@@ -632,6 +684,14 @@ func getManifestFromContext(ctx *context.Context) (*packages_model.PackageFileDe
 		return nil, err
 	}
 
+	proxy, err := getImageProxy(ctx, ctx.Package.Owner, opts.Image)
+	if err != nil {
+		return nil, err
+	}
+	if proxy != nil {
+		return proxy.getManifest(ctx, opts)
+	}
+
 	return workaroundGetContainerBlob(ctx, opts)
 }
 
@@ -639,9 +699,12 @@ func getManifestFromContext(ctx *context.Context) (*packages_model.PackageFileDe
 func HeadManifest(ctx *context.Context) {
 	manifest, err := getManifestFromContext(ctx)
 	if err != nil {
-		if err == container_model.ErrContainerBlobNotExist {
+		switch err {
+		case container_model.ErrContainerBlobNotExist:
 			apiErrorDefined(ctx, errManifestUnknown)
-		} else {
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
 			apiError(ctx, http.StatusInternalServerError, err)
 		}
 		return
@@ -659,9 +722,12 @@ func HeadManifest(ctx *context.Context) {
 func GetManifest(ctx *context.Context) {
 	manifest, err := getManifestFromContext(ctx)
 	if err != nil {
-		if err == container_model.ErrContainerBlobNotExist {
+		switch err {
+		case container_model.ErrContainerBlobNotExist:
 			apiErrorDefined(ctx, errManifestUnknown)
-		} else {
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+			apiError(ctx, http.StatusForbidden, err)
+		default:
 			apiError(ctx, http.StatusInternalServerError, err)
 		}
 		return
@@ -778,6 +844,80 @@ func GetTagList(ctx *context.Context) {
 	})
 }
 
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers
+func GetReferrers(ctx *context.Context) {
+	subject := digest.Digest(ctx.PathParam("digest"))
+	if subject.Validate() != nil {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	artifactType := ctx.FormTrim("artifactType")
+
+	pvs, err := container_model.GetReferrerVersions(ctx, &container_model.ReferrerSearchOptions{
+		OwnerID:      ctx.Package.Owner.ID,
+		Image:        ctx.PathParam("image"),
+		Subject:      string(subject),
+		ArtifactType: artifactType,
+	})
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	index := oci.Index{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2,
+		},
+		MediaType: oci.MediaTypeImageIndex,
+		Manifests: make([]oci.Descriptor, 0, len(pds)),
+	}
+
+	// The same manifest may be stored as tagged and untagged version
+	seen := make(map[string]bool)
+	for _, pd := range pds {
+		for _, pfd := range pd.Files {
+			if pfd.File.LowerName != container_model.ManifestFilename {
+				continue
+			}
+
+			manifestDigest := pfd.Properties.GetByName(container_module.PropertyDigest)
+			if seen[manifestDigest] {
+				continue
+			}
+			seen[manifestDigest] = true
+
+			metadata := pd.Metadata.(*container_module.Metadata)
+
+			index.Manifests = append(index.Manifests, oci.Descriptor{
+				MediaType:    pfd.Properties.GetByName(container_module.PropertyMediaType),
+				Digest:       digest.Digest(manifestDigest),
+				Size:         pfd.Blob.Size,
+				ArtifactType: metadata.ArtifactType,
+				Annotations:  metadata.Annotations,
+			})
+		}
+	}
+
+	if artifactType != "" {
+		ctx.Resp.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+
+	setResponseHeaders(ctx.Resp, &containerHeaders{
+		Status:      http.StatusOK,
+		ContentType: oci.MediaTypeImageIndex,
+	})
+	if err := json.NewEncoder(ctx.Resp).Encode(index); err != nil {
+		log.Error("JSON encode: %v", err)
+	}
+}
+
 // FIXME: Workaround to be removed in v1.20
 // https://github.com/go-gitea/gitea/issues/19586
 func workaroundGetContainerBlob(ctx *context.Context, opts *container_model.BlobSearchOptions) (*packages_model.PackageFileDescriptor, error) {
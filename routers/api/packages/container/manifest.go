@@ -44,13 +44,19 @@ type manifestCreationInfo struct {
 	MediaType  string
 	Owner      *user_model.User
 	Creator    *user_model.User
+	RepoID     int64 // the repository which the package is linked to if it's created
 	Image      string
 	Reference  string
 	IsTagged   bool
 	Properties map[string]string
+	Subject    string
 }
 
 func processManifest(ctx context.Context, mci *manifestCreationInfo, buf *packages_module.HashedBuffer) (string, error) {
+	if err := packages_service.CheckPackageWriteAccess(ctx, mci.Owner.ID, packages_model.TypeContainer, mci.Image); err != nil {
+		return "", err
+	}
+
 	var index oci.Index
 	if err := json.NewDecoder(buf).Decode(&index); err != nil {
 		return "", err
@@ -60,6 +66,15 @@ func processManifest(ctx context.Context, mci *manifestCreationInfo, buf *packag
 		return "", errUnsupported.WithMessage("Schema version is not supported")
 	}
 
+	// Image manifests and indexes share the subject field
+	// https://github.com/opencontainers/image-spec/blob/main/manifest.md#image-manifest-property-descriptions
+	if index.Subject != nil {
+		if err := index.Subject.Digest.Validate(); err != nil {
+			return "", errManifestInvalid.WithMessage("Subject digest is invalid")
+		}
+		mci.Subject = string(index.Subject.Digest)
+	}
+
 	if _, err := buf.Seek(0, io.SeekStart); err != nil {
 		return "", err
 	}
@@ -107,16 +122,32 @@ func processImageManifest(ctx context.Context, mci *manifestCreationInfo, buf *p
 			return err
 		}
 
-		configReader, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(configDescriptor.Blob.HashSHA256))
-		if err != nil {
-			return err
+		var metadata *container_module.Metadata
+		if strings.EqualFold(manifest.Config.MediaType, oci.MediaTypeEmptyJSON) {
+			// Artifacts like signatures or SBOMs don't have an image config
+			metadata = &container_module.Metadata{
+				Type: container_module.TypeOCI,
+			}
+		} else {
+			configReader, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(configDescriptor.Blob.HashSHA256))
+			if err != nil {
+				return err
+			}
+			defer configReader.Close()
+
+			metadata, err = container_module.ParseImageConfig(manifest.Config.MediaType, configReader)
+			if err != nil {
+				return err
+			}
 		}
-		defer configReader.Close()
 
-		metadata, err := container_module.ParseImageConfig(manifest.Config.MediaType, configReader)
-		if err != nil {
-			return err
+		// If the artifact type is missing the media type of the config is used instead
+		// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#listing-referrers
+		artifactType := manifest.ArtifactType
+		if artifactType == "" {
+			artifactType = manifest.Config.MediaType
 		}
+		setReferrerMetadata(mci, metadata, artifactType, manifest.Annotations)
 
 		blobReferences := make([]*blobReference, 0, 1+len(manifest.Layers))
 
@@ -185,6 +216,8 @@ func processImageManifest(ctx context.Context, mci *manifestCreationInfo, buf *p
 			return err
 		}
 
+		attachReferrerSBOMDocuments(ctx, mci, &manifest)
+
 		manifestDigest = digest
 
 		return nil
@@ -220,6 +253,8 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 			Manifests: make([]*container_module.Manifest, 0, len(index.Manifests)),
 		}
 
+		setReferrerMetadata(mci, metadata, index.ArtifactType, index.Annotations)
+
 		for _, manifest := range index.Manifests {
 			if !isImageManifestMediaType(manifest.MediaType) {
 				return errManifestInvalid
@@ -289,6 +324,8 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 			return err
 		}
 
+		attachAttestationSBOMDocuments(ctx, mci, &index, pv)
+
 		manifestDigest = digest
 
 		return nil
@@ -300,6 +337,17 @@ func processImageManifestIndex(ctx context.Context, mci *manifestCreationInfo, b
 	return manifestDigest, nil
 }
 
+// setReferrerMetadata stores the information needed to list the manifest as a referrer of its subject
+func setReferrerMetadata(mci *manifestCreationInfo, metadata *container_module.Metadata, artifactType string, annotations map[string]string) {
+	if mci.Subject == "" {
+		return
+	}
+
+	metadata.Subject = mci.Subject
+	metadata.ArtifactType = artifactType
+	metadata.Annotations = annotations
+}
+
 func notifyPackageCreate(ctx context.Context, doer *user_model.User, pv *packages_model.PackageVersion) error {
 	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
 	if err != nil {
@@ -315,6 +363,7 @@ func createPackageAndVersion(ctx context.Context, mci *manifestCreationInfo, met
 	created := true
 	p := &packages_model.Package{
 		OwnerID:   mci.Owner.ID,
+		RepoID:    mci.RepoID,
 		Type:      packages_model.TypeContainer,
 		Name:      strings.ToLower(mci.Image),
 		LowerName: strings.ToLower(mci.Image),
@@ -386,6 +435,18 @@ func createPackageAndVersion(ctx context.Context, mci *manifestCreationInfo, met
 			return nil, err
 		}
 	}
+	if metadata.Subject != "" {
+		props := map[string]string{
+			container_module.PropertyManifestSubject: metadata.Subject,
+			container_module.PropertyArtifactType:    metadata.ArtifactType,
+		}
+		for name, value := range props {
+			if _, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, pv.ID, name, value); err != nil {
+				log.Error("Error setting package version property: %v", err)
+				return nil, err
+			}
+		}
+	}
 
 	return pv, nil
 }
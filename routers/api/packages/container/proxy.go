@@ -0,0 +1,334 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/globallock"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	container_service "code.gitea.io/gitea/services/packages/container"
+
+	digest "github.com/opencontainers/go-digest"
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// imageProxy fetches missing content of an image in the pull-through cache namespace from the upstream registry
+// The image "{upstream}/{name}" of an organization is mirrored from "{name}" of the upstream registry.
+type imageProxy struct {
+	owner         *user_model.User
+	image         string
+	upstreamImage string
+	client        *container_service.UpstreamClient
+}
+
+// isProxyNamespace checks if the image is in the namespace of an upstream registry an organization could use as pull-through cache
+func isProxyNamespace(owner *user_model.User, image string) bool {
+	if !owner.IsOrganization() {
+		return false
+	}
+	name, _, ok := strings.Cut(image, "/")
+	return ok && setting.GetContainerProxyUpstream(name) != nil
+}
+
+// rejectProxyNamespacePush rejects pushes into the pull-through cache namespaces, which would shadow the upstream images
+func rejectProxyNamespacePush(ctx *context.Context) bool {
+	if !isProxyNamespace(ctx.Package.Owner, ctx.PathParam("image")) {
+		return false
+	}
+	apiErrorDefined(ctx, errDenied.WithMessage("Pushing into the namespace of an upstream registry is not allowed"))
+	return true
+}
+
+// getImageProxy returns the proxy if the image belongs to an upstream registry the owner uses as pull-through cache
+func getImageProxy(ctx *context.Context, owner *user_model.User, image string) (*imageProxy, error) {
+	if !isProxyNamespace(owner, image) {
+		return nil, nil
+	}
+
+	name, upstreamImage, _ := strings.Cut(image, "/")
+	upstream := setting.GetContainerProxyUpstream(name)
+
+	allowed, err := container_service.IsProxyUpstreamAllowed(ctx, owner, name)
+	if err != nil || !allowed {
+		return nil, err
+	}
+
+	return &imageProxy{
+		owner:         owner,
+		image:         image,
+		upstreamImage: upstreamImage,
+		client:        container_service.NewUpstreamClient(upstream),
+	}, nil
+}
+
+// getManifest gets the cached manifest or fetches it from the upstream registry
+// Cached tags are validated against the upstream registry again after the configured TTL.
+func (p *imageProxy) getManifest(ctx *context.Context, opts *container_model.BlobSearchOptions) (*packages_model.PackageFileDescriptor, error) {
+	reference := opts.Tag
+	if reference == "" {
+		reference = opts.Digest
+	}
+
+	releaser, err := globallock.Lock(ctx, fmt.Sprintf("container_proxy_%d_%s_%s", p.owner.ID, p.image, reference))
+	if err != nil {
+		return nil, err
+	}
+	defer releaser()
+
+	pfd, err := workaroundGetContainerBlob(ctx, opts)
+	if err != nil && err != container_model.ErrContainerBlobNotExist {
+		return nil, err
+	}
+
+	if pfd != nil {
+		// Manifests referenced by digest never change
+		if opts.Tag == "" {
+			return pfd, nil
+		}
+
+		isValid, err := isProxyValidationValid(ctx, pfd.File.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		if isValid {
+			return pfd, nil
+		}
+
+		upstreamDigest, err := p.client.GetManifestDigest(ctx, p.upstreamImage, reference)
+		if err != nil {
+			log.Warn("Serving cached manifest %s:%s because the upstream registry is not available: %v", p.image, reference, err)
+			return pfd, nil
+		}
+		if upstreamDigest == pfd.Properties.GetByName(container_module.PropertyDigest) {
+			return pfd, setProxyValidated(ctx, pfd.File.VersionID)
+		}
+	}
+
+	if err := p.fetchManifest(ctx, reference); err != nil {
+		if pfd != nil {
+			log.Warn("Serving cached manifest %s:%s because the upstream registry is not available: %v", p.image, reference, err)
+			return pfd, nil
+		}
+		if errors.Is(err, container_service.ErrUpstreamNotExist) {
+			return nil, container_model.ErrContainerBlobNotExist
+		}
+		return nil, err
+	}
+
+	return workaroundGetContainerBlob(ctx, opts)
+}
+
+// getBlob gets the cached blob or fetches it from the upstream registry
+func (p *imageProxy) getBlob(ctx *context.Context, opts *container_model.BlobSearchOptions) (*packages_model.PackageFileDescriptor, error) {
+	releaser, err := globallock.Lock(ctx, fmt.Sprintf("container_proxy_%d_%s_%s", p.owner.ID, p.image, opts.Digest))
+	if err != nil {
+		return nil, err
+	}
+	defer releaser()
+
+	pfd, err := workaroundGetContainerBlob(ctx, opts)
+	if err != container_model.ErrContainerBlobNotExist {
+		return pfd, err
+	}
+
+	if err := p.fetchBlob(ctx, opts.Digest, -1); err != nil {
+		if errors.Is(err, container_service.ErrUpstreamNotExist) {
+			return nil, container_model.ErrContainerBlobNotExist
+		}
+		return nil, err
+	}
+
+	return workaroundGetContainerBlob(ctx, opts)
+}
+
+// fetchManifest stores the manifest and all referenced manifests and blobs from the upstream registry
+func (p *imageProxy) fetchManifest(ctx *context.Context, reference string) error {
+	resp, err := p.client.GetManifest(ctx, p.upstreamImage, reference)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	maxSize := maxManifestSize + 1
+	buf, err := packages_module.CreateHashedBufferFromReaderWithSize(&io.LimitedReader{R: resp.Body, N: int64(maxSize)}, maxSize)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	if buf.Size() > maxManifestSize {
+		return errManifestInvalid.WithMessage("Manifest exceeds maximum size")
+	}
+
+	isTagged := digest.Digest(reference).Validate() != nil
+
+	manifestDigest := digestFromHashSummer(buf)
+	if (!isTagged && reference != manifestDigest) || (resp.Digest != "" && resp.Digest != manifestDigest) {
+		return errDigestInvalid.WithMessage("Upstream manifest digest does not match")
+	}
+
+	var manifest struct {
+		oci.Manifest
+		Manifests []oci.Descriptor `json:"manifests"`
+	}
+	if err := json.NewDecoder(buf).Decode(&manifest); err != nil {
+		return err
+	}
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	mediaType := resp.MediaType
+	if !isValidMediaType(mediaType) {
+		mediaType = manifest.MediaType
+	}
+
+	if isImageIndexMediaType(mediaType) {
+		for _, m := range manifest.Manifests {
+			if _, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+				OwnerID:    p.owner.ID,
+				Image:      p.image,
+				Digest:     string(m.Digest),
+				IsManifest: true,
+			}); err == nil {
+				continue
+			} else if err != container_model.ErrContainerBlobNotExist {
+				return err
+			}
+
+			if err := p.fetchManifest(ctx, string(m.Digest)); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, d := range append([]oci.Descriptor{manifest.Config}, manifest.Layers...) {
+			if _, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+				OwnerID: p.owner.ID,
+				Image:   p.image,
+				Digest:  string(d.Digest),
+			}); err == nil {
+				continue
+			} else if err != container_model.ErrContainerBlobNotExist {
+				return err
+			}
+
+			if err := p.fetchBlob(ctx, string(d.Digest), d.Size); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := processManifest(ctx, &manifestCreationInfo{
+		MediaType: mediaType,
+		Owner:     p.owner,
+		Creator:   user_model.NewGhostUser(),
+		Image:     p.image,
+		Reference: reference,
+		IsTagged:  isTagged,
+	}, buf); err != nil {
+		return err
+	}
+
+	if isTagged {
+		pv, err := packages_model.GetVersionByNameAndVersion(ctx, p.owner.ID, packages_model.TypeContainer, p.image, reference)
+		if err != nil {
+			return err
+		}
+		if err := setProxyValidated(ctx, pv.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchBlob stores the blob from the upstream registry like an uploaded blob
+// The size is checked against the package limits and quotas of the owner before the blob is downloaded,
+// it's taken from the response of the upstream registry if the size of the blob is unknown (-1).
+func (p *imageProxy) fetchBlob(ctx *context.Context, blobDigest string, size int64) error {
+	resp, err := p.client.GetBlob(ctx, p.upstreamImage, blobDigest)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if size < 0 {
+		size = resp.ContentLength
+	}
+	if size < 0 {
+		return errSizeInvalid.WithMessage("Upstream blob size is unknown")
+	}
+	if resp.ContentLength >= 0 && resp.ContentLength != size {
+		return errSizeInvalid.WithMessage("Upstream blob size does not match")
+	}
+
+	creator := user_model.NewGhostUser()
+	if err := packages_service.CheckSizeQuotaExceeded(ctx, creator, p.owner, packages_model.TypeContainer, size); err != nil {
+		return err
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReaderWithSize(&io.LimitedReader{R: resp.Body, N: size + 1}, packages_module.DefaultMemorySize)
+	if err != nil {
+		return err
+	}
+	defer buf.Close()
+
+	if buf.Size() != size {
+		return errSizeInvalid.WithMessage("Upstream blob size does not match")
+	}
+	if digestFromHashSummer(buf) != blobDigest {
+		return errDigestInvalid.WithMessage("Upstream blob digest does not match")
+	}
+
+	_, err = saveAsPackageBlob(ctx,
+		buf,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner: p.owner,
+				Name:  p.image,
+			},
+			Creator: creator,
+		},
+	)
+	return err
+}
+
+// isProxyValidationValid checks if the tag was validated against the upstream registry within the TTL
+// Tags without validation time weren't created by the proxy and are always validated.
+func isProxyValidationValid(ctx *context.Context, versionID int64) (bool, error) {
+	pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeVersion, versionID, container_module.PropertyProxyValidated)
+	if err != nil {
+		return false, err
+	}
+	if len(pps) == 0 {
+		return false, nil
+	}
+
+	validated, _ := strconv.ParseInt(pps[0].Value, 10, 64)
+	return time.Since(timeutil.TimeStamp(validated).AsTime()) < setting.Packages.ContainerProxyTagTTL, nil
+}
+
+func setProxyValidated(ctx *context.Context, versionID int64) error {
+	if err := packages_model.DeletePropertyByName(ctx, packages_model.PropertyTypeVersion, versionID, container_module.PropertyProxyValidated); err != nil {
+		return err
+	}
+	_, err := packages_model.InsertProperty(ctx, packages_model.PropertyTypeVersion, versionID, container_module.PropertyProxyValidated, strconv.FormatInt(int64(timeutil.TimeStampNow()), 10))
+	return err
+}
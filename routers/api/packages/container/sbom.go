@@ -0,0 +1,137 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	container_model "code.gitea.io/gitea/models/packages/container"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// BuildKit stores the attestations of an image as manifests in the image index which are annotated with these keys
+// https://github.com/moby/buildkit/blob/master/docs/attestations/attestation-storage.md
+const (
+	annotationReferenceType       = "vnd.docker.reference.type"
+	referenceTypeAttestationImage = "attestation-manifest"
+)
+
+// extractSBOMDocuments reads the SBOM documents stored in the layers of an artifact or attestation manifest
+func extractSBOMDocuments(ctx context.Context, mci *manifestCreationInfo, layers []oci.Descriptor) [][]byte {
+	var documents [][]byte
+	for _, layer := range layers {
+		mediaType := strings.ToLower(layer.MediaType)
+		if mediaType != sbom_module.MediaTypeCycloneDX && mediaType != sbom_module.MediaTypeSPDX && mediaType != sbom_module.MediaTypeInToto {
+			continue
+		}
+		if layer.Size > sbom_module.MaxDocumentSize {
+			continue
+		}
+
+		data, err := func() ([]byte, error) {
+			pfd, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+				OwnerID: mci.Owner.ID,
+				Image:   mci.Image,
+				Digest:  string(layer.Digest),
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			r, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pfd.Blob.HashSHA256))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+
+			if mediaType == sbom_module.MediaTypeInToto {
+				return sbom_module.ExtractFromInTotoStatement(r)
+			}
+			return io.ReadAll(io.LimitReader(r, sbom_module.MaxDocumentSize))
+		}()
+		if err != nil {
+			log.Warn("Error reading SBOM layer %s of image %s: %v", layer.Digest, mci.Image, err)
+			continue
+		}
+		if data != nil {
+			documents = append(documents, data)
+		}
+	}
+	return documents
+}
+
+// attachReferrerSBOMDocuments attaches the SBOM documents of an artifact manifest to the image versions of its subject
+func attachReferrerSBOMDocuments(ctx context.Context, mci *manifestCreationInfo, manifest *oci.Manifest) {
+	if mci.Subject == "" {
+		return
+	}
+
+	documents := extractSBOMDocuments(ctx, mci, manifest.Layers)
+	if len(documents) == 0 {
+		return
+	}
+
+	pvs, err := container_model.GetManifestVersions(ctx, &container_model.BlobSearchOptions{
+		OwnerID:    mci.Owner.ID,
+		Image:      mci.Image,
+		Digest:     mci.Subject,
+		IsManifest: true,
+	})
+	if err != nil {
+		log.Error("Error getting versions of manifest %s: %v", mci.Subject, err)
+		return
+	}
+
+	for _, pv := range pvs {
+		packages_sbom_service.AttachExtractedDocuments(ctx, pv, documents)
+	}
+}
+
+// attachAttestationSBOMDocuments attaches the SBOM documents of the attestation manifests listed in the image index to the index version
+func attachAttestationSBOMDocuments(ctx context.Context, mci *manifestCreationInfo, index *oci.Index, pv *packages_model.PackageVersion) {
+	for _, m := range index.Manifests {
+		if m.Annotations[annotationReferenceType] != referenceTypeAttestationImage {
+			continue
+		}
+
+		manifest, err := func() (*oci.Manifest, error) {
+			pfd, err := container_model.GetContainerBlob(ctx, &container_model.BlobSearchOptions{
+				OwnerID:    mci.Owner.ID,
+				Image:      mci.Image,
+				Digest:     string(m.Digest),
+				IsManifest: true,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			r, err := packages_module.NewContentStore().Get(packages_module.BlobHash256Key(pfd.Blob.HashSHA256))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+
+			var manifest *oci.Manifest
+			if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+				return nil, err
+			}
+			return manifest, nil
+		}()
+		if err != nil || manifest == nil {
+			log.Warn("Error reading attestation manifest %s of image %s: %v", m.Digest, mci.Image, err)
+			continue
+		}
+
+		packages_sbom_service.AttachExtractedDocuments(ctx, pv, extractSBOMDocuments(ctx, mci, manifest.Layers))
+	}
+}
@@ -23,6 +23,11 @@ import (
 	debian_service "code.gitea.io/gitea/services/packages/debian"
 )
 
+// ResolvePackage resolves the package addressed by the route, the uploaded package is named by its control file
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	return context.PackageRoute{Type: packages_model.TypeDebian, Name: ctx.PathParam("name"), Upload: ctx.Req.Method == http.MethodPut}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		ctx.PlainText(status, message)
@@ -190,7 +195,7 @@ func UploadPackageFile(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion, packages_model.ErrDuplicatePackageFile:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
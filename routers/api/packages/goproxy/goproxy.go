@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"sort"
+	"strings"
 	"time"
 
 	packages_model "code.gitea.io/gitea/models/packages"
@@ -21,6 +22,17 @@ import (
 	packages_service "code.gitea.io/gitea/services/packages"
 )
 
+// ResolvePackage resolves the module addressed by the route, the uploaded module is named by its go.mod file
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeGo, Upload: ctx.Req.Method == http.MethodPut}
+	if path := ctx.PathParam("*"); strings.HasSuffix(path, "/@latest") {
+		route.Name = path[:len(path)-len("/@latest")]
+	} else if name, _, ok := strings.Cut(path, "/@v/"); ok {
+		route.Name = name
+	}
+	return route
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		ctx.PlainText(status, message)
@@ -212,7 +224,7 @@ func UploadPackage(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
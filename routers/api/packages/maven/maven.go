@@ -4,6 +4,7 @@
 package maven
 
 import (
+	std_ctx "context"
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
@@ -28,6 +29,7 @@ import (
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
 )
 
 const (
@@ -47,6 +49,15 @@ var (
 	illegalCharacters    = regexp.MustCompile(`[\\/:"<>|?\*]`)
 )
 
+// ResolvePackage resolves the package addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeMaven}
+	if params, err := extractPathParameters(ctx); err == nil {
+		route.Name = params.GroupID + "-" + params.ArtifactID
+	}
+	return route
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		// The maven client does not present the error message to the user. Log it for users with access to server logs.
@@ -69,7 +80,7 @@ func ProvidePackageFileHeader(ctx *context.Context) {
 }
 
 func handlePackageFile(ctx *context.Context, serveContent bool) {
-	params, err := extractPathParameters(ctx)
+	params, err := extractPathParameters(ctx.Base)
 	if err != nil {
 		apiError(ctx, http.StatusBadRequest, err)
 		return
@@ -91,10 +102,6 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
-	if len(pvs) == 0 {
-		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
-		return
-	}
 
 	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
 	if err != nil {
@@ -107,17 +114,41 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 		return pds[i].Version.CreatedUnix < pds[j].Version.CreatedUnix
 	})
 
-	xmlMetadata, err := xml.Marshal(createMetadataResponse(pds))
+	var resp *MetadataResponse
+	if len(pds) != 0 {
+		resp = createMetadataResponse(pds)
+	}
+
+	upstreamMetadata, err := getUpstreamMetadata(ctx, params)
+	if err != nil {
+		if resp == nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		log.Warn("Serving local index of Maven package %s because the upstream registry is not available: %v", packageName, err)
+	}
+	if upstreamMetadata != nil && len(upstreamMetadata.Version) != 0 {
+		resp = mergeUpstreamMetadata(resp, upstreamMetadata)
+	}
+
+	if resp == nil {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	xmlMetadata, err := xml.Marshal(resp)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 	xmlMetadataWithHeader := append([]byte(xml.Header), xmlMetadata...)
 
-	latest := pds[len(pds)-1]
-	// http.TimeFormat required a UTC time, refer to https://pkg.go.dev/net/http#TimeFormat
-	lastModifed := latest.Version.CreatedUnix.AsTime().UTC().Format(http.TimeFormat)
-	ctx.Resp.Header().Set("Last-Modified", lastModifed)
+	if len(pds) != 0 {
+		latest := pds[len(pds)-1]
+		// http.TimeFormat required a UTC time, refer to https://pkg.go.dev/net/http#TimeFormat
+		lastModifed := latest.Version.CreatedUnix.AsTime().UTC().Format(http.TimeFormat)
+		ctx.Resp.Header().Set("Last-Modified", lastModifed)
+	}
 
 	ext := strings.ToLower(filepath.Ext(params.Filename))
 	if isChecksumExtension(ext) {
@@ -149,16 +180,6 @@ func serveMavenMetadata(ctx *context.Context, params parameters) {
 func servePackageFile(ctx *context.Context, params parameters, serveContent bool) {
 	packageName := params.GroupID + "-" + params.ArtifactID
 
-	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName, params.Version)
-	if err != nil {
-		if err == packages_model.ErrPackageNotExist {
-			apiError(ctx, http.StatusNotFound, err)
-		} else {
-			apiError(ctx, http.StatusInternalServerError, err)
-		}
-		return
-	}
-
 	filename := params.Filename
 
 	ext := strings.ToLower(filepath.Ext(filename))
@@ -166,10 +187,26 @@ func servePackageFile(ctx *context.Context, params parameters, serveContent bool
 		filename = filename[:len(filename)-len(ext)]
 	}
 
-	pf, err := packages_model.GetFileForVersionByName(ctx, pv.ID, filename, packages_model.EmptyFileKey)
+	getFile := func() (*packages_model.PackageFile, error) {
+		pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeMaven, packageName, params.Version)
+		if err != nil {
+			return nil, err
+		}
+		return packages_model.GetFileForVersionByName(ctx, pv.ID, filename, packages_model.EmptyFileKey)
+	}
+
+	pf, err := getFile()
+	if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
+		// the file may be available in the upstream registry
+		if err = fetchUpstreamFile(ctx, params, filename); err == nil {
+			pf, err = getFile()
+		}
+	}
 	if err != nil {
-		if err == packages_model.ErrPackageFileNotExist {
+		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
+		} else if upstream_service.IsSizeLimitExceeded(err) {
+			apiError(ctx, http.StatusForbidden, err)
 		} else {
 			apiError(ctx, http.StatusInternalServerError, err)
 		}
@@ -232,7 +269,7 @@ func mavenPkgNameKey(packageName string) string {
 
 // UploadPackageFile adds a file to the package. If the package does not exist, it gets created.
 func UploadPackageFile(ctx *context.Context) {
-	params, err := extractPathParameters(ctx)
+	params, err := extractPathParameters(ctx.Base)
 	if err != nil {
 		apiError(ctx, http.StatusBadRequest, err)
 		return
@@ -341,24 +378,13 @@ func UploadPackageFile(ctx *context.Context) {
 			return
 		}
 
-		if pvci.Metadata != nil {
-			pv, err := packages_model.GetVersionByNameAndVersion(ctx, pvci.Owner.ID, pvci.PackageType, pvci.Name, pvci.Version)
-			if err != nil && err != packages_model.ErrPackageNotExist {
+		if err := updatePackageVersionMetadata(ctx, pvci); err != nil {
+			if err == packages_service.ErrPackageAccessDenied {
+				apiError(ctx, http.StatusForbidden, err)
+			} else {
 				apiError(ctx, http.StatusInternalServerError, err)
-				return
-			}
-			if pv != nil {
-				raw, err := json.Marshal(pvci.Metadata)
-				if err != nil {
-					apiError(ctx, http.StatusInternalServerError, err)
-					return
-				}
-				pv.MetadataJSON = string(raw)
-				if err := packages_model.UpdateVersion(ctx, pv); err != nil {
-					apiError(ctx, http.StatusInternalServerError, err)
-					return
-				}
 			}
+			return
 		}
 
 		if _, err := buf.Seek(0, io.SeekStart); err != nil {
@@ -376,7 +402,7 @@ func UploadPackageFile(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageFile:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -387,6 +413,32 @@ func UploadPackageFile(ctx *context.Context) {
 	ctx.Status(http.StatusCreated)
 }
 
+// updatePackageVersionMetadata updates the metadata of an existing package version with the metadata of the pom file
+func updatePackageVersionMetadata(ctx std_ctx.Context, pvci *packages_service.PackageCreationInfo) error {
+	if pvci.Metadata == nil {
+		return nil
+	}
+
+	if err := packages_service.CheckPackageWriteAccess(ctx, pvci.Owner.ID, pvci.PackageType, pvci.Name); err != nil {
+		return err
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, pvci.Owner.ID, pvci.PackageType, pvci.Name, pvci.Version)
+	if err != nil {
+		if err == packages_model.ErrPackageNotExist {
+			return nil
+		}
+		return err
+	}
+
+	raw, err := json.Marshal(pvci.Metadata)
+	if err != nil {
+		return err
+	}
+	pv.MetadataJSON = string(raw)
+	return packages_model.UpdateVersion(ctx, pv)
+}
+
 func isChecksumExtension(ext string) bool {
 	return ext == extensionMD5 || ext == extensionSHA1 || ext == extensionSHA256 || ext == extensionSHA512
 }
@@ -399,7 +451,7 @@ type parameters struct {
 	IsMeta     bool
 }
 
-func extractPathParameters(ctx *context.Context) (parameters, error) {
+func extractPathParameters(ctx *context.Base) (parameters, error) {
 	parts := strings.Split(ctx.PathParam("*"), "/")
 
 	p := parameters{
@@ -0,0 +1,174 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package maven
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/globallock"
+	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
+)
+
+// getUpstreamClient returns the client for the upstream registry if the package may be fetched from it
+func getUpstreamClient(params parameters) *upstream_service.Client {
+	client := upstream_service.NewClient(string(packages_model.TypeMaven))
+	if client == nil || !client.IsPackageAllowed(params.GroupID+"-"+params.ArtifactID) {
+		return nil
+	}
+	return client
+}
+
+// getUpstreamMetadata fetches the package index of the upstream registry
+// It returns nil if no upstream registry is configured, the package is blocked or the upstream registry does not know the package.
+func getUpstreamMetadata(ctx *context.Context, params parameters) (*MetadataResponse, error) {
+	client := getUpstreamClient(params)
+	if client == nil {
+		return nil, nil
+	}
+
+	rc, err := client.Get(ctx, strings.ReplaceAll(params.GroupID, ".", "/")+"/"+params.ArtifactID+"/"+mavenMetadataFile)
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	var metadata *MetadataResponse
+	if err := xml.NewDecoder(rc).Decode(&metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// mergeUpstreamMetadata adds the versions of the upstream registry which are not present in the local package index
+// Versions only present in this registry are considered newer than the upstream versions.
+func mergeUpstreamMetadata(local, upstream *MetadataResponse) *MetadataResponse {
+	if local == nil {
+		return upstream
+	}
+
+	versions := make([]string, 0, len(upstream.Version)+len(local.Version))
+	for _, v := range upstream.Version {
+		if !slices.Contains(local.Version, v) {
+			versions = append(versions, v)
+		}
+	}
+	versions = append(versions, local.Version...)
+
+	resp := &MetadataResponse{
+		GroupID:    local.GroupID,
+		ArtifactID: local.ArtifactID,
+		Latest:     versions[len(versions)-1],
+		Version:    versions,
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		if !strings.HasSuffix(versions[i], "-SNAPSHOT") {
+			resp.Release = versions[i]
+			break
+		}
+	}
+	return resp
+}
+
+// fetchUpstreamFile fetches the file from the upstream registry and adds it to the package version
+// The file is verified against the SHA1 checksum the upstream registry provides.
+func fetchUpstreamFile(ctx *context.Context, params parameters, filename string) error {
+	client := getUpstreamClient(params)
+	if client == nil {
+		return packages_model.ErrPackageFileNotExist
+	}
+
+	packageName := params.GroupID + "-" + params.ArtifactID
+
+	releaser, err := globallock.Lock(ctx, mavenPkgNameKey(packageName))
+	if err != nil {
+		return err
+	}
+	defer releaser()
+
+	filePath := strings.ReplaceAll(params.GroupID, ".", "/") + "/" + params.ArtifactID + "/" + params.Version + "/" + filename
+
+	buf, err := client.Download(ctx, filePath, ctx.Package.Owner)
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return packages_model.ErrPackageFileNotExist
+		}
+		return err
+	}
+	defer buf.Close()
+
+	checksum, err := client.Get(ctx, filePath+extensionSHA1)
+	if err == nil {
+		defer checksum.Close()
+
+		expected, err := io.ReadAll(io.LimitReader(checksum, 1024))
+		if err != nil {
+			return err
+		}
+
+		_, hashSHA1, _, _ := buf.Sums()
+		// the checksum file may contain the filename after the hash
+		if fields := strings.Fields(string(expected)); len(fields) == 0 || !strings.EqualFold(fields[0], hex.EncodeToString(hashSHA1)) {
+			return errors.New("hash mismatch of upstream file")
+		}
+	} else if !errors.Is(err, upstream_service.ErrNotExist) {
+		return err
+	}
+
+	creator := user_model.NewGhostUser()
+
+	pvci := &packages_service.PackageCreationInfo{
+		PackageInfo: packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeMaven,
+			Name:        packageName,
+			Version:     params.Version,
+		},
+		SemverCompatible: false,
+		Creator:          creator,
+	}
+	pfci := &packages_service.PackageFileCreationInfo{
+		PackageFileInfo: packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+		Creator:           creator,
+		Data:              buf,
+		OverwriteExisting: params.IsMeta,
+	}
+
+	if filepath.Ext(filename) == extensionPom {
+		pfci.IsLead = true
+
+		if pvci.Metadata, err = maven_module.ParsePackageMetaData(buf); err != nil {
+			return err
+		}
+		if err := updatePackageVersionMetadata(ctx, pvci); err != nil {
+			return err
+		}
+
+		if _, err := buf.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(ctx, pvci, pfci)
+	// a concurrent request may have cached the file already
+	if err == packages_model.ErrDuplicatePackageFile {
+		return nil
+	}
+	return err
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"code.gitea.io/gitea/models/db"
@@ -17,14 +18,18 @@ import (
 	access_model "code.gitea.io/gitea/models/perm/access"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
 
 	"github.com/hashicorp/go-version"
 )
@@ -32,6 +37,11 @@ import (
 // errInvalidTagName indicates an invalid tag name
 var errInvalidTagName = errors.New("The tag name is invalid")
 
+// ResolvePackage resolves the package addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	return context.PackageRoute{Type: packages_model.TypeNpm, Name: packageNameFromParams(ctx)}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		ctx.JSON(status, map[string]string{
@@ -42,7 +52,7 @@ func apiError(ctx *context.Context, status int, obj any) {
 
 // packageNameFromParams gets the package name from the url parameters
 // Variations: /name/, /@scope/name/, /@scope%2Fname/
-func packageNameFromParams(ctx *context.Context) string {
+func packageNameFromParams(ctx *context.Base) string {
 	scope := ctx.PathParam("scope")
 	id := ctx.PathParam("id")
 	if scope != "" {
@@ -53,55 +63,84 @@ func packageNameFromParams(ctx *context.Context) string {
 
 // PackageMetadata returns the metadata for a single package
 func PackageMetadata(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
+
+	registryURL := setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/npm"
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
 	if err != nil {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
-	if len(pvs) == 0 {
-		apiError(ctx, http.StatusNotFound, err)
-		return
+
+	var resp *npm_module.PackageMetadata
+	if len(pvs) != 0 {
+		pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp = createPackageMetadataResponse(registryURL, pds)
 	}
 
-	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
+	upstreamMetadata, err := getUpstreamMetadata(ctx, packageName)
 	if err != nil {
-		apiError(ctx, http.StatusInternalServerError, err)
-		return
+		if resp == nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		log.Warn("Serving local metadata of npm package %s because the upstream registry is not available: %v", packageName, err)
+	}
+	if upstreamMetadata != nil {
+		resp = mergeUpstreamMetadata(registryURL, resp, upstreamMetadata)
 	}
 
-	resp := createPackageMetadataResponse(
-		setting.AppURL+"api/packages/"+ctx.Package.Owner.Name+"/npm",
-		pds,
-	)
+	if resp == nil {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
 
 	ctx.JSON(http.StatusOK, resp)
 }
 
 // DownloadPackageFile serves the content of a package
 func DownloadPackageFile(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
 	packageVersion := ctx.PathParam("version")
 	filename := ctx.PathParam("filename")
 
-	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
-		ctx,
-		&packages_service.PackageInfo{
-			Owner:       ctx.Package.Owner,
-			PackageType: packages_model.TypeNpm,
-			Name:        packageName,
-			Version:     packageVersion,
-		},
-		&packages_service.PackageFileInfo{
-			Filename: filename,
-		},
-	)
+	getFileStream := func() (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+		return packages_service.GetFileStreamByPackageNameAndVersion(
+			ctx,
+			&packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeNpm,
+				Name:        packageName,
+				Version:     packageVersion,
+			},
+			&packages_service.PackageFileInfo{
+				Filename: filename,
+			},
+		)
+	}
+
+	s, u, pf, err := getFileStream()
+	if err == packages_model.ErrPackageNotExist {
+		// the version may be available in the upstream registry
+		if err = fetchUpstreamPackage(ctx, packageName, packageVersion, filename); err == nil {
+			s, u, pf, err = getFileStream()
+		}
+	}
 	if err != nil {
 		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
 			return
 		}
+		if upstream_service.IsSizeLimitExceeded(err) {
+			apiError(ctx, http.StatusForbidden, err)
+			return
+		}
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -118,7 +157,7 @@ func DownloadPackageFileByName(ctx *context.Context) {
 		Type:    packages_model.TypeNpm,
 		Name: packages_model.SearchValue{
 			ExactMatch: true,
-			Value:      packageNameFromParams(ctx),
+			Value:      packageNameFromParams(ctx.Base),
 		},
 		HasFileWithName: filename,
 		IsInternal:      optional.Some(false),
@@ -216,7 +255,7 @@ func UploadPackage(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -242,6 +281,12 @@ func UploadPackage(ctx *context.Context) {
 		}
 	}
 
+	if documents, err := sbom_module.ExtractFromNpmTarball(bytes.NewReader(npmPackage.Data)); err != nil {
+		log.Warn("Error extracting SBOM documents of npm package %s: %v", npmPackage.Name, err)
+	} else {
+		packages_sbom_service.AttachExtractedDocuments(ctx, pv, documents)
+	}
+
 	ctx.Status(http.StatusCreated)
 }
 
@@ -253,7 +298,7 @@ func DeletePreview(ctx *context.Context) {
 
 // DeletePackageVersion deletes the package version
 func DeletePackageVersion(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
 	packageVersion := ctx.PathParam("version")
 
 	err := packages_service.RemovePackageVersionByNameAndVersion(
@@ -280,7 +325,7 @@ func DeletePackageVersion(ctx *context.Context) {
 
 // DeletePackage deletes the package and all versions
 func DeletePackage(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
 	if err != nil {
@@ -305,7 +350,7 @@ func DeletePackage(ctx *context.Context) {
 
 // ListPackageTags returns all tags for a package
 func ListPackageTags(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
 	if err != nil {
@@ -330,7 +375,12 @@ func ListPackageTags(ctx *context.Context) {
 
 // AddPackageTag adds a tag to the package
 func AddPackageTag(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
+
+	if err := packages_service.CheckPackageWriteAccess(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName); err != nil {
+		apiError(ctx, http.StatusForbidden, err)
+		return
+	}
 
 	body, err := io.ReadAll(ctx.Req.Body)
 	if err != nil {
@@ -361,7 +411,7 @@ func AddPackageTag(ctx *context.Context) {
 
 // DeletePackageTag deletes a package tag
 func DeletePackageTag(ctx *context.Context) {
-	packageName := packageNameFromParams(ctx)
+	packageName := packageNameFromParams(ctx.Base)
 
 	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeNpm, packageName)
 	if err != nil {
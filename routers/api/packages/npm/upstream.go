@@ -0,0 +1,227 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package npm
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
+
+	"github.com/hashicorp/go-version"
+)
+
+// getUpstreamMetadata fetches the metadata of the package from the upstream registry
+// It returns nil if no upstream registry is configured, the package is blocked or the upstream registry does not know the package.
+func getUpstreamMetadata(ctx *context.Context, packageName string) (*npm_module.PackageMetadata, error) {
+	client := upstream_service.NewClient(string(packages_model.TypeNpm))
+	if client == nil || !client.IsPackageAllowed(packageName) {
+		return nil, nil
+	}
+
+	// scoped packages are requested as "@scope%2fname"
+	rc, err := client.Get(ctx, strings.Replace(packageName, "/", "%2f", 1))
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	// The metadata of old packages does not always follow the current format.
+	// Versions which can't be parsed are ignored instead of failing for the whole package.
+	var raw struct {
+		Readme   string                     `json:"readme"`
+		DistTags map[string]string          `json:"dist-tags"`
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := json.NewDecoder(rc).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	metadata := &npm_module.PackageMetadata{
+		ID:       packageName,
+		Name:     packageName,
+		Readme:   raw.Readme,
+		DistTags: make(map[string]string),
+		Versions: make(map[string]*npm_module.PackageMetadataVersion),
+	}
+	for v, data := range raw.Versions {
+		var pmv *npm_module.PackageMetadataVersion
+		if err := json.Unmarshal(data, &pmv); err != nil || pmv == nil {
+			continue
+		}
+		if pmv.Name != packageName || pmv.Version != v || pmv.Dist.Tarball == "" {
+			continue
+		}
+		if _, err := version.NewSemver(v); err != nil {
+			continue
+		}
+		metadata.Versions[v] = pmv
+	}
+	for tag, v := range raw.DistTags {
+		if _, ok := metadata.Versions[v]; ok {
+			metadata.DistTags[tag] = v
+		}
+	}
+
+	return metadata, nil
+}
+
+// mergeUpstreamMetadata adds the versions and tags of the upstream registry which are not present in the local metadata
+// The tarball URLs of upstream versions point to this registry, which fetches and caches them on first download.
+func mergeUpstreamMetadata(registryURL string, local, upstream *npm_module.PackageMetadata) *npm_module.PackageMetadata {
+	if local == nil {
+		local = &npm_module.PackageMetadata{
+			ID:       upstream.ID,
+			Name:     upstream.Name,
+			Readme:   upstream.Readme,
+			DistTags: make(map[string]string),
+			Versions: make(map[string]*npm_module.PackageMetadataVersion),
+		}
+		if latest, ok := upstream.Versions[upstream.DistTags["latest"]]; ok {
+			local.Description = latest.Description
+			local.Homepage = latest.Homepage
+			local.Author = latest.Author
+			local.License = latest.License
+			local.Repository = latest.Repository
+		}
+	}
+
+	for v, pmv := range upstream.Versions {
+		if _, ok := local.Versions[v]; ok {
+			continue
+		}
+
+		merged := *pmv
+		merged.Dist.Tarball = fmt.Sprintf("%s/%s/-/%s/%s", registryURL, url.QueryEscape(pmv.Name), url.PathEscape(pmv.Version), url.PathEscape(upstreamTarballName(pmv)))
+		local.Versions[v] = &merged
+	}
+
+	for tag, v := range upstream.DistTags {
+		if _, ok := local.DistTags[tag]; !ok {
+			local.DistTags[tag] = v
+		}
+	}
+
+	return local
+}
+
+func upstreamTarballName(pmv *npm_module.PackageMetadataVersion) string {
+	if u, err := url.Parse(pmv.Dist.Tarball); err == nil {
+		return strings.ToLower(path.Base(u.Path))
+	}
+	return ""
+}
+
+// fetchUpstreamPackage fetches the tarball of the version from the upstream registry and stores it as package version
+func fetchUpstreamPackage(ctx *context.Context, packageName, packageVersion, filename string) error {
+	metadata, err := getUpstreamMetadata(ctx, packageName)
+	if err != nil {
+		return err
+	}
+	if metadata == nil {
+		return packages_model.ErrPackageNotExist
+	}
+
+	pmv, ok := metadata.Versions[packageVersion]
+	if !ok || upstreamTarballName(pmv) != strings.ToLower(filename) {
+		return packages_model.ErrPackageNotExist
+	}
+
+	client := upstream_service.NewClient(string(packages_model.TypeNpm))
+	buf, err := client.Download(ctx, pmv.Dist.Tarball, ctx.Package.Owner)
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return packages_model.ErrPackageNotExist
+		}
+		return err
+	}
+	defer buf.Close()
+
+	_, hashSHA1, _, hashSHA512 := buf.Sums()
+	if integrity, ok := strings.CutPrefix(pmv.Dist.Integrity, "sha512-"); ok {
+		if integrity != base64.StdEncoding.EncodeToString(hashSHA512) {
+			return npm_module.ErrInvalidIntegrity
+		}
+	} else if !strings.EqualFold(pmv.Dist.Shasum, hex.EncodeToString(hashSHA1)) {
+		return npm_module.ErrInvalidIntegrity
+	}
+
+	scope := ""
+	name := pmv.Name
+	if s, n, ok := strings.Cut(pmv.Name, "/"); ok {
+		scope = s
+		name = n
+	}
+
+	projectURL := pmv.Homepage
+	if !validation.IsValidURL(projectURL) {
+		projectURL = ""
+	}
+
+	readme := pmv.Readme
+	if readme == "" && metadata.DistTags["latest"] == pmv.Version {
+		readme = metadata.Readme
+	}
+
+	creator := user_model.NewGhostUser()
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeNpm,
+				Name:        pmv.Name,
+				Version:     pmv.Version,
+			},
+			SemverCompatible: true,
+			Creator:          creator,
+			Metadata: &npm_module.Metadata{
+				Scope:                   scope,
+				Name:                    name,
+				Description:             pmv.Description,
+				Author:                  pmv.Author.Name,
+				License:                 pmv.License,
+				ProjectURL:              projectURL,
+				Keywords:                pmv.Keywords,
+				Dependencies:            pmv.Dependencies,
+				BundleDependencies:      pmv.BundleDependencies,
+				DevelopmentDependencies: pmv.DevDependencies,
+				PeerDependencies:        pmv.PeerDependencies,
+				OptionalDependencies:    pmv.OptionalDependencies,
+				Bin:                     pmv.Bin,
+				Readme:                  readme,
+				Repository:              pmv.Repository,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: upstreamTarballName(pmv),
+			},
+			Creator: creator,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	// a concurrent request may have cached the version already
+	if err == packages_model.ErrDuplicatePackageVersion {
+		return nil
+	}
+	return err
+}
@@ -34,6 +34,12 @@ func jsonResponse(ctx *context.Context, status int, obj any) {
 	}
 }
 
+// ResolvePackage resolves the package addressed by the route, the uploaded package is named by its pubspec file
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	name := ctx.PathParam("id")
+	return context.PackageRoute{Type: packages_model.TypePub, Name: name, Upload: name == ""}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	type Error struct {
 		Code    string `json:"code"`
@@ -214,7 +220,7 @@ func UploadPackageFile(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -7,18 +7,25 @@ import (
 	"encoding/hex"
 	"io"
 	"net/http"
+	"net/url"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
 	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	pypi_module "code.gitea.io/gitea/modules/packages/pypi"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers/api/packages/helper"
 	"code.gitea.io/gitea/services/context"
 	packages_service "code.gitea.io/gitea/services/packages"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
 )
 
 // https://peps.python.org/pep-0426/#name
@@ -37,6 +44,14 @@ var versionMatcher = regexp.MustCompile(`\Av?` +
 	`(?:\+[a-z0-9]+(?:[-_\.][a-z0-9]+)*)?` + // local version
 	`\z`)
 
+// ResolvePackage resolves the package addressed by the route, the uploaded package is named by the form
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	if ctx.Req.Method == http.MethodPost {
+		return context.PackageRoute{Type: packages_model.TypePyPI, Upload: true}
+	}
+	return context.PackageRoute{Type: packages_model.TypePyPI, Name: normalizer.Replace(ctx.PathParam("id"))}
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	helper.LogAndProcessError(ctx, status, obj, func(message string) {
 		ctx.PlainText(status, message)
@@ -52,10 +67,6 @@ func PackageMetadata(ctx *context.Context) {
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
-	if len(pvs) == 0 {
-		apiError(ctx, http.StatusNotFound, err)
-		return
-	}
 
 	pds, err := packages_model.GetPackageDescriptors(ctx, pvs)
 	if err != nil {
@@ -63,14 +74,43 @@ func PackageMetadata(ctx *context.Context) {
 		return
 	}
 
+	upstreamFiles, err := getUpstreamFiles(ctx, packageName)
+	if err != nil {
+		if len(pds) == 0 {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		log.Warn("Serving local files of PyPI package %s because the upstream registry is not available: %v", packageName, err)
+	}
+
+	// files cached from the upstream registry are listed as local files
+	localFiles := make(container.Set[string])
+	for _, pd := range pds {
+		for _, pfd := range pd.Files {
+			localFiles.Add(pfd.File.LowerName)
+		}
+	}
+	upstreamFiles = slices.DeleteFunc(upstreamFiles, func(f *upstreamFile) bool {
+		return localFiles.Contains(strings.ToLower(f.Filename))
+	})
+
+	if len(pds) == 0 && len(upstreamFiles) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
 	// sort package descriptors by version to mimic PyPI format
 	sort.Slice(pds, func(i, j int) bool {
 		return strings.Compare(pds[i].Version.Version, pds[j].Version.Version) < 0
 	})
 
 	ctx.Data["RegistryURL"] = setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/pypi"
-	ctx.Data["PackageDescriptor"] = pds[0]
+	ctx.Data["PackageName"] = packageName
+	if len(pds) != 0 {
+		ctx.Data["PackageName"] = pds[0].Package.Name
+	}
 	ctx.Data["PackageDescriptors"] = pds
+	ctx.Data["UpstreamFiles"] = upstreamFiles
 	ctx.HTML(http.StatusOK, "api/packages/pypi/simple")
 }
 
@@ -80,23 +120,37 @@ func DownloadPackageFile(ctx *context.Context) {
 	packageVersion := ctx.PathParam("version")
 	filename := ctx.PathParam("filename")
 
-	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
-		ctx,
-		&packages_service.PackageInfo{
-			Owner:       ctx.Package.Owner,
-			PackageType: packages_model.TypePyPI,
-			Name:        packageName,
-			Version:     packageVersion,
-		},
-		&packages_service.PackageFileInfo{
-			Filename: filename,
-		},
-	)
+	getFileStream := func() (io.ReadSeekCloser, *url.URL, *packages_model.PackageFile, error) {
+		return packages_service.GetFileStreamByPackageNameAndVersion(
+			ctx,
+			&packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypePyPI,
+				Name:        packageName,
+				Version:     packageVersion,
+			},
+			&packages_service.PackageFileInfo{
+				Filename: filename,
+			},
+		)
+	}
+
+	s, u, pf, err := getFileStream()
+	if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
+		// the file may be available in the upstream registry
+		if err = fetchUpstreamFile(ctx, packageName, packageVersion, filename); err == nil {
+			s, u, pf, err = getFileStream()
+		}
+	}
 	if err != nil {
 		if err == packages_model.ErrPackageNotExist || err == packages_model.ErrPackageFileNotExist {
 			apiError(ctx, http.StatusNotFound, err)
 			return
 		}
+		if upstream_service.IsSizeLimitExceeded(err) {
+			apiError(ctx, http.StatusForbidden, err)
+			return
+		}
 		apiError(ctx, http.StatusInternalServerError, err)
 		return
 	}
@@ -144,7 +198,7 @@ func UploadPackageFile(ctx *context.Context) {
 		projectURL = ""
 	}
 
-	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+	pv, _, err := packages_service.CreatePackageOrAddFileToExisting(
 		ctx,
 		&packages_service.PackageCreationInfo{
 			PackageInfo: packages_service.PackageInfo{
@@ -178,7 +232,7 @@ func UploadPackageFile(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageFile:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -186,6 +240,15 @@ func UploadPackageFile(ctx *context.Context) {
 		return
 	}
 
+	// wheels may contain SBOM documents: https://peps.python.org/pep-0770/
+	if strings.HasSuffix(strings.ToLower(fileHeader.Filename), ".whl") {
+		if documents, err := sbom_module.ExtractFromWheel(buf, buf.Size()); err != nil {
+			log.Warn("Error extracting SBOM documents of PyPI package %s: %v", packageName, err)
+		} else {
+			packages_sbom_service.AttachExtractedDocuments(ctx, pv, documents)
+		}
+	}
+
 	ctx.Status(http.StatusCreated)
 }
 
@@ -36,3 +36,15 @@ func TestIsValidNameAndVersion(t *testing.T) {
 	assert.False(t, isValidNameAndVersion("test-name", "1.0.1aa"))
 	assert.False(t, isValidNameAndVersion("test-name", "1.0.0-alpha.beta"))
 }
+
+func TestVersionFromFilename(t *testing.T) {
+	assert.Equal(t, "2.31.0", versionFromFilename("requests-2.31.0-py3-none-any.whl"))
+	assert.Equal(t, "1.0", versionFromFilename("test_name-1.0-1-cp311-cp311-manylinux_2_17_x86_64.whl"))
+	assert.Equal(t, "2.31.0", versionFromFilename("requests-2.31.0.tar.gz"))
+	assert.Equal(t, "1.0rc1", versionFromFilename("test-name-1.0rc1.zip"))
+
+	assert.Empty(t, versionFromFilename("requests-2.31.0-py3.7.egg"))
+	assert.Empty(t, versionFromFilename("requests.tar.gz"))
+	assert.Empty(t, versionFromFilename("requests-invalid.tar.gz"))
+	assert.Empty(t, versionFromFilename("requests-2.31.0.whl"))
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package pypi
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	pypi_module "code.gitea.io/gitea/modules/packages/pypi"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	upstream_service "code.gitea.io/gitea/services/packages/upstream"
+
+	"golang.org/x/net/html"
+)
+
+// upstreamFile is a file listed on the simple index page of the upstream registry
+type upstreamFile struct {
+	URL            string
+	Filename       string
+	Version        string
+	SHA256         string
+	RequiresPython string
+}
+
+// getUpstreamFiles fetches the files of the package listed by the upstream registry
+// It returns nil if no upstream registry is configured, the package is blocked or the upstream registry does not know the package.
+func getUpstreamFiles(ctx *context.Context, packageName string) ([]*upstreamFile, error) {
+	client := upstream_service.NewClient(string(packages_model.TypePyPI))
+	if client == nil || !client.IsPackageAllowed(packageName) {
+		return nil, nil
+	}
+
+	// https://peps.python.org/pep-0503/
+	pageURL, err := url.Parse(client.URL() + "/" + url.PathEscape(packageName) + "/")
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := client.Get(ctx, pageURL.String())
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	files := make([]*upstreamFile, 0, 10)
+
+	z := html.NewTokenizer(rc)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		if tt != html.StartTagToken {
+			continue
+		}
+
+		token := z.Token()
+		if token.Data != "a" {
+			continue
+		}
+
+		f := &upstreamFile{}
+		for _, attr := range token.Attr {
+			switch attr.Key {
+			case "href":
+				f.URL = attr.Val
+			case "data-requires-python":
+				f.RequiresPython = attr.Val
+			}
+		}
+
+		fileURL, err := pageURL.Parse(f.URL)
+		if err != nil {
+			continue
+		}
+		if hash, ok := strings.CutPrefix(fileURL.Fragment, "sha256="); ok {
+			f.SHA256 = strings.ToLower(hash)
+		}
+		fileURL.Fragment = ""
+		f.URL = fileURL.String()
+
+		f.Filename = path.Base(fileURL.Path)
+		f.Version = versionFromFilename(f.Filename)
+		if f.Version == "" {
+			continue
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// versionFromFilename extracts the version of wheel and source distribution files
+// https://packaging.python.org/en/latest/specifications/binary-distribution-format/#file-name-convention
+// https://packaging.python.org/en/latest/specifications/source-distribution-format/#source-distribution-file-name
+func versionFromFilename(filename string) string {
+	var v string
+	if name, ok := strings.CutSuffix(filename, ".whl"); ok {
+		parts := strings.Split(name, "-")
+		if len(parts) < 5 {
+			return ""
+		}
+		v = parts[1]
+	} else {
+		name, ok := strings.CutSuffix(filename, ".tar.gz")
+		if !ok {
+			if name, ok = strings.CutSuffix(filename, ".zip"); !ok {
+				return ""
+			}
+		}
+		pos := strings.LastIndex(name, "-")
+		if pos == -1 {
+			return ""
+		}
+		v = name[pos+1:]
+	}
+
+	if !versionMatcher.MatchString(v) {
+		return ""
+	}
+	return v
+}
+
+// fetchUpstreamFile fetches the file from the upstream registry and adds it to the package version
+func fetchUpstreamFile(ctx *context.Context, packageName, packageVersion, filename string) error {
+	files, err := getUpstreamFiles(ctx, packageName)
+	if err != nil {
+		return err
+	}
+
+	var f *upstreamFile
+	for _, file := range files {
+		if strings.EqualFold(file.Filename, filename) && file.Version == packageVersion {
+			f = file
+			break
+		}
+	}
+	if f == nil {
+		return packages_model.ErrPackageFileNotExist
+	}
+
+	buf, err := upstream_service.NewClient(string(packages_model.TypePyPI)).Download(ctx, f.URL, ctx.Package.Owner)
+	if err != nil {
+		if errors.Is(err, upstream_service.ErrNotExist) {
+			return packages_model.ErrPackageFileNotExist
+		}
+		return err
+	}
+	defer buf.Close()
+
+	_, _, hashSHA256, _ := buf.Sums()
+	if f.SHA256 != "" && f.SHA256 != hex.EncodeToString(hashSHA256) {
+		return errors.New("hash mismatch of upstream file")
+	}
+
+	creator := user_model.NewGhostUser()
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypePyPI,
+				Name:        packageName,
+				Version:     packageVersion,
+			},
+			SemverCompatible: false,
+			Creator:          creator,
+			Metadata: &pypi_module.Metadata{
+				RequiresPython: f.RequiresPython,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: f.Filename,
+			},
+			Creator: creator,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	// a concurrent request may have cached the file already
+	if err == packages_model.ErrDuplicatePackageFile {
+		return nil
+	}
+	return err
+}
@@ -70,6 +70,15 @@ func setResponseHeaders(resp http.ResponseWriter, h *headers) {
 }
 
 // https://github.com/apple/swift-package-manager/blob/main/Documentation/Registry.md#33-error-handling
+// ResolvePackage resolves the package addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeSwift}
+	if scope, name := ctx.PathParam("scope"), ctx.PathParam("name"); scope != "" && name != "" {
+		route.Name = buildPackageID(scope, name)
+	}
+	return route
+}
+
 func apiError(ctx *context.Context, status int, obj any) {
 	// https://www.rfc-editor.org/rfc/rfc7807
 	type Problem struct {
@@ -359,7 +368,7 @@ func UploadPackageFile(ctx *context.Context) {
 		switch err {
 		case packages_model.ErrDuplicatePackageVersion:
 			apiError(ctx, http.StatusConflict, err)
-		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize:
+		case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
 			apiError(ctx, http.StatusForbidden, err)
 		default:
 			apiError(ctx, http.StatusInternalServerError, err)
@@ -0,0 +1,613 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	"code.gitea.io/gitea/services/context"
+	packages_service "code.gitea.io/gitea/services/packages"
+	terraform_service "code.gitea.io/gitea/services/packages/terraform"
+
+	"github.com/hashicorp/go-version"
+)
+
+// ResolvePackage resolves the module or the provider addressed by the route
+func ResolvePackage(ctx *context.Base) context.PackageRoute {
+	route := context.PackageRoute{Type: packages_model.TypeTerraform}
+	if provider := ctx.PathParam("provider"); provider != "" {
+		route.Name, _ = terraform_module.ProviderPackageName(provider)
+	} else if name, system := ctx.PathParam("name"), ctx.PathParam("system"); name != "" {
+		route.Name, _ = terraform_module.ModulePackageName(name, system)
+	}
+	return route
+}
+
+func apiError(ctx *context.Context, status int, obj any) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.JSON(status, struct {
+			Errors []string `json:"errors"`
+		}{
+			Errors: []string{
+				message,
+			},
+		})
+	})
+}
+
+// GetRepositoryKey serves the public key which signs the checksums of the providers
+func GetRepositoryKey(ctx *context.Context) {
+	_, pub, err := terraform_service.GetOrCreateKeyPair(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(strings.NewReader(pub), &context.ServeHeaderOptions{
+		ContentType: "application/pgp-keys",
+		Filename:    "repository.key",
+	})
+}
+
+func ownerURL(ctx *context.Context) string {
+	return fmt.Sprintf("%sapi/packages/%s/terraform", setting.AppURL, url.PathEscape(ctx.Package.Owner.Name))
+}
+
+func sortedVersions(pvs []*packages_model.PackageVersion) []*packages_model.PackageVersion {
+	sort.Slice(pvs, func(i, j int) bool {
+		vi, erri := version.NewSemver(pvs[i].Version)
+		vj, errj := version.NewSemver(pvs[j].Version)
+		if erri != nil || errj != nil {
+			return pvs[i].Version < pvs[j].Version
+		}
+		return vi.LessThan(vj)
+	})
+	return pvs
+}
+
+type moduleVersion struct {
+	Version string `json:"version"`
+}
+
+type moduleVersions struct {
+	Versions []*moduleVersion `json:"versions"`
+}
+
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#list-available-versions-for-a-specific-module
+type moduleVersionsResponse struct {
+	Modules []*moduleVersions `json:"modules"`
+}
+
+// EnumerateModuleVersions lists all available versions of a module
+func EnumerateModuleVersions(ctx *context.Context) {
+	packageName, err := terraform_module.ModulePackageName(ctx.PathParam("name"), ctx.PathParam("system"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraform, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	versions := make([]*moduleVersion, 0, len(pvs))
+	for _, pv := range sortedVersions(pvs) {
+		versions = append(versions, &moduleVersion{Version: pv.Version})
+	}
+
+	ctx.JSON(http.StatusOK, &moduleVersionsResponse{
+		Modules: []*moduleVersions{{Versions: versions}},
+	})
+}
+
+// GetModuleDownloadURL returns the location of the module archive in the X-Terraform-Get header
+// https://developer.hashicorp.com/terraform/internals/module-registry-protocol#download-source-code-for-a-specific-module-version
+func GetModuleDownloadURL(ctx *context.Context) {
+	name, system, moduleVersion := ctx.PathParam("name"), ctx.PathParam("system"), ctx.PathParam("version")
+
+	packageName, err := terraform_module.ModulePackageName(name, system)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraform, packageName, moduleVersion)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pfs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	ctx.Resp.Header().Set("X-Terraform-Get", fmt.Sprintf("%s/modules/%s/%s/%s/%s", ownerURL(ctx), url.PathEscape(name), url.PathEscape(system), url.PathEscape(pv.Version), url.PathEscape(pfs[0].Name)))
+	ctx.Status(http.StatusNoContent)
+}
+
+type providerPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type providerVersion struct {
+	Version   string              `json:"version"`
+	Protocols []string            `json:"protocols"`
+	Platforms []*providerPlatform `json:"platforms"`
+}
+
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#list-available-versions
+type providerVersionsResponse struct {
+	Versions []*providerVersion `json:"versions"`
+}
+
+// EnumerateProviderVersions lists all available versions of a provider with their supported platforms
+func EnumerateProviderVersions(ctx *context.Context) {
+	packageName, err := terraform_module.ProviderPackageName(ctx.PathParam("provider"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	pvs, err := packages_model.GetVersionsByPackageName(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraform, packageName)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageNotExist)
+		return
+	}
+
+	pds, err := packages_model.GetPackageDescriptors(ctx, sortedVersions(pvs))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	versions := make([]*providerVersion, 0, len(pds))
+	for _, pd := range pds {
+		platforms := make([]*providerPlatform, 0, len(pd.Files))
+		for _, pfd := range pd.Files {
+			platforms = append(platforms, &providerPlatform{
+				OS:   pfd.Properties.GetByName(terraform_module.PropertyOS),
+				Arch: pfd.Properties.GetByName(terraform_module.PropertyArch),
+			})
+		}
+
+		versions = append(versions, &providerVersion{
+			Version:   pd.Version.Version,
+			Protocols: pd.Metadata.(*terraform_module.Metadata).Protocols,
+			Platforms: platforms,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, &providerVersionsResponse{
+		Versions: versions,
+	})
+}
+
+type gpgPublicKey struct {
+	KeyID      string `json:"key_id"`
+	ASCIIArmor string `json:"ascii_armor"`
+}
+
+type signingKeys struct {
+	GPGPublicKeys []*gpgPublicKey `json:"gpg_public_keys"`
+}
+
+// https://developer.hashicorp.com/terraform/internals/provider-registry-protocol#find-a-provider-package
+type providerPackageResponse struct {
+	Protocols           []string     `json:"protocols"`
+	OS                  string       `json:"os"`
+	Arch                string       `json:"arch"`
+	Filename            string       `json:"filename"`
+	DownloadURL         string       `json:"download_url"`
+	SHASumsURL          string       `json:"shasums_url"`
+	SHASumsSignatureURL string       `json:"shasums_signature_url"`
+	SHASum              string       `json:"shasum"`
+	SigningKeys         *signingKeys `json:"signing_keys"`
+}
+
+// GetProviderPackage returns the download information of the provider package for the platform
+func GetProviderPackage(ctx *context.Context) {
+	providerType, providerVersion := ctx.PathParam("provider"), ctx.PathParam("version")
+	os, arch := ctx.PathParam("os"), ctx.PathParam("arch")
+
+	packageName, err := terraform_module.ProviderPackageName(providerType)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraform, packageName, providerVersion)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	filename := terraform_module.ProviderFilename(providerType, pv.Version, os, arch)
+
+	var pfd *packages_model.PackageFileDescriptor
+	for _, f := range pd.Files {
+		if f.File.Name == filename {
+			pfd = f
+			break
+		}
+	}
+	if pfd == nil {
+		apiError(ctx, http.StatusNotFound, packages_model.ErrPackageFileNotExist)
+		return
+	}
+
+	keyID, pub, err := terraform_service.GetSigningKey(ctx, ctx.Package.Owner.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	versionURL := fmt.Sprintf("%s/providers/%s/%s", ownerURL(ctx), url.PathEscape(providerType), url.PathEscape(pv.Version))
+	shasumsURL := versionURL + "/" + url.PathEscape(terraform_module.SHA256SumsFilename(providerType, pv.Version))
+
+	ctx.JSON(http.StatusOK, &providerPackageResponse{
+		Protocols:           pd.Metadata.(*terraform_module.Metadata).Protocols,
+		OS:                  os,
+		Arch:                arch,
+		Filename:            filename,
+		DownloadURL:         versionURL + "/" + url.PathEscape(filename),
+		SHASumsURL:          shasumsURL,
+		SHASumsSignatureURL: shasumsURL + ".sig",
+		SHASum:              pfd.Blob.HashSHA256,
+		SigningKeys: &signingKeys{
+			GPGPublicKeys: []*gpgPublicKey{
+				{
+					KeyID:      keyID,
+					ASCIIArmor: pub,
+				},
+			},
+		},
+	})
+}
+
+// UploadModule uploads a module archive as new version
+func UploadModule(ctx *context.Context) {
+	name, system, moduleVersion := ctx.PathParam("name"), ctx.PathParam("system"), ctx.PathParam("version")
+
+	packageName, err := terraform_module.ModulePackageName(name, system)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := version.NewSemver(moduleVersion); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	buf := readUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	defer buf.Close()
+
+	ext, err := terraform_module.ModuleArchiveExtension(buf)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageAndAddFile(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeTerraform,
+				Name:        packageName,
+				Version:     moduleVersion,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata: &terraform_module.Metadata{
+				Kind: terraform_module.KindModule,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: terraform_module.ModuleFilename(name, system, moduleVersion, ext),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+		},
+	)
+	if err != nil {
+		handleCreateError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// UploadProvider uploads the provider package of a platform, all platforms of a version share the protocols of the first upload
+func UploadProvider(ctx *context.Context) {
+	providerType, providerVersion := ctx.PathParam("provider"), ctx.PathParam("version")
+	os, arch := ctx.PathParam("os"), ctx.PathParam("arch")
+
+	packageName, err := terraform_module.ProviderPackageName(providerType)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := version.NewSemver(providerVersion); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if err := terraform_module.ValidatePlatform(os, arch); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	protocols, err := terraform_module.ParseProtocols(ctx.FormString("protocols"))
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	buf := readUpload(ctx)
+	if ctx.Written() {
+		return
+	}
+	defer buf.Close()
+
+	if err := terraform_module.IsZipArchive(buf); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		ctx,
+		&packages_service.PackageCreationInfo{
+			PackageInfo: packages_service.PackageInfo{
+				Owner:       ctx.Package.Owner,
+				PackageType: packages_model.TypeTerraform,
+				Name:        packageName,
+				Version:     providerVersion,
+			},
+			SemverCompatible: true,
+			Creator:          ctx.Doer,
+			Metadata: &terraform_module.Metadata{
+				Kind:      terraform_module.KindProvider,
+				Protocols: protocols,
+			},
+		},
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename: terraform_module.ProviderFilename(providerType, providerVersion, os, arch),
+			},
+			Creator: ctx.Doer,
+			Data:    buf,
+			IsLead:  true,
+			Properties: map[string]string{
+				terraform_module.PropertyOS:   os,
+				terraform_module.PropertyArch: arch,
+			},
+		},
+	)
+	if err != nil {
+		handleCreateError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func readUpload(ctx *context.Context) *packages_module.HashedBuffer {
+	upload, needToClose, err := ctx.UploadStream()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil
+	}
+	if needToClose {
+		defer upload.Close()
+	}
+
+	buf, err := packages_module.CreateHashedBufferFromReader(upload)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return nil
+	}
+	return buf
+}
+
+func handleCreateError(ctx *context.Context, err error) {
+	switch err {
+	case packages_model.ErrDuplicatePackageVersion, packages_model.ErrDuplicatePackageFile:
+		apiError(ctx, http.StatusConflict, err)
+	case packages_service.ErrQuotaTotalCount, packages_service.ErrQuotaTypeSize, packages_service.ErrQuotaTotalSize, packages_service.ErrPackageAccessDenied:
+		apiError(ctx, http.StatusForbidden, err)
+	default:
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// DownloadModuleFile serves the module archive
+func DownloadModuleFile(ctx *context.Context) {
+	packageName, err := terraform_module.ModulePackageName(ctx.PathParam("name"), ctx.PathParam("system"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	downloadFile(ctx, packageName, ctx.PathParam("filename"))
+}
+
+// DownloadProviderFile serves the provider packages and the signed checksums
+func DownloadProviderFile(ctx *context.Context) {
+	providerType, providerVersion, filename := ctx.PathParam("provider"), ctx.PathParam("version"), ctx.PathParam("filename")
+
+	packageName, err := terraform_module.ProviderPackageName(providerType)
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	shasumsFilename := terraform_module.SHA256SumsFilename(providerType, providerVersion)
+	if filename != shasumsFilename && filename != shasumsFilename+".sig" {
+		downloadFile(ctx, packageName, filename)
+		return
+	}
+
+	pv, err := packages_model.GetVersionByNameAndVersion(ctx, ctx.Package.Owner.ID, packages_model.TypeTerraform, packageName, providerVersion)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pd, err := packages_model.GetPackageDescriptor(ctx, pv)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	content := terraform_service.BuildSHA256Sums(pd)
+	if filename == shasumsFilename {
+		ctx.ServeContent(bytes.NewReader(content), &context.ServeHeaderOptions{
+			ContentType: "text/plain; charset=utf-8",
+			Filename:    filename,
+		})
+		return
+	}
+
+	signature, err := terraform_service.SignSHA256Sums(ctx, ctx.Package.Owner.ID, content)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.ServeContent(bytes.NewReader(signature), &context.ServeHeaderOptions{
+		ContentType: "application/pgp-signature",
+		Filename:    filename,
+	})
+}
+
+func downloadFile(ctx *context.Context, packageName, filename string) {
+	s, u, pf, err := packages_service.GetFileStreamByPackageNameAndVersion(
+		ctx,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeTerraform,
+			Name:        packageName,
+			Version:     ctx.PathParam("version"),
+		},
+		&packages_service.PackageFileInfo{
+			Filename: filename,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	helper.ServePackageFile(ctx, s, u, pf)
+}
+
+// DeleteModule deletes a module version
+func DeleteModule(ctx *context.Context) {
+	packageName, err := terraform_module.ModulePackageName(ctx.PathParam("name"), ctx.PathParam("system"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	deleteVersion(ctx, packageName)
+}
+
+// DeleteProvider deletes a provider version with the packages of all platforms
+func DeleteProvider(ctx *context.Context) {
+	packageName, err := terraform_module.ProviderPackageName(ctx.PathParam("provider"))
+	if err != nil {
+		apiError(ctx, http.StatusNotFound, err)
+		return
+	}
+
+	deleteVersion(ctx, packageName)
+}
+
+func deleteVersion(ctx *context.Context, packageName string) {
+	err := packages_service.RemovePackageVersionByNameAndVersion(
+		ctx,
+		ctx.Doer,
+		&packages_service.PackageInfo{
+			Owner:       ctx.Package.Owner,
+			PackageType: packages_model.TypeTerraform,
+			Name:        packageName,
+			Version:     ctx.PathParam("version"),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/shared"
+	"code.gitea.io/gitea/services/context"
+)
+
+// ListActionsUsage aggregates the actions usage of all owners
+func ListActionsUsage(ctx *context.APIContext) {
+	// swagger:operation GET /admin/actions/usage admin adminListActionsUsage
+	// ---
+	// summary: Get the actions usage of all owners
+	// produces:
+	// - application/json
+	// - text/csv
+	// parameters:
+	// - name: group_by
+	//   in: query
+	//   description: aggregate the usage by the repository, the owner, the user who triggered the runs or the runner labels, defaults to owner
+	//   type: string
+	//   enum: [repo, owner, user, label]
+	// - name: since
+	//   in: query
+	//   description: only the jobs stopped at or after the given time are counted, defaults to the beginning of the current month. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: only the jobs stopped before the given time are counted. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: format
+	//   in: query
+	//   description: set to csv to export the usage as CSV
+	//   type: string
+	//   enum: [json, csv]
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionUsageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.ListActionsUsage(ctx, 0, actions_model.UsageGroupByOwner)
+}
+
+// ListActionsJobUsage lists the actions usage of the jobs of all owners
+func ListActionsJobUsage(ctx *context.APIContext) {
+	// swagger:operation GET /admin/actions/usage/jobs admin adminListActionsJobUsage
+	// ---
+	// summary: List the actions usage of the finished jobs of all owners
+	// produces:
+	// - application/json
+	// - text/csv
+	// parameters:
+	// - name: since
+	//   in: query
+	//   description: only the jobs stopped at or after the given time are listed, defaults to the beginning of the current month. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: only the jobs stopped before the given time are listed. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: format
+	//   in: query
+	//   description: set to csv to export all the jobs as CSV, the pagination is ignored
+	//   type: string
+	//   enum: [json, csv]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionJobUsageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.ListActionsJobUsage(ctx, 0)
+}
+
+// GetActionsQuota gets the actions quota of a user or an organization
+func GetActionsQuota(ctx *context.APIContext) {
+	// swagger:operation GET /admin/actions/quotas/{username} admin adminGetActionsQuota
+	// ---
+	// summary: Get the actions quota of a user or an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionQuota"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	shared.GetActionsQuota(ctx, ctx.ContextUser.ID)
+}
+
+// SetActionsQuota sets the actions quota of a user or an organization
+func SetActionsQuota(ctx *context.APIContext) {
+	// swagger:operation PUT /admin/actions/quotas/{username} admin adminSetActionsQuota
+	// ---
+	// summary: Set the actions quota of a user or an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetActionQuotaOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionQuota"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.SetActionsQuota(ctx, ctx.ContextUser.ID)
+}
+
+// DeleteActionsQuota deletes the actions quota of a user or an organization
+func DeleteActionsQuota(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/actions/quotas/{username} admin adminDeleteActionsQuota
+	// ---
+	// summary: Delete the actions quota of a user or an organization, so the usage is unlimited
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := actions_model.DeleteQuota(ctx, ctx.ContextUser.ID); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
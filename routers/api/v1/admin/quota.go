@@ -0,0 +1,421 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	quota_model "code.gitea.io/gitea/models/quota"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/shared"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// getQuotaGroup returns the quota group of the path, it responds an error if it doesn't exist
+func getQuotaGroup(ctx *context.APIContext) *quota_model.Group {
+	g, err := quota_model.GetGroupByName(ctx, ctx.PathParam("group"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil
+	}
+	return g
+}
+
+// ListQuotaGroups lists all the quota groups
+func ListQuotaGroups(ctx *context.APIContext) {
+	// swagger:operation GET /admin/quota/groups admin adminListQuotaGroups
+	// ---
+	// summary: List the quota groups
+	// produces:
+	// - application/json
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/QuotaGroupList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+
+	groups, err := quota_model.GetAllGroups(ctx)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	result := make([]*api.QuotaGroup, 0, len(groups))
+	for _, g := range groups {
+		group, err := convert.ToQuotaGroup(ctx, g)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		result = append(result, group)
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// CreateQuotaGroup creates a quota group
+func CreateQuotaGroup(ctx *context.APIContext) {
+	// swagger:operation POST /admin/quota/groups admin adminCreateQuotaGroup
+	// ---
+	// summary: Create a quota group
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreateQuotaGroupOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/QuotaGroup"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateQuotaGroupOption)
+	g, err := quota_model.CreateGroup(ctx, form.Name)
+	if err != nil {
+		if errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Error(http.StatusConflict, "CreateGroup", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	group, err := convert.ToQuotaGroup(ctx, g)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, group)
+}
+
+// GetQuotaGroup gets a quota group with its members and rules
+func GetQuotaGroup(ctx *context.APIContext) {
+	// swagger:operation GET /admin/quota/groups/{group} admin adminGetQuotaGroup
+	// ---
+	// summary: Get a quota group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/QuotaGroup"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	group, err := convert.ToQuotaGroup(ctx, g)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, group)
+}
+
+// DeleteQuotaGroup deletes a quota group
+func DeleteQuotaGroup(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/quota/groups/{group} admin adminDeleteQuotaGroup
+	// ---
+	// summary: Delete a quota group with its rules, the members are released from the group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := quota_model.DeleteGroup(ctx, g.ID); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SetQuotaGroupRule sets a rule of a quota group
+func SetQuotaGroupRule(ctx *context.APIContext) {
+	// swagger:operation PUT /admin/quota/groups/{group}/rules/{subject} admin adminSetQuotaGroupRule
+	// ---
+	// summary: Set the limit of a subject for the members of a quota group
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// - name: subject
+	//   in: path
+	//   description: the limited storage
+	//   type: string
+	//   enum: [total, git, lfs, packages, attachments, artifacts]
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SetQuotaRuleOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/QuotaRule"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	shared.SetQuotaRule(ctx, g.ID, 0)
+}
+
+// DeleteQuotaGroupRule deletes a rule of a quota group
+func DeleteQuotaGroupRule(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/quota/groups/{group}/rules/{subject} admin adminDeleteQuotaGroupRule
+	// ---
+	// summary: Delete the limit of a subject of a quota group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// - name: subject
+	//   in: path
+	//   description: the limited storage
+	//   type: string
+	//   enum: [total, git, lfs, packages, attachments, artifacts]
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	shared.DeleteQuotaRule(ctx, g.ID, 0)
+}
+
+// AddQuotaGroupMember adds a user or an organization to a quota group
+func AddQuotaGroupMember(ctx *context.APIContext) {
+	// swagger:operation PUT /admin/quota/groups/{group}/members/{username} admin adminAddQuotaGroupMember
+	// ---
+	// summary: Add a user or an organization to a quota group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := quota_model.AddGroupMember(ctx, g.ID, ctx.ContextUser.ID); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveQuotaGroupMember removes a user or an organization from a quota group
+func RemoveQuotaGroupMember(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/quota/groups/{group}/members/{username} admin adminRemoveQuotaGroupMember
+	// ---
+	// summary: Remove a user or an organization from a quota group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: group
+	//   in: path
+	//   description: name of the quota group
+	//   type: string
+	//   required: true
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getQuotaGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := quota_model.RemoveGroupMember(ctx, g.ID, ctx.ContextUser.ID); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetUserQuota gets the storage quota of a user or an organization
+func GetUserQuota(ctx *context.APIContext) {
+	// swagger:operation GET /admin/quota/users/{username} admin adminGetUserQuota
+	// ---
+	// summary: Get the storage quota of a user or an organization and its current usage
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/QuotaInfo"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	shared.GetQuotaInfo(ctx, ctx.ContextUser.ID)
+}
+
+// SetUserQuotaRule sets a rule of a user or an organization
+func SetUserQuotaRule(ctx *context.APIContext) {
+	// swagger:operation PUT /admin/quota/users/{username}/rules/{subject} admin adminSetUserQuotaRule
+	// ---
+	// summary: Set the limit of a subject for a user or an organization, it overrides the limits of its quota groups
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// - name: subject
+	//   in: path
+	//   description: the limited storage
+	//   type: string
+	//   enum: [total, git, lfs, packages, attachments, artifacts]
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SetQuotaRuleOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/QuotaRule"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.SetQuotaRule(ctx, 0, ctx.ContextUser.ID)
+}
+
+// DeleteUserQuotaRule deletes a rule of a user or an organization
+func DeleteUserQuotaRule(ctx *context.APIContext) {
+	// swagger:operation DELETE /admin/quota/users/{username}/rules/{subject} admin adminDeleteUserQuotaRule
+	// ---
+	// summary: Delete the limit of a subject of a user or an organization, so the limits of its quota groups apply
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: name of the user or the organization
+	//   type: string
+	//   required: true
+	// - name: subject
+	//   in: path
+	//   description: the limited storage
+	//   type: string
+	//   enum: [total, git, lfs, packages, attachments, artifacts]
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.DeleteQuotaRule(ctx, 0, ctx.ContextUser.ID)
+}
@@ -24,3 +24,28 @@ func GetRegistrationToken(ctx *context.APIContext) {
 
 	shared.GetRegistrationToken(ctx, 0, 0)
 }
+
+// GenerateRunnerJITConfig creates a just-in-time global runner
+func GenerateRunnerJITConfig(ctx *context.APIContext) {
+	// swagger:operation POST /admin/runners/generate-jitconfig admin adminGenerateRunnerJITConfig
+	// ---
+	// summary: Create an ephemeral global runner which could start without registering
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/GenerateRunnerJITConfigOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/RunnerJITConfig"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.GenerateRunnerJITConfig(ctx, 0, 0)
+}
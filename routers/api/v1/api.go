@@ -83,6 +83,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/modules/web/routing"
 	"code.gitea.io/gitea/routers/api/v1/activitypub"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
@@ -200,17 +201,12 @@ func repoAssignment() func(ctx *context.APIContext) {
 				return
 			}
 
-			if task.IsForkPullRequest {
-				ctx.Repo.Permission.AccessMode = perm.AccessModeRead
-			} else {
-				ctx.Repo.Permission.AccessMode = perm.AccessModeWrite
-			}
-
 			if err := ctx.Repo.Repository.LoadUnits(ctx); err != nil {
 				ctx.Error(http.StatusInternalServerError, "LoadUnits", err)
 				return
 			}
-			ctx.Repo.Permission.SetUnitsWithDefaultAccessMode(ctx.Repo.Repository.Units, ctx.Repo.Permission.AccessMode)
+			// the token could only access the units granted by the permissions of the job
+			ctx.Repo.Permission.SetUnitsWithAccessModes(ctx.Repo.Repository.Units, task.UnitAccessMode)
 		} else {
 			ctx.Repo.Permission, err = access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 			if err != nil {
@@ -410,6 +406,32 @@ func reqRepoReader(unitType unit.Type) func(ctx *context.APIContext) {
 	}
 }
 
+// reqAttestationsWriter the token should be the one of a running job which is granted the write permission of `attestations`
+func reqAttestationsWriter() func(ctx *context.APIContext) {
+	return func(ctx *context.APIContext) {
+		if ctx.Data["IsActionsToken"] != true {
+			ctx.Error(http.StatusForbidden, "reqAttestationsWriter", "only the running jobs can request attestations")
+			return
+		}
+		task, err := actions_model.GetTaskByID(ctx, ctx.Data["ActionsTaskID"].(int64))
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetTaskByID", err)
+			return
+		}
+		if err := task.LoadJob(ctx); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadJob", err)
+			return
+		}
+		if ok, err := actions.CanRequestAttestation(ctx, task.Job); err != nil {
+			ctx.Error(http.StatusInternalServerError, "CanRequestAttestation", err)
+			return
+		} else if !ok {
+			ctx.Error(http.StatusForbidden, "reqAttestationsWriter", "the job should be granted the write permission of attestations")
+			return
+		}
+	}
+}
+
 // reqAnyRepoReader user should have any permission to read repository or permissions of site admin
 func reqAnyRepoReader() func(ctx *context.APIContext) {
 	return func(ctx *context.APIContext) {
@@ -860,6 +882,7 @@ func Routes() *web.Router {
 
 			m.Group("/runners", func() {
 				m.Get("/registration-token", reqToken(), reqChecker, act.GetRegistrationToken)
+				m.Post("/generate-jitconfig", reqToken(), reqChecker, bind(api.GenerateRunnerJITConfigOption{}), act.GenerateRunnerJITConfig)
 			})
 		})
 	}
@@ -1050,6 +1073,7 @@ func Routes() *web.Router {
 			m.Get("/stopwatches", repo.GetStopwatches)
 			m.Get("/subscriptions", user.GetMyWatchedRepos)
 			m.Get("/teams", org.ListUserTeams)
+			m.Get("/quota", user.GetQuota)
 			m.Group("/hooks", func() {
 				m.Combo("").Get(user.ListHooks).
 					Post(bind(api.CreateHookOption{}), user.CreateHook)
@@ -1179,7 +1203,63 @@ func Routes() *web.Router {
 				}, reqToken(), reqAdmin())
 				m.Group("/actions", func() {
 					m.Get("/tasks", repo.ListActionTasks)
+					m.Group("/runs", func() {
+						m.Get("", repo.ListActionRuns)
+						m.Group("/{run_id}", func() {
+							m.Get("", repo.GetActionRun)
+							m.Get("/jobs", repo.ListActionRunJobs)
+							m.Post("/cancel", reqToken(), reqRepoWriter(unit.TypeActions), repo.CancelActionRun)
+							m.Post("/rerun", reqToken(), reqRepoWriter(unit.TypeActions), repo.RerunActionRun)
+							m.Post("/approve", reqToken(), reqRepoWriter(unit.TypeActions), repo.ApproveActionRun)
+							m.Combo("/pending_deployments").
+								Get(repo.ListPendingDeployments).
+								Post(reqToken(), bind(api.ReviewPendingDeploymentsOption{}), repo.ReviewPendingDeployments)
+						})
+					})
+					m.Group("/jobs/{job_id}", func() {
+						m.Get("", repo.GetActionJob)
+						m.Get("/logs", repo.GetActionJobLogs)
+						m.Get("/logs/stream", routing.MarkLongPolling, repo.StreamActionJobLogs)
+						m.Get("/summaries", repo.ListActionJobStepSummaries)
+						m.Post("/rerun", reqToken(), reqRepoWriter(unit.TypeActions), repo.RerunActionJob)
+					})
+					m.Group("/attestations", func() {
+						m.Post("", reqToken(), reqAttestationsWriter(), bind(api.CreateActionAttestationOption{}), repo.CreateActionAttestations)
+						m.Get("/{subject_digest}", repo.ListActionAttestations)
+						m.Get("/{subject_digest}/verify", repo.VerifyActionAttestations)
+					})
+					m.Combo("/permissions/workflow", reqToken(), reqAdmin()).
+						Get(repo.GetActionWorkflowPermissions).
+						Put(bind(api.EditActionWorkflowPermissionsOption{}), repo.SetActionWorkflowPermissions)
 				}, reqRepoReader(unit.TypeActions), context.ReferencesGitRepo(true))
+				m.Group("/environments", func() {
+					m.Get("", repo.ListEnvironments)
+					m.Group("/{environment_name}", func() {
+						m.Combo("").Get(repo.GetEnvironment).
+							Put(reqToken(), reqAdmin(), bind(api.CreateOrUpdateEnvironmentOption{}), repo.CreateOrUpdateEnvironment).
+							Delete(reqToken(), reqAdmin(), repo.DeleteEnvironment)
+						m.Group("/secrets", func() {
+							m.Get("", repo.ListEnvironmentSecrets)
+							m.Combo("/{secretname}").
+								Put(bind(api.CreateOrUpdateSecretOption{}), repo.CreateOrUpdateEnvironmentSecret).
+								Delete(repo.DeleteEnvironmentSecret)
+						}, reqToken(), reqAdmin())
+						m.Group("/variables", func() {
+							m.Get("", repo.ListEnvironmentVariables)
+							m.Combo("/{variablename}").
+								Post(bind(api.CreateVariableOption{}), repo.CreateEnvironmentVariable).
+								Put(bind(api.UpdateVariableOption{}), repo.UpdateEnvironmentVariable).
+								Delete(repo.DeleteEnvironmentVariable)
+						}, reqToken(), reqAdmin())
+					})
+				}, reqRepoReader(unit.TypeActions))
+				m.Group("/deployments", func() {
+					m.Get("", repo.ListDeployments)
+					m.Group("/{deployment_id}", func() {
+						m.Get("", repo.GetDeployment)
+						m.Get("/statuses", repo.ListDeploymentStatuses)
+					})
+				}, reqRepoReader(unit.TypeActions))
 				m.Group("/keys", func() {
 					m.Combo("").Get(repo.ListDeployKeys).
 						Post(bind(api.CreateKeyOption{}), repo.CreateDeployKey)
@@ -1281,11 +1361,20 @@ func Routes() *web.Router {
 					m.Combo("/{sha}").Get(repo.GetCommitStatuses).
 						Post(reqToken(), reqRepoWriter(unit.TypeCode), bind(api.CreateStatusOption{}), repo.NewCommitStatus)
 				}, reqRepoReader(unit.TypeCode))
+				m.Group("/check-runs", func() {
+					m.Post("", reqToken(), reqRepoWriter(unit.TypeCode), bind(api.CreateCheckRunOption{}), repo.CreateCheckRun)
+					m.Group("/{check_run_id}", func() {
+						m.Combo("").Get(repo.GetCheckRun).
+							Patch(reqToken(), reqRepoWriter(unit.TypeCode), bind(api.UpdateCheckRunOption{}), repo.UpdateCheckRun)
+						m.Get("/annotations", repo.ListCheckRunAnnotations)
+					})
+				}, reqRepoReader(unit.TypeCode))
 				m.Group("/commits", func() {
 					m.Get("", context.ReferencesGitRepo(), repo.GetAllCommits)
 					m.Group("/{ref}", func() {
 						m.Get("/status", repo.GetCombinedCommitStatusByRef)
 						m.Get("/statuses", repo.GetCommitStatusesByRef)
+						m.Get("/check-runs", repo.ListCheckRunsByRef)
 					}, context.ReferencesGitRepo())
 					m.Group("/{sha}", func() {
 						m.Get("/pull", repo.GetCommitPullRequest)
@@ -1465,7 +1554,31 @@ func Routes() *web.Router {
 				m.Get("", reqToken(), packages.GetPackage)
 				m.Delete("", reqToken(), reqPackageAccess(perm.AccessModeWrite), packages.DeletePackage)
 				m.Get("/files", reqToken(), packages.ListPackageFiles)
+				m.Group("/sboms", func() {
+					m.Combo("").Get(packages.ListPackageSBOMs).
+						Put(reqPackageAccess(perm.AccessModeWrite), packages.UploadPackageSBOM)
+					m.Combo("/{format}").Get(packages.GetPackageSBOM).
+						Delete(reqPackageAccess(perm.AccessModeWrite), packages.DeletePackageSBOM)
+				}, reqToken())
+				m.Get("/vulnerabilities", reqToken(), packages.ListPackageVulnerabilities)
 			})
+			m.Group("/{type}/{name}/-", func() {
+				m.Group("/access", func() {
+					m.Get("", packages.ListPackageAccess)
+					m.Combo("/users/{user}").
+						Put(bind(api.SetPackageAccessOption{}), packages.SetPackageUserAccess).
+						Delete(packages.DeletePackageUserAccess)
+					m.Combo("/teams/{team}").
+						Put(bind(api.SetPackageAccessOption{}), packages.SetPackageTeamAccess).
+						Delete(packages.DeletePackageTeamAccess)
+				}, reqPackageAccess(perm.AccessModeAdmin))
+				m.Group("/repositories", func() {
+					m.Get("", packages.ListPackageRepositories)
+					m.Combo("/{repo_owner}/{repo}").
+						Put(reqPackageAccess(perm.AccessModeAdmin), packages.LinkPackageRepository).
+						Delete(reqPackageAccess(perm.AccessModeAdmin), packages.UnlinkPackageRepository)
+				})
+			}, reqToken())
 			m.Get("/", reqToken(), packages.ListPackages)
 		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryPackage), context.UserAssignmentAPI(), context.PackageAssignmentAPI(), reqPackageAccess(perm.AccessModeRead))
 
@@ -1493,6 +1606,35 @@ func Routes() *web.Router {
 				reqOrgOwnership(),
 				org.NewAction(),
 			)
+			m.Group("/actions/required_workflows", func() {
+				m.Combo("").Get(org.ListRequiredWorkflows).
+					Post(bind(api.CreateRequiredWorkflowOption{}), org.CreateRequiredWorkflow)
+				m.Combo("/{workflow_id}").Get(org.GetRequiredWorkflow).
+					Patch(bind(api.EditRequiredWorkflowOption{}), org.EditRequiredWorkflow).
+					Delete(org.DeleteRequiredWorkflow)
+			}, reqToken(), reqOrgOwnership())
+			m.Group("/actions/runner-groups", func() {
+				m.Combo("").Get(org.ListRunnerGroups).
+					Post(bind(api.CreateActionRunnerGroupOption{}), org.CreateRunnerGroup)
+				m.Group("/{group_id}", func() {
+					m.Combo("").Get(org.GetRunnerGroup).
+						Patch(bind(api.EditActionRunnerGroupOption{}), org.EditRunnerGroup).
+						Delete(org.DeleteRunnerGroup)
+					m.Get("/runners", org.ListRunnerGroupRunners)
+					m.Combo("/runners/{runner_id}").
+						Put(org.AddRunnerGroupRunner).
+						Delete(org.RemoveRunnerGroupRunner)
+				})
+			}, reqToken(), reqOrgOwnership())
+			m.Group("/actions", func() {
+				m.Get("/usage", org.ListActionsUsage)
+				m.Get("/usage/jobs", org.ListActionsJobUsage)
+				m.Get("/quota", org.GetActionsQuota)
+				m.Combo("/permissions/workflow").
+					Get(org.GetActionWorkflowPermissions).
+					Put(bind(api.EditActionWorkflowPermissionsOption{}), org.SetActionWorkflowPermissions)
+			}, reqToken(), reqOrgOwnership())
+			m.Get("/quota", reqToken(), reqOrgOwnership(), org.GetQuota)
 			m.Group("/public_members", func() {
 				m.Get("", org.ListPublicMembers)
 				m.Combo("/{username}").Get(org.IsPublicMember).
@@ -1597,6 +1739,31 @@ func Routes() *web.Router {
 			})
 			m.Group("/runners", func() {
 				m.Get("/registration-token", admin.GetRegistrationToken)
+				m.Post("/generate-jitconfig", bind(api.GenerateRunnerJITConfigOption{}), admin.GenerateRunnerJITConfig)
+			})
+			m.Group("/actions", func() {
+				m.Get("/usage", admin.ListActionsUsage)
+				m.Get("/usage/jobs", admin.ListActionsJobUsage)
+				m.Combo("/quotas/{username}", context.UserAssignmentAPI()).Get(admin.GetActionsQuota).
+					Put(bind(api.SetActionQuotaOption{}), admin.SetActionsQuota).
+					Delete(admin.DeleteActionsQuota)
+			})
+			m.Group("/quota", func() {
+				m.Combo("/groups").Get(admin.ListQuotaGroups).
+					Post(bind(api.CreateQuotaGroupOption{}), admin.CreateQuotaGroup)
+				m.Group("/groups/{group}", func() {
+					m.Combo("").Get(admin.GetQuotaGroup).
+						Delete(admin.DeleteQuotaGroup)
+					m.Combo("/rules/{subject}").Put(bind(api.SetQuotaRuleOption{}), admin.SetQuotaGroupRule).
+						Delete(admin.DeleteQuotaGroupRule)
+					m.Combo("/members/{username}", context.UserAssignmentAPI()).Put(admin.AddQuotaGroupMember).
+						Delete(admin.RemoveQuotaGroupMember)
+				})
+				m.Group("/users/{username}", func() {
+					m.Get("", admin.GetUserQuota)
+					m.Combo("/rules/{subject}").Put(bind(api.SetQuotaRuleOption{}), admin.SetUserQuotaRule).
+						Delete(admin.DeleteUserQuotaRule)
+				}, context.UserAssignmentAPI())
 			})
 		}, tokenRequiresScopes(auth_model.AccessTokenScopeCategoryAdmin), reqToken(), reqSiteAdmin())
 
@@ -189,6 +189,38 @@ func (Action) GetRegistrationToken(ctx *context.APIContext) {
 	shared.GetRegistrationToken(ctx, ctx.Org.Organization.ID, 0)
 }
 
+// GenerateRunnerJITConfig creates a just-in-time runner of the organization
+func (Action) GenerateRunnerJITConfig(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/actions/runners/generate-jitconfig organization orgGenerateRunnerJITConfig
+	// ---
+	// summary: Create an ephemeral runner of an organization which could start without registering
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/GenerateRunnerJITConfigOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/RunnerJITConfig"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.GenerateRunnerJITConfig(ctx, ctx.Org.Organization.ID, 0)
+}
+
 // ListVariables list org-level variables
 func (Action) ListVariables(ctx *context.APIContext) {
 	// swagger:operation GET /orgs/{org}/actions/variables organization getOrgVariablesList
@@ -0,0 +1,86 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"errors"
+	"net/http"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+// GetActionWorkflowPermissions gets the default permissions of the tokens of the actions jobs in the repositories of an organization
+func GetActionWorkflowPermissions(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/permissions/workflow organization orgGetActionWorkflowPermissions
+	// ---
+	// summary: Get the default permissions of the tokens of the actions jobs in the repositories of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionWorkflowPermissions"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p, err := actions_service.GetOwnerDefaultWorkflowPermissions(ctx, ctx.Org.Organization.ID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.ActionWorkflowPermissions{DefaultWorkflowPermissions: string(p)})
+}
+
+// SetActionWorkflowPermissions sets the default permissions of the tokens of the actions jobs in the repositories of an organization
+func SetActionWorkflowPermissions(ctx *context.APIContext) {
+	// swagger:operation PUT /orgs/{org}/actions/permissions/workflow organization orgSetActionWorkflowPermissions
+	// ---
+	// summary: Set the default permissions of the tokens of the actions jobs in the repositories of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditActionWorkflowPermissionsOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionWorkflowPermissions"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditActionWorkflowPermissionsOption)
+	if err := actions_service.SetOwnerDefaultWorkflowPermissions(ctx, ctx.Org.Organization.ID, repo_model.WorkflowPermissions(form.DefaultWorkflowPermissions)); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "SetOwnerDefaultWorkflowPermissions", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	GetActionWorkflowPermissions(ctx)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/routers/api/v1/shared"
+	"code.gitea.io/gitea/services/context"
+)
+
+// ListActionsUsage aggregates the actions usage of an organization
+func ListActionsUsage(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/usage organization orgListActionsUsage
+	// ---
+	// summary: Get the actions usage of an organization
+	// produces:
+	// - application/json
+	// - text/csv
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_by
+	//   in: query
+	//   description: aggregate the usage by the repository, the user who triggered the runs or the runner labels, defaults to repo
+	//   type: string
+	//   enum: [repo, user, label]
+	// - name: since
+	//   in: query
+	//   description: only the jobs stopped at or after the given time are counted, defaults to the beginning of the current month. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: only the jobs stopped before the given time are counted. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: format
+	//   in: query
+	//   description: set to csv to export the usage as CSV
+	//   type: string
+	//   enum: [json, csv]
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionUsageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.ListActionsUsage(ctx, ctx.Org.Organization.ID, actions_model.UsageGroupByRepo)
+}
+
+// ListActionsJobUsage lists the actions usage of the jobs of an organization
+func ListActionsJobUsage(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/usage/jobs organization orgListActionsJobUsage
+	// ---
+	// summary: List the actions usage of the finished jobs of an organization
+	// produces:
+	// - application/json
+	// - text/csv
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: since
+	//   in: query
+	//   description: only the jobs stopped at or after the given time are listed, defaults to the beginning of the current month. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: only the jobs stopped before the given time are listed. This is a timestamp in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: format
+	//   in: query
+	//   description: set to csv to export all the jobs as CSV, the pagination is ignored
+	//   type: string
+	//   enum: [json, csv]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionJobUsageList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.ListActionsJobUsage(ctx, ctx.Org.Organization.ID)
+}
+
+// GetActionsQuota gets the actions quota of an organization
+func GetActionsQuota(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/quota organization orgGetActionsQuota
+	// ---
+	// summary: Get the actions quota of an organization and its current usage
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionQuota"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	shared.GetActionsQuota(ctx, ctx.Org.Organization.ID)
+}
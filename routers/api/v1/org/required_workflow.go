@@ -0,0 +1,349 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+
+	"github.com/gobwas/glob"
+)
+
+// ListRequiredWorkflows list the required workflows of an organization
+func ListRequiredWorkflows(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/required_workflows organization orgListRequiredWorkflows
+	// ---
+	// summary: List the required workflows of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RequiredWorkflowList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	rws, count, err := db.FindAndCount[actions_model.ActionRequiredWorkflow](ctx, actions_model.FindRequiredWorkflowsOptions{
+		OrgID:       ctx.Org.Organization.ID,
+		ListOptions: utils.GetListOptions(ctx),
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiRequiredWorkflows := make([]*api.RequiredWorkflow, 0, len(rws))
+	for _, rw := range rws {
+		apiRequiredWorkflow, err := convert.ToRequiredWorkflow(ctx, rw)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		apiRequiredWorkflows = append(apiRequiredWorkflows, apiRequiredWorkflow)
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiRequiredWorkflows)
+}
+
+// GetRequiredWorkflow get a required workflow of an organization
+func GetRequiredWorkflow(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/required_workflows/{workflow_id} organization orgGetRequiredWorkflow
+	// ---
+	// summary: Get a required workflow of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: workflow_id
+	//   in: path
+	//   description: id of the required workflow
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RequiredWorkflow"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	rw := getRequiredWorkflow(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiRequiredWorkflow, err := convert.ToRequiredWorkflow(ctx, rw)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiRequiredWorkflow)
+}
+
+// CreateRequiredWorkflow register a workflow of a central repository as required for the repositories of an organization
+func CreateRequiredWorkflow(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/actions/required_workflows organization orgCreateRequiredWorkflow
+	// ---
+	// summary: Register a workflow of a repository as required for the repositories of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateRequiredWorkflowOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/RequiredWorkflow"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     description: the workflow has been registered
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateRequiredWorkflowOption)
+
+	repo, err := repo_model.GetRepositoryByName(ctx, ctx.Org.Organization.ID, form.Repository)
+	if err != nil {
+		if repo_model.IsErrRepoNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "GetRepositoryByName", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+		}
+		return
+	}
+	repoPatterns, err := validateRepoPatterns(form.RepoPatterns)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ValidateRepoPatterns", err)
+		return
+	}
+
+	rw := &actions_model.ActionRequiredWorkflow{
+		OrgID:              ctx.Org.Organization.ID,
+		RepoID:             repo.ID,
+		WorkflowPath:       strings.TrimPrefix(form.WorkflowPath, "/"),
+		Ref:                form.Ref,
+		RepoPatterns:       repoPatterns,
+		EnforceStatusCheck: form.EnforceStatusCheck,
+		CreatorID:          ctx.Doer.ID,
+		Repo:               repo,
+	}
+	if err := actions_service.PrepareRequiredWorkflow(ctx, rw); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "PrepareRequiredWorkflow", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "PrepareRequiredWorkflow", err)
+		}
+		return
+	}
+	if err := actions_model.CreateRequiredWorkflow(ctx, rw); err != nil {
+		if errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Error(http.StatusConflict, "CreateRequiredWorkflow", err)
+		} else if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateRequiredWorkflow", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateRequiredWorkflow", err)
+		}
+		return
+	}
+
+	apiRequiredWorkflow, err := convert.ToRequiredWorkflow(ctx, rw)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiRequiredWorkflow)
+}
+
+// EditRequiredWorkflow edit a required workflow of an organization
+func EditRequiredWorkflow(ctx *context.APIContext) {
+	// swagger:operation PATCH /orgs/{org}/actions/required_workflows/{workflow_id} organization orgEditRequiredWorkflow
+	// ---
+	// summary: Edit a required workflow of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: workflow_id
+	//   in: path
+	//   description: id of the required workflow
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditRequiredWorkflowOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RequiredWorkflow"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditRequiredWorkflowOption)
+
+	rw := getRequiredWorkflow(ctx)
+	if ctx.Written() {
+		return
+	}
+	if form.Ref != nil {
+		rw.Ref = *form.Ref
+	}
+	if form.RepoPatterns != nil {
+		repoPatterns, err := validateRepoPatterns(*form.RepoPatterns)
+		if err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "ValidateRepoPatterns", err)
+			return
+		}
+		rw.RepoPatterns = repoPatterns
+	}
+	if form.EnforceStatusCheck != nil {
+		rw.EnforceStatusCheck = *form.EnforceStatusCheck
+	}
+
+	// the workflow file is checked again since the ref may be changed, and the workflow name is refreshed
+	if err := actions_service.PrepareRequiredWorkflow(ctx, rw); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "PrepareRequiredWorkflow", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "PrepareRequiredWorkflow", err)
+		}
+		return
+	}
+	if err := actions_model.UpdateRequiredWorkflow(ctx, rw, "ref", "repo_patterns", "workflow_name", "enforce_status_check"); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiRequiredWorkflow, err := convert.ToRequiredWorkflow(ctx, rw)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiRequiredWorkflow)
+}
+
+// DeleteRequiredWorkflow delete a required workflow of an organization
+func DeleteRequiredWorkflow(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/actions/required_workflows/{workflow_id} organization orgDeleteRequiredWorkflow
+	// ---
+	// summary: Delete a required workflow of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: workflow_id
+	//   in: path
+	//   description: id of the required workflow
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := actions_model.DeleteRequiredWorkflow(ctx, ctx.Org.Organization.ID, ctx.PathParamInt64("workflow_id")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func getRequiredWorkflow(ctx *context.APIContext) *actions_model.ActionRequiredWorkflow {
+	rw, err := actions_model.GetRequiredWorkflowByID(ctx, ctx.Org.Organization.ID, ctx.PathParamInt64("workflow_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil
+	}
+	return rw
+}
+
+// validateRepoPatterns checks the glob patterns of the repository names, the empty patterns are ignored
+func validateRepoPatterns(patterns []string) ([]string, error) {
+	return validateGlobPatterns("repository", patterns)
+}
+
+// validateGlobPatterns checks the glob patterns of the names of a kind of objects, the empty patterns are ignored
+func validateGlobPatterns(kind string, patterns []string) ([]string, error) {
+	ret := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := glob.Compile(pattern); err != nil {
+			return nil, util.NewInvalidArgumentErrorf("invalid %s pattern %q: %v", kind, pattern, err)
+		}
+		ret = append(ret, pattern)
+	}
+	return ret, nil
+}
@@ -0,0 +1,464 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package org
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListRunnerGroups list the runner groups of an organization
+func ListRunnerGroups(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/runner-groups organization orgListRunnerGroups
+	// ---
+	// summary: List the runner groups of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerGroupList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	groups, count, err := db.FindAndCount[actions_model.ActionRunnerGroup](ctx, actions_model.FindRunnerGroupsOptions{
+		OwnerID:     ctx.Org.Organization.ID,
+		ListOptions: utils.GetListOptions(ctx),
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiGroups := make([]*api.ActionRunnerGroup, 0, len(groups))
+	for _, g := range groups {
+		apiGroups = append(apiGroups, convert.ToActionRunnerGroup(g))
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiGroups)
+}
+
+// GetRunnerGroup get a runner group of an organization
+func GetRunnerGroup(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/runner-groups/{group_id} organization orgGetRunnerGroup
+	// ---
+	// summary: Get a runner group of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerGroup"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getRunnerGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToActionRunnerGroup(g))
+}
+
+// CreateRunnerGroup create a runner group of an organization
+func CreateRunnerGroup(ctx *context.APIContext) {
+	// swagger:operation POST /orgs/{org}/actions/runner-groups organization orgCreateRunnerGroup
+	// ---
+	// summary: Create a runner group of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateActionRunnerGroupOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ActionRunnerGroup"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     description: the name of the runner group has been used
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateActionRunnerGroupOption)
+
+	g := &actions_model.ActionRunnerGroup{
+		OwnerID: ctx.Org.Organization.ID,
+		Name:    strings.TrimSpace(form.Name),
+	}
+	var err error
+	if g.RepoPatterns, err = validateRepoPatterns(form.RepoPatterns); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ValidateRepoPatterns", err)
+		return
+	}
+	if g.WorkflowPatterns, err = validateGlobPatterns("workflow", form.WorkflowPatterns); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ValidateWorkflowPatterns", err)
+		return
+	}
+
+	if err := actions_model.CreateRunnerGroup(ctx, g); err != nil {
+		if errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Error(http.StatusConflict, "CreateRunnerGroup", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToActionRunnerGroup(g))
+}
+
+// EditRunnerGroup edit a runner group of an organization
+func EditRunnerGroup(ctx *context.APIContext) {
+	// swagger:operation PATCH /orgs/{org}/actions/runner-groups/{group_id} organization orgEditRunnerGroup
+	// ---
+	// summary: Edit a runner group of an organization
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditActionRunnerGroupOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerGroup"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     description: the name of the runner group has been used
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditActionRunnerGroupOption)
+
+	g := getRunnerGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	if form.Name != nil {
+		if name := strings.TrimSpace(*form.Name); name != "" {
+			g.Name = name
+		}
+	}
+	var err error
+	if form.RepoPatterns != nil {
+		if g.RepoPatterns, err = validateRepoPatterns(*form.RepoPatterns); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "ValidateRepoPatterns", err)
+			return
+		}
+	}
+	if form.WorkflowPatterns != nil {
+		if g.WorkflowPatterns, err = validateGlobPatterns("workflow", *form.WorkflowPatterns); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "ValidateWorkflowPatterns", err)
+			return
+		}
+	}
+
+	if err := actions_model.UpdateRunnerGroup(ctx, g, "name", "repo_patterns", "workflow_patterns"); err != nil {
+		if errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Error(http.StatusConflict, "UpdateRunnerGroup", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToActionRunnerGroup(g))
+}
+
+// DeleteRunnerGroup delete a runner group of an organization
+func DeleteRunnerGroup(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/actions/runner-groups/{group_id} organization orgDeleteRunnerGroup
+	// ---
+	// summary: Delete a runner group of an organization, the runners in it could be used by all repositories again
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	if err := actions_model.DeleteRunnerGroup(ctx, ctx.Org.Organization.ID, ctx.PathParamInt64("group_id")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListRunnerGroupRunners list the runners in a runner group of an organization
+func ListRunnerGroupRunners(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/actions/runner-groups/{group_id}/runners organization orgListRunnerGroupRunners
+	// ---
+	// summary: List the runners in a runner group of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionRunnerList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getRunnerGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	runners, count, err := db.FindAndCount[actions_model.ActionRunner](ctx, actions_model.FindRunnerOptions{
+		OwnerID:     ctx.Org.Organization.ID,
+		GroupID:     g.ID,
+		Sort:        "oldest",
+		ListOptions: utils.GetListOptions(ctx),
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiRunners := make([]*api.ActionRunner, 0, len(runners))
+	for _, runner := range runners {
+		apiRunners = append(apiRunners, convert.ToActionRunner(runner))
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiRunners)
+}
+
+// AddRunnerGroupRunner add a runner of an organization to a runner group
+func AddRunnerGroupRunner(ctx *context.APIContext) {
+	// swagger:operation PUT /orgs/{org}/actions/runner-groups/{group_id}/runners/{runner_id} organization orgAddRunnerGroupRunner
+	// ---
+	// summary: Add a runner of an organization to a runner group, it's moved out of its previous group
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: runner_id
+	//   in: path
+	//   description: id of the runner
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getRunnerGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	runner := getOrgRunner(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	runner.GroupID = g.ID
+	if err := actions_model.UpdateRunner(ctx, runner, "group_id"); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveRunnerGroupRunner remove a runner from a runner group of an organization
+func RemoveRunnerGroupRunner(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/actions/runner-groups/{group_id}/runners/{runner_id} organization orgRemoveRunnerGroupRunner
+	// ---
+	// summary: Remove a runner from a runner group of an organization, it could be used by all repositories again
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: group_id
+	//   in: path
+	//   description: id of the runner group
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: runner_id
+	//   in: path
+	//   description: id of the runner
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	g := getRunnerGroup(ctx)
+	if ctx.Written() {
+		return
+	}
+	runner := getOrgRunner(ctx)
+	if ctx.Written() {
+		return
+	}
+	if runner.GroupID != g.ID {
+		ctx.NotFound()
+		return
+	}
+
+	runner.GroupID = 0
+	if err := actions_model.UpdateRunner(ctx, runner, "group_id"); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func getRunnerGroup(ctx *context.APIContext) *actions_model.ActionRunnerGroup {
+	g, err := actions_model.GetRunnerGroupByID(ctx, ctx.Org.Organization.ID, ctx.PathParamInt64("group_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil
+	}
+	return g
+}
+
+// getOrgRunner returns the runner in the path, it should be an org level runner of the organization
+func getOrgRunner(ctx *context.APIContext) *actions_model.ActionRunner {
+	runner, err := actions_model.GetRunnerByID(ctx, ctx.PathParamInt64("runner_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil
+	}
+	if runner.OwnerID != ctx.Org.Organization.ID || runner.RepoID != 0 {
+		ctx.NotFound()
+		return nil
+	}
+	return runner
+}
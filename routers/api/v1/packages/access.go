@@ -0,0 +1,569 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"code.gitea.io/gitea/models/organization"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+func packageTypeFromPath(ctx *context.APIContext) (packages_model.Type, bool) {
+	packageType := packages_model.Type(ctx.PathParam("type"))
+	if !slices.Contains(packages_model.TypeList, packageType) {
+		ctx.NotFound()
+		return "", false
+	}
+	return packageType, true
+}
+
+// ListPackageAccess gets the users and teams with access to a package
+func ListPackageAccess(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/-/access package listPackageAccess
+	// ---
+	// summary: Gets the users and teams which were granted access to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageAccessList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	pas, err := packages_model.GetAccessList(ctx, ctx.Package.Owner.ID, packageType, ctx.PathParam("name"))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetAccessList", err)
+		return
+	}
+
+	apiAccess := make([]*api.PackageAccess, 0, len(pas))
+	for _, pa := range pas {
+		a, err := convert.ToPackageAccess(ctx, pa, ctx.Doer)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToPackageAccess", err)
+			return
+		}
+		apiAccess = append(apiAccess, a)
+	}
+
+	ctx.JSON(http.StatusOK, apiAccess)
+}
+
+// SetPackageUserAccess grants a user access to a package
+func SetPackageUserAccess(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/-/access/users/{user} package setPackageUserAccess
+	// ---
+	// summary: Grant a user read, write or admin access to a package, the package does not need to exist yet
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: user
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetPackageAccessOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := user_model.GetUserByName(ctx, ctx.PathParam("user"))
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+		}
+		return
+	}
+
+	setPackageAccess(ctx, packageType, user, nil)
+}
+
+// SetPackageTeamAccess grants a team access to a package
+func SetPackageTeamAccess(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/-/access/teams/{team} package setPackageTeamAccess
+	// ---
+	// summary: Grant a team of the owning organization read, write or admin access to a package, the package does not need to exist yet
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: team
+	//   in: path
+	//   description: name of the team
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/SetPackageAccessOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	team, err := organization.GetTeam(ctx, ctx.Package.Owner.ID, ctx.PathParam("team"))
+	if err != nil {
+		if organization.IsErrTeamNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetTeam", err)
+		}
+		return
+	}
+
+	setPackageAccess(ctx, packageType, nil, team)
+}
+
+func setPackageAccess(ctx *context.APIContext, packageType packages_model.Type, user *user_model.User, team *organization.Team) {
+	form := web.GetForm(ctx).(*api.SetPackageAccessOption)
+
+	mode := perm.ParseAccessMode(form.Permission, perm.AccessModeRead, perm.AccessModeWrite, perm.AccessModeAdmin)
+
+	if err := packages_service.SetPackageAccess(ctx, ctx.Package.Owner, packageType, ctx.PathParam("name"), user, team, mode); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "SetPackageAccess", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "SetPackageAccess", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeletePackageUserAccess revokes the access of a user to a package
+func DeletePackageUserAccess(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/-/access/users/{user} package deletePackageUserAccess
+	// ---
+	// summary: Revoke the access of a user to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: user
+	//   in: path
+	//   description: username of the user
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	user, err := user_model.GetUserByName(ctx, ctx.PathParam("user"))
+	if err != nil {
+		if user_model.IsErrUserNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+		}
+		return
+	}
+
+	deletePackageAccess(ctx, packageType, user.ID, 0)
+}
+
+// DeletePackageTeamAccess revokes the access of a team to a package
+func DeletePackageTeamAccess(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/-/access/teams/{team} package deletePackageTeamAccess
+	// ---
+	// summary: Revoke the access of a team to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: team
+	//   in: path
+	//   description: name of the team
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return
+	}
+
+	team, err := organization.GetTeam(ctx, ctx.Package.Owner.ID, ctx.PathParam("team"))
+	if err != nil {
+		if organization.IsErrTeamNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetTeam", err)
+		}
+		return
+	}
+
+	deletePackageAccess(ctx, packageType, 0, team.ID)
+}
+
+func deletePackageAccess(ctx *context.APIContext, packageType packages_model.Type, userID, teamID int64) {
+	if err := packages_model.DeleteAccess(ctx, ctx.Package.Owner.ID, packageType, ctx.PathParam("name"), userID, teamID); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteAccess", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func getPackageFromPath(ctx *context.APIContext) *packages_model.Package {
+	packageType, ok := packageTypeFromPath(ctx)
+	if !ok {
+		return nil
+	}
+
+	p, err := packages_model.GetPackageByName(ctx, ctx.Package.Owner.ID, packageType, ctx.PathParam("name"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// ListPackageRepositories gets the repositories linked to a package
+func ListPackageRepositories(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/-/repositories package listPackageRepositories
+	// ---
+	// summary: Gets the repositories linked to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/RepositoryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getPackageFromPath(ctx)
+	if p == nil {
+		return
+	}
+
+	repos, err := packages_service.GetLinkedRepositories(ctx, p)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetLinkedRepositories", err)
+		return
+	}
+
+	apiRepos := make([]*api.Repository, 0, len(repos))
+	for _, repo := range repos {
+		permission, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+			return
+		}
+		if permission.HasAnyUnitAccess() {
+			apiRepos = append(apiRepos, convert.ToRepo(ctx, repo, permission))
+		}
+	}
+
+	ctx.JSON(http.StatusOK, apiRepos)
+}
+
+// getRepositoryFromPath gets the repository of the path, a repository to link must be visible to the doer
+func getRepositoryFromPath(ctx *context.APIContext, requireAccess bool) *repo_model.Repository {
+	repo, err := repo_model.GetRepositoryByOwnerAndName(ctx, ctx.PathParam("repo_owner"), ctx.PathParam("repo"))
+	if err != nil {
+		if repo_model.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByOwnerAndName", err)
+		}
+		return nil
+	}
+	if !requireAccess {
+		return repo
+	}
+
+	permission, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+		return nil
+	}
+	if !permission.HasAnyUnitAccess() {
+		ctx.NotFound()
+		return nil
+	}
+	return repo
+}
+
+// LinkPackageRepository links a package to a repository
+func LinkPackageRepository(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/-/repositories/{repo_owner}/{repo} package linkPackageRepository
+	// ---
+	// summary: Link a package to a repository, the actions jobs of linked repositories may publish the package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: repo_owner
+	//   in: path
+	//   description: owner of the repository
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repository
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getPackageFromPath(ctx)
+	if p == nil {
+		return
+	}
+	repo := getRepositoryFromPath(ctx, true)
+	if repo == nil {
+		return
+	}
+
+	if err := packages_service.LinkRepository(ctx, p, repo); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LinkRepository", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// UnlinkPackageRepository removes the link between a package and a repository
+func UnlinkPackageRepository(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/-/repositories/{repo_owner}/{repo} package unlinkPackageRepository
+	// ---
+	// summary: Remove the link between a package and a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: repo_owner
+	//   in: path
+	//   description: owner of the repository
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repository
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getPackageFromPath(ctx)
+	if p == nil {
+		return
+	}
+	repo := getRepositoryFromPath(ctx, false)
+	if repo == nil {
+		return
+	}
+
+	if err := packages_service.UnlinkRepository(ctx, p, repo); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "UnlinkRepository", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
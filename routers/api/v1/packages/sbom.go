@@ -0,0 +1,288 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/common"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
+)
+
+// ListPackageSBOMs gets the SBOM documents attached to a package
+func ListPackageSBOMs(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/sboms package listPackageSBOMs
+	// ---
+	// summary: Gets the SBOM documents attached to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageSBOMList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	docs, err := packages_sbom_service.GetDocuments(ctx, ctx.Package.Descriptor.Version)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetDocuments", err)
+		return
+	}
+
+	apiSBOMs := make([]*api.PackageSBOM, 0, len(docs))
+	for _, doc := range docs {
+		apiSBOMs = append(apiSBOMs, convert.ToPackageSBOM(doc))
+	}
+
+	ctx.JSON(http.StatusOK, apiSBOMs)
+}
+
+// UploadPackageSBOM attaches a SBOM document to a package
+func UploadPackageSBOM(ctx *context.APIContext) {
+	// swagger:operation PUT /packages/{owner}/{type}/{name}/{version}/sboms package uploadPackageSBOM
+	// ---
+	// summary: Attach a CycloneDX or SPDX document in JSON format to a package, an existing document of the same format is replaced
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   description: the SBOM document
+	//   required: true
+	//   schema:
+	//     type: object
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PackageSBOM"
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pd := ctx.Package.Descriptor
+
+	doc, err := packages_sbom_service.AttachDocument(ctx, ctx.Doer, pd.Package, pd.Version, ctx.Req.Body)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "AttachDocument", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "AttachDocument", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convert.ToPackageSBOM(doc))
+}
+
+// GetPackageSBOM downloads a SBOM document attached to a package
+func GetPackageSBOM(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/sboms/{format} package getPackageSBOM
+	// ---
+	// summary: Download a SBOM document attached to a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// - name: format
+	//   in: path
+	//   description: format of the SBOM document
+	//   type: string
+	//   enum: [cyclonedx, spdx]
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: the SBOM document
+	//     schema:
+	//       type: object
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	doc, err := packages_sbom_service.GetDocument(ctx, ctx.Package.Descriptor.Version, ctx.PathParam("format"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDocument", err)
+		}
+		return
+	}
+
+	s, err := packages_sbom_service.OpenDocument(doc)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenDocument", err)
+		return
+	}
+	defer s.Close()
+
+	modTime := time.Unix(int64(doc.File.CreatedUnix), 0)
+	common.ServeContentByReadSeeker(ctx.Base, doc.File.Name, &modTime, s)
+}
+
+// DeletePackageSBOM deletes a SBOM document attached to a package
+func DeletePackageSBOM(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version}/sboms/{format} package deletePackageSBOM
+	// ---
+	// summary: Delete a SBOM document attached to a package
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// - name: format
+	//   in: path
+	//   description: format of the SBOM document
+	//   type: string
+	//   enum: [cyclonedx, spdx]
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pd := ctx.Package.Descriptor
+
+	if err := packages_sbom_service.DeleteDocument(ctx, pd.Package, pd.Version, ctx.PathParam("format")); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteDocument", err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListPackageVulnerabilities gets the known vulnerabilities affecting a package
+func ListPackageVulnerabilities(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/vulnerabilities package listPackageVulnerabilities
+	// ---
+	// summary: Gets the known vulnerabilities affecting a package or the components listed in its SBOM documents
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageVulnerabilityList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	vulnerabilities, err := packages_model.GetVersionVulnerabilities(ctx, ctx.Package.Descriptor.Version.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetVersionVulnerabilities", err)
+		return
+	}
+
+	apiVulnerabilities := make([]*api.PackageVulnerability, 0, len(vulnerabilities))
+	for _, v := range vulnerabilities {
+		apiVulnerabilities = append(apiVulnerabilities, convert.ToPackageVulnerability(v))
+	}
+
+	ctx.JSON(http.StatusOK, apiVulnerabilities)
+}
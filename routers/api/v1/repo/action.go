@@ -5,16 +5,23 @@ package repo
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
 	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
 	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	webhook_module "code.gitea.io/gitea/modules/webhook"
 	"code.gitea.io/gitea/routers/api/v1/shared"
 	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/routers/common"
 	actions_service "code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
@@ -507,6 +514,43 @@ func (Action) GetRegistrationToken(ctx *context.APIContext) {
 	shared.GetRegistrationToken(ctx, 0, ctx.Repo.Repository.ID)
 }
 
+// GenerateRunnerJITConfig creates a just-in-time runner of the repository
+func (Action) GenerateRunnerJITConfig(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runners/generate-jitconfig repository repoGenerateRunnerJITConfig
+	// ---
+	// summary: Create an ephemeral runner of a repository which could start without registering
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/GenerateRunnerJITConfigOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/RunnerJITConfig"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	shared.GenerateRunnerJITConfig(ctx, 0, ctx.Repo.Repository.ID)
+}
+
 var _ actions_service.API = new(Action)
 
 // Action implements actions_service.API
@@ -581,3 +625,716 @@ func ListActionTasks(ctx *context.APIContext) {
 
 	ctx.JSON(http.StatusOK, &res)
 }
+
+// actionsStatusFromAPI converts a status or conclusion used by the API to statuses of actions_model.
+// It returns nil if the status is unknown.
+func actionsStatusFromAPI(status string) []actions_model.Status {
+	switch status {
+	case "queued":
+		return []actions_model.Status{actions_model.StatusUnknown, actions_model.StatusWaiting}
+	case "waiting":
+		return []actions_model.Status{actions_model.StatusBlocked}
+	case "in_progress":
+		return []actions_model.Status{actions_model.StatusRunning}
+	case "completed":
+		return []actions_model.Status{actions_model.StatusSuccess, actions_model.StatusFailure, actions_model.StatusCancelled, actions_model.StatusSkipped}
+	case "success":
+		return []actions_model.Status{actions_model.StatusSuccess}
+	case "failure":
+		return []actions_model.Status{actions_model.StatusFailure}
+	case "cancelled":
+		return []actions_model.Status{actions_model.StatusCancelled}
+	case "skipped":
+		return []actions_model.Status{actions_model.StatusSkipped}
+	}
+	return nil
+}
+
+// ListActionRuns list the workflow runs of a repository
+func ListActionRuns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs repository ListActionRuns
+	// ---
+	// summary: List a repository's workflow runs
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: event
+	//   in: query
+	//   description: the event that triggered the runs
+	//   type: string
+	// - name: branch
+	//   in: query
+	//   description: the branch of the runs
+	//   type: string
+	// - name: status
+	//   in: query
+	//   description: the status or conclusion of the runs
+	//   type: string
+	//   enum: [queued, waiting, in_progress, completed, success, failure, cancelled, skipped]
+	// - name: head_sha
+	//   in: query
+	//   description: the commit SHA of the runs
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WorkflowRunsList"
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	opts := actions_model.FindRunOptions{
+		ListOptions:  utils.GetListOptions(ctx),
+		RepoID:       ctx.Repo.Repository.ID,
+		TriggerEvent: webhook_module.HookEventType(ctx.FormString("event")),
+		CommitSHA:    ctx.FormString("head_sha"),
+	}
+	if branch := ctx.FormString("branch"); branch != "" {
+		opts.Ref = string(git.RefNameFromBranch(branch))
+	}
+	if status := ctx.FormString("status"); status != "" {
+		opts.Status = actionsStatusFromAPI(status)
+		if opts.Status == nil {
+			ctx.Error(http.StatusBadRequest, "ListActionRuns", fmt.Errorf("invalid status %q", status))
+			return
+		}
+	}
+
+	runs, total, err := db.FindAndCount[actions_model.ActionRun](ctx, opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ListActionRuns", err)
+		return
+	}
+
+	res := &api.ActionWorkflowRunsResponse{
+		TotalCount: total,
+		Entries:    make([]*api.ActionWorkflowRun, len(runs)),
+	}
+	for i := range runs {
+		res.Entries[i], err = convert.ToActionWorkflowRun(ctx, ctx.Repo.Repository, runs[i])
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToActionWorkflowRun", err)
+			return
+		}
+	}
+
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// getActionRun gets the run of the repository by the run_id path parameter, and all its jobs.
+// Any error will be written to the ctx.
+func getActionRun(ctx *context.APIContext) (*actions_model.ActionRun, []*actions_model.ActionRunJob) {
+	run, err := actions_model.GetRunByID(ctx, ctx.PathParamInt64("run_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRunByID", err)
+		}
+		return nil, nil
+	}
+	if run.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return nil, nil
+	}
+	run.Repo = ctx.Repo.Repository
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRunJobsByRunID", err)
+		return nil, nil
+	}
+	for _, job := range jobs {
+		job.Run = run
+	}
+	return run, jobs
+}
+
+// getActionJob gets the job of the repository by the job_id path parameter, its index in the run, and all jobs of the run.
+// Any error will be written to the ctx.
+func getActionJob(ctx *context.APIContext) (*actions_model.ActionRunJob, int, []*actions_model.ActionRunJob) {
+	job, err := actions_model.GetRunJobByID(ctx, ctx.PathParamInt64("job_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRunJobByID", err)
+		}
+		return nil, 0, nil
+	}
+	if job.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return nil, 0, nil
+	}
+
+	run, err := actions_model.GetRunByID(ctx, job.RunID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRunByID", err)
+		return nil, 0, nil
+	}
+	run.Repo = ctx.Repo.Repository
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetRunJobsByRunID", err)
+		return nil, 0, nil
+	}
+	for i, j := range jobs {
+		j.Run = run
+		if j.ID == job.ID {
+			return j, i, jobs
+		}
+	}
+	ctx.NotFound()
+	return nil, 0, nil
+}
+
+// GetActionRun get a workflow run of a repository
+func GetActionRun(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs/{run_id} repository GetActionRun
+	// ---
+	// summary: Get a workflow run of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WorkflowRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run, _ := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	res, err := convert.ToActionWorkflowRun(ctx, ctx.Repo.Repository, run)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToActionWorkflowRun", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ListActionRunJobs list the jobs of a workflow run
+func ListActionRunJobs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs/{run_id}/jobs repository ListActionRunJobs
+	// ---
+	// summary: List the jobs of a workflow run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WorkflowJobsList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	_, jobs := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	res := &api.ActionWorkflowJobsResponse{
+		TotalCount: int64(len(jobs)),
+		Entries:    make([]*api.ActionWorkflowJob, len(jobs)),
+	}
+	for i := range jobs {
+		var err error
+		res.Entries[i], err = convert.ToActionWorkflowJob(ctx, ctx.Repo.Repository, jobs[i], i)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToActionWorkflowJob", err)
+			return
+		}
+	}
+
+	ctx.SetTotalCountHeader(res.TotalCount)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetActionJob get a job of a workflow run
+func GetActionJob(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/jobs/{job_id} repository GetActionJob
+	// ---
+	// summary: Get a job of a workflow run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job_id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WorkflowJob"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, index, _ := getActionJob(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	res, err := convert.ToActionWorkflowJob(ctx, ctx.Repo.Repository, job, index)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToActionWorkflowJob", err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetActionJobLogs download the logs of a job
+func GetActionJobLogs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/jobs/{job_id}/logs repository GetActionJobLogs
+	// ---
+	// summary: Download the logs of a job
+	// produces:
+	// - text/plain
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job_id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   200:
+	//     description: Returns the logs of the job.
+	//     schema:
+	//       type: file
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, _, _ := getActionJob(ctx)
+	if ctx.Written() {
+		return
+	}
+	if job.TaskID == 0 {
+		ctx.NotFound("job is not started")
+		return
+	}
+
+	task, err := actions_model.GetTaskByID(ctx, job.TaskID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetTaskByID", err)
+		return
+	}
+	if task.LogExpired {
+		ctx.NotFound("logs have been cleaned up")
+		return
+	}
+
+	reader, err := actions.OpenLogs(ctx, task.LogInStorage, task.LogFilename)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "OpenLogs", err)
+		return
+	}
+	defer reader.Close()
+
+	workflowName := job.Run.WorkflowID
+	if p := strings.Index(workflowName, "."); p > 0 {
+		workflowName = workflowName[0:p]
+	}
+	ctx.ServeContent(reader, &context.ServeHeaderOptions{
+		Filename:           fmt.Sprintf("%v-%v-%v.log", workflowName, job.Name, task.ID),
+		ContentLength:      &task.LogSize,
+		ContentType:        "text/plain",
+		ContentTypeCharset: "utf-8",
+		Disposition:        "attachment",
+	})
+}
+
+// StreamActionJobLogs streams the logs of a job
+func StreamActionJobLogs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/jobs/{job_id}/logs/stream repository StreamActionJobLogs
+	// ---
+	// summary: Stream the logs of a job as server-sent events until the job is done
+	// description: A `logs` event is sent with the new lines, its id is the number of the lines sent so far.
+	//   A `done` event is sent with the status of the job at the end of the stream.
+	// produces:
+	// - text/event-stream
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job_id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: offset
+	//   in: query
+	//   description: the number of the lines to skip, to resume the stream. The Last-Event-ID header takes precedence if it's sent
+	//   type: integer
+	//   format: int64
+	// - name: Last-Event-ID
+	//   in: header
+	//   description: the id of the last received event, to resume the stream
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionJobLogStream"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, _, _ := getActionJob(ctx)
+	if ctx.Written() {
+		return
+	}
+	common.ServeActionJobLogStream(ctx.Base, job)
+}
+
+// ListActionJobStepSummaries lists the summaries written by the steps of a job
+func ListActionJobStepSummaries(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/jobs/{job_id}/summaries repository ListActionJobStepSummaries
+	// ---
+	// summary: List the summaries written by the steps of a job
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job_id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionJobStepSummaryList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	job, _, _ := getActionJob(ctx)
+	if ctx.Written() {
+		return
+	}
+	ret := make([]*api.ActionJobStepSummary, 0)
+	if job.TaskID == 0 {
+		ctx.JSON(http.StatusOK, ret)
+		return
+	}
+
+	task, err := actions_model.GetTaskByID(ctx, job.TaskID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetTaskByID", err)
+		return
+	}
+	summaries, err := actions_service.GetTaskStepSummaries(ctx, ctx.Repo.Repository, task)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetTaskStepSummaries", err)
+		return
+	}
+	for _, s := range summaries {
+		ret = append(ret, &api.ActionJobStepSummary{
+			StepIndex:   s.StepIndex,
+			StepName:    s.StepName,
+			Content:     s.Content,
+			ContentHTML: string(s.RenderedHTML),
+		})
+	}
+	ctx.JSON(http.StatusOK, ret)
+}
+
+// CancelActionRun cancel a workflow run
+func CancelActionRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runs/{run_id}/cancel repository CancelActionRun
+	// ---
+	// summary: Cancel a workflow run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+
+	run, jobs := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if run.Status.IsDone() {
+		ctx.Error(http.StatusConflict, "CancelActionRun", "the run is already done")
+		return
+	}
+
+	if err := actions_service.CancelRunJobs(ctx, jobs); err != nil {
+		ctx.Error(http.StatusInternalServerError, "CancelRunJobs", err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+// ApproveActionRun approve a workflow run which needs approval
+func ApproveActionRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runs/{run_id}/approve repository ApproveActionRun
+	// ---
+	// summary: Approve a workflow run which is triggered by a pull request from a fork
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+
+	run, jobs := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	if !run.NeedApproval {
+		ctx.Error(http.StatusConflict, "ApproveActionRun", "the run doesn't need approval")
+		return
+	}
+
+	if err := actions_service.ApproveRun(ctx, run, jobs, ctx.Doer.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "ApproveRun", err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// RerunActionRun rerun all jobs of a workflow run
+func RerunActionRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runs/{run_id}/rerun repository RerunActionRun
+	// ---
+	// summary: Rerun all jobs of a workflow run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+
+	run, jobs := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	rerunActionJobs(ctx, run, jobs, nil)
+}
+
+// RerunActionJob rerun a job of a workflow run and the jobs depending on it
+func RerunActionJob(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/jobs/{job_id}/rerun repository RerunActionJob
+	// ---
+	// summary: Rerun a job of a workflow run and the jobs depending on it
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: job_id
+	//   in: path
+	//   description: id of the job
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+
+	job, _, jobs := getActionJob(ctx)
+	if ctx.Written() {
+		return
+	}
+	if !job.Status.IsDone() {
+		ctx.Error(http.StatusConflict, "RerunActionJob", "the job is not done")
+		return
+	}
+
+	rerunActionJobs(ctx, job.Run, jobs, job)
+}
+
+func rerunActionJobs(ctx *context.APIContext, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob, job *actions_model.ActionRunJob) {
+	if !run.Status.IsDone() {
+		ctx.Error(http.StatusConflict, "RerunActionJobs", "the run is not done")
+		return
+	}
+
+	// can not rerun job when workflow is disabled
+	cfg := ctx.Repo.Repository.MustGetUnit(ctx, unit.TypeActions).ActionsConfig()
+	if cfg.IsWorkflowDisabled(run.WorkflowID) {
+		ctx.Error(http.StatusConflict, "RerunActionJobs", "the workflow is disabled")
+		return
+	}
+
+	if err := actions_service.RerunRun(ctx, run, jobs, job); err != nil {
+		ctx.Error(http.StatusInternalServerError, "RerunRun", err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
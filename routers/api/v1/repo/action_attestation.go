@@ -0,0 +1,199 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// CreateActionAttestations attests the artifacts built by the running job
+func CreateActionAttestations(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/attestations repository CreateActionAttestations
+	// ---
+	// summary: Sign build provenance attestations of the artifacts built by the running job
+	// description: Only the jobs granted the read permission of `actions` and the write permission of `attestations` can request attestations with their tokens.
+	//   An attestation is created for each subject and each file of the artifacts.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateActionAttestationOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ActionAttestationList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	task, err := actions_model.GetTaskByID(ctx, ctx.Data["ActionsTaskID"].(int64))
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetTaskByID", err)
+		return
+	}
+
+	opts := web.GetForm(ctx).(*api.CreateActionAttestationOption)
+	attestations, err := actions_service.CreateTaskAttestations(ctx, task, opts)
+	if err != nil {
+		if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.Error(http.StatusForbidden, "CreateTaskAttestations", err)
+		} else if errors.Is(err, util.ErrNotExist) {
+			ctx.Error(http.StatusNotFound, "CreateTaskAttestations", err)
+		} else if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateTaskAttestations", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateTaskAttestations", err)
+		}
+		return
+	}
+
+	res := make([]*api.ActionAttestation, 0, len(attestations))
+	for _, a := range attestations {
+		apiAttestation, err := convert.ToActionAttestation(a)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		res = append(res, apiAttestation)
+	}
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// ListActionAttestations lists the attestations of an artifact
+func ListActionAttestations(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/attestations/{subject_digest} repository ListActionAttestations
+	// ---
+	// summary: List the attestations of an artifact built by the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: subject_digest
+	//   in: path
+	//   description: digest of the artifact like "sha256:{hex}"
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionAttestationList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	digest, err := actions_service.NormalizeSubjectDigest(ctx.PathParam("subject_digest"))
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "NormalizeSubjectDigest", err)
+		return
+	}
+
+	attestations, total, err := db.FindAndCount[actions_model.ActionAttestation](ctx, actions_model.FindAttestationsOptions{
+		ListOptions:   utils.GetListOptions(ctx),
+		RepoID:        ctx.Repo.Repository.ID,
+		SubjectDigest: digest,
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	res := make([]*api.ActionAttestation, 0, len(attestations))
+	for _, a := range attestations {
+		apiAttestation, err := convert.ToActionAttestation(a)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		res = append(res, apiAttestation)
+	}
+	ctx.SetTotalCountHeader(total)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// VerifyActionAttestations verifies the attestations of an artifact
+func VerifyActionAttestations(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/attestations/{subject_digest}/verify repository VerifyActionAttestations
+	// ---
+	// summary: Verify the attestations of an artifact built by the repository with the instance key
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: subject_digest
+	//   in: path
+	//   description: digest of the artifact like "sha256:{hex}"
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionAttestationVerification"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	verification, err := actions_service.VerifySubjectAttestations(ctx, ctx.Repo.Repository.ID, ctx.PathParam("subject_digest"))
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "VerifySubjectAttestations", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, verification)
+}
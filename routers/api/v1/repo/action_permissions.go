@@ -0,0 +1,99 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	repo_model "code.gitea.io/gitea/models/repo"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+// GetActionWorkflowPermissions gets the default permissions of the tokens of the actions jobs in a repository
+func GetActionWorkflowPermissions(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/permissions/workflow repository repoGetActionWorkflowPermissions
+	// ---
+	// summary: Get the default permissions of the tokens of the actions jobs in a repository
+	// description: The default of the owner is returned if the repository doesn't set its own.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionWorkflowPermissions"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p, err := actions_service.GetDefaultWorkflowPermissions(ctx, ctx.Repo.Repository)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.ActionWorkflowPermissions{DefaultWorkflowPermissions: string(p)})
+}
+
+// SetActionWorkflowPermissions sets the default permissions of the tokens of the actions jobs in a repository
+func SetActionWorkflowPermissions(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/actions/permissions/workflow repository repoSetActionWorkflowPermissions
+	// ---
+	// summary: Set the default permissions of the tokens of the actions jobs in a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditActionWorkflowPermissionsOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ActionWorkflowPermissions"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.EditActionWorkflowPermissionsOption)
+	if err := actions_service.SetDefaultWorkflowPermissions(ctx, ctx.Repo.Repository, repo_model.WorkflowPermissions(form.DefaultWorkflowPermissions)); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "SetDefaultWorkflowPermissions", err)
+		} else if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	GetActionWorkflowPermissions(ctx)
+}
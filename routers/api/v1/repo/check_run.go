@@ -0,0 +1,437 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	commitstatus_service "code.gitea.io/gitea/services/repository/commitstatus"
+)
+
+// maxAnnotationsPerRequest is the max number of annotations could be added by a request, the same as GitHub's
+const maxAnnotationsPerRequest = 50
+
+// CreateCheckRun creates a check run of a commit
+func CreateCheckRun(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/check-runs repository repoCreateCheckRun
+	// ---
+	// summary: Create a check run of a commit
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateCheckRunOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/CheckRun"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateCheckRunOption)
+
+	cr := &git_model.CheckRun{
+		HeadSHA:    form.HeadSHA,
+		Name:       form.Name,
+		ExternalID: form.ExternalID,
+		DetailsURL: form.DetailsURL,
+	}
+	if err := cr.SetStatus(git_model.CheckRunStatus(form.Status), git_model.CheckRunConclusion(form.Conclusion)); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "SetStatus", err)
+		return
+	}
+	setCheckRunTimes(cr, form.StartedAt, form.CompletedAt)
+	annotations, err := toCheckRunAnnotations(cr, form.Output)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ToCheckRunAnnotations", err)
+		return
+	}
+
+	if err := commitstatus_service.CreateCheckRun(ctx, ctx.Repo.Repository, ctx.Doer, cr, annotations); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateCheckRun", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateCheckRun", err)
+		}
+		return
+	}
+
+	apiCheckRun, err := convert.ToCheckRun(ctx, cr)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, apiCheckRun)
+}
+
+// GetCheckRun gets a check run of the repository
+func GetCheckRun(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/check-runs/{check_run_id} repository repoGetCheckRun
+	// ---
+	// summary: Get a check run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: check_run_id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRun"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	cr := getCheckRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiCheckRun, err := convert.ToCheckRun(ctx, cr)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiCheckRun)
+}
+
+// UpdateCheckRun updates a check run of the repository, the annotations are appended to the existing ones
+func UpdateCheckRun(ctx *context.APIContext) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/check-runs/{check_run_id} repository repoUpdateCheckRun
+	// ---
+	// summary: Update a check run, the annotations in the output are appended to the existing ones
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: check_run_id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/UpdateCheckRunOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRun"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.UpdateCheckRunOption)
+
+	cr := getCheckRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	cols := []string{"name", "external_id", "details_url", "status", "conclusion", "started_unix", "completed_unix"}
+	if form.Name != nil {
+		if *form.Name == "" {
+			ctx.Error(http.StatusUnprocessableEntity, "Name", "name can't be empty")
+			return
+		}
+		cr.Name = *form.Name
+	}
+	if form.ExternalID != nil {
+		cr.ExternalID = *form.ExternalID
+	}
+	if form.DetailsURL != nil {
+		if *form.DetailsURL != "" && !validation.IsValidURL(*form.DetailsURL) {
+			ctx.Error(http.StatusUnprocessableEntity, "DetailsURL", "invalid details url")
+			return
+		}
+		cr.DetailsURL = *form.DetailsURL
+	}
+	if form.Status != nil || form.Conclusion != nil {
+		status, conclusion := cr.Status, cr.Conclusion
+		if form.Status != nil {
+			status = git_model.CheckRunStatus(*form.Status)
+			if status != git_model.CheckRunStatusCompleted {
+				conclusion = ""
+			}
+		}
+		if form.Conclusion != nil {
+			conclusion = git_model.CheckRunConclusion(*form.Conclusion)
+			if form.Status == nil {
+				status = ""
+			}
+		}
+		if err := cr.SetStatus(status, conclusion); err != nil {
+			ctx.Error(http.StatusUnprocessableEntity, "SetStatus", err)
+			return
+		}
+	}
+	setCheckRunTimes(cr, form.StartedAt, form.CompletedAt)
+	if form.Output != nil {
+		cols = append(cols, "title", "summary", "text")
+	}
+	annotations, err := toCheckRunAnnotations(cr, form.Output)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ToCheckRunAnnotations", err)
+		return
+	}
+
+	if err := git_model.UpdateCheckRun(ctx, cr, cols, annotations); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiCheckRun, err := convert.ToCheckRun(ctx, cr)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiCheckRun)
+}
+
+// ListCheckRunAnnotations lists the annotations of a check run
+func ListCheckRunAnnotations(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/check-runs/{check_run_id}/annotations repository repoListCheckRunAnnotations
+	// ---
+	// summary: List the annotations of a check run
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: check_run_id
+	//   in: path
+	//   description: id of the check run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRunAnnotationList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	cr := getCheckRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	annotations, count, err := db.FindAndCount[git_model.CheckRunAnnotation](ctx, git_model.FindCheckRunAnnotationsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		CheckRunIDs: []int64{cr.ID},
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiAnnotations := make([]*api.CheckRunAnnotation, 0, len(annotations))
+	for _, a := range annotations {
+		apiAnnotations = append(apiAnnotations, convert.ToCheckRunAnnotation(a))
+	}
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiAnnotations)
+}
+
+// ListCheckRunsByRef lists the check runs of a commit
+func ListCheckRunsByRef(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/commits/{ref}/check-runs repository repoListCheckRunsByRef
+	// ---
+	// summary: List the check runs of a commit, by branch/tag/commit reference
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: ref
+	//   in: path
+	//   description: name of branch/tag/commit
+	//   type: string
+	//   required: true
+	// - name: check_name
+	//   in: query
+	//   description: only return the check runs with the name
+	//   type: string
+	// - name: status
+	//   in: query
+	//   description: only return the check runs with the status
+	//   type: string
+	//   enum: [queued, in_progress, completed]
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CheckRunList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	sha := utils.ResolveRefOrSha(ctx, ctx.PathParam("ref"))
+	if ctx.Written() {
+		return
+	}
+
+	checkRuns, count, err := db.FindAndCount[git_model.CheckRun](ctx, git_model.FindCheckRunsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		RepoID:      ctx.Repo.Repository.ID,
+		HeadSHA:     sha,
+		Name:        ctx.FormString("check_name"),
+		Status:      git_model.CheckRunStatus(ctx.FormString("status")),
+	})
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	apiCheckRuns := make([]*api.CheckRun, 0, len(checkRuns))
+	for _, cr := range checkRuns {
+		apiCheckRun, err := convert.ToCheckRun(ctx, cr)
+		if err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		apiCheckRuns = append(apiCheckRuns, apiCheckRun)
+	}
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiCheckRuns)
+}
+
+func getCheckRun(ctx *context.APIContext) *git_model.CheckRun {
+	cr, err := git_model.GetCheckRunByID(ctx, ctx.Repo.Repository.ID, ctx.PathParamInt64("check_run_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return nil
+	}
+	return cr
+}
+
+// setCheckRunTimes overrides the timestamps of the check run with the ones reported by the client
+func setCheckRunTimes(cr *git_model.CheckRun, startedAt, completedAt *time.Time) {
+	if startedAt != nil {
+		cr.StartedUnix = timeutil.TimeStamp(startedAt.Unix())
+	}
+	if completedAt != nil && cr.Status == git_model.CheckRunStatusCompleted {
+		cr.CompletedUnix = timeutil.TimeStamp(completedAt.Unix())
+	}
+}
+
+// toCheckRunAnnotations converts and validates the annotations in the output of a check run
+func toCheckRunAnnotations(cr *git_model.CheckRun, output *api.CheckRunOutputOption) ([]*git_model.CheckRunAnnotation, error) {
+	if output == nil {
+		return nil, nil
+	}
+	cr.Title, cr.Summary, cr.Text = output.Title, output.Summary, output.Text
+	if len(output.Annotations) > maxAnnotationsPerRequest {
+		return nil, util.NewInvalidArgumentErrorf("at most %d annotations could be added by a request", maxAnnotationsPerRequest)
+	}
+
+	annotations := make([]*git_model.CheckRunAnnotation, 0, len(output.Annotations))
+	for _, a := range output.Annotations {
+		annotation := &git_model.CheckRunAnnotation{
+			Path:        a.Path,
+			StartLine:   a.StartLine,
+			EndLine:     a.EndLine,
+			StartColumn: a.StartColumn,
+			EndColumn:   a.EndColumn,
+			Level:       git_model.CheckRunAnnotationLevel(a.AnnotationLevel),
+			Title:       a.Title,
+			Message:     a.Message,
+			RawDetails:  a.RawDetails,
+		}
+		if annotation.EndLine == 0 {
+			annotation.EndLine = annotation.StartLine
+		}
+		if err := annotation.Validate(); err != nil {
+			return nil, err
+		}
+		annotations = append(annotations, annotation)
+	}
+	return annotations, nil
+}
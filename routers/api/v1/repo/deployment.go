@@ -0,0 +1,330 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/optional"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// ListDeployments list the deployments of a repository
+func ListDeployments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/deployments repository repoListDeployments
+	// ---
+	// summary: List the deployments of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment
+	//   in: query
+	//   description: name of the environment to filter by
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DeploymentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	listOptions := utils.GetListOptions(ctx)
+	opts := actions_model.FindDeploymentsOptions{
+		ListOptions: listOptions,
+		RepoID:      ctx.Repo.Repository.ID,
+	}
+	if name := ctx.FormString("environment"); name != "" {
+		env, err := actions_model.GetEnvironmentByName(ctx, ctx.Repo.Repository.ID, name)
+		if err != nil {
+			if errors.Is(err, util.ErrNotExist) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetEnvironmentByName", err)
+			}
+			return
+		}
+		opts.EnvironmentID = env.ID
+	}
+
+	deployments, count, err := db.FindAndCount[actions_model.ActionDeployment](ctx, opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindDeployments", err)
+		return
+	}
+
+	apiDeployments := make([]*api.Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		apiDeployment, err := convert.ToDeployment(ctx, d)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToDeployment", err)
+			return
+		}
+		apiDeployments = append(apiDeployments, apiDeployment)
+	}
+
+	ctx.SetLinkHeader(int(count), listOptions.PageSize)
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiDeployments)
+}
+
+// getDeployment gets the deployment of the repository by the deployment_id path parameter.
+// Any error will be written to the ctx.
+func getDeployment(ctx *context.APIContext) *actions_model.ActionDeployment {
+	d, err := actions_model.GetDeploymentByRepoAndID(ctx, ctx.Repo.Repository.ID, ctx.PathParamInt64("deployment_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDeploymentByRepoAndID", err)
+		}
+		return nil
+	}
+	return d
+}
+
+// GetDeployment get a deployment of a repository
+func GetDeployment(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/deployments/{deployment_id} repository repoGetDeployment
+	// ---
+	// summary: Get a deployment of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: deployment_id
+	//   in: path
+	//   description: id of the deployment
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Deployment"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	d := getDeployment(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiDeployment, err := convert.ToDeployment(ctx, d)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToDeployment", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiDeployment)
+}
+
+// ListDeploymentStatuses list the status history of a deployment
+func ListDeploymentStatuses(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/deployments/{deployment_id}/statuses repository repoListDeploymentStatuses
+	// ---
+	// summary: List the status history of a deployment, the latest one first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: deployment_id
+	//   in: path
+	//   description: id of the deployment
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DeploymentStatusList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	d := getDeployment(ctx)
+	if ctx.Written() {
+		return
+	}
+	statuses, err := actions_model.FindDeploymentStatuses(ctx, d.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindDeploymentStatuses", err)
+		return
+	}
+
+	apiStatuses := make([]*api.DeploymentStatus, 0, len(statuses))
+	for _, s := range statuses {
+		apiStatus, err := convert.ToDeploymentStatus(ctx, s)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToDeploymentStatus", err)
+			return
+		}
+		apiStatuses = append(apiStatuses, apiStatus)
+	}
+	ctx.JSON(http.StatusOK, apiStatuses)
+}
+
+// ListPendingDeployments list the deployments of a workflow run waiting for the approval
+func ListPendingDeployments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/actions/runs/{run_id}/pending_deployments repository listPendingDeployments
+	// ---
+	// summary: List the deployments of a workflow run which are waiting for the approval of reviewers
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DeploymentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	run, _ := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	deployments, err := db.Find[actions_model.ActionDeployment](ctx, actions_model.FindDeploymentsOptions{
+		RunID:        run.ID,
+		States:       []actions_model.DeploymentState{actions_model.DeploymentStateWaiting},
+		NeedApproval: optional.Some(true),
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindDeployments", err)
+		return
+	}
+
+	apiDeployments := make([]*api.Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		apiDeployment, err := convert.ToDeployment(ctx, d)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToDeployment", err)
+			return
+		}
+		apiDeployments = append(apiDeployments, apiDeployment)
+	}
+	ctx.JSON(http.StatusOK, apiDeployments)
+}
+
+// ReviewPendingDeployments approve or reject the pending deployments of a workflow run
+func ReviewPendingDeployments(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/actions/runs/{run_id}/pending_deployments repository reviewPendingDeployments
+	// ---
+	// summary: Approve or reject the pending deployments of a workflow run, the doer must be a reviewer of the environments
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: run_id
+	//   in: path
+	//   description: id of the run
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/ReviewPendingDeploymentsOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/DeploymentList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	run, _ := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+	form := web.GetForm(ctx).(*api.ReviewPendingDeploymentsOption)
+
+	deployments, err := actions_service.ReviewPendingDeployments(ctx, run, form.EnvironmentIDs, ctx.Doer, form.State == "approved", form.Comment)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.Error(http.StatusNotFound, "ReviewPendingDeployments", err)
+		} else if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.Error(http.StatusForbidden, "ReviewPendingDeployments", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ReviewPendingDeployments", err)
+		}
+		return
+	}
+
+	apiDeployments := make([]*api.Deployment, 0, len(deployments))
+	for _, d := range deployments {
+		apiDeployment, err := convert.ToDeployment(ctx, d)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToDeployment", err)
+			return
+		}
+		apiDeployments = append(apiDeployments, apiDeployment)
+	}
+	ctx.JSON(http.StatusOK, apiDeployments)
+}
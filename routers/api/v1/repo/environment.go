@@ -0,0 +1,718 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package repo
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	secret_model "code.gitea.io/gitea/models/secret"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/optional"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+	secret_service "code.gitea.io/gitea/services/secrets"
+)
+
+// ListEnvironments list the deployment environments of a repository
+func ListEnvironments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/environments repository repoListEnvironments
+	// ---
+	// summary: List the deployment environments of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/EnvironmentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	envs, count, err := db.FindAndCount[actions_model.ActionEnvironment](ctx, actions_model.FindEnvironmentsOptions{
+		ListOptions: utils.GetListOptions(ctx),
+		RepoID:      ctx.Repo.Repository.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindEnvironments", err)
+		return
+	}
+
+	apiEnvs := make([]*api.Environment, 0, len(envs))
+	for _, env := range envs {
+		apiEnv, err := convert.ToEnvironment(ctx, env)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "ToEnvironment", err)
+			return
+		}
+		apiEnvs = append(apiEnvs, apiEnv)
+	}
+
+	ctx.SetLinkHeader(int(count), utils.GetListOptions(ctx).PageSize)
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiEnvs)
+}
+
+// getEnvironment gets the environment of the repository by the environment_name path parameter.
+// Any error will be written to the ctx.
+func getEnvironment(ctx *context.APIContext) *actions_model.ActionEnvironment {
+	env, err := actions_model.GetEnvironmentByName(ctx, ctx.Repo.Repository.ID, ctx.PathParam("environment_name"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetEnvironmentByName", err)
+		}
+		return nil
+	}
+	return env
+}
+
+// GetEnvironment get a deployment environment of a repository
+func GetEnvironment(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/environments/{environment_name} repository repoGetEnvironment
+	// ---
+	// summary: Get a deployment environment of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Environment"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	apiEnv, err := convert.ToEnvironment(ctx, env)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToEnvironment", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiEnv)
+}
+
+// CreateOrUpdateEnvironment create or update a deployment environment of a repository
+func CreateOrUpdateEnvironment(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/environments/{environment_name} repository repoCreateOrUpdateEnvironment
+	// ---
+	// summary: Create or update a deployment environment of a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateOrUpdateEnvironmentOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Environment"
+	//   "201":
+	//     "$ref": "#/responses/Environment"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	form := web.GetForm(ctx).(*api.CreateOrUpdateEnvironmentOption)
+	opts := actions_service.EnvironmentOptions{}
+	if form.WaitTimer != nil {
+		opts.WaitTimer = optional.Some(*form.WaitTimer)
+	}
+	if form.BranchPatterns != nil {
+		opts.BranchPatterns = optional.Some(*form.BranchPatterns)
+	}
+	if form.Reviewers != nil {
+		ids := make([]int64, 0, len(*form.Reviewers))
+		for _, name := range *form.Reviewers {
+			u, err := user_model.GetUserByName(ctx, name)
+			if err != nil {
+				if user_model.IsErrUserNotExist(err) {
+					ctx.Error(http.StatusUnprocessableEntity, "GetUserByName", err)
+				} else {
+					ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+				}
+				return
+			}
+			ids = append(ids, u.ID)
+		}
+		opts.ReviewerIDs = optional.Some(ids)
+	}
+
+	env, created, err := actions_service.CreateOrUpdateEnvironment(ctx, ctx.Repo.Repository, ctx.PathParam("environment_name"), opts)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) || errors.Is(err, util.ErrAlreadyExist) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateOrUpdateEnvironment", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateOrUpdateEnvironment", err)
+		}
+		return
+	}
+
+	apiEnv, err := convert.ToEnvironment(ctx, env)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ToEnvironment", err)
+		return
+	}
+	if created {
+		ctx.JSON(http.StatusCreated, apiEnv)
+	} else {
+		ctx.JSON(http.StatusOK, apiEnv)
+	}
+}
+
+// DeleteEnvironment delete a deployment environment of a repository
+func DeleteEnvironment(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/environments/{environment_name} repository repoDeleteEnvironment
+	// ---
+	// summary: Delete a deployment environment of a repository with its deployments, secrets and variables
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := actions_service.DeleteEnvironment(ctx, env); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteEnvironment", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListEnvironmentSecrets list the secrets of a deployment environment
+func ListEnvironmentSecrets(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/environments/{environment_name}/secrets repository repoListEnvironmentSecrets
+	// ---
+	// summary: List the secrets of a deployment environment
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/SecretList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	secrets, count, err := db.FindAndCount[secret_model.Secret](ctx, &secret_model.FindSecretsOptions{
+		ListOptions:   utils.GetListOptions(ctx),
+		RepoID:        ctx.Repo.Repository.ID,
+		EnvironmentID: env.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindSecrets", err)
+		return
+	}
+
+	apiSecrets := make([]*api.Secret, len(secrets))
+	for k, v := range secrets {
+		apiSecrets[k] = &api.Secret{
+			Name:    v.Name,
+			Created: v.CreatedUnix.AsTime(),
+		}
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, apiSecrets)
+}
+
+// CreateOrUpdateEnvironmentSecret create or update a secret of a deployment environment
+func CreateOrUpdateEnvironmentSecret(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/environments/{environment_name}/secrets/{secretname} repository repoUpdateEnvironmentSecret
+	// ---
+	// summary: Create or update a secret of a deployment environment
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateOrUpdateSecretOption"
+	// responses:
+	//   "201":
+	//     description: response when creating a secret
+	//   "204":
+	//     description: response when updating a secret
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	opt := web.GetForm(ctx).(*api.CreateOrUpdateSecretOption)
+
+	_, created, err := secret_service.CreateOrUpdateEnvironmentSecret(ctx, ctx.Repo.Repository.ID, env.ID, ctx.PathParam("secretname"), opt.Data)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "CreateOrUpdateEnvironmentSecret", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateOrUpdateEnvironmentSecret", err)
+		}
+		return
+	}
+
+	if created {
+		ctx.Status(http.StatusCreated)
+	} else {
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// DeleteEnvironmentSecret delete a secret of a deployment environment
+func DeleteEnvironmentSecret(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/environments/{environment_name}/secrets/{secretname} repository repoDeleteEnvironmentSecret
+	// ---
+	// summary: Delete a secret of a deployment environment
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     description: response when deleting a secret
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := secret_service.DeleteEnvironmentSecretByName(ctx, ctx.Repo.Repository.ID, env.ID, ctx.PathParam("secretname")); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "DeleteEnvironmentSecret", err)
+		} else if errors.Is(err, util.ErrNotExist) {
+			ctx.Error(http.StatusNotFound, "DeleteEnvironmentSecret", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "DeleteEnvironmentSecret", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListEnvironmentVariables list the variables of a deployment environment
+func ListEnvironmentVariables(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/environments/{environment_name}/variables repository repoListEnvironmentVariables
+	// ---
+	// summary: List the variables of a deployment environment
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/VariableList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	vars, count, err := db.FindAndCount[actions_model.ActionVariable](ctx, &actions_model.FindVariablesOpts{
+		ListOptions:   utils.GetListOptions(ctx),
+		RepoID:        ctx.Repo.Repository.ID,
+		EnvironmentID: env.ID,
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindVariables", err)
+		return
+	}
+
+	variables := make([]*api.ActionVariable, len(vars))
+	for i, v := range vars {
+		variables[i] = &api.ActionVariable{
+			OwnerID: v.OwnerID,
+			RepoID:  v.RepoID,
+			Name:    v.Name,
+			Data:    v.Data,
+		}
+	}
+
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, variables)
+}
+
+// CreateEnvironmentVariable create a variable of a deployment environment
+func CreateEnvironmentVariable(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/environments/{environment_name}/variables/{variablename} repository repoCreateEnvironmentVariable
+	// ---
+	// summary: Create a variable of a deployment environment
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: variablename
+	//   in: path
+	//   description: name of the variable
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateVariableOption"
+	// responses:
+	//   "204":
+	//     description: response when creating a variable
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/conflict"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	opt := web.GetForm(ctx).(*api.CreateVariableOption)
+	variableName := ctx.PathParam("variablename")
+
+	v, err := actions_service.GetVariable(ctx, actions_model.FindVariablesOpts{
+		RepoID:        ctx.Repo.Repository.ID,
+		EnvironmentID: env.ID,
+		Name:          variableName,
+	})
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		ctx.Error(http.StatusInternalServerError, "GetVariable", err)
+		return
+	}
+	if v != nil && v.ID > 0 {
+		ctx.Error(http.StatusConflict, "VariableNameAlreadyExists", util.NewAlreadyExistErrorf("variable name %s already exists", variableName))
+		return
+	}
+
+	if _, err := actions_service.CreateEnvironmentVariable(ctx, ctx.Repo.Repository.ID, env.ID, variableName, opt.Value); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "CreateEnvironmentVariable", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "CreateEnvironmentVariable", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// getEnvironmentVariable gets the variable of the environment by the variablename path parameter.
+// Any error will be written to the ctx.
+func getEnvironmentVariable(ctx *context.APIContext, env *actions_model.ActionEnvironment) *actions_model.ActionVariable {
+	v, err := actions_service.GetVariable(ctx, actions_model.FindVariablesOpts{
+		RepoID:        ctx.Repo.Repository.ID,
+		EnvironmentID: env.ID,
+		Name:          ctx.PathParam("variablename"),
+	})
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.Error(http.StatusNotFound, "GetVariable", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetVariable", err)
+		}
+		return nil
+	}
+	return v
+}
+
+// UpdateEnvironmentVariable update a variable of a deployment environment
+func UpdateEnvironmentVariable(ctx *context.APIContext) {
+	// swagger:operation PUT /repos/{owner}/{repo}/environments/{environment_name}/variables/{variablename} repository repoUpdateEnvironmentVariable
+	// ---
+	// summary: Update a variable of a deployment environment
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: variablename
+	//   in: path
+	//   description: name of the variable
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/UpdateVariableOption"
+	// responses:
+	//   "204":
+	//     description: response when updating a variable
+	//   "400":
+	//     "$ref": "#/responses/error"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	v := getEnvironmentVariable(ctx, env)
+	if ctx.Written() {
+		return
+	}
+	opt := web.GetForm(ctx).(*api.UpdateVariableOption)
+	if opt.Name == "" {
+		opt.Name = v.Name
+	}
+	if _, err := actions_service.UpdateVariable(ctx, v.ID, opt.Name, opt.Value); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusBadRequest, "UpdateVariable", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "UpdateVariable", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeleteEnvironmentVariable delete a variable of a deployment environment
+func DeleteEnvironmentVariable(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/environments/{environment_name}/variables/{variablename} repository repoDeleteEnvironmentVariable
+	// ---
+	// summary: Delete a variable of a deployment environment
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: environment_name
+	//   in: path
+	//   description: name of the environment
+	//   type: string
+	//   required: true
+	// - name: variablename
+	//   in: path
+	//   description: name of the variable
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     description: response when deleting a variable
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	env := getEnvironment(ctx)
+	if ctx.Written() {
+		return
+	}
+	v := getEnvironmentVariable(ctx, env)
+	if ctx.Written() {
+		return
+	}
+	if err := actions_service.DeleteVariableByID(ctx, v.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteVariableByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -4,10 +4,12 @@
 package repo
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 
+	quota_model "code.gitea.io/gitea/models/quota"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -191,6 +193,8 @@ func CreateReleaseAttachment(ctx *context.APIContext) {
 	//     "$ref": "#/responses/error"
 	//   "404":
 	//     "$ref": "#/responses/notFound"
+	//   "413":
+	//     "$ref": "#/responses/error"
 
 	// Check if attachments are enabled
 	if !setting.Attachment.Enabled {
@@ -245,6 +249,10 @@ func CreateReleaseAttachment(ctx *context.APIContext) {
 			ctx.Error(http.StatusBadRequest, "DetectContentType", err)
 			return
 		}
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			ctx.Error(http.StatusRequestEntityTooLarge, "", err)
+			return
+		}
 		ctx.Error(http.StatusInternalServerError, "NewAttachment", err)
 		return
 	}
@@ -0,0 +1,276 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	stdCtx "context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	"code.gitea.io/gitea/services/context"
+)
+
+// getTaskUsageOptions parses the time range of the usage from the query, the usage of the current month is returned by default
+func getTaskUsageOptions(ctx *context.APIContext, ownerID int64) (actions_model.FindTaskUsageOptions, bool) {
+	before, since, err := context.GetQueryBeforeSince(ctx.Base)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetQueryBeforeSince", err)
+		return actions_model.FindTaskUsageOptions{}, false
+	}
+	opts := actions_model.FindTaskUsageOptions{
+		OwnerID: ownerID,
+		Since:   timeutil.TimeStamp(since),
+		Before:  timeutil.TimeStamp(before),
+	}
+	if since == 0 && before == 0 {
+		opts.Since = actions_model.MonthStart(time.Now())
+	}
+	return opts, true
+}
+
+// isCSVRequested returns whether the usage should be exported as CSV
+func isCSVRequested(ctx *context.APIContext) bool {
+	return ctx.FormString("format") == "csv"
+}
+
+func writeCSV(ctx *context.APIContext, filename string, records [][]string) {
+	ctx.Resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	ctx.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Resp.WriteHeader(http.StatusOK)
+	w := csv.NewWriter(ctx.Resp)
+	if err := w.WriteAll(records); err != nil {
+		log.Error("Write CSV: %v", err)
+	}
+}
+
+// usageNames returns the names of the repositories or the users of the usage summaries
+func usageNames(ctx stdCtx.Context, groupBy actions_model.UsageGroupBy, summaries []*actions_model.UsageSummary) (map[int64]string, error) {
+	ids := make([]int64, 0, len(summaries))
+	for _, s := range summaries {
+		if s.ID > 0 {
+			ids = append(ids, s.ID)
+		}
+	}
+	names := make(map[int64]string, len(ids))
+	switch groupBy {
+	case actions_model.UsageGroupByRepo:
+		repos, err := repo_model.GetRepositoriesMapByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id, repo := range repos {
+			names[id] = repo.FullName()
+		}
+	case actions_model.UsageGroupByOwner, actions_model.UsageGroupByUser:
+		users, err := user_model.GetUsersByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, u := range users {
+			names[u.ID] = u.Name
+		}
+	}
+	return names, nil
+}
+
+// ListActionsUsage aggregates the actions usage of the owner, or the usage of all owners if ownerID is 0
+func ListActionsUsage(ctx *context.APIContext, ownerID int64, defaultGroupBy actions_model.UsageGroupBy) {
+	opts, ok := getTaskUsageOptions(ctx, ownerID)
+	if !ok {
+		return
+	}
+	groupBy := actions_model.UsageGroupBy(ctx.FormString("group_by"))
+	if groupBy == "" {
+		groupBy = defaultGroupBy
+	}
+	if !groupBy.IsValid() {
+		ctx.Error(http.StatusUnprocessableEntity, "GroupBy", fmt.Errorf("unknown group %q", groupBy))
+		return
+	}
+
+	summaries, err := actions_model.SumTaskUsage(ctx, opts, groupBy)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	names, err := usageNames(ctx, groupBy, summaries)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	usages := make([]*api.ActionUsage, 0, len(summaries))
+	for _, s := range summaries {
+		name := s.Labels
+		if groupBy != actions_model.UsageGroupByLabel {
+			name = names[s.ID]
+		}
+		usages = append(usages, &api.ActionUsage{
+			ID:      s.ID,
+			Name:    name,
+			Jobs:    s.Jobs,
+			Seconds: s.Seconds,
+			Minutes: s.Minutes,
+		})
+	}
+
+	if isCSVRequested(ctx) {
+		records := [][]string{{string(groupBy), "name", "jobs", "seconds", "minutes"}}
+		for _, u := range usages {
+			records = append(records, []string{strconv.FormatInt(u.ID, 10), u.Name, strconv.FormatInt(u.Jobs, 10), strconv.FormatInt(u.Seconds, 10), strconv.FormatInt(u.Minutes, 10)})
+		}
+		writeCSV(ctx, "actions-usage.csv", records)
+		return
+	}
+	ctx.JSON(http.StatusOK, usages)
+}
+
+func toJobUsage(u *actions_model.ActionTaskUsage, repoName string) *api.ActionJobUsage {
+	labels := []string{}
+	if u.Labels != "" {
+		labels = strings.Split(u.Labels, ",")
+	}
+	return &api.ActionJobUsage{
+		TaskID:     u.TaskID,
+		RepoID:     u.RepoID,
+		Repository: repoName,
+		RunID:      u.RunID,
+		JobID:      u.JobID,
+		JobName:    u.JobName,
+		Labels:     labels,
+		RunnerID:   u.RunnerID,
+		Status:     u.Status.String(),
+		StartedAt:  u.Started.AsLocalTime(),
+		StoppedAt:  u.Stopped.AsLocalTime(),
+		Seconds:    u.Seconds,
+		Minutes:    u.Minutes,
+	}
+}
+
+// ListActionsJobUsage lists the actions usage of the finished jobs of the owner, or the jobs of all owners if ownerID is 0.
+// All the jobs in the time range are exported if CSV is requested.
+func ListActionsJobUsage(ctx *context.APIContext, ownerID int64) {
+	opts, ok := getTaskUsageOptions(ctx, ownerID)
+	if !ok {
+		return
+	}
+
+	repoNames := make(map[int64]string)
+	getRepoName := func(usages []*actions_model.ActionTaskUsage) error {
+		ids := make(container.Set[int64])
+		for _, u := range usages {
+			if _, ok := repoNames[u.RepoID]; !ok {
+				ids.Add(u.RepoID)
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		repos, err := repo_model.GetRepositoriesMapByIDs(ctx, ids.Values())
+		if err != nil {
+			return err
+		}
+		for id, repo := range repos {
+			repoNames[id] = repo.FullName()
+		}
+		return nil
+	}
+
+	if isCSVRequested(ctx) {
+		records := [][]string{{"task_id", "repository", "run_id", "job_id", "job_name", "labels", "runner_id", "status", "started_at", "stopped_at", "seconds", "minutes"}}
+		if err := db.Iterate(ctx, opts.ToConds(), func(ctx stdCtx.Context, u *actions_model.ActionTaskUsage) error {
+			if err := getRepoName([]*actions_model.ActionTaskUsage{u}); err != nil {
+				return err
+			}
+			ju := toJobUsage(u, repoNames[u.RepoID])
+			records = append(records, []string{
+				strconv.FormatInt(ju.TaskID, 10), ju.Repository, strconv.FormatInt(ju.RunID, 10), strconv.FormatInt(ju.JobID, 10), ju.JobName,
+				strings.Join(ju.Labels, ","), strconv.FormatInt(ju.RunnerID, 10), ju.Status,
+				ju.StartedAt.Format(time.RFC3339), ju.StoppedAt.Format(time.RFC3339), strconv.FormatInt(ju.Seconds, 10), strconv.FormatInt(ju.Minutes, 10),
+			})
+			return nil
+		}); err != nil {
+			ctx.InternalServerError(err)
+			return
+		}
+		writeCSV(ctx, "actions-job-usage.csv", records)
+		return
+	}
+
+	opts.ListOptions = utils.GetListOptions(ctx)
+	usages, count, err := db.FindAndCount[actions_model.ActionTaskUsage](ctx, opts)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	if err := getRepoName(usages); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	jobUsages := make([]*api.ActionJobUsage, 0, len(usages))
+	for _, u := range usages {
+		jobUsages = append(jobUsages, toJobUsage(u, repoNames[u.RepoID]))
+	}
+	ctx.SetTotalCountHeader(count)
+	ctx.JSON(http.StatusOK, jobUsages)
+}
+
+// GetActionsQuota responds the actions quota of the owner and its current usage, the limits are zero if the owner has no quota
+func GetActionsQuota(ctx *context.APIContext, ownerID int64) {
+	q, err := actions_model.GetQuotaByOwnerID(ctx, ownerID)
+	if errors.Is(err, util.ErrNotExist) {
+		q = &actions_model.ActionQuota{OwnerID: ownerID}
+	} else if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	usage, err := actions_model.GetQuotaUsage(ctx, ownerID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.ActionQuota{
+		MaxMonthlyMinutes: q.MaxMonthlyMinutes,
+		MaxConcurrentJobs: q.MaxConcurrentJobs,
+		MaxArtifactBytes:  q.MaxArtifactBytes,
+		MonthlyMinutes:    usage.MonthlyMinutes,
+		ConcurrentJobs:    usage.ConcurrentJobs,
+		ArtifactBytes:     usage.ArtifactBytes,
+	})
+}
+
+// SetActionsQuota sets the actions quota of the owner
+func SetActionsQuota(ctx *context.APIContext, ownerID int64) {
+	form := web.GetForm(ctx).(*api.SetActionQuotaOption)
+	if form.MaxMonthlyMinutes < 0 || form.MaxConcurrentJobs < 0 || form.MaxArtifactBytes < 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "SetActionsQuota", errors.New("the limits of the quota can't be negative"))
+		return
+	}
+	if err := actions_model.SetQuota(ctx, &actions_model.ActionQuota{
+		OwnerID:           ownerID,
+		MaxMonthlyMinutes: form.MaxMonthlyMinutes,
+		MaxConcurrentJobs: form.MaxConcurrentJobs,
+		MaxArtifactBytes:  form.MaxArtifactBytes,
+	}); err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	GetActionsQuota(ctx, ownerID)
+}
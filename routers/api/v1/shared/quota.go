@@ -0,0 +1,99 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	quota_model "code.gitea.io/gitea/models/quota"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
+)
+
+// GetQuotaInfo responds the storage quota of the owner and its current usage
+func GetQuotaInfo(ctx *context.APIContext, ownerID int64) {
+	groups, err := quota_model.GetOwnerGroups(ctx, ownerID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	rules, err := quota_model.GetRules(ctx, 0, ownerID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	limits, err := quota_model.GetLimits(ctx, ownerID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+	usage, err := quota_model.GetUsage(ctx, ownerID)
+	if err != nil {
+		ctx.InternalServerError(err)
+		return
+	}
+
+	info := &api.QuotaInfo{
+		Groups: make([]string, 0, len(groups)),
+		Rules:  convert.ToQuotaRules(rules),
+		Limits: make(map[string]int64, len(limits)),
+		Used:   make(map[string]int64, len(quota_model.Subjects)),
+	}
+	for _, g := range groups {
+		info.Groups = append(info.Groups, g.Name)
+	}
+	for subject, limit := range limits {
+		info.Limits[string(subject)] = limit
+	}
+	for _, subject := range quota_model.Subjects {
+		info.Used[string(subject)] = usage.Size(subject)
+	}
+	ctx.JSON(http.StatusOK, info)
+}
+
+// SetQuotaRule sets the rule of the subject in the path of a quota group or an owner
+func SetQuotaRule(ctx *context.APIContext, groupID, ownerID int64) {
+	form := web.GetForm(ctx).(*api.SetQuotaRuleOption)
+	r := &quota_model.Rule{
+		GroupID: groupID,
+		OwnerID: ownerID,
+		Subject: quota_model.Subject(ctx.PathParam("subject")),
+		MaxSize: form.Limit,
+	}
+	if err := quota_model.SetRule(ctx, r); err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "SetRule", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, &api.QuotaRule{
+		Subject: string(r.Subject),
+		Limit:   r.MaxSize,
+	})
+}
+
+// DeleteQuotaRule deletes the rule of the subject in the path of a quota group or an owner
+func DeleteQuotaRule(ctx *context.APIContext, groupID, ownerID int64) {
+	subject := quota_model.Subject(ctx.PathParam("subject"))
+	if !subject.IsValid() {
+		ctx.Error(http.StatusUnprocessableEntity, "DeleteRule", fmt.Errorf("unknown quota subject %q", subject))
+		return
+	}
+	if err := quota_model.DeleteRule(ctx, groupID, ownerID, subject); err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound(err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
@@ -8,8 +8,12 @@ import (
 	"net/http"
 
 	actions_model "code.gitea.io/gitea/models/actions"
+	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/convert"
 )
 
 // RegistrationToken is response related to registration token
@@ -30,3 +34,30 @@ func GetRegistrationToken(ctx *context.APIContext, ownerID, repoID int64) {
 
 	ctx.JSON(http.StatusOK, RegistrationToken{Token: token.Token})
 }
+
+// GenerateRunnerJITConfig creates a just-in-time runner in the scope and returns its config
+func GenerateRunnerJITConfig(ctx *context.APIContext, ownerID, repoID int64) {
+	form := web.GetForm(ctx).(*api.GenerateRunnerJITConfigOption)
+
+	runner, config, err := actions_service.CreateJITRunner(ctx, actions_service.JITRunnerOptions{
+		OwnerID: ownerID,
+		RepoID:  repoID,
+		Name:    form.Name,
+		Labels:  form.Labels,
+		JobID:   form.JobID,
+		GroupID: form.RunnerGroupID,
+	})
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.Error(http.StatusUnprocessableEntity, "CreateJITRunner", err)
+		} else {
+			ctx.InternalServerError(err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, &api.RunnerJITConfig{
+		Runner:           convert.ToActionRunner(runner),
+		EncodedJITConfig: config,
+	})
+}
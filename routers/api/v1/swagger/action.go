@@ -32,3 +32,66 @@ type swaggerResponseVariableList struct {
 	// in:body
 	Body []api.ActionVariable `json:"body"`
 }
+
+// RunnerJITConfig
+// swagger:response RunnerJITConfig
+type swaggerResponseRunnerJITConfig struct {
+	// in:body
+	Body api.RunnerJITConfig `json:"body"`
+}
+
+// ActionRunner
+// swagger:response ActionRunner
+type swaggerResponseActionRunner struct {
+	// in:body
+	Body api.ActionRunner `json:"body"`
+}
+
+// ActionRunnerList
+// swagger:response ActionRunnerList
+type swaggerResponseActionRunnerList struct {
+	// in:body
+	Body []api.ActionRunner `json:"body"`
+}
+
+// ActionRunnerGroup
+// swagger:response ActionRunnerGroup
+type swaggerResponseActionRunnerGroup struct {
+	// in:body
+	Body api.ActionRunnerGroup `json:"body"`
+}
+
+// ActionRunnerGroupList
+// swagger:response ActionRunnerGroupList
+type swaggerResponseActionRunnerGroupList struct {
+	// in:body
+	Body []api.ActionRunnerGroup `json:"body"`
+}
+
+// ActionUsageList
+// swagger:response ActionUsageList
+type swaggerResponseActionUsageList struct {
+	// in:body
+	Body []api.ActionUsage `json:"body"`
+}
+
+// ActionJobUsageList
+// swagger:response ActionJobUsageList
+type swaggerResponseActionJobUsageList struct {
+	// in:body
+	Body []api.ActionJobUsage `json:"body"`
+}
+
+// ActionQuota
+// swagger:response ActionQuota
+type swaggerResponseActionQuota struct {
+	// in:body
+	Body api.ActionQuota `json:"body"`
+}
+
+// ActionWorkflowPermissions
+// swagger:response ActionWorkflowPermissions
+type swaggerResponseActionWorkflowPermissions struct {
+	// in:body
+	Body api.ActionWorkflowPermissions `json:"body"`
+}
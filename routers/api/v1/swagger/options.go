@@ -205,4 +205,49 @@ type swaggerParameterBodies struct {
 
 	// in:body
 	UpdateVariableOption api.UpdateVariableOption
+
+	// in:body
+	CreateOrUpdateEnvironmentOption api.CreateOrUpdateEnvironmentOption
+
+	// in:body
+	ReviewPendingDeploymentsOption api.ReviewPendingDeploymentsOption
+
+	// in:body
+	CreateRequiredWorkflowOption api.CreateRequiredWorkflowOption
+
+	// in:body
+	EditRequiredWorkflowOption api.EditRequiredWorkflowOption
+
+	// in:body
+	GenerateRunnerJITConfigOption api.GenerateRunnerJITConfigOption
+
+	// in:body
+	CreateActionRunnerGroupOption api.CreateActionRunnerGroupOption
+
+	// in:body
+	EditActionRunnerGroupOption api.EditActionRunnerGroupOption
+
+	// in:body
+	CreateCheckRunOption api.CreateCheckRunOption
+
+	// in:body
+	UpdateCheckRunOption api.UpdateCheckRunOption
+
+	// in:body
+	SetActionQuotaOption api.SetActionQuotaOption
+
+	// in:body
+	CreateQuotaGroupOption api.CreateQuotaGroupOption
+
+	// in:body
+	SetQuotaRuleOption api.SetQuotaRuleOption
+
+	// in:body
+	CreateActionAttestationOption api.CreateActionAttestationOption
+
+	// in:body
+	EditActionWorkflowPermissionsOption api.EditActionWorkflowPermissionsOption
+
+	// in:body
+	SetPackageAccessOption api.SetPackageAccessOption
 }
@@ -41,3 +41,17 @@ type swaggerResponseOrganizationPermissions struct {
 	// in:body
 	Body api.OrganizationPermissions `json:"body"`
 }
+
+// RequiredWorkflow
+// swagger:response RequiredWorkflow
+type swaggerResponseRequiredWorkflow struct {
+	// in:body
+	Body api.RequiredWorkflow `json:"body"`
+}
+
+// RequiredWorkflowList
+// swagger:response RequiredWorkflowList
+type swaggerResponseRequiredWorkflowList struct {
+	// in:body
+	Body []api.RequiredWorkflow `json:"body"`
+}
@@ -27,3 +27,31 @@ type swaggerResponsePackageFileList struct {
 	// in:body
 	Body []api.PackageFile `json:"body"`
 }
+
+// PackageSBOM
+// swagger:response PackageSBOM
+type swaggerResponsePackageSBOM struct {
+	// in:body
+	Body api.PackageSBOM `json:"body"`
+}
+
+// PackageSBOMList
+// swagger:response PackageSBOMList
+type swaggerResponsePackageSBOMList struct {
+	// in:body
+	Body []api.PackageSBOM `json:"body"`
+}
+
+// PackageVulnerabilityList
+// swagger:response PackageVulnerabilityList
+type swaggerResponsePackageVulnerabilityList struct {
+	// in:body
+	Body []api.PackageVulnerability `json:"body"`
+}
+
+// PackageAccessList
+// swagger:response PackageAccessList
+type swaggerResponsePackageAccessList struct {
+	// in:body
+	Body []api.PackageAccess `json:"body"`
+}
@@ -436,8 +436,120 @@ type swaggerRepoTasksList struct {
 	Body api.ActionTaskResponse `json:"body"`
 }
 
+// WorkflowRunsList
+// swagger:response WorkflowRunsList
+type swaggerRepoWorkflowRunsList struct {
+	// in:body
+	Body api.ActionWorkflowRunsResponse `json:"body"`
+}
+
+// WorkflowRun
+// swagger:response WorkflowRun
+type swaggerRepoWorkflowRun struct {
+	// in:body
+	Body api.ActionWorkflowRun `json:"body"`
+}
+
+// WorkflowJobsList
+// swagger:response WorkflowJobsList
+type swaggerRepoWorkflowJobsList struct {
+	// in:body
+	Body api.ActionWorkflowJobsResponse `json:"body"`
+}
+
+// WorkflowJob
+// swagger:response WorkflowJob
+type swaggerRepoWorkflowJob struct {
+	// in:body
+	Body api.ActionWorkflowJob `json:"body"`
+}
+
+// ActionJobLogStream is the stream of the log chunks of a job as server-sent events
+// swagger:response ActionJobLogStream
+type swaggerRepoActionJobLogStream struct {
+	// in:body
+	Body api.ActionJobLogChunk `json:"body"`
+}
+
+// ActionJobStepSummaryList
+// swagger:response ActionJobStepSummaryList
+type swaggerRepoActionJobStepSummaryList struct {
+	// in:body
+	Body []api.ActionJobStepSummary `json:"body"`
+}
+
+// Environment
+// swagger:response Environment
+type swaggerRepoEnvironment struct {
+	// in:body
+	Body api.Environment `json:"body"`
+}
+
+// EnvironmentList
+// swagger:response EnvironmentList
+type swaggerRepoEnvironmentList struct {
+	// in:body
+	Body []api.Environment `json:"body"`
+}
+
+// Deployment
+// swagger:response Deployment
+type swaggerRepoDeployment struct {
+	// in:body
+	Body api.Deployment `json:"body"`
+}
+
+// DeploymentList
+// swagger:response DeploymentList
+type swaggerRepoDeploymentList struct {
+	// in:body
+	Body []api.Deployment `json:"body"`
+}
+
+// DeploymentStatusList
+// swagger:response DeploymentStatusList
+type swaggerRepoDeploymentStatusList struct {
+	// in:body
+	Body []api.DeploymentStatus `json:"body"`
+}
+
 // swagger:response Compare
 type swaggerCompare struct {
 	// in:body
 	Body api.Compare `json:"body"`
 }
+
+// CheckRun
+// swagger:response CheckRun
+type swaggerCheckRun struct {
+	// in:body
+	Body api.CheckRun `json:"body"`
+}
+
+// CheckRunList
+// swagger:response CheckRunList
+type swaggerCheckRunList struct {
+	// in:body
+	Body []api.CheckRun `json:"body"`
+}
+
+// CheckRunAnnotationList
+// swagger:response CheckRunAnnotationList
+type swaggerCheckRunAnnotationList struct {
+	// in:body
+	Body []api.CheckRunAnnotation `json:"body"`
+}
+
+// ActionAttestationList
+// swagger:response ActionAttestationList
+type swaggerRepoActionAttestationList struct {
+	// in:body
+	Body []api.ActionAttestation `json:"body"`
+}
+
+// ActionAttestationVerification
+// swagger:response ActionAttestationVerification
+type swaggerRepoActionAttestationVerification struct {
+	// in:body
+	Body api.ActionAttestationVerification `json:"body"`
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package common
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/eventsource"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+const (
+	// logStreamMaxLines is the max number of the log lines sent by an event
+	logStreamMaxLines = 1000
+	// logStreamPollInterval is the interval to read the logs if no update is notified,
+	// the runner of the task could be served by another instance, so the updates are not always notified
+	logStreamPollInterval = 5 * time.Second
+	// logStreamPingInterval is the interval to send pings to keep the connection alive
+	logStreamPingInterval = 30 * time.Second
+)
+
+// getLogStreamOffset returns the offset to resume the log stream from,
+// the browsers send the id of the last received event in the Last-Event-ID header when they reconnect.
+func getLogStreamOffset(ctx *context.Base) int64 {
+	if id := ctx.Req.Header.Get("Last-Event-ID"); id != "" {
+		if offset, err := strconv.ParseInt(id, 10, 64); err == nil && offset >= 0 {
+			return offset
+		}
+	}
+	return max(ctx.FormInt64("offset"), 0)
+}
+
+// ServeActionJobLogStream streams the logs of the job as server-sent events until the job is done.
+// A "logs" event is sent with an api.ActionJobLogChunk and the offset as its id when there are new lines,
+// and a "done" event is sent with the status of the job at the end of the stream.
+func ServeActionJobLogStream(ctx *context.Base, job *actions_model.ActionRunJob) {
+	offset := getLogStreamOffset(ctx)
+
+	ctx.Resp.Header().Set("Content-Type", "text/event-stream")
+	ctx.Resp.Header().Set("Cache-Control", "no-cache")
+	ctx.Resp.Header().Set("Connection", "keep-alive")
+	ctx.Resp.Header().Set("X-Accel-Buffering", "no")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	ctx.Resp.Flush()
+
+	write := func(event *eventsource.Event) bool {
+		if _, err := event.WriteTo(ctx.Resp); err != nil {
+			log.Debug("Unable to write to the log stream of job %d: %v", job.ID, err)
+			return false
+		}
+		ctx.Resp.Flush()
+		return true
+	}
+
+	var taskID int64
+	var updates <-chan *eventsource.Event
+	var unsubscribe func()
+	defer func() {
+		if unsubscribe != nil {
+			unsubscribe()
+		}
+	}()
+
+	pollTicker := time.NewTicker(logStreamPollInterval)
+	defer pollTicker.Stop()
+	pingTicker := time.NewTicker(logStreamPingInterval)
+	defer pingTicker.Stop()
+	shutdownCtx := graceful.GetManager().ShutdownContext()
+
+	for {
+		current, err := actions_model.GetRunJobByID(ctx, job.ID)
+		if err != nil {
+			log.Error("GetRunJobByID: %v", err)
+			return
+		}
+
+		if current.TaskID > 0 {
+			// the stream ends if the job is rerun, since the offset is meaningless for the logs of the new task
+			if taskID > 0 && taskID != current.TaskID {
+				write(&eventsource.Event{Name: "done", Data: current.Status.String()})
+				return
+			}
+			if taskID == 0 {
+				// subscribe before reading the logs, so no update will be missed
+				taskID = current.TaskID
+				updates, unsubscribe = actions_service.SubscribeTaskLogUpdates(taskID)
+			}
+
+			task, err := actions_model.GetTaskByID(ctx, taskID)
+			if err != nil {
+				log.Error("GetTaskByID: %v", err)
+				return
+			}
+			for {
+				chunk, err := actions_service.ReadTaskLogChunk(ctx, task, offset, logStreamMaxLines)
+				if err != nil {
+					log.Error("ReadTaskLogChunk: %v", err)
+					return
+				}
+				if chunk.Offset == offset {
+					break
+				}
+				offset = chunk.Offset
+				if !write(&eventsource.Event{Name: "logs", ID: strconv.FormatInt(offset, 10), Data: chunk}) {
+					return
+				}
+			}
+			if task.Status.IsDone() {
+				write(&eventsource.Event{Name: "done", Data: task.Status.String()})
+				return
+			}
+		} else if current.Status.IsDone() {
+			// the job is done without running, e.g. it has been skipped or cancelled
+			write(&eventsource.Event{Name: "done", Data: current.Status.String()})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-shutdownCtx.Done():
+			return
+		case _, ok := <-updates:
+			if !ok {
+				updates = nil
+			}
+		case <-pollTicker.C:
+		case <-pingTicker.C:
+			if !write(&eventsource.Event{Name: "ping"}) {
+				return
+			}
+		}
+	}
+}
@@ -51,6 +51,7 @@ import (
 	release_service "code.gitea.io/gitea/services/release"
 	repo_service "code.gitea.io/gitea/services/repository"
 	"code.gitea.io/gitea/services/repository/archiver"
+	secret_service "code.gitea.io/gitea/services/secrets"
 	"code.gitea.io/gitea/services/task"
 	"code.gitea.io/gitea/services/uinotification"
 	"code.gitea.io/gitea/services/webhook"
@@ -171,6 +172,7 @@ func InitWebInstalled(ctx context.Context) {
 	mustInit(svg.Init)
 
 	actions_service.Init()
+	mustInit(secret_service.Init)
 
 	// Finally start up the cron
 	cron.NewContext(ctx)
@@ -207,6 +209,8 @@ func NormalRoutes() *web.Router {
 		r.Mount(prefix, actions_router.ArtifactsRoutes(prefix))
 		prefix = actions_router.ArtifactV4RouteBase
 		r.Mount(prefix, actions_router.ArtifactsV4Routes(prefix))
+		prefix = actions_router.CacheRouteBase
+		r.Mount(prefix, actions_router.CacheRoutes(prefix))
 	}
 
 	r.NotFound(func(w http.ResponseWriter, req *http.Request) {
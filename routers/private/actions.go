@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/util"
+	actions_service "code.gitea.io/gitea/services/actions"
 	"code.gitea.io/gitea/services/context"
 )
 
@@ -90,3 +91,51 @@ func parseScope(ctx *context.PrivateContext, scope string) (ownerID, repoID int6
 	repoID = r.ID
 	return ownerID, repoID, nil
 }
+
+// VerifyActionsAttestation verifies the attestations of an artifact built by a repository
+func VerifyActionsAttestation(ctx *context.PrivateContext) {
+	var verifyRequest private.VerifyAttestationRequest
+	rd := ctx.Req.Body
+	defer rd.Close()
+
+	if err := json.NewDecoder(rd).Decode(&verifyRequest); err != nil {
+		log.Error("JSON Decode failed: %v", err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: err.Error(),
+		})
+		return
+	}
+
+	ownerName, repoName, _ := strings.Cut(verifyRequest.Repo, "/")
+	repo, err := repo_model.GetRepositoryByOwnerAndName(ctx, ownerName, repoName)
+	if err != nil {
+		if repo_model.IsErrRepoNotExist(err) {
+			ctx.JSON(http.StatusNotFound, private.Response{
+				UserMsg: fmt.Sprintf("Repository %q not found", verifyRequest.Repo),
+			})
+			return
+		}
+		log.Error("GetRepositoryByOwnerAndName failed: %v", err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: err.Error(),
+		})
+		return
+	}
+
+	verification, err := actions_service.VerifySubjectAttestations(ctx, repo.ID, verifyRequest.Digest)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.JSON(http.StatusBadRequest, private.Response{
+				UserMsg: err.Error(),
+			})
+			return
+		}
+		log.Error("VerifySubjectAttestations failed: %v", err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, verification)
+}
@@ -4,9 +4,11 @@
 package private
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
 
 	"code.gitea.io/gitea/models"
 	asymkey_model "code.gitea.io/gitea/models/asymkey"
@@ -14,6 +16,7 @@ import (
 	issues_model "code.gitea.io/gitea/models/issues"
 	perm_model "code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
+	quota_model "code.gitea.io/gitea/models/quota"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/git"
@@ -111,6 +114,10 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 		opts:           opts,
 	}
 
+	if !preReceiveQuota(ourCtx) {
+		return
+	}
+
 	// Iterate across the provided old commit IDs
 	for i := range opts.OldCommitIDs {
 		oldCommitID := opts.OldCommitIDs[i]
@@ -135,6 +142,33 @@ func HookPreReceive(ctx *gitea_context.PrivateContext) {
 	ctx.PlainText(http.StatusOK, "ok")
 }
 
+// preReceiveQuota rejects pushes adding data to a repository whose owner exceeds the git storage quota.
+// The size of the pushed objects is unknown here, so pushes are only rejected once the quota is used up.
+// Deleting references is always allowed to free some space.
+func preReceiveQuota(ctx *preReceiveContext) bool {
+	emptyObjectID := ctx.Repo.GetObjectFormat().EmptyObjectID().String()
+	if !slices.ContainsFunc(ctx.opts.NewCommitIDs, func(id string) bool { return id != emptyObjectID }) {
+		return true
+	}
+
+	repo := ctx.Repo.Repository
+	if err := quota_model.CheckQuota(ctx, repo.OwnerID, quota_model.SubjectGit, 0); err != nil {
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			log.Warn("Forbidden: Push to %-v rejected: %v", repo, err)
+			ctx.JSON(http.StatusForbidden, private.Response{
+				UserMsg: err.Error(),
+			})
+			return false
+		}
+		log.Error("Unable to check the quota of %-v: %v", repo, err)
+		ctx.JSON(http.StatusInternalServerError, private.Response{
+			Err: err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
 func preReceiveBranch(ctx *preReceiveContext, oldCommitID, newCommitID string, refFullName git.RefName) {
 	branchName := refFullName.BranchName()
 	ctx.branchName = branchName
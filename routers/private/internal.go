@@ -79,6 +79,7 @@ func Routes() *web.Router {
 	r.Post("/mail/send", SendEmail)
 	r.Post("/restore_repo", RestoreRepo)
 	r.Post("/actions/generate_actions_runner_token", GenerateActionsRunnerToken)
+	r.Post("/actions/verify_attestation", VerifyActionsAttestation)
 
 	return r
 }
@@ -32,6 +32,14 @@ func Packages(ctx *context.Context) {
 	}
 
 	shared.SetPackagesContext(ctx, ctx.ContextUser)
+	if ctx.Written() {
+		return
+	}
+
+	shared.SetContainerProxyContext(ctx, ctx.ContextUser)
+	if ctx.Written() {
+		return
+	}
 
 	ctx.HTML(http.StatusOK, tplSettingsPackages)
 }
@@ -110,6 +118,16 @@ func PackagesRulePreview(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, tplSettingsPackagesRulePreview)
 }
 
+func UpdateContainerProxy(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("packages.title")
+	ctx.Data["PageIsOrgSettings"] = true
+	ctx.Data["PageIsSettingsPackages"] = true
+
+	shared.UpdateContainerProxy(ctx, ctx.ContextUser)
+
+	ctx.Redirect(fmt.Sprintf("%s/org/%s/settings/packages", setting.AppSubURL, ctx.ContextUser.Name))
+}
+
 func InitializeCargoIndex(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("packages.title")
 	ctx.Data["PageIsOrgSettings"] = true
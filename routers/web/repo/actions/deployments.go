@@ -0,0 +1,165 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+const tplDeployments base.TplName = "repo/actions/deployments"
+
+// DeploymentView is a deployment with its run and status history to be rendered
+type DeploymentView struct {
+	*actions_model.ActionDeployment
+	Run       *actions_model.ActionRun
+	Statuses  []*actions_model.ActionDeploymentStatus
+	CanReview bool
+}
+
+// IconStatus returns the job status whose icon is used for the state of the deployment
+func (d *DeploymentView) IconStatus() string {
+	switch d.State {
+	case actions_model.DeploymentStateWaiting:
+		return actions_model.StatusBlocked.String()
+	case actions_model.DeploymentStateQueued:
+		return actions_model.StatusWaiting.String()
+	case actions_model.DeploymentStateInProgress:
+		return actions_model.StatusRunning.String()
+	case actions_model.DeploymentStateSuccess:
+		return actions_model.StatusSuccess.String()
+	case actions_model.DeploymentStateFailure:
+		return actions_model.StatusFailure.String()
+	case actions_model.DeploymentStateError:
+		return actions_model.StatusCancelled.String()
+	}
+	return actions_model.StatusSkipped.String()
+}
+
+// Deployments lists the deployments of the repository, they could be filtered by the environment
+func Deployments(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.deployments")
+	ctx.Data["PageIsActions"] = true
+	ctx.Data["PageIsDeployments"] = true
+
+	envs, err := db.Find[actions_model.ActionEnvironment](ctx, actions_model.FindEnvironmentsOptions{
+		RepoID: ctx.Repo.Repository.ID,
+	})
+	if err != nil {
+		ctx.ServerError("FindEnvironments", err)
+		return
+	}
+	ctx.Data["Environments"] = envs
+
+	envID := ctx.FormInt64("environment")
+	ctx.Data["CurEnvironment"] = envID
+
+	page := ctx.FormInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	opts := actions_model.FindDeploymentsOptions{
+		ListOptions: db.ListOptions{
+			Page:     page,
+			PageSize: setting.UI.IssuePagingNum,
+		},
+		RepoID:        ctx.Repo.Repository.ID,
+		EnvironmentID: envID,
+	}
+	deployments, total, err := db.FindAndCount[actions_model.ActionDeployment](ctx, opts)
+	if err != nil {
+		ctx.ServerError("FindDeployments", err)
+		return
+	}
+
+	views := make([]*DeploymentView, 0, len(deployments))
+	for _, d := range deployments {
+		if err := d.LoadAttributes(ctx); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+		view := &DeploymentView{ActionDeployment: d}
+		if run, err := actions_model.GetRunByID(ctx, d.RunID); err == nil {
+			run.Repo = ctx.Repo.Repository
+			view.Run = run
+		} else if !errors.Is(err, util.ErrNotExist) {
+			ctx.ServerError("GetRunByID", err)
+			return
+		}
+		view.Statuses, err = actions_model.FindDeploymentStatuses(ctx, d.ID)
+		if err != nil {
+			ctx.ServerError("FindDeploymentStatuses", err)
+			return
+		}
+		for _, s := range view.Statuses {
+			if err := s.LoadCreator(ctx); err != nil {
+				ctx.ServerError("LoadCreator", err)
+				return
+			}
+		}
+		view.CanReview = ctx.Doer != nil && d.IsPending() && d.NeedApproval && d.Environment.IsReviewer(ctx.Doer.ID)
+		views = append(views, view)
+	}
+	ctx.Data["Deployments"] = views
+
+	pager := context.NewPagination(int(total), opts.PageSize, opts.Page, 5)
+	pager.SetDefaultParams(ctx)
+	pager.AddParamString("environment", ctx.FormString("environment"))
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(http.StatusOK, tplDeployments)
+}
+
+// DeploymentApprove approves a pending deployment, the doer must be a reviewer of the environment
+func DeploymentApprove(ctx *context.Context) {
+	reviewDeployment(ctx, true)
+}
+
+// DeploymentReject rejects a pending deployment, the doer must be a reviewer of the environment
+func DeploymentReject(ctx *context.Context) {
+	reviewDeployment(ctx, false)
+}
+
+func reviewDeployment(ctx *context.Context, approved bool) {
+	d, err := actions_model.GetDeploymentByRepoAndID(ctx, ctx.Repo.Repository.ID, ctx.PathParamInt64("deployment_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetDeploymentByRepoAndID", err)
+		} else {
+			ctx.ServerError("GetDeploymentByRepoAndID", err)
+		}
+		return
+	}
+	run, err := actions_model.GetRunByID(ctx, d.RunID)
+	if err != nil {
+		ctx.ServerError("GetRunByID", err)
+		return
+	}
+
+	if _, err := actions_service.ReviewPendingDeployments(ctx, run, []int64{d.EnvironmentID}, ctx.Doer, approved, ctx.FormString("comment")); err != nil {
+		if errors.Is(err, util.ErrPermissionDenied) {
+			ctx.JSONError(ctx.Tr("actions.deployments.review.not_reviewer"))
+		} else if errors.Is(err, util.ErrNotExist) {
+			ctx.JSONError(ctx.Tr("actions.deployments.review.not_pending"))
+		} else {
+			ctx.ServerError("ReviewPendingDeployments", err)
+		}
+		return
+	}
+
+	if approved {
+		ctx.Flash.Success(ctx.Tr("actions.deployments.review.approved"))
+	} else {
+		ctx.Flash.Success(ctx.Tr("actions.deployments.review.rejected"))
+	}
+	ctx.JSONRedirect(ctx.Repo.RepoLink + "/actions/deployments")
+}
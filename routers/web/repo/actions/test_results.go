@@ -0,0 +1,51 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/util"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+)
+
+const (
+	tplTestResults base.TplName = "repo/actions/test_results"
+
+	// maxFailedTestCases is the max number of failed test cases shown in the test results of a run
+	maxFailedTestCases = 200
+)
+
+// TestResults shows the test results parsed from the JUnit reports in the artifacts of a run
+func TestResults(ctx *context.Context) {
+	run, err := actions_model.GetRunByIndex(ctx, ctx.Repo.Repository.ID, getRunIndex(ctx))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetRunByIndex", err)
+		} else {
+			ctx.ServerError("GetRunByIndex", err)
+		}
+		return
+	}
+	if err := run.LoadAttributes(ctx); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return
+	}
+
+	results, err := actions_service.GetRunTestResults(ctx, run, maxFailedTestCases)
+	if err != nil {
+		ctx.ServerError("GetRunTestResults", err)
+		return
+	}
+
+	ctx.Data["Title"] = ctx.Locale.TrString("actions.test_results") + " - " + run.Title
+	ctx.Data["PageIsActions"] = true
+	ctx.Data["Run"] = run
+	ctx.Data["TestResults"] = results
+	ctx.HTML(http.StatusOK, tplTestResults)
+}
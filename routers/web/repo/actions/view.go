@@ -6,7 +6,6 @@ package actions
 import (
 	"archive/zip"
 	"compress/gzip"
-	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -29,16 +28,15 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/storage"
 	api "code.gitea.io/gitea/modules/structs"
-	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/web"
+	"code.gitea.io/gitea/routers/common"
 	actions_service "code.gitea.io/gitea/services/actions"
 	context_module "code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/convert"
 
 	"github.com/nektos/act/pkg/jobparser"
 	"github.com/nektos/act/pkg/model"
-	"xorm.io/builder"
 )
 
 func getRunIndex(ctx *context_module.Context) int64 {
@@ -77,24 +75,26 @@ type ViewRequest struct {
 type ViewResponse struct {
 	State struct {
 		Run struct {
-			Link              string     `json:"link"`
-			Title             string     `json:"title"`
-			Status            string     `json:"status"`
-			CanCancel         bool       `json:"canCancel"`
-			CanApprove        bool       `json:"canApprove"` // the run needs an approval and the doer has permission to approve
-			CanRerun          bool       `json:"canRerun"`
-			CanDeleteArtifact bool       `json:"canDeleteArtifact"`
-			Done              bool       `json:"done"`
-			WorkflowID        string     `json:"workflowID"`
-			WorkflowLink      string     `json:"workflowLink"`
-			IsSchedule        bool       `json:"isSchedule"`
-			Jobs              []*ViewJob `json:"jobs"`
-			Commit            ViewCommit `json:"commit"`
+			Link              string           `json:"link"`
+			Title             string           `json:"title"`
+			Status            string           `json:"status"`
+			CanCancel         bool             `json:"canCancel"`
+			CanApprove        bool             `json:"canApprove"` // the run needs an approval and the doer has permission to approve
+			CanRerun          bool             `json:"canRerun"`
+			CanDeleteArtifact bool             `json:"canDeleteArtifact"`
+			Done              bool             `json:"done"`
+			WorkflowID        string           `json:"workflowID"`
+			WorkflowLink      string           `json:"workflowLink"`
+			IsSchedule        bool             `json:"isSchedule"`
+			Jobs              []*ViewJob       `json:"jobs"`
+			Commit            ViewCommit       `json:"commit"`
+			TestSummary       *ViewTestSummary `json:"testSummary"`
 		} `json:"run"`
 		CurrentJob struct {
-			Title  string         `json:"title"`
-			Detail string         `json:"detail"`
-			Steps  []*ViewJobStep `json:"steps"`
+			Title     string            `json:"title"`
+			Detail    string            `json:"detail"`
+			Steps     []*ViewJobStep    `json:"steps"`
+			Summaries []*ViewJobSummary `json:"summaries"`
 		} `json:"currentJob"`
 	} `json:"state"`
 	Logs struct {
@@ -110,6 +110,16 @@ type ViewJob struct {
 	Duration string `json:"duration"`
 }
 
+// ViewTestSummary is the summary of the test reports found in the artifacts of the run
+type ViewTestSummary struct {
+	Total   int64  `json:"total"`
+	Passed  int64  `json:"passed"`
+	Failed  int64  `json:"failed"`
+	Skipped int64  `json:"skipped"`
+	Text    string `json:"text"`
+	Link    string `json:"link"`
+}
+
 type ViewCommit struct {
 	ShortSha string     `json:"shortSHA"`
 	Link     string     `json:"link"`
@@ -133,6 +143,13 @@ type ViewJobStep struct {
 	Status   string `json:"status"`
 }
 
+// ViewJobSummary is the summary written by a step to $GITHUB_STEP_SUMMARY, rendered and sanitized
+type ViewJobSummary struct {
+	Step int64  `json:"step"`
+	Name string `json:"name"`
+	HTML string `json:"html"`
+}
+
 type ViewStepLog struct {
 	Step    int                `json:"step"`
 	Cursor  int64              `json:"cursor"`
@@ -200,6 +217,26 @@ func ViewPost(ctx *context_module.Context) {
 		Branch:   branch,
 	}
 
+	reports, err := db.Find[actions_model.ActionTestReport](ctx, actions_model.FindTestReportsOptions{
+		RepoID: run.RepoID,
+		RunIDs: []int64{run.ID},
+	})
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(reports) > 0 {
+		counts := actions_model.SumTestReports(reports)
+		resp.State.Run.TestSummary = &ViewTestSummary{
+			Total:   counts.Total,
+			Passed:  counts.Passed,
+			Failed:  counts.Failed,
+			Skipped: counts.Skipped,
+			Text:    ctx.Locale.TrString("actions.test_results.summary", counts.Passed, counts.Failed, counts.Skipped),
+			Link:    run.Link() + "/tests",
+		}
+	}
+
 	var task *actions_model.ActionTask
 	if current.TaskID > 0 {
 		var err error
@@ -220,9 +257,23 @@ func ViewPost(ctx *context_module.Context) {
 	if run.NeedApproval {
 		resp.State.CurrentJob.Detail = ctx.Locale.TrString("actions.need_approval_desc")
 	}
-	resp.State.CurrentJob.Steps = make([]*ViewJobStep, 0) // marshal to '[]' instead fo 'null' in json
-	resp.Logs.StepsLog = make([]*ViewStepLog, 0)          // marshal to '[]' instead fo 'null' in json
+	resp.State.CurrentJob.Steps = make([]*ViewJobStep, 0)        // marshal to '[]' instead fo 'null' in json
+	resp.State.CurrentJob.Summaries = make([]*ViewJobSummary, 0) // marshal to '[]' instead fo 'null' in json
+	resp.Logs.StepsLog = make([]*ViewStepLog, 0)                 // marshal to '[]' instead fo 'null' in json
 	if task != nil {
+		summaries, err := actions_service.GetTaskStepSummaries(ctx, ctx.Repo.Repository, task)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, err.Error())
+			return
+		}
+		for _, s := range summaries {
+			resp.State.CurrentJob.Summaries = append(resp.State.CurrentJob.Summaries, &ViewJobSummary{
+				Step: s.StepIndex,
+				Name: s.StepName,
+				HTML: string(s.RenderedHTML),
+			})
+		}
+
 		steps := actions.FullSteps(task)
 
 		for _, v := range steps {
@@ -328,74 +379,23 @@ func Rerun(ctx *context_module.Context) {
 		return
 	}
 
-	// reset run's start and stop time when it is done
-	if run.Status.IsDone() {
-		run.PreviousDuration = run.Duration()
-		run.Started = 0
-		run.Stopped = 0
-		if err := actions_model.UpdateRun(ctx, run, "started", "stopped", "previous_duration"); err != nil {
-			ctx.Error(http.StatusInternalServerError, err.Error())
-			return
-		}
-	}
-
-	job, jobs := getRunJobs(ctx, runIndex, jobIndex)
+	var job *actions_model.ActionRunJob
+	current, jobs := getRunJobs(ctx, runIndex, jobIndex)
 	if ctx.Written() {
 		return
 	}
-
-	if jobIndexStr == "" { // rerun all jobs
-		for _, j := range jobs {
-			// if the job has needs, it should be set to "blocked" status to wait for other jobs
-			shouldBlock := len(j.Needs) > 0
-			if err := rerunJob(ctx, j, shouldBlock); err != nil {
-				ctx.Error(http.StatusInternalServerError, err.Error())
-				return
-			}
-		}
-		ctx.JSON(http.StatusOK, struct{}{})
-		return
+	if jobIndexStr != "" {
+		job = current
 	}
 
-	rerunJobs := actions_service.GetAllRerunJobs(job, jobs)
-
-	for _, j := range rerunJobs {
-		// jobs other than the specified one should be set to "blocked" status
-		shouldBlock := j.JobID != job.JobID
-		if err := rerunJob(ctx, j, shouldBlock); err != nil {
-			ctx.Error(http.StatusInternalServerError, err.Error())
-			return
-		}
+	if err := actions_service.RerunRun(ctx, run, jobs, job); err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
 	}
 
 	ctx.JSON(http.StatusOK, struct{}{})
 }
 
-func rerunJob(ctx *context_module.Context, job *actions_model.ActionRunJob, shouldBlock bool) error {
-	status := job.Status
-	if !status.IsDone() {
-		return nil
-	}
-
-	job.TaskID = 0
-	job.Status = actions_model.StatusWaiting
-	if shouldBlock {
-		job.Status = actions_model.StatusBlocked
-	}
-	job.Started = 0
-	job.Stopped = 0
-
-	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		_, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": status}, "task_id", "status", "started", "stopped")
-		return err
-	}); err != nil {
-		return err
-	}
-
-	actions_service.CreateCommitStatus(ctx, job)
-	return nil
-}
-
 func Logs(ctx *context_module.Context) {
 	runIndex := getRunIndex(ctx)
 	jobIndex := ctx.PathParamInt64("job")
@@ -445,6 +445,18 @@ func Logs(ctx *context_module.Context) {
 	})
 }
 
+// LogsStream streams the logs of a job to the run view as server-sent events
+func LogsStream(ctx *context_module.Context) {
+	runIndex := getRunIndex(ctx)
+	jobIndex := ctx.PathParamInt64("job")
+
+	job, _ := getRunJobs(ctx, runIndex, jobIndex)
+	if ctx.Written() {
+		return
+	}
+	common.ServeActionJobLogStream(ctx.Base, job)
+}
+
 func Cancel(ctx *context_module.Context) {
 	runIndex := getRunIndex(ctx)
 
@@ -453,36 +465,11 @@ func Cancel(ctx *context_module.Context) {
 		return
 	}
 
-	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		for _, job := range jobs {
-			status := job.Status
-			if status.IsDone() {
-				continue
-			}
-			if job.TaskID == 0 {
-				job.Status = actions_model.StatusCancelled
-				job.Stopped = timeutil.TimeStampNow()
-				n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped")
-				if err != nil {
-					return err
-				}
-				if n == 0 {
-					return fmt.Errorf("job has changed, try again")
-				}
-				continue
-			}
-			if err := actions_model.StopTask(ctx, job.TaskID, actions_model.StatusCancelled); err != nil {
-				return err
-			}
-		}
-		return nil
-	}); err != nil {
+	if err := actions_service.CancelRunJobs(ctx, jobs); err != nil {
 		ctx.Error(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	actions_service.CreateCommitStatus(ctx, jobs...)
-
 	ctx.JSON(http.StatusOK, struct{}{})
 }
 
@@ -493,32 +480,12 @@ func Approve(ctx *context_module.Context) {
 	if ctx.Written() {
 		return
 	}
-	run := current.Run
-	doer := ctx.Doer
 
-	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		run.NeedApproval = false
-		run.ApprovedBy = doer.ID
-		if err := actions_model.UpdateRun(ctx, run, "need_approval", "approved_by"); err != nil {
-			return err
-		}
-		for _, job := range jobs {
-			if len(job.Needs) == 0 && job.Status.IsBlocked() {
-				job.Status = actions_model.StatusWaiting
-				_, err := actions_model.UpdateRunJob(ctx, job, nil, "status")
-				if err != nil {
-					return err
-				}
-			}
-		}
-		return nil
-	}); err != nil {
+	if err := actions_service.ApproveRun(ctx, current.Run, jobs, ctx.Doer.ID); err != nil {
 		ctx.Error(http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	actions_service.CreateCommitStatus(ctx, jobs...)
-
 	ctx.JSON(http.StatusOK, struct{}{})
 }
 
@@ -812,9 +779,10 @@ func Run(ctx *context_module.Context) {
 
 	// find workflow from commit
 	var workflows []*jobparser.SingleWorkflow
+	var content []byte
 	for _, entry := range entries {
 		if entry.Name() == workflowID {
-			content, err := actions.GetContentFromEntry(entry)
+			content, err = actions.GetContentFromEntry(entry)
 			if err != nil {
 				ctx.Error(http.StatusInternalServerError, err.Error())
 				return
@@ -900,7 +868,7 @@ func Run(ctx *context_module.Context) {
 	}
 
 	// Insert the action run and its associated jobs into the database
-	if err := actions_model.InsertRun(ctx, run, workflows); err != nil {
+	if err := actions_service.InsertRun(ctx, run, content, workflows); err != nil {
 		ctx.ServerError("workflow", err)
 		return
 	}
@@ -199,19 +199,13 @@ func httpBase(ctx *context.Context) *serviceHandler {
 					return nil
 				}
 
-				if task.IsForkPullRequest {
-					if accessMode > perm.AccessModeRead {
-						ctx.PlainText(http.StatusForbidden, "User permission denied")
-						return nil
-					}
-					environ = append(environ, fmt.Sprintf("%s=%d", repo_module.EnvActionPerm, perm.AccessModeRead))
-				} else {
-					if accessMode > perm.AccessModeWrite {
-						ctx.PlainText(http.StatusForbidden, "User permission denied")
-						return nil
-					}
-					environ = append(environ, fmt.Sprintf("%s=%d", repo_module.EnvActionPerm, perm.AccessModeWrite))
+				// the token could only access the code or the wiki as the permissions of the job grant
+				tokenAccessMode := task.UnitAccessMode(unitType)
+				if tokenAccessMode < perm.AccessModeRead || accessMode > tokenAccessMode {
+					ctx.PlainText(http.StatusForbidden, "User permission denied")
+					return nil
 				}
+				environ = append(environ, fmt.Sprintf("%s=%d", repo_module.EnvActionPerm, tokenAccessMode))
 			} else {
 				p, err := access_model.GetUserRepoPermission(ctx, repo, ctx.Doer)
 				if err != nil {
@@ -35,6 +35,7 @@ import (
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/routers/utils"
 	shared_user "code.gitea.io/gitea/routers/web/shared/user"
+	actions_service "code.gitea.io/gitea/services/actions"
 	asymkey_service "code.gitea.io/gitea/services/asymkey"
 	"code.gitea.io/gitea/services/automerge"
 	"code.gitea.io/gitea/services/context"
@@ -304,11 +305,32 @@ func PrepareMergedViewPullInfo(ctx *context.Context, issue *issues_model.Issue)
 			ctx.Data["LatestCommitStatuses"] = commitStatuses
 			ctx.Data["LatestCommitStatus"] = git_model.CalcCommitStatus(commitStatuses)
 		}
+		if !prepareTestResults(ctx, ctx.Repo.Repository, sha) {
+			return nil
+		}
 	}
 
 	return compareInfo
 }
 
+// maxPullTestFailures is the max number of failed tests of each run shown in the summary of the test results of a pull request
+const maxPullTestFailures = 10
+
+// prepareTestResults loads the test results of the Actions runs of the head commit of the pull request,
+// it returns false if an error has been written to the response.
+func prepareTestResults(ctx *context.Context, repo *repo_model.Repository, sha string) bool {
+	if !ctx.Repo.CanRead(unit.TypeActions) {
+		return true
+	}
+	testResults, err := actions_service.GetCommitTestResults(ctx, repo.ID, sha, maxPullTestFailures)
+	if err != nil {
+		ctx.ServerError("GetCommitTestResults", err)
+		return false
+	}
+	ctx.Data["TestResults"] = testResults
+	return true
+}
+
 // PrepareViewPullInfo show meta information for a pull request preview page
 func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.CompareInfo {
 	ctx.Data["PullRequestWorkInProgressPrefixes"] = setting.Repository.PullRequest.WorkInProgressPrefixes
@@ -333,7 +355,12 @@ func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.C
 		ctx.ServerError("LoadProtectedBranch", err)
 		return nil
 	}
-	ctx.Data["EnableStatusCheck"] = pb != nil && pb.EnableStatusCheck
+	enableStatusCheck, requiredContexts, err := pull_service.GetRequiredStatusCheckContexts(ctx, repo, pb)
+	if err != nil {
+		ctx.ServerError("GetRequiredStatusCheckContexts", err)
+		return nil
+	}
+	ctx.Data["EnableStatusCheck"] = enableStatusCheck
 
 	var baseGitRepo *git.Repository
 	if pull.BaseRepoID == ctx.Repo.Repository.ID && ctx.Repo.GitRepo != nil {
@@ -371,6 +398,10 @@ func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.C
 			ctx.Data["LatestCommitStatus"] = git_model.CalcCommitStatus(commitStatuses)
 		}
 
+		if !prepareTestResults(ctx, repo, sha) {
+			return nil
+		}
+
 		compareInfo, err := baseGitRepo.GetCompareInfo(pull.BaseRepo.RepoPath(),
 			pull.MergeBase, pull.GetGitRefName(), false, false)
 		if err != nil {
@@ -466,10 +497,13 @@ func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.C
 		ctx.Data["LatestCommitStatuses"] = commitStatuses
 		ctx.Data["LatestCommitStatus"] = git_model.CalcCommitStatus(commitStatuses)
 	}
+	if !prepareTestResults(ctx, repo, sha) {
+		return nil
+	}
 
-	if pb != nil && pb.EnableStatusCheck {
+	if enableStatusCheck {
 		var missingRequiredChecks []string
-		for _, requiredContext := range pb.StatusCheckContexts {
+		for _, requiredContext := range requiredContexts {
 			contextFound := false
 			matchesRequiredContext := createRequiredContextMatcher(requiredContext)
 			for _, presentStatus := range commitStatuses {
@@ -486,7 +520,7 @@ func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.C
 		ctx.Data["MissingRequiredChecks"] = missingRequiredChecks
 
 		ctx.Data["is_context_required"] = func(context string) bool {
-			for _, c := range pb.StatusCheckContexts {
+			for _, c := range requiredContexts {
 				if c == context {
 					return true
 				}
@@ -501,7 +535,7 @@ func PrepareViewPullInfo(ctx *context.Context, issue *issues_model.Issue) *git.C
 			}
 			return false
 		}
-		ctx.Data["RequiredStatusCheckState"] = pull_service.MergeRequiredContextsCommitStatus(commitStatuses, pb.StatusCheckContexts)
+		ctx.Data["RequiredStatusCheckState"] = pull_service.MergeRequiredContextsCommitStatus(commitStatuses, requiredContexts)
 	}
 
 	ctx.Data["HeadBranchMovedOn"] = headBranchSha != sha
@@ -782,6 +816,11 @@ func viewPullFiles(ctx *context.Context, specifiedStartCommit, specifiedEndCommi
 		return
 	}
 
+	if err = diff.LoadCheckRunAnnotations(ctx, ctx.Repo.Repository.ID, endCommitID); err != nil {
+		ctx.ServerError("LoadCheckRunAnnotations", err)
+		return
+	}
+
 	for _, file := range diff.Files {
 		for _, section := range file.Sections {
 			for _, line := range section.Lines {
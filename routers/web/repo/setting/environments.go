@@ -0,0 +1,119 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package setting
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/modules/web"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/context"
+	"code.gitea.io/gitea/services/forms"
+)
+
+// Environments lists the deployment environments of the repository
+func Environments(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("actions.environments")
+	ctx.Data["PageType"] = "environments"
+	ctx.Data["PageIsSharedSettingsEnvironments"] = true
+
+	envs, err := db.Find[actions_model.ActionEnvironment](ctx, actions_model.FindEnvironmentsOptions{
+		RepoID: ctx.Repo.Repository.ID,
+	})
+	if err != nil {
+		ctx.ServerError("FindEnvironments", err)
+		return
+	}
+	for _, env := range envs {
+		if err := env.LoadReviewers(ctx); err != nil {
+			ctx.ServerError("LoadReviewers", err)
+			return
+		}
+	}
+	ctx.Data["Environments"] = envs
+
+	ctx.HTML(http.StatusOK, tplRepoVariables)
+}
+
+// splitFields splits the string by any of the separators, the empty fields are dropped
+func splitFields(s, separators string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(separators, r)
+	})
+}
+
+// EnvironmentPost creates an environment or updates the environment with the same name
+func EnvironmentPost(ctx *context.Context) {
+	if ctx.HasError() { // form binding validation error
+		ctx.JSONError(ctx.GetErrMsg())
+		return
+	}
+	form := web.GetForm(ctx).(*forms.EditEnvironmentForm)
+
+	reviewerIDs := make([]int64, 0, 2)
+	for _, name := range splitFields(form.Reviewers, ", ") {
+		u, err := user_model.GetUserByName(ctx, name)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				ctx.JSONError(ctx.Tr("actions.environments.reviewer_not_exist", name))
+			} else {
+				ctx.ServerError("GetUserByName", err)
+			}
+			return
+		}
+		reviewerIDs = append(reviewerIDs, u.ID)
+	}
+	patterns := make([]string, 0, 2)
+	for _, pattern := range splitFields(form.BranchPatterns, "\r\n") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	env, _, err := actions_service.CreateOrUpdateEnvironment(ctx, ctx.Repo.Repository, form.Name, actions_service.EnvironmentOptions{
+		WaitTimer:      optional.Some(form.WaitTimer),
+		ReviewerIDs:    optional.Some(reviewerIDs),
+		BranchPatterns: optional.Some(patterns),
+	})
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidArgument) {
+			ctx.JSONError(err.Error())
+		} else {
+			log.Error("CreateOrUpdateEnvironment: %v", err)
+			ctx.JSONError(ctx.Tr("actions.environments.update.failed"))
+		}
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("actions.environments.update.success", env.Name))
+	ctx.JSONRedirect(ctx.Repo.RepoLink + "/settings/actions/environments")
+}
+
+// EnvironmentDelete deletes an environment with its deployments, secrets and variables
+func EnvironmentDelete(ctx *context.Context) {
+	env, err := actions_model.GetEnvironmentByID(ctx, ctx.Repo.Repository.ID, ctx.PathParamInt64("environment_id"))
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			ctx.NotFound("GetEnvironmentByID", err)
+		} else {
+			ctx.ServerError("GetEnvironmentByID", err)
+		}
+		return
+	}
+	if err := actions_service.DeleteEnvironment(ctx, env); err != nil {
+		log.Error("DeleteEnvironment: %v", err)
+		ctx.JSONError(ctx.Tr("actions.environments.deletion.failed"))
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("actions.environments.deletion.success"))
+	ctx.JSONRedirect(ctx.Repo.RepoLink + "/settings/actions/environments")
+}
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/web"
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
@@ -226,6 +227,29 @@ func getCleanupRuleByContext(ctx *context.Context, owner *user_model.User) *pack
 	return nil
 }
 
+func SetContainerProxyContext(ctx *context.Context, owner *user_model.User) {
+	allowed, err := container_service.GetAllowedProxyUpstreams(ctx, owner)
+	if err != nil {
+		ctx.ServerError("GetAllowedProxyUpstreams", err)
+		return
+	}
+
+	ctx.Data["ContainerProxyUpstreams"] = container_service.GetAvailableProxyUpstreams(owner)
+	ctx.Data["AllowedContainerProxyUpstreams"] = allowed
+	ctx.Data["ContainerProxyTagTTL"] = setting.Packages.ContainerProxyTagTTL
+}
+
+func UpdateContainerProxy(ctx *context.Context, owner *user_model.User) {
+	form := web.GetForm(ctx).(*forms.PackageContainerProxyForm)
+
+	if err := container_service.SetAllowedProxyUpstreams(ctx, owner, form.Upstreams); err != nil {
+		log.Error("SetAllowedProxyUpstreams failed: %v", err)
+		ctx.Flash.Error(ctx.Tr("packages.owner.settings.container_proxy.error", err))
+	} else {
+		ctx.Flash.Success(ctx.Tr("packages.owner.settings.container_proxy.success"))
+	}
+}
+
 func InitializeCargoIndex(ctx *context.Context, owner *user_model.User) {
 	err := cargo_service.InitializeIndexRepository(ctx, owner, owner)
 	if err != nil {
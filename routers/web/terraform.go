@@ -0,0 +1,25 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package web
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/context"
+)
+
+// https://developer.hashicorp.com/terraform/internals/remote-service-discovery
+type terraformServiceDiscoveryType struct {
+	ModulesV1   string `json:"modules.v1"`
+	ProvidersV1 string `json:"providers.v1"`
+}
+
+// terraformServiceDiscovery tells Terraform where the module and provider registries of the package registry are served
+func terraformServiceDiscovery(ctx *context.Context) {
+	ctx.JSON(http.StatusOK, terraformServiceDiscoveryType{
+		ModulesV1:   setting.AppSubURL + "/api/packages/terraform/modules/v1/",
+		ProvidersV1: setting.AppSubURL + "/api/packages/terraform/providers/v1/",
+	})
+}
@@ -20,6 +20,8 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
 	alpine_module "code.gitea.io/gitea/modules/packages/alpine"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	container_module "code.gitea.io/gitea/modules/packages/container"
 	debian_module "code.gitea.io/gitea/modules/packages/debian"
 	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
 	"code.gitea.io/gitea/modules/setting"
@@ -30,6 +32,7 @@ import (
 	"code.gitea.io/gitea/services/context"
 	"code.gitea.io/gitea/services/forms"
 	packages_service "code.gitea.io/gitea/services/packages"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
 )
 
 const (
@@ -179,12 +182,19 @@ func ViewPackageVersion(ctx *context.Context) {
 	ctx.Data["PackageDescriptor"] = pd
 
 	switch pd.Package.Type {
-	case packages_model.TypeContainer:
+	case packages_model.TypeContainer, packages_model.TypeTerraform:
 		registryAppURL, err := url.Parse(httplib.GuessCurrentAppURL(ctx))
 		if err != nil {
 			registryAppURL, _ = url.Parse(setting.AppURL)
 		}
 		ctx.Data["RegistryHost"] = registryAppURL.Host
+
+		if pd.Package.Type == packages_model.TypeContainer {
+			if err := loadContainerReferrers(ctx, pd); err != nil {
+				ctx.ServerError("loadContainerReferrers", err)
+				return
+			}
+		}
 	case packages_model.TypeAlpine:
 		branches := make(container.Set[string])
 		repositories := make(container.Set[string])
@@ -204,6 +214,23 @@ func ViewPackageVersion(ctx *context.Context) {
 		}
 
 		ctx.Data["Branches"] = util.Sorted(branches.Values())
+		ctx.Data["Repositories"] = util.Sorted(repositories.Values())
+		ctx.Data["Architectures"] = util.Sorted(architectures.Values())
+	case packages_model.TypeArch:
+		repositories := make(container.Set[string])
+		architectures := make(container.Set[string])
+
+		for _, f := range pd.Files {
+			for _, pp := range f.Properties {
+				switch pp.Name {
+				case arch_module.PropertyRepository:
+					repositories.Add(pp.Value)
+				case arch_module.PropertyArchitecture:
+					architectures.Add(pp.Value)
+				}
+			}
+		}
+
 		ctx.Data["Repositories"] = util.Sorted(repositories.Values())
 		ctx.Data["Architectures"] = util.Sorted(architectures.Values())
 	case packages_model.TypeDebian:
@@ -273,6 +300,22 @@ func ViewPackageVersion(ctx *context.Context) {
 	ctx.Data["LatestVersions"] = pvs
 	ctx.Data["TotalVersionCount"] = total
 
+	if !loadVulnerabilityCounts(ctx, append(pvs, pd.Version)) {
+		return
+	}
+
+	ctx.Data["Vulnerabilities"], err = packages_model.GetVersionVulnerabilities(ctx, pd.Version.ID)
+	if err != nil {
+		ctx.ServerError("GetVersionVulnerabilities", err)
+		return
+	}
+
+	ctx.Data["SBOMs"], err = packages_sbom_service.GetDocuments(ctx, pd.Version)
+	if err != nil {
+		ctx.ServerError("GetDocuments", err)
+		return
+	}
+
 	ctx.Data["CanWritePackages"] = ctx.Package.AccessMode >= perm.AccessModeWrite || ctx.IsUserSiteAdmin()
 
 	hasRepositoryAccess := false
@@ -295,6 +338,44 @@ func ViewPackageVersion(ctx *context.Context) {
 	ctx.HTML(http.StatusOK, tplPackagesView)
 }
 
+// loadVulnerabilityCounts loads the number of known vulnerabilities of the package versions to flag the affected versions
+func loadVulnerabilityCounts(ctx *context.Context, pvs []*packages_model.PackageVersion) bool {
+	versionIDs := make([]int64, 0, len(pvs))
+	for _, pv := range pvs {
+		versionIDs = append(versionIDs, pv.ID)
+	}
+
+	counts, err := packages_model.CountVersionVulnerabilities(ctx, versionIDs)
+	if err != nil {
+		ctx.ServerError("CountVersionVulnerabilities", err)
+		return false
+	}
+	ctx.Data["VulnerabilityCounts"] = counts
+	return true
+}
+
+// loadContainerReferrers loads the artifacts like signatures or SBOMs which are attached to the image manifest
+func loadContainerReferrers(ctx *context.Context, pd *packages_model.PackageDescriptor) error {
+	for _, pfd := range pd.Files {
+		if pfd.File.LowerName != container_model.ManifestFilename {
+			continue
+		}
+
+		pvs, err := container_model.GetReferrerVersions(ctx, &container_model.ReferrerSearchOptions{
+			OwnerID: pd.Owner.ID,
+			Image:   pd.Package.LowerName,
+			Subject: pfd.Properties.GetByName(container_module.PropertyDigest),
+		})
+		if err != nil {
+			return err
+		}
+
+		ctx.Data["ContainerReferrers"], err = packages_model.GetPackageDescriptors(ctx, pvs)
+		return err
+	}
+	return nil
+}
+
 // ListPackageVersions lists all versions of a package
 func ListPackageVersions(ctx *context.Context) {
 	shared_user.PrepareContextForProfileBigAvatar(ctx)
@@ -375,6 +456,10 @@ func ListPackageVersions(ctx *context.Context) {
 		}
 	}
 
+	if !loadVulnerabilityCounts(ctx, pvs) {
+		return
+	}
+
 	ctx.Data["PackageDescriptors"], err = packages_model.GetPackageDescriptors(ctx, pvs)
 	if err != nil {
 		ctx.ServerError("GetPackageDescriptors", err)
@@ -503,3 +588,24 @@ func DownloadPackageFile(ctx *context.Context) {
 
 	packages_helper.ServePackageFile(ctx, s, u, pf)
 }
+
+// DownloadPackageSBOM serves the content of a SBOM document attached to the package version
+func DownloadPackageSBOM(ctx *context.Context) {
+	doc, err := packages_sbom_service.GetDocument(ctx, ctx.Package.Descriptor.Version, ctx.PathParam("format"))
+	if err != nil {
+		if err == packages_model.ErrPackageFileNotExist {
+			ctx.NotFound("", err)
+		} else {
+			ctx.ServerError("GetDocument", err)
+		}
+		return
+	}
+
+	s, u, _, err := packages_service.GetPackageBlobStream(ctx, doc.File, doc.Blob)
+	if err != nil {
+		ctx.ServerError("GetPackageBlobStream", err)
+		return
+	}
+
+	packages_helper.ServePackageFile(ctx, s, u, doc.File)
+}
@@ -475,6 +475,7 @@ func registerRoutes(m *web.Router) {
 			ctx.Redirect(setting.AppSubURL + "/user/settings/account")
 		})
 		m.Get("/passkey-endpoints", passkeyEndpoints)
+		m.Get("/terraform.json", packagesEnabled, terraformServiceDiscovery)
 		m.Methods("GET, HEAD", "/*", public.FileHandlerFunc())
 	}, optionsCorsHandler())
 
@@ -963,6 +964,7 @@ func registerRoutes(m *web.Router) {
 						m.Post("/initialize", org.InitializeCargoIndex)
 						m.Post("/rebuild", org.RebuildCargoIndex)
 					})
+					m.Post("/container_proxy", web.Bind(forms.PackageContainerProxyForm{}), org.UpdateContainerProxy)
 				}, packagesEnabled)
 
 				m.Group("/blocked_users", func() {
@@ -993,13 +995,14 @@ func registerRoutes(m *web.Router) {
 					m.Group("/{version}", func() {
 						m.Get("", user.ViewPackageVersion)
 						m.Get("/files/{fileid}", user.DownloadPackageFile)
+						m.Get("/sboms/{format}", user.DownloadPackageSBOM)
 						m.Group("/settings", func() {
 							m.Get("", user.PackageSettings)
 							m.Post("", web.Bind(forms.PackageSettingForm{}), user.PackageSettingsPost)
 						}, reqPackageAccess(perm.AccessModeWrite))
 					})
 				})
-			}, context.PackageAssignment(), reqPackageAccess(perm.AccessModeRead))
+			}, context.PackageAssignment(nil), reqPackageAccess(perm.AccessModeRead))
 		}
 
 		m.Get("/repositories", org.Repositories)
@@ -1121,6 +1124,11 @@ func registerRoutes(m *web.Router) {
 			addSettingsRunnersRoutes()
 			addSettingsSecretsRoutes()
 			addSettingsVariablesRoutes()
+			m.Group("/environments", func() {
+				m.Combo("").Get(repo_setting.Environments).
+					Post(web.Bind(forms.EditEnvironmentForm{}), repo_setting.EnvironmentPost)
+				m.Post("/{environment_id}/delete", repo_setting.EnvironmentDelete)
+			})
 		}, actions.MustEnableActions)
 		// the follow handler must be under "settings", otherwise this incomplete repo can't be accessed
 		m.Group("/migrate", func() {
@@ -1404,17 +1412,24 @@ func registerRoutes(m *web.Router) {
 					Post(web.Bind(actions.ViewRequest{}), actions.ViewPost)
 				m.Post("/rerun", reqRepoActionsWriter, actions.Rerun)
 				m.Get("/logs", actions.Logs)
+				m.Get("/logs/stream", routing.MarkLongPolling, actions.LogsStream)
 			})
 			m.Post("/cancel", reqRepoActionsWriter, actions.Cancel)
 			m.Post("/approve", reqRepoActionsWriter, actions.Approve)
 			m.Get("/artifacts", actions.ArtifactsView)
 			m.Get("/artifacts/{artifact_name}", actions.ArtifactsDownloadView)
 			m.Delete("/artifacts/{artifact_name}", actions.ArtifactsDeleteView)
+			m.Get("/tests", actions.TestResults)
 			m.Post("/rerun", reqRepoActionsWriter, actions.Rerun)
 		})
 		m.Group("/workflows/{workflow_name}", func() {
 			m.Get("/badge.svg", actions.GetWorkflowBadge)
 		})
+		m.Group("/deployments", func() {
+			m.Get("", actions.Deployments)
+			m.Post("/{deployment_id}/approve", reqSignIn, actions.DeploymentApprove)
+			m.Post("/{deployment_id}/reject", reqSignIn, actions.DeploymentReject)
+		})
 	}, ignSignIn, context.RepoAssignment, reqRepoActionsReader, actions.MustEnableActions)
 	// end "/{username}/{reponame}/actions"
 
@@ -0,0 +1,462 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+)
+
+const (
+	inTotoStatementType   = "https://in-toto.io/Statement/v1"
+	inTotoPayloadType     = "application/vnd.in-toto+json"
+	ProvenancePredicateV1 = "https://slsa.dev/provenance/v1"
+	// provenanceBuildType identifies how the provenance of the workflow runs is built, it isn't required to be resolvable
+	provenanceBuildType = "https://gitea.com/actions/buildtypes/workflow/v1"
+	// maxAttestationSubjects is the max number of the subjects attested by a request
+	maxAttestationSubjects = 1024
+)
+
+// InTotoStatement is an in-toto statement of the provenance of artifacts,
+// see https://github.com/in-toto/attestation/blob/main/spec/v1/statement.md
+type InTotoStatement struct {
+	Type          string               `json:"_type"`
+	Subject       []*InTotoSubject     `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     *ProvenancePredicate `json:"predicate"`
+}
+
+// InTotoSubject is an artifact of an in-toto statement, the digests are keyed by the algorithms
+type InTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ProvenancePredicate is a SLSA build provenance, see https://slsa.dev/spec/v1.0/provenance
+type ProvenancePredicate struct {
+	BuildDefinition ProvenanceBuildDefinition `json:"buildDefinition"`
+	RunDetails      ProvenanceRunDetails      `json:"runDetails"`
+}
+
+type ProvenanceBuildDefinition struct {
+	BuildType            string                          `json:"buildType"`
+	ExternalParameters   ProvenanceExternalParameters    `json:"externalParameters"`
+	InternalParameters   ProvenanceInternalParameters    `json:"internalParameters"`
+	ResolvedDependencies []*ProvenanceResourceDescriptor `json:"resolvedDependencies"`
+}
+
+type ProvenanceExternalParameters struct {
+	Workflow ProvenanceWorkflow `json:"workflow"`
+}
+
+type ProvenanceWorkflow struct {
+	Ref        string `json:"ref"`
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+}
+
+type ProvenanceInternalParameters struct {
+	EventName         string `json:"event_name"`
+	RepositoryID      string `json:"repository_id"`
+	RepositoryOwnerID string `json:"repository_owner_id"`
+	RunID             string `json:"run_id"`
+	RunAttempt        string `json:"run_attempt"`
+}
+
+type ProvenanceResourceDescriptor struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+type ProvenanceRunDetails struct {
+	Builder  ProvenanceBuilder  `json:"builder"`
+	Metadata ProvenanceMetadata `json:"metadata"`
+}
+
+type ProvenanceBuilder struct {
+	ID string `json:"id"`
+}
+
+type ProvenanceMetadata struct {
+	InvocationID string     `json:"invocationId"`
+	StartedOn    *time.Time `json:"startedOn,omitempty"`
+}
+
+// attestationDigestLengths are the lengths of the hex digests of the supported algorithms
+var attestationDigestLengths = map[string]int{
+	"sha256": sha256.Size * 2,
+	"sha512": 128,
+}
+
+// parseSubjectDigest splits a digest like "sha256:{hex}" into the algorithm and the hex in lowercase
+func parseSubjectDigest(digest string) (string, string, error) {
+	algorithm, value, _ := strings.Cut(strings.ToLower(digest), ":")
+	if l, ok := attestationDigestLengths[algorithm]; !ok || len(value) != l {
+		return "", "", util.NewInvalidArgumentErrorf("invalid digest %q", digest)
+	}
+	if _, err := hex.DecodeString(value); err != nil {
+		return "", "", util.NewInvalidArgumentErrorf("invalid digest %q", digest)
+	}
+	return algorithm, value, nil
+}
+
+// NormalizeSubjectDigest returns the digest in lowercase, or an error if it's invalid
+func NormalizeSubjectDigest(digest string) (string, error) {
+	algorithm, value, err := parseSubjectDigest(digest)
+	if err != nil {
+		return "", err
+	}
+	return algorithm + ":" + value, nil
+}
+
+// dssePAE returns the pre-authentication encoding of the payload which is signed,
+// see https://github.com/secure-systems-lab/dsse/blob/master/protocol.md
+func dssePAE(payloadType string, payload []byte) string {
+	return fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// attestationSigningKey returns the instance key which signs the attestations and its id,
+// it's the JWT signing key of OAuth2, so the public key is published in the JWKS of the OIDC issuer of Actions.
+func attestationSigningKey() (oauth2.JWTSigningKey, string, error) {
+	signingKey := oauth2.DefaultSigningKey
+	if signingKey == nil || signingKey.IsSymmetric() {
+		return nil, "", errors.New("attestations can only be signed by an asymmetric JWT signing algorithm")
+	}
+	jwk, err := signingKey.ToJWK()
+	if err != nil {
+		return nil, "", err
+	}
+	return signingKey, jwk["kid"], nil
+}
+
+// CanRequestAttestation returns whether the job is granted `attestations: write`,
+// the jobs of the pull requests from forks can't request attestations unless they are triggered by pull_request_target.
+func CanRequestAttestation(ctx context.Context, job *actions_model.ActionRunJob) (bool, error) {
+	if err := job.LoadRun(ctx); err != nil {
+		return false, err
+	}
+	if job.Run.IsForkPullRequest && job.Run.TriggerEvent != actions_module.GithubEventPullRequestTarget {
+		return false, nil
+	}
+	permissions, err := getJobPermissions(job)
+	if err != nil || permissions == nil {
+		return false, err
+	}
+	return permissions.Get("attestations") == permissionWrite, nil
+}
+
+// CreateTaskAttestations signs a build provenance attestation for each of the subjects and the files of the artifacts built by the running task
+func CreateTaskAttestations(ctx context.Context, task *actions_model.ActionTask, opts *api.CreateActionAttestationOption) ([]*actions_model.ActionAttestation, error) {
+	signingKey, keyID, err := attestationSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if task.Status.IsDone() {
+		return nil, util.NewInvalidArgumentErrorf("the task is done")
+	}
+	if err := task.LoadJob(ctx); err != nil {
+		return nil, err
+	}
+	job := task.Job
+	if ok, err := CanRequestAttestation(ctx, job); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, util.NewPermissionDeniedErrorf("the job isn't granted to request attestations")
+	}
+	run := job.Run
+	if err := run.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+
+	subjects := make([]*api.ActionAttestationSubject, 0, len(opts.Subjects))
+	for _, subject := range opts.Subjects {
+		digest, err := NormalizeSubjectDigest(subject.Digest)
+		if err != nil {
+			return nil, err
+		}
+		if subject.Name == "" {
+			return nil, util.NewInvalidArgumentErrorf("the name of the subject %q is empty", subject.Digest)
+		}
+		subjects = append(subjects, &api.ActionAttestationSubject{Name: subject.Name, Digest: digest})
+	}
+	for _, name := range opts.Artifacts {
+		artifactSubjects, err := getArtifactSubjects(ctx, run.ID, name)
+		if err != nil {
+			return nil, err
+		}
+		subjects = append(subjects, artifactSubjects...)
+	}
+	if len(subjects) == 0 {
+		return nil, util.NewInvalidArgumentErrorf("no subjects to attest")
+	} else if len(subjects) > maxAttestationSubjects {
+		return nil, util.NewInvalidArgumentErrorf("too many subjects to attest: %d", len(subjects))
+	}
+
+	predicate := &ProvenancePredicate{
+		BuildDefinition: ProvenanceBuildDefinition{
+			BuildType: provenanceBuildType,
+			ExternalParameters: ProvenanceExternalParameters{
+				Workflow: ProvenanceWorkflow{
+					Ref:        run.Ref,
+					Repository: run.Repo.HTMLURL(),
+					Path:       run.WorkflowID,
+				},
+			},
+			InternalParameters: ProvenanceInternalParameters{
+				EventName:         run.TriggerEvent,
+				RepositoryID:      strconv.FormatInt(run.RepoID, 10),
+				RepositoryOwnerID: strconv.FormatInt(run.Repo.OwnerID, 10),
+				RunID:             strconv.FormatInt(run.ID, 10),
+				RunAttempt:        strconv.FormatInt(task.Attempt, 10),
+			},
+			ResolvedDependencies: []*ProvenanceResourceDescriptor{
+				{
+					URI:    "git+" + run.Repo.HTMLURL() + "@" + run.Ref,
+					Digest: map[string]string{"gitCommit": run.CommitSHA},
+				},
+			},
+		},
+		RunDetails: ProvenanceRunDetails{
+			Builder: ProvenanceBuilder{
+				ID: setting.AppURL + "actions/runner",
+			},
+			Metadata: ProvenanceMetadata{
+				InvocationID: fmt.Sprintf("%s/jobs/%d/attempts/%d", run.HTMLURL(), job.ID, task.Attempt),
+			},
+		},
+	}
+	if !task.Started.IsZero() {
+		started := task.Started.AsTime().UTC()
+		predicate.RunDetails.Metadata.StartedOn = &started
+	}
+
+	attestations := make([]*actions_model.ActionAttestation, 0, len(subjects))
+	for _, subject := range subjects {
+		algorithm, value, _ := parseSubjectDigest(subject.Digest)
+		statement := &InTotoStatement{
+			Type:          inTotoStatementType,
+			Subject:       []*InTotoSubject{{Name: subject.Name, Digest: map[string]string{algorithm: value}}},
+			PredicateType: ProvenancePredicateV1,
+			Predicate:     predicate,
+		}
+		payload, err := json.Marshal(statement)
+		if err != nil {
+			return nil, err
+		}
+		sig, err := signingKey.SigningMethod().Sign(dssePAE(inTotoPayloadType, payload), signingKey.SignKey())
+		if err != nil {
+			return nil, fmt.Errorf("sign attestation: %w", err)
+		}
+		envelope, err := json.Marshal(&api.ActionAttestationEnvelope{
+			PayloadType: inTotoPayloadType,
+			Payload:     base64.StdEncoding.EncodeToString(payload),
+			Signatures: []*api.ActionAttestationSignature{
+				{KeyID: keyID, Sig: base64.StdEncoding.EncodeToString(sig)},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		name, _ := util.SplitStringAtByteN(subject.Name, 255)
+		attestations = append(attestations, &actions_model.ActionAttestation{
+			RepoID:        task.RepoID,
+			RunID:         run.ID,
+			TaskID:        task.ID,
+			SubjectName:   name,
+			SubjectDigest: subject.Digest,
+			PredicateType: ProvenancePredicateV1,
+			KeyID:         keyID,
+			Envelope:      string(envelope),
+		})
+	}
+
+	if err := actions_model.InsertAttestations(ctx, attestations); err != nil {
+		return nil, err
+	}
+	return attestations, nil
+}
+
+// getArtifactSubjects returns the subjects of the uploaded files of an artifact of the run with their sha256 digests,
+// the zip file is the subject for the artifacts uploaded by actions/upload-artifact@v4, like GitHub,
+// and the files are the subjects with the paths prefixed by the name of the artifact for the older versions.
+func getArtifactSubjects(ctx context.Context, runID int64, name string) ([]*api.ActionAttestationSubject, error) {
+	artifacts, err := db.Find[actions_model.ActionArtifact](ctx, actions_model.FindArtifactsOptions{
+		RunID:        runID,
+		ArtifactName: name,
+		Status:       int(actions_model.ArtifactStatusUploadConfirmed),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(artifacts) == 0 {
+		return nil, util.NewNotExistErrorf("artifact %q not found", name)
+	}
+
+	subjects := make([]*api.ActionAttestationSubject, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		digest, err := getArtifactDigest(artifact)
+		if err != nil {
+			return nil, err
+		}
+		name := artifact.ArtifactPath
+		if artifact.ContentEncoding != "application/zip" {
+			name = path.Join(artifact.ArtifactName, artifact.ArtifactPath)
+		}
+		subjects = append(subjects, &api.ActionAttestationSubject{
+			Name:   name,
+			Digest: "sha256:" + digest,
+		})
+	}
+	return subjects, nil
+}
+
+// getArtifactDigest returns the sha256 of the uploaded file, the files which are gzipped by the runner are decompressed
+func getArtifactDigest(artifact *actions_model.ActionArtifact) (string, error) {
+	f, err := storage.ActionsArtifacts.Open(artifact.StoragePath)
+	if err != nil {
+		return "", fmt.Errorf("open artifact: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if artifact.ContentEncoding == "gzip" {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return "", fmt.Errorf("open gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", fmt.Errorf("read artifact: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyAttestation verifies the signature of the attestation by the instance key and returns the signed statement,
+// the statement must be about the subject of the attestation and built by the repository of it.
+// The attestations signed by the keys which have been rotated can't be verified any longer.
+func VerifyAttestation(attestation *actions_model.ActionAttestation) (*InTotoStatement, error) {
+	signingKey, keyID, err := attestationSigningKey()
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := &api.ActionAttestationEnvelope{}
+	if err := json.Unmarshal([]byte(attestation.Envelope), envelope); err != nil {
+		return nil, fmt.Errorf("unmarshal envelope: %w", err)
+	}
+	if envelope.PayloadType != inTotoPayloadType {
+		return nil, fmt.Errorf("unsupported payload type %q", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	verified := false
+	for _, signature := range envelope.Signatures {
+		if signature.KeyID != keyID {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(signature.Sig)
+		if err != nil {
+			continue
+		}
+		if signingKey.SigningMethod().Verify(dssePAE(envelope.PayloadType, payload), sig, signingKey.VerifyKey()) == nil {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("no signature is verified by the instance key")
+	}
+
+	statement := &InTotoStatement{}
+	if err := json.Unmarshal(payload, statement); err != nil {
+		return nil, fmt.Errorf("unmarshal statement: %w", err)
+	}
+	if statement.Type != inTotoStatementType || statement.PredicateType != attestation.PredicateType || statement.Predicate == nil {
+		return nil, errors.New("the statement isn't a build provenance")
+	}
+	if statement.Predicate.BuildDefinition.InternalParameters.RepositoryID != strconv.FormatInt(attestation.RepoID, 10) {
+		return nil, errors.New("the statement isn't built by the repository")
+	}
+	algorithm, value, err := parseSubjectDigest(attestation.SubjectDigest)
+	if err != nil {
+		return nil, err
+	}
+	for _, subject := range statement.Subject {
+		if subject.Digest[algorithm] == value {
+			return statement, nil
+		}
+	}
+	return nil, errors.New("the statement isn't about the subject")
+}
+
+// VerifySubjectAttestations verifies the attestations of the subject built by the repository
+func VerifySubjectAttestations(ctx context.Context, repoID int64, digest string) (*api.ActionAttestationVerification, error) {
+	digest, err := NormalizeSubjectDigest(digest)
+	if err != nil {
+		return nil, err
+	}
+	attestations, err := db.Find[actions_model.ActionAttestation](ctx, actions_model.FindAttestationsOptions{
+		RepoID:        repoID,
+		SubjectDigest: digest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &api.ActionAttestationVerification{
+		SubjectDigest: digest,
+		Results:       make([]*api.ActionAttestationVerificationResult, 0, len(attestations)),
+	}
+	for _, attestation := range attestations {
+		result := &api.ActionAttestationVerificationResult{AttestationID: attestation.ID}
+		statement, err := VerifyAttestation(attestation)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Verified = true
+			ret.Verified = true
+			workflow := statement.Predicate.BuildDefinition.ExternalParameters.Workflow
+			result.Repository = workflow.Repository
+			result.Workflow = workflow.Path
+			result.Ref = workflow.Ref
+			for _, dependency := range statement.Predicate.BuildDefinition.ResolvedDependencies {
+				if sha, ok := dependency.Digest["gitCommit"]; ok {
+					result.CommitSHA = sha
+				}
+			}
+			internal := statement.Predicate.BuildDefinition.InternalParameters
+			result.RunID, _ = strconv.ParseInt(internal.RunID, 10, 64)
+			result.RunAttempt, _ = strconv.ParseInt(internal.RunAttempt, 10, 64)
+		}
+		ret.Results = append(ret.Results, result)
+	}
+	return ret, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSubjectDigest(t *testing.T) {
+	sha256Hex := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	digest, err := NormalizeSubjectDigest("sha256:" + sha256Hex)
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:"+sha256Hex, digest)
+
+	digest, err = NormalizeSubjectDigest("sha256:2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824")
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:"+sha256Hex, digest)
+
+	for _, invalid := range []string{
+		"",
+		sha256Hex,
+		"md5:5d41402abc4b2a76b9719d911017c592",
+		"sha256:" + sha256Hex[:63],
+		"sha256:" + sha256Hex[:63] + "x",
+		"sha512:" + sha256Hex,
+	} {
+		_, err := NormalizeSubjectDigest(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestDSSEPAE(t *testing.T) {
+	// the example in https://github.com/secure-systems-lab/dsse/blob/master/protocol.md
+	assert.Equal(t, "DSSEv1 29 http://example.com/HelloWorld 11 hello world", dssePAE("http://example.com/HelloWorld", []byte("hello world")))
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// GetCacheReadableRefs returns the refs whose caches could be restored by the run, in the order of priority.
+// Like GitHub, a run can restore the caches of its own ref, the base branch of the pull request and the default branch.
+func GetCacheReadableRefs(ctx context.Context, run *actions_model.ActionRun) ([]string, error) {
+	if err := run.LoadRepo(ctx); err != nil {
+		return nil, err
+	}
+	refs := []string{run.Ref}
+	if payload, err := run.GetPullRequestEventPayload(); err == nil && payload.PullRequest != nil && payload.PullRequest.Base != nil {
+		refs = append(refs, git.BranchPrefix+payload.PullRequest.Base.Ref)
+	}
+	refs = append(refs, git.BranchPrefix+run.Repo.DefaultBranch)
+
+	ret := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if !slices.Contains(ret, ref) {
+			ret = append(ret, ref)
+		}
+	}
+	return ret, nil
+}
+
+// incompleteCacheTimeout is the time to delete the caches which haven't been committed,
+// they could be left by the jobs which are cancelled or failed while saving the caches
+const incompleteCacheTimeout = 24 * time.Hour
+
+// CleanupCaches evicts the caches which haven't been used for a long time,
+// and the least recently used caches of the repositories whose caches exceed the size limit
+func CleanupCaches(ctx context.Context) error {
+	expired, err := db.Find[actions_model.ActionCache](ctx, actions_model.FindCachesOptions{
+		Complete:   optional.Some(true),
+		UsedBefore: timeutil.TimeStamp(time.Now().AddDate(0, 0, -int(setting.Actions.CacheRetentionDays)).Unix()),
+	})
+	if err != nil {
+		return fmt.Errorf("find expired caches: %w", err)
+	}
+	incomplete, err := db.Find[actions_model.ActionCache](ctx, actions_model.FindCachesOptions{
+		Complete:      optional.Some(false),
+		CreatedBefore: timeutil.TimeStamp(time.Now().Add(-incompleteCacheTimeout).Unix()),
+	})
+	if err != nil {
+		return fmt.Errorf("find incomplete caches: %w", err)
+	}
+	log.Info("Found %d expired caches and %d incomplete caches", len(expired), len(incomplete))
+	for _, cache := range append(expired, incomplete...) {
+		deleteCache(ctx, cache)
+	}
+
+	if setting.Actions.CacheMaxSize < 0 {
+		return nil
+	}
+	repoSizes, err := actions_model.GetReposOverCacheSize(ctx, setting.Actions.CacheMaxSize)
+	if err != nil {
+		return fmt.Errorf("get repositories over cache size: %w", err)
+	}
+	for repoID, size := range repoSizes {
+		size, err := evictCaches(ctx, repoID, size, setting.Actions.CacheMaxSize)
+		if err != nil {
+			return err
+		}
+		log.Info("Evicted caches of repo %d, total size: %d", repoID, size)
+	}
+	return nil
+}
+
+// MakeRoomForCache evicts the least recently used caches of the repository until a cache of the size fits into CACHE_MAX_SIZE,
+// it returns false if the cache is larger than the limit.
+func MakeRoomForCache(ctx context.Context, repoID, size int64) (bool, error) {
+	if setting.Actions.CacheMaxSize < 0 {
+		return true, nil
+	}
+	if size > setting.Actions.CacheMaxSize {
+		return false, nil
+	}
+	total, err := actions_model.GetRepoCacheSize(ctx, repoID)
+	if err != nil {
+		return false, err
+	}
+	if total+size <= setting.Actions.CacheMaxSize {
+		return true, nil
+	}
+	total, err = evictCaches(ctx, repoID, total, setting.Actions.CacheMaxSize-size)
+	if err != nil {
+		return false, err
+	}
+	return total+size <= setting.Actions.CacheMaxSize, nil
+}
+
+// evictCaches evicts the least recently used caches of the repository until their total size isn't greater than the limit,
+// and returns the total size after the eviction
+func evictCaches(ctx context.Context, repoID, size, limit int64) (int64, error) {
+	caches, err := db.Find[actions_model.ActionCache](ctx, actions_model.FindCachesOptions{
+		RepoID:   repoID,
+		Complete: optional.Some(true),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("find caches of repo %d: %w", repoID, err)
+	}
+	// the caches are sorted by the last used time, so the least recently used ones are evicted first
+	for _, cache := range caches {
+		if size <= limit {
+			break
+		}
+		if deleteCache(ctx, cache) {
+			size -= cache.Size
+		}
+	}
+	return size, nil
+}
+
+// CacheChunksDir returns the directory in the storage to save the uploading chunks of a cache
+func CacheChunksDir(cacheID int64) string {
+	return fmt.Sprintf("tmp%d", cacheID)
+}
+
+// deleteCache deletes a cache with its archive and uploaded chunks, and returns whether it has been deleted
+func deleteCache(ctx context.Context, cache *actions_model.ActionCache) bool {
+	if err := actions_model.DeleteCacheByID(ctx, cache.ID); err != nil {
+		log.Error("Cannot delete cache %d: %v", cache.ID, err)
+		return false
+	}
+	if cache.StoragePath != "" {
+		if err := storage.ActionsCache.Delete(cache.StoragePath); err != nil {
+			log.Error("Cannot delete cache archive %q: %v", cache.StoragePath, err)
+		}
+	}
+	if !cache.Complete {
+		if err := storage.ActionsCache.IterateObjects(CacheChunksDir(cache.ID), func(path string, _ storage.Object) error {
+			return storage.ActionsCache.Delete(path)
+		}); err != nil {
+			log.Error("Cannot delete chunks of cache %d: %v", cache.ID, err)
+		}
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	git_model "code.gitea.io/gitea/models/git"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/util"
+
+	runnerv1 "code.gitea.io/actions-proto-go/runner/v1"
+)
+
+// annotationCommandPattern matches the workflow commands which create annotations, like
+// "::error file=app.js,line=1,col=5,endColumn=7,title=Lint::Missing semicolon".
+// The commands are logged as they are by the runner, so the log line starts with the command.
+var annotationCommandPattern = regexp.MustCompile(`^::(error|warning|notice)(?: ([^:]*))?::(.*)$`)
+
+// unescapeCommandData unescapes the message of a workflow command
+func unescapeCommandData(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%25", "%").Replace(s)
+}
+
+// unescapeCommandProperty unescapes the property value of a workflow command
+func unescapeCommandProperty(s string) string {
+	return strings.NewReplacer("%0D", "\r", "%0A", "\n", "%3A", ":", "%2C", ",", "%25", "%").Replace(s)
+}
+
+// parseAnnotationCommand parses the annotation of a workflow command in a log line, it returns nil if it's not an annotation command
+func parseAnnotationCommand(line string) *git_model.CheckRunAnnotation {
+	m := annotationCommandPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if m == nil {
+		return nil
+	}
+
+	a := &git_model.CheckRunAnnotation{
+		Level:   git_model.CheckRunAnnotationLevel(m[1]),
+		Message: unescapeCommandData(m[3]),
+	}
+	if a.Level == "error" {
+		a.Level = git_model.CheckRunAnnotationLevelFailure
+	}
+	for _, kv := range strings.Split(m[2], ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(kv), "=")
+		if !ok {
+			continue
+		}
+		v = unescapeCommandProperty(v)
+		switch k {
+		case "file":
+			a.Path = strings.TrimPrefix(v, "./")
+		case "title":
+			a.Title = v
+		case "line":
+			a.StartLine, _ = strconv.ParseInt(v, 10, 64)
+		case "endLine":
+			a.EndLine, _ = strconv.ParseInt(v, 10, 64)
+		case "col":
+			a.StartColumn, _ = strconv.ParseInt(v, 10, 64)
+		case "endColumn":
+			a.EndColumn, _ = strconv.ParseInt(v, 10, 64)
+		}
+	}
+	if a.EndLine < a.StartLine {
+		a.EndLine = a.StartLine
+	}
+	return a
+}
+
+// checkRunExternalID returns the external id of the check run created for the annotations of a task,
+// every attempt of a job has its own check run, so the annotations of a re-run job don't mix with the old ones.
+func checkRunExternalID(taskID int64) string {
+	return fmt.Sprintf("actions-task-%d", taskID)
+}
+
+// toCheckRunStatus converts the status of a job to the status and the conclusion of a check run
+func toCheckRunStatus(status actions_model.Status) (git_model.CheckRunStatus, git_model.CheckRunConclusion) {
+	switch status {
+	case actions_model.StatusSuccess:
+		return git_model.CheckRunStatusCompleted, git_model.CheckRunConclusionSuccess
+	case actions_model.StatusFailure:
+		return git_model.CheckRunStatusCompleted, git_model.CheckRunConclusionFailure
+	case actions_model.StatusCancelled:
+		return git_model.CheckRunStatusCompleted, git_model.CheckRunConclusionCancelled
+	case actions_model.StatusSkipped:
+		return git_model.CheckRunStatusCompleted, git_model.CheckRunConclusionSkipped
+	case actions_model.StatusRunning:
+		return git_model.CheckRunStatusInProgress, ""
+	default:
+		return git_model.CheckRunStatusQueued, ""
+	}
+}
+
+// CreateAnnotationsFromLogRows converts the annotation commands in the log rows of a task into the annotations of a check run,
+// the check run is created for the task when the first annotation is found, and it has the same name as the commit status of the job.
+func CreateAnnotationsFromLogRows(ctx context.Context, task *actions_model.ActionTask, rows []*runnerv1.LogRow) error {
+	var annotations []*git_model.CheckRunAnnotation
+	for _, row := range rows {
+		if a := parseAnnotationCommand(row.Content); a != nil {
+			annotations = append(annotations, a)
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	if err := task.LoadJob(ctx); err != nil {
+		return fmt.Errorf("LoadJob: %w", err)
+	}
+	job := task.Job
+	if err := job.LoadAttributes(ctx); err != nil {
+		return fmt.Errorf("LoadAttributes: %w", err)
+	}
+	sha, name, err := getCommitStatusTarget(job)
+	if err != nil {
+		return err
+	} else if sha == "" {
+		return nil
+	}
+
+	cr, err := git_model.GetCheckRunByExternalID(ctx, task.RepoID, checkRunExternalID(task.ID))
+	if err == nil {
+		return git_model.UpdateCheckRun(ctx, cr, nil, annotations)
+	} else if !errors.Is(err, util.ErrNotExist) {
+		return err
+	}
+
+	index, err := getIndexOfJob(ctx, job)
+	if err != nil {
+		return fmt.Errorf("getIndexOfJob: %w", err)
+	}
+	cr = &git_model.CheckRun{
+		RepoID:     task.RepoID,
+		HeadSHA:    sha,
+		Name:       name,
+		ExternalID: checkRunExternalID(task.ID),
+		DetailsURL: fmt.Sprintf("%s/jobs/%d", job.Run.Link(), index),
+		CreatorID:  user_model.NewActionsUser().ID,
+	}
+	if err := cr.SetStatus(toCheckRunStatus(job.Status)); err != nil {
+		return err
+	}
+	return git_model.CreateCheckRun(ctx, cr, annotations)
+}
+
+// updateCheckRunOfJob updates the status of the check run of the current task of the job if it exists
+func updateCheckRunOfJob(ctx context.Context, job *actions_model.ActionRunJob) error {
+	if job.TaskID == 0 {
+		return nil
+	}
+	cr, err := git_model.GetCheckRunByExternalID(ctx, job.RepoID, checkRunExternalID(job.TaskID))
+	if errors.Is(err, util.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	status, conclusion := toCheckRunStatus(job.Status)
+	if cr.Status == status && cr.Conclusion == conclusion {
+		return nil
+	}
+	if err := cr.SetStatus(status, conclusion); err != nil {
+		return err
+	}
+	return git_model.UpdateCheckRun(ctx, cr, []string{"status", "conclusion", "started_unix", "completed_unix"}, nil)
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	git_model "code.gitea.io/gitea/models/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseAnnotationCommand(t *testing.T) {
+	kases := []struct {
+		line     string
+		expected *git_model.CheckRunAnnotation
+	}{
+		{
+			line: "::error file=./src/app.js,line=10,col=5,endColumn=7,title=Lint%3A semi::Missing semicolon",
+			expected: &git_model.CheckRunAnnotation{
+				Path:        "src/app.js",
+				StartLine:   10,
+				EndLine:     10,
+				StartColumn: 5,
+				EndColumn:   7,
+				Level:       git_model.CheckRunAnnotationLevelFailure,
+				Title:       "Lint: semi",
+				Message:     "Missing semicolon",
+			},
+		},
+		{
+			line: "::warning file=main.go,line=3,endLine=5::line1%0Aline2 100%25\n",
+			expected: &git_model.CheckRunAnnotation{
+				Path:      "main.go",
+				StartLine: 3,
+				EndLine:   5,
+				Level:     git_model.CheckRunAnnotationLevelWarning,
+				Message:   "line1\nline2 100%",
+			},
+		},
+		{
+			line: "::notice::Deployed",
+			expected: &git_model.CheckRunAnnotation{
+				Level:   git_model.CheckRunAnnotationLevelNotice,
+				Message: "Deployed",
+			},
+		},
+		{
+			line: "  ❗  ::error file=main.go,line=1::duplicated by the runner",
+		},
+		{
+			line: "::debug::not an annotation",
+		},
+		{
+			line: "echo ::error::hello",
+		},
+	}
+	for _, kase := range kases {
+		t.Run(kase.line, func(t *testing.T) {
+			assert.Equal(t, kase.expected, parseAnnotationCommand(kase.line))
+		})
+	}
+}
+
+func Test_toCheckRunStatus(t *testing.T) {
+	status, conclusion := toCheckRunStatus(actions_model.StatusFailure)
+	assert.Equal(t, git_model.CheckRunStatusCompleted, status)
+	assert.Equal(t, git_model.CheckRunConclusionFailure, conclusion)
+
+	status, conclusion = toCheckRunStatus(actions_model.StatusRunning)
+	assert.Equal(t, git_model.CheckRunStatusInProgress, status)
+	assert.Empty(t, conclusion)
+
+	status, conclusion = toCheckRunStatus(actions_model.StatusBlocked)
+	assert.Equal(t, git_model.CheckRunStatusQueued, status)
+	assert.Empty(t, conclusion)
+}
@@ -28,6 +28,11 @@ func Cleanup(ctx context.Context) error {
 		return fmt.Errorf("cleanup logs: %w", err)
 	}
 
+	// clean up the ephemeral runners which will never run a task
+	if err := CleanupEphemeralRunners(ctx); err != nil {
+		return fmt.Errorf("cleanup ephemeral runners: %w", err)
+	}
+
 	return nil
 }
 
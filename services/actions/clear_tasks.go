@@ -68,6 +68,12 @@ func stopTasks(ctx context.Context, opts actions_model.FindTaskOptions) error {
 	}
 
 	CreateCommitStatus(ctx, jobs...)
+	for _, job := range jobs {
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+		if err := EmitJobsIfReady(job.RunID); err != nil {
+			log.Warn("Cannot emit jobs of run %v: %v", job.RunID, err)
+		}
+	}
 
 	return nil
 }
@@ -95,6 +101,10 @@ func CancelAbandonedJobs(ctx context.Context) error {
 			// go on
 		}
 		CreateCommitStatus(ctx, job)
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+		if err := EmitJobsIfReady(job.RunID); err != nil {
+			log.Warn("emit jobs of run %v: %v", job.RunID, err)
+		}
 	}
 
 	return nil
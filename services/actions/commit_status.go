@@ -29,6 +29,9 @@ func CreateCommitStatus(ctx context.Context, jobs ...*actions_model.ActionRunJob
 		if err := createCommitStatus(ctx, job); err != nil {
 			log.Error("Failed to create commit status for job %d: %v", job.ID, err)
 		}
+		if err := updateCheckRunOfJob(ctx, job); err != nil {
+			log.Error("Failed to update check run for job %d: %v", job.ID, err)
+		}
 	}
 }
 
@@ -37,53 +40,15 @@ func createCommitStatus(ctx context.Context, job *actions_model.ActionRunJob) er
 		return fmt.Errorf("load run: %w", err)
 	}
 
-	run := job.Run
-
-	var (
-		sha   string
-		event string
-	)
-	switch run.Event {
-	case webhook_module.HookEventPush:
-		event = "push"
-		payload, err := run.GetPushEventPayload()
-		if err != nil {
-			return fmt.Errorf("GetPushEventPayload: %w", err)
-		}
-		if payload.HeadCommit == nil {
-			return fmt.Errorf("head commit is missing in event payload")
-		}
-		sha = payload.HeadCommit.ID
-	case webhook_module.HookEventPullRequest, webhook_module.HookEventPullRequestSync:
-		if run.TriggerEvent == actions_module.GithubEventPullRequestTarget {
-			event = "pull_request_target"
-		} else {
-			event = "pull_request"
-		}
-		payload, err := run.GetPullRequestEventPayload()
-		if err != nil {
-			return fmt.Errorf("GetPullRequestEventPayload: %w", err)
-		}
-		if payload.PullRequest == nil {
-			return fmt.Errorf("pull request is missing in event payload")
-		} else if payload.PullRequest.Head == nil {
-			return fmt.Errorf("head of pull request is missing in event payload")
-		}
-		sha = payload.PullRequest.Head.Sha
-	case webhook_module.HookEventRelease:
-		event = string(run.Event)
-		sha = run.CommitSHA
-	default:
+	sha, ctxname, err := getCommitStatusTarget(job)
+	if err != nil {
+		return err
+	} else if sha == "" {
 		return nil
 	}
 
+	run := job.Run
 	repo := run.Repo
-	// TODO: store workflow name as a field in ActionRun to avoid parsing
-	runName := path.Base(run.WorkflowID)
-	if wfs, err := jobparser.Parse(job.WorkflowPayload); err == nil && len(wfs) > 0 {
-		runName = wfs[0].Name
-	}
-	ctxname := fmt.Sprintf("%s / %s (%s)", runName, job.Name, event)
 	state := toCommitStatus(job.Status)
 	if statuses, _, err := git_model.GetLatestCommitStatus(ctx, repo.ID, sha, db.ListOptionsAll); err == nil {
 		for _, v := range statuses {
@@ -142,6 +107,55 @@ func createCommitStatus(ctx context.Context, job *actions_model.ActionRunJob) er
 	return nil
 }
 
+// getCommitStatusTarget returns the sha and the context of the commit status of the job,
+// the sha is empty if the event of the run doesn't create commit statuses.
+// The run of the job should have been loaded.
+func getCommitStatusTarget(job *actions_model.ActionRunJob) (sha, ctxname string, err error) {
+	run := job.Run
+
+	var event string
+	switch run.Event {
+	case webhook_module.HookEventPush:
+		event = "push"
+		payload, err := run.GetPushEventPayload()
+		if err != nil {
+			return "", "", fmt.Errorf("GetPushEventPayload: %w", err)
+		}
+		if payload.HeadCommit == nil {
+			return "", "", fmt.Errorf("head commit is missing in event payload")
+		}
+		sha = payload.HeadCommit.ID
+	case webhook_module.HookEventPullRequest, webhook_module.HookEventPullRequestSync:
+		if run.TriggerEvent == actions_module.GithubEventPullRequestTarget {
+			event = "pull_request_target"
+		} else {
+			event = "pull_request"
+		}
+		payload, err := run.GetPullRequestEventPayload()
+		if err != nil {
+			return "", "", fmt.Errorf("GetPullRequestEventPayload: %w", err)
+		}
+		if payload.PullRequest == nil {
+			return "", "", fmt.Errorf("pull request is missing in event payload")
+		} else if payload.PullRequest.Head == nil {
+			return "", "", fmt.Errorf("head of pull request is missing in event payload")
+		}
+		sha = payload.PullRequest.Head.Sha
+	case webhook_module.HookEventRelease:
+		event = string(run.Event)
+		sha = run.CommitSHA
+	default:
+		return "", "", nil
+	}
+
+	// TODO: store workflow name as a field in ActionRun to avoid parsing
+	runName := path.Base(run.WorkflowID)
+	if wfs, err := jobparser.Parse(job.WorkflowPayload); err == nil && len(wfs) > 0 {
+		runName = wfs[0].Name
+	}
+	return sha, fmt.Sprintf("%s / %s (%s)", runName, job.Name, event), nil
+}
+
 func toCommitStatus(status actions_model.Status) api.CommitStatusState {
 	switch status {
 	case actions_model.StatusSuccess, actions_model.StatusSkipped:
@@ -0,0 +1,314 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+
+	"gopkg.in/yaml.v3"
+)
+
+// concurrencyConfig is the evaluated `concurrency` configuration of a workflow or a job,
+// see https://docs.github.com/en/actions/writing-workflows/workflow-syntax-for-github-actions#concurrency
+type concurrencyConfig struct {
+	Group            string `yaml:"group"`
+	CancelInProgress bool   `yaml:"cancel-in-progress"`
+}
+
+// UnmarshalYAML supports the short syntax which only specifies the group
+func (c *concurrencyConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&c.Group)
+	}
+	type plain concurrencyConfig
+	return node.Decode((*plain)(c))
+}
+
+// parseRawConcurrency returns the raw `concurrency` configurations of the workflow and its jobs,
+// they are kept unevaluated since the expressions could only be evaluated when the run or the job is created.
+func parseRawConcurrency(content []byte) (string, map[string]string, error) {
+	var workflow struct {
+		Concurrency yaml.Node `yaml:"concurrency"`
+		Jobs        map[string]struct {
+			Concurrency yaml.Node `yaml:"concurrency"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return "", nil, err
+	}
+
+	workflowRaw, err := encodeRawNode(&workflow.Concurrency, "concurrency")
+	if err != nil {
+		return "", nil, err
+	}
+	jobRaws := make(map[string]string, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		raw, err := encodeRawNode(&job.Concurrency, "concurrency")
+		if err != nil {
+			return "", nil, err
+		}
+		if raw != "" {
+			jobRaws[id] = raw
+		}
+	}
+	return workflowRaw, jobRaws, nil
+}
+
+// encodeRawNode encodes the raw configuration of the key to be saved and evaluated later,
+// the configuration could be a string or a mapping
+func encodeRawNode(node *yaml.Node, key string) (string, error) {
+	if node.IsZero() {
+		return "", nil
+	}
+	if node.Kind != yaml.ScalarNode && node.Kind != yaml.MappingNode {
+		return "", fmt.Errorf("invalid %s at line %d", key, node.Line)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// evaluateConcurrency evaluates the raw concurrency of a run, or of a job if job is not nil
+func evaluateConcurrency(ctx context.Context, run *actions_model.ActionRun, job *actions_model.ActionRunJob, raw string) (*concurrencyConfig, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &node); err != nil {
+		return nil, fmt.Errorf("unmarshal raw concurrency: %w", err)
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = *node.Content[0]
+	}
+
+	// the expressions of workflow level concurrency can only use github, inputs and vars contexts
+	interpreter, err := newInterpreter(ctx, run, job)
+	if err != nil {
+		return nil, err
+	}
+	if err := evaluateYamlNode(interpreter, &node); err != nil {
+		return nil, fmt.Errorf("evaluate concurrency: %w", err)
+	}
+
+	cfg := &concurrencyConfig{}
+	if err := node.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("decode concurrency: %w", err)
+	}
+	return cfg, nil
+}
+
+// checkRunConcurrency evaluates the workflow level concurrency of a newly inserted run,
+// cancels the runs in the same group which should be replaced, and returns whether the run should be blocked.
+// The cancelled jobs are returned to be notified by notifyCancelledJobs after the transaction is committed.
+func checkRunConcurrency(ctx context.Context, run *actions_model.ActionRun) (bool, []*actions_model.ActionRunJob, error) {
+	if run.RawConcurrency == "" {
+		return false, nil, nil
+	}
+	cfg, err := evaluateConcurrency(ctx, run, nil, run.RawConcurrency)
+	if err != nil {
+		return false, nil, err
+	}
+	if cfg.Group == "" {
+		return false, nil, nil
+	}
+	run.ConcurrencyGroup = cfg.Group
+	run.ConcurrencyCancel = cfg.CancelInProgress
+	if err := actions_model.UpdateRun(ctx, run, "concurrency_group", "concurrency_cancel"); err != nil {
+		return false, nil, err
+	}
+
+	// the pending runs are always replaced, and the running ones only if cancel-in-progress is set
+	statuses := []actions_model.Status{actions_model.StatusBlocked}
+	if cfg.CancelInProgress {
+		statuses = append(statuses, actions_model.StatusWaiting, actions_model.StatusRunning)
+	}
+	runs, err := db.Find[actions_model.ActionRun](ctx, actions_model.FindRunOptions{
+		RepoID:           run.RepoID,
+		ConcurrencyGroup: cfg.Group,
+		Status:           statuses,
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("FindRuns: %w", err)
+	}
+	var cancelledJobs []*actions_model.ActionRunJob
+	for _, r := range runs {
+		if r.ID == run.ID {
+			continue
+		}
+		jobs, err := actions_model.GetRunJobsByRunID(ctx, r.ID)
+		if err != nil {
+			return false, nil, fmt.Errorf("GetRunJobsByRunID: %w", err)
+		}
+		cancelled, err := cancelJobs(ctx, jobs)
+		if err != nil {
+			return false, nil, err
+		}
+		CreateCommitStatus(ctx, jobs...)
+		cancelledJobs = append(cancelledJobs, cancelled...)
+	}
+	if cfg.CancelInProgress {
+		return false, cancelledJobs, nil
+	}
+	occupied, err := isRunConcurrencyOccupied(ctx, run)
+	return occupied, cancelledJobs, err
+}
+
+// isRunConcurrencyOccupied returns whether another run in the concurrency group of the run is in progress
+func isRunConcurrencyOccupied(ctx context.Context, run *actions_model.ActionRun) (bool, error) {
+	if run.ConcurrencyGroup == "" || run.ConcurrencyCancel {
+		return false, nil
+	}
+	runs, err := db.Find[actions_model.ActionRun](ctx, actions_model.FindRunOptions{
+		RepoID:           run.RepoID,
+		ConcurrencyGroup: run.ConcurrencyGroup,
+		Status:           []actions_model.Status{actions_model.StatusWaiting, actions_model.StatusRunning},
+	})
+	if err != nil {
+		return false, fmt.Errorf("FindRuns: %w", err)
+	}
+	for _, r := range runs {
+		if r.ID != run.ID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkJobConcurrency is called when a job is ready to run, it returns whether the job should be kept blocked
+// since another run or job in the same concurrency group is in progress.
+// The job level concurrency is evaluated only once, and the jobs in the same group which should be replaced are cancelled at that time.
+func checkJobConcurrency(ctx context.Context, job *actions_model.ActionRunJob) (bool, []*actions_model.ActionRunJob, error) {
+	if err := job.LoadRun(ctx); err != nil {
+		return false, nil, err
+	}
+	if occupied, err := isRunConcurrencyOccupied(ctx, job.Run); err != nil || occupied {
+		return occupied, nil, err
+	}
+
+	if job.RawConcurrency == "" {
+		return false, nil, nil
+	}
+	var cancelledJobs []*actions_model.ActionRunJob
+	if !job.IsConcurrencyEvaluated {
+		cfg, err := evaluateConcurrency(ctx, job.Run, job, job.RawConcurrency)
+		if err != nil {
+			return false, nil, err
+		}
+		job.ConcurrencyGroup = cfg.Group
+		job.ConcurrencyCancel = cfg.CancelInProgress
+		job.IsConcurrencyEvaluated = true
+		if _, err := actions_model.UpdateRunJob(ctx, job, nil, "concurrency_group", "concurrency_cancel", "is_concurrency_evaluated"); err != nil {
+			return false, nil, err
+		}
+
+		if cfg.Group != "" {
+			statuses := []actions_model.Status{actions_model.StatusBlocked}
+			if cfg.CancelInProgress {
+				statuses = append(statuses, actions_model.StatusWaiting, actions_model.StatusRunning)
+			}
+			jobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{
+				RepoID:           job.RepoID,
+				ConcurrencyGroup: cfg.Group,
+				Statuses:         statuses,
+			})
+			if err != nil {
+				return false, nil, fmt.Errorf("FindRunJobs: %w", err)
+			}
+			toCancel := make([]*actions_model.ActionRunJob, 0, len(jobs))
+			for _, j := range jobs {
+				if j.ID != job.ID {
+					toCancel = append(toCancel, j)
+				}
+			}
+			cancelled, err := cancelJobs(ctx, toCancel)
+			if err != nil {
+				return false, nil, err
+			}
+			CreateCommitStatus(ctx, toCancel...)
+			cancelledJobs = cancelled
+		}
+	}
+
+	if job.ConcurrencyGroup == "" || job.ConcurrencyCancel {
+		return false, cancelledJobs, nil
+	}
+	jobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{
+		RepoID:           job.RepoID,
+		ConcurrencyGroup: job.ConcurrencyGroup,
+		Statuses:         []actions_model.Status{actions_model.StatusWaiting, actions_model.StatusRunning},
+	})
+	if err != nil {
+		return false, nil, fmt.Errorf("FindRunJobs: %w", err)
+	}
+	for _, j := range jobs {
+		if j.ID != job.ID {
+			return true, cancelledJobs, nil
+		}
+	}
+	return false, cancelledJobs, nil
+}
+
+// emitBlockedConcurrentRuns emits the jobs of the runs which could be blocked by the concurrency groups
+// of the given run and its jobs, it should be called when the jobs of the run have been updated.
+func emitBlockedConcurrentRuns(ctx context.Context, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob) error {
+	runIDs := make(map[int64]struct{})
+	allDone := true
+	for _, job := range jobs {
+		if !job.Status.IsDone() {
+			allDone = false
+			continue
+		}
+		if job.ConcurrencyGroup == "" {
+			continue
+		}
+		blockedJobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{
+			RepoID:           job.RepoID,
+			ConcurrencyGroup: job.ConcurrencyGroup,
+			Statuses:         []actions_model.Status{actions_model.StatusBlocked},
+		})
+		if err != nil {
+			return fmt.Errorf("FindRunJobs: %w", err)
+		}
+		for _, j := range blockedJobs {
+			runIDs[j.RunID] = struct{}{}
+		}
+	}
+	if allDone && run.ConcurrencyGroup != "" {
+		blockedRuns, err := db.Find[actions_model.ActionRun](ctx, actions_model.FindRunOptions{
+			RepoID:           run.RepoID,
+			ConcurrencyGroup: run.ConcurrencyGroup,
+			Status:           []actions_model.Status{actions_model.StatusBlocked},
+		})
+		if err != nil {
+			return fmt.Errorf("FindRuns: %w", err)
+		}
+		for _, r := range blockedRuns {
+			runIDs[r.ID] = struct{}{}
+		}
+	}
+	delete(runIDs, run.ID)
+
+	for id := range runIDs {
+		blockedRun, err := actions_model.GetRunByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if blockedRun.NeedApproval {
+			// the jobs of the run are blocked until it's approved, ApproveRun checks their concurrency then
+			continue
+		}
+		if err := EmitJobsIfReady(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
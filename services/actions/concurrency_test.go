@@ -0,0 +1,74 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_parseRawConcurrency(t *testing.T) {
+	content := []byte(`
+name: test
+on: push
+concurrency:
+  group: ${{ github.workflow }}-${{ github.ref }}
+  cancel-in-progress: true
+jobs:
+  job1:
+    runs-on: ubuntu-latest
+    concurrency: deploy-${{ matrix.env }}
+    strategy:
+      matrix:
+        env: [staging, production]
+    steps:
+      - run: echo job1
+  job2:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo job2
+`)
+	workflowRaw, jobRaws, err := parseRawConcurrency(content)
+	require.NoError(t, err)
+	assert.Equal(t, "group: ${{ github.workflow }}-${{ github.ref }}\ncancel-in-progress: true\n", workflowRaw)
+	assert.Equal(t, map[string]string{"job1": "deploy-${{ matrix.env }}\n"}, jobRaws)
+
+	workflowRaw, jobRaws, err = parseRawConcurrency([]byte("on: push\njobs:\n  job1:\n    runs-on: ubuntu-latest\n"))
+	require.NoError(t, err)
+	assert.Empty(t, workflowRaw)
+	assert.Empty(t, jobRaws)
+
+	_, _, err = parseRawConcurrency([]byte("on: push\nconcurrency: [a, b]\n"))
+	assert.Error(t, err)
+}
+
+func Test_concurrencyConfig_UnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want concurrencyConfig
+	}{
+		{
+			raw:  "ci-main",
+			want: concurrencyConfig{Group: "ci-main"},
+		},
+		{
+			raw:  "group: ci-main",
+			want: concurrencyConfig{Group: "ci-main"},
+		},
+		{
+			raw:  "group: ci-main\ncancel-in-progress: true",
+			want: concurrencyConfig{Group: "ci-main", CancelInProgress: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			var got concurrencyConfig
+			require.NoError(t, yaml.Unmarshal([]byte(tt.raw), &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
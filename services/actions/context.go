@@ -0,0 +1,69 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/nektos/act/pkg/model"
+)
+
+// generateGiteaContext generates the github context of a run to evaluate expressions on the server side,
+// job could be nil if the expression is evaluated at the workflow level.
+// The values are the same as the ones sent to the runner, except for the token which is never available here.
+func generateGiteaContext(ctx context.Context, run *actions_model.ActionRun, job *actions_model.ActionRunJob) (*model.GithubContext, error) {
+	if err := run.LoadAttributes(ctx); err != nil {
+		return nil, fmt.Errorf("LoadAttributes: %w", err)
+	}
+
+	event := map[string]any{}
+	_ = json.Unmarshal([]byte(run.EventPayload), &event)
+
+	baseRef := ""
+	headRef := ""
+	ref := run.Ref
+	sha := run.CommitSHA
+	if pullPayload, err := run.GetPullRequestEventPayload(); err == nil && pullPayload.PullRequest != nil && pullPayload.PullRequest.Base != nil && pullPayload.PullRequest.Head != nil {
+		baseRef = pullPayload.PullRequest.Base.Ref
+		headRef = pullPayload.PullRequest.Head.Ref
+
+		// if the TriggerEvent is pull_request_target, ref and sha need to be set according to the base of pull request
+		if run.TriggerEvent == actions_module.GithubEventPullRequestTarget {
+			ref = git.BranchPrefix + pullPayload.PullRequest.Base.Name
+			sha = pullPayload.PullRequest.Base.Sha
+		}
+	}
+
+	refName := git.RefName(ref)
+
+	gitCtx := &model.GithubContext{
+		Event:           event,
+		Workflow:        run.WorkflowID,
+		RunID:           fmt.Sprint(run.ID),
+		RunNumber:       fmt.Sprint(run.Index),
+		Actor:           run.TriggerUser.Name,
+		Repository:      run.Repo.OwnerName + "/" + run.Repo.Name,
+		EventName:       run.TriggerEvent,
+		Sha:             sha,
+		Ref:             ref,
+		RefName:         refName.ShortName(),
+		RefType:         refName.RefType(),
+		HeadRef:         headRef,
+		BaseRef:         baseRef,
+		RepositoryOwner: run.Repo.OwnerName,
+		ServerURL:       setting.AppURL,
+		APIURL:          setting.AppURL + "api/v1",
+	}
+	if job != nil {
+		gitCtx.Job = job.JobID
+	}
+	return gitCtx, nil
+}
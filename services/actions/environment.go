@@ -0,0 +1,419 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v3"
+	"xorm.io/builder"
+)
+
+// environmentConfig is the evaluated `environment` configuration of a job,
+// see https://docs.github.com/en/actions/writing-workflows/workflow-syntax-for-github-actions#jobsjob_idenvironment
+type environmentConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// UnmarshalYAML supports the short syntax which only specifies the name
+func (c *environmentConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&c.Name)
+	}
+	type plain environmentConfig
+	return node.Decode((*plain)(c))
+}
+
+// parseRawEnvironments returns the raw `environment` configurations of the jobs,
+// they are kept unevaluated since the expressions could only be evaluated when the jobs are ready to run.
+func parseRawEnvironments(content []byte) (map[string]string, error) {
+	var workflow struct {
+		Jobs map[string]struct {
+			Environment yaml.Node `yaml:"environment"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return nil, err
+	}
+
+	ret := make(map[string]string, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		raw, err := encodeRawNode(&job.Environment, "environment")
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", id, err)
+		}
+		if raw != "" {
+			ret[id] = raw
+		}
+	}
+	return ret, nil
+}
+
+func evaluateEnvironment(ctx context.Context, job *actions_model.ActionRunJob) (*environmentConfig, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(job.RawEnvironment), &node); err != nil {
+		return nil, fmt.Errorf("unmarshal raw environment: %w", err)
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) == 1 {
+		node = *node.Content[0]
+	}
+	interpreter, err := newInterpreter(ctx, job.Run, job)
+	if err != nil {
+		return nil, err
+	}
+	if err := evaluateYamlNode(interpreter, &node); err != nil {
+		return nil, fmt.Errorf("evaluate environment: %w", err)
+	}
+	cfg := &environmentConfig{}
+	if err := node.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("decode environment: %w", err)
+	}
+	return cfg, nil
+}
+
+// checkJobEnvironment is called when a job targeting an environment is ready to run,
+// the deployment of the job is created when the job is checked for the first time.
+// It returns the status which the job should be updated to:
+// StatusWaiting if the job could be picked by runners, StatusBlocked if the deployment is waiting for the approval or the wait timer,
+// StatusFailure if the deployment is rejected or not allowed, or StatusSkipped if the job is skipped by its condition.
+func checkJobEnvironment(ctx context.Context, job *actions_model.ActionRunJob) (actions_model.Status, error) {
+	if job.RawEnvironment == "" {
+		return actions_model.StatusWaiting, nil
+	}
+	if err := job.LoadRun(ctx); err != nil {
+		return actions_model.StatusUnknown, err
+	}
+
+	var deployment *actions_model.ActionDeployment
+	if job.DeploymentID > 0 {
+		var err error
+		deployment, err = actions_model.GetDeploymentByID(ctx, job.DeploymentID)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return actions_model.StatusUnknown, err
+		}
+		// the deployment could have been deleted with its environment, create it again
+	}
+	if deployment == nil {
+		return createJobDeployment(ctx, job)
+	}
+
+	switch deployment.State {
+	case actions_model.DeploymentStateWaiting:
+		if deployment.NeedApproval || deployment.WaitUntil > timeutil.TimeStampNow() {
+			return actions_model.StatusBlocked, nil
+		}
+		if _, err := actions_model.UpdateDeploymentState(ctx, deployment, actions_model.DeploymentStateQueued, 0, "The deployment is queued"); err != nil {
+			return actions_model.StatusUnknown, err
+		}
+		return actions_model.StatusWaiting, nil
+	case actions_model.DeploymentStateFailure:
+		return actions_model.StatusFailure, nil
+	default:
+		return actions_model.StatusWaiting, nil
+	}
+}
+
+func createJobDeployment(ctx context.Context, job *actions_model.ActionRunJob) (actions_model.Status, error) {
+	// the condition of the job is evaluated here, since the deployment shouldn't be created if the job would be skipped
+	wfJob, err := readWorkflowJob(job)
+	if err != nil {
+		return actions_model.StatusUnknown, err
+	}
+	interpreter, err := newInterpreter(ctx, job.Run, job)
+	if err != nil {
+		return actions_model.StatusUnknown, err
+	}
+	if ok, err := evaluateCondition(interpreter, wfJob.If.Value); err != nil {
+		// the condition will be evaluated by the runner again, so it's not treated as an error here
+		log.Warn("Cannot evaluate the condition of job %d: %v", job.ID, err)
+	} else if !ok {
+		return actions_model.StatusSkipped, nil
+	}
+
+	cfg, err := evaluateEnvironment(ctx, job)
+	if err != nil {
+		return actions_model.StatusUnknown, fmt.Errorf("job %q: %w", job.JobID, err)
+	}
+	if cfg.Name == "" {
+		return actions_model.StatusWaiting, nil
+	}
+	env, err := actions_model.GetOrCreateEnvironment(ctx, job.RepoID, cfg.Name)
+	if err != nil {
+		return actions_model.StatusUnknown, fmt.Errorf("job %q: environment %q: %w", job.JobID, cfg.Name, err)
+	}
+
+	deployment := &actions_model.ActionDeployment{
+		RepoID:        job.RepoID,
+		EnvironmentID: env.ID,
+		RunID:         job.RunID,
+		JobID:         job.ID,
+		Ref:           job.Run.Ref,
+		CommitSHA:     job.Run.CommitSHA,
+		CreatorID:     job.Run.TriggerUserID,
+	}
+	status, description := actions_model.StatusWaiting, "The deployment is queued"
+	switch {
+	case !env.IsRefAllowed(job.Run.Ref):
+		deployment.State = actions_model.DeploymentStateFailure
+		status, description = actions_model.StatusFailure, fmt.Sprintf("%s is not allowed to deploy to %s due to environment protection rules", job.Run.Ref, env.Name)
+	case env.NeedApproval() || env.WaitTimer > 0:
+		deployment.State = actions_model.DeploymentStateWaiting
+		deployment.NeedApproval = env.NeedApproval()
+		if env.WaitTimer > 0 {
+			deployment.WaitUntil = timeutil.TimeStampNow().AddDuration(time.Duration(env.WaitTimer) * time.Minute)
+		}
+		status, description = actions_model.StatusBlocked, "The deployment is waiting for the protection rules of the environment"
+	default:
+		deployment.State = actions_model.DeploymentStateQueued
+	}
+	if err := actions_model.CreateDeployment(ctx, deployment, description); err != nil {
+		return actions_model.StatusUnknown, err
+	}
+	job.DeploymentID = deployment.ID
+	if _, err := actions_model.UpdateRunJob(ctx, job, nil, "deployment_id"); err != nil {
+		return actions_model.StatusUnknown, err
+	}
+	return status, nil
+}
+
+// syncDeploymentState updates the state of the deployment of a job when the status of the job is changed,
+// the previous successful deployments to the environment become inactive when the job succeeds.
+func syncDeploymentState(ctx context.Context, job *actions_model.ActionRunJob) error {
+	if job.DeploymentID == 0 {
+		return nil
+	}
+	var state actions_model.DeploymentState
+	var description string
+	switch job.Status {
+	case actions_model.StatusRunning:
+		state, description = actions_model.DeploymentStateInProgress, "The deployment is in progress"
+	case actions_model.StatusSuccess:
+		state, description = actions_model.DeploymentStateSuccess, "The deployment succeeded"
+	case actions_model.StatusFailure:
+		state, description = actions_model.DeploymentStateFailure, "The deployment failed"
+	case actions_model.StatusCancelled:
+		state, description = actions_model.DeploymentStateError, "The deployment was cancelled"
+	case actions_model.StatusSkipped:
+		state, description = actions_model.DeploymentStateInactive, "The deployment was skipped"
+	default:
+		return nil
+	}
+
+	deployment, err := actions_model.GetDeploymentByID(ctx, job.DeploymentID)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if deployment.State == state || deployment.State.IsDone() && !state.IsDone() {
+		return nil
+	}
+	if updated, err := actions_model.UpdateDeploymentState(ctx, deployment, state, 0, description); err != nil || !updated {
+		return err
+	}
+	if state != actions_model.DeploymentStateSuccess {
+		return nil
+	}
+
+	previous, err := db.Find[actions_model.ActionDeployment](ctx, actions_model.FindDeploymentsOptions{
+		EnvironmentID: deployment.EnvironmentID,
+		States:        []actions_model.DeploymentState{actions_model.DeploymentStateSuccess},
+		ExcludeID:     deployment.ID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, d := range previous {
+		if _, err := actions_model.UpdateDeploymentState(ctx, d, actions_model.DeploymentStateInactive, 0, fmt.Sprintf("The deployment was replaced by deployment #%d", deployment.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReviewPendingDeployments approves or rejects the deployments of a run which are waiting for the approval,
+// the doer must be a reviewer of the environments of the deployments.
+func ReviewPendingDeployments(ctx context.Context, run *actions_model.ActionRun, environmentIDs []int64, doer *user_model.User, approved bool, comment string) ([]*actions_model.ActionDeployment, error) {
+	deployments, err := db.Find[actions_model.ActionDeployment](ctx, actions_model.FindDeploymentsOptions{
+		RunID:        run.ID,
+		States:       []actions_model.DeploymentState{actions_model.DeploymentStateWaiting},
+		NeedApproval: optional.Some(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := container.SetOf(environmentIDs...)
+	reviewed := make([]*actions_model.ActionDeployment, 0, len(deployments))
+	for _, d := range deployments {
+		if ids.Contains(d.EnvironmentID) {
+			reviewed = append(reviewed, d)
+		}
+	}
+	if len(reviewed) == 0 {
+		return nil, util.NewNotExistErrorf("no pending deployments of the environments")
+	}
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		for _, d := range reviewed {
+			if err := d.LoadAttributes(ctx); err != nil {
+				return err
+			}
+			if !d.Environment.IsReviewer(doer.ID) {
+				return util.NewPermissionDeniedErrorf("user %s isn't a reviewer of environment %s", doer.Name, d.Environment.Name)
+			}
+			d.ReviewerID = doer.ID
+			description := fmt.Sprintf("Approved by %s", doer.Name)
+			if !approved {
+				description = fmt.Sprintf("Rejected by %s", doer.Name)
+			}
+			if comment != "" {
+				description += ": " + comment
+			}
+			if !approved {
+				if _, err := actions_model.UpdateDeploymentState(ctx, d, actions_model.DeploymentStateFailure, doer.ID, description, "reviewer_id"); err != nil {
+					return err
+				}
+				continue
+			}
+			d.NeedApproval = false
+			if err := actions_model.AddDeploymentStatus(ctx, d, doer.ID, description, "need_approval", "reviewer_id"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// the jobs will be emitted or failed according to the deployments
+	return reviewed, EmitJobsIfReady(run.ID)
+}
+
+// EmitWaitTimerExpiredDeployments emits the runs of the deployments whose wait timers have expired
+func EmitWaitTimerExpiredDeployments(ctx context.Context) error {
+	deployments, err := db.Find[actions_model.ActionDeployment](ctx, actions_model.FindDeploymentsOptions{
+		States:          []actions_model.DeploymentState{actions_model.DeploymentStateWaiting},
+		NeedApproval:    optional.Some(false),
+		WaitUntilBefore: timeutil.TimeStampNow(),
+	})
+	if err != nil {
+		return fmt.Errorf("find deployments: %w", err)
+	}
+	runIDs := make(container.Set[int64])
+	for _, d := range deployments {
+		runIDs.Add(d.RunID)
+	}
+	for runID := range runIDs {
+		if err := EmitJobsIfReady(runID); err != nil {
+			log.Error("EmitJobsIfReady: %v", err)
+		}
+	}
+	return nil
+}
+
+// DeleteEnvironment deletes an environment with its deployments, secrets and variables
+func DeleteEnvironment(ctx context.Context, env *actions_model.ActionEnvironment) error {
+	return db.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := db.GetEngine(ctx).Where(builder.Eq{"repo_id": env.RepoID, "environment_id": env.ID}).Delete(&secret_model.Secret{}); err != nil {
+			return err
+		}
+		return actions_model.DeleteEnvironment(ctx, env)
+	})
+}
+
+// maxEnvironmentWaitTimer is the max minutes of the wait timer of an environment, it's 30 days like GitHub
+const maxEnvironmentWaitTimer = 43200
+
+// EnvironmentOptions represents the protection rules to create or update an environment, the unset options aren't changed
+type EnvironmentOptions struct {
+	WaitTimer      optional.Option[int64]
+	ReviewerIDs    optional.Option[[]int64]
+	BranchPatterns optional.Option[[]string]
+}
+
+// CreateOrUpdateEnvironment creates an environment of the repository or updates its protection rules,
+// the reviewers must be able to write the actions of the repository. It returns whether the environment is created.
+func CreateOrUpdateEnvironment(ctx context.Context, repo *repo_model.Repository, name string, opts EnvironmentOptions) (*actions_model.ActionEnvironment, bool, error) {
+	if err := actions_model.ValidateEnvironmentName(name); err != nil {
+		return nil, false, err
+	}
+	if waitTimer := opts.WaitTimer.Value(); waitTimer < 0 || waitTimer > maxEnvironmentWaitTimer {
+		return nil, false, util.NewInvalidArgumentErrorf("wait timer should be between 0 and %d minutes", maxEnvironmentWaitTimer)
+	}
+	for _, pattern := range opts.BranchPatterns.Value() {
+		if strings.TrimSpace(pattern) == "" {
+			return nil, false, util.NewInvalidArgumentErrorf("branch pattern can't be empty")
+		}
+		if _, err := glob.Compile(pattern, '/'); err != nil {
+			return nil, false, util.NewInvalidArgumentErrorf("invalid branch pattern %q: %v", pattern, err)
+		}
+	}
+	reviewerIDs := container.SetOf(opts.ReviewerIDs.Value()...).Values()
+	slices.Sort(reviewerIDs)
+	for _, id := range reviewerIDs {
+		u, err := user_model.GetUserByID(ctx, id)
+		if err != nil {
+			if user_model.IsErrUserNotExist(err) {
+				return nil, false, util.NewInvalidArgumentErrorf("reviewer %d doesn't exist", id)
+			}
+			return nil, false, err
+		}
+		perm, err := access_model.GetUserRepoPermission(ctx, repo, u)
+		if err != nil {
+			return nil, false, err
+		}
+		if !perm.CanWrite(unit.TypeActions) {
+			return nil, false, util.NewInvalidArgumentErrorf("reviewer %s can't write the actions of the repository", u.Name)
+		}
+	}
+
+	var env *actions_model.ActionEnvironment
+	created := false
+	err := db.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		env, err = actions_model.GetEnvironmentByName(ctx, repo.ID, name)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return err
+		}
+		if env == nil {
+			env = &actions_model.ActionEnvironment{RepoID: repo.ID, Name: name}
+			created = true
+		}
+		if opts.WaitTimer.Has() {
+			env.WaitTimer = opts.WaitTimer.Value()
+		}
+		if opts.ReviewerIDs.Has() {
+			env.ReviewerIDs = reviewerIDs
+		}
+		if opts.BranchPatterns.Has() {
+			env.BranchPatterns = opts.BranchPatterns.Value()
+		}
+		if created {
+			return actions_model.CreateEnvironment(ctx, env)
+		}
+		return actions_model.UpdateEnvironment(ctx, env, "wait_timer", "reviewer_ids", "branch_patterns")
+	})
+	return env, created, err
+}
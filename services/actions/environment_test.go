@@ -0,0 +1,56 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_parseRawEnvironments(t *testing.T) {
+	raws, err := parseRawEnvironments([]byte(`
+name: test
+on: push
+jobs:
+  job1:
+    runs-on: ubuntu-latest
+    environment: production
+    steps:
+      - run: echo job1
+  job2:
+    runs-on: ubuntu-latest
+    environment:
+      name: ${{ github.ref_name }}
+      url: https://example.com
+    steps:
+      - run: echo job2
+  job3:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo job3
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"job1": "production\n",
+		"job2": "name: ${{ github.ref_name }}\nurl: https://example.com\n",
+	}, raws)
+
+	_, err = parseRawEnvironments([]byte("on: push\njobs:\n  job1:\n    environment: [a, b]\n"))
+	assert.EqualError(t, err, `job "job1": invalid environment at line 4`)
+}
+
+func Test_environmentConfig(t *testing.T) {
+	var cfg environmentConfig
+	require.NoError(t, yaml.Unmarshal([]byte("production"), &cfg))
+	assert.Equal(t, environmentConfig{Name: "production"}, cfg)
+
+	cfg = environmentConfig{}
+	require.NoError(t, yaml.Unmarshal([]byte("name: staging\nurl: https://example.com\n"), &cfg))
+	assert.Equal(t, environmentConfig{Name: "staging", URL: "https://example.com"}, cfg)
+
+	assert.Error(t, yaml.Unmarshal([]byte("[a, b]"), &cfg))
+}
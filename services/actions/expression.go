@@ -0,0 +1,219 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/container"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// JobNeed is the result and the outputs of a job needed by another job
+type JobNeed struct {
+	Status  actions_model.Status
+	Outputs map[string]string
+}
+
+// jobScope returns the prefix of the ids of the jobs in the same workflow as the job,
+// the jobs of a called workflow are prefixed by the id of the caller job, like "caller/build".
+func jobScope(jobID string) string {
+	if i := strings.LastIndex(jobID, "/"); i >= 0 {
+		return jobID[:i+1]
+	}
+	return ""
+}
+
+// FindJobNeeds returns the done jobs needed by the job,
+// the keys are the ids of the needed jobs in the workflow which defines the job.
+func FindJobNeeds(ctx context.Context, job *actions_model.ActionRunJob) (map[string]*JobNeed, error) {
+	if len(job.Needs) == 0 {
+		return nil, nil
+	}
+	needs := container.SetOf(job.Needs...)
+
+	jobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{RunID: job.RunID})
+	if err != nil {
+		return nil, fmt.Errorf("FindRunJobs: %w", err)
+	}
+
+	scope := jobScope(job.JobID)
+	ret := make(map[string]*JobNeed, len(needs))
+	for _, j := range jobs {
+		// the jobs in different called workflows could have the same id if the caller job has a matrix
+		if !needs.Contains(j.JobID) || j.CallerID != job.CallerID || !j.Status.IsDone() {
+			continue
+		}
+		outputs := make(map[string]string)
+		if j.TaskID != 0 {
+			got, err := actions_model.FindTaskOutputByTaskID(ctx, j.TaskID)
+			if err != nil {
+				return nil, fmt.Errorf("FindTaskOutputByTaskID: %w", err)
+			}
+			for _, v := range got {
+				outputs[v.OutputKey] = v.OutputValue
+			}
+		} else if isWorkflowCaller(j, jobs) {
+			if j.Status == actions_model.StatusSuccess {
+				if outputs, err = getWorkflowCallOutputs(ctx, j, jobs); err != nil {
+					return nil, err
+				}
+			}
+		} else {
+			// it shouldn't happen, or the job has been rerun
+			continue
+		}
+		ret[strings.TrimPrefix(j.JobID, scope)] = &JobNeed{
+			Status:  j.Status,
+			Outputs: outputs,
+		}
+	}
+	return ret, nil
+}
+
+// newEvaluationEnvironment returns the environment and the config to evaluate the expressions of a run on the server side,
+// the expressions are evaluated in the context of the job, or at the workflow level if job is nil.
+func newEvaluationEnvironment(ctx context.Context, run *actions_model.ActionRun, job *actions_model.ActionRunJob) (*exprparser.EvaluationEnvironment, exprparser.Config, error) {
+	gitCtx, err := generateGiteaContext(ctx, run, job)
+	if err != nil {
+		return nil, exprparser.Config{}, err
+	}
+	vars, err := actions_model.GetVariablesOfRun(ctx, run)
+	if err != nil {
+		return nil, exprparser.Config{}, fmt.Errorf("GetVariablesOfRun: %w", err)
+	}
+
+	env := &exprparser.EvaluationEnvironment{
+		Github: gitCtx,
+		Vars:   vars,
+		Job:    &model.JobContext{},
+	}
+	config := exprparser.Config{
+		Run: &model.Run{
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{}},
+		},
+		Context: "job",
+	}
+
+	if job == nil {
+		// the workflow level expressions can't use the job related contexts,
+		// but a placeholder job is still required by the interpreter
+		config.Run.Workflow.Jobs[""] = &model.Job{}
+		return env, config, nil
+	}
+
+	wfJob, err := readWorkflowJob(job)
+	if err != nil {
+		return nil, exprparser.Config{}, err
+	}
+	// the payload of a job contains only one combination of the matrix
+	if matrixes, err := wfJob.GetMatrixes(); err == nil && len(matrixes) == 1 {
+		env.Matrix = matrixes[0]
+	}
+	if wfJob.Strategy != nil {
+		env.Strategy = map[string]any{
+			"fail-fast":    wfJob.Strategy.FailFast,
+			"max-parallel": wfJob.Strategy.MaxParallel,
+		}
+	}
+
+	if env.Inputs, err = GetWorkflowCallInputs(ctx, job); err != nil {
+		return nil, exprparser.Config{}, err
+	}
+
+	needs, err := FindJobNeeds(ctx, job)
+	if err != nil {
+		return nil, exprparser.Config{}, err
+	}
+	scope := jobScope(job.JobID)
+	needIDs := make([]string, 0, len(job.Needs))
+	env.Needs = make(map[string]exprparser.Needs, len(job.Needs))
+	for _, need := range job.Needs {
+		id := strings.TrimPrefix(need, scope)
+		needIDs = append(needIDs, id)
+		needJob := &model.Job{}
+		if n, ok := needs[id]; ok {
+			needJob.Result = n.Status.String()
+			needJob.Outputs = n.Outputs
+			env.Needs[id] = exprparser.Needs{
+				Result:  needJob.Result,
+				Outputs: n.Outputs,
+			}
+		}
+		config.Run.Workflow.Jobs[id] = needJob
+	}
+
+	jobID := strings.TrimPrefix(job.JobID, scope)
+	if err := wfJob.RawNeeds.Encode(needIDs); err != nil {
+		return nil, exprparser.Config{}, err
+	}
+	config.Run.Workflow.Jobs[jobID] = wfJob
+	config.Run.JobID = jobID
+
+	return env, config, nil
+}
+
+// newInterpreter returns an interpreter to evaluate the expressions of a run on the server side,
+// see newEvaluationEnvironment
+func newInterpreter(ctx context.Context, run *actions_model.ActionRun, job *actions_model.ActionRunJob) (exprparser.Interpreter, error) {
+	env, config, err := newEvaluationEnvironment(ctx, run, job)
+	if err != nil {
+		return nil, err
+	}
+	return exprparser.NewInterpeter(env, config), nil
+}
+
+// evaluateYamlNode evaluates the expressions in the node,
+// it recovers from the panics of the expression evaluator of act which panics on malformed expressions.
+func evaluateYamlNode(interpreter exprparser.Interpreter, node *yaml.Node) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("evaluate expressions: %v", r)
+		}
+	}()
+	return jobparser.NewExpressionEvaluator(interpreter).EvaluateYamlNode(node)
+}
+
+// evaluateString evaluates the expressions in the string and returns the result as a string
+func evaluateString(interpreter exprparser.Interpreter, in string) (string, error) {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: in}
+	if err := evaluateYamlNode(interpreter, node); err != nil {
+		return "", err
+	}
+	var out string
+	if err := node.Decode(&out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// evaluateCondition evaluates the `if` condition of a job, the default status check is success()
+func evaluateCondition(interpreter exprparser.Interpreter, condition string) (ret bool, err error) {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "${{") && strings.HasSuffix(condition, "}}") {
+		condition = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(condition, "${{"), "}}"))
+	}
+	if condition == "" {
+		condition = "success()"
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("evaluate condition: %v", r)
+		}
+	}()
+	result, err := interpreter.Evaluate(condition, exprparser.DefaultStatusCheckSuccess)
+	if err != nil {
+		return false, err
+	}
+	return exprparser.IsTruthy(result), nil
+}
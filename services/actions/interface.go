@@ -25,4 +25,6 @@ type API interface {
 	UpdateVariable(*context.APIContext)
 	// GetRegistrationToken get registration token
 	GetRegistrationToken(*context.APIContext)
+	// GenerateRunnerJITConfig create a just-in-time runner
+	GenerateRunnerJITConfig(*context.APIContext)
 }
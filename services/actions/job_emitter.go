@@ -11,7 +11,9 @@ import (
 	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/queue"
+	notify_service "code.gitea.io/gitea/services/notify"
 
 	"github.com/nektos/act/pkg/jobparser"
 	"xorm.io/builder"
@@ -45,25 +47,61 @@ func jobEmitterQueueHandler(items ...*jobUpdate) []*jobUpdate {
 }
 
 func checkJobsOfRun(ctx context.Context, runID int64) error {
+	run, err := actions_model.GetRunByID(ctx, runID)
+	if err != nil {
+		return err
+	}
 	jobs, err := db.Find[actions_model.ActionRunJob](ctx, actions_model.FindRunJobOptions{RunID: runID})
 	if err != nil {
 		return err
 	}
+	updatedCalls := false
+	var doneJobs, cancelledJobs []*actions_model.ActionRunJob
 	if err := db.WithTx(ctx, func(ctx context.Context) error {
-		idToJobs := make(map[string][]*actions_model.ActionRunJob, len(jobs))
-		for _, job := range jobs {
-			idToJobs[job.JobID] = append(idToJobs[job.JobID], job)
+		finished, err := finishWorkflowCalls(ctx, jobs)
+		if err != nil {
+			return err
 		}
+		updatedCalls = finished
 
 		updates := newJobStatusResolver(jobs).Resolve()
 		for _, job := range jobs {
 			if status, ok := updates[job.ID]; ok {
+				if status == actions_model.StatusWaiting {
+					job.Run = run
+					blocked, cancelled, err := checkJobConcurrency(ctx, job)
+					if err != nil {
+						return err
+					}
+					cancelledJobs = append(cancelledJobs, cancelled...)
+					if blocked {
+						continue
+					}
+					if isWorkflowCaller(job, jobs) {
+						// the job calling a reusable workflow is never picked by runners
+						if err := startWorkflowCall(ctx, job); err != nil {
+							return err
+						}
+						updatedCalls = true
+						continue
+					}
+					// the job targeting an environment waits for the protection rules of the environment
+					if status, err = checkJobEnvironment(ctx, job); err != nil {
+						return err
+					}
+					if status == actions_model.StatusBlocked {
+						continue
+					}
+				}
 				job.Status = status
 				if n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusBlocked}, "status"); err != nil {
 					return err
 				} else if n != 1 {
 					return fmt.Errorf("no affected for updating blocked job %v", job.ID)
 				}
+				if status.IsDone() {
+					doneJobs = append(doneJobs, job)
+				}
 			}
 		}
 		return nil
@@ -71,6 +109,58 @@ func checkJobsOfRun(ctx context.Context, runID int64) error {
 		return err
 	}
 	CreateCommitStatus(ctx, jobs...)
+	for _, job := range doneJobs {
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+	}
+	notifyCancelledJobs(ctx, cancelledJobs)
+	if updatedCalls || hasFailedJobs(doneJobs) {
+		// the jobs of the called workflows or the jobs needing the callers or the failed jobs could be ready now
+		if err := EmitJobsIfReady(runID); err != nil {
+			return err
+		}
+	}
+	if err := emitBlockedConcurrentRuns(ctx, run, jobs); err != nil {
+		return err
+	}
+	return notifyWorkflowRunDone(ctx, runID)
+}
+
+// hasFailedJobs returns whether any of the jobs has failed, the jobs could be failed by the protection rules of environments
+func hasFailedJobs(jobs []*actions_model.ActionRunJob) bool {
+	for _, job := range jobs {
+		if job.Status == actions_model.StatusFailure {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyWorkflowJobStatusUpdate notifies the status update of a job, the run of the job is loaded if needed,
+// and the state of the deployment of the job is updated as well
+func NotifyWorkflowJobStatusUpdate(ctx context.Context, job *actions_model.ActionRunJob) {
+	if err := syncDeploymentState(ctx, job); err != nil {
+		log.Error("syncDeploymentState: %v", err)
+	}
+	if err := job.LoadAttributes(ctx); err != nil {
+		log.Error("LoadAttributes: %v", err)
+		return
+	}
+	notify_service.WorkflowJobStatusUpdate(ctx, job.Run.Repo, job.Run.TriggerUser, job)
+}
+
+// notifyWorkflowRunDone notifies the completion of a run only once, it does nothing if the run isn't done yet
+func notifyWorkflowRunDone(ctx context.Context, runID int64) error {
+	if notified, err := actions_model.SetRunDoneNotified(ctx, runID); err != nil || !notified {
+		return err
+	}
+	run, err := actions_model.GetRunByID(ctx, runID)
+	if err != nil {
+		return err
+	}
+	if err := run.LoadAttributes(ctx); err != nil {
+		return err
+	}
+	notify_service.WorkflowRunStatusUpdate(ctx, run.Repo, run.TriggerUser, run)
 	return nil
 }
 
@@ -94,7 +184,10 @@ func newJobStatusResolver(jobs actions_model.ActionJobList) *jobStatusResolver {
 		statuses[job.ID] = job.Status
 		for _, need := range job.Needs {
 			for _, v := range idToJobs[need] {
-				needs[job.ID] = append(needs[job.ID], v.ID)
+				// the jobs in different called workflows could have the same id if the caller job has a matrix
+				if v.CallerID == job.CallerID {
+					needs[job.ID] = append(needs[job.ID], v.ID)
+				}
 			}
 		}
 	}
@@ -126,6 +219,16 @@ func (r *jobStatusResolver) resolve() map[int64]actions_model.Status {
 		if status != actions_model.StatusBlocked {
 			continue
 		}
+		if callerID := r.jobMap[id].CallerID; callerID > 0 {
+			// the jobs of a called workflow are skipped if the caller is done without running them,
+			// and they are kept blocked until the caller starts
+			if callerStatus := r.statuses[callerID]; callerStatus.IsDone() {
+				ret[id] = actions_model.StatusSkipped
+				continue
+			} else if callerStatus != actions_model.StatusRunning {
+				continue
+			}
+		}
 		allDone, allSucceed := true, true
 		for _, need := range r.needs[id] {
 			needStatus := r.statuses[need]
@@ -126,6 +126,55 @@ jobs:
 			},
 			want: map[int64]actions_model.Status{2: actions_model.StatusSkipped},
 		},
+		{
+			name: "called jobs wait for the caller",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "1", Status: actions_model.StatusBlocked, Needs: []string{}},
+				{ID: 2, JobID: "1/a", Status: actions_model.StatusBlocked, Needs: []string{}, CallerID: 1},
+				{ID: 3, JobID: "1/b", Status: actions_model.StatusBlocked, Needs: []string{"1/a"}, CallerID: 1},
+			},
+			want: map[int64]actions_model.Status{
+				1: actions_model.StatusWaiting,
+			},
+		},
+		{
+			name: "called jobs of a running caller",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "1", Status: actions_model.StatusRunning, Needs: []string{}},
+				{ID: 2, JobID: "1/a", Status: actions_model.StatusBlocked, Needs: []string{}, CallerID: 1},
+				{ID: 3, JobID: "1/b", Status: actions_model.StatusBlocked, Needs: []string{"1/a"}, CallerID: 1},
+				{ID: 4, JobID: "2", Status: actions_model.StatusBlocked, Needs: []string{"1"}},
+			},
+			want: map[int64]actions_model.Status{
+				2: actions_model.StatusWaiting,
+			},
+		},
+		{
+			name: "called jobs of a skipped caller",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "1", Status: actions_model.StatusSkipped, Needs: []string{}},
+				{ID: 2, JobID: "1/a", Status: actions_model.StatusBlocked, Needs: []string{}, CallerID: 1},
+				{ID: 3, JobID: "1/b", Status: actions_model.StatusBlocked, Needs: []string{"1/a"}, CallerID: 1},
+			},
+			want: map[int64]actions_model.Status{
+				2: actions_model.StatusSkipped,
+				3: actions_model.StatusSkipped,
+			},
+		},
+		{
+			name: "called jobs of a caller with matrix",
+			jobs: actions_model.ActionJobList{
+				{ID: 1, JobID: "1", Status: actions_model.StatusRunning, Needs: []string{}},
+				{ID: 2, JobID: "1/a", Status: actions_model.StatusSuccess, Needs: []string{}, CallerID: 1},
+				{ID: 3, JobID: "1/b", Status: actions_model.StatusBlocked, Needs: []string{"1/a"}, CallerID: 1},
+				{ID: 4, JobID: "1", Status: actions_model.StatusRunning, Needs: []string{}},
+				{ID: 5, JobID: "1/a", Status: actions_model.StatusRunning, Needs: []string{}, CallerID: 4},
+				{ID: 6, JobID: "1/b", Status: actions_model.StatusBlocked, Needs: []string{"1/a"}, CallerID: 4},
+			},
+			want: map[int64]actions_model.Status{
+				3: actions_model.StatusWaiting,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
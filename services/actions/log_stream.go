@@ -0,0 +1,92 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/eventsource"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// taskLogManager wakes up the log streams of the tasks when their logs or states are updated,
+// the messengers are keyed by the ids of the tasks.
+var taskLogManager = eventsource.NewManager()
+
+// NotifyTaskLogUpdated wakes up the log streams of the task.
+// The streams served by other instances are not notified, they will read the logs when they poll.
+func NotifyTaskLogUpdated(taskID int64) {
+	taskLogManager.SendMessage(taskID, &eventsource.Event{Name: "update"})
+}
+
+// SubscribeTaskLogUpdates returns a channel to receive the notifications of the updates of the task's logs,
+// the returned function must be called to unsubscribe when the stream is closed.
+func SubscribeTaskLogUpdates(taskID int64) (<-chan *eventsource.Event, func()) {
+	ch := taskLogManager.Register(taskID)
+	return ch, func() {
+		taskLogManager.Unregister(taskID, ch)
+	}
+}
+
+// ReadTaskLogChunk reads at most limit lines of the logs of the task from the offset, the lines are grouped by the steps they belong to.
+// The lines are read only if the steps they belong to are known, so the chunk could end before the end of the logs
+// if the runner has sent the logs but not the state of the task yet.
+func ReadTaskLogChunk(ctx context.Context, task *actions_model.ActionTask, offset, limit int64) (*api.ActionJobLogChunk, error) {
+	chunk := &api.ActionJobLogChunk{
+		Offset: offset,
+		Steps:  make([]*api.ActionJobStepLogs, 0), // marshal to '[]' instead fo 'null' in json
+	}
+	if task.LogExpired {
+		return chunk, nil
+	}
+	if task.Steps == nil {
+		steps, err := actions_model.GetTaskStepsByTaskID(ctx, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		task.Steps = steps
+	}
+
+	// task.LogIndexes could be older than task.LogLength, see the comments in ViewPost
+	end := min(offset+limit, task.LogLength, int64(len(task.LogIndexes)))
+	for i, step := range actions_module.FullSteps(task) {
+		if chunk.Offset >= end {
+			break
+		}
+		stepEnd := step.LogIndex + step.LogLength
+		if chunk.Offset < step.LogIndex || chunk.Offset >= stepEnd {
+			continue
+		}
+
+		rows, err := actions_module.ReadLogs(ctx, task.LogInStorage, task.LogFilename, task.LogIndexes[chunk.Offset], min(stepEnd, end)-chunk.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("ReadLogs: %w", err)
+		}
+		cursor := chunk.Offset - step.LogIndex
+		stepLogs := &api.ActionJobStepLogs{
+			Step:    i,
+			Started: int64(step.Started),
+			Lines:   make([]*api.ActionJobLogLine, 0, len(rows)),
+		}
+		for j, row := range rows {
+			stepLogs.Lines = append(stepLogs.Lines, &api.ActionJobLogLine{
+				Index:     cursor + int64(j) + 1, // start at 1
+				Message:   row.Content,
+				Timestamp: float64(row.Time.AsTime().UnixNano()) / float64(time.Second),
+			})
+		}
+		stepLogs.Cursor = cursor + int64(len(rows))
+		chunk.Steps = append(chunk.Steps, stepLogs)
+		chunk.Offset += int64(len(rows))
+		if chunk.Offset < min(stepEnd, end) {
+			// the log file is shorter than expected, try again later
+			break
+		}
+	}
+	return chunk, nil
+}
@@ -6,6 +6,7 @@ package actions
 import (
 	"context"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	issues_model "code.gitea.io/gitea/models/issues"
 	packages_model "code.gitea.io/gitea/models/packages"
 	perm_model "code.gitea.io/gitea/models/perm"
@@ -13,6 +14,7 @@ import (
 	repo_model "code.gitea.io/gitea/models/repo"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
@@ -754,3 +756,57 @@ func (n *actionsNotifier) MigrateRepository(ctx context.Context, doer, u *user_m
 		Sender:       convert.ToUser(ctx, doer, nil),
 	}).Notify(ctx)
 }
+
+// maxWorkflowRunChainDepth is the max levels of workflows chained by workflow_run events,
+// it's the same as GitHub's and avoids triggering cyclically.
+// See https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows#workflow_run
+const maxWorkflowRunChainDepth = 3
+
+// WorkflowRunStatusUpdate is used to trigger the workflows listening to the completion of a workflow run
+func (n *actionsNotifier) WorkflowRunStatusUpdate(ctx context.Context, repo *repo_model.Repository, sender *user_model.User, run *actions_model.ActionRun) {
+	if !run.Status.IsDone() {
+		return
+	}
+	ctx = withMethod(ctx, "WorkflowRunStatusUpdate")
+
+	if depth := getWorkflowRunChainDepth(ctx, run); depth >= maxWorkflowRunChainDepth {
+		log.Trace("the workflow run %d is the level %d of a workflow_run chain, skip triggering the next level", run.ID, depth)
+		return
+	}
+
+	workflow, err := convert.ToActionWorkflow(ctx, run)
+	if err != nil {
+		log.Error("ToActionWorkflow: %v", err)
+		return
+	}
+	workflowRun, err := convert.ToActionWorkflowRun(ctx, repo, run)
+	if err != nil {
+		log.Error("ToActionWorkflowRun: %v", err)
+		return
+	}
+
+	newNotifyInput(repo, sender, webhook_module.HookEventWorkflowRun).WithPayload(&api.WorkflowRunPayload{
+		Action:      api.HookWorkflowRunCompleted,
+		Workflow:    workflow,
+		WorkflowRun: workflowRun,
+		Repository:  convert.ToRepo(ctx, repo, access_model.Permission{AccessMode: perm_model.AccessModeOwner}),
+		Sender:      convert.ToUser(ctx, sender, nil),
+	}).Notify(ctx)
+}
+
+// getWorkflowRunChainDepth returns the level of a run in the chain of workflows triggered by workflow_run events
+func getWorkflowRunChainDepth(ctx context.Context, run *actions_model.ActionRun) int {
+	depth := 1
+	for ; run.Event == webhook_module.HookEventWorkflowRun && depth < maxWorkflowRunChainDepth; depth++ {
+		var payload api.WorkflowRunPayload
+		if err := json.Unmarshal([]byte(run.EventPayload), &payload); err != nil || payload.WorkflowRun == nil {
+			break
+		}
+		triggering, err := actions_model.GetRunByID(ctx, payload.WorkflowRun.ID)
+		if err != nil {
+			break
+		}
+		run = triggering
+	}
+	return depth
+}
@@ -175,10 +175,15 @@ func notify(ctx context.Context, input *notifyInput) error {
 	var detectedWorkflows []*actions_module.DetectedWorkflow
 	actionsConfig := input.Repo.MustGetUnit(ctx, unit_model.TypeActions).ActionsConfig()
 	shouldDetectSchedules := input.Event == webhook_module.HookEventPush && input.Ref.BranchName() == input.Repo.DefaultBranch
+	requiredWorkflows, err := loadRequiredWorkflows(ctx, input.Repo)
+	if err != nil {
+		return err
+	}
 	workflows, schedules, err := actions_module.DetectWorkflows(gitRepo, commit,
 		input.Event,
 		input.Payload,
 		shouldDetectSchedules,
+		requiredWorkflows...,
 	)
 	if err != nil {
 		return fmt.Errorf("DetectWorkflows: %w", err)
@@ -193,7 +198,8 @@ func notify(ctx context.Context, input *notifyInput) error {
 	)
 
 	for _, wf := range workflows {
-		if actionsConfig.IsWorkflowDisabled(wf.EntryName) {
+		// the required workflows of the organization can't be disabled by the repository
+		if wf.RequiredWorkflowID == 0 && actionsConfig.IsWorkflowDisabled(wf.EntryName) {
 			log.Trace("repo %s has disable workflows %s", input.Repo.RepoPath(), wf.EntryName)
 			continue
 		}
@@ -210,7 +216,7 @@ func notify(ctx context.Context, input *notifyInput) error {
 		if err != nil {
 			return fmt.Errorf("gitRepo.GetCommit: %w", err)
 		}
-		baseWorkflows, _, err := actions_module.DetectWorkflows(gitRepo, baseCommit, input.Event, input.Payload, false)
+		baseWorkflows, _, err := actions_module.DetectWorkflows(gitRepo, baseCommit, input.Event, input.Payload, false, requiredWorkflows...)
 		if err != nil {
 			return fmt.Errorf("DetectWorkflows: %w", err)
 		}
@@ -344,7 +350,7 @@ func handleWorkflows(
 			}
 		}
 
-		if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
+		if err := InsertRun(ctx, run, dwf.Content, jobs); err != nil {
 			log.Error("InsertRun: %v", err)
 			continue
 		}
@@ -0,0 +1,164 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// idTokenExpiration is the lifetime of the ID tokens, they are supposed to be exchanged for the credentials of other services at once
+const idTokenExpiration = 10 * time.Minute
+
+// IDTokenClaims are the claims of the OIDC ID token of a job, the names are the same as GitHub's,
+// see https://docs.github.com/en/actions/security-for-github-actions/security-hardening-your-deployments/about-security-hardening-with-openid-connect#understanding-the-oidc-token
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Ref                  string `json:"ref"`
+	RefType              string `json:"ref_type"`
+	SHA                  string `json:"sha"`
+	Repository           string `json:"repository"`
+	RepositoryID         string `json:"repository_id"`
+	RepositoryOwner      string `json:"repository_owner"`
+	RepositoryOwnerID    string `json:"repository_owner_id"`
+	RepositoryVisibility string `json:"repository_visibility"`
+	Actor                string `json:"actor"`
+	ActorID              string `json:"actor_id"`
+	Workflow             string `json:"workflow"`
+	EventName            string `json:"event_name"`
+	HeadRef              string `json:"head_ref,omitempty"`
+	BaseRef              string `json:"base_ref,omitempty"`
+	Environment          string `json:"environment,omitempty"`
+	RunID                string `json:"run_id"`
+	RunNumber            string `json:"run_number"`
+	RunAttempt           string `json:"run_attempt"`
+	JobID                string `json:"job_id"`
+}
+
+// IDTokenIssuer returns the issuer of the ID tokens, the discovery document is at "{issuer}/.well-known/openid-configuration"
+func IDTokenIssuer() string {
+	return setting.AppURL + "api/actions/oidc"
+}
+
+// CanRequestIDToken returns whether the job is granted `id-token: write`,
+// the jobs of the pull requests from forks can't request ID tokens unless they are triggered by pull_request_target.
+func CanRequestIDToken(ctx context.Context, job *actions_model.ActionRunJob) (bool, error) {
+	if err := job.LoadRun(ctx); err != nil {
+		return false, err
+	}
+	if job.Run.IsForkPullRequest && job.Run.TriggerEvent != actions_module.GithubEventPullRequestTarget {
+		return false, nil
+	}
+	permissions, err := getJobPermissions(job)
+	if err != nil || permissions == nil {
+		return false, err
+	}
+	return permissions.Get("id-token") == permissionWrite, nil
+}
+
+// CreateIDToken creates an OIDC ID token of the running task signed by the JWT signing key of OAuth2,
+// the audience is the URL of the repository owner if it's empty.
+func CreateIDToken(ctx context.Context, task *actions_model.ActionTask, audience string) (string, error) {
+	signingKey := oauth2.DefaultSigningKey
+	if signingKey == nil || signingKey.IsSymmetric() {
+		return "", errors.New("ID tokens can only be signed by an asymmetric JWT signing algorithm")
+	}
+
+	if err := task.LoadJob(ctx); err != nil {
+		return "", err
+	}
+	job := task.Job
+	if ok, err := CanRequestIDToken(ctx, job); err != nil {
+		return "", err
+	} else if !ok {
+		return "", util.NewPermissionDeniedErrorf("the job isn't granted to request ID tokens")
+	}
+	run := job.Run
+	if err := run.LoadAttributes(ctx); err != nil {
+		return "", err
+	}
+
+	environment := ""
+	if job.DeploymentID > 0 {
+		deployment, err := actions_model.GetDeploymentByID(ctx, job.DeploymentID)
+		if err != nil {
+			return "", err
+		}
+		if err := deployment.LoadAttributes(ctx); err != nil {
+			return "", err
+		}
+		environment = deployment.Environment.Name
+	}
+
+	repository := run.Repo.OwnerName + "/" + run.Repo.Name
+	subject := fmt.Sprintf("repo:%s:ref:%s", repository, run.Ref)
+	if environment != "" {
+		subject = fmt.Sprintf("repo:%s:environment:%s", repository, environment)
+	} else if run.TriggerEvent == actions_module.GithubEventPullRequest {
+		subject = fmt.Sprintf("repo:%s:pull_request", repository)
+	}
+	if audience == "" {
+		audience = setting.AppURL + url.PathEscape(run.Repo.OwnerName)
+	}
+	visibility := "public"
+	if run.Repo.IsPrivate {
+		visibility = "private"
+	}
+
+	headRef, baseRef := "", ""
+	if payload, err := run.GetPullRequestEventPayload(); err == nil && payload.PullRequest != nil && payload.PullRequest.Base != nil && payload.PullRequest.Head != nil {
+		headRef = payload.PullRequest.Head.Ref
+		baseRef = payload.PullRequest.Base.Ref
+	}
+
+	now := time.Now()
+	claims := IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    IDTokenIssuer(),
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(idTokenExpiration)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+		Ref:                  run.Ref,
+		RefType:              git.RefName(run.Ref).RefType(),
+		SHA:                  run.CommitSHA,
+		Repository:           repository,
+		RepositoryID:         strconv.FormatInt(run.RepoID, 10),
+		RepositoryOwner:      run.Repo.OwnerName,
+		RepositoryOwnerID:    strconv.FormatInt(run.Repo.OwnerID, 10),
+		RepositoryVisibility: visibility,
+		Actor:                run.TriggerUser.Name,
+		ActorID:              strconv.FormatInt(run.TriggerUserID, 10),
+		Workflow:             run.WorkflowID,
+		EventName:            run.TriggerEvent,
+		HeadRef:              headRef,
+		BaseRef:              baseRef,
+		Environment:          environment,
+		RunID:                strconv.FormatInt(run.ID, 10),
+		RunNumber:            strconv.FormatInt(run.Index, 10),
+		RunAttempt:           strconv.FormatInt(job.Attempt, 10),
+		JobID:                job.JobID,
+	}
+
+	token := jwt.NewWithClaims(signingKey.SigningMethod(), claims)
+	signingKey.PreProcessToken(token)
+	return token.SignedString(signingKey.SignKey())
+}
@@ -0,0 +1,306 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	perm_model "code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/util"
+
+	"gopkg.in/yaml.v3"
+)
+
+// the access levels of the scopes in `permissions`
+const (
+	permissionNone  = "none"
+	permissionRead  = "read"
+	permissionWrite = "write"
+)
+
+// permissionsConfig is the `permissions` configuration of a job,
+// see https://docs.github.com/en/actions/writing-workflows/workflow-syntax-for-github-actions#permissions
+type permissionsConfig struct {
+	All    string            // the access level of all scopes set by "read-all" or "write-all"
+	Scopes map[string]string // the access levels of the scopes like "contents" or "id-token"
+}
+
+// UnmarshalYAML supports both "read-all" / "write-all" and the mapping of scopes
+func (p *permissionsConfig) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var all string
+		if err := node.Decode(&all); err != nil {
+			return err
+		}
+		switch all {
+		case "read-all":
+			p.All = permissionRead
+		case "write-all":
+			p.All = permissionWrite
+		default:
+			return fmt.Errorf("invalid permissions %q at line %d", all, node.Line)
+		}
+		return nil
+	}
+	if err := node.Decode(&p.Scopes); err != nil {
+		return err
+	}
+	for scope, level := range p.Scopes {
+		if level != permissionNone && level != permissionRead && level != permissionWrite {
+			return fmt.Errorf("invalid access level %q of %q at line %d", level, scope, node.Line)
+		}
+	}
+	return nil
+}
+
+// Get returns the access level of the scope, the scopes not listed in the mapping have no access
+func (p *permissionsConfig) Get(scope string) string {
+	if p.All != "" {
+		return p.All
+	}
+	if level, ok := p.Scopes[scope]; ok {
+		return level
+	}
+	return permissionNone
+}
+
+// declares returns whether the access level of the scope is declared
+func (p *permissionsConfig) declares(scope string) bool {
+	if p.All != "" {
+		return true
+	}
+	_, ok := p.Scopes[scope]
+	return ok
+}
+
+// permissionLevels are the access levels from the lowest to the highest
+var permissionLevels = []string{permissionNone, permissionRead, permissionWrite}
+
+// permissionScopes are all the scopes which could be declared in `permissions`
+var permissionScopes = []string{
+	"actions",
+	"attestations",
+	"checks",
+	"contents",
+	"deployments",
+	"discussions",
+	"id-token",
+	"issues",
+	"packages",
+	"pages",
+	"pull-requests",
+	"repository-projects",
+	"security-events",
+	"statuses",
+}
+
+// capPermissions returns the permissions of a job in a called workflow, which can't be higher than the ones of the caller job.
+// Each scope has the lower access level of the caller and the called job, and the caller's one if the called job doesn't declare it.
+func capPermissions(caller, called *permissionsConfig) *permissionsConfig {
+	capped := &permissionsConfig{Scopes: make(map[string]string, len(permissionScopes))}
+	for _, scope := range permissionScopes {
+		level := caller.Get(scope)
+		if called.declares(scope) && slices.Index(permissionLevels, called.Get(scope)) < slices.Index(permissionLevels, level) {
+			level = called.Get(scope)
+		}
+		capped.Scopes[scope] = level
+	}
+	return capped
+}
+
+// parseRawPermissions returns the raw `permissions` of each job in the workflow,
+// the permissions of the workflow are used for the jobs which don't have their own.
+func parseRawPermissions(content []byte) (string, map[string]string, error) {
+	var workflow struct {
+		Permissions yaml.Node `yaml:"permissions"`
+		Jobs        map[string]struct {
+			Permissions yaml.Node `yaml:"permissions"`
+		} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &workflow); err != nil {
+		return "", nil, err
+	}
+
+	workflowRaw, err := encodeRawNode(&workflow.Permissions, "permissions")
+	if err != nil {
+		return "", nil, err
+	}
+	jobRaws := make(map[string]string, len(workflow.Jobs))
+	for id, job := range workflow.Jobs {
+		raw, err := encodeRawNode(&job.Permissions, "permissions")
+		if err != nil {
+			return "", nil, fmt.Errorf("job %q: %w", id, err)
+		}
+		if raw == "" {
+			raw = workflowRaw
+		}
+		if raw != "" {
+			jobRaws[id] = raw
+		}
+	}
+	return workflowRaw, jobRaws, nil
+}
+
+// getJobPermissions returns the permissions of the job, it's nil if the job doesn't declare `permissions`
+func getJobPermissions(job *actions_model.ActionRunJob) (*permissionsConfig, error) {
+	if job.RawPermissions == "" {
+		return nil, nil
+	}
+	p := &permissionsConfig{}
+	if err := yaml.Unmarshal([]byte(job.RawPermissions), p); err != nil {
+		return nil, fmt.Errorf("unmarshal raw permissions: %w", err)
+	}
+	return p, nil
+}
+
+// permissionScopeUnits are the units of the repository which the scopes of `permissions` grant access to,
+// the other scopes like "id-token" or "attestations" are checked by the features using them.
+var permissionScopeUnits = map[string][]unit.Type{
+	"contents":            {unit.TypeCode, unit.TypeReleases, unit.TypeWiki},
+	"issues":              {unit.TypeIssues},
+	"pull-requests":       {unit.TypePullRequests},
+	"packages":            {unit.TypePackages},
+	"actions":             {unit.TypeActions},
+	"repository-projects": {unit.TypeProjects},
+}
+
+// restrictedPermissions are the permissions of the jobs which don't declare `permissions` when the default workflow permissions are read
+var restrictedPermissions = permissionsConfig{Scopes: map[string]string{
+	"contents": permissionRead,
+	"packages": permissionRead,
+}}
+
+// permissivePermissions are the permissions of the jobs which don't declare `permissions` when the default workflow permissions are write
+var permissivePermissions = permissionsConfig{All: permissionWrite}
+
+func toAccessMode(level string) perm_model.AccessMode {
+	switch level {
+	case permissionRead:
+		return perm_model.AccessModeRead
+	case permissionWrite:
+		return perm_model.AccessModeWrite
+	default:
+		return perm_model.AccessModeNone
+	}
+}
+
+// GetOwnerDefaultWorkflowPermissions returns the default workflow permissions of the repositories of the owner, it's write if not set
+func GetOwnerDefaultWorkflowPermissions(ctx context.Context, ownerID int64) (repo_model.WorkflowPermissions, error) {
+	value, err := user_model.GetUserSetting(ctx, ownerID, user_model.SettingsKeyActionsDefaultWorkflowPermissions, string(repo_model.WorkflowPermissionsWrite))
+	if err != nil {
+		return "", err
+	}
+	if p := repo_model.WorkflowPermissions(value); p.IsValid() {
+		return p, nil
+	}
+	return repo_model.WorkflowPermissionsWrite, nil
+}
+
+// SetOwnerDefaultWorkflowPermissions sets the default workflow permissions of the repositories of the owner
+func SetOwnerDefaultWorkflowPermissions(ctx context.Context, ownerID int64, p repo_model.WorkflowPermissions) error {
+	if !p.IsValid() {
+		return util.NewInvalidArgumentErrorf("invalid default workflow permissions %q", p)
+	}
+	return user_model.SetUserSetting(ctx, ownerID, user_model.SettingsKeyActionsDefaultWorkflowPermissions, string(p))
+}
+
+// GetDefaultWorkflowPermissions returns the default workflow permissions of the repository, the ones of the owner are used if the repository doesn't set them
+func GetDefaultWorkflowPermissions(ctx context.Context, repo *repo_model.Repository) (repo_model.WorkflowPermissions, error) {
+	cfgUnit, err := repo.GetUnit(ctx, unit.TypeActions)
+	if err != nil && !repo_model.IsErrUnitTypeNotExist(err) {
+		return "", err
+	}
+	if cfgUnit != nil {
+		if p := cfgUnit.ActionsConfig().DefaultWorkflowPermissions; p.IsValid() {
+			return p, nil
+		}
+	}
+	return GetOwnerDefaultWorkflowPermissions(ctx, repo.OwnerID)
+}
+
+// SetDefaultWorkflowPermissions sets the default workflow permissions of the repository, the ones of the owner are used if it's empty
+func SetDefaultWorkflowPermissions(ctx context.Context, repo *repo_model.Repository, p repo_model.WorkflowPermissions) error {
+	if p != "" && !p.IsValid() {
+		return util.NewInvalidArgumentErrorf("invalid default workflow permissions %q", p)
+	}
+	cfgUnit, err := repo.GetUnit(ctx, unit.TypeActions)
+	if err != nil {
+		if repo_model.IsErrUnitTypeNotExist(err) {
+			return util.NewNotExistErrorf("actions are disabled in the repository")
+		}
+		return err
+	}
+	cfgUnit.ActionsConfig().DefaultWorkflowPermissions = p
+	return repo_model.UpdateRepoUnit(ctx, cfgUnit)
+}
+
+// getDefaultPermissions returns the permissions of the jobs which don't declare `permissions` in the repository
+func getDefaultPermissions(ctx context.Context, repo *repo_model.Repository) (*permissionsConfig, error) {
+	defaults, err := GetDefaultWorkflowPermissions(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if defaults == repo_model.WorkflowPermissionsRead {
+		return &restrictedPermissions, nil
+	}
+	return &permissivePermissions, nil
+}
+
+// getTaskTokenPermissions returns the access modes of the units granted to the token of the task by the `permissions` of its job,
+// the default workflow permissions of the repository are used if the job doesn't declare `permissions`,
+// and the tokens of the pull requests from forks could read the repository at most.
+func getTaskTokenPermissions(ctx context.Context, task *actions_model.ActionTask) (actions_model.TokenPermissions, error) {
+	if err := task.LoadJob(ctx); err != nil {
+		return nil, err
+	}
+	if err := task.Job.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	permissions, err := getJobPermissions(task.Job)
+	if err != nil {
+		return nil, err
+	}
+	if permissions == nil {
+		permissions, err = getDefaultPermissions(ctx, task.Job.Run.Repo)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tokenPermissions := actions_model.TokenPermissions{}
+	for scope, units := range permissionScopeUnits {
+		mode := toAccessMode(permissions.Get(scope))
+		if task.IsForkPullRequest {
+			mode = min(mode, perm_model.AccessModeRead)
+		}
+		if mode == perm_model.AccessModeNone {
+			continue
+		}
+		for _, u := range units {
+			tokenPermissions[u] = mode
+		}
+	}
+	return tokenPermissions, nil
+}
+
+// CreateTaskForRunner creates a task for the runner, the permissions of the task's token are stored when the task is created
+func CreateTaskForRunner(ctx context.Context, runner *actions_model.ActionRunner) (*actions_model.ActionTask, bool, error) {
+	return actions_model.CreateTaskForRunner(ctx, runner, getTaskTokenPermissions)
+}
+
+// SetTaskTokenPermissions updates the stored permissions of the token of the task by the `permissions` of its job
+func SetTaskTokenPermissions(ctx context.Context, task *actions_model.ActionTask) error {
+	tokenPermissions, err := getTaskTokenPermissions(ctx, task)
+	if err != nil {
+		return err
+	}
+	task.TokenPermissions = tokenPermissions
+	return actions_model.UpdateTask(ctx, task, "token_permissions")
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseRawPermissions(t *testing.T) {
+	workflowRaw, jobRaws, err := parseRawPermissions([]byte(`
+name: test
+on: push
+permissions: read-all
+jobs:
+  job1:
+    runs-on: ubuntu-latest
+    permissions:
+      id-token: write
+      contents: read
+    steps:
+      - run: echo job1
+  job2:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo job2
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "read-all\n", workflowRaw)
+	assert.Equal(t, map[string]string{
+		"job1": "id-token: write\ncontents: read\n",
+		"job2": "read-all\n",
+	}, jobRaws)
+
+	_, jobRaws, err = parseRawPermissions([]byte("on: push\njobs:\n  job1:\n    runs-on: ubuntu-latest\n"))
+	require.NoError(t, err)
+	assert.Empty(t, jobRaws)
+}
+
+func Test_getJobPermissions(t *testing.T) {
+	p, err := getJobPermissions(&actions_model.ActionRunJob{})
+	require.NoError(t, err)
+	assert.Nil(t, p)
+
+	p, err = getJobPermissions(&actions_model.ActionRunJob{RawPermissions: "id-token: write\ncontents: read\n"})
+	require.NoError(t, err)
+	assert.Equal(t, permissionWrite, p.Get("id-token"))
+	assert.Equal(t, permissionRead, p.Get("contents"))
+	assert.Equal(t, permissionNone, p.Get("packages"))
+
+	p, err = getJobPermissions(&actions_model.ActionRunJob{RawPermissions: "{}\n"})
+	require.NoError(t, err)
+	assert.Equal(t, permissionNone, p.Get("id-token"))
+
+	p, err = getJobPermissions(&actions_model.ActionRunJob{RawPermissions: "write-all\n"})
+	require.NoError(t, err)
+	assert.Equal(t, permissionWrite, p.Get("id-token"))
+
+	_, err = getJobPermissions(&actions_model.ActionRunJob{RawPermissions: "admin-all\n"})
+	assert.Error(t, err)
+	_, err = getJobPermissions(&actions_model.ActionRunJob{RawPermissions: "contents: admin\n"})
+	assert.Error(t, err)
+}
+
+func Test_capPermissions(t *testing.T) {
+	parse := func(raw string) *permissionsConfig {
+		p, err := getJobPermissions(&actions_model.ActionRunJob{RawPermissions: raw})
+		require.NoError(t, err)
+		return p
+	}
+
+	// the called workflow can't escalate the permissions of the caller
+	capped := capPermissions(parse("contents: read\n"), parse("write-all\n"))
+	assert.Equal(t, permissionRead, capped.Get("contents"))
+	assert.Equal(t, permissionNone, capped.Get("id-token"))
+	assert.Equal(t, permissionNone, capped.Get("packages"))
+
+	// the lower access level is used for each scope, and the caller's one for the scopes not declared by the called workflow
+	capped = capPermissions(parse("read-all\n"), parse("contents: write\nissues: none\n"))
+	assert.Equal(t, permissionRead, capped.Get("contents"))
+	assert.Equal(t, permissionNone, capped.Get("issues"))
+	assert.Equal(t, permissionRead, capped.Get("packages"))
+
+	capped = capPermissions(parse("write-all\n"), parse("id-token: write\ncontents: read\n"))
+	assert.Equal(t, permissionWrite, capped.Get("id-token"))
+	assert.Equal(t, permissionRead, capped.Get("contents"))
+	assert.Equal(t, permissionWrite, capped.Get("packages"))
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// refuseRunOverQuota fails the jobs of a new run if its owner has used up the minutes quota of the month,
+// it returns the failed jobs, or nothing if the run could go on.
+func refuseRunOverQuota(ctx context.Context, run *actions_model.ActionRun) ([]*actions_model.ActionRunJob, error) {
+	exceeded, err := actions_model.IsMonthlyMinutesQuotaExceeded(ctx, run.OwnerID)
+	if err != nil || !exceeded {
+		return nil, err
+	}
+	log.Warn("The jobs of run %d of repo %d are refused because the owner %d has used up the actions minutes of the month", run.ID, run.RepoID, run.OwnerID)
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := timeutil.TimeStampNow()
+	refused := make([]*actions_model.ActionRunJob, 0, len(jobs))
+	for _, job := range jobs {
+		if job.Status.IsDone() {
+			continue
+		}
+		job.Run = run
+		job.Status = actions_model.StatusFailure
+		job.Stopped = now
+		if _, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped"); err != nil {
+			return nil, err
+		}
+		refused = append(refused, job)
+	}
+	return refused, nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	repo_model "code.gitea.io/gitea/models/repo"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/nektos/act/pkg/jobparser"
+)
+
+// readRequiredWorkflowContent reads the content of the required workflow from its central repository
+func readRequiredWorkflowContent(ctx context.Context, rw *actions_model.ActionRequiredWorkflow) ([]byte, error) {
+	if err := rw.LoadRepo(ctx); err != nil {
+		return nil, err
+	}
+	if rw.Repo.OwnerID != rw.OrgID {
+		// the central repository has been transferred to another owner
+		return nil, util.NewNotExistErrorf("repository %d is not owned by organization %d", rw.RepoID, rw.OrgID)
+	}
+
+	gitRepo, err := gitrepo.OpenRepository(ctx, rw.Repo)
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	ref := rw.Ref
+	if ref == "" {
+		ref = rw.Repo.DefaultBranch
+	}
+	commit, err := gitRepo.GetBranchCommit(ref)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, util.NewNotExistErrorf("branch %q of repository %s doesn't exist", ref, rw.Repo.FullName())
+		}
+		return nil, err
+	}
+	entry, err := commit.GetTreeEntryByPath(rw.WorkflowPath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, util.NewNotExistErrorf("workflow %q doesn't exist in branch %q of repository %s", rw.WorkflowPath, ref, rw.Repo.FullName())
+		}
+		return nil, err
+	}
+	return actions_module.GetContentFromEntry(entry)
+}
+
+// PrepareRequiredWorkflow checks the workflow file of the required workflow and fills its workflow name,
+// it should be called before the required workflow is created or updated.
+func PrepareRequiredWorkflow(ctx context.Context, rw *actions_model.ActionRequiredWorkflow) error {
+	if err := actions_model.ValidateRequiredWorkflowPath(rw.WorkflowPath); err != nil {
+		return err
+	}
+	content, err := readRequiredWorkflowContent(ctx, rw)
+	if err != nil {
+		if errors.Is(err, util.ErrNotExist) {
+			return util.NewInvalidArgumentErrorf("%v", err)
+		}
+		return err
+	}
+	if _, err := actions_module.GetEventsFromContent(content); err != nil {
+		return util.NewInvalidArgumentErrorf("invalid workflow %q: %v", rw.WorkflowPath, err)
+	}
+
+	// keep the same as the run name of the commit status contexts, see createCommitStatus
+	rw.WorkflowName = path.Base(rw.RunWorkflowID())
+	if wfs, err := jobparser.Parse(content); err == nil && len(wfs) > 0 {
+		rw.WorkflowName = wfs[0].Name
+	}
+	return nil
+}
+
+// loadRequiredWorkflows returns the required workflows of the organization which should run on the repository,
+// the workflows which can't be read are ignored so they won't block the workflows of the repository.
+func loadRequiredWorkflows(ctx context.Context, repo *repo_model.Repository) ([]*actions_module.RequiredWorkflow, error) {
+	rws, err := actions_model.GetRequiredWorkflowsForRepo(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("GetRequiredWorkflowsForRepo: %w", err)
+	}
+
+	ret := make([]*actions_module.RequiredWorkflow, 0, len(rws))
+	for _, rw := range rws {
+		content, err := readRequiredWorkflowContent(ctx, rw)
+		if err != nil {
+			log.Warn("ignore required workflow %d of organization %d: %v", rw.ID, rw.OrgID, err)
+			continue
+		}
+		ret = append(ret, &actions_module.RequiredWorkflow{
+			ID:        rw.ID,
+			EntryName: rw.RunWorkflowID(),
+			Content:   content,
+		})
+	}
+	return ret, nil
+}
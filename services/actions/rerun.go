@@ -4,15 +4,23 @@
 package actions
 
 import (
+	"context"
+
 	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
 	"code.gitea.io/gitea/modules/container"
+
+	"xorm.io/builder"
 )
 
-// GetAllRerunJobs get all jobs that need to be rerun when job should be rerun
+// GetAllRerunJobs get all jobs that need to be rerun when job should be rerun,
+// including the jobs of the workflows called by the rerun jobs
 func GetAllRerunJobs(job *actions_model.ActionRunJob, allJobs []*actions_model.ActionRunJob) []*actions_model.ActionRunJob {
 	rerunJobs := []*actions_model.ActionRunJob{job}
 	rerunJobsIDSet := make(container.Set[string])
 	rerunJobsIDSet.Add(job.JobID)
+	rerunCallerIDSet := make(container.Set[int64])
+	rerunCallerIDSet.Add(job.ID)
 
 	for {
 		found := false
@@ -20,11 +28,19 @@ func GetAllRerunJobs(job *actions_model.ActionRunJob, allJobs []*actions_model.A
 			if rerunJobsIDSet.Contains(j.JobID) {
 				continue
 			}
+			if j.CallerID > 0 && rerunCallerIDSet.Contains(j.CallerID) {
+				found = true
+				rerunJobs = append(rerunJobs, j)
+				rerunJobsIDSet.Add(j.JobID)
+				rerunCallerIDSet.Add(j.ID)
+				continue
+			}
 			for _, need := range j.Needs {
 				if rerunJobsIDSet.Contains(need) {
 					found = true
 					rerunJobs = append(rerunJobs, j)
 					rerunJobsIDSet.Add(j.JobID)
+					rerunCallerIDSet.Add(j.ID)
 					break
 				}
 			}
@@ -36,3 +52,124 @@ func GetAllRerunJobs(job *actions_model.ActionRunJob, allJobs []*actions_model.A
 
 	return rerunJobs
 }
+
+// RerunRun reruns the given job of a run and all jobs that depend on it.
+// If job is nil, all jobs of the run will be rerun.
+// If the job is in a called workflow, the job calling the workflow will be rerun instead.
+// The jobs must contain all jobs of the run.
+func RerunRun(ctx context.Context, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob, job *actions_model.ActionRunJob) error {
+	// reset run's start and stop time when it is done, and its completion will be notified again
+	if run.Status.IsDone() {
+		run.PreviousDuration = run.Duration()
+		run.Started = 0
+		run.Stopped = 0
+		run.DoneNotified = false
+		if err := actions_model.UpdateRun(ctx, run, "started", "stopped", "previous_duration", "done_notified"); err != nil {
+			return err
+		}
+	}
+
+	hasCalls := false
+	if job == nil { // rerun all jobs
+		for _, j := range jobs {
+			// if the job has needs, it should be set to "blocked" status to wait for other jobs,
+			// so do the jobs calling reusable workflows and the jobs of the called workflows, they will be emitted later
+			isCall := j.CallerID > 0 || isWorkflowCaller(j, jobs)
+			hasCalls = hasCalls || isCall
+			shouldBlock := len(j.Needs) > 0 || isCall
+			if err := rerunJob(ctx, j, shouldBlock); err != nil {
+				return err
+			}
+		}
+	} else {
+		job = getTopWorkflowCaller(job, jobs)
+		for _, j := range GetAllRerunJobs(job, jobs) {
+			// jobs other than the specified one should be set to "blocked" status
+			isCall := j.CallerID > 0 || isWorkflowCaller(j, jobs)
+			hasCalls = hasCalls || isCall
+			shouldBlock := j.JobID != job.JobID || isCall
+			if err := rerunJob(ctx, j, shouldBlock); err != nil {
+				return err
+			}
+		}
+	}
+
+	if hasCalls {
+		return EmitJobsIfReady(run.ID)
+	}
+	return nil
+}
+
+// getTopWorkflowCaller returns the top level job calling the workflow which defines the job,
+// or the job itself if it isn't in a called workflow
+func getTopWorkflowCaller(job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) *actions_model.ActionRunJob {
+	for job.CallerID > 0 {
+		found := false
+		for _, j := range jobs {
+			if j.ID == job.CallerID {
+				job, found = j, true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return job
+}
+
+func rerunJob(ctx context.Context, job *actions_model.ActionRunJob, shouldBlock bool) error {
+	status := job.Status
+	if !status.IsDone() {
+		return nil
+	}
+
+	job.TaskID = 0
+	job.Status = actions_model.StatusWaiting
+	if shouldBlock {
+		job.Status = actions_model.StatusBlocked
+	}
+	job.Started = 0
+	job.Stopped = 0
+	// the concurrency of the job should be evaluated again since the contexts could have been changed
+	job.IsConcurrencyEvaluated = false
+	// a new deployment will be created for the job targeting an environment
+	job.DeploymentID = 0
+
+	var cancelledJobs []*actions_model.ActionRunJob
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if !shouldBlock {
+			blocked, cancelled, err := checkJobConcurrency(ctx, job)
+			if err != nil {
+				return err
+			}
+			cancelledJobs = cancelled
+			if blocked {
+				job.Status = actions_model.StatusBlocked
+			}
+		}
+		_, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": status}, "task_id", "status", "started", "stopped", "is_concurrency_evaluated", "deployment_id")
+		if err != nil || job.Status != actions_model.StatusWaiting {
+			return err
+		}
+
+		envStatus, err := checkJobEnvironment(ctx, job)
+		if err != nil || envStatus == actions_model.StatusWaiting {
+			return err
+		}
+		job.Status = envStatus
+		_, err = actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusWaiting}, "status")
+		return err
+	}); err != nil {
+		return err
+	}
+
+	CreateCommitStatus(ctx, job)
+	notifyCancelledJobs(ctx, cancelledJobs)
+	if job.Status.IsDone() {
+		// the job has been failed or skipped by the protection rules of its environment
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+		return EmitJobsIfReady(job.RunID)
+	}
+	return nil
+}
@@ -46,3 +46,19 @@ func TestGetAllRerunJobs(t *testing.T) {
 		assert.ElementsMatch(t, tc.rerunJobs, rerunJobs)
 	}
 }
+
+func TestGetAllRerunJobsWithWorkflowCalls(t *testing.T) {
+	job1 := &actions_model.ActionRunJob{ID: 1, JobID: "job1"}
+	job2 := &actions_model.ActionRunJob{ID: 2, JobID: "job2", Needs: []string{"job1"}}
+	job21 := &actions_model.ActionRunJob{ID: 3, JobID: "job2/build", CallerID: 2}
+	job22 := &actions_model.ActionRunJob{ID: 4, JobID: "job2/test", Needs: []string{"job2/build"}, CallerID: 2}
+	job3 := &actions_model.ActionRunJob{ID: 5, JobID: "job3", Needs: []string{"job2"}}
+
+	jobs := []*actions_model.ActionRunJob{job1, job2, job21, job22, job3}
+
+	assert.ElementsMatch(t, []*actions_model.ActionRunJob{job1, job2, job21, job22, job3}, GetAllRerunJobs(job1, jobs))
+	assert.ElementsMatch(t, []*actions_model.ActionRunJob{job2, job21, job22, job3}, GetAllRerunJobs(job2, jobs))
+	assert.ElementsMatch(t, []*actions_model.ActionRunJob{job3}, GetAllRerunJobs(job3, jobs))
+	assert.Equal(t, job2, getTopWorkflowCaller(job22, jobs))
+	assert.Equal(t, job1, getTopWorkflowCaller(job1, jobs))
+}
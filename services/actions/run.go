@@ -0,0 +1,299 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/nektos/act/pkg/jobparser"
+	"xorm.io/builder"
+)
+
+// CancelRunJobs cancels all the jobs of a run which are not done yet
+func CancelRunJobs(ctx context.Context, jobs []*actions_model.ActionRunJob) error {
+	cancelledJobs, err := cancelJobs(ctx, jobs)
+	if err != nil {
+		return err
+	}
+
+	CreateCommitStatus(ctx, jobs...)
+	notifyCancelledJobs(ctx, cancelledJobs)
+	return nil
+}
+
+// notifyCancelledJobs notifies the status of the cancelled jobs and emits the jobs of their runs,
+// since the jobs or runs blocked by the concurrency groups of the cancelled jobs could be ready now,
+// and the completion of the runs should be notified.
+// It must be called after the transaction cancelling the jobs has been committed.
+func notifyCancelledJobs(ctx context.Context, jobs []*actions_model.ActionRunJob) {
+	runIDs := make(container.Set[int64])
+	for _, job := range jobs {
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+		runIDs.Add(job.RunID)
+	}
+	for runID := range runIDs {
+		if err := EmitJobsIfReady(runID); err != nil {
+			log.Error("EmitJobsIfReady: %v", err)
+		}
+	}
+}
+
+func cancelJobs(ctx context.Context, jobs []*actions_model.ActionRunJob) ([]*actions_model.ActionRunJob, error) {
+	cancelledJobs := make([]*actions_model.ActionRunJob, 0, len(jobs))
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		for _, job := range jobs {
+			status := job.Status
+			if status.IsDone() {
+				continue
+			}
+			if job.TaskID == 0 {
+				job.Status = actions_model.StatusCancelled
+				job.Stopped = timeutil.TimeStampNow()
+				n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"task_id": 0}, "status", "stopped")
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					return fmt.Errorf("job has changed, try again")
+				}
+				cancelledJobs = append(cancelledJobs, job)
+				continue
+			}
+			if err := actions_model.StopTask(ctx, job.TaskID, actions_model.StatusCancelled); err != nil {
+				return err
+			}
+			cancelledJobs = append(cancelledJobs, job)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	for _, job := range cancelledJobs {
+		if job.TaskID > 0 {
+			// the job has been updated by stopping its task
+			job.Status = actions_model.StatusCancelled
+		}
+	}
+	return cancelledJobs, nil
+}
+
+// ApproveRun approves a run which needs approval and unblocks its jobs without needs
+func ApproveRun(ctx context.Context, run *actions_model.ActionRun, jobs []*actions_model.ActionRunJob, doerID int64) error {
+	needEmit := false
+	var cancelledJobs, doneJobs []*actions_model.ActionRunJob
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		run.NeedApproval = false
+		run.ApprovedBy = doerID
+		if err := actions_model.UpdateRun(ctx, run, "need_approval", "approved_by"); err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			// the jobs of called workflows will be emitted after their callers have been started
+			if len(job.Needs) == 0 && job.CallerID == 0 && job.Status.IsBlocked() {
+				blocked, cancelled, err := checkJobConcurrency(ctx, job)
+				if err != nil {
+					return err
+				}
+				cancelledJobs = append(cancelledJobs, cancelled...)
+				if blocked {
+					continue
+				}
+				if isWorkflowCaller(job, jobs) {
+					if err := startWorkflowCall(ctx, job); err != nil {
+						return err
+					}
+					needEmit = true
+					continue
+				}
+				status, err := checkJobEnvironment(ctx, job)
+				if err != nil {
+					return err
+				}
+				if status == actions_model.StatusBlocked {
+					continue
+				}
+				job.Status = status
+				_, err = actions_model.UpdateRunJob(ctx, job, nil, "status")
+				if err != nil {
+					return err
+				}
+				if status.IsDone() {
+					doneJobs = append(doneJobs, job)
+					needEmit = true
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	CreateCommitStatus(ctx, jobs...)
+	for _, job := range doneJobs {
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+	}
+	notifyCancelledJobs(ctx, cancelledJobs)
+	if needEmit {
+		return EmitJobsIfReady(run.ID)
+	}
+	return nil
+}
+
+// InsertRun inserts a run and its jobs parsed from the content of the workflow,
+// the jobs calling reusable workflows are expanded, and the runs and jobs in the same concurrency groups are handled as well.
+func InsertRun(ctx context.Context, run *actions_model.ActionRun, content []byte, jobs []*jobparser.SingleWorkflow) error {
+	runConcurrency, jobConcurrencies, err := parseRawConcurrency(content)
+	if err != nil {
+		return fmt.Errorf("parseRawConcurrency: %w", err)
+	}
+	run.RawConcurrency = runConcurrency
+	jobEnvironments, err := parseRawEnvironments(content)
+	if err != nil {
+		return fmt.Errorf("parseRawEnvironments: %w", err)
+	}
+	_, jobPermissions, err := parseRawPermissions(content)
+	if err != nil {
+		return fmt.Errorf("parseRawPermissions: %w", err)
+	}
+
+	var callers []int
+	if hasWorkflowCalls(jobs) {
+		vars, err := actions_model.GetVariablesOfRun(ctx, run)
+		if err != nil {
+			return fmt.Errorf("GetVariablesOfRun: %w", err)
+		}
+		if jobs, callers, err = expandWorkflowCalls(ctx, run, jobs, vars, jobConcurrencies, jobEnvironments, jobPermissions); err != nil {
+			return fmt.Errorf("expandWorkflowCalls: %w", err)
+		}
+	}
+
+	needEmit := false
+	var cancelledJobs, doneJobs []*actions_model.ActionRunJob
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if err := actions_model.InsertRun(ctx, run, jobs); err != nil {
+			return err
+		}
+		if refused, err := refuseRunOverQuota(ctx, run); err != nil {
+			return err
+		} else if len(refused) > 0 {
+			doneJobs = append(doneJobs, refused...)
+			return nil
+		}
+		if run.RawConcurrency == "" && len(jobConcurrencies) == 0 && len(jobEnvironments) == 0 && len(jobPermissions) == 0 && len(callers) == 0 {
+			return nil
+		}
+
+		// the run should be updated before its jobs, since updating jobs changes the version of the run
+		runBlocked, cancelled, err := checkRunConcurrency(ctx, run)
+		if err != nil {
+			return err
+		}
+		cancelledJobs = append(cancelledJobs, cancelled...)
+		runJobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+		if err != nil {
+			return err
+		}
+		for i, job := range runJobs {
+			job.Run = run
+			var cols []string
+			if raw, ok := jobConcurrencies[job.JobID]; ok {
+				job.RawConcurrency = raw
+				cols = append(cols, "raw_concurrency")
+			}
+			if raw, ok := jobEnvironments[job.JobID]; ok {
+				job.RawEnvironment = raw
+				cols = append(cols, "raw_environment")
+			}
+			if raw, ok := jobPermissions[job.JobID]; ok {
+				job.RawPermissions = raw
+				cols = append(cols, "raw_permissions")
+			}
+			if len(callers) > 0 && callers[i] >= 0 {
+				job.CallerID = runJobs[callers[i]].ID
+				cols = append(cols, "caller_id")
+				// the called jobs wait for their caller
+				if job.Status == actions_model.StatusWaiting {
+					job.Status = actions_model.StatusBlocked
+					cols = append(cols, "status")
+				}
+			}
+			if len(cols) > 0 {
+				if _, err := actions_model.UpdateRunJob(ctx, job, nil, cols...); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, job := range runJobs {
+			if !job.Status.IsWaiting() {
+				continue
+			}
+			blocked := runBlocked
+			if !blocked {
+				if blocked, cancelled, err = checkJobConcurrency(ctx, job); err != nil {
+					return err
+				}
+				cancelledJobs = append(cancelledJobs, cancelled...)
+			}
+			if blocked {
+				job.Status = actions_model.StatusBlocked
+				if _, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusWaiting}, "status"); err != nil {
+					return err
+				}
+				continue
+			}
+			if isWorkflowCaller(job, runJobs) {
+				if err := startWorkflowCall(ctx, job); err != nil {
+					return err
+				}
+				needEmit = true
+				continue
+			}
+			status, err := checkJobEnvironment(ctx, job)
+			if err != nil {
+				return err
+			}
+			if status != actions_model.StatusWaiting {
+				job.Status = status
+				if _, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": actions_model.StatusWaiting}, "status"); err != nil {
+					return err
+				}
+				if status.IsDone() {
+					doneJobs = append(doneJobs, job)
+					// the jobs needing the failed or skipped job could be ready now
+					needEmit = true
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, job := range doneJobs {
+		NotifyWorkflowJobStatusUpdate(ctx, job)
+	}
+	notifyCancelledJobs(ctx, cancelledJobs)
+	if needEmit {
+		return EmitJobsIfReady(run.ID)
+	}
+	return nil
+}
+
+func hasWorkflowCalls(jobs []*jobparser.SingleWorkflow) bool {
+	for _, swf := range jobs {
+		if _, job := swf.Job(); job != nil && job.Uses != "" {
+			return true
+		}
+	}
+	return false
+}
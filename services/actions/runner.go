@@ -0,0 +1,138 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/google/uuid"
+)
+
+// staleEphemeralRunnerTime is the time after which an ephemeral runner which has never been online or has gone offline is deleted
+const staleEphemeralRunnerTime = 24 * time.Hour
+
+// JITRunnerOptions represents the options to create a just-in-time runner
+type JITRunnerOptions struct {
+	OwnerID int64
+	RepoID  int64
+	Name    string
+	Labels  []string
+	JobID   int64 // the waiting job to bind the runner to, optional
+	GroupID int64 // the runner group of an org level runner, optional
+}
+
+// jitRunnerConfig is the registration file of act_runner, the runner could start without registering with it
+type jitRunnerConfig struct {
+	ID        int64    `json:"id"`
+	UUID      string   `json:"uuid"`
+	Name      string   `json:"name"`
+	Token     string   `json:"token"`
+	Address   string   `json:"address"`
+	Labels    []string `json:"labels"`
+	Ephemeral bool     `json:"ephemeral"`
+}
+
+// CreateJITRunner creates an ephemeral runner which is registered in advance, and returns it with its encoded registration file.
+// It returns ErrInvalidArgument if the job or the runner group is not available in the scope of the runner.
+func CreateJITRunner(ctx context.Context, opts JITRunnerOptions) (*actions_model.ActionRunner, string, error) {
+	if opts.OwnerID != 0 && opts.RepoID != 0 {
+		opts.OwnerID = 0
+	}
+
+	labels := opts.Labels
+	var job *actions_model.ActionRunJob
+	if opts.JobID > 0 {
+		var err error
+		job, err = actions_model.GetRunJobByID(ctx, opts.JobID)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return nil, "", err
+		}
+		// the job should be in the scope of the runner
+		if err != nil || (opts.RepoID > 0 && job.RepoID != opts.RepoID) || (opts.OwnerID > 0 && job.OwnerID != opts.OwnerID) {
+			return nil, "", util.NewInvalidArgumentErrorf("job %d does not exist", opts.JobID)
+		}
+		if job.Status != actions_model.StatusWaiting || job.TaskID != 0 {
+			return nil, "", util.NewInvalidArgumentErrorf("job %d is not waiting for a runner", opts.JobID)
+		}
+		if len(labels) == 0 {
+			labels = job.RunsOn
+		}
+	}
+	if len(labels) == 0 {
+		return nil, "", util.NewInvalidArgumentErrorf("the labels of the runner are required")
+	}
+
+	if opts.GroupID > 0 {
+		if opts.OwnerID == 0 {
+			return nil, "", util.NewInvalidArgumentErrorf("only organization runners could be added to runner groups")
+		}
+		group, err := actions_model.GetRunnerGroupByID(ctx, opts.OwnerID, opts.GroupID)
+		if errors.Is(err, util.ErrNotExist) {
+			return nil, "", util.NewInvalidArgumentErrorf("runner group %d does not exist", opts.GroupID)
+		} else if err != nil {
+			return nil, "", err
+		}
+		if job != nil {
+			if err := job.LoadAttributes(ctx); err != nil {
+				return nil, "", err
+			}
+			if !group.CanRunJob(job) {
+				return nil, "", util.NewInvalidArgumentErrorf("job %d is not allowed to use the runners of group %q", opts.JobID, group.Name)
+			}
+		}
+	}
+
+	name, _ := util.SplitStringAtByteN(opts.Name, 255)
+	runner := &actions_model.ActionRunner{
+		UUID:        gouuid.New().String(),
+		Name:        name,
+		OwnerID:     opts.OwnerID,
+		RepoID:      opts.RepoID,
+		AgentLabels: labels,
+		Ephemeral:   true,
+		JobID:       opts.JobID,
+		GroupID:     opts.GroupID,
+	}
+	if err := runner.GenerateToken(); err != nil {
+		return nil, "", err
+	}
+	if err := actions_model.CreateRunner(ctx, runner); err != nil {
+		return nil, "", err
+	}
+
+	config, err := json.Marshal(&jitRunnerConfig{
+		ID:        runner.ID,
+		UUID:      runner.UUID,
+		Name:      runner.Name,
+		Token:     runner.Token,
+		Address:   setting.AppURL,
+		Labels:    runner.AgentLabels,
+		Ephemeral: true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return runner, base64.StdEncoding.EncodeToString(config), nil
+}
+
+// CleanupEphemeralRunners removes the ephemeral runners which have never come online or have gone offline for a long time,
+// like the ones whose machines failed to start or were destroyed before running a task.
+func CleanupEphemeralRunners(ctx context.Context) error {
+	n, err := actions_model.DeleteStaleEphemeralRunners(ctx, timeutil.TimeStamp(time.Now().Add(-staleEphemeralRunnerTime).Unix()))
+	if err != nil {
+		return err
+	}
+	log.Info("Removed %d stale ephemeral runners", n)
+	return nil
+}
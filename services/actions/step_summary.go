@@ -0,0 +1,62 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"context"
+	"html/template"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+)
+
+// StepSummary is the summary of a step of a task with the markdown rendered to HTML
+type StepSummary struct {
+	StepIndex    int64
+	StepName     string
+	Content      string
+	RenderedHTML template.HTML
+}
+
+// GetTaskStepSummaries returns the rendered summaries of the steps of the task, the links in them are relative to the repository
+func GetTaskStepSummaries(ctx context.Context, repo *repo_model.Repository, task *actions_model.ActionTask) ([]*StepSummary, error) {
+	summaries, err := actions_model.FindTaskStepSummariesByTaskID(ctx, task.ID)
+	if err != nil || len(summaries) == 0 {
+		return nil, err
+	}
+	steps, err := actions_model.GetTaskStepsByTaskID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]*StepSummary, 0, len(summaries))
+	for _, s := range summaries {
+		if s.Content == "" {
+			continue
+		}
+		rendered, err := markdown.RenderString(&markup.RenderContext{
+			Ctx: ctx,
+			Links: markup.Links{
+				Base: repo.Link(),
+			},
+			Metas: repo.ComposeDocumentMetas(ctx),
+			Repo:  repo,
+		}, s.Content)
+		if err != nil {
+			return nil, err
+		}
+		summary := &StepSummary{
+			StepIndex:    s.StepIndex,
+			Content:      s.Content,
+			RenderedHTML: rendered,
+		}
+		if s.StepIndex >= 0 && s.StepIndex < int64(len(steps)) {
+			summary.StepName = steps[s.StepIndex].Name
+		}
+		ret = append(ret, summary)
+	}
+	return ret, nil
+}
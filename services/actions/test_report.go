@@ -0,0 +1,223 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+const (
+	// maxTestReportSize is the max size of a test report file, and the max size of a zipped artifact to look for test reports in
+	maxTestReportSize = 32 << 20
+	// maxTestCaseDetailsLength is the max length of the failure details of a test case to store
+	maxTestCaseDetailsLength = 16 << 10
+)
+
+// isTestReportFile returns whether the file in an artifact could be a test report, only JUnit XML reports are supported
+func isTestReportFile(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".xml")
+}
+
+// ParseArtifactTestReports looks for JUnit test reports in an uploaded artifact and stores them with the run of the artifact.
+// The artifacts uploaded by actions/upload-artifact@v4 are zip files, and the ones uploaded by the older versions are stored file by file.
+func ParseArtifactTestReports(ctx context.Context, artifact *actions_model.ActionArtifact) error {
+	isZip := artifact.ContentEncoding == "application/zip"
+	if !isZip && !isTestReportFile(artifact.ArtifactPath) {
+		return nil
+	}
+	if artifact.FileCompressedSize > maxTestReportSize {
+		log.Debug("[artifact] artifact %d is too large to look for test reports", artifact.ID)
+		return nil
+	}
+
+	f, err := storage.ActionsArtifacts.Open(artifact.StoragePath)
+	if err != nil {
+		return fmt.Errorf("open artifact: %w", err)
+	}
+	defer f.Close()
+
+	var reports []*actions_model.ActionTestReport
+	if isZip {
+		content, err := io.ReadAll(io.LimitReader(f, maxTestReportSize))
+		if err != nil {
+			return fmt.Errorf("read artifact: %w", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return fmt.Errorf("open zip: %w", err)
+		}
+		for _, file := range zr.File {
+			if file.FileInfo().IsDir() || !isTestReportFile(file.Name) || file.UncompressedSize64 > maxTestReportSize {
+				continue
+			}
+			rc, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("open %s: %w", file.Name, err)
+			}
+			report, err := parseTestReport(path.Join(artifact.ArtifactName, file.Name), rc)
+			_ = rc.Close()
+			if err != nil {
+				return err
+			} else if report != nil {
+				reports = append(reports, report)
+			}
+		}
+	} else {
+		var r io.Reader = f
+		if artifact.ContentEncoding == "gzip" {
+			gr, err := gzip.NewReader(f)
+			if err != nil {
+				return fmt.Errorf("open gzip: %w", err)
+			}
+			defer gr.Close()
+			r = gr
+		}
+		report, err := parseTestReport(artifact.ArtifactPath, r)
+		if err != nil {
+			return err
+		} else if report != nil {
+			reports = append(reports, report)
+		}
+	}
+
+	return actions_model.ReplaceTestReports(ctx, artifact, reports)
+}
+
+// parseTestReport parses a JUnit test report, it returns nil if the file is not a JUnit test report
+func parseTestReport(name string, r io.Reader) (*actions_model.ActionTestReport, error) {
+	cases, err := actions_module.ParseJUnitReport(io.LimitReader(r, maxTestReportSize))
+	if errors.Is(err, actions_module.ErrNotJUnitReport) {
+		log.Debug("[artifact] %s is not a test report: %v", name, err)
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+
+	report := &actions_model.ActionTestReport{
+		Name:  name,
+		Cases: make([]*actions_model.ActionTestCase, 0, len(cases)),
+	}
+	for _, c := range cases {
+		tc := &actions_model.ActionTestCase{
+			Suite:     base.TruncateString(c.Suite, 255),
+			ClassName: base.TruncateString(c.ClassName, 255),
+			Name:      c.Name,
+			Status:    actions_model.TestCaseStatusPassed,
+			Duration:  c.Duration,
+			Message:   c.Message,
+			Details:   base.TruncateString(c.Details, maxTestCaseDetailsLength),
+		}
+		report.Total++
+		report.Duration += c.Duration
+		switch {
+		case c.Failed:
+			tc.Status = actions_model.TestCaseStatusFailed
+			report.Failed++
+		case c.Skipped:
+			tc.Status = actions_model.TestCaseStatusSkipped
+			report.Skipped++
+		default:
+			report.Passed++
+		}
+		report.Cases = append(report.Cases, tc)
+	}
+	return report, nil
+}
+
+// RunTestResults represents the test results of a run
+type RunTestResults struct {
+	Run *actions_model.ActionRun
+	actions_model.TestCounts
+	Reports     []*actions_model.ActionTestReport
+	FailedCases []*actions_model.ActionTestCase
+	Flaky       container.Set[string]
+}
+
+// IsFlaky returns whether the failed test case is flaky
+func (r *RunTestResults) IsFlaky(c *actions_model.ActionTestCase) bool {
+	return r.Flaky.Contains(c.Key())
+}
+
+// GetRunTestResults returns the test results of the run, it returns nil if there is no test report in the run.
+// At most maxFailedCases failed test cases are loaded.
+func GetRunTestResults(ctx context.Context, run *actions_model.ActionRun, maxFailedCases int) (*RunTestResults, error) {
+	reports, err := db.Find[actions_model.ActionTestReport](ctx, actions_model.FindTestReportsOptions{
+		RepoID: run.RepoID,
+		RunIDs: []int64{run.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+
+	results := &RunTestResults{
+		Run:        run,
+		TestCounts: actions_model.SumTestReports(reports),
+		Reports:    reports,
+	}
+	if results.Failed > 0 {
+		results.FailedCases, err = db.Find[actions_model.ActionTestCase](ctx, actions_model.FindTestCasesOptions{
+			ListOptions: db.ListOptions{PageSize: maxFailedCases},
+			RunIDs:      []int64{run.ID},
+			Status:      actions_model.TestCaseStatusFailed,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	results.Flaky, err = actions_model.GetFlakyTestCaseKeys(ctx, run, results.FailedCases)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetCommitTestResults returns the test results of the latest run of each workflow triggered by the commit
+func GetCommitTestResults(ctx context.Context, repoID int64, sha string, maxFailedCases int) ([]*RunTestResults, error) {
+	runs, err := db.Find[actions_model.ActionRun](ctx, actions_model.FindRunOptions{
+		RepoID:    repoID,
+		CommitSHA: sha,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the runs are in descending order of id
+	workflows := make(container.Set[string])
+	var results []*RunTestResults
+	for _, run := range runs {
+		if !workflows.Add(run.WorkflowID) {
+			continue
+		}
+		result, err := GetRunTestResults(ctx, run, maxFailedCases)
+		if err != nil {
+			return nil, err
+		} else if result == nil {
+			continue
+		}
+		if err := run.LoadRepo(ctx); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
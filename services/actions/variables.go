@@ -31,6 +31,19 @@ func CreateVariable(ctx context.Context, ownerID, repoID int64, name, data strin
 	return v, nil
 }
 
+// CreateEnvironmentVariable creates a variable of an environment of the repository
+func CreateEnvironmentVariable(ctx context.Context, repoID, environmentID int64, name, data string) (*actions_model.ActionVariable, error) {
+	if err := secret_service.ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	if err := envNameCIRegexMatch(name); err != nil {
+		return nil, err
+	}
+
+	return actions_model.InsertEnvironmentVariable(ctx, repoID, environmentID, name, util.ReserveLineBreakForTextarea(data))
+}
+
 func UpdateVariable(ctx context.Context, variableID int64, name, data string) (bool, error) {
 	if err := secret_service.ValidateName(name); err != nil {
 		return false, err
@@ -0,0 +1,565 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"gopkg.in/yaml.v3"
+	"xorm.io/builder"
+)
+
+// maxWorkflowCallDepth is the max depth of nested reusable workflows,
+// see https://docs.github.com/en/actions/sharing-automations/reusing-workflows#nesting-reusable-workflows
+const maxWorkflowCallDepth = 4
+
+// expandWorkflowCalls expands the jobs which call reusable workflows.
+// A caller job is kept in the returned jobs, and it's followed by the jobs of the called workflow,
+// whose ids are prefixed by the id of the caller, like "caller/build".
+// It also returns the index of the caller of each returned job, -1 if the job isn't in a called workflow,
+// and the raw job level concurrency, environment and permissions of the called jobs are added to concurrencies, environments and permissions.
+func expandWorkflowCalls(ctx context.Context, run *actions_model.ActionRun, jobs []*jobparser.SingleWorkflow, vars, concurrencies, environments, permissions map[string]string) ([]*jobparser.SingleWorkflow, []int, error) {
+	e := &workflowCallExpander{
+		run:           run,
+		vars:          vars,
+		concurrencies: concurrencies,
+		environments:  environments,
+		permissions:   permissions,
+	}
+	if err := e.expand(ctx, jobs, -1, 0); err != nil {
+		return nil, nil, err
+	}
+	return e.jobs, e.callers, nil
+}
+
+type workflowCallExpander struct {
+	run           *actions_model.ActionRun
+	vars          map[string]string
+	concurrencies map[string]string
+	environments  map[string]string
+	permissions   map[string]string
+
+	jobs    []*jobparser.SingleWorkflow
+	callers []int
+}
+
+func (e *workflowCallExpander) expand(ctx context.Context, jobs []*jobparser.SingleWorkflow, caller, depth int) error {
+	prefix, namePrefix := "", ""
+	if caller >= 0 {
+		callerID, callerJob := e.jobs[caller].Job()
+		prefix = callerID + "/"
+		namePrefix = callerJob.Name + " / "
+	}
+
+	for _, swf := range jobs {
+		id, job := swf.Job()
+		if prefix != "" {
+			needs := job.Needs()
+			for i, need := range needs {
+				needs[i] = prefix + need
+			}
+			if len(needs) > 0 {
+				if err := job.RawNeeds.Encode(needs); err != nil {
+					return err
+				}
+			}
+			job.Name = namePrefix + job.Name
+			if err := swf.SetJob(prefix+id, job); err != nil {
+				return fmt.Errorf("SetJob: %w", err)
+			}
+		}
+		e.jobs = append(e.jobs, swf)
+		e.callers = append(e.callers, caller)
+
+		if job.Uses == "" {
+			continue
+		}
+		if _, err := (&model.Job{Uses: job.Uses}).Type(); err != nil {
+			return err
+		}
+		if depth >= maxWorkflowCallDepth {
+			return fmt.Errorf("job %q: reusable workflows can only be nested %d levels deep", prefix+id, maxWorkflowCallDepth)
+		}
+
+		content, err := getCalledWorkflowContent(ctx, e.run, job.Uses)
+		if err != nil {
+			return fmt.Errorf("job %q: %w", prefix+id, err)
+		}
+		calledJobs, err := jobparser.Parse(content, jobparser.WithVars(e.vars))
+		if err != nil {
+			return fmt.Errorf("job %q: parse %s: %w", prefix+id, job.Uses, err)
+		}
+		if !isWorkflowCallable(calledJobs) {
+			return fmt.Errorf("job %q: %s isn't triggered by %s", prefix+id, job.Uses, actions_module.GithubEventWorkflowCall)
+		}
+		_, calledConcurrencies, err := parseRawConcurrency(content)
+		if err != nil {
+			return fmt.Errorf("job %q: parseRawConcurrency: %w", prefix+id, err)
+		}
+		for k, v := range calledConcurrencies {
+			e.concurrencies[prefix+id+"/"+k] = v
+		}
+		calledEnvironments, err := parseRawEnvironments(content)
+		if err != nil {
+			return fmt.Errorf("job %q: parseRawEnvironments: %w", prefix+id, err)
+		}
+		for k, v := range calledEnvironments {
+			e.environments[prefix+id+"/"+k] = v
+		}
+		_, calledPermissions, err := parseRawPermissions(content)
+		if err != nil {
+			return fmt.Errorf("job %q: parseRawPermissions: %w", prefix+id, err)
+		}
+		if err := e.addCalledPermissions(ctx, prefix+id, calledJobs, calledPermissions); err != nil {
+			return fmt.Errorf("job %q: %w", prefix+id, err)
+		}
+
+		if err := e.expand(ctx, calledJobs, len(e.jobs)-1, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addCalledPermissions adds the raw permissions of the called jobs, the called jobs without permissions inherit the permissions of the caller,
+// and the permissions declared by the called workflow are capped at the ones of the caller, so a reusable workflow can't escalate them.
+func (e *workflowCallExpander) addCalledPermissions(ctx context.Context, callerID string, calledJobs []*jobparser.SingleWorkflow, calledPermissions map[string]string) error {
+	var callerPermissions *permissionsConfig
+	for _, calledJob := range calledJobs {
+		k, _ := calledJob.Job()
+		raw, ok := calledPermissions[k]
+		if !ok {
+			if v, ok := e.permissions[callerID]; ok {
+				e.permissions[callerID+"/"+k] = v
+			}
+			continue
+		}
+
+		if callerPermissions == nil {
+			var err error
+			if callerPermissions, err = e.getPermissions(ctx, callerID); err != nil {
+				return err
+			}
+		}
+		called := &permissionsConfig{}
+		if err := yaml.Unmarshal([]byte(raw), called); err != nil {
+			return fmt.Errorf("unmarshal permissions of %q: %w", k, err)
+		}
+		capped, err := yaml.Marshal(capPermissions(callerPermissions, called).Scopes)
+		if err != nil {
+			return err
+		}
+		e.permissions[callerID+"/"+k] = string(capped)
+	}
+	return nil
+}
+
+// getPermissions returns the permissions of the job, the default permissions of the repository are used if it doesn't declare them
+func (e *workflowCallExpander) getPermissions(ctx context.Context, jobID string) (*permissionsConfig, error) {
+	raw, ok := e.permissions[jobID]
+	if !ok {
+		if err := e.run.LoadRepo(ctx); err != nil {
+			return nil, err
+		}
+		return getDefaultPermissions(ctx, e.run.Repo)
+	}
+	p := &permissionsConfig{}
+	if err := yaml.Unmarshal([]byte(raw), p); err != nil {
+		return nil, fmt.Errorf("unmarshal permissions of %q: %w", jobID, err)
+	}
+	return p, nil
+}
+
+func isWorkflowCallable(jobs []*jobparser.SingleWorkflow) bool {
+	if len(jobs) == 0 {
+		return false
+	}
+	// jobparser.ParseRawOn doesn't support the inputs of workflow_call, so only the event names are checked here
+	rawOn := &jobs[0].RawOn
+	var events []string
+	switch rawOn.Kind {
+	case yaml.ScalarNode:
+		events = []string{rawOn.Value}
+	case yaml.SequenceNode:
+		if err := rawOn.Decode(&events); err != nil {
+			return false
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(rawOn.Content); i += 2 {
+			events = append(events, rawOn.Content[i].Value)
+		}
+	}
+	return slices.Contains(events, actions_module.GithubEventWorkflowCall)
+}
+
+// getCalledWorkflowContent returns the content of the reusable workflow referenced by `uses`,
+// it could be "./.gitea/workflows/build.yml" in the repository of the run, or "owner/repo/.gitea/workflows/build.yml@ref".
+func getCalledWorkflowContent(ctx context.Context, run *actions_model.ActionRun, uses string) ([]byte, error) {
+	if err := run.LoadRepo(ctx); err != nil {
+		return nil, err
+	}
+
+	repo, ref, treePath := run.Repo, run.CommitSHA, ""
+	if strings.HasPrefix(uses, "./") {
+		treePath = strings.TrimPrefix(uses, "./")
+	} else {
+		i := strings.LastIndex(uses, "@")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid reusable workflow %q", uses)
+		}
+		ref = uses[i+1:]
+		parts := strings.SplitN(uses[:i], "/", 3)
+		if len(parts) != 3 || ref == "" {
+			return nil, fmt.Errorf("invalid reusable workflow %q", uses)
+		}
+		treePath = parts[2]
+
+		if !strings.EqualFold(parts[0], run.Repo.OwnerName) || !strings.EqualFold(parts[1], run.Repo.Name) {
+			calledRepo, err := repo_model.GetRepositoryByOwnerAndName(ctx, parts[0], parts[1])
+			if err != nil {
+				if repo_model.IsErrRepoNotExist(err) {
+					return nil, fmt.Errorf("reusable workflow %q: repository not found", uses)
+				}
+				return nil, err
+			}
+			if ok, err := canCallWorkflowsOf(ctx, run.Repo, calledRepo); err != nil {
+				return nil, err
+			} else if !ok {
+				// don't tell whether the repository exists
+				return nil, fmt.Errorf("reusable workflow %q: repository not found", uses)
+			}
+			repo = calledRepo
+		}
+	}
+
+	treePath = path.Clean(treePath)
+	if dir := path.Dir(treePath); dir != ".gitea/workflows" && dir != ".github/workflows" {
+		return nil, fmt.Errorf("reusable workflow %q should be in .gitea/workflows or .github/workflows", uses)
+	}
+
+	gitRepo, err := gitrepo.OpenRepository(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %w", err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(ref)
+	if err != nil {
+		return nil, fmt.Errorf("reusable workflow %q: GetCommit: %w", uses, err)
+	}
+	content, err := commit.GetFileContent(treePath, 0)
+	if err != nil {
+		return nil, fmt.Errorf("reusable workflow %q: GetFileContent: %w", uses, err)
+	}
+	return []byte(content), nil
+}
+
+// canCallWorkflowsOf returns whether the workflows of the repository could call the reusable workflows of another repository.
+// The called repository should be public or belong to the same owner, and its code should be available.
+func canCallWorkflowsOf(ctx context.Context, repo, calledRepo *repo_model.Repository) (bool, error) {
+	if err := calledRepo.LoadOwner(ctx); err != nil {
+		return false, err
+	}
+	if calledRepo.OwnerID != repo.OwnerID && (calledRepo.IsPrivate || !calledRepo.Owner.Visibility.IsPublic()) {
+		return false, nil
+	}
+	return calledRepo.UnitEnabled(ctx, unit.TypeCode), nil
+}
+
+// startWorkflowCall starts a job which calls a reusable workflow when it's ready to run.
+// The job won't be picked by runners, instead, its "if" condition is evaluated here,
+// and then the jobs of the called workflow are emitted or skipped according to the result.
+func startWorkflowCall(ctx context.Context, job *actions_model.ActionRunJob) error {
+	if err := job.LoadRun(ctx); err != nil {
+		return err
+	}
+	wfJob, err := readWorkflowJob(job)
+	if err != nil {
+		return err
+	}
+	interpreter, err := newInterpreter(ctx, job.Run, job)
+	if err != nil {
+		return err
+	}
+	ok, err := evaluateCondition(interpreter, wfJob.If.Value)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", job.JobID, err)
+	}
+
+	oldStatus := job.Status
+	if ok {
+		job.Status = actions_model.StatusRunning
+		job.Started = timeutil.TimeStampNow()
+	} else {
+		job.Status = actions_model.StatusSkipped
+	}
+	n, err := actions_model.UpdateRunJob(ctx, job, builder.Eq{"status": oldStatus}, "status", "started")
+	if err != nil {
+		return err
+	} else if n != 1 {
+		return fmt.Errorf("no affected for starting workflow call job %v", job.ID)
+	}
+	return nil
+}
+
+// finishWorkflowCalls marks the running jobs which call reusable workflows as done when all jobs of the called workflows are done,
+// it returns whether any job has been updated.
+func finishWorkflowCalls(ctx context.Context, jobs []*actions_model.ActionRunJob) (bool, error) {
+	updated := false
+	for _, caller := range jobs {
+		if caller.Status != actions_model.StatusRunning || caller.TaskID != 0 {
+			continue
+		}
+		hasCalled, allDone := false, true
+		status := actions_model.StatusSuccess
+		for _, job := range jobs {
+			if job.CallerID != caller.ID {
+				continue
+			}
+			hasCalled = true
+			switch {
+			case !job.Status.IsDone():
+				allDone = false
+			case job.Status == actions_model.StatusFailure:
+				status = actions_model.StatusFailure
+			case job.Status == actions_model.StatusCancelled && status == actions_model.StatusSuccess:
+				status = actions_model.StatusCancelled
+			}
+		}
+		if !hasCalled || !allDone {
+			continue
+		}
+		caller.Status = status
+		caller.Stopped = timeutil.TimeStampNow()
+		if _, err := actions_model.UpdateRunJob(ctx, caller, builder.Eq{"status": actions_model.StatusRunning}, "status", "stopped"); err != nil {
+			return false, err
+		}
+		updated = true
+	}
+	return updated, nil
+}
+
+// getWorkflowCallOutputs returns the outputs of a job which calls a reusable workflow,
+// they are evaluated from the outputs of the jobs of the called workflow.
+func getWorkflowCallOutputs(ctx context.Context, caller *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) (map[string]string, error) {
+	var called []*actions_model.ActionRunJob
+	for _, job := range jobs {
+		if job.CallerID == caller.ID {
+			called = append(called, job)
+		}
+	}
+	if len(called) == 0 {
+		return map[string]string{}, nil
+	}
+
+	workflow, err := model.ReadWorkflow(bytes.NewReader(called[0].WorkflowPayload))
+	if err != nil {
+		return nil, fmt.Errorf("ReadWorkflow: %w", err)
+	}
+	outputs := workflow.WorkflowCallConfig().Outputs
+	if len(outputs) == 0 {
+		return map[string]string{}, nil
+	}
+
+	results := make(map[string]*model.WorkflowCallResult, len(called))
+	for _, job := range called {
+		jobOutputs := map[string]string{}
+		if job.TaskID != 0 {
+			got, err := actions_model.FindTaskOutputByTaskID(ctx, job.TaskID)
+			if err != nil {
+				return nil, fmt.Errorf("FindTaskOutputByTaskID: %w", err)
+			}
+			for _, v := range got {
+				jobOutputs[v.OutputKey] = v.OutputValue
+			}
+		} else if job.Status == actions_model.StatusSuccess {
+			if jobOutputs, err = getWorkflowCallOutputs(ctx, job, jobs); err != nil {
+				return nil, err
+			}
+		}
+		results[strings.TrimPrefix(job.JobID, caller.JobID+"/")] = &model.WorkflowCallResult{Outputs: jobOutputs}
+	}
+
+	if err := caller.LoadRun(ctx); err != nil {
+		return nil, err
+	}
+	env, config, err := newEvaluationEnvironment(ctx, caller.Run, nil)
+	if err != nil {
+		return nil, err
+	}
+	if env.Inputs, err = GetWorkflowCallInputs(ctx, called[0]); err != nil {
+		return nil, err
+	}
+	env.Jobs = &results
+	return evaluateWorkflowCallOutputs(exprparser.NewInterpeter(env, config), outputs)
+}
+
+func evaluateWorkflowCallOutputs(interpreter exprparser.Interpreter, outputs map[string]model.WorkflowCallOutput) (map[string]string, error) {
+	ret := make(map[string]string, len(outputs))
+	for name, output := range outputs {
+		value, err := evaluateString(interpreter, output.Value)
+		if err != nil {
+			return nil, fmt.Errorf("output %q: %w", name, err)
+		}
+		ret[name] = value
+	}
+	return ret, nil
+}
+
+// GetWorkflowCallInputs returns the inputs of the called workflow which defines the job,
+// they are evaluated from the "with" of the caller job, or the default values. It returns nil if the job isn't in a called workflow.
+func GetWorkflowCallInputs(ctx context.Context, job *actions_model.ActionRunJob) (map[string]any, error) {
+	if job.CallerID == 0 {
+		return nil, nil
+	}
+	caller, err := actions_model.GetRunJobByID(ctx, job.CallerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := caller.LoadRun(ctx); err != nil {
+		return nil, err
+	}
+	callerJob, err := readWorkflowJob(caller)
+	if err != nil {
+		return nil, err
+	}
+	workflow, err := model.ReadWorkflow(bytes.NewReader(job.WorkflowPayload))
+	if err != nil {
+		return nil, fmt.Errorf("ReadWorkflow: %w", err)
+	}
+
+	// the inputs are evaluated in the context of the caller job
+	interpreter, err := newInterpreter(ctx, caller.Run, caller)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make(map[string]any)
+	for name, input := range workflow.WorkflowCallConfig().Inputs {
+		value, ok := callerJob.With[name]
+		if !ok {
+			if input.Required {
+				return nil, fmt.Errorf("job %q: input %q is required", caller.JobID, name)
+			}
+			inputs[name] = convertWorkflowCallInput(input.Type, input.Default)
+			continue
+		}
+
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return nil, err
+		}
+		if err := evaluateYamlNode(interpreter, &node); err != nil {
+			return nil, fmt.Errorf("job %q: input %q: %w", caller.JobID, name, err)
+		}
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return nil, err
+		}
+		if s, ok := v.(string); ok {
+			v = convertWorkflowCallInput(input.Type, s)
+		}
+		inputs[name] = v
+	}
+	return inputs, nil
+}
+
+func convertWorkflowCallInput(typ, value string) any {
+	switch typ {
+	case "boolean":
+		return value == "true"
+	case "number":
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return value
+}
+
+// GetWorkflowCallSecrets returns the secrets which could be used by the job,
+// the jobs of a called workflow can only use the secrets passed by the caller job, unless the caller uses "secrets: inherit".
+func GetWorkflowCallSecrets(ctx context.Context, job *actions_model.ActionRunJob, secrets map[string]string) (map[string]string, error) {
+	if job.CallerID == 0 {
+		return secrets, nil
+	}
+	caller, err := actions_model.GetRunJobByID(ctx, job.CallerID)
+	if err != nil {
+		return nil, err
+	}
+	// the secrets passed by the caller job are limited by the secrets of the caller itself
+	if secrets, err = GetWorkflowCallSecrets(ctx, caller, secrets); err != nil {
+		return nil, err
+	}
+	callerJob, err := readWorkflowJob(caller)
+	if err != nil {
+		return nil, err
+	}
+	if callerJob.InheritSecrets() {
+		return secrets, nil
+	}
+
+	ret := make(map[string]string)
+	// the automatically generated tokens are always available
+	for _, name := range []string{"GITHUB_TOKEN", "GITEA_TOKEN"} {
+		if v, ok := secrets[name]; ok {
+			ret[name] = v
+		}
+	}
+	if len(callerJob.Secrets()) == 0 {
+		return ret, nil
+	}
+
+	if err := caller.LoadRun(ctx); err != nil {
+		return nil, err
+	}
+	env, config, err := newEvaluationEnvironment(ctx, caller.Run, caller)
+	if err != nil {
+		return nil, err
+	}
+	env.Secrets = secrets
+	interpreter := exprparser.NewInterpeter(env, config)
+	for name, value := range callerJob.Secrets() {
+		if ret[name], err = evaluateString(interpreter, value); err != nil {
+			return nil, fmt.Errorf("job %q: secret %q: %w", caller.JobID, name, err)
+		}
+	}
+	return ret, nil
+}
+
+// isWorkflowCaller returns whether the job calls a reusable workflow
+func isWorkflowCaller(job *actions_model.ActionRunJob, jobs []*actions_model.ActionRunJob) bool {
+	for _, j := range jobs {
+		if j.CallerID == job.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func readWorkflowJob(job *actions_model.ActionRunJob) (*model.Job, error) {
+	workflow, err := model.ReadWorkflow(bytes.NewReader(job.WorkflowPayload))
+	if err != nil {
+		return nil, fmt.Errorf("ReadWorkflow: %w", err)
+	}
+	wfJob := workflow.GetJob(job.JobID)
+	if wfJob == nil {
+		return nil, fmt.Errorf("job %q not found in workflow payload", job.JobID)
+	}
+	return wfJob, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/nektos/act/pkg/exprparser"
+	"github.com/nektos/act/pkg/jobparser"
+	"github.com/nektos/act/pkg/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_evaluateWorkflowCallOutputs(t *testing.T) {
+	results := map[string]*model.WorkflowCallResult{
+		"build": {Outputs: map[string]string{"version": "1.2.3"}},
+		"test":  {Outputs: map[string]string{}},
+	}
+	env := &exprparser.EvaluationEnvironment{
+		Jobs:   &results,
+		Inputs: map[string]any{"name": "gitea"},
+	}
+	config := exprparser.Config{
+		Run: &model.Run{
+			JobID:    "",
+			Workflow: &model.Workflow{Jobs: map[string]*model.Job{"": {}}},
+		},
+		Context: "job",
+	}
+
+	outputs, err := evaluateWorkflowCallOutputs(exprparser.NewInterpeter(env, config), map[string]model.WorkflowCallOutput{
+		"version": {Value: "${{ jobs.build.outputs.version }}"},
+		"label":   {Value: "${{ inputs.name }}-${{ jobs.build.outputs.version }}"},
+		"missing": {Value: "${{ jobs.test.outputs.version }}"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"version": "1.2.3",
+		"label":   "gitea-1.2.3",
+		"missing": "",
+	}, outputs)
+}
+
+func Test_convertWorkflowCallInput(t *testing.T) {
+	assert.Equal(t, true, convertWorkflowCallInput("boolean", "true"))
+	assert.Equal(t, false, convertWorkflowCallInput("boolean", "no"))
+	assert.Equal(t, 1.5, convertWorkflowCallInput("number", "1.5"))
+	assert.Equal(t, "abc", convertWorkflowCallInput("number", "abc"))
+	assert.Equal(t, "abc", convertWorkflowCallInput("string", "abc"))
+}
+
+func Test_isWorkflowCallable(t *testing.T) {
+	parse := func(content string) []*jobparser.SingleWorkflow {
+		jobs, err := jobparser.Parse([]byte(content))
+		require.NoError(t, err)
+		return jobs
+	}
+
+	assert.True(t, isWorkflowCallable(parse(`
+on:
+  workflow_call:
+    inputs:
+      name:
+        type: string
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ inputs.name }}
+`)))
+	assert.True(t, isWorkflowCallable(parse(`
+on: [push, workflow_call]
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo build
+`)))
+	assert.False(t, isWorkflowCallable(parse(`
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo build
+`)))
+}
+
+func Test_jobScope(t *testing.T) {
+	assert.Equal(t, "", jobScope("build"))
+	assert.Equal(t, "call/", jobScope("call/build"))
+	assert.Equal(t, "call/nested/", jobScope("call/nested/build"))
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 
 	"code.gitea.io/gitea/models/db"
+	quota_model "code.gitea.io/gitea/models/quota"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/util"
@@ -24,7 +25,16 @@ func NewAttachment(ctx context.Context, attach *repo_model.Attachment, file io.R
 		return nil, fmt.Errorf("attachment %s should belong to a repository", attach.Name)
 	}
 
-	err := db.WithTx(ctx, func(ctx context.Context) error {
+	repo, err := repo_model.GetRepositoryByID(ctx, attach.RepoID)
+	if err != nil {
+		return nil, err
+	}
+	// the size may be unknown before reading the file, then only a used up quota rejects it
+	if err := quota_model.CheckQuota(ctx, repo.OwnerID, quota_model.SubjectAttachments, max(size, 0)); err != nil {
+		return nil, err
+	}
+
+	err = db.WithTx(ctx, func(ctx context.Context) error {
 		attach.UUID = uuid.New().String()
 		size, err := storage.Attachments.Save(attach.RelativePath(), file, size)
 		if err != nil {
@@ -15,13 +15,15 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/templates"
+	packages_service "code.gitea.io/gitea/services/packages"
 )
 
-// Package contains owner, access mode and optional the package descriptor
+// Package contains owner, access mode, the access to single packages and optional the package descriptor
 type Package struct {
-	Owner      *user_model.User
-	AccessMode perm.AccessMode
-	Descriptor *packages_model.PackageDescriptor
+	Owner       *user_model.User
+	AccessMode  perm.AccessMode
+	AccessScope *packages_service.AccessScope
+	Descriptor  *packages_model.PackageDescriptor
 }
 
 type packageAssignmentCtx struct {
@@ -30,8 +32,19 @@ type packageAssignmentCtx struct {
 	ContextUser *user_model.User
 }
 
-// PackageAssignment returns a middleware to handle Context.Package assignment
-func PackageAssignment() func(ctx *Context) {
+// PackageRoute is the package addressed by a route of a package registry
+type PackageRoute struct {
+	Type   packages_model.Type
+	Name   string // the name of the package, empty if the route doesn't address a single package
+	Upload bool   // whether the route uploads a package which is named by the uploaded file
+}
+
+// PackageRouteResolver resolves the package addressed by a route of a package registry
+type PackageRouteResolver func(ctx *Base) PackageRoute
+
+// PackageAssignment returns a middleware to handle Context.Package assignment.
+// The resolver resolves the package addressed by the routes which don't have the "type" and "name" parameters, it may be nil.
+func PackageAssignment(resolver PackageRouteResolver) func(ctx *Context) {
 	return func(ctx *Context) {
 		errorFn := func(status int, title string, obj any) {
 			err, ok := obj.(error)
@@ -45,7 +58,7 @@ func PackageAssignment() func(ctx *Context) {
 			}
 		}
 		paCtx := &packageAssignmentCtx{Base: ctx.Base, Doer: ctx.Doer, ContextUser: ctx.ContextUser}
-		ctx.Package = packageAssignment(paCtx, errorFn)
+		ctx.Package = packageAssignment(paCtx, resolver, errorFn)
 	}
 }
 
@@ -53,11 +66,11 @@ func PackageAssignment() func(ctx *Context) {
 func PackageAssignmentAPI() func(ctx *APIContext) {
 	return func(ctx *APIContext) {
 		paCtx := &packageAssignmentCtx{Base: ctx.Base, Doer: ctx.Doer, ContextUser: ctx.ContextUser}
-		ctx.Package = packageAssignment(paCtx, ctx.Error)
+		ctx.Package = packageAssignment(paCtx, nil, ctx.Error)
 	}
 }
 
-func packageAssignment(ctx *packageAssignmentCtx, errCb func(int, string, any)) *Package {
+func packageAssignment(ctx *packageAssignmentCtx, resolver PackageRouteResolver, errCb func(int, string, any)) *Package {
 	pkg := &Package{
 		Owner: ctx.ContextUser,
 	}
@@ -68,9 +81,35 @@ func packageAssignment(ctx *packageAssignmentCtx, errCb func(int, string, any))
 		return pkg
 	}
 
+	// the access lists of single packages may grant more than the access to all packages of the owner
+	if ctx.Doer != nil && !ctx.Doer.IsAdmin && ctx.Doer.IsActive && !ctx.Doer.ProhibitLogin && pkg.AccessMode < perm.AccessModeAdmin {
+		taskID, _ := ctx.Data["ActionsTaskID"].(int64)
+		pkg.AccessScope, err = packages_service.GetAccessScope(ctx, pkg.Owner, ctx.Doer, taskID)
+		if err != nil {
+			errCb(http.StatusInternalServerError, "GetAccessScope", err)
+			return pkg
+		}
+	}
+
 	packageType := ctx.PathParam("type")
 	name := ctx.PathParam("name")
 	version := ctx.PathParam("version")
+
+	route := PackageRoute{Type: packages_model.Type(packageType), Name: name}
+	if resolver != nil {
+		route = resolver(ctx.Base)
+	}
+	mode, err := scopedAccessMode(ctx.Base, pkg.AccessScope, route)
+	if err != nil {
+		errCb(http.StatusInternalServerError, "scopedAccessMode", err)
+		return pkg
+	}
+	if pkg.AccessMode < mode {
+		pkg.AccessMode = mode
+		// the package service checks the access again before a package is written, in case the request writes another package
+		ctx.AppendContextValue(packages_service.AccessScopeContextKey, pkg.AccessScope)
+	}
+
 	if packageType != "" && name != "" && version != "" {
 		pv, err := packages_model.GetVersionByNameAndVersion(ctx, pkg.Owner.ID, packages_model.Type(packageType), name, version)
 		if err != nil {
@@ -92,6 +131,30 @@ func packageAssignment(ctx *packageAssignmentCtx, errCb func(int, string, any))
 	return pkg
 }
 
+// scopedAccessMode returns the access mode the access lists of single packages grant to the route,
+// the actions tasks could also write the packages which they could create.
+func scopedAccessMode(ctx *Base, scope *packages_service.AccessScope, route PackageRoute) (perm.AccessMode, error) {
+	if route.Type == "" {
+		return perm.AccessModeNone, nil
+	}
+	if route.Name != "" {
+		mode := scope.AccessMode(route.Type, route.Name)
+		if mode < perm.AccessModeWrite {
+			if canCreate, err := scope.CanCreate(ctx, route.Type, route.Name); err != nil {
+				return perm.AccessModeNone, err
+			} else if canCreate {
+				return perm.AccessModeWrite, nil
+			}
+		}
+		return mode, nil
+	}
+	if route.Upload && (scope.MaxAccessMode(route.Type) >= perm.AccessModeWrite || scope != nil && scope.RepoID > 0) {
+		// the name of the uploaded package is unknown yet, the package service checks the access to it before it's written
+		return perm.AccessModeWrite, nil
+	}
+	return perm.AccessModeNone, nil
+}
+
 func determineAccessMode(ctx *Base, pkg *Package, doer *user_model.User) (perm.AccessMode, error) {
 	if setting.Service.RequireSignInView && (doer == nil || doer.IsGhost()) {
 		return perm.AccessModeNone, nil
@@ -101,7 +164,6 @@ func determineAccessMode(ctx *Base, pkg *Package, doer *user_model.User) (perm.A
 		return perm.AccessModeNone, nil
 	}
 
-	// TODO: ActionUser permission check
 	accessMode := perm.AccessModeNone
 	if pkg.Owner.IsOrganization() {
 		org := organization.OrgFromUser(pkg.Owner)
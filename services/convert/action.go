@@ -0,0 +1,330 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/perm"
+	access_model "code.gitea.io/gitea/models/perm/access"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/json"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/nektos/act/pkg/jobparser"
+)
+
+// ToActionsStatus converts an actions_model.Status to the status and conclusion used by the API,
+// the values are compatible with GitHub's.
+func ToActionsStatus(status actions_model.Status) (string, string) {
+	switch status {
+	case actions_model.StatusUnknown, actions_model.StatusWaiting:
+		return "queued", ""
+	case actions_model.StatusBlocked:
+		return "waiting", ""
+	case actions_model.StatusRunning:
+		return "in_progress", ""
+	default:
+		return "completed", status.String()
+	}
+}
+
+// ToActionWorkflowRun converts an actions_model.ActionRun to an api.ActionWorkflowRun
+func ToActionWorkflowRun(ctx context.Context, repo *repo_model.Repository, run *actions_model.ActionRun) (*api.ActionWorkflowRun, error) {
+	run.Repo = repo
+	if err := run.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+
+	status, conclusion := ToActionsStatus(run.Status)
+	runURL := fmt.Sprintf("%s/actions/runs/%d", repo.APIURL(), run.ID)
+	actor := ToUser(ctx, run.TriggerUser, nil)
+
+	return &api.ActionWorkflowRun{
+		ID:              run.ID,
+		Name:            run.WorkflowID,
+		DisplayTitle:    run.Title,
+		HeadBranch:      run.PrettyRef(),
+		HeadSHA:         run.CommitSHA,
+		RunNumber:       run.Index,
+		Event:           run.TriggerEvent,
+		Status:          status,
+		Conclusion:      conclusion,
+		URL:             runURL,
+		HTMLURL:         run.HTMLURL(),
+		JobsURL:         runURL + "/jobs",
+		CancelURL:       runURL + "/cancel",
+		RerunURL:        runURL + "/rerun",
+		NeedApproval:    run.NeedApproval,
+		Actor:           actor,
+		TriggeringActor: actor,
+		CreatedAt:       run.Created.AsLocalTime(),
+		UpdatedAt:       run.Updated.AsLocalTime(),
+		RunStartedAt:    timeStampAsTimePtr(run.Started),
+	}, nil
+}
+
+// ToActionWorkflow returns the api.ActionWorkflow of an actions_model.ActionRun,
+// the name of the workflow falls back to its file name if it isn't set in the workflow.
+func ToActionWorkflow(ctx context.Context, run *actions_model.ActionRun) (*api.ActionWorkflow, error) {
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	workflow := &api.ActionWorkflow{
+		ID:   run.WorkflowID,
+		Name: run.WorkflowID,
+	}
+	for _, job := range jobs {
+		// the jobs of called workflows carry the names of the called workflows
+		if job.CallerID > 0 {
+			continue
+		}
+		if swf, err := jobparser.Parse(job.WorkflowPayload); err == nil && len(swf) > 0 && swf[0].Name != "" {
+			workflow.Name = swf[0].Name
+		}
+		break
+	}
+	return workflow, nil
+}
+
+// ToActionWorkflowJob converts an actions_model.ActionRunJob to an api.ActionWorkflowJob,
+// jobIndex is the index of the job in its run, it's used to generate the html url of the job.
+func ToActionWorkflowJob(ctx context.Context, repo *repo_model.Repository, job *actions_model.ActionRunJob, jobIndex int) (*api.ActionWorkflowJob, error) {
+	if err := job.LoadRun(ctx); err != nil {
+		return nil, err
+	}
+	job.Run.Repo = repo
+
+	status, conclusion := ToActionsStatus(job.Status)
+	apiJob := &api.ActionWorkflowJob{
+		ID:           job.ID,
+		RunID:        job.RunID,
+		RunURL:       fmt.Sprintf("%s/actions/runs/%d", repo.APIURL(), job.RunID),
+		RunAttempt:   job.Attempt,
+		HeadSHA:      job.CommitSHA,
+		HeadBranch:   job.Run.PrettyRef(),
+		URL:          fmt.Sprintf("%s/actions/jobs/%d", repo.APIURL(), job.ID),
+		HTMLURL:      fmt.Sprintf("%s/jobs/%d", job.Run.HTMLURL(), jobIndex),
+		Status:       status,
+		Conclusion:   conclusion,
+		Name:         job.Name,
+		WorkflowName: job.Run.WorkflowID,
+		Steps:        make([]*api.ActionWorkflowStep, 0),
+		Labels:       job.RunsOn,
+		CreatedAt:    job.Created.AsLocalTime(),
+		StartedAt:    timeStampAsTimePtr(job.Started),
+		CompletedAt:  timeStampAsTimePtr(job.Stopped),
+	}
+
+	if apiJob.Labels == nil {
+		apiJob.Labels = make([]string, 0)
+	}
+
+	if job.TaskID == 0 {
+		return apiJob, nil
+	}
+
+	task, err := actions_model.GetTaskByID(ctx, job.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	task.Job = job
+	if err := task.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+
+	if task.RunnerID > 0 {
+		runner, err := actions_model.GetRunnerByID(ctx, task.RunnerID)
+		if err != nil && !errors.Is(err, util.ErrNotExist) {
+			return nil, err
+		}
+		apiJob.RunnerID = task.RunnerID
+		if runner != nil {
+			apiJob.RunnerName = runner.Name
+		}
+	}
+
+	for i, step := range actions.FullSteps(task) {
+		stepStatus, stepConclusion := ToActionsStatus(step.Status)
+		apiJob.Steps = append(apiJob.Steps, &api.ActionWorkflowStep{
+			Name:        step.Name,
+			Number:      int64(i + 1),
+			Status:      stepStatus,
+			Conclusion:  stepConclusion,
+			StartedAt:   timeStampAsTimePtr(step.Started),
+			CompletedAt: timeStampAsTimePtr(step.Stopped),
+		})
+	}
+
+	return apiJob, nil
+}
+
+func timeStampAsTimePtr(ts timeutil.TimeStamp) *time.Time {
+	if ts.IsZero() {
+		return nil
+	}
+	t := ts.AsLocalTime()
+	return &t
+}
+
+// ToEnvironment converts an actions_model.ActionEnvironment to an api.Environment
+func ToEnvironment(ctx context.Context, env *actions_model.ActionEnvironment) (*api.Environment, error) {
+	if err := env.LoadReviewers(ctx); err != nil {
+		return nil, err
+	}
+	reviewers := make([]*api.User, 0, len(env.Reviewers))
+	for _, u := range env.Reviewers {
+		reviewers = append(reviewers, ToUser(ctx, u, nil))
+	}
+	branchPatterns := env.BranchPatterns
+	if branchPatterns == nil {
+		branchPatterns = []string{}
+	}
+	return &api.Environment{
+		ID:             env.ID,
+		Name:           env.Name,
+		WaitTimer:      env.WaitTimer,
+		Reviewers:      reviewers,
+		BranchPatterns: branchPatterns,
+		CreatedAt:      env.CreatedUnix.AsLocalTime(),
+		UpdatedAt:      env.UpdatedUnix.AsLocalTime(),
+	}, nil
+}
+
+// ToDeployment converts an actions_model.ActionDeployment to an api.Deployment
+func ToDeployment(ctx context.Context, d *actions_model.ActionDeployment) (*api.Deployment, error) {
+	if err := d.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	ret := &api.Deployment{
+		ID:            d.ID,
+		Environment:   d.Environment.Name,
+		EnvironmentID: d.EnvironmentID,
+		RunID:         d.RunID,
+		JobID:         d.JobID,
+		Ref:           d.Ref,
+		SHA:           d.CommitSHA,
+		State:         string(d.State),
+		NeedApproval:  d.NeedApproval,
+		Creator:       ToUser(ctx, d.Creator, nil),
+		WaitUntil:     timeStampAsTimePtr(d.WaitUntil),
+		CreatedAt:     d.CreatedUnix.AsLocalTime(),
+		UpdatedAt:     d.UpdatedUnix.AsLocalTime(),
+	}
+	if d.ReviewerID > 0 {
+		reviewer, err := user_model.GetPossibleUserByID(ctx, d.ReviewerID)
+		if err != nil {
+			return nil, err
+		}
+		ret.Reviewer = ToUser(ctx, reviewer, nil)
+	}
+	return ret, nil
+}
+
+// ToDeploymentStatus converts an actions_model.ActionDeploymentStatus to an api.DeploymentStatus
+func ToDeploymentStatus(ctx context.Context, s *actions_model.ActionDeploymentStatus) (*api.DeploymentStatus, error) {
+	if err := s.LoadCreator(ctx); err != nil {
+		return nil, err
+	}
+	ret := &api.DeploymentStatus{
+		ID:          s.ID,
+		State:       string(s.State),
+		Description: s.Description,
+		CreatedAt:   s.CreatedUnix.AsLocalTime(),
+	}
+	if s.Creator != nil {
+		ret.Creator = ToUser(ctx, s.Creator, nil)
+	}
+	return ret, nil
+}
+
+// ToRequiredWorkflow converts an actions_model.ActionRequiredWorkflow to an api.RequiredWorkflow,
+// the doer should be an owner of the organization.
+func ToRequiredWorkflow(ctx context.Context, rw *actions_model.ActionRequiredWorkflow) (*api.RequiredWorkflow, error) {
+	if err := rw.LoadRepo(ctx); err != nil {
+		return nil, err
+	}
+	repoPatterns := rw.RepoPatterns
+	if repoPatterns == nil {
+		repoPatterns = []string{}
+	}
+	return &api.RequiredWorkflow{
+		ID:                 rw.ID,
+		Repository:         ToRepo(ctx, rw.Repo, access_model.Permission{AccessMode: perm.AccessModeOwner}),
+		WorkflowPath:       rw.WorkflowPath,
+		Ref:                rw.Ref,
+		RepoPatterns:       repoPatterns,
+		WorkflowName:       rw.WorkflowName,
+		EnforceStatusCheck: rw.EnforceStatusCheck,
+		CreatedAt:          rw.CreatedUnix.AsLocalTime(),
+		UpdatedAt:          rw.UpdatedUnix.AsLocalTime(),
+	}, nil
+}
+
+// ToActionRunner converts an actions_model.ActionRunner to an api.ActionRunner
+func ToActionRunner(runner *actions_model.ActionRunner) *api.ActionRunner {
+	labels := runner.AgentLabels
+	if labels == nil {
+		labels = []string{}
+	}
+	return &api.ActionRunner{
+		ID:            runner.ID,
+		Name:          runner.Name,
+		Status:        runner.StatusName(),
+		Version:       runner.Version,
+		Labels:        labels,
+		Ephemeral:     runner.Ephemeral,
+		JobID:         runner.JobID,
+		RunnerGroupID: runner.GroupID,
+	}
+}
+
+// ToActionRunnerGroup converts an actions_model.ActionRunnerGroup to an api.ActionRunnerGroup
+func ToActionRunnerGroup(g *actions_model.ActionRunnerGroup) *api.ActionRunnerGroup {
+	repoPatterns := g.RepoPatterns
+	if repoPatterns == nil {
+		repoPatterns = []string{}
+	}
+	workflowPatterns := g.WorkflowPatterns
+	if workflowPatterns == nil {
+		workflowPatterns = []string{}
+	}
+	return &api.ActionRunnerGroup{
+		ID:               g.ID,
+		Name:             g.Name,
+		RepoPatterns:     repoPatterns,
+		WorkflowPatterns: workflowPatterns,
+		CreatedAt:        g.CreatedUnix.AsLocalTime(),
+		UpdatedAt:        g.UpdatedUnix.AsLocalTime(),
+	}
+}
+
+// ToActionAttestation converts an actions_model.ActionAttestation to an api.ActionAttestation
+func ToActionAttestation(a *actions_model.ActionAttestation) (*api.ActionAttestation, error) {
+	envelope := &api.ActionAttestationEnvelope{}
+	if err := json.Unmarshal([]byte(a.Envelope), envelope); err != nil {
+		return nil, fmt.Errorf("unmarshal envelope of attestation %d: %w", a.ID, err)
+	}
+	return &api.ActionAttestation{
+		ID:    a.ID,
+		RunID: a.RunID,
+		Subject: &api.ActionAttestationSubject{
+			Name:   a.SubjectName,
+			Digest: a.SubjectDigest,
+		},
+		PredicateType: a.PredicateType,
+		Envelope:      envelope,
+		Created:       a.Created.AsLocalTime(),
+	}, nil
+}
@@ -6,10 +6,12 @@ package convert
 import (
 	"context"
 
+	"code.gitea.io/gitea/models/organization"
 	"code.gitea.io/gitea/models/packages"
 	access_model "code.gitea.io/gitea/models/perm/access"
 	user_model "code.gitea.io/gitea/models/user"
 	api "code.gitea.io/gitea/modules/structs"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
 )
 
 // ToPackage convert a packages.PackageDescriptor to api.Package
@@ -26,6 +28,11 @@ func ToPackage(ctx context.Context, pd *packages.PackageDescriptor, doer *user_m
 		}
 	}
 
+	vulnerabilityCounts, err := packages.CountVersionVulnerabilities(ctx, []int64{pd.Version.ID})
+	if err != nil {
+		return nil, err
+	}
+
 	return &api.Package{
 		ID:         pd.Version.ID,
 		Owner:      ToUser(ctx, pd.Owner, doer),
@@ -36,6 +43,8 @@ func ToPackage(ctx context.Context, pd *packages.PackageDescriptor, doer *user_m
 		Version:    pd.Version.Version,
 		CreatedAt:  pd.Version.CreatedUnix.AsTime(),
 		HTMLURL:    pd.VersionHTMLURL(),
+
+		VulnerabilityCount: vulnerabilityCounts[pd.Version.ID],
 	}, nil
 }
 
@@ -51,3 +60,56 @@ func ToPackageFile(pfd *packages.PackageFileDescriptor) *api.PackageFile {
 		HashSHA512: pfd.Blob.HashSHA512,
 	}
 }
+
+// ToPackageSBOM converts a SBOM document attached to a package version to api.PackageSBOM
+func ToPackageSBOM(doc *packages_sbom_service.Document) *api.PackageSBOM {
+	return &api.PackageSBOM{
+		Format:     doc.Format,
+		Size:       doc.Blob.Size,
+		HashSHA256: doc.Blob.HashSHA256,
+		CreatedAt:  doc.File.CreatedUnix.AsTime(),
+	}
+}
+
+// ToPackageVulnerability converts a vulnerability matched to a package version to api.PackageVulnerability
+func ToPackageVulnerability(v *packages.VersionVulnerability) *api.PackageVulnerability {
+	aliases := v.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return &api.PackageVulnerability{
+		ID:          v.VulnID,
+		Aliases:     aliases,
+		Summary:     v.Summary,
+		Severity:    v.Severity,
+		Component:   v.Component,
+		URL:         v.URL(),
+		PublishedAt: v.PublishedUnix.AsTime(),
+		ModifiedAt:  v.ModifiedUnix.AsTime(),
+	}
+}
+
+// ToPackageAccess converts an access entry of a package to api.PackageAccess
+func ToPackageAccess(ctx context.Context, pa *packages.PackageAccess, doer *user_model.User) (*api.PackageAccess, error) {
+	apiAccess := &api.PackageAccess{
+		Permission: pa.Mode.ToString(),
+		CreatedAt:  pa.CreatedUnix.AsTime(),
+		UpdatedAt:  pa.UpdatedUnix.AsTime(),
+	}
+	if pa.TeamID != 0 {
+		team, err := organization.GetTeamByID(ctx, pa.TeamID)
+		if err != nil {
+			return nil, err
+		}
+		if apiAccess.Team, err = ToTeam(ctx, team); err != nil {
+			return nil, err
+		}
+	} else {
+		user, err := user_model.GetPossibleUserByID(ctx, pa.UserID)
+		if err != nil {
+			return nil, err
+		}
+		apiAccess.User = ToUser(ctx, user, doer)
+	}
+	return apiAccess, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package convert
+
+import (
+	"context"
+
+	quota_model "code.gitea.io/gitea/models/quota"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToQuotaRules converts quota rules to API format
+func ToQuotaRules(rules []*quota_model.Rule) []*api.QuotaRule {
+	result := make([]*api.QuotaRule, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, &api.QuotaRule{
+			Subject: string(r.Subject),
+			Limit:   r.MaxSize,
+		})
+	}
+	return result
+}
+
+// ToQuotaGroup converts a quota group with its members and rules to API format
+func ToQuotaGroup(ctx context.Context, g *quota_model.Group) (*api.QuotaGroup, error) {
+	rules, err := quota_model.GetRules(ctx, g.ID, 0)
+	if err != nil {
+		return nil, err
+	}
+	memberIDs, err := quota_model.GetGroupMemberIDs(ctx, g.ID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := user_model.GetUsersByIDs(ctx, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &api.QuotaGroup{
+		Name:    g.Name,
+		Members: make([]string, 0, len(members)),
+		Rules:   ToQuotaRules(rules),
+	}
+	for _, u := range members {
+		group.Members = append(group.Members, u.Name)
+	}
+	return group, nil
+}
@@ -63,3 +63,52 @@ func ToCombinedStatus(ctx context.Context, statuses []*git_model.CommitStatus, r
 
 	return retStatus
 }
+
+// ToCheckRun converts git_model.CheckRun to api.CheckRun
+func ToCheckRun(ctx context.Context, cr *git_model.CheckRun) (*api.CheckRun, error) {
+	if err := cr.LoadCreator(ctx); err != nil {
+		return nil, err
+	}
+	apiCheckRun := &api.CheckRun{
+		ID:         cr.ID,
+		HeadSHA:    cr.HeadSHA,
+		Name:       cr.Name,
+		ExternalID: cr.ExternalID,
+		Status:     string(cr.Status),
+		Conclusion: string(cr.Conclusion),
+		DetailsURL: cr.DetailsURL,
+		Output: &api.CheckRunOutput{
+			Title:            cr.Title,
+			Summary:          cr.Summary,
+			Text:             cr.Text,
+			AnnotationsCount: cr.AnnotationsCount,
+		},
+		Creator:   ToUser(ctx, cr.Creator, nil),
+		CreatedAt: cr.CreatedUnix.AsTime(),
+		UpdatedAt: cr.UpdatedUnix.AsTime(),
+	}
+	if !cr.StartedUnix.IsZero() {
+		t := cr.StartedUnix.AsTime()
+		apiCheckRun.StartedAt = &t
+	}
+	if !cr.CompletedUnix.IsZero() {
+		t := cr.CompletedUnix.AsTime()
+		apiCheckRun.CompletedAt = &t
+	}
+	return apiCheckRun, nil
+}
+
+// ToCheckRunAnnotation converts git_model.CheckRunAnnotation to api.CheckRunAnnotation
+func ToCheckRunAnnotation(a *git_model.CheckRunAnnotation) *api.CheckRunAnnotation {
+	return &api.CheckRunAnnotation{
+		Path:            a.Path,
+		StartLine:       a.StartLine,
+		EndLine:         a.EndLine,
+		StartColumn:     a.StartColumn,
+		EndColumn:       a.EndColumn,
+		AnnotationLevel: string(a.Level),
+		Title:           a.Title,
+		Message:         a.Message,
+		RawDetails:      a.RawDetails,
+	}
+}
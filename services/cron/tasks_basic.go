@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/services/migrations"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	packages_cleanup_service "code.gitea.io/gitea/services/packages/cleanup"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
 	repo_service "code.gitea.io/gitea/services/repository"
 	archiver_service "code.gitea.io/gitea/services/repository/archiver"
 )
@@ -156,6 +157,26 @@ func registerCleanupPackages() {
 	})
 }
 
+func registerImportPackageVulnerabilities() {
+	type ImportPackageVulnerabilitiesConfig struct {
+		BaseConfig
+		Path string
+	}
+	RegisterTaskFatal("import_package_vulnerabilities", &ImportPackageVulnerabilitiesConfig{
+		BaseConfig: BaseConfig{
+			Enabled:    false,
+			RunAtStart: false,
+			Schedule:   "@midnight",
+		},
+	}, func(ctx context.Context, _ *user_model.User, config Config) error {
+		realConfig := config.(*ImportPackageVulnerabilitiesConfig)
+		if realConfig.Path == "" {
+			return nil
+		}
+		return packages_sbom_service.ImportDatabase(ctx, realConfig.Path)
+	})
+}
+
 func initBasicTasks() {
 	if setting.Mirror.Enabled {
 		registerUpdateMirrorTask()
@@ -171,5 +192,6 @@ func initBasicTasks() {
 	registerCleanupHookTaskTable()
 	if setting.Packages.Enabled {
 		registerCleanupPackages()
+		registerImportPackageVulnerabilities()
 	}
 }
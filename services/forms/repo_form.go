@@ -258,6 +258,8 @@ type WebhookForm struct {
 	Wiki                     bool
 	Repository               bool
 	Package                  bool
+	WorkflowRun              bool
+	WorkflowJob              bool
 	Active                   bool
 	BranchFilter             string `binding:"GlobPattern"`
 	AuthorizationHeader      string
@@ -871,3 +873,17 @@ func (f *DeadlineForm) Validate(req *http.Request, errs binding.Errors) binding.
 	ctx := context.GetValidateContext(req)
 	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
 }
+
+// EditEnvironmentForm form for creating or updating a deployment environment
+type EditEnvironmentForm struct {
+	Name           string `binding:"Required;MaxSize(255)"`
+	WaitTimer      int64  `binding:"Range(0,43200)"`
+	Reviewers      string // the comma-separated names of the reviewers
+	BranchPatterns string // the patterns of the allowed branches or tags, one per line
+}
+
+// Validate validates the fields
+func (f *EditEnvironmentForm) Validate(req *http.Request, errs binding.Errors) binding.Errors {
+	ctx := context.GetValidateContext(req)
+	return middleware.Validate(errs, ctx.Data, f, ctx.Locale)
+}
@@ -81,6 +81,7 @@ type DiffLine struct {
 	Type        DiffLineType
 	Content     string
 	Comments    []*issues_model.Comment
+	Annotations []*git_model.CheckRunAnnotation // the annotations of check runs on the new side of the line
 	SectionInfo *DiffLineSectionInfo
 }
 
@@ -491,6 +492,43 @@ func (diff *Diff) LoadComments(ctx context.Context, issue *issues_model.Issue, c
 	return nil
 }
 
+// LoadCheckRunAnnotations loads the annotations of the latest check runs of the head commit into each line,
+// an annotation is shown on the last line of its range which is in the diff.
+func (diff *Diff) LoadCheckRunAnnotations(ctx context.Context, repoID int64, headCommitID string) error {
+	annotations, err := git_model.GetLatestCheckRunAnnotations(ctx, repoID, headCommitID)
+	if err != nil {
+		return err
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	fileAnnotations := make(map[string][]*git_model.CheckRunAnnotation)
+	for _, a := range annotations {
+		fileAnnotations[a.Path] = append(fileAnnotations[a.Path], a)
+	}
+
+	for _, file := range diff.Files {
+		annotations, ok := fileAnnotations[file.Name]
+		if !ok || file.IsDeleted {
+			continue
+		}
+		for _, a := range annotations {
+			var target *DiffLine
+			for _, section := range file.Sections {
+				for _, line := range section.Lines {
+					if line.Type != DiffLineSection && line.RightIdx > 0 && int64(line.RightIdx) >= a.StartLine && int64(line.RightIdx) <= a.EndLine {
+						target = line
+					}
+				}
+			}
+			if target != nil {
+				target.Annotations = append(target.Annotations, a)
+			}
+		}
+	}
+	return nil
+}
+
 const cmdDiffHead = "diff --git "
 
 // ParsePatch builds a Diff object from a io.Reader and some parameters.
@@ -23,6 +23,7 @@ import (
 	git_model "code.gitea.io/gitea/models/git"
 	"code.gitea.io/gitea/models/perm"
 	access_model "code.gitea.io/gitea/models/perm/access"
+	quota_model "code.gitea.io/gitea/models/quota"
 	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/models/unit"
 	user_model "code.gitea.io/gitea/models/user"
@@ -179,6 +180,10 @@ func BatchHandler(ctx *context.Context) {
 		return
 	}
 
+	if isUpload && !checkQuota(ctx, repository, br.Objects) {
+		return
+	}
+
 	contentStore := lfs_module.NewContentStore()
 
 	var responseObjects []*lfs_module.ObjectResponse
@@ -289,6 +294,10 @@ func UploadHandler(ctx *context.Context) {
 		return
 	}
 
+	if !checkQuota(ctx, repository, []lfs_module.Pointer{p}) {
+		return
+	}
+
 	contentStore := lfs_module.NewContentStore()
 	exists, err := contentStore.Exists(p)
 	if err != nil {
@@ -485,6 +494,35 @@ func buildObjectResponse(rc *requestContext, pointer lfs_module.Pointer, downloa
 	return rep
 }
 
+// checkQuota verifies the objects which are not yet part of the repository fit into the LFS quota of the repository owner
+func checkQuota(ctx *context.Context, repository *repo_model.Repository, pointers []lfs_module.Pointer) bool {
+	var size int64
+	for _, p := range pointers {
+		if !p.IsValid() {
+			continue
+		}
+		if _, err := git_model.GetLFSMetaObjectByOid(ctx, repository.ID, p.Oid); err == nil {
+			continue
+		} else if err != git_model.ErrLFSObjectNotExist {
+			log.Error("Unable to get LFS MetaObject [%s] for %-v. Error: %v", p.Oid, repository, err)
+			writeStatus(ctx, http.StatusInternalServerError)
+			return false
+		}
+		size += p.Size
+	}
+
+	if err := quota_model.CheckQuota(ctx, repository.OwnerID, quota_model.SubjectLFS, size); err != nil {
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			writeStatusMessage(ctx, http.StatusRequestEntityTooLarge, err.Error())
+		} else {
+			log.Error("Unable to check the LFS quota of %-v. Error: %v", repository, err)
+			writeStatus(ctx, http.StatusInternalServerError)
+		}
+		return false
+	}
+	return true
+}
+
 func writeStatus(ctx *context.Context, status int) {
 	writeStatusMessage(ctx, status, http.StatusText(status))
 }
@@ -520,10 +558,7 @@ func authenticate(ctx *context.Context, repository *repo_model.Repository, autho
 			return false
 		}
 
-		if task.IsForkPullRequest {
-			return accessMode <= perm.AccessModeRead
-		}
-		return accessMode <= perm.AccessModeWrite
+		return accessMode <= task.UnitAccessMode(unit.TypeCode)
 	}
 
 	// ctx.IsSigned is unnecessary here, this will be checked in perm.CanAccess
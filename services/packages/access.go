@@ -0,0 +1,269 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/organization"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// ErrPackageAccessDenied indicates that the request may only write to other packages of the owner
+var ErrPackageAccessDenied = errors.New("no write access to the package")
+
+type accessScopeContextKeyType struct{}
+
+// AccessScopeContextKey is the context key of the AccessScope which restricts the packages a request may write to
+var AccessScopeContextKey = accessScopeContextKeyType{}
+
+// AccessScope holds the access to single packages of the owner granted by the package access lists
+// or by the repositories linked to the packages if the doer is an actions task.
+type AccessScope struct {
+	OwnerID int64
+	RepoID  int64 // the repository of the actions task which could write packages, the packages created by the task are linked to it
+	modes   map[string]perm.AccessMode
+}
+
+func accessScopeKey(packageType packages_model.Type, name string) string {
+	return string(packageType) + "/" + strings.ToLower(name)
+}
+
+func (s *AccessScope) grant(packageType packages_model.Type, name string, mode perm.AccessMode) {
+	key := accessScopeKey(packageType, name)
+	if s.modes[key] < mode {
+		s.modes[key] = mode
+	}
+}
+
+// AccessMode returns the access mode granted to the package
+func (s *AccessScope) AccessMode(packageType packages_model.Type, name string) perm.AccessMode {
+	if s == nil {
+		return perm.AccessModeNone
+	}
+	return s.modes[accessScopeKey(packageType, name)]
+}
+
+// MaxAccessMode returns the highest access mode granted to any package of the type
+func (s *AccessScope) MaxAccessMode(packageType packages_model.Type) perm.AccessMode {
+	mode := perm.AccessModeNone
+	if s == nil {
+		return mode
+	}
+	prefix := string(packageType) + "/"
+	for key, m := range s.modes {
+		if strings.HasPrefix(key, prefix) && mode < m {
+			mode = m
+		}
+	}
+	return mode
+}
+
+// CanCreate returns whether the package could be created with the access scope,
+// the actions tasks which could write packages could create the packages of the owner of the repository which don't exist yet.
+func (s *AccessScope) CanCreate(ctx context.Context, packageType packages_model.Type, name string) (bool, error) {
+	if s == nil || s.RepoID == 0 {
+		return false, nil
+	}
+	if _, err := packages_model.GetPackageByName(ctx, s.OwnerID, packageType, name); err != nil {
+		if errors.Is(err, packages_model.ErrPackageNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
+// GetAccessScope gets the access of the doer to single packages of the owner.
+// The actions tasks get the access mode their token grants to the packages linked to the repository of the task,
+// and could create new packages if the task belongs to the owner and its token could write packages.
+func GetAccessScope(ctx context.Context, owner, doer *user_model.User, actionsTaskID int64) (*AccessScope, error) {
+	scope := &AccessScope{
+		OwnerID: owner.ID,
+		modes:   make(map[string]perm.AccessMode),
+	}
+
+	if doer == nil || doer.IsGhost() {
+		return scope, nil
+	}
+
+	if doer.IsActions() {
+		if actionsTaskID == 0 {
+			return scope, nil
+		}
+		task, err := actions_model.GetTaskByID(ctx, actionsTaskID)
+		if err != nil {
+			return nil, err
+		}
+		mode := min(task.UnitAccessMode(unit.TypePackages), perm.AccessModeWrite)
+		if mode == perm.AccessModeNone {
+			return scope, nil
+		}
+		ps, err := packages_model.GetPackagesLinkedToRepository(ctx, owner.ID, task.RepoID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ps {
+			scope.grant(p.Type, p.LowerName, mode)
+		}
+		if mode >= perm.AccessModeWrite && task.OwnerID == owner.ID {
+			scope.RepoID = task.RepoID
+		}
+		return scope, nil
+	}
+
+	var teamIDs []int64
+	if owner.IsOrganization() {
+		teams, err := organization.GetUserOrgTeams(ctx, owner.ID, doer.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range teams {
+			teamIDs = append(teamIDs, t.ID)
+		}
+	}
+
+	pas, err := packages_model.GetAccessEntriesForUser(ctx, owner.ID, doer.ID, teamIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, pa := range pas {
+		scope.grant(pa.Type, pa.LowerName, pa.Mode)
+	}
+	return scope, nil
+}
+
+// CheckPackageWriteAccess returns ErrPackageAccessDenied if the request is restricted to single packages of the owner
+// and has no write access to the package. Requests without an AccessScope in the context are not restricted.
+func CheckPackageWriteAccess(ctx context.Context, ownerID int64, packageType packages_model.Type, name string) error {
+	scope, ok := ctx.Value(AccessScopeContextKey).(*AccessScope)
+	if !ok || scope == nil || scope.OwnerID != ownerID {
+		return nil
+	}
+	if scope.AccessMode(packageType, name) >= perm.AccessModeWrite {
+		return nil
+	}
+	if canCreate, err := scope.CanCreate(ctx, packageType, name); err != nil {
+		return err
+	} else if canCreate {
+		return nil
+	}
+	return ErrPackageAccessDenied
+}
+
+// LinkedRepositoryID returns the repository which the packages created by the request are linked to,
+// it's the repository of the actions task if the request is restricted by the access scope of the task.
+func LinkedRepositoryID(ctx context.Context, ownerID int64) int64 {
+	scope, ok := ctx.Value(AccessScopeContextKey).(*AccessScope)
+	if !ok || scope == nil || scope.OwnerID != ownerID {
+		return 0
+	}
+	return scope.RepoID
+}
+
+// SetPackageAccess grants the user or the team of the owner read, write or admin access to the package with the name
+func SetPackageAccess(ctx context.Context, owner *user_model.User, packageType packages_model.Type, name string, user *user_model.User, team *organization.Team, mode perm.AccessMode) error {
+	if mode != perm.AccessModeRead && mode != perm.AccessModeWrite && mode != perm.AccessModeAdmin {
+		return util.NewInvalidArgumentErrorf("invalid access mode %s", mode.ToString())
+	}
+
+	pa := &packages_model.PackageAccess{
+		OwnerID:   owner.ID,
+		Type:      packageType,
+		LowerName: name,
+		Mode:      mode,
+	}
+	if user != nil {
+		if user.IsOrganization() || user.ID == owner.ID {
+			return util.NewInvalidArgumentErrorf("access can't be granted to %s", user.Name)
+		}
+		pa.UserID = user.ID
+	} else {
+		if team == nil || team.OrgID != owner.ID {
+			return util.NewInvalidArgumentErrorf("the team does not belong to %s", owner.Name)
+		}
+		pa.TeamID = team.ID
+	}
+
+	return packages_model.SetAccess(ctx, pa)
+}
+
+// GetLinkedRepositories gets the repositories linked to the package, the repository stored in Package.RepoID comes first
+func GetLinkedRepositories(ctx context.Context, p *packages_model.Package) ([]*repo_model.Repository, error) {
+	repoIDs, err := packages_model.GetLinkedRepositoryIDs(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if p.RepoID != 0 {
+		repoIDs = append([]int64{p.RepoID}, repoIDs...)
+	}
+
+	repos := make([]*repo_model.Repository, 0, len(repoIDs))
+	for i, repoID := range repoIDs {
+		// the repository stored in Package.RepoID may be changed by the package settings to an additionally linked one
+		if i > 0 && repoID == p.RepoID {
+			continue
+		}
+		repo, err := repo_model.GetRepositoryByID(ctx, repoID)
+		if err != nil {
+			if repo_model.IsErrRepoNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// LinkRepository links the package to the repository. The first linked repository is stored in Package.RepoID.
+func LinkRepository(ctx context.Context, p *packages_model.Package, repo *repo_model.Repository) error {
+	if p.RepoID == repo.ID {
+		return nil
+	}
+	if p.RepoID == 0 {
+		if err := packages_model.SetRepositoryLink(ctx, p.ID, repo.ID); err != nil {
+			return err
+		}
+		p.RepoID = repo.ID
+		return nil
+	}
+	return packages_model.AddRepositoryLink(ctx, p.ID, repo.ID)
+}
+
+// UnlinkRepository removes the link between the package and the repository.
+// If the repository is stored in Package.RepoID, the next linked repository takes its place.
+func UnlinkRepository(ctx context.Context, p *packages_model.Package, repo *repo_model.Repository) error {
+	if p.RepoID != repo.ID {
+		return packages_model.RemoveRepositoryLink(ctx, p.ID, repo.ID)
+	}
+
+	next := int64(0)
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		repoIDs, err := packages_model.GetLinkedRepositoryIDs(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if len(repoIDs) > 0 {
+			next = repoIDs[0]
+			if err := packages_model.RemoveRepositoryLink(ctx, p.ID, next); err != nil {
+				return err
+			}
+		}
+		return packages_model.SetRepositoryLink(ctx, p.ID, next)
+	}); err != nil {
+		return err
+	}
+	p.RepoID = next
+	return nil
+}
@@ -0,0 +1,394 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package arch
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	arch_model "code.gitea.io/gitea/models/packages/arch"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const (
+	DatabaseSuffix  = ".db"
+	FilesSuffix     = ".files"
+	SignatureSuffix = ".sig"
+)
+
+// GetOrCreateRepositoryVersion gets or creates the internal repository package
+// The Arch registry needs multiple database files which are stored in this package.
+func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages_model.PackageVersion, error) {
+	return packages_service.GetOrCreateInternalPackageVersion(ctx, ownerID, packages_model.TypeArch, arch_module.RepositoryPackage, arch_module.RepositoryVersion)
+}
+
+// GetOrCreateKeyPair gets or creates the PGP keys used to sign packages and repository files
+func GetOrCreateKeyPair(ctx context.Context, ownerID int64) (string, string, error) {
+	return packages_service.GetOrCreateKeyPair(ctx, ownerID, arch_module.SettingKeyPrivate, arch_module.SettingKeyPublic, func() (string, string, error) {
+		return packages_service.GeneratePGPKeyPair("Arch Registry")
+	})
+}
+
+// SignPackage creates a detached binary signature of the content with the key of the owner
+func SignPackage(ctx context.Context, ownerID int64, r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := sign(ctx, ownerID, &buf, r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sign(ctx context.Context, ownerID int64, w io.Writer, r io.Reader) error {
+	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	return packages_service.DetachSignPGP(priv, w, r)
+}
+
+// BuildAllRepositoryFiles (re)builds all repository files for every available repositories and architectures
+func BuildAllRepositoryFiles(ctx context.Context, ownerID int64) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	// 1. Delete all existing repository files
+	pfs, err := packages_model.GetFilesByVersionID(ctx, pv.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, pf := range pfs {
+		if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
+	// 2. (Re)Build repository files for existing packages
+	repositories, err := arch_model.GetRepositories(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	for _, repository := range repositories {
+		architectures, err := arch_model.GetArchitectures(ctx, ownerID, repository)
+		if err != nil {
+			return err
+		}
+		for _, architecture := range architectures {
+			if err := buildRepositoryFiles(ctx, ownerID, pv, repository, architecture); err != nil {
+				return fmt.Errorf("failed to build repository files [%s/%s]: %w", repository, architecture, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// BuildSpecificRepositoryFiles builds the database files for the repository and architecture
+func BuildSpecificRepositoryFiles(ctx context.Context, ownerID int64, repository, architecture string) error {
+	pv, err := GetOrCreateRepositoryVersion(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+
+	architectures := container.SetOf(architecture)
+	if architecture == arch_module.AnyArch {
+		// Packages of the "any" architecture are part of the databases of all other architectures
+		additionalArchitectures, err := arch_model.GetArchitectures(ctx, ownerID, repository)
+		if err != nil {
+			return err
+		}
+		architectures.AddMultiple(additionalArchitectures...)
+	}
+
+	for architecture := range architectures {
+		if err := buildRepositoryFiles(ctx, ownerID, pv, repository, architecture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type packageData struct {
+	Package         *packages_model.Package
+	Version         *packages_model.PackageVersion
+	File            *packages_model.PackageFile
+	Blob            *packages_model.PackageBlob
+	VersionMetadata *arch_module.VersionMetadata
+	FileMetadata    *arch_module.FileMetadata
+	Signature       string
+}
+
+func searchPackageFiles(ctx context.Context, ownerID int64, repository, architecture string) ([]*packages_model.PackageFile, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		OwnerID:     ownerID,
+		PackageType: packages_model.TypeArch,
+		Query:       "%.pkg.tar.%",
+		Properties: map[string]string{
+			arch_module.PropertyRepository:   repository,
+			arch_module.PropertyArchitecture: architecture,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pfs, nil
+}
+
+// https://man.archlinux.org/man/repo-add.8
+func buildRepositoryFiles(ctx context.Context, ownerID int64, repoVersion *packages_model.PackageVersion, repository, architecture string) error {
+	pfs, err := searchPackageFiles(ctx, ownerID, repository, architecture)
+	if err != nil {
+		return err
+	}
+	if architecture != arch_module.AnyArch {
+		// Add all packages of the "any" architecture too
+		anyFiles, err := searchPackageFiles(ctx, ownerID, repository, arch_module.AnyArch)
+		if err != nil {
+			return err
+		}
+		pfs = append(pfs, anyFiles...)
+	}
+
+	compositeKey := fmt.Sprintf("%s|%s", repository, architecture)
+
+	// Delete the databases if there are no packages
+	if len(pfs) == 0 {
+		for _, suffix := range []string{DatabaseSuffix, DatabaseSuffix + SignatureSuffix, FilesSuffix, FilesSuffix + SignatureSuffix} {
+			pf, err := packages_model.GetFileForVersionByName(ctx, repoVersion.ID, repository+suffix, compositeKey)
+			if err != nil {
+				if errors.Is(err, util.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			if err := packages_service.DeletePackageFile(ctx, pf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pds := make([]*packageData, 0, len(pfs))
+	for _, pf := range pfs {
+		pv, err := packages_model.GetVersionByID(ctx, pf.VersionID)
+		if err != nil {
+			return err
+		}
+		p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+		if err != nil {
+			return err
+		}
+		pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+		if err != nil {
+			return err
+		}
+
+		pd := &packageData{
+			Package: p,
+			Version: pv,
+			File:    pf,
+			Blob:    pb,
+		}
+
+		if err := json.Unmarshal([]byte(pv.MetadataJSON), &pd.VersionMetadata); err != nil {
+			return err
+		}
+
+		pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeFile, pf.ID, arch_module.PropertyMetadata)
+		if err != nil {
+			return err
+		}
+		if len(pps) > 0 {
+			if err := json.Unmarshal([]byte(pps[0].Value), &pd.FileMetadata); err != nil {
+				return err
+			}
+		}
+		if pd.FileMetadata == nil {
+			pd.FileMetadata = &arch_module.FileMetadata{}
+		}
+
+		pps, err = packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeFile, pf.ID, arch_module.PropertySignature)
+		if err != nil {
+			return err
+		}
+		if len(pps) > 0 {
+			pd.Signature = pps[0].Value
+		}
+
+		pds = append(pds, pd)
+	}
+
+	for _, db := range []struct {
+		Suffix       string
+		IncludeFiles bool
+	}{
+		{DatabaseSuffix, false},
+		{FilesSuffix, true},
+	} {
+		content, _ := packages_module.NewHashedBuffer()
+		defer content.Close()
+
+		if err := writeDatabase(content, pds, db.IncludeFiles); err != nil {
+			return err
+		}
+
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		signature, _ := packages_module.NewHashedBuffer()
+		defer signature.Close()
+
+		if err := sign(ctx, ownerID, signature, content); err != nil {
+			return err
+		}
+
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		for _, file := range []struct {
+			Name string
+			Data packages_module.HashedSizeReader
+		}{
+			{repository + db.Suffix, content},
+			{repository + db.Suffix + SignatureSuffix, signature},
+		} {
+			_, err = packages_service.AddFileToPackageVersionInternal(
+				ctx,
+				repoVersion,
+				&packages_service.PackageFileCreationInfo{
+					PackageFileInfo: packages_service.PackageFileInfo{
+						Filename:     file.Name,
+						CompositeKey: compositeKey,
+					},
+					Creator:           user_model.NewGhostUser(),
+					Data:              file.Data,
+					IsLead:            false,
+					OverwriteExisting: true,
+				},
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeDatabase writes a gzipped tar archive which contains a "desc" file (and a "files" file) for every package
+func writeDatabase(w io.Writer, pds []*packageData, includeFiles bool) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+
+	for _, pd := range pds {
+		dir := pd.Package.Name + "-" + pd.Version.Version
+
+		if err := writeTarEntry(tw, &tar.Header{
+			Name:     dir + "/",
+			Typeflag: tar.TypeDir,
+			Mode:     0o755,
+			ModTime:  pd.File.CreatedUnix.AsLocalTime(),
+		}, nil); err != nil {
+			return err
+		}
+
+		if err := writeTarEntry(tw, &tar.Header{
+			Name:    dir + "/desc",
+			Mode:    0o644,
+			ModTime: pd.File.CreatedUnix.AsLocalTime(),
+		}, buildDesc(pd)); err != nil {
+			return err
+		}
+
+		if includeFiles {
+			var buf bytes.Buffer
+			writeField(&buf, "FILES", pd.FileMetadata.Files...)
+
+			if err := writeTarEntry(tw, &tar.Header{
+				Name:    dir + "/files",
+				Mode:    0o644,
+				ModTime: pd.File.CreatedUnix.AsLocalTime(),
+			}, buf.Bytes()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeTarEntry(tw *tar.Writer, hdr *tar.Header, content []byte) error {
+	hdr.Size = int64(len(content))
+	if hdr.ModTime.IsZero() {
+		hdr.ModTime = time.Now()
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
+
+// https://gitlab.archlinux.org/pacman/pacman/-/blob/master/lib/libalpm/be_sync.c
+func buildDesc(pd *packageData) []byte {
+	var buf bytes.Buffer
+
+	writeField(&buf, "FILENAME", pd.File.Name)
+	writeField(&buf, "NAME", pd.Package.Name)
+	writeField(&buf, "BASE", pd.VersionMetadata.Base)
+	writeField(&buf, "VERSION", pd.Version.Version)
+	writeField(&buf, "DESC", pd.VersionMetadata.Description)
+	writeField(&buf, "GROUPS", pd.VersionMetadata.Groups...)
+	writeField(&buf, "CSIZE", fmt.Sprint(pd.Blob.Size))
+	writeField(&buf, "ISIZE", fmt.Sprint(pd.FileMetadata.InstalledSize))
+	writeField(&buf, "MD5SUM", pd.Blob.HashMD5)
+	writeField(&buf, "SHA256SUM", pd.Blob.HashSHA256)
+	writeField(&buf, "PGPSIG", pd.Signature)
+	writeField(&buf, "URL", pd.VersionMetadata.ProjectURL)
+	writeField(&buf, "LICENSE", pd.VersionMetadata.Licenses...)
+	writeField(&buf, "ARCH", pd.FileMetadata.Architecture)
+	writeField(&buf, "BUILDDATE", fmt.Sprint(pd.FileMetadata.BuildDate))
+	writeField(&buf, "PACKAGER", pd.FileMetadata.Packager)
+	writeField(&buf, "REPLACES", pd.FileMetadata.Replaces...)
+	writeField(&buf, "CONFLICTS", pd.FileMetadata.Conflicts...)
+	writeField(&buf, "PROVIDES", pd.FileMetadata.Provides...)
+	writeField(&buf, "DEPENDS", pd.FileMetadata.Depends...)
+	writeField(&buf, "OPTDEPENDS", pd.FileMetadata.OptDepends...)
+	writeField(&buf, "MAKEDEPENDS", pd.FileMetadata.MakeDepends...)
+	writeField(&buf, "CHECKDEPENDS", pd.FileMetadata.CheckDepends...)
+
+	return buf.Bytes()
+}
+
+func writeField(w io.Writer, name string, values ...string) {
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		return
+	}
+	fmt.Fprintf(w, "%%%s%%\n", name)
+	for _, value := range values {
+		fmt.Fprintln(w, value)
+	}
+	fmt.Fprintln(w)
+}
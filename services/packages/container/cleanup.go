@@ -102,6 +102,26 @@ func ShouldBeSkipped(ctx context.Context, pcr *packages_model.PackageCleanupRule
 		if has {
 			return true, nil
 		}
+
+		// Keep signatures and other attached artifacts as long as their subject exists
+		pps, err := packages_model.GetPropertiesByName(ctx, packages_model.PropertyTypeVersion, pv.ID, container_module.PropertyManifestSubject)
+		if err != nil {
+			return false, err
+		}
+		if len(pps) != 0 {
+			pvs, err := container_model.GetManifestVersions(ctx, &container_model.BlobSearchOptions{
+				OwnerID:    p.OwnerID,
+				Image:      p.LowerName,
+				Digest:     pps[0].Value,
+				IsManifest: true,
+			})
+			if err != nil {
+				return false, err
+			}
+			if len(pvs) != 0 {
+				return true, nil
+			}
+		}
 	}
 
 	return false, nil
@@ -0,0 +1,298 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var (
+	ErrUpstreamNotExist        = util.NewNotExistErrorf("upstream manifest or blob does not exist")
+	ErrInvalidProxyUpstream    = util.NewInvalidArgumentErrorf("container proxy upstream is not configured")
+	ErrProxyUpstreamNotAllowed = util.NewPermissionDeniedErrorf("container proxy upstream is not allowed for the owner")
+
+	challengeParamPattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+	manifestMediaTypes = []string{
+		oci.MediaTypeImageManifest,
+		oci.MediaTypeImageIndex,
+		"application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+	}
+)
+
+// GetAllowedProxyUpstreams gets the names of the upstream registries the owner uses as pull-through cache
+func GetAllowedProxyUpstreams(ctx context.Context, owner *user_model.User) ([]string, error) {
+	value, err := user_model.GetUserSetting(ctx, owner.ID, container_module.SettingKeyProxyUpstreams)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(setting.Packages.ContainerProxyUpstreams))
+	for _, name := range strings.Split(value, ",") {
+		// upstreams may have been removed from the configuration or not be allowed for the owner any longer in the meantime
+		if upstream := setting.GetContainerProxyUpstream(name); upstream != nil && upstream.IsAllowedOwner(owner.Name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// IsProxyUpstreamAllowed checks if the owner uses the upstream registry as pull-through cache
+func IsProxyUpstreamAllowed(ctx context.Context, owner *user_model.User, name string) (bool, error) {
+	names, err := GetAllowedProxyUpstreams(ctx, owner)
+	if err != nil {
+		return false, err
+	}
+	return util.SliceContainsString(names, name), nil
+}
+
+// GetAvailableProxyUpstreams gets the upstream registries the owner can use as pull-through cache
+func GetAvailableProxyUpstreams(owner *user_model.User) []*setting.ContainerProxyUpstream {
+	upstreams := make([]*setting.ContainerProxyUpstream, 0, len(setting.Packages.ContainerProxyUpstreams))
+	for _, upstream := range setting.Packages.ContainerProxyUpstreams {
+		if upstream.IsAllowedOwner(owner.Name) {
+			upstreams = append(upstreams, upstream)
+		}
+	}
+	return upstreams
+}
+
+// SetAllowedProxyUpstreams sets the names of the upstream registries the owner uses as pull-through cache
+func SetAllowedProxyUpstreams(ctx context.Context, owner *user_model.User, names []string) error {
+	for _, name := range names {
+		upstream := setting.GetContainerProxyUpstream(name)
+		if upstream == nil {
+			return ErrInvalidProxyUpstream
+		}
+		if !upstream.IsAllowedOwner(owner.Name) {
+			return ErrProxyUpstreamNotAllowed
+		}
+	}
+
+	if len(names) == 0 {
+		return user_model.DeleteUserSetting(ctx, owner.ID, container_module.SettingKeyProxyUpstreams)
+	}
+	return user_model.SetUserSetting(ctx, owner.ID, container_module.SettingKeyProxyUpstreams, strings.Join(names, ","))
+}
+
+// UpstreamClient fetches manifests and blobs from an upstream registry
+// https://github.com/opencontainers/distribution-spec/blob/main/spec.md#pull
+type UpstreamClient struct {
+	upstream *setting.ContainerProxyUpstream
+	client   *http.Client
+	token    string
+}
+
+// UpstreamResponse is the content of a manifest or blob served by the upstream registry
+type UpstreamResponse struct {
+	MediaType     string
+	Digest        string
+	ContentLength int64 // -1 if the length is unknown
+	Body          io.ReadCloser
+}
+
+// NewUpstreamClient creates a client for the upstream registry
+func NewUpstreamClient(upstream *setting.ContainerProxyUpstream) *UpstreamClient {
+	return &UpstreamClient{
+		upstream: upstream,
+		client: &http.Client{
+			Timeout: setting.Packages.ContainerProxyTimeout,
+			Transport: &http.Transport{
+				Proxy: proxy.Proxy(),
+			},
+		},
+	}
+}
+
+// GetManifest fetches a manifest by tag or digest
+func (c *UpstreamClient) GetManifest(ctx context.Context, image, reference string) (*UpstreamResponse, error) {
+	return c.request(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", image, reference))
+}
+
+// GetManifestDigest gets the digest of the manifest a tag currently points to
+func (c *UpstreamClient) GetManifestDigest(ctx context.Context, image, reference string) (string, error) {
+	resp, err := c.request(ctx, http.MethodHead, fmt.Sprintf("/v2/%s/manifests/%s", image, reference))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if resp.Digest == "" {
+		return "", fmt.Errorf("upstream registry %s returned no digest for %s:%s", c.upstream.Name, image, reference)
+	}
+	return resp.Digest, nil
+}
+
+// GetBlob fetches a blob by digest
+func (c *UpstreamClient) GetBlob(ctx context.Context, image, digest string) (*UpstreamResponse, error) {
+	return c.request(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/blobs/%s", image, digest))
+}
+
+func (c *UpstreamClient) request(ctx context.Context, method, path string) (*UpstreamResponse, error) {
+	resp, err := c.send(ctx, method, c.upstream.URL+path)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+
+		if resp, err = c.send(ctx, method, c.upstream.URL+path); err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return &UpstreamResponse{
+			MediaType:     resp.Header.Get("Content-Type"),
+			Digest:        resp.Header.Get("Docker-Content-Digest"),
+			ContentLength: resp.ContentLength,
+			Body:          resp.Body,
+		}, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrUpstreamNotExist
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("upstream registry %s returned status %d for %s %s", c.upstream.Name, resp.StatusCode, method, path)
+	}
+}
+
+func (c *UpstreamClient) send(ctx context.Context, method, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else if c.upstream.Username != "" {
+		req.SetBasicAuth(c.upstream.Username, c.upstream.Password)
+	}
+
+	return c.client.Do(req)
+}
+
+// authenticate requests a token from the authorization service named in the challenge of the registry
+// https://distribution.github.io/distribution/spec/auth/token/
+func (c *UpstreamClient) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return fmt.Errorf("upstream registry %s requires unsupported authentication: %q", c.upstream.Name, challenge)
+	}
+
+	tokenURL, err := c.getTokenURL(params["realm"])
+	if err != nil {
+		return err
+	}
+
+	q := tokenURL.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		q.Set("scope", params["scope"])
+	}
+	tokenURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	if c.upstream.Username != "" {
+		req.SetBasicAuth(c.upstream.Username, c.upstream.Password)
+	}
+
+	// the credentials must not be sent anywhere else, so redirects of the authorization service are not followed
+	tokenClient := *c.client
+	tokenClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	resp, err := tokenClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("authorization service of upstream registry %s returned status %d", c.upstream.Name, resp.StatusCode)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return fmt.Errorf("authorization service of upstream registry %s returned no token", c.upstream.Name)
+	}
+	return nil
+}
+
+// getTokenURL returns the configured authorization service of the registry, or the realm of the challenge if it's on the host of the registry
+// The realm must use https unless the registry itself uses http.
+func (c *UpstreamClient) getTokenURL(realm string) (*url.URL, error) {
+	if c.upstream.TokenURL != "" {
+		return url.Parse(c.upstream.TokenURL)
+	}
+
+	upstreamURL, err := url.Parse(c.upstream.URL)
+	if err != nil {
+		return nil, err
+	}
+	realmURL, err := url.Parse(realm)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(realmURL.Host, upstreamURL.Host) {
+		return nil, fmt.Errorf("authorization service %q of upstream registry %s is not on the host of the registry, its TOKEN_URL must be configured", realm, c.upstream.Name)
+	}
+	if realmURL.Scheme != "https" && (realmURL.Scheme != "http" || upstreamURL.Scheme != "http") {
+		return nil, fmt.Errorf("authorization service %q of upstream registry %s uses an insecure scheme", realm, c.upstream.Name)
+	}
+	return realmURL, nil
+}
+
+// parseChallenge parses a header like `Bearer realm="https://auth.example.com/token",service="registry",scope="repository:image:pull"`
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+
+	params := make(map[string]string)
+	for _, m := range challengeParamPattern.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	return scheme, params
+}
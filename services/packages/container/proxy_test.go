@@ -0,0 +1,148 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package container
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull,push"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:library/alpine:pull,push",
+	}, params)
+
+	scheme, params = parseChallenge(`Basic realm="registry"`)
+	assert.Equal(t, "Basic", scheme)
+	assert.Equal(t, map[string]string{"realm": "registry"}, params)
+}
+
+func TestUpstreamClient(t *testing.T) {
+	const manifestDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000001"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			user, password, _ := r.BasicAuth()
+			if user != "user" || password != "secret" || r.URL.Query().Get("scope") != "repository:library/alpine:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"abc"}`))
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test",scope="repository:library/alpine:pull"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/library/alpine/manifests/latest":
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", manifestDigest)
+			if r.Method == http.MethodGet {
+				_, _ = w.Write([]byte(`{}`))
+			}
+		case "/v2/library/alpine/blobs/" + manifestDigest:
+			_, _ = w.Write([]byte(`blob`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	newClient := func() *UpstreamClient {
+		return NewUpstreamClient(&setting.ContainerProxyUpstream{
+			Name:     "test",
+			URL:      server.URL,
+			Username: "user",
+			Password: "secret",
+		})
+	}
+
+	resp, err := newClient().GetManifest(context.Background(), "library/alpine", "latest")
+	assert.NoError(t, err)
+	assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", resp.MediaType)
+	assert.Equal(t, manifestDigest, resp.Digest)
+	content, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "{}", string(content))
+
+	digest, err := newClient().GetManifestDigest(context.Background(), "library/alpine", "latest")
+	assert.NoError(t, err)
+	assert.Equal(t, manifestDigest, digest)
+
+	resp, err = newClient().GetBlob(context.Background(), "library/alpine", manifestDigest)
+	assert.NoError(t, err)
+	content, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "blob", string(content))
+
+	_, err = newClient().GetManifest(context.Background(), "library/alpine", "unknown")
+	assert.ErrorIs(t, err, ErrUpstreamNotExist)
+
+	c := newClient()
+	c.upstream.Password = "wrong"
+	_, err = c.GetManifest(context.Background(), "library/alpine", "latest")
+	assert.Error(t, err)
+}
+
+func TestUpstreamClientTokenURL(t *testing.T) {
+	var hostileRequests int
+	hostile := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hostileRequests++
+		_, _ = w.Write([]byte(`{"token":"abc"}`))
+	}))
+	defer hostile.Close()
+
+	var realm string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`",service="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	newClient := func(tokenURL string) *UpstreamClient {
+		return NewUpstreamClient(&setting.ContainerProxyUpstream{
+			Name:     "test",
+			URL:      server.URL,
+			TokenURL: tokenURL,
+			Username: "user",
+			Password: "secret",
+		})
+	}
+
+	// the credentials are not sent to an authorization service on another host
+	realm = hostile.URL + "/token"
+	_, err := newClient("").GetManifest(context.Background(), "library/alpine", "latest")
+	assert.ErrorContains(t, err, "TOKEN_URL")
+	assert.Zero(t, hostileRequests)
+
+	// the realm must use https if the registry does
+	c := newClient("")
+	c.upstream.URL = "https://registry.example.com"
+	_, err = c.getTokenURL("http://registry.example.com/token")
+	assert.ErrorContains(t, err, "insecure")
+	u, err := c.getTokenURL("https://registry.example.com/token")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://registry.example.com/token", u.String())
+
+	// the configured authorization service is used instead of the realm
+	u, err = newClient(hostile.URL + "/configured").getTokenURL(server.URL + "/token")
+	assert.NoError(t, err)
+	assert.Equal(t, hostile.URL+"/configured", u.String())
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package packages
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// GetOrCreateKeyPair gets the keys stored in the settings of the owner, or generates and stores them if they don't exist.
+// They are used by the registries which sign their repository files.
+func GetOrCreateKeyPair(ctx context.Context, ownerID int64, settingKeyPrivate, settingKeyPublic string, generate func() (string, string, error)) (string, string, error) {
+	priv, err := user_model.GetSetting(ctx, ownerID, settingKeyPrivate)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	pub, err := user_model.GetSetting(ctx, ownerID, settingKeyPublic)
+	if err != nil && !errors.Is(err, util.ErrNotExist) {
+		return "", "", err
+	}
+
+	if priv == "" || pub == "" {
+		priv, pub, err = generate()
+		if err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, settingKeyPrivate, priv); err != nil {
+			return "", "", err
+		}
+
+		if err := user_model.SetUserSetting(ctx, ownerID, settingKeyPublic, pub); err != nil {
+			return "", "", err
+		}
+	}
+
+	return priv, pub, nil
+}
+
+// GeneratePGPKeyPair generates armored PGP keys with the name
+func GeneratePGPKeyPair(name string) (string, string, error) {
+	e, err := openpgp.NewEntity("", name, "", nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	var priv strings.Builder
+	var pub strings.Builder
+
+	w, err := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if err := e.SerializePrivate(w, nil); err != nil {
+		return "", "", err
+	}
+	w.Close()
+
+	w, err = armor.Encode(&pub, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if err := e.Serialize(w); err != nil {
+		return "", "", err
+	}
+	w.Close()
+
+	return priv.String(), pub.String(), nil
+}
+
+// DetachSignPGP writes a detached binary signature of the content signed with the armored private key
+func DetachSignPGP(priv string, w io.Writer, r io.Reader) error {
+	block, err := armor.Decode(strings.NewReader(priv))
+	if err != nil {
+		return err
+	}
+
+	e, err := openpgp.ReadEntity(packet.NewReader(block.Body))
+	if err != nil {
+		return err
+	}
+
+	return openpgp.DetachSign(w, e, r, nil)
+}
+
+// PGPKeyID returns the uppercase hex key id of the armored public key
+func PGPKeyID(pub string) (string, error) {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pub))
+	if err != nil {
+		return "", err
+	}
+	if len(keyring) == 0 {
+		return "", errors.New("no key found")
+	}
+	return strings.ToUpper(keyring[0].PrimaryKey.KeyIdString()), nil
+}
@@ -10,16 +10,19 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/models/db"
 	packages_model "code.gitea.io/gitea/models/packages"
+	quota_model "code.gitea.io/gitea/models/quota"
 	repo_model "code.gitea.io/gitea/models/repo"
 	user_model "code.gitea.io/gitea/models/user"
 	"code.gitea.io/gitea/modules/json"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/optional"
 	packages_module "code.gitea.io/gitea/modules/packages"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/storage"
 	notify_service "code.gitea.io/gitea/services/notify"
@@ -76,13 +79,19 @@ func CreatePackageOrAddFileToExisting(ctx context.Context, pvci *PackageCreation
 }
 
 func createPackageAndAddFile(ctx context.Context, pvci *PackageCreationInfo, pfci *PackageFileCreationInfo, allowDuplicate bool) (*packages_model.PackageVersion, *packages_model.PackageFile, error) {
+	// the access scope of the request is not available in the transaction context
+	if err := CheckPackageWriteAccess(ctx, pvci.Owner.ID, pvci.PackageType, pvci.Name); err != nil {
+		return nil, nil, err
+	}
+	repoID := LinkedRepositoryID(ctx, pvci.Owner.ID)
+
 	dbCtx, committer, err := db.TxContext(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer committer.Close()
 
-	pv, created, err := createPackageAndVersion(dbCtx, pvci, allowDuplicate)
+	pv, created, err := createPackageAndVersion(dbCtx, pvci, repoID, allowDuplicate)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -119,12 +128,14 @@ func createPackageAndAddFile(ctx context.Context, pvci *PackageCreationInfo, pfc
 	return pv, pf, nil
 }
 
-func createPackageAndVersion(ctx context.Context, pvci *PackageCreationInfo, allowDuplicate bool) (*packages_model.PackageVersion, bool, error) {
+// createPackageAndVersion creates the package version, the package is linked to the repository if it's created
+func createPackageAndVersion(ctx context.Context, pvci *PackageCreationInfo, repoID int64, allowDuplicate bool) (*packages_model.PackageVersion, bool, error) {
 	log.Trace("Creating package: %v, %v, %v, %s, %s, %+v, %+v, %v", pvci.Creator.ID, pvci.Owner.ID, pvci.PackageType, pvci.Name, pvci.Version, pvci.PackageProperties, pvci.VersionProperties, allowDuplicate)
 
 	packageCreated := true
 	p := &packages_model.Package{
 		OwnerID:          pvci.Owner.ID,
+		RepoID:           repoID,
 		Type:             pvci.PackageType,
 		Name:             pvci.Name,
 		LowerName:        strings.ToLower(pvci.Name),
@@ -190,6 +201,10 @@ func createPackageAndVersion(ctx context.Context, pvci *PackageCreationInfo, all
 
 // AddFileToExistingPackage adds a file to an existing package. If the package does not exist, ErrPackageNotExist is returned
 func AddFileToExistingPackage(ctx context.Context, pvi *PackageInfo, pfci *PackageFileCreationInfo) (*packages_model.PackageFile, error) {
+	if err := CheckPackageWriteAccess(ctx, pvi.Owner.ID, pvi.PackageType, pvi.Name); err != nil {
+		return nil, err
+	}
+
 	return addFileToPackageWrapper(ctx, func(ctx context.Context) (*packages_model.PackageFile, *packages_model.PackageBlob, bool, error) {
 		pv, err := packages_model.GetVersionByNameAndVersion(ctx, pvi.Owner.ID, pvi.PackageType, pvi.Name, pvi.Version)
 		if err != nil {
@@ -355,6 +370,8 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 	switch packageType {
 	case packages_model.TypeAlpine:
 		typeSpecificSize = setting.Packages.LimitSizeAlpine
+	case packages_model.TypeArch:
+		typeSpecificSize = setting.Packages.LimitSizeArch
 	case packages_model.TypeCargo:
 		typeSpecificSize = setting.Packages.LimitSizeCargo
 	case packages_model.TypeChef:
@@ -393,6 +410,8 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 		typeSpecificSize = setting.Packages.LimitSizeRubyGems
 	case packages_model.TypeSwift:
 		typeSpecificSize = setting.Packages.LimitSizeSwift
+	case packages_model.TypeTerraform:
+		typeSpecificSize = setting.Packages.LimitSizeTerraform
 	case packages_model.TypeVagrant:
 		typeSpecificSize = setting.Packages.LimitSizeVagrant
 	}
@@ -413,6 +432,15 @@ func CheckSizeQuotaExceeded(ctx context.Context, doer, owner *user_model.User, p
 		}
 	}
 
+	if err := quota_model.CheckQuota(ctx, owner.ID, quota_model.SubjectPackages, uploadSize); err != nil {
+		if errors.Is(err, quota_model.ErrQuotaExceeded) {
+			log.Debug("Package upload of %s rejected: %v", owner.Name, err)
+			return ErrQuotaTotalSize
+		}
+		log.Error("CheckQuota failed: %v", err)
+		return err
+	}
+
 	return nil
 }
 
@@ -552,6 +580,25 @@ func DeletePackageVersionAndReferences(ctx context.Context, pv *packages_model.P
 		}
 	}
 
+	if err := packages_model.DeleteVersionVulnerabilities(ctx, pv.ID); err != nil {
+		return err
+	}
+
+	// the SBOM documents of the version are stored in an internal package version
+	sbomFiles, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		Properties: map[string]string{
+			sbom_module.PropertyVersionID: strconv.FormatInt(pv.ID, 10),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	for _, pf := range sbomFiles {
+		if err := DeletePackageFile(ctx, pf); err != nil {
+			return err
+		}
+	}
+
 	return packages_model.DeleteVersionByID(ctx, pv.ID)
 }
 
@@ -10,7 +10,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -22,7 +21,6 @@ import (
 	"code.gitea.io/gitea/modules/json"
 	packages_module "code.gitea.io/gitea/modules/packages"
 	rpm_module "code.gitea.io/gitea/modules/packages/rpm"
-	"code.gitea.io/gitea/modules/util"
 	packages_service "code.gitea.io/gitea/services/packages"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
@@ -38,62 +36,9 @@ func GetOrCreateRepositoryVersion(ctx context.Context, ownerID int64) (*packages
 
 // GetOrCreateKeyPair gets or creates the PGP keys used to sign repository metadata files
 func GetOrCreateKeyPair(ctx context.Context, ownerID int64) (string, string, error) {
-	priv, err := user_model.GetSetting(ctx, ownerID, rpm_module.SettingKeyPrivate)
-	if err != nil && !errors.Is(err, util.ErrNotExist) {
-		return "", "", err
-	}
-
-	pub, err := user_model.GetSetting(ctx, ownerID, rpm_module.SettingKeyPublic)
-	if err != nil && !errors.Is(err, util.ErrNotExist) {
-		return "", "", err
-	}
-
-	if priv == "" || pub == "" {
-		priv, pub, err = generateKeypair()
-		if err != nil {
-			return "", "", err
-		}
-
-		if err := user_model.SetUserSetting(ctx, ownerID, rpm_module.SettingKeyPrivate, priv); err != nil {
-			return "", "", err
-		}
-
-		if err := user_model.SetUserSetting(ctx, ownerID, rpm_module.SettingKeyPublic, pub); err != nil {
-			return "", "", err
-		}
-	}
-
-	return priv, pub, nil
-}
-
-func generateKeypair() (string, string, error) {
-	e, err := openpgp.NewEntity("", "RPM Registry", "", nil)
-	if err != nil {
-		return "", "", err
-	}
-
-	var priv strings.Builder
-	var pub strings.Builder
-
-	w, err := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
-	if err != nil {
-		return "", "", err
-	}
-	if err := e.SerializePrivate(w, nil); err != nil {
-		return "", "", err
-	}
-	w.Close()
-
-	w, err = armor.Encode(&pub, openpgp.PublicKeyType, nil)
-	if err != nil {
-		return "", "", err
-	}
-	if err := e.Serialize(w); err != nil {
-		return "", "", err
-	}
-	w.Close()
-
-	return priv.String(), pub.String(), nil
+	return packages_service.GetOrCreateKeyPair(ctx, ownerID, rpm_module.SettingKeyPrivate, rpm_module.SettingKeyPublic, func() (string, string, error) {
+		return packages_service.GeneratePGPKeyPair("RPM Registry")
+	})
 }
 
 // BuildAllRepositoryFiles (re)builds all repository files for every available group
@@ -0,0 +1,37 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"context"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	notify_service "code.gitea.io/gitea/services/notify"
+)
+
+func init() {
+	notify_service.RegisterNotifier(NewNotifier())
+}
+
+type sbomNotifier struct {
+	notify_service.NullNotifier
+}
+
+var _ notify_service.Notifier = &sbomNotifier{}
+
+// NewNotifier create a new sbomNotifier notifier which matches new package versions against the vulnerability database
+func NewNotifier() notify_service.Notifier {
+	return &sbomNotifier{}
+}
+
+func (m *sbomNotifier) PackageCreate(ctx context.Context, doer *user_model.User, pd *packages_model.PackageDescriptor) {
+	if Ecosystem(pd.Package.Type) == "" {
+		return
+	}
+	if err := MatchVersion(ctx, pd.Package, pd.Version); err != nil {
+		log.Error("Error matching vulnerabilities of package version %d: %v", pd.Version.ID, err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// Document is a SBOM document attached to a package version
+type Document struct {
+	Format string
+	File   *packages_model.PackageFile
+	Blob   *packages_model.PackageBlob
+}
+
+// AttachDocument stores the SBOM document for the package version and matches the listed components against the vulnerability database.
+// A document of the same format attached to the version before is replaced.
+func AttachDocument(ctx context.Context, doer *user_model.User, p *packages_model.Package, pv *packages_model.PackageVersion, r io.Reader) (*Document, error) {
+	buf, err := packages_module.CreateHashedBufferFromReader(io.LimitReader(r, sbom_module.MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Close()
+
+	if buf.Size() > sbom_module.MaxDocumentSize {
+		return nil, sbom_module.ErrDocumentTooLarge
+	}
+
+	doc, err := sbom_module.ParseDocument(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := buf.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	sbomVersion, err := packages_service.GetOrCreateInternalPackageVersion(ctx, p.OwnerID, p.Type, sbom_module.PackageName, sbom_module.PackageVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	versionID := strconv.FormatInt(pv.ID, 10)
+
+	pf, err := packages_service.AddFileToPackageVersionInternal(
+		ctx,
+		sbomVersion,
+		&packages_service.PackageFileCreationInfo{
+			PackageFileInfo: packages_service.PackageFileInfo{
+				Filename:     fmt.Sprintf("%d.%s.json", pv.ID, doc.Format),
+				CompositeKey: versionID,
+			},
+			Creator:           doer,
+			Data:              buf,
+			IsLead:            false,
+			OverwriteExisting: true,
+			Properties: map[string]string{
+				sbom_module.PropertyVersionID: versionID,
+				sbom_module.PropertyFormat:    doc.Format,
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := MatchVersion(ctx, p, pv); err != nil {
+		return nil, err
+	}
+
+	pb, err := packages_model.GetBlobByID(ctx, pf.BlobID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Format: doc.Format,
+		File:   pf,
+		Blob:   pb,
+	}, nil
+}
+
+// AttachExtractedDocuments attaches the SBOM documents extracted from an uploaded package
+// Errors are only logged because they must not break the upload of the package.
+func AttachExtractedDocuments(ctx context.Context, pv *packages_model.PackageVersion, documents [][]byte) {
+	if len(documents) == 0 {
+		return
+	}
+
+	p, err := packages_model.GetPackageByID(ctx, pv.PackageID)
+	if err != nil {
+		log.Error("Error getting package %d: %v", pv.PackageID, err)
+		return
+	}
+
+	for _, data := range documents {
+		if _, err := AttachDocument(ctx, user_model.NewGhostUser(), p, pv, bytes.NewReader(data)); err != nil {
+			if errors.Is(err, util.ErrInvalidArgument) {
+				log.Debug("Skipping SBOM document of package version %d: %v", pv.ID, err)
+			} else {
+				log.Error("Error attaching SBOM document to package version %d: %v", pv.ID, err)
+			}
+		}
+	}
+}
+
+// GetDocuments gets the SBOM documents attached to the package version ordered by format
+func GetDocuments(ctx context.Context, pv *packages_model.PackageVersion) ([]*Document, error) {
+	return searchDocuments(ctx, map[string]string{
+		sbom_module.PropertyVersionID: strconv.FormatInt(pv.ID, 10),
+	})
+}
+
+// GetDocument gets the SBOM document of the format attached to the package version
+func GetDocument(ctx context.Context, pv *packages_model.PackageVersion, format string) (*Document, error) {
+	docs, err := searchDocuments(ctx, map[string]string{
+		sbom_module.PropertyVersionID: strconv.FormatInt(pv.ID, 10),
+		sbom_module.PropertyFormat:    format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, packages_model.ErrPackageFileNotExist
+	}
+	return docs[0], nil
+}
+
+func searchDocuments(ctx context.Context, properties map[string]string) ([]*Document, error) {
+	pfs, _, err := packages_model.SearchFiles(ctx, &packages_model.PackageFileSearchOptions{
+		Properties: properties,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]*Document, 0, len(pfs))
+	for _, pf := range pfs {
+		pfd, err := packages_model.GetPackageFileDescriptor(ctx, pf)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, &Document{
+			Format: pfd.Properties.GetByName(sbom_module.PropertyFormat),
+			File:   pfd.File,
+			Blob:   pfd.Blob,
+		})
+	}
+
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Format < docs[j].Format
+	})
+
+	return docs, nil
+}
+
+// OpenDocument opens the content of the SBOM document
+func OpenDocument(doc *Document) (io.ReadSeekCloser, error) {
+	return packages_module.NewContentStore().Get(packages_module.BlobHash256Key(doc.Blob.HashSHA256))
+}
+
+// DeleteDocument deletes the SBOM document of the format attached to the package version and updates the matched vulnerabilities
+func DeleteDocument(ctx context.Context, p *packages_model.Package, pv *packages_model.PackageVersion, format string) error {
+	doc, err := GetDocument(ctx, pv, format)
+	if err != nil {
+		return err
+	}
+
+	if err := packages_service.DeletePackageFile(ctx, doc.File); err != nil {
+		return err
+	}
+
+	return MatchVersion(ctx, p, pv)
+}
+
+// readComponents reads the components listed in the SBOM documents attached to the package version
+func readComponents(ctx context.Context, pv *packages_model.PackageVersion) ([]*sbom_module.Component, error) {
+	docs, err := GetDocuments(ctx, pv)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []*sbom_module.Component
+	for _, doc := range docs {
+		s, err := OpenDocument(doc)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := sbom_module.ParseDocument(s)
+		s.Close()
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, parsed.Components...)
+	}
+	return components, nil
+}
@@ -0,0 +1,337 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package sbom
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/optional"
+	"code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/modules/packages/osv"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ecosystems maps the package types to the OSV ecosystems their packages are matched against
+var ecosystems = map[packages_model.Type]string{
+	packages_model.TypeCargo:    osv.EcosystemCratesIO,
+	packages_model.TypeComposer: osv.EcosystemPackagist,
+	packages_model.TypeGo:       osv.EcosystemGo,
+	packages_model.TypeMaven:    osv.EcosystemMaven,
+	packages_model.TypeNpm:      osv.EcosystemNpm,
+	packages_model.TypeNuGet:    osv.EcosystemNuGet,
+	packages_model.TypePub:      osv.EcosystemPub,
+	packages_model.TypePyPI:     osv.EcosystemPyPI,
+	packages_model.TypeRubyGems: osv.EcosystemRubyGems,
+}
+
+// Ecosystem returns the OSV ecosystem of the package type or an empty string if the type can't be matched
+func Ecosystem(packageType packages_model.Type) string {
+	return ecosystems[packageType]
+}
+
+func isKnownEcosystem(ecosystem string) bool {
+	for _, e := range ecosystems {
+		if e == ecosystem {
+			return true
+		}
+	}
+	return false
+}
+
+var pypiNameNormalizer = regexp.MustCompile(`[-_.]+`)
+
+// NormalizeName normalizes the package name so that names of the vulnerability database and of the registry can be compared
+func NormalizeName(ecosystem, name string) string {
+	name = strings.ToLower(name)
+	if ecosystem == osv.EcosystemPyPI {
+		// https://peps.python.org/pep-0503/#normalized-names
+		name = pypiNameNormalizer.ReplaceAllString(name, "-")
+	}
+	return name
+}
+
+// packageName returns the name of the package as used by the vulnerability database
+func packageName(p *packages_model.Package, pv *packages_model.PackageVersion) string {
+	if p.Type == packages_model.TypeMaven {
+		var metadata *maven.Metadata
+		if err := json.Unmarshal([]byte(pv.MetadataJSON), &metadata); err == nil && metadata != nil && metadata.GroupID != "" && metadata.ArtifactID != "" {
+			return metadata.GroupID + ":" + metadata.ArtifactID
+		}
+	}
+	return p.Name
+}
+
+// matcher looks up the vulnerabilities of packages and caches them for repeated lookups
+type matcher struct {
+	cache map[string][]*packages_model.PackageVulnerability
+}
+
+func newMatcher() *matcher {
+	return &matcher{
+		cache: make(map[string][]*packages_model.PackageVulnerability),
+	}
+}
+
+func (m *matcher) vulnerabilities(ctx context.Context, ecosystem, name string) ([]*packages_model.PackageVulnerability, error) {
+	lowerName := NormalizeName(ecosystem, name)
+	key := ecosystem + "/" + lowerName
+	if vulnerabilities, ok := m.cache[key]; ok {
+		return vulnerabilities, nil
+	}
+	vulnerabilities, err := packages_model.GetVulnerabilitiesByPackage(ctx, ecosystem, lowerName)
+	if err != nil {
+		return nil, err
+	}
+	m.cache[key] = vulnerabilities
+	return vulnerabilities, nil
+}
+
+func (m *matcher) match(ctx context.Context, p *packages_model.Package, pv *packages_model.PackageVersion) error {
+	matches := make([]*packages_model.PackageVersionVulnerability, 0, 5)
+	seen := make(map[string]bool)
+
+	add := func(ecosystem, name, version, component string) error {
+		vulnerabilities, err := m.vulnerabilities(ctx, ecosystem, name)
+		if err != nil {
+			return err
+		}
+		for _, v := range vulnerabilities {
+			key := strconv.FormatInt(v.ID, 10) + "|" + component
+			if seen[key] || !v.IsAffected(version) {
+				continue
+			}
+			seen[key] = true
+			matches = append(matches, &packages_model.PackageVersionVulnerability{
+				VulnerabilityID: v.ID,
+				Component:       component,
+			})
+		}
+		return nil
+	}
+
+	ecosystem := Ecosystem(p.Type)
+	name := packageName(p, pv)
+	if ecosystem != "" {
+		if err := add(ecosystem, name, pv.Version, ""); err != nil {
+			return err
+		}
+	}
+
+	components, err := readComponents(ctx, pv)
+	if err != nil {
+		return err
+	}
+	for _, c := range components {
+		if c.Ecosystem == "" || c.PackageName == "" || c.Version == "" {
+			continue
+		}
+		// the document usually lists the package itself which is already matched
+		if c.Ecosystem == ecosystem && NormalizeName(ecosystem, c.PackageName) == NormalizeName(ecosystem, name) && c.Version == pv.Version {
+			continue
+		}
+		if err := add(c.Ecosystem, c.PackageName, c.Version, c.PackageName+"@"+c.Version); err != nil {
+			return err
+		}
+	}
+
+	return packages_model.SetVersionVulnerabilities(ctx, pv.ID, matches)
+}
+
+// MatchVersion matches the package version and the components of its SBOM documents against the vulnerability database
+func MatchVersion(ctx context.Context, p *packages_model.Package, pv *packages_model.PackageVersion) error {
+	return newMatcher().match(ctx, p, pv)
+}
+
+// MatchAll matches all package versions which have a known ecosystem or SBOM documents against the vulnerability database
+func MatchAll(ctx context.Context) error {
+	m := newMatcher()
+	packages := make(map[int64]*packages_model.Package)
+
+	matchVersion := func(pv *packages_model.PackageVersion) error {
+		select {
+		case <-ctx.Done():
+			return db.ErrCancelledf("While matching package vulnerabilities")
+		default:
+		}
+
+		p, ok := packages[pv.PackageID]
+		if !ok {
+			var err error
+			if p, err = packages_model.GetPackageByID(ctx, pv.PackageID); err != nil {
+				return err
+			}
+			packages[pv.PackageID] = p
+		}
+		return m.match(ctx, p, pv)
+	}
+
+	matched := make(map[int64]bool)
+	for packageType := range ecosystems {
+		for page := 1; ; page++ {
+			pvs, _, err := packages_model.SearchVersions(ctx, &packages_model.PackageSearchOptions{
+				Type:       packageType,
+				IsInternal: optional.Some(false),
+				Paginator:  db.NewAbsoluteListOptions((page-1)*200, 200),
+			})
+			if err != nil {
+				return err
+			}
+			for _, pv := range pvs {
+				if err := matchVersion(pv); err != nil {
+					return err
+				}
+				matched[pv.ID] = true
+			}
+			if len(pvs) < 200 {
+				break
+			}
+		}
+	}
+
+	versionIDs, err := packages_model.GetPropertyValuesByName(ctx, packages_model.PropertyTypeFile, sbom_module.PropertyVersionID)
+	if err != nil {
+		return err
+	}
+	for _, value := range versionIDs {
+		versionID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || matched[versionID] {
+			continue
+		}
+		pv, err := packages_model.GetVersionByID(ctx, versionID)
+		if err != nil {
+			if err == packages_model.ErrPackageNotExist {
+				continue
+			}
+			return err
+		}
+		if err := matchVersion(pv); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportDatabase replaces the vulnerability database with the OSV entries found at the path and matches all package versions against it.
+// The path may be a single file or a directory, JSON files contain a single entry and ZIP archives contain multiple JSON files like the dumps of https://osv.dev/.
+func ImportDatabase(ctx context.Context, path string) error {
+	vulnerabilities := make(map[string]*packages_model.PackageVulnerability)
+
+	add := func(r io.Reader, name string) {
+		v, err := osv.ParseVulnerability(r)
+		if err != nil {
+			log.Warn("Skipping invalid OSV entry %s: %v", name, err)
+			return
+		}
+		if v.Withdrawn != nil {
+			return
+		}
+		for _, a := range v.Affected {
+			ecosystem := osv.BaseEcosystem(a.Package.Ecosystem)
+			if !isKnownEcosystem(ecosystem) || a.Package.Name == "" {
+				continue
+			}
+			lowerName := NormalizeName(ecosystem, a.Package.Name)
+			key := v.ID + "|" + ecosystem + "|" + lowerName
+			pv, ok := vulnerabilities[key]
+			if !ok {
+				pv = &packages_model.PackageVulnerability{
+					VulnID:        v.ID,
+					Ecosystem:     ecosystem,
+					LowerName:     lowerName,
+					Aliases:       v.Aliases,
+					Summary:       v.Summary,
+					Severity:      strings.ToUpper(v.DatabaseSpecific.Severity),
+					PublishedUnix: timeutil.TimeStamp(v.Published.Unix()),
+					ModifiedUnix:  timeutil.TimeStamp(v.Modified.Unix()),
+				}
+				if v.Published.IsZero() {
+					pv.PublishedUnix = 0
+				}
+				if v.Modified.IsZero() {
+					pv.ModifiedUnix = 0
+				}
+				vulnerabilities[key] = pv
+			}
+			pv.Versions = append(pv.Versions, a.Versions...)
+			pv.Ranges = append(pv.Ranges, a.Ranges...)
+		}
+	}
+
+	if err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json":
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			add(f, p)
+		case ".zip":
+			zr, err := zip.OpenReader(p)
+			if err != nil {
+				return fmt.Errorf("open %s: %w", p, err)
+			}
+			defer zr.Close()
+			for _, file := range zr.File {
+				if file.FileInfo().IsDir() || !strings.EqualFold(filepath.Ext(file.Name), ".json") {
+					continue
+				}
+				f, err := file.Open()
+				if err != nil {
+					return err
+				}
+				add(f, p+"/"+file.Name)
+				f.Close()
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	log.Info("Importing %d package vulnerabilities from %s", len(vulnerabilities), path)
+
+	if err := db.WithTx(ctx, func(ctx context.Context) error {
+		if err := packages_model.DeleteAllVulnerabilities(ctx); err != nil {
+			return err
+		}
+
+		batch := make([]*packages_model.PackageVulnerability, 0, 50)
+		for _, v := range vulnerabilities {
+			batch = append(batch, v)
+			if len(batch) == cap(batch) {
+				if err := packages_model.InsertVulnerabilities(ctx, batch); err != nil {
+					return err
+				}
+				batch = batch[:0]
+			}
+		}
+		return packages_model.InsertVulnerabilities(ctx, batch)
+	}); err != nil {
+		return err
+	}
+
+	return MatchAll(ctx)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// GetOrCreateKeyPair gets or creates the PGP keys used to sign the checksums of the providers
+func GetOrCreateKeyPair(ctx context.Context, ownerID int64) (string, string, error) {
+	return packages_service.GetOrCreateKeyPair(ctx, ownerID, terraform_module.SettingKeyPrivate, terraform_module.SettingKeyPublic, func() (string, string, error) {
+		return packages_service.GeneratePGPKeyPair("Terraform Registry")
+	})
+}
+
+// GetSigningKey returns the id and the armored public key which Terraform uses to verify the checksums
+func GetSigningKey(ctx context.Context, ownerID int64) (string, string, error) {
+	_, pub, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return "", "", err
+	}
+
+	keyID, err := packages_service.PGPKeyID(pub)
+	if err != nil {
+		return "", "", err
+	}
+	return keyID, pub, nil
+}
+
+// BuildSHA256Sums builds the content of the SHA256SUMS file which lists the checksums of all platform packages of the provider version
+// https://developer.hashicorp.com/terraform/registry/providers/publishing#manually-preparing-a-release
+func BuildSHA256Sums(pd *packages_model.PackageDescriptor) []byte {
+	files := make([]*packages_model.PackageFileDescriptor, len(pd.Files))
+	copy(files, pd.Files)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].File.Name < files[j].File.Name
+	})
+
+	var buf bytes.Buffer
+	for _, pfd := range files {
+		fmt.Fprintf(&buf, "%s  %s\n", pfd.Blob.HashSHA256, pfd.File.Name)
+	}
+	return buf.Bytes()
+}
+
+// SignSHA256Sums creates the detached binary signature of the SHA256SUMS file
+func SignSHA256Sums(ctx context.Context, ownerID int64, content []byte) ([]byte, error) {
+	priv, _, err := GetOrCreateKeyPair(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := packages_service.DetachSignPGP(priv, &buf, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	packages_model "code.gitea.io/gitea/models/packages"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/hostmatcher"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/proxy"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	// ErrNotExist indicates the upstream registry does not provide the requested file
+	ErrNotExist = util.NewNotExistErrorf("upstream file does not exist")
+	// ErrHostNotAllowed indicates the upstream index links to a file on a host which isn't allowed
+	ErrHostNotAllowed = util.NewPermissionDeniedErrorf("upstream file host is not allowed")
+	// ErrFileTooLarge indicates the upstream file exceeds the max file size of the upstream registry
+	ErrFileTooLarge = util.NewInvalidArgumentErrorf("upstream file exceeds the maximum size")
+)
+
+// IsSizeLimitExceeded returns whether the file can't be cached because it exceeds the max file size or the package limits and quotas of the owner
+func IsSizeLimitExceeded(err error) bool {
+	return errors.Is(err, ErrFileTooLarge) ||
+		errors.Is(err, packages_service.ErrQuotaTypeSize) ||
+		errors.Is(err, packages_service.ErrQuotaTotalSize) ||
+		errors.Is(err, packages_service.ErrQuotaTotalCount)
+}
+
+// Client fetches index and package files from the upstream registry of a package type
+type Client struct {
+	packageType  string
+	upstream     *setting.PackageUpstream
+	upstreamURL  *url.URL
+	allowedHosts *hostmatcher.HostMatchList
+	client       *http.Client
+}
+
+// NewClient creates a client for the upstream registry of the package type
+// It returns nil if no upstream registry is configured.
+func NewClient(packageType string) *Client {
+	upstream := setting.GetPackageUpstream(packageType)
+	if upstream == nil {
+		return nil
+	}
+
+	// the URL is validated when the settings are loaded
+	upstreamURL, _ := url.Parse(upstream.URL)
+
+	return &Client{
+		packageType:  packageType,
+		upstream:     upstream,
+		upstreamURL:  upstreamURL,
+		allowedHosts: hostmatcher.ParseSimpleMatchList(fmt.Sprintf("packages.upstream.%s.ALLOWED_HOSTS", packageType), upstream.AllowedHosts),
+		client: &http.Client{
+			Timeout: upstream.Timeout,
+			Transport: &http.Transport{
+				Proxy: proxy.Proxy(),
+			},
+		},
+	}
+}
+
+// URL returns the base URL of the upstream registry
+func (c *Client) URL() string {
+	return c.upstream.URL
+}
+
+// IsPackageAllowed checks if the package may be fetched from the upstream registry
+func (c *Client) IsPackageAllowed(name string) bool {
+	return c.upstream.IsPackageAllowed(name)
+}
+
+// isAllowedURL checks if the absolute URL is on the host of the upstream registry or on one of the allowed hosts
+func (c *Client) isAllowedURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	if strings.EqualFold(parsed.Host, c.upstreamURL.Host) {
+		return true
+	}
+	return c.allowedHosts.MatchHostName(parsed.Hostname())
+}
+
+// Get fetches the file at the path relative to the upstream URL
+// Absolute URLs are fetched as-is, which is used for files the upstream index links to.
+// They must be on the host of the upstream registry or on one of the allowed hosts.
+func (c *Client) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Download fetches the package file at the path into a buffer like Get.
+// The size is checked against the max file size of the upstream registry and the package limits and quotas of the owner
+// before the file is downloaded if the upstream registry announces it, the download is stopped at the max file size otherwise.
+func (c *Client) Download(ctx context.Context, path string, owner *user_model.User) (*packages_module.HashedBuffer, error) {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	maxSize := c.upstream.MaxFileSize
+	if resp.ContentLength >= 0 {
+		if maxSize > -1 && resp.ContentLength > maxSize {
+			return nil, ErrFileTooLarge
+		}
+		if err := packages_service.CheckSizeQuotaExceeded(ctx, user_model.NewGhostUser(), owner, packages_model.Type(c.packageType), resp.ContentLength); err != nil {
+			return nil, err
+		}
+		maxSize = resp.ContentLength
+	}
+
+	var r io.Reader = resp.Body
+	if maxSize > -1 {
+		r = &io.LimitedReader{R: resp.Body, N: maxSize + 1}
+	}
+	buf, err := packages_module.CreateHashedBufferFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > -1 && buf.Size() > maxSize {
+		buf.Close()
+		return nil, ErrFileTooLarge
+	}
+	return buf, nil
+}
+
+func (c *Client) get(ctx context.Context, path string) (*http.Response, error) {
+	u := path
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		u = c.upstream.URL + "/" + strings.TrimPrefix(path, "/")
+	} else if !c.isAllowedURL(u) {
+		return nil, ErrHostNotAllowed
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	// credentials are only sent to the configured upstream registry
+	if c.upstream.Username != "" && strings.HasPrefix(u, c.upstream.URL+"/") {
+		req.SetBasicAuth(c.upstream.Username, c.upstream.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusNotFound, http.StatusGone:
+		resp.Body.Close()
+		return nil, ErrNotExist
+	default:
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s upstream registry returned status %d for %s", c.packageType, resp.StatusCode, u)
+	}
+}
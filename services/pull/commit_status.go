@@ -7,9 +7,11 @@ package pull
 import (
 	"context"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	"code.gitea.io/gitea/models/db"
 	git_model "code.gitea.io/gitea/models/git"
 	issues_model "code.gitea.io/gitea/models/issues"
+	repo_model "code.gitea.io/gitea/models/repo"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/gitrepo"
 	"code.gitea.io/gitea/modules/log"
@@ -100,13 +102,47 @@ func IsCommitStatusContextSuccess(commitStatuses []*git_model.CommitStatus, requ
 	return true
 }
 
+// GetRequiredStatusCheckContexts returns whether the status check is enabled for the protected branch and the required contexts,
+// the contexts of the required workflows of the organization which enforce status checks are required by any protected branch.
+// If the protected branch requires all the statuses to pass without specific contexts, the contexts of the required workflows are not appended.
+func GetRequiredStatusCheckContexts(ctx context.Context, repo *repo_model.Repository, pb *git_model.ProtectedBranch) (bool, []string, error) {
+	if pb == nil {
+		return false, nil, nil
+	}
+	if pb.EnableStatusCheck && len(pb.StatusCheckContexts) == 0 {
+		return true, nil, nil
+	}
+
+	var requiredContexts []string
+	if pb.EnableStatusCheck {
+		requiredContexts = append(requiredContexts, pb.StatusCheckContexts...)
+	}
+	requiredWorkflows, err := actions_model.GetRequiredWorkflowsForRepo(ctx, repo)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "GetRequiredWorkflowsForRepo")
+	}
+	for _, rw := range requiredWorkflows {
+		if rw.EnforceStatusCheck {
+			requiredContexts = append(requiredContexts, rw.StatusCheckContext())
+		}
+	}
+	return pb.EnableStatusCheck || len(requiredContexts) > 0, requiredContexts, nil
+}
+
 // IsPullCommitStatusPass returns if all required status checks PASS
 func IsPullCommitStatusPass(ctx context.Context, pr *issues_model.PullRequest) (bool, error) {
 	pb, err := git_model.GetFirstMatchProtectedBranchRule(ctx, pr.BaseRepoID, pr.BaseBranch)
 	if err != nil {
 		return false, errors.Wrap(err, "GetLatestCommitStatus")
 	}
-	if pb == nil || !pb.EnableStatusCheck {
+	if err := pr.LoadBaseRepo(ctx); err != nil {
+		return false, errors.Wrap(err, "LoadBaseRepo")
+	}
+	enableStatusCheck, _, err := GetRequiredStatusCheckContexts(ctx, pr.BaseRepo, pb)
+	if err != nil {
+		return false, err
+	}
+	if !enableStatusCheck {
 		return true, nil
 	}
 
@@ -161,9 +197,9 @@ func GetPullRequestCommitStatusState(ctx context.Context, pr *issues_model.PullR
 	if err != nil {
 		return "", errors.Wrap(err, "LoadProtectedBranch")
 	}
-	var requiredContexts []string
-	if pb != nil {
-		requiredContexts = pb.StatusCheckContexts
+	_, requiredContexts, err := GetRequiredStatusCheckContexts(ctx, pr.BaseRepo, pb)
+	if err != nil {
+		return "", err
 	}
 
 	return MergeRequiredContextsCommitStatus(commitStatuses, requiredContexts), nil
@@ -0,0 +1,40 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package commitstatus
+
+import (
+	"context"
+	"fmt"
+
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/gitrepo"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// CreateCheckRun creates a check run of a commit with its annotations, the head sha of the check run could be a short one
+func CreateCheckRun(ctx context.Context, repo *repo_model.Repository, creator *user_model.User, cr *git_model.CheckRun, annotations []*git_model.CheckRunAnnotation) error {
+	// confirm that commit is exist
+	gitRepo, closer, err := gitrepo.RepositoryFromContextOrOpen(ctx, repo)
+	if err != nil {
+		return fmt.Errorf("OpenRepository[%s]: %w", repo.FullName(), err)
+	}
+	defer closer.Close()
+
+	commit, err := gitRepo.GetCommit(cr.HeadSHA)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return util.NewInvalidArgumentErrorf("commit %s doesn't exist", cr.HeadSHA)
+		}
+		return fmt.Errorf("GetCommit[%s]: %w", cr.HeadSHA, err)
+	}
+
+	cr.RepoID = repo.ID
+	cr.HeadSHA = commit.ID.String()
+	cr.CreatorID = creator.ID
+	cr.Creator = creator
+	return git_model.CreateCheckRun(ctx, cr, annotations)
+}
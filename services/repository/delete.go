@@ -66,6 +66,12 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		return fmt.Errorf("list actions artifacts of repo %v: %w", repoID, err)
 	}
 
+	// Query the caches of this repo, they will be needed after they have been deleted to remove cache archives in ObjectStorage
+	caches, err := db.Find[actions_model.ActionCache](ctx, actions_model.FindCachesOptions{RepoID: repoID})
+	if err != nil {
+		return fmt.Errorf("list actions caches of repo %v: %w", repoID, err)
+	}
+
 	// In case owner is a organization, we have to change repo specific teams
 	// if ignoreOrgTeams is not true
 	var org *user_model.User
@@ -137,6 +143,8 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		&repo_model.Collaboration{RepoID: repoID},
 		&issues_model.Comment{RefRepoID: repoID},
 		&git_model.CommitStatus{RepoID: repoID},
+		&git_model.CheckRun{RepoID: repoID},
+		&git_model.CheckRunAnnotation{RepoID: repoID},
 		&git_model.Branch{RepoID: repoID},
 		&git_model.LFSLock{RepoID: repoID},
 		&repo_model.LanguageStat{RepoID: repoID},
@@ -156,6 +164,8 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		&webhook.Webhook{RepoID: repoID},
 		&secret_model.Secret{RepoID: repoID},
 		&actions_model.ActionTaskStep{RepoID: repoID},
+		&actions_model.ActionTaskStepSummary{RepoID: repoID},
+		&actions_model.ActionAttestation{RepoID: repoID},
 		&actions_model.ActionTask{RepoID: repoID},
 		&actions_model.ActionRunJob{RepoID: repoID},
 		&actions_model.ActionRun{RepoID: repoID},
@@ -163,7 +173,14 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		&actions_model.ActionScheduleSpec{RepoID: repoID},
 		&actions_model.ActionSchedule{RepoID: repoID},
 		&actions_model.ActionArtifact{RepoID: repoID},
+		&actions_model.ActionTestReport{RepoID: repoID},
+		&actions_model.ActionTestCase{RepoID: repoID},
+		&actions_model.ActionCache{RepoID: repoID},
+		&actions_model.ActionEnvironment{RepoID: repoID},
+		&actions_model.ActionDeployment{RepoID: repoID},
+		&actions_model.ActionDeploymentStatus{RepoID: repoID},
 		&actions_model.ActionRunnerToken{RepoID: repoID},
+		&actions_model.ActionRequiredWorkflow{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %w", err)
 	}
@@ -344,6 +361,17 @@ func DeleteRepositoryDirectly(ctx context.Context, doer *user_model.User, repoID
 		}
 	}
 
+	// delete actions caches in ObjectStorage after the repo have already been deleted
+	for _, cache := range caches {
+		if cache.StoragePath == "" {
+			continue
+		}
+		if err := storage.ActionsCache.Delete(cache.StoragePath); err != nil {
+			log.Error("remove cache file %q: %v", cache.StoragePath, err)
+			// go on
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,229 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"code.gitea.io/gitea/models/db"
+	secret_model "code.gitea.io/gitea/models/secret"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/proxy"
+	secret_module "code.gitea.io/gitea/modules/secret"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Scope is an owner, a repository or an environment of a repository which secrets belong to
+type Scope struct {
+	OwnerID       int64
+	RepoID        int64 // zero for the secrets of the owner
+	EnvironmentID int64 // zero for the secrets of the repository
+}
+
+// Path returns the path of the scope in the key-value stores, like "owners/{owner_id}", "repos/{repo_id}" or "repos/{repo_id}/environments/{environment_id}".
+// The paths are keyed by the IDs, so the secrets stay with the owner, the repository or the environment when they are renamed or transferred,
+// and they are never inherited by another one which takes the old name.
+func (s *Scope) Path() string {
+	if s.RepoID == 0 {
+		return fmt.Sprintf("owners/%d", s.OwnerID)
+	}
+	if s.EnvironmentID == 0 {
+		return fmt.Sprintf("repos/%d", s.RepoID)
+	}
+	return fmt.Sprintf("repos/%d/environments/%d", s.RepoID, s.EnvironmentID)
+}
+
+// Provider is a backend which the secrets of the actions tasks are read from
+type Provider interface {
+	// GetSecrets returns the secrets of the scope, only the secrets with the names are returned unless the names are nil
+	GetSecrets(ctx context.Context, scope *Scope, names container.Set[string]) (map[string]string, error)
+}
+
+var provider Provider = &dbProvider{}
+
+// Init creates the secret provider configured by [actions] SECRET_PROVIDER
+func Init() error {
+	switch setting.Actions.SecretProvider {
+	case setting.SecretProviderHTTP:
+		provider = &kvProvider{store: newHTTPStore(setting.Actions.SecretProviderURL, setting.Actions.SecretProviderToken)}
+	case setting.SecretProviderFile:
+		provider = &kvProvider{store: &fileStore{filename: setting.Actions.SecretProviderFile}}
+	default:
+		provider = &dbProvider{}
+	}
+	return nil
+}
+
+// filterSecrets removes the secrets with the names not in the filter
+func filterSecrets(secrets map[string]string, names container.Set[string]) map[string]string {
+	if names == nil {
+		return secrets
+	}
+	for name := range secrets {
+		if !names.Contains(name) {
+			delete(secrets, name)
+		}
+	}
+	return secrets
+}
+
+// dbProvider reads the secrets which are encrypted and stored in the database, it's the default provider
+type dbProvider struct{}
+
+func (p *dbProvider) GetSecrets(ctx context.Context, scope *Scope, names container.Set[string]) (map[string]string, error) {
+	opts := secret_model.FindSecretsOptions{OwnerID: scope.OwnerID}
+	if scope.RepoID != 0 {
+		opts = secret_model.FindSecretsOptions{RepoID: scope.RepoID, EnvironmentID: scope.EnvironmentID}
+	}
+	list, err := db.Find[secret_model.Secret](ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("find secrets of %s: %w", scope.Path(), err)
+	}
+
+	secrets := make(map[string]string, len(list))
+	for _, s := range list {
+		if names != nil && !names.Contains(s.Name) {
+			continue
+		}
+		v, err := secret_module.DecryptSecret(setting.SecretKey, s.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt secret %d %q: %w", s.ID, s.Name, err)
+		}
+		secrets[s.Name] = v
+	}
+	return secrets, nil
+}
+
+// kvStore is an external key-value store, the secrets of a scope are stored as a map at the path of the scope
+type kvStore interface {
+	// Read returns the secrets at the path, it's empty if nothing is stored at the path
+	Read(ctx context.Context, path string) (map[string]string, error)
+}
+
+// kvProvider reads the secrets from an external key-value store, the secrets are never stored by Gitea
+type kvProvider struct {
+	store kvStore
+}
+
+func (p *kvProvider) GetSecrets(ctx context.Context, scope *Scope, names container.Set[string]) (map[string]string, error) {
+	secrets, err := p.store.Read(ctx, scope.Path())
+	if err != nil {
+		return nil, fmt.Errorf("read secrets of %s: %w", scope.Path(), err)
+	}
+	return filterSecrets(secrets, names), nil
+}
+
+// parseKVSecrets parses the secrets of a path, it supports the responses of Vault KV v1 and v2 and a plain JSON object
+func parseKVSecrets(content []byte) (map[string]string, error) {
+	var data map[string]any
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	return toKVSecrets(data)
+}
+
+// toKVSecrets converts the decoded JSON object to the secrets, the values which aren't strings are passed as JSON
+func toKVSecrets(data map[string]any) (map[string]string, error) {
+	// Vault KV v1 responds with {"data": {...}}, and v2 responds with {"data": {"data": {...}, "metadata": {...}}}
+	for range 2 {
+		inner, ok := data["data"].(map[string]any)
+		if !ok {
+			break
+		}
+		data = inner
+	}
+
+	secrets := make(map[string]string, len(data))
+	for k, v := range data {
+		if s, ok := v.(string); ok {
+			secrets[k] = s
+			continue
+		}
+		s, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		secrets[k] = string(s)
+	}
+	return secrets, nil
+}
+
+// httpStore reads the secrets from a Vault-style key-value store over HTTP, the path is appended to the base URL
+type httpStore struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newHTTPStore(baseURL, token string) *httpStore {
+	return &httpStore{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client: &http.Client{
+			Timeout: setting.Actions.SecretProviderTimeout,
+			Transport: &http.Transport{
+				Proxy: proxy.Proxy(),
+			},
+		},
+	}
+}
+
+func (s *httpStore) Read(ctx context.Context, path string) (map[string]string, error) {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if s.token != "" {
+		req.Header.Set("X-Vault-Token", s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return map[string]string{}, nil
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseKVSecrets(content)
+}
+
+// fileStore reads the secrets from a JSON file which maps the paths to the secrets, it's a stand-in of the key-value stores for testing,
+// the file is read every time, so it could be changed without restarting.
+type fileStore struct {
+	filename string
+}
+
+func (s *fileStore) Read(_ context.Context, path string) (map[string]string, error) {
+	content, err := os.ReadFile(s.filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var paths map[string]map[string]any
+	if err := json.Unmarshal(content, &paths); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", s.filename, err)
+	}
+	return toKVSecrets(paths[path])
+}
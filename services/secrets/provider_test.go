@@ -0,0 +1,121 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/modules/container"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopePath(t *testing.T) {
+	assert.Equal(t, "owners/5", (&Scope{OwnerID: 5}).Path())
+	assert.Equal(t, "repos/4", (&Scope{OwnerID: 5, RepoID: 4}).Path())
+	assert.Equal(t, "repos/4/environments/1", (&Scope{OwnerID: 5, RepoID: 4, EnvironmentID: 1}).Path())
+}
+
+func TestParseKVSecrets(t *testing.T) {
+	for _, content := range []string{
+		`{"TOKEN": "abc", "PORT": 8080}`,
+		`{"data": {"TOKEN": "abc", "PORT": 8080}}`,
+		`{"data": {"data": {"TOKEN": "abc", "PORT": 8080}, "metadata": {"version": 1}}}`,
+	} {
+		secrets, err := parseKVSecrets([]byte(content))
+		require.NoError(t, err, content)
+		assert.Equal(t, map[string]string{"TOKEN": "abc", "PORT": "8080"}, secrets, content)
+	}
+}
+
+func TestHTTPStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/v1/secret/data/gitea/repos/4":
+			_, _ = w.Write([]byte(`{"data": {"data": {"DEPLOY_KEY": "repo-key", "UNUSED": "unused"}}}`))
+		case "/v1/secret/data/gitea/repos/4/environments/1":
+			_, _ = w.Write([]byte(`{"data": {"data": {"DEPLOY_KEY": "env-key"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &kvProvider{store: newHTTPStore(srv.URL+"/v1/secret/data/gitea/", "vault-token")}
+	names := container.SetOf("DEPLOY_KEY")
+	secrets, err := p.GetSecrets(context.Background(), &Scope{OwnerID: 5, RepoID: 4}, names)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DEPLOY_KEY": "repo-key"}, secrets)
+
+	secrets, err = p.GetSecrets(context.Background(), &Scope{OwnerID: 5, RepoID: 4, EnvironmentID: 1}, names)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DEPLOY_KEY": "env-key"}, secrets)
+
+	// nothing is stored for the owner
+	secrets, err = p.GetSecrets(context.Background(), &Scope{OwnerID: 5}, names)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	p = &kvProvider{store: newHTTPStore(srv.URL+"/v1/secret/data/gitea", "wrong-token")}
+	_, err = p.GetSecrets(context.Background(), &Scope{OwnerID: 5}, names)
+	assert.Error(t, err)
+}
+
+func TestFileStore(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "secrets.json")
+	p := &kvProvider{store: &fileStore{filename: filename}}
+	scope := &Scope{OwnerID: 5, RepoID: 4}
+
+	// the file doesn't exist
+	secrets, err := p.GetSecrets(context.Background(), scope, nil)
+	require.NoError(t, err)
+	assert.Empty(t, secrets)
+
+	require.NoError(t, os.WriteFile(filename, []byte(`{"owners/5": {"ORG_TOKEN": "org"}, "repos/4": {"REPO_TOKEN": "repo", "UNUSED": "unused"}}`), 0o600))
+	secrets, err = p.GetSecrets(context.Background(), scope, container.SetOf("REPO_TOKEN"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"REPO_TOKEN": "repo"}, secrets)
+	secrets, err = p.GetSecrets(context.Background(), scope, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"REPO_TOKEN": "repo", "UNUSED": "unused"}, secrets)
+}
+
+func TestGetReferencedSecretNames(t *testing.T) {
+	names, err := getReferencedSecretNames(context.Background(), &actions_model.ActionRunJob{WorkflowPayload: []byte(`
+name: test
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: ./deploy.sh
+        env:
+          KEY: ${{ secrets.DEPLOY_KEY }}
+          TOKEN: ${{ secrets['npm_token'] }}
+        if: secrets.ENABLED != ''
+`)})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ACTIONS_STEP_DEBUG", "ACTIONS_RUNNER_DEBUG", "DEPLOY_KEY", "npm_token", "NPM_TOKEN", "ENABLED"}, names.Values())
+
+	// all the secrets could be used
+	names, err = getReferencedSecretNames(context.Background(), &actions_model.ActionRunJob{WorkflowPayload: []byte(`
+jobs:
+  dump:
+    steps:
+      - run: echo "${{ toJSON(secrets) }}" > secrets.json
+`)})
+	require.NoError(t, err)
+	assert.Nil(t, names)
+}
@@ -11,21 +11,36 @@ import (
 )
 
 func CreateOrUpdateSecret(ctx context.Context, ownerID, repoID int64, name, data string) (*secret_model.Secret, bool, error) {
+	return createOrUpdateSecret(ctx, ownerID, repoID, 0, name, data)
+}
+
+// CreateOrUpdateEnvironmentSecret creates or updates a secret of an environment of the repository
+func CreateOrUpdateEnvironmentSecret(ctx context.Context, repoID, environmentID int64, name, data string) (*secret_model.Secret, bool, error) {
+	return createOrUpdateSecret(ctx, 0, repoID, environmentID, name, data)
+}
+
+func createOrUpdateSecret(ctx context.Context, ownerID, repoID, environmentID int64, name, data string) (*secret_model.Secret, bool, error) {
 	if err := ValidateName(name); err != nil {
 		return nil, false, err
 	}
 
 	s, err := db.Find[secret_model.Secret](ctx, secret_model.FindSecretsOptions{
-		OwnerID: ownerID,
-		RepoID:  repoID,
-		Name:    name,
+		OwnerID:       ownerID,
+		RepoID:        repoID,
+		EnvironmentID: environmentID,
+		Name:          name,
 	})
 	if err != nil {
 		return nil, false, err
 	}
 
 	if len(s) == 0 {
-		s, err := secret_model.InsertEncryptedSecret(ctx, ownerID, repoID, name, data)
+		var s *secret_model.Secret
+		if environmentID != 0 {
+			s, err = secret_model.InsertEncryptedEnvironmentSecret(ctx, repoID, environmentID, name, data)
+		} else {
+			s, err = secret_model.InsertEncryptedSecret(ctx, ownerID, repoID, name, data)
+		}
 		if err != nil {
 			return nil, false, err
 		}
@@ -56,14 +71,24 @@ func DeleteSecretByID(ctx context.Context, ownerID, repoID, secretID int64) erro
 }
 
 func DeleteSecretByName(ctx context.Context, ownerID, repoID int64, name string) error {
+	return deleteSecretByName(ctx, ownerID, repoID, 0, name)
+}
+
+// DeleteEnvironmentSecretByName deletes a secret of an environment of the repository
+func DeleteEnvironmentSecretByName(ctx context.Context, repoID, environmentID int64, name string) error {
+	return deleteSecretByName(ctx, 0, repoID, environmentID, name)
+}
+
+func deleteSecretByName(ctx context.Context, ownerID, repoID, environmentID int64, name string) error {
 	if err := ValidateName(name); err != nil {
 		return err
 	}
 
 	s, err := db.Find[secret_model.Secret](ctx, secret_model.FindSecretsOptions{
-		OwnerID: ownerID,
-		RepoID:  repoID,
-		Name:    name,
+		OwnerID:       ownerID,
+		RepoID:        repoID,
+		EnvironmentID: environmentID,
+		Name:          name,
 	})
 	if err != nil {
 		return err
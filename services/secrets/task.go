@@ -0,0 +1,125 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package secrets
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"strings"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/container"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var (
+	// secretReferencePattern matches the references like `secrets.NAME` or `secrets['NAME']` in the expressions
+	secretReferencePattern = regexp.MustCompile(`\bsecrets\s*(?:\.\s*([A-Za-z_][A-Za-z0-9_-]*)|\[\s*'([^']+)'\s*\])`)
+	// secretsDumpPattern matches the expressions which use all the secrets, like `toJSON(secrets)`
+	secretsDumpPattern = regexp.MustCompile(`(?i)\(\s*secrets\s*\)`)
+)
+
+// secretsReadByRunners are read by the runners instead of the workflows, so they are always passed
+var secretsReadByRunners = []string{"ACTIONS_STEP_DEBUG", "ACTIONS_RUNNER_DEBUG"}
+
+// getReferencedSecretNames returns the names of the secrets referenced by the job and the jobs calling it,
+// it returns nil if all the secrets could be used. The names of the secrets are case-insensitive in the expressions,
+// so both the names as they are written and the uppercase ones are returned.
+func getReferencedSecretNames(ctx context.Context, job *actions_model.ActionRunJob) (container.Set[string], error) {
+	names := container.SetOf(secretsReadByRunners...)
+	for {
+		if secretsDumpPattern.Match(job.WorkflowPayload) {
+			return nil, nil
+		}
+		for _, match := range secretReferencePattern.FindAllSubmatch(job.WorkflowPayload, -1) {
+			name := string(match[1])
+			if name == "" {
+				name = string(match[2])
+			}
+			names.AddMultiple(name, strings.ToUpper(name))
+		}
+		if job.CallerID == 0 {
+			return names, nil
+		}
+		// the secrets passed to the called workflows are referenced by the callers
+		caller, err := actions_model.GetRunJobByID(ctx, job.CallerID)
+		if err != nil {
+			return nil, err
+		}
+		job = caller
+	}
+}
+
+// getSecretNamesOfTask returns the names of the secrets which should be read for the task,
+// all the secrets are read from the database to keep the behavior, but only the referenced ones are read from the external stores.
+func getSecretNamesOfTask(ctx context.Context, task *actions_model.ActionTask) (container.Set[string], error) {
+	if !setting.Actions.SecretProvider.IsExternal() {
+		return nil, nil
+	}
+	return getReferencedSecretNames(ctx, task.Job)
+}
+
+// canReadSecrets returns whether the task could read the secrets,
+// the tasks of the pull requests from forks can't, unless they are triggered by pull_request_target,
+// since they run in the context of the base branch,
+// see https://docs.github.com/en/actions/using-workflows/events-that-trigger-workflows#pull_request_target
+func canReadSecrets(task *actions_model.ActionTask) bool {
+	return !task.Job.Run.IsForkPullRequest || task.Job.Run.TriggerEvent == actions_module.GithubEventPullRequestTarget
+}
+
+// GetSecretsOfTask returns the secrets of the owner and the repository of the task from the secret provider,
+// the secrets of the repository override the ones with the same names of the owner.
+// The tokens of the task are always available as GITHUB_TOKEN and GITEA_TOKEN.
+func GetSecretsOfTask(ctx context.Context, task *actions_model.ActionTask) (map[string]string, error) {
+	secrets := map[string]string{}
+	if canReadSecrets(task) {
+		names, err := getSecretNamesOfTask(ctx, task)
+		if err != nil {
+			return nil, err
+		}
+		repo := task.Job.Run.Repo
+		ownerSecrets, err := provider.GetSecrets(ctx, &Scope{OwnerID: repo.OwnerID}, names)
+		if err != nil {
+			return nil, err
+		}
+		repoSecrets, err := provider.GetSecrets(ctx, &Scope{OwnerID: repo.OwnerID, RepoID: repo.ID}, names)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(secrets, ownerSecrets)
+		maps.Copy(secrets, repoSecrets)
+	}
+
+	secrets["GITHUB_TOKEN"] = task.Token
+	secrets["GITEA_TOKEN"] = task.Token
+	return secrets, nil
+}
+
+// GetEnvironmentSecretsOfTask returns the secrets of the environment which the job of the task deploys to from the secret provider,
+// they override the secrets with the same names of the repo and the owner.
+func GetEnvironmentSecretsOfTask(ctx context.Context, task *actions_model.ActionTask) (map[string]string, error) {
+	if task.Job.DeploymentID == 0 || !canReadSecrets(task) {
+		return map[string]string{}, nil
+	}
+
+	deployment, err := actions_model.GetDeploymentByID(ctx, task.Job.DeploymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := deployment.LoadAttributes(ctx); err != nil {
+		return nil, err
+	}
+	names, err := getSecretNamesOfTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	repo := task.Job.Run.Repo
+	return provider.GetSecrets(ctx, &Scope{
+		OwnerID:       repo.OwnerID,
+		RepoID:        repo.ID,
+		EnvironmentID: deployment.EnvironmentID,
+	}, names)
+}
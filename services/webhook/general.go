@@ -307,6 +307,49 @@ func getPackagePayloadInfo(p *api.PackagePayload, linkFormatter linkFormatter, w
 	return text, color
 }
 
+func getWorkflowRunPayloadInfo(p *api.WorkflowRunPayload, linkFormatter linkFormatter, withSender bool) (text string, color int) {
+	repoLink := linkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	runLink := linkFormatter(p.WorkflowRun.HTMLURL, fmt.Sprintf("%s #%d", p.Workflow.Name, p.WorkflowRun.RunNumber))
+
+	text, color = getWorkflowStatusInfo(p.Action, p.WorkflowRun.Conclusion)
+	text = fmt.Sprintf("[%s] Workflow run %s %s", repoLink, runLink, text)
+	if withSender {
+		text += fmt.Sprintf(" by %s", linkFormatter(setting.AppURL+url.PathEscape(p.Sender.UserName), p.Sender.UserName))
+	}
+
+	return text, color
+}
+
+func getWorkflowJobPayloadInfo(p *api.WorkflowJobPayload, linkFormatter linkFormatter, withSender bool) (text string, color int) {
+	repoLink := linkFormatter(p.Repository.HTMLURL, p.Repository.FullName)
+	jobLink := linkFormatter(p.WorkflowJob.HTMLURL, p.WorkflowJob.WorkflowName+" / "+p.WorkflowJob.Name)
+
+	text, color = getWorkflowStatusInfo(p.Action, p.WorkflowJob.Conclusion)
+	text = fmt.Sprintf("[%s] Job %s %s", repoLink, jobLink, text)
+	if withSender {
+		text += fmt.Sprintf(" by %s", linkFormatter(setting.AppURL+url.PathEscape(p.Sender.UserName), p.Sender.UserName))
+	}
+
+	return text, color
+}
+
+func getWorkflowStatusInfo(action api.HookWorkflowRunAction, conclusion string) (text string, color int) {
+	if action == api.HookWorkflowRunInProgress {
+		return "started", yellowColor
+	}
+	switch conclusion {
+	case "success":
+		return "succeeded", greenColor
+	case "failure":
+		return "failed", redColor
+	case "cancelled":
+		return "cancelled", greyColor
+	case "skipped":
+		return "skipped", greyColor
+	}
+	return "completed", greyColor
+}
+
 // ToHook convert models.Webhook to api.Hook
 // This function is not part of the convert package to prevent an import cycle
 func ToHook(repoLink string, w *webhook_model.Webhook) (*api.Hook, error) {
@@ -341,6 +341,57 @@ func packageTestPayload() *api.PackagePayload {
 	}
 }
 
+func workflowRunTestPayload() *api.WorkflowRunPayload {
+	return &api.WorkflowRunPayload{
+		Action: api.HookWorkflowRunCompleted,
+		Sender: &api.User{
+			UserName:  "user1",
+			AvatarURL: "http://localhost:3000/user1/avatar",
+		},
+		Repository: &api.Repository{
+			HTMLURL:  "http://localhost:3000/test/repo",
+			Name:     "repo",
+			FullName: "test/repo",
+		},
+		Workflow: &api.ActionWorkflow{
+			ID:   "build.yml",
+			Name: "Build",
+		},
+		WorkflowRun: &api.ActionWorkflowRun{
+			ID:         1,
+			RunNumber:  3,
+			HeadBranch: "main",
+			Status:     "completed",
+			Conclusion: "success",
+			HTMLURL:    "http://localhost:3000/test/repo/actions/runs/3",
+		},
+	}
+}
+
+func workflowJobTestPayload() *api.WorkflowJobPayload {
+	return &api.WorkflowJobPayload{
+		Action: api.HookWorkflowRunCompleted,
+		Sender: &api.User{
+			UserName:  "user1",
+			AvatarURL: "http://localhost:3000/user1/avatar",
+		},
+		Repository: &api.Repository{
+			HTMLURL:  "http://localhost:3000/test/repo",
+			Name:     "repo",
+			FullName: "test/repo",
+		},
+		WorkflowJob: &api.ActionWorkflowJob{
+			ID:           2,
+			RunID:        1,
+			Name:         "test",
+			WorkflowName: "build.yml",
+			Status:       "completed",
+			Conclusion:   "failure",
+			HTMLURL:      "http://localhost:3000/test/repo/actions/runs/3/jobs/0",
+		},
+	}
+}
+
 func TestGetIssuesPayloadInfo(t *testing.T) {
 	p := issueTestPayload()
 
@@ -647,3 +698,79 @@ func TestGetIssueCommentPayloadInfo(t *testing.T) {
 		assert.Equal(t, c.color, color, "case %d", i)
 	}
 }
+
+func TestGetWorkflowRunPayloadInfo(t *testing.T) {
+	p := workflowRunTestPayload()
+
+	cases := []struct {
+		action     api.HookWorkflowRunAction
+		conclusion string
+		text       string
+		color      int
+	}{
+		{
+			api.HookWorkflowRunCompleted,
+			"success",
+			"[test/repo] Workflow run Build #3 succeeded by user1",
+			greenColor,
+		},
+		{
+			api.HookWorkflowRunCompleted,
+			"failure",
+			"[test/repo] Workflow run Build #3 failed by user1",
+			redColor,
+		},
+		{
+			api.HookWorkflowRunCompleted,
+			"cancelled",
+			"[test/repo] Workflow run Build #3 cancelled by user1",
+			greyColor,
+		},
+	}
+
+	for i, c := range cases {
+		p.Action = c.action
+		p.WorkflowRun.Conclusion = c.conclusion
+		text, color := getWorkflowRunPayloadInfo(p, noneLinkFormatter, true)
+		assert.Equal(t, c.text, text, "case %d", i)
+		assert.Equal(t, c.color, color, "case %d", i)
+	}
+}
+
+func TestGetWorkflowJobPayloadInfo(t *testing.T) {
+	p := workflowJobTestPayload()
+
+	cases := []struct {
+		action     api.HookWorkflowRunAction
+		conclusion string
+		text       string
+		color      int
+	}{
+		{
+			api.HookWorkflowRunInProgress,
+			"",
+			"[test/repo] Job build.yml / test started by user1",
+			yellowColor,
+		},
+		{
+			api.HookWorkflowRunCompleted,
+			"failure",
+			"[test/repo] Job build.yml / test failed by user1",
+			redColor,
+		},
+		{
+			api.HookWorkflowRunCompleted,
+			"skipped",
+			"[test/repo] Job build.yml / test skipped by user1",
+			greyColor,
+		},
+	}
+
+	for i, c := range cases {
+		p.Action = c.action
+		p.WorkflowJob.Conclusion = c.conclusion
+		text, color := getWorkflowJobPayloadInfo(p, noneLinkFormatter, true)
+		assert.Equal(t, c.text, text, "case %d", i)
+		assert.Equal(t, c.color, color, "case %d", i)
+	}
+}
@@ -6,6 +6,7 @@ package webhook
 import (
 	"context"
 
+	actions_model "code.gitea.io/gitea/models/actions"
 	issues_model "code.gitea.io/gitea/models/issues"
 	packages_model "code.gitea.io/gitea/models/packages"
 	"code.gitea.io/gitea/models/perm"
@@ -887,3 +888,67 @@ func notifyPackage(ctx context.Context, sender *user_model.User, pd *packages_mo
 		log.Error("PrepareWebhooks: %v", err)
 	}
 }
+
+func (m *webhookNotifier) WorkflowRunStatusUpdate(ctx context.Context, repo *repo_model.Repository, sender *user_model.User, run *actions_model.ActionRun) {
+	if !run.Status.IsDone() {
+		// only the completed workflow runs are sent, like the workflow_run trigger of Actions
+		return
+	}
+
+	workflow, err := convert.ToActionWorkflow(ctx, run)
+	if err != nil {
+		log.Error("ToActionWorkflow: %v", err)
+		return
+	}
+	workflowRun, err := convert.ToActionWorkflowRun(ctx, repo, run)
+	if err != nil {
+		log.Error("ToActionWorkflowRun: %v", err)
+		return
+	}
+
+	if err := PrepareWebhooks(ctx, EventSource{Repository: repo}, webhook_module.HookEventWorkflowRun, &api.WorkflowRunPayload{
+		Action:      api.HookWorkflowRunCompleted,
+		Workflow:    workflow,
+		WorkflowRun: workflowRun,
+		Repository:  convert.ToRepo(ctx, repo, access_model.Permission{AccessMode: perm.AccessModeOwner}),
+		Sender:      convert.ToUser(ctx, sender, nil),
+	}); err != nil {
+		log.Error("PrepareWebhooks: %v", err)
+	}
+}
+
+func (m *webhookNotifier) WorkflowJobStatusUpdate(ctx context.Context, repo *repo_model.Repository, sender *user_model.User, job *actions_model.ActionRunJob) {
+	action := api.HookWorkflowRunInProgress
+	if job.Status.IsDone() {
+		action = api.HookWorkflowRunCompleted
+	} else if !job.Status.IsRunning() {
+		return
+	}
+
+	jobs, err := actions_model.GetRunJobsByRunID(ctx, job.RunID)
+	if err != nil {
+		log.Error("GetRunJobsByRunID: %v", err)
+		return
+	}
+	jobIndex := 0
+	for i, j := range jobs {
+		if j.ID == job.ID {
+			jobIndex = i
+			break
+		}
+	}
+	workflowJob, err := convert.ToActionWorkflowJob(ctx, repo, job, jobIndex)
+	if err != nil {
+		log.Error("ToActionWorkflowJob: %v", err)
+		return
+	}
+
+	if err := PrepareWebhooks(ctx, EventSource{Repository: repo}, webhook_module.HookEventWorkflowJob, &api.WorkflowJobPayload{
+		Action:      action,
+		WorkflowJob: workflowJob,
+		Repository:  convert.ToRepo(ctx, repo, access_model.Permission{AccessMode: perm.AccessModeOwner}),
+		Sender:      convert.ToUser(ctx, sender, nil),
+	}); err != nil {
+		log.Error("PrepareWebhooks: %v", err)
+	}
+}
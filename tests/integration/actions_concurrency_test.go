@@ -0,0 +1,125 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsConcurrency(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		session := loginUser(t, user2.Name)
+		token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository)
+
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "actions-concurrency",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "master",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil))
+
+		workflow := func(branch string, cancelInProgress bool) string {
+			return fmt.Sprintf(`name: %[1]s
+on:
+  push:
+    branches: [%[1]s]
+concurrency:
+  group: ${{ github.repository_owner }}-group
+  cancel-in-progress: %[2]t
+jobs:
+  job1:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo %[1]s
+`, branch, cancelInProgress)
+		}
+		addFiles := func(oldBranch, newBranch string, files map[string]string) {
+			opts := &files_service.ChangeRepoFilesOptions{
+				Message:   "add files",
+				OldBranch: oldBranch,
+				NewBranch: newBranch,
+				Author: &files_service.IdentityOptions{
+					Name:  user2.Name,
+					Email: user2.Email,
+				},
+				Committer: &files_service.IdentityOptions{
+					Name:  user2.Name,
+					Email: user2.Email,
+				},
+				Dates: &files_service.CommitDateOptions{
+					Author:    time.Now(),
+					Committer: time.Now(),
+				},
+			}
+			for treePath, content := range files {
+				opts.Files = append(opts.Files, &files_service.ChangeRepoFile{
+					Operation:     "create",
+					TreePath:      treePath,
+					ContentReader: strings.NewReader(content),
+				})
+			}
+			resp, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, opts)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, resp)
+		}
+
+		// the workflows are only triggered by pushing to the branches with the same names
+		addFiles("master", "master", map[string]string{
+			".gitea/workflows/master.yml":  workflow("master", false),
+			".gitea/workflows/dev.yml":     workflow("dev", false),
+			".gitea/workflows/release.yml": workflow("release", true),
+		})
+
+		masterRun := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "master.yml"})
+		assert.Equal(t, "user2-group", masterRun.ConcurrencyGroup)
+		assert.Equal(t, actions_model.StatusWaiting, masterRun.Status)
+
+		// the run of dev is blocked by the run of master
+		addFiles("master", "dev", map[string]string{"dev.txt": "dev"})
+		devRun := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "dev.yml"})
+		assert.Equal(t, "user2-group", devRun.ConcurrencyGroup)
+		assert.Equal(t, actions_model.StatusBlocked, devRun.Status)
+
+		// the run of dev is emitted after the run of master has been cancelled
+		req := NewRequest(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/%d/cancel", repo.FullName(), masterRun.ID)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusAccepted)
+		assert.Eventually(t, func() bool {
+			devRun = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{ID: devRun.ID})
+			return devRun.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+
+		// the run of release cancels the run of dev in progress
+		addFiles("master", "release", map[string]string{"release.txt": "release"})
+		releaseRun := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "release.yml"})
+		assert.True(t, releaseRun.ConcurrencyCancel)
+		assert.Equal(t, actions_model.StatusWaiting, releaseRun.Status)
+		devJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: devRun.ID})
+		assert.Equal(t, actions_model.StatusCancelled, devJob.Status)
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsEnvironment(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		session := loginUser(t, user2.Name)
+		token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository)
+
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "actions-environment",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "master",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil))
+
+		// production requires the approval of user2, and only master could deploy to staging
+		req := NewRequestWithJSON(t, "PUT", fmt.Sprintf("/api/v1/repos/%s/environments/production", repo.FullName()), api.CreateOrUpdateEnvironmentOption{
+			Reviewers: &[]string{user2.Name},
+		}).AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		production := &api.Environment{}
+		DecodeJSON(t, resp, production)
+		assert.Equal(t, "production", production.Name)
+		assert.Len(t, production.Reviewers, 1)
+
+		req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("/api/v1/repos/%s/environments/staging", repo.FullName()), api.CreateOrUpdateEnvironmentOption{
+			BranchPatterns: &[]string{"master"},
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusCreated)
+		req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("/api/v1/repos/%s/environments/staging", repo.FullName()), api.CreateOrUpdateEnvironmentOption{
+			BranchPatterns: &[]string{"main"},
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequestWithJSON(t, "PUT", fmt.Sprintf("/api/v1/repos/%s/environments/production/secrets/DEPLOY_KEY", repo.FullName()), api.CreateOrUpdateSecretOption{
+			Data: "secret",
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusCreated)
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/environments/production/secrets", repo.FullName())).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var secrets []*api.Secret
+		DecodeJSON(t, resp, &secrets)
+		assert.Len(t, secrets, 1)
+		// the secrets of environments are not the secrets of the repository
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/secrets", repo.FullName())).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		secrets = nil
+		DecodeJSON(t, resp, &secrets)
+		assert.Empty(t, secrets)
+
+		opts := &files_service.ChangeRepoFilesOptions{
+			Message:   "add workflow",
+			OldBranch: "master",
+			NewBranch: "master",
+			Files: []*files_service.ChangeRepoFile{{
+				Operation: "create",
+				TreePath:  ".gitea/workflows/deploy.yml",
+				ContentReader: strings.NewReader(`name: deploy
+on: push
+jobs:
+  production:
+    runs-on: ubuntu-latest
+    environment: production
+    steps:
+      - run: echo production
+  staging:
+    runs-on: ubuntu-latest
+    environment:
+      name: staging
+      url: https://staging.example.com
+    steps:
+      - run: echo staging
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo test
+`),
+			}},
+			Author:    &files_service.IdentityOptions{Name: user2.Name, Email: user2.Email},
+			Committer: &files_service.IdentityOptions{Name: user2.Name, Email: user2.Email},
+			Dates:     &files_service.CommitDateOptions{Author: time.Now(), Committer: time.Now()},
+		}
+		_, err = files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, opts)
+		assert.NoError(t, err)
+
+		run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "deploy.yml"})
+		productionJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "production"})
+		stagingJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "staging"})
+		testJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "test"})
+		assert.Equal(t, actions_model.StatusBlocked, productionJob.Status)
+		assert.Equal(t, actions_model.StatusFailure, stagingJob.Status)
+		assert.Equal(t, actions_model.StatusWaiting, testJob.Status)
+
+		// master isn't allowed to deploy to staging any more
+		stagingDeployment := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionDeployment{ID: stagingJob.DeploymentID})
+		assert.Equal(t, actions_model.DeploymentStateFailure, stagingDeployment.State)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs/%d/pending_deployments", repo.FullName(), run.ID)).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var pending []*api.Deployment
+		DecodeJSON(t, resp, &pending)
+		if assert.Len(t, pending, 1) {
+			assert.Equal(t, "production", pending[0].Environment)
+			assert.Equal(t, string(actions_model.DeploymentStateWaiting), pending[0].State)
+			assert.True(t, pending[0].NeedApproval)
+		}
+
+		// only the reviewers could approve the deployment
+		user4 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/%d/pending_deployments", repo.FullName(), run.ID), api.ReviewPendingDeploymentsOption{
+			EnvironmentIDs: []int64{production.ID},
+			State:          "approved",
+		}).AddTokenAuth(getUserToken(t, user4.Name, auth_model.AccessTokenScopeWriteRepository))
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/%d/pending_deployments", repo.FullName(), run.ID), api.ReviewPendingDeploymentsOption{
+			EnvironmentIDs: []int64{production.ID},
+			State:          "approved",
+			Comment:        "LGTM",
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+
+		assert.Eventually(t, func() bool {
+			productionJob = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: productionJob.ID})
+			return productionJob.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+		productionDeployment := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionDeployment{ID: productionJob.DeploymentID})
+		assert.Equal(t, actions_model.DeploymentStateQueued, productionDeployment.State)
+		assert.Equal(t, user2.ID, productionDeployment.ReviewerID)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/deployments/%d/statuses", repo.FullName(), productionDeployment.ID)).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var statuses []*api.DeploymentStatus
+		DecodeJSON(t, resp, &statuses)
+		if assert.Len(t, statuses, 3) {
+			assert.Equal(t, string(actions_model.DeploymentStateQueued), statuses[0].State)
+			assert.Equal(t, "Approved by user2: LGTM", statuses[1].Description)
+			assert.Equal(t, string(actions_model.DeploymentStateWaiting), statuses[2].State)
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/deployments?environment=production", repo.FullName())).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var deployments []*api.Deployment
+		DecodeJSON(t, resp, &deployments)
+		assert.Len(t, deployments, 1)
+
+		// the deployments page is shown to the readers of the repository
+		req = NewRequest(t, "GET", fmt.Sprintf("/%s/actions/deployments", repo.FullName()))
+		session.MakeRequest(t, req, http.StatusOK)
+		req = NewRequest(t, "GET", fmt.Sprintf("/%s/settings/actions/environments", repo.FullName()))
+		session.MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/repos/%s/environments/production", repo.FullName())).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+		unittest.AssertNotExistsBean(t, &actions_model.ActionDeployment{ID: productionDeployment.ID})
+	})
+}
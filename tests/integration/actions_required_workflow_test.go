@@ -0,0 +1,155 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	git_model "code.gitea.io/gitea/models/git"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	pull_service "code.gitea.io/gitea/services/pull"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsRequiredWorkflow(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		org3 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 3})
+		session := loginUser(t, user2.Name)
+		token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteOrganization, auth_model.AccessTokenScopeWriteRepository)
+
+		createRepo := func(name string, enableActions bool) *repo_model.Repository {
+			repo, err := repo_service.CreateRepository(db.DefaultContext, user2, org3, repo_service.CreateRepoOptions{
+				Name:          name,
+				AutoInit:      true,
+				Readme:        "Default",
+				DefaultBranch: "master",
+			})
+			assert.NoError(t, err)
+			if enableActions {
+				assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+					RepoID: repo.ID,
+					Type:   unit_model.TypeActions,
+				}}, nil))
+			}
+			return repo
+		}
+		createFile := func(repo *repo_model.Repository, treePath, content string) {
+			_, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, &files_service.ChangeRepoFilesOptions{
+				Message:   "add " + treePath,
+				OldBranch: "master",
+				NewBranch: "master",
+				Files: []*files_service.ChangeRepoFile{{
+					Operation:     "create",
+					TreePath:      treePath,
+					ContentReader: strings.NewReader(content),
+				}},
+				Author:    &files_service.IdentityOptions{Name: user2.Name, Email: user2.Email},
+				Committer: &files_service.IdentityOptions{Name: user2.Name, Email: user2.Email},
+				Dates:     &files_service.CommitDateOptions{Author: time.Now(), Committer: time.Now()},
+			})
+			assert.NoError(t, err)
+		}
+
+		central := createRepo("required-workflows", false)
+		target := createRepo("required-target", true)
+		other := createRepo("other-target", true)
+		createFile(central, ".gitea/workflows/scan.yml", `name: scan
+on: [push, pull_request]
+jobs:
+  scan:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo scan
+`)
+
+		// the workflow file must exist in the central repository
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows", org3.Name), api.CreateRequiredWorkflowOption{
+			Repository:   central.Name,
+			WorkflowPath: ".gitea/workflows/missing.yml",
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows", org3.Name), api.CreateRequiredWorkflowOption{
+			Repository:         central.Name,
+			WorkflowPath:       ".gitea/workflows/scan.yml",
+			RepoPatterns:       []string{"required-*"},
+			EnforceStatusCheck: true,
+		}).AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		requiredWorkflow := &api.RequiredWorkflow{}
+		DecodeJSON(t, resp, requiredWorkflow)
+		assert.Equal(t, "scan", requiredWorkflow.WorkflowName)
+		assert.Equal(t, central.ID, requiredWorkflow.Repository.ID)
+		req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows", org3.Name), api.CreateRequiredWorkflowOption{
+			Repository:   central.Name,
+			WorkflowPath: ".gitea/workflows/scan.yml",
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows", org3.Name)).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var requiredWorkflows []*api.RequiredWorkflow
+		DecodeJSON(t, resp, &requiredWorkflows)
+		assert.Len(t, requiredWorkflows, 1)
+
+		// the required workflow runs on the matched repository without copying the workflow file
+		createFile(target, "README2.md", "required")
+		createFile(other, "README2.md", "not required")
+		runWorkflowID := central.FullName() + "/.gitea/workflows/scan.yml"
+		run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: target.ID, WorkflowID: runWorkflowID})
+		unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "scan"})
+		unittest.AssertNotExistsBean(t, &actions_model.ActionRun{RepoID: other.ID})
+		unittest.AssertNotExistsBean(t, &actions_model.ActionRun{RepoID: central.ID, WorkflowID: runWorkflowID})
+		unittest.AssertExistsAndLoadBean(t, &git_model.CommitStatus{RepoID: target.ID, SHA: run.CommitSHA, Context: "scan / scan (push)"})
+
+		// the commit statuses are required by the protected branches of the matched repositories
+		assert.NoError(t, git_model.UpdateProtectBranch(db.DefaultContext, target, &git_model.ProtectedBranch{
+			RepoID:   target.ID,
+			RuleName: "master",
+		}, git_model.WhitelistOptions{}))
+		pb, err := git_model.GetFirstMatchProtectedBranchRule(db.DefaultContext, target.ID, "master")
+		assert.NoError(t, err)
+		enableStatusCheck, requiredContexts, err := pull_service.GetRequiredStatusCheckContexts(db.DefaultContext, target, pb)
+		assert.NoError(t, err)
+		assert.True(t, enableStatusCheck)
+		assert.Equal(t, []string{"scan / *"}, requiredContexts)
+
+		enforceStatusCheck := false
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows/%d", org3.Name, requiredWorkflow.ID), api.EditRequiredWorkflowOption{
+			EnforceStatusCheck: &enforceStatusCheck,
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusOK)
+		enableStatusCheck, requiredContexts, err = pull_service.GetRequiredStatusCheckContexts(db.DefaultContext, target, pb)
+		assert.NoError(t, err)
+		assert.False(t, enableStatusCheck)
+		assert.Empty(t, requiredContexts)
+
+		// only the owners of the organization could manage the required workflows
+		user4Token := getTokenForLoggedInUser(t, loginUser(t, "user4"), auth_model.AccessTokenScopeWriteOrganization)
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows", org3.Name)).AddTokenAuth(user4Token)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows/%d", org3.Name, requiredWorkflow.ID)).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/%s/actions/required_workflows/%d", org3.Name, requiredWorkflow.ID)).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
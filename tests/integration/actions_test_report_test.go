@@ -0,0 +1,131 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/routers/api/actions"
+	actions_web "code.gitea.io/gitea/routers/web/repo/actions"
+	actions_service "code.gitea.io/gitea/services/actions"
+	repo_service "code.gitea.io/gitea/services/repository"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const testJUnitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pkg/calc" tests="3">
+    <testcase classname="calc" name="TestAdd" time="0.01"/>
+    <testcase classname="calc" name="TestDivide" time="0.02">
+      <failure message="division by zero">calc_test.go:20: division by zero</failure>
+    </testcase>
+    <testcase classname="calc" name="TestPow">
+      <skipped/>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+func TestActionsTestReports(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 4})
+	require.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+		RepoID: repo.ID,
+		Type:   unit_model.TypeActions,
+	}}, nil))
+
+	// upload an artifact of actions/upload-artifact@v4 which contains a JUnit report and some other files
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	for name, content := range map[string]string{
+		"reports/junit.xml": testJUnitReport,
+		"reports/pom.xml":   "<project></project>",
+		"coverage.txt":      "mode: set",
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	body := buf.Bytes()
+
+	token, err := actions_service.CreateAuthorizationToken(48, 792, 193)
+	require.NoError(t, err)
+	req := NewRequestWithBody(t, "POST", "/twirp/github.actions.results.api.v1.ArtifactService/CreateArtifact", toProtoJSON(&actions.CreateArtifactRequest{
+		Version:                 4,
+		Name:                    "test-results",
+		WorkflowRunBackendId:    "792",
+		WorkflowJobRunBackendId: "193",
+	})).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var uploadResp actions.CreateArtifactResponse
+	require.NoError(t, protojson.Unmarshal(resp.Body.Bytes(), &uploadResp))
+	idx := strings.Index(uploadResp.SignedUploadUrl, "/twirp/")
+	req = NewRequestWithBody(t, "PUT", uploadResp.SignedUploadUrl[idx:]+"&comp=block", bytes.NewReader(body))
+	MakeRequest(t, req, http.StatusCreated)
+
+	sha := sha256.Sum256(body)
+	req = NewRequestWithBody(t, "POST", "/twirp/github.actions.results.api.v1.ArtifactService/FinalizeArtifact", toProtoJSON(&actions.FinalizeArtifactRequest{
+		Name:                    "test-results",
+		Size:                    int64(len(body)),
+		Hash:                    wrapperspb.String("sha256:" + hex.EncodeToString(sha[:])),
+		WorkflowRunBackendId:    "792",
+		WorkflowJobRunBackendId: "193",
+	})).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusOK)
+
+	report := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTestReport{RunID: 792})
+	assert.Equal(t, "test-results/reports/junit.xml", report.Name)
+	assert.EqualValues(t, 3, report.Total)
+	assert.EqualValues(t, 1, report.Passed)
+	assert.EqualValues(t, 1, report.Failed)
+	assert.EqualValues(t, 1, report.Skipped)
+	unittest.AssertCount(t, &actions_model.ActionTestReport{RunID: 792}, 1)
+	unittest.AssertCount(t, &actions_model.ActionTestCase{RunID: 792}, 3)
+
+	// the test results page of the run
+	session := loginUser(t, "user5")
+	req = NewRequest(t, "GET", "/user5/repo4/actions/runs/188/tests")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	failed := htmlDoc.Find(".test-case-failed")
+	assert.Equal(t, 1, failed.Length())
+	assert.Contains(t, failed.Text(), "calc.TestDivide")
+	assert.Contains(t, failed.Text(), "calc_test.go:20: division by zero")
+
+	req = NewRequest(t, "GET", "/user5/repo4/actions/runs/187/tests")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	NewHTMLParser(t, resp.Body).AssertElement(t, ".test-case-failed", false)
+
+	// the summary in the run view
+	csrf := GetCSRFFromCookie(t, session, "/user5/repo4/actions/runs/188")
+	req = NewRequestWithJSON(t, "POST", "/user5/repo4/actions/runs/188/jobs/0", actions_web.ViewRequest{})
+	req.Header.Add("X-Csrf-Token", csrf)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var viewResp actions_web.ViewResponse
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &viewResp))
+	if assert.NotNil(t, viewResp.State.Run.TestSummary) {
+		assert.EqualValues(t, 3, viewResp.State.Run.TestSummary.Total)
+		assert.EqualValues(t, 1, viewResp.State.Run.TestSummary.Failed)
+		assert.Equal(t, "/user5/repo4/actions/runs/188/tests", viewResp.State.Run.TestSummary.Link)
+	}
+}
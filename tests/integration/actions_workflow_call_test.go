@@ -0,0 +1,146 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	actions_service "code.gitea.io/gitea/services/actions"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsWorkflowCall(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "actions-workflow-call",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "master",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil))
+
+		files := map[string]string{
+			".gitea/workflows/reusable.yml": `name: reusable
+on:
+  workflow_call:
+    inputs:
+      name:
+        type: string
+        required: true
+      debug:
+        type: boolean
+        default: false
+    outputs:
+      greeting:
+        value: hello ${{ inputs.name }}
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ inputs.name }}
+`,
+			".gitea/workflows/main.yml": `name: main
+on: push
+jobs:
+  call:
+    uses: ./.gitea/workflows/reusable.yml
+    with:
+      name: ${{ github.repository_owner }}
+  after:
+    needs: call
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ needs.call.outputs.greeting }}
+`,
+		}
+		opts := &files_service.ChangeRepoFilesOptions{
+			Message:   "add workflows",
+			OldBranch: "master",
+			NewBranch: "master",
+			Author: &files_service.IdentityOptions{
+				Name:  user2.Name,
+				Email: user2.Email,
+			},
+			Committer: &files_service.IdentityOptions{
+				Name:  user2.Name,
+				Email: user2.Email,
+			},
+			Dates: &files_service.CommitDateOptions{
+				Author:    time.Now(),
+				Committer: time.Now(),
+			},
+		}
+		for treePath, content := range files {
+			opts.Files = append(opts.Files, &files_service.ChangeRepoFile{
+				Operation:     "create",
+				TreePath:      treePath,
+				ContentReader: strings.NewReader(content),
+			})
+		}
+		resp, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, opts)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp)
+
+		// the reusable workflow itself isn't triggered by the push
+		unittest.AssertNotExistsBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "reusable.yml"})
+		run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "main.yml"})
+
+		callJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "call"})
+		assert.Equal(t, actions_model.StatusRunning, callJob.Status)
+		buildJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "call/build"})
+		assert.Equal(t, callJob.ID, buildJob.CallerID)
+		assert.Equal(t, "call / build", buildJob.Name)
+		assert.Equal(t, []string{"ubuntu-latest"}, []string(buildJob.RunsOn))
+		afterJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID, JobID: "after"})
+		assert.Equal(t, actions_model.StatusBlocked, afterJob.Status)
+
+		// the called job is emitted after the caller has been started
+		assert.Eventually(t, func() bool {
+			buildJob = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: buildJob.ID})
+			return buildJob.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+
+		inputs, err := actions_service.GetWorkflowCallInputs(db.DefaultContext, buildJob)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{"name": "user2", "debug": false}, inputs)
+
+		// the caller is done when all the called jobs are done, and then the jobs needing it are emitted
+		buildJob.Status = actions_model.StatusSuccess
+		_, err = actions_model.UpdateRunJob(db.DefaultContext, buildJob, nil, "status")
+		assert.NoError(t, err)
+		assert.NoError(t, actions_service.EmitJobsIfReady(run.ID))
+		assert.Eventually(t, func() bool {
+			afterJob = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: afterJob.ID})
+			return afterJob.Status == actions_model.StatusWaiting
+		}, 10*time.Second, 100*time.Millisecond)
+		callJob = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: callJob.ID})
+		assert.Equal(t, actions_model.StatusSuccess, callJob.Status)
+
+		needs, err := actions_service.FindJobNeeds(db.DefaultContext, afterJob)
+		assert.NoError(t, err)
+		if assert.Contains(t, needs, "call") {
+			assert.Equal(t, actions_model.StatusSuccess, needs["call"].Status)
+			assert.Equal(t, map[string]string{"greeting": "hello user2"}, needs["call"].Outputs)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/json"
+	api "code.gitea.io/gitea/modules/structs"
+	webhook_module "code.gitea.io/gitea/modules/webhook"
+	actions_service "code.gitea.io/gitea/services/actions"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionsWorkflowRun(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "actions-workflow-run",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "master",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil))
+
+		files := map[string]string{
+			".gitea/workflows/build.yml": `name: Build
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo build
+`,
+			".gitea/workflows/deploy.yml": `name: Deploy
+on:
+  workflow_run:
+    workflows: [Build]
+    types: [completed]
+    branches: [master]
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ${{ github.event.workflow_run.conclusion }}
+`,
+			".gitea/workflows/ignored.yml": `name: Ignored
+on:
+  workflow_run:
+    workflows: [Test]
+jobs:
+  ignored:
+    runs-on: ubuntu-latest
+    steps:
+      - run: echo ignored
+`,
+		}
+		opts := &files_service.ChangeRepoFilesOptions{
+			Message:   "add workflows",
+			OldBranch: "master",
+			NewBranch: "master",
+			Author: &files_service.IdentityOptions{
+				Name:  user2.Name,
+				Email: user2.Email,
+			},
+			Committer: &files_service.IdentityOptions{
+				Name:  user2.Name,
+				Email: user2.Email,
+			},
+			Dates: &files_service.CommitDateOptions{
+				Author:    time.Now(),
+				Committer: time.Now(),
+			},
+		}
+		for treePath, content := range files {
+			opts.Files = append(opts.Files, &files_service.ChangeRepoFile{
+				Operation:     "create",
+				TreePath:      treePath,
+				ContentReader: strings.NewReader(content),
+			})
+		}
+		resp, err := files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, opts)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp)
+
+		buildRun := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "build.yml"})
+		unittest.AssertNotExistsBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "deploy.yml"})
+
+		buildJob := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: buildRun.ID, JobID: "build"})
+		buildJob.Status = actions_model.StatusFailure
+		_, err = actions_model.UpdateRunJob(db.DefaultContext, buildJob, nil, "status")
+		assert.NoError(t, err)
+		assert.NoError(t, actions_service.EmitJobsIfReady(buildRun.ID))
+
+		// the deploy workflow is triggered when the build workflow run is completed
+		deployRun := &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "deploy.yml"}
+		if !assert.Eventually(t, func() bool {
+			return unittest.BeanExists(t, deployRun)
+		}, 10*time.Second, 100*time.Millisecond) {
+			return
+		}
+		assert.Equal(t, webhook_module.HookEventWorkflowRun, deployRun.Event)
+		assert.Equal(t, "workflow_run", deployRun.TriggerEvent)
+		assert.Equal(t, user2.ID, deployRun.TriggerUserID)
+
+		var payload api.WorkflowRunPayload
+		assert.NoError(t, json.Unmarshal([]byte(deployRun.EventPayload), &payload))
+		assert.Equal(t, api.HookWorkflowRunCompleted, payload.Action)
+		assert.Equal(t, "Build", payload.Workflow.Name)
+		assert.Equal(t, buildRun.ID, payload.WorkflowRun.ID)
+		assert.Equal(t, "failure", payload.WorkflowRun.Conclusion)
+		assert.Equal(t, buildRun.CommitSHA, payload.WorkflowRun.HeadSHA)
+
+		unittest.AssertNotExistsBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "ignored.yml"})
+
+		// the completion is notified only once
+		assert.NoError(t, actions_service.EmitJobsIfReady(buildRun.ID))
+		time.Sleep(time.Second)
+		assert.Equal(t, 1, unittest.GetCount(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "deploy.yml"}))
+	})
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/json"
+	api "code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
+	repo_service "code.gitea.io/gitea/services/repository"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIActionsAttestations(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 4})
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+	require.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+		RepoID: repo.ID,
+		Type:   unit_model.TypeActions,
+	}}, nil))
+	userToken := getTokenForLoggedInUser(t, loginUser(t, user.Name), auth_model.AccessTokenScopeWriteRepository)
+	// the token of the running task 47 of the job 192 in the run 791
+	taskToken := "8061e833a55f6fc0157c98b883e91fcfeeb1a71a"
+
+	// upload an artifact by the task
+	req := NewRequestWithJSON(t, "POST", "/api/actions_pipeline/_apis/pipelines/workflows/791/artifacts", getUploadArtifactRequest{
+		Type: "actions_storage",
+		Name: "dist",
+	}).AddTokenAuth(taskToken)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var uploadResp uploadArtifactResponse
+	DecodeJSON(t, resp, &uploadResp)
+	idx := strings.Index(uploadResp.FileContainerResourceURL, "/api/actions_pipeline/_apis/pipelines/")
+	content := strings.Repeat("A", 1024)
+	req = NewRequestWithBody(t, "PUT", uploadResp.FileContainerResourceURL[idx:]+"?itemPath=dist/app.bin", strings.NewReader(content)).
+		AddTokenAuth(taskToken).
+		SetHeader("Content-Range", "bytes 0-1023/1024").
+		SetHeader("x-tfs-filelength", "1024").
+		SetHeader("x-actions-results-md5", "1HsSe8LeLWh93ILaw1TEFQ==")
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "PATCH", "/api/actions_pipeline/_apis/pipelines/workflows/791/artifacts?artifactName=dist").
+		AddTokenAuth(taskToken)
+	MakeRequest(t, req, http.StatusOK)
+	sum := sha256.Sum256([]byte(content))
+	artifactDigest := "sha256:" + hex.EncodeToString(sum[:])
+	packageDigest := "sha256:" + strings.Repeat("ab", 32)
+
+	createURL := fmt.Sprintf("/api/v1/repos/%s/actions/attestations", repo.FullName())
+	option := &api.CreateActionAttestationOption{
+		Subjects:  []*api.ActionAttestationSubject{{Name: "app-1.0.0.tgz", Digest: strings.ToUpper(packageDigest)}},
+		Artifacts: []string{"dist"},
+	}
+
+	// only the jobs can request attestations
+	req = NewRequestWithJSON(t, "POST", createURL, option).AddTokenAuth(userToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// the job isn't granted `attestations: write`
+	req = NewRequestWithJSON(t, "POST", createURL, option).AddTokenAuth(taskToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	_, err := db.GetEngine(db.DefaultContext).ID(192).Cols("raw_permissions").Update(&actions_model.ActionRunJob{RawPermissions: "actions: read\nattestations: write\n"})
+	require.NoError(t, err)
+
+	// the token should be granted the read permission of actions
+	setTokenPermissions := func(p actions_model.TokenPermissions) {
+		_, err := db.GetEngine(db.DefaultContext).ID(47).Cols("token_permissions").Update(&actions_model.ActionTask{TokenPermissions: p})
+		require.NoError(t, err)
+	}
+	setTokenPermissions(actions_model.TokenPermissions{unit_model.TypeCode: perm.AccessModeRead})
+	req = NewRequestWithJSON(t, "POST", createURL, option).AddTokenAuth(taskToken)
+	MakeRequest(t, req, http.StatusForbidden)
+	setTokenPermissions(actions_model.TokenPermissions{unit_model.TypeActions: perm.AccessModeRead})
+
+	req = NewRequestWithJSON(t, "POST", createURL, &api.CreateActionAttestationOption{
+		Subjects: []*api.ActionAttestationSubject{{Name: "app", Digest: "sha256:1234"}},
+	}).AddTokenAuth(taskToken)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", createURL, &api.CreateActionAttestationOption{
+		Artifacts: []string{"not-exist"},
+	}).AddTokenAuth(taskToken)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithJSON(t, "POST", createURL, option).AddTokenAuth(taskToken)
+	resp = MakeRequest(t, req, http.StatusCreated)
+	var attestations []*api.ActionAttestation
+	DecodeJSON(t, resp, &attestations)
+	require.Len(t, attestations, 2)
+	assert.Equal(t, "app-1.0.0.tgz", attestations[0].Subject.Name)
+	assert.Equal(t, packageDigest, attestations[0].Subject.Digest)
+	assert.Equal(t, "dist/app.bin", attestations[1].Subject.Name)
+	assert.Equal(t, artifactDigest, attestations[1].Subject.Digest)
+
+	// the envelope contains the in-toto statement of the provenance
+	envelope := attestations[1].Envelope
+	assert.Equal(t, "application/vnd.in-toto+json", envelope.PayloadType)
+	require.Len(t, envelope.Signatures, 1)
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	require.NoError(t, err)
+	statement := &actions_service.InTotoStatement{}
+	require.NoError(t, json.Unmarshal(payload, statement))
+	assert.Equal(t, "https://in-toto.io/Statement/v1", statement.Type)
+	assert.Equal(t, actions_service.ProvenancePredicateV1, statement.PredicateType)
+	assert.Equal(t, hex.EncodeToString(sum[:]), statement.Subject[0].Digest["sha256"])
+	run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{ID: 791})
+	assert.Equal(t, run.WorkflowID, statement.Predicate.BuildDefinition.ExternalParameters.Workflow.Path)
+	assert.Equal(t, run.CommitSHA, statement.Predicate.BuildDefinition.ResolvedDependencies[0].Digest["gitCommit"])
+
+	// query the attestations by the digest
+	req = NewRequest(t, "GET", fmt.Sprintf("%s/%s", createURL, artifactDigest)).AddTokenAuth(userToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &attestations)
+	require.Len(t, attestations, 1)
+	assert.Equal(t, artifactDigest, attestations[0].Subject.Digest)
+
+	req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/verify", createURL, artifactDigest)).AddTokenAuth(userToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var verification api.ActionAttestationVerification
+	DecodeJSON(t, resp, &verification)
+	assert.True(t, verification.Verified)
+	require.Len(t, verification.Results, 1)
+	assert.True(t, verification.Results[0].Verified)
+	assert.Equal(t, run.CommitSHA, verification.Results[0].CommitSHA)
+	assert.Equal(t, run.Ref, verification.Results[0].Ref)
+	assert.EqualValues(t, 791, verification.Results[0].RunID)
+
+	// a tampered statement isn't verified
+	attestation := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionAttestation{ID: attestations[0].ID})
+	tampered := strings.Replace(string(payload), run.CommitSHA, strings.Repeat("0", 40), 1)
+	attestation.Envelope = strings.Replace(attestation.Envelope, envelope.Payload, base64.StdEncoding.EncodeToString([]byte(tampered)), 1)
+	_, err = db.GetEngine(db.DefaultContext).ID(attestation.ID).Cols("envelope").Update(attestation)
+	require.NoError(t, err)
+	req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/verify", createURL, artifactDigest)).AddTokenAuth(userToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &verification)
+	assert.False(t, verification.Verified)
+	require.Len(t, verification.Results, 1)
+	assert.False(t, verification.Results[0].Verified)
+	assert.NotEmpty(t, verification.Results[0].Error)
+
+	// the artifact of another repository isn't verified
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/attestations/%s/verify", "user2/repo1", packageDigest)).AddTokenAuth(userToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &verification)
+	assert.False(t, verification.Verified)
+	assert.Empty(t, verification.Results)
+}
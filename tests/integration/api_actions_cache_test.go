@@ -0,0 +1,161 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type actionsCacheEntry struct {
+	Result          string `json:"result"`
+	ArchiveLocation string `json:"archiveLocation"`
+	CacheKey        string `json:"cacheKey"`
+	Scope           string `json:"scope"`
+}
+
+func TestActionsCache(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	// the token of the running task 47 of the run 791 on the master branch of repo 4
+	const token = "8061e833a55f6fc0157c98b883e91fcfeeb1a71a"
+	const cacheURL = "/api/actions_pipeline/_apis/artifactcache"
+
+	// not found
+	req := NewRequest(t, "GET", cacheURL+"/cache?keys=linux-deps-1,linux-deps-&version=v1").AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	// the requests must be authenticated
+	req = NewRequest(t, "GET", cacheURL+"/cache?keys=linux-deps-1&version=v1")
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// reserve a cache
+	reserveReq := func() *RequestWrapper {
+		return NewRequestWithJSON(t, "POST", cacheURL+"/caches", map[string]any{
+			"key":       "linux-deps-1",
+			"version":   "v1",
+			"cacheSize": 2048,
+		}).AddTokenAuth(token)
+	}
+	resp := MakeRequest(t, reserveReq(), http.StatusOK)
+	var reserved struct {
+		CacheID int64 `json:"cacheId"`
+	}
+	DecodeJSON(t, resp, &reserved)
+	assert.NotZero(t, reserved.CacheID)
+	cache := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionCache{ID: reserved.CacheID})
+	assert.Equal(t, "refs/heads/master", cache.Ref)
+	assert.EqualValues(t, 791, cache.RunID)
+	assert.False(t, cache.Complete)
+
+	// the same cache can't be reserved twice
+	MakeRequest(t, reserveReq(), http.StatusConflict)
+
+	// the cache is too large
+	defer test.MockVariableValue(&setting.Actions.CacheMaxEntrySize, 4096)()
+	req = NewRequestWithJSON(t, "POST", cacheURL+"/caches", map[string]any{
+		"key":       "linux-deps-2",
+		"version":   "v1",
+		"cacheSize": 8192,
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	// upload the chunks in any order, and commit the cache
+	cacheIDURL := fmt.Sprintf("%s/caches/%d", cacheURL, reserved.CacheID)
+	req = NewRequestWithBody(t, "PATCH", cacheIDURL, strings.NewReader(strings.Repeat("B", 1024))).
+		AddTokenAuth(token).
+		SetHeader("Content-Range", "bytes 1024-2047/*")
+	MakeRequest(t, req, http.StatusNoContent)
+
+	// the cache isn't committed until all chunks are uploaded
+	req = NewRequestWithJSON(t, "POST", cacheIDURL, map[string]any{"size": 2048}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	for _, chunk := range []struct{ body, contentRange string }{
+		{strings.Repeat("A", 1024), "bytes 0-1023/*"},
+		{strings.Repeat("B", 1024), "bytes 1024-2047/*"},
+	} {
+		req = NewRequestWithBody(t, "PATCH", cacheIDURL, strings.NewReader(chunk.body)).
+			AddTokenAuth(token).
+			SetHeader("Content-Range", chunk.contentRange)
+		MakeRequest(t, req, http.StatusNoContent)
+	}
+	req = NewRequestWithJSON(t, "POST", cacheIDURL, map[string]any{"size": 2048}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	cache = unittest.AssertExistsAndLoadBean(t, &actions_model.ActionCache{ID: reserved.CacheID})
+	assert.True(t, cache.Complete)
+	assert.EqualValues(t, 2048, cache.Size)
+
+	// restore the cache by prefix
+	req = NewRequest(t, "GET", cacheURL+"/cache?keys=linux-deps-2,linux-deps-&version=v1").AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var entry actionsCacheEntry
+	DecodeJSON(t, resp, &entry)
+	assert.Equal(t, "hit", entry.Result)
+	assert.Equal(t, "linux-deps-1", entry.CacheKey)
+	assert.Equal(t, "refs/heads/master", entry.Scope)
+
+	// download the cache archive without authentication
+	idx := strings.Index(entry.ArchiveLocation, cacheURL)
+	assert.Positive(t, idx)
+	req = NewRequest(t, "GET", entry.ArchiveLocation[idx:])
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, strings.Repeat("A", 1024)+strings.Repeat("B", 1024), resp.Body.String())
+
+	// the signature must be valid
+	req = NewRequest(t, "GET", strings.Replace(entry.ArchiveLocation[idx:], "sig=", "sig=x", 1))
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// the caches are evicted when the size limit is exceeded
+	defer test.MockVariableValue(&setting.Actions.CacheMaxSize, 1024)()
+	assert.NoError(t, actions_service.CleanupCaches(db.DefaultContext))
+	unittest.AssertNotExistsBean(t, &actions_model.ActionCache{ID: reserved.CacheID})
+	req = NewRequest(t, "GET", cacheURL+"/cache?keys=linux-deps-1&version=v1").AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	// reserving a cache evicts the least recently used caches to keep the total size within the limit
+	reserveCache := func(key string, size int64, expectedStatus int) int64 {
+		req := NewRequestWithJSON(t, "POST", cacheURL+"/caches", map[string]any{
+			"key":       key,
+			"version":   "v1",
+			"cacheSize": size,
+		}).AddTokenAuth(token)
+		resp := MakeRequest(t, req, expectedStatus)
+		if expectedStatus != http.StatusOK {
+			return 0
+		}
+		var reserved struct {
+			CacheID int64 `json:"cacheId"`
+		}
+		DecodeJSON(t, resp, &reserved)
+		return reserved.CacheID
+	}
+	cacheID := reserveCache("linux-deps-3", 1024, http.StatusOK)
+	cacheIDURL = fmt.Sprintf("%s/caches/%d", cacheURL, cacheID)
+	req = NewRequestWithBody(t, "PATCH", cacheIDURL, strings.NewReader(strings.Repeat("C", 1024))).
+		AddTokenAuth(token).
+		SetHeader("Content-Range", "bytes 0-1023/*")
+	MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequestWithJSON(t, "POST", cacheIDURL, map[string]any{"size": 1024}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	unittest.AssertExistsAndLoadBean(t, &actions_model.ActionCache{ID: cacheID, Complete: true})
+
+	reserveCache("linux-deps-4", 1024, http.StatusOK)
+	unittest.AssertNotExistsBean(t, &actions_model.ActionCache{ID: cacheID})
+
+	// a cache larger than the total size limit can't be reserved
+	reserveCache("linux-deps-5", 2048, http.StatusBadRequest)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/services/auth/source/oauth2"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionsOIDC(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	issuer := setting.AppURL + "api/actions/oidc"
+
+	req := NewRequest(t, "GET", "/api/actions/oidc/.well-known/openid-configuration")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	DecodeJSON(t, resp, &discovery)
+	assert.Equal(t, issuer, discovery.Issuer)
+	assert.Equal(t, issuer+"/.well-known/jwks", discovery.JWKSURI)
+
+	req = NewRequest(t, "GET", "/api/actions/oidc/.well-known/jwks")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	DecodeJSON(t, resp, &jwks)
+	require.Len(t, jwks.Keys, 1)
+
+	// the running task 47 of the job 192 in the run 791 of user5/repo4
+	runtimeToken, err := actions_service.CreateAuthorizationToken(47, 791, 192)
+	require.NoError(t, err)
+
+	// the job isn't granted `id-token: write`
+	req = NewRequest(t, "GET", "/api/actions/oidc/token?api-version=2.0&audience=sts.example.com").
+		AddTokenAuth(runtimeToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	_, err = db.GetEngine(db.DefaultContext).ID(192).Cols("raw_permissions").Update(&actions_model.ActionRunJob{RawPermissions: "id-token: write\n"})
+	require.NoError(t, err)
+
+	req = NewRequest(t, "GET", "/api/actions/oidc/token?api-version=2.0&audience=sts.example.com").
+		AddTokenAuth(runtimeToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var tokenResp struct {
+		Value string `json:"value"`
+	}
+	DecodeJSON(t, resp, &tokenResp)
+
+	claims := &actions_service.IDTokenClaims{}
+	token, err := jwt.ParseWithClaims(tokenResp.Value, claims, func(token *jwt.Token) (any, error) {
+		return oauth2.DefaultSigningKey.VerifyKey(), nil
+	}, jwt.WithIssuer(issuer), jwt.WithAudience("sts.example.com"))
+	require.NoError(t, err)
+	assert.Equal(t, jwks.Keys[0]["kid"], token.Header["kid"])
+
+	job := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: 192})
+	run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{ID: job.RunID})
+	assert.Equal(t, "repo:user5/repo4:ref:"+run.Ref, claims.Subject)
+	assert.Equal(t, "user5/repo4", claims.Repository)
+	assert.Equal(t, run.Ref, claims.Ref)
+	assert.Equal(t, run.WorkflowID, claims.Workflow)
+	assert.Equal(t, "791", claims.RunID)
+	assert.Empty(t, claims.Environment)
+}
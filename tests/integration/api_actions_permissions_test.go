@@ -0,0 +1,143 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/perm"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	api "code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionsTokenPermissions(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	// the running task 47 of the job 192 in the run 791 of user5/repo4
+	const taskToken = "8061e833a55f6fc0157c98b883e91fcfeeb1a71a"
+	setJobPermissions := func(t *testing.T, raw string) *actions_model.ActionTask {
+		_, err := db.GetEngine(db.DefaultContext).ID(192).Cols("raw_permissions").Update(&actions_model.ActionRunJob{RawPermissions: raw})
+		require.NoError(t, err)
+		task := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTask{ID: 47})
+		require.NoError(t, actions_service.SetTaskTokenPermissions(db.DefaultContext, task))
+		return unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTask{ID: 47})
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		task := setJobPermissions(t, "")
+		assert.Equal(t, actions_model.TokenPermissions{
+			unit.TypeCode:         perm.AccessModeWrite,
+			unit.TypeReleases:     perm.AccessModeWrite,
+			unit.TypeWiki:         perm.AccessModeWrite,
+			unit.TypeIssues:       perm.AccessModeWrite,
+			unit.TypePullRequests: perm.AccessModeWrite,
+			unit.TypePackages:     perm.AccessModeWrite,
+			unit.TypeActions:      perm.AccessModeWrite,
+			unit.TypeProjects:     perm.AccessModeWrite,
+		}, task.TokenPermissions)
+
+		require.NoError(t, actions_service.SetOwnerDefaultWorkflowPermissions(db.DefaultContext, 5, repo_model.WorkflowPermissionsRead))
+		task = setJobPermissions(t, "")
+		assert.Equal(t, actions_model.TokenPermissions{
+			unit.TypeCode:     perm.AccessModeRead,
+			unit.TypeReleases: perm.AccessModeRead,
+			unit.TypeWiki:     perm.AccessModeRead,
+			unit.TypePackages: perm.AccessModeRead,
+		}, task.TokenPermissions)
+
+		req := NewRequest(t, "GET", "/api/v1/repos/user5/repo4").AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusOK)
+		// neither the issues nor the pull requests could be read
+		req = NewRequest(t, "GET", "/api/v1/repos/user5/repo4/issues").AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusNotFound)
+		req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user5/repo4/releases", &api.CreateReleaseOption{TagName: "v-token", Target: "master"}).AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("Declared", func(t *testing.T) {
+		task := setJobPermissions(t, "issues: write\npull-requests: read\nid-token: write\n")
+		assert.Equal(t, actions_model.TokenPermissions{
+			unit.TypeIssues:       perm.AccessModeWrite,
+			unit.TypePullRequests: perm.AccessModeRead,
+		}, task.TokenPermissions)
+
+		req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user5/repo4/labels", &api.CreateLabelOption{Name: "job", Color: "#abcdef"}).AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusCreated)
+		req = NewRequest(t, "GET", "/api/v1/repos/user5/repo4/branches").AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		// the git operations require the access to the code, the pushes are checked by the hooks
+		req = NewRequest(t, "GET", "/user5/repo4.git/info/refs?service=git-receive-pack")
+		req.SetBasicAuth("gitea-actions", taskToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		task = setJobPermissions(t, "contents: read\n")
+		req = NewRequest(t, "GET", "/user5/repo4.git/info/refs?service=git-receive-pack")
+		req.SetBasicAuth("gitea-actions", taskToken)
+		MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, perm.AccessModeNone, task.UnitAccessMode(unit.TypeIssues))
+	})
+
+	t.Run("ForkPullRequest", func(t *testing.T) {
+		_, err := db.GetEngine(db.DefaultContext).ID(47).Cols("is_fork_pull_request").Update(&actions_model.ActionTask{IsForkPullRequest: true})
+		require.NoError(t, err)
+		task := setJobPermissions(t, "write-all\n")
+		for _, mode := range task.TokenPermissions {
+			assert.Equal(t, perm.AccessModeRead, mode)
+		}
+		req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user5/repo4/labels", &api.CreateLabelOption{Name: "fork", Color: "#abcdef"}).AddTokenAuth(taskToken)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+}
+
+func TestAPIActionWorkflowPermissions(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository, auth_model.AccessTokenScopeWriteOrganization)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/actions/permissions/workflow").AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var permissions api.ActionWorkflowPermissions
+	DecodeJSON(t, resp, &permissions)
+	assert.Equal(t, "write", permissions.DefaultWorkflowPermissions)
+
+	req = NewRequestWithJSON(t, "PUT", "/api/v1/repos/user2/repo1/actions/permissions/workflow", &api.EditActionWorkflowPermissionsOption{DefaultWorkflowPermissions: "admin"}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "PUT", "/api/v1/repos/user2/repo1/actions/permissions/workflow", &api.EditActionWorkflowPermissionsOption{DefaultWorkflowPermissions: "read"}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &permissions)
+	assert.Equal(t, "read", permissions.DefaultWorkflowPermissions)
+
+	// the repository uses the default of its owner after resetting
+	req = NewRequestWithJSON(t, "PUT", "/api/v1/repos/user2/repo1/actions/permissions/workflow", &api.EditActionWorkflowPermissionsOption{}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &permissions)
+	assert.Equal(t, "write", permissions.DefaultWorkflowPermissions)
+
+	req = NewRequest(t, "GET", "/api/v1/orgs/org3/actions/permissions/workflow").AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &permissions)
+	assert.Equal(t, "write", permissions.DefaultWorkflowPermissions)
+	req = NewRequestWithJSON(t, "PUT", "/api/v1/orgs/org3/actions/permissions/workflow", &api.EditActionWorkflowPermissionsOption{DefaultWorkflowPermissions: "read"}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &permissions)
+	assert.Equal(t, "read", permissions.DefaultWorkflowPermissions)
+
+	// the users who aren't admins of the repository can't read the settings
+	token = getUserToken(t, "user4", auth_model.AccessTokenScopeWriteRepository)
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/actions/permissions/workflow").AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusForbidden)
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/actions"
+	api "code.gitea.io/gitea/modules/structs"
+	repo_service "code.gitea.io/gitea/services/repository"
+	"code.gitea.io/gitea/tests"
+
+	runnerv1 "code.gitea.io/actions-proto-go/runner/v1"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestAPIActionsRuns(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	repo := unittest.AssertExistsAndLoadBean(t, &repo_model.Repository{ID: 4})
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: repo.OwnerID})
+	assert.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+		RepoID: repo.ID,
+		Type:   unit_model.TypeActions,
+	}}, nil))
+
+	session := loginUser(t, user.Name)
+	readToken := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeReadRepository)
+	writeToken := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository)
+
+	t.Run("ListRuns", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var runs api.ActionWorkflowRunsResponse
+		DecodeJSON(t, resp, &runs)
+		assert.EqualValues(t, 2, runs.TotalCount)
+		if assert.Len(t, runs.Entries, 2) {
+			assert.EqualValues(t, 792, runs.Entries[0].ID)
+			assert.EqualValues(t, 188, runs.Entries[0].RunNumber)
+			assert.Equal(t, "completed", runs.Entries[0].Status)
+			assert.Equal(t, "success", runs.Entries[0].Conclusion)
+			assert.Equal(t, "master", runs.Entries[0].HeadBranch)
+		}
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs?status=in_progress", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &runs)
+		assert.EqualValues(t, 0, runs.TotalCount)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs?status=unknown", repo.FullName())).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("GetRun", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs/791", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var run api.ActionWorkflowRun
+		DecodeJSON(t, resp, &run)
+		assert.EqualValues(t, 791, run.ID)
+		assert.Equal(t, "artifact.yaml", run.Name)
+		if assert.NotNil(t, run.RunStartedAt) {
+			assert.EqualValues(t, 1683636528, run.RunStartedAt.Unix())
+		}
+
+		// run of another repository
+		req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/actions/runs/791").
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("ListJobs", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs/791/jobs", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var jobs api.ActionWorkflowJobsResponse
+		DecodeJSON(t, resp, &jobs)
+		assert.EqualValues(t, 1, jobs.TotalCount)
+		if assert.Len(t, jobs.Entries, 1) {
+			assert.EqualValues(t, 192, jobs.Entries[0].ID)
+			assert.EqualValues(t, 791, jobs.Entries[0].RunID)
+			assert.Equal(t, "job_2", jobs.Entries[0].Name)
+		}
+	})
+
+	t.Run("GetJob", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/193", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var job api.ActionWorkflowJob
+		DecodeJSON(t, resp, &job)
+		assert.EqualValues(t, 193, job.ID)
+		assert.EqualValues(t, 792, job.RunID)
+		assert.Equal(t, "completed", job.Status)
+	})
+
+	t.Run("ListStepSummaries", func(t *testing.T) {
+		task := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTask{ID: 48})
+		assert.NoError(t, db.Insert(db.DefaultContext, &actions_model.ActionTaskStep{TaskID: task.ID, RepoID: repo.ID, Index: 0, Name: "Build"}))
+		assert.NoError(t, actions_model.UpsertTaskStepSummary(db.DefaultContext, task, 0, "## Build\n<script>alert(1)</script>"))
+		assert.NoError(t, actions_model.UpsertTaskStepSummary(db.DefaultContext, task, 0, "## Build result\n<script>alert(1)</script>\n\n| os | result |\n|---|---|\n| linux | pass |\n"))
+
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/193/summaries", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var summaries []*api.ActionJobStepSummary
+		DecodeJSON(t, resp, &summaries)
+		if assert.Len(t, summaries, 1) {
+			assert.Equal(t, "Build", summaries[0].StepName)
+			assert.Contains(t, summaries[0].Content, "## Build result")
+			assert.Contains(t, summaries[0].ContentHTML, "Build result</h2>")
+			assert.Contains(t, summaries[0].ContentHTML, "<table>")
+			assert.NotContains(t, summaries[0].ContentHTML, "<script>")
+		}
+
+		// the summaries are bound to the task of the job
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/192/summaries", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &summaries)
+		assert.Empty(t, summaries)
+	})
+
+	t.Run("StreamLogs", func(t *testing.T) {
+		task := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionTask{ID: 47})
+		now := timestamppb.Now()
+		rows := []*runnerv1.LogRow{
+			{Time: now, Content: "set up job"},
+			{Time: now, Content: "build 1"},
+			{Time: now, Content: "build 2"},
+		}
+		task.LogFilename = "stream-test/47.log"
+		task.LogInStorage = false
+		ns, err := actions.WriteLogs(db.DefaultContext, task.LogFilename, 0, rows)
+		assert.NoError(t, err)
+		task.LogIndexes = nil
+		task.LogSize = 0
+		for _, n := range ns {
+			task.LogIndexes = append(task.LogIndexes, task.LogSize)
+			task.LogSize += int64(n)
+		}
+		task.LogLength = int64(len(rows))
+		task.Status = actions_model.StatusSuccess
+		assert.NoError(t, actions_model.UpdateTask(db.DefaultContext, task, "log_filename", "log_in_storage", "log_indexes", "log_length", "log_size", "status"))
+		assert.NoError(t, db.Insert(db.DefaultContext, &actions_model.ActionTaskStep{TaskID: task.ID, RepoID: repo.ID, Index: 0, Name: "Build", LogIndex: 1, LogLength: 2}))
+
+		// resume from the second line, the first one belongs to the "Set up job" step
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/192/logs/stream?offset=2", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "text/event-stream", resp.Header().Get("Content-Type"))
+		body := resp.Body.String()
+		assert.Contains(t, body, "event: logs\n")
+		assert.Contains(t, body, "id: 3\n")
+		assert.Contains(t, body, `"offset":3`)
+		assert.Contains(t, body, `"message":"build 2"`)
+		assert.NotContains(t, body, `"message":"build 1"`)
+		assert.Contains(t, body, "event: done\ndata: success\n")
+
+		// the Last-Event-ID header sent by the reconnecting clients takes precedence
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/192/logs/stream?offset=0", repo.FullName())).
+			AddTokenAuth(readToken)
+		req.Header.Set("Last-Event-ID", "3")
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.NotContains(t, resp.Body.String(), "event: logs")
+		assert.Contains(t, resp.Body.String(), "event: done\ndata: success\n")
+	})
+
+	t.Run("Rerun", func(t *testing.T) {
+		req := NewRequest(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/791/rerun", repo.FullName())).
+			AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/jobs/192/rerun", repo.FullName())).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusCreated)
+		job := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: 192})
+		assert.Equal(t, actions_model.StatusWaiting, job.Status)
+		assert.EqualValues(t, 0, job.TaskID)
+
+		// the waiting run hasn't started yet
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/repos/%s/actions/runs/791", repo.FullName())).
+			AddTokenAuth(readToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var run map[string]any
+		DecodeJSON(t, resp, &run)
+		assert.Equal(t, "queued", run["status"])
+		startedAt, ok := run["run_started_at"]
+		assert.True(t, ok)
+		assert.Nil(t, startedAt)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		req := NewRequest(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/791/cancel", repo.FullName())).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusAccepted)
+		job := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{ID: 192})
+		assert.Equal(t, actions_model.StatusCancelled, job.Status)
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		req := NewRequest(t, "POST", fmt.Sprintf("/api/v1/repos/%s/actions/runs/792/approve", repo.FullName())).
+			AddTokenAuth(writeToken)
+		MakeRequest(t, req, http.StatusConflict)
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/json"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIGenerateRunnerJITConfig(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/actions/runners/generate-jitconfig", &api.GenerateRunnerJITConfigOption{
+		Name:   "jit-runner",
+		Labels: []string{"ubuntu-latest"},
+	}).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var jitConfig api.RunnerJITConfig
+	DecodeJSON(t, resp, &jitConfig)
+	assert.True(t, jitConfig.Runner.Ephemeral)
+	assert.Equal(t, []string{"ubuntu-latest"}, jitConfig.Runner.Labels)
+
+	// the encoded config is the registration file of act_runner
+	content, err := base64.StdEncoding.DecodeString(jitConfig.EncodedJITConfig)
+	require.NoError(t, err)
+	var registration map[string]any
+	require.NoError(t, json.Unmarshal(content, &registration))
+	assert.Equal(t, setting.AppURL, registration["address"])
+	assert.Equal(t, "jit-runner", registration["name"])
+	assert.NotEmpty(t, registration["token"])
+
+	runner := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunner{ID: jitConfig.Runner.ID})
+	assert.EqualValues(t, 1, runner.RepoID)
+	assert.True(t, runner.Ephemeral)
+	assert.Equal(t, registration["uuid"], runner.UUID)
+
+	// the job to bind should be waiting in the repository
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/actions/runners/generate-jitconfig", &api.GenerateRunnerJITConfigOption{
+		Name:  "jit-runner",
+		JobID: 192,
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// the labels are required if no job is bound
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/actions/runners/generate-jitconfig", &api.GenerateRunnerJITConfigOption{
+		Name: "jit-runner",
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPIOrgRunnerGroups(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteOrganization)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/orgs/org3/actions/runner-groups", &api.CreateActionRunnerGroupOption{
+		Name:             "deploy",
+		RepoPatterns:     []string{"repo*"},
+		WorkflowPatterns: []string{"deploy-*.yml"},
+	}).AddTokenAuth(token)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var group api.ActionRunnerGroup
+	DecodeJSON(t, resp, &group)
+	assert.Equal(t, "deploy", group.Name)
+	assert.Equal(t, []string{"deploy-*.yml"}, group.WorkflowPatterns)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/org3/actions/runner-groups", &api.CreateActionRunnerGroupOption{
+		Name: "deploy",
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusConflict)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/org3/actions/runner-groups", &api.CreateActionRunnerGroupOption{
+		Name:             "invalid",
+		WorkflowPatterns: []string{"deploy-[.yml"},
+	}).AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	newPatterns := []string{"release.yml"}
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d", group.ID), &api.EditActionRunnerGroupOption{
+		WorkflowPatterns: &newPatterns,
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &group)
+	assert.Equal(t, "deploy", group.Name)
+	assert.Equal(t, newPatterns, group.WorkflowPatterns)
+
+	// create a just-in-time runner in the group
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/org3/actions/runners/generate-jitconfig", &api.GenerateRunnerJITConfigOption{
+		Name:          "jit-runner",
+		Labels:        []string{"ubuntu-latest"},
+		RunnerGroupID: group.ID,
+	}).AddTokenAuth(token)
+	resp = MakeRequest(t, req, http.StatusCreated)
+	var jitConfig api.RunnerJITConfig
+	DecodeJSON(t, resp, &jitConfig)
+	assert.Equal(t, group.ID, jitConfig.Runner.RunnerGroupID)
+
+	listRunners := func() []*api.ActionRunner {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d/runners", group.ID)).AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var runners []*api.ActionRunner
+		DecodeJSON(t, resp, &runners)
+		return runners
+	}
+	runners := listRunners()
+	if assert.Len(t, runners, 1) {
+		assert.Equal(t, jitConfig.Runner.ID, runners[0].ID)
+	}
+
+	runnerURL := fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d/runners/%d", group.ID, jitConfig.Runner.ID)
+	MakeRequest(t, NewRequest(t, "DELETE", runnerURL).AddTokenAuth(token), http.StatusNoContent)
+	assert.Empty(t, listRunners())
+	MakeRequest(t, NewRequest(t, "PUT", runnerURL).AddTokenAuth(token), http.StatusNoContent)
+	assert.Len(t, listRunners(), 1)
+
+	// only the runners of the organization could be added to the group
+	MakeRequest(t, NewRequest(t, "PUT", fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d/runners/%d", group.ID, 34346)).AddTokenAuth(token), http.StatusNotFound)
+
+	// the runners are kept when the group is deleted
+	MakeRequest(t, NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d", group.ID)).AddTokenAuth(token), http.StatusNoContent)
+	runner := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunner{ID: jitConfig.Runner.ID})
+	assert.EqualValues(t, 0, runner.GroupID)
+	MakeRequest(t, NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/org3/actions/runner-groups/%d", group.ID)).AddTokenAuth(token), http.StatusNotFound)
+
+	// only the owners of the organization could manage the runner groups
+	user4Token := getTokenForLoggedInUser(t, loginUser(t, "user4"), auth_model.AccessTokenScopeWriteOrganization)
+	MakeRequest(t, NewRequest(t, "GET", "/api/v1/orgs/org3/actions/runner-groups").AddTokenAuth(user4Token), http.StatusForbidden)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	repo_model "code.gitea.io/gitea/models/repo"
+	unit_model "code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	repo_service "code.gitea.io/gitea/services/repository"
+	files_service "code.gitea.io/gitea/services/repository/files"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIActionsUsage(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		adminToken := getTokenForLoggedInUser(t, loginUser(t, "user1"), auth_model.AccessTokenScopeWriteAdmin)
+		orgToken := getTokenForLoggedInUser(t, loginUser(t, "user2"), auth_model.AccessTokenScopeReadOrganization)
+
+		now := timeutil.TimeStampNow()
+		require.NoError(t, db.Insert(db.DefaultContext, &actions_model.ActionTaskUsage{
+			TaskID: 1001, RepoID: 3, OwnerID: 3, TriggerUserID: 2, RunID: 1, JobID: 1, JobName: "build",
+			Labels: "ubuntu-latest", Status: actions_model.StatusSuccess, Started: now - 61, Stopped: now, Seconds: 61, Minutes: 2,
+		}))
+		require.NoError(t, db.Insert(db.DefaultContext, &actions_model.ActionTaskUsage{
+			TaskID: 1002, RepoID: 1, OwnerID: 2, TriggerUserID: 2, RunID: 2, JobID: 2, JobName: "test",
+			Labels: "ubuntu-latest", Status: actions_model.StatusFailure, Started: now - 30, Stopped: now, Seconds: 30, Minutes: 1,
+		}))
+
+		// the usage of an organization is grouped by repositories by default
+		req := NewRequest(t, "GET", "/api/v1/orgs/org3/actions/usage").AddTokenAuth(orgToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var usages []*api.ActionUsage
+		DecodeJSON(t, resp, &usages)
+		if assert.Len(t, usages, 1) {
+			assert.Equal(t, "org3/repo3", usages[0].Name)
+			assert.EqualValues(t, 2, usages[0].Minutes)
+		}
+
+		req = NewRequest(t, "GET", "/api/v1/orgs/org3/actions/usage/jobs?format=csv").AddTokenAuth(orgToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+		if assert.Len(t, lines, 2) {
+			assert.True(t, strings.HasPrefix(lines[0], "task_id,repository,"))
+			assert.True(t, strings.HasPrefix(lines[1], "1001,org3/repo3,1,1,build,ubuntu-latest,"))
+		}
+
+		// only the jobs stopped in the time range are counted
+		req = NewRequest(t, "GET", "/api/v1/orgs/org3/actions/usage?before="+url.QueryEscape(time.Unix(int64(now)-3600, 0).Format(time.RFC3339))).AddTokenAuth(orgToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &usages)
+		assert.Empty(t, usages)
+
+		req = NewRequest(t, "GET", "/api/v1/admin/actions/usage?group_by=label").AddTokenAuth(adminToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &usages)
+		if assert.Len(t, usages, 1) {
+			assert.Equal(t, "ubuntu-latest", usages[0].Name)
+			assert.EqualValues(t, 2, usages[0].Jobs)
+			assert.EqualValues(t, 3, usages[0].Minutes)
+		}
+		MakeRequest(t, NewRequest(t, "GET", "/api/v1/admin/actions/usage?group_by=unknown").AddTokenAuth(adminToken), http.StatusUnprocessableEntity)
+
+		// only the site administrators could set the quotas
+		req = NewRequestWithJSON(t, "PUT", "/api/v1/admin/actions/quotas/user2", &api.SetActionQuotaOption{MaxMonthlyMinutes: -1}).AddTokenAuth(adminToken)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+		req = NewRequestWithJSON(t, "PUT", "/api/v1/admin/actions/quotas/user2", &api.SetActionQuotaOption{MaxMonthlyMinutes: 1, MaxArtifactBytes: 1024}).AddTokenAuth(adminToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var quota api.ActionQuota
+		DecodeJSON(t, resp, &quota)
+		assert.EqualValues(t, 1, quota.MaxMonthlyMinutes)
+		assert.EqualValues(t, 1024, quota.MaxArtifactBytes)
+		assert.EqualValues(t, 1, quota.MonthlyMinutes)
+
+		// the jobs of new runs are refused since user2 has used up the minutes
+		user2 := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+		repo, err := repo_service.CreateRepository(db.DefaultContext, user2, user2, repo_service.CreateRepoOptions{
+			Name:          "actions-quota",
+			AutoInit:      true,
+			Readme:        "Default",
+			DefaultBranch: "master",
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo_service.UpdateRepositoryUnits(db.DefaultContext, repo, []repo_model.RepoUnit{{
+			RepoID: repo.ID,
+			Type:   unit_model.TypeActions,
+		}}, nil))
+		_, err = files_service.ChangeRepoFiles(git.DefaultContext, repo, user2, &files_service.ChangeRepoFilesOptions{
+			Files: []*files_service.ChangeRepoFile{{
+				Operation:     "create",
+				TreePath:      ".gitea/workflows/build.yml",
+				ContentReader: strings.NewReader("on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: echo build\n"),
+			}},
+			Message:   "add workflow",
+			OldBranch: "master",
+			NewBranch: "master",
+		})
+		require.NoError(t, err)
+		run := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRun{RepoID: repo.ID, WorkflowID: "build.yml"})
+		assert.Equal(t, actions_model.StatusFailure, run.Status)
+		job := unittest.AssertExistsAndLoadBean(t, &actions_model.ActionRunJob{RunID: run.ID})
+		assert.Equal(t, actions_model.StatusFailure, job.Status)
+
+		// the organization owners could read the quota of the organization
+		req = NewRequest(t, "GET", "/api/v1/orgs/org3/actions/quota").AddTokenAuth(orgToken)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &quota)
+		assert.EqualValues(t, 0, quota.MaxMonthlyMinutes)
+		assert.EqualValues(t, 2, quota.MonthlyMinutes)
+
+		MakeRequest(t, NewRequest(t, "DELETE", "/api/v1/admin/actions/quotas/user2").AddTokenAuth(adminToken), http.StatusNoContent)
+		MakeRequest(t, NewRequest(t, "DELETE", "/api/v1/admin/actions/quotas/user2").AddTokenAuth(adminToken), http.StatusNotFound)
+		unittest.AssertNotExistsBean(t, &actions_model.ActionQuota{OwnerID: 2})
+	})
+}
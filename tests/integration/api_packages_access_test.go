@@ -0,0 +1,349 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	actions_model "code.gitea.io/gitea/models/actions"
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/perm"
+	"code.gitea.io/gitea/models/unit"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageAccessList(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+
+	ownerToken := getUserToken(t, owner.Name, auth_model.AccessTokenScopeWritePackage)
+	doerToken := getUserToken(t, doer.Name, auth_model.AccessTokenScopeWritePackage)
+
+	uploadPackage := func(doer, owner *user_model.User, name string, expectedStatus int) {
+		url := fmt.Sprintf("/api/packages/%s/generic/%s/1.0/file.bin", owner.Name, name)
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{1})).
+			AddBasicAuth(doer.Name)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	setAccess := func(token, target, permission string, expectedStatus int) {
+		url := fmt.Sprintf("/api/v1/packages/%s/generic/test-package/-/access/%s", owner.Name, target)
+		req := NewRequestWithJSON(t, "PUT", url, &api.SetPackageAccessOption{Permission: permission}).
+			AddTokenAuth(token)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	listAccess := func(token string, expectedStatus int) []*api.PackageAccess {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s/generic/test-package/-/access", owner.Name)).
+			AddTokenAuth(token)
+		resp := MakeRequest(t, req, expectedStatus)
+		if expectedStatus != http.StatusOK {
+			return nil
+		}
+		var access []*api.PackageAccess
+		DecodeJSON(t, resp, &access)
+		return access
+	}
+
+	t.Run("User", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		uploadPackage(doer, owner, "test-package", http.StatusUnauthorized)
+
+		setAccess(ownerToken, "users/"+doer.Name, "read", http.StatusNoContent)
+		uploadPackage(doer, owner, "test-package", http.StatusUnauthorized)
+
+		setAccess(ownerToken, "users/"+doer.Name, "write", http.StatusNoContent)
+		uploadPackage(doer, owner, "test-package", http.StatusCreated)
+		uploadPackage(doer, owner, "other-package", http.StatusUnauthorized)
+
+		access := listAccess(ownerToken, http.StatusOK)
+		assert.Len(t, access, 1)
+		assert.Equal(t, doer.Name, access[0].User.UserName)
+		assert.Nil(t, access[0].Team)
+		assert.Equal(t, "write", access[0].Permission)
+
+		// the access list is managed by the admins of the package
+		listAccess(doerToken, http.StatusForbidden)
+		setAccess(ownerToken, "users/"+doer.Name, "admin", http.StatusNoContent)
+		assert.Len(t, listAccess(doerToken, http.StatusOK), 1)
+
+		setAccess(ownerToken, "users/org3", "write", http.StatusUnprocessableEntity)
+		setAccess(ownerToken, "users/"+owner.Name, "write", http.StatusUnprocessableEntity)
+		setAccess(ownerToken, "users/"+doer.Name, "owner", http.StatusUnprocessableEntity)
+		setAccess(ownerToken, "users/not-existing", "write", http.StatusNotFound)
+		setAccess(ownerToken, "teams/team1", "write", http.StatusNotFound)
+
+		req := NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/packages/%s/generic/test-package/-/access/users/%s", owner.Name, doer.Name)).
+			AddTokenAuth(ownerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		uploadPackage(doer, owner, "test-package", http.StatusUnauthorized)
+		unittest.AssertNotExistsBean(t, &packages_model.PackageAccess{OwnerID: owner.ID})
+	})
+
+	t.Run("Team", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		org := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 17})
+		orgOwnerToken := getUserToken(t, "user15", auth_model.AccessTokenScopeWritePackage)
+		member := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 20}) // member of the review_team with read access
+
+		uploadPackage(member, org, "team-package", http.StatusUnauthorized)
+
+		url := fmt.Sprintf("/api/v1/packages/%s/generic/team-package/-/access/teams/review_team", org.Name)
+		req := NewRequestWithJSON(t, "PUT", url, &api.SetPackageAccessOption{Permission: "write"}).
+			AddTokenAuth(orgOwnerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		uploadPackage(member, org, "team-package", http.StatusCreated)
+		uploadPackage(member, org, "other-package", http.StatusUnauthorized)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s/generic/team-package/-/access", org.Name)).
+			AddTokenAuth(orgOwnerToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var access []*api.PackageAccess
+		DecodeJSON(t, resp, &access)
+		assert.Len(t, access, 1)
+		assert.Nil(t, access[0].User)
+		assert.Equal(t, "review_team", access[0].Team.Name)
+
+		req = NewRequest(t, "DELETE", url).
+			AddTokenAuth(orgOwnerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		uploadPackage(member, org, "team-package", http.StatusUnauthorized)
+	})
+}
+
+func TestPackageAccessListRead(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 31}) // private user
+	doer := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 4})
+
+	ownerToken := getUserToken(t, owner.Name, auth_model.AccessTokenScopeWritePackage)
+
+	npmData := "H4sIAAAAAAAA/ytITM5OTE/VL4DQelnF+XkMVAYGBgZmJiYK2MRBwNDcSIHB2NTMwNDQzMwAqA7IMDUxA9LUdgg2UFpcklgEdAql5kD8ogCnhwio5lJQUMpLzE1VslJQcihOzi9I1S9JLS7RhSYIJR2QgrLUouLM/DyQGkM9Az1D3YIiqExKanFyUWZBCVQ2BKhVwQVJDKwosbQkI78IJO/tZ+LsbRykxFXLNdA+HwWjYBSMgpENACgAbtAACAAA"
+	npmUpload := `{
+		"_id": "read-package",
+		"name": "read-package",
+		"dist-tags": {"latest": "1.0.0"},
+		"versions": {
+			"1.0.0": {
+				"name": "read-package",
+				"version": "1.0.0",
+				"dist": {
+					"integrity": "sha512-yA4FJsVhetynGfOC1jFf79BuS+jrHbm0fhh+aHzCQkOaOBXKf9oBnC4a6DnLLnEsHQDRLYd00cwj8sCXpC+wIg==",
+					"shasum": "aaa7eaf852a948b0aa05afeda35b1badca155d90"
+				}
+			}
+		},
+		"_attachments": {"read-package-1.0.0.tgz": {"data": "` + npmData + `"}}
+	}`
+
+	blobDigest := "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+	blobContent, _ := base64.StdEncoding.DecodeString(`H4sIAAAJbogA/2IYBaNgFIxYAAgAAP//Lq+17wAEAAA=`)
+
+	packageURL := fmt.Sprintf("/api/packages/%s", owner.Name)
+	containerURL := fmt.Sprintf("/v2/%s", owner.Name)
+
+	// the owner publishes the packages
+	for _, req := range []*RequestWrapper{
+		NewRequestWithBody(t, "PUT", packageURL+"/generic/read-package/1.0/file.bin", bytes.NewReader([]byte{1})),
+		NewRequestWithBody(t, "PUT", packageURL+"/generic/other-package/1.0/file.bin", bytes.NewReader([]byte{1})),
+		NewRequestWithBody(t, "PUT", packageURL+"/maven/com/gitea/read-package/1.0/read-package-1.0.jar", bytes.NewReader([]byte{1})),
+		NewRequestWithBody(t, "PUT", packageURL+"/npm/read-package", strings.NewReader(npmUpload)),
+		NewRequestWithBody(t, "POST", fmt.Sprintf("%s/read-image/blobs/uploads?digest=%s", containerURL, blobDigest), bytes.NewReader(blobContent)),
+		NewRequestWithBody(t, "POST", fmt.Sprintf("%s/read/image/blobs/uploads?digest=%s", containerURL, blobDigest), bytes.NewReader(blobContent)),
+	} {
+		MakeRequest(t, req.AddBasicAuth(owner.Name), http.StatusCreated)
+	}
+
+	pullURLs := []string{
+		packageURL + "/generic/read-package/1.0/file.bin",
+		packageURL + "/maven/com/gitea/read-package/1.0/read-package-1.0.jar",
+		packageURL + "/npm/read-package",
+		packageURL + "/npm/read-package/-/1.0.0/read-package-1.0.0.tgz",
+		fmt.Sprintf("%s/read-image/blobs/%s", containerURL, blobDigest),
+		fmt.Sprintf("%s/read/image/blobs/%s", containerURL, blobDigest),
+	}
+
+	pull := func(expectedStatus int) {
+		for _, url := range pullURLs {
+			MakeRequest(t, NewRequest(t, "GET", url).AddBasicAuth(doer.Name), expectedStatus)
+		}
+	}
+
+	pull(http.StatusUnauthorized)
+
+	for _, p := range []struct{ packageType, name string }{
+		{"generic", "read-package"},
+		{"maven", "com.gitea-read-package"},
+		{"npm", "read-package"},
+		{"container", "read-image"},
+		{"container", "read/image"},
+	} {
+		url := fmt.Sprintf("/api/v1/packages/%s/%s/%s/-/access/users/%s", owner.Name, p.packageType, url.PathEscape(p.name), doer.Name)
+		req := NewRequestWithJSON(t, "PUT", url, &api.SetPackageAccessOption{Permission: "read"}).
+			AddTokenAuth(ownerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+	}
+
+	pull(http.StatusOK)
+
+	// the read access is limited to the packages in the access list
+	req := NewRequest(t, "GET", packageURL+"/generic/other-package/1.0/file.bin").
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "GET", packageURL+"/npm/-/v1/search?text=package").
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequest(t, "GET", fmt.Sprintf("%s/other-image/blobs/%s", containerURL, blobDigest)).
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// the packages can't be written with read access
+	req = NewRequestWithBody(t, "PUT", packageURL+"/generic/read-package/1.1/file.bin", bytes.NewReader([]byte{1})).
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequestWithBody(t, "PUT", packageURL+"/maven/com/gitea/read-package/1.1/read-package-1.1.jar", bytes.NewReader([]byte{1})).
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+	req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/read-image/blobs/uploads?digest=%s", containerURL, blobDigest), bytes.NewReader(blobContent)).
+		AddBasicAuth(doer.Name)
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
+
+func TestPackageRepositoryLinks(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	token := getUserToken(t, owner.Name, auth_model.AccessTokenScopeWritePackage, auth_model.AccessTokenScopeReadRepository)
+
+	// the running task 47 of the job 192 in the run 791 of user5/repo4
+	const taskToken = "8061e833a55f6fc0157c98b883e91fcfeeb1a71a"
+
+	packageURL := fmt.Sprintf("/api/v1/packages/%s/generic/test-package/-/repositories", owner.Name)
+
+	uploadPackage := func(name, version string, expectedStatus int) {
+		url := fmt.Sprintf("/api/packages/%s/generic/%s/%s/file.bin", owner.Name, name, version)
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{1}))
+		req.SetBasicAuth("gitea-actions", taskToken)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	listRepositories := func() []string {
+		req := NewRequest(t, "GET", packageURL).
+			AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var repos []*api.Repository
+		DecodeJSON(t, resp, &repos)
+		names := make([]string, 0, len(repos))
+		for _, repo := range repos {
+			names = append(names, repo.FullName)
+		}
+		return names
+	}
+
+	req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/test-package/1.0/file.bin", owner.Name), bytes.NewReader([]byte{1})).
+		AddBasicAuth(owner.Name)
+	MakeRequest(t, req, http.StatusCreated)
+
+	assert.Empty(t, listRepositories())
+
+	// the actions tasks of other owners can only publish to linked packages
+	uploadPackage("test-package", "1.1", http.StatusUnauthorized)
+
+	req = NewRequest(t, "PUT", packageURL+"/user2/repo1").
+		AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequest(t, "PUT", packageURL+"/user5/repo4").
+		AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequest(t, "PUT", packageURL+"/user2/not-existing").
+		AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	assert.Equal(t, []string{"user2/repo1", "user5/repo4"}, listRepositories())
+
+	p := unittest.AssertExistsAndLoadBean(t, &packages_model.Package{OwnerID: owner.ID, LowerName: "test-package"})
+	assert.EqualValues(t, 1, p.RepoID)
+
+	uploadPackage("test-package", "1.1", http.StatusCreated)
+	uploadPackage("other-package", "1.1", http.StatusUnauthorized)
+
+	// the next linked repository takes the place of the removed one
+	req = NewRequest(t, "DELETE", packageURL+"/user2/repo1").
+		AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	assert.Equal(t, []string{"user5/repo4"}, listRepositories())
+	p = unittest.AssertExistsAndLoadBean(t, &packages_model.Package{ID: p.ID})
+	assert.EqualValues(t, 4, p.RepoID)
+
+	req = NewRequest(t, "DELETE", packageURL+"/user5/repo4").
+		AddTokenAuth(token)
+	MakeRequest(t, req, http.StatusNoContent)
+
+	assert.Empty(t, listRepositories())
+	uploadPackage("test-package", "1.2", http.StatusUnauthorized)
+}
+
+func TestPackageActionsTaskAccess(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	owner := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 5})
+
+	// the running task 47 of the job 192 in the run 791 of user5/repo4
+	const taskToken = "8061e833a55f6fc0157c98b883e91fcfeeb1a71a"
+	_, err := db.GetEngine(db.DefaultContext).ID(47).Cols("owner_id").Update(&actions_model.ActionTask{OwnerID: owner.ID})
+	require.NoError(t, err)
+
+	uploadPackage := func(name, version string, expectedStatus int) {
+		url := fmt.Sprintf("/api/packages/%s/generic/%s/%s/file.bin", owner.Name, name, version)
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{1}))
+		req.SetBasicAuth("gitea-actions", taskToken)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	// the packages created by the task are linked to the repository of the task
+	uploadPackage("task-package", "1.0", http.StatusCreated)
+	p := unittest.AssertExistsAndLoadBean(t, &packages_model.Package{OwnerID: owner.ID, LowerName: "task-package"})
+	assert.EqualValues(t, 4, p.RepoID)
+	uploadPackage("task-package", "1.1", http.StatusCreated)
+
+	// the packages of the owner which aren't linked to the repository can't be written
+	req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/generic/owner-package/1.0/file.bin", owner.Name), bytes.NewReader([]byte{1})).
+		AddBasicAuth(owner.Name)
+	MakeRequest(t, req, http.StatusCreated)
+	uploadPackage("owner-package", "1.1", http.StatusUnauthorized)
+
+	// the task can't create packages if its token can only read packages
+	_, err = db.GetEngine(db.DefaultContext).ID(47).Cols("token_permissions").Update(&actions_model.ActionTask{
+		TokenPermissions: actions_model.TokenPermissions{unit.TypePackages: perm.AccessModeRead},
+	})
+	require.NoError(t, err)
+	uploadPackage("another-package", "1.0", http.StatusUnauthorized)
+	uploadPackage("task-package", "1.2", http.StatusUnauthorized)
+}
@@ -0,0 +1,292 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	arch_module "code.gitea.io/gitea/modules/packages/arch"
+	"code.gitea.io/gitea/modules/zstd"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageArch(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	packageName := "gitea-test"
+	packageVersion := "1.4.1-3"
+
+	createPackage := func(t *testing.T, name, version, architecture string) []byte {
+		pkginfo := []byte(`pkgname = ` + name + `
+pkgbase = ` + name + `
+pkgver = ` + version + `
+pkgdesc = Gitea Test Package
+url = https://gitea.io/
+builddate = 1679498030
+packager = KN4CK3R <kn4ck3r@gitea.io>
+size = 4096
+arch = ` + architecture + `
+license = MIT
+depend = glibc
+`)
+
+		var buf bytes.Buffer
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		tw := tar.NewWriter(zw)
+		for _, file := range []struct {
+			Name    string
+			Content []byte
+		}{
+			{".PKGINFO", pkginfo},
+			{"usr/bin/" + name, []byte("gitea")},
+		} {
+			require.NoError(t, tw.WriteHeader(&tar.Header{
+				Name: file.Name,
+				Mode: 0o755,
+				Size: int64(len(file.Content)),
+			}))
+			_, err := tw.Write(file.Content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, zw.Close())
+		return buf.Bytes()
+	}
+
+	content := createPackage(t, packageName, packageVersion, "x86_64")
+	anyContent := createPackage(t, packageName+"-any", packageVersion, arch_module.AnyArch)
+
+	readDatabase := func(t *testing.T, r io.Reader) map[string]string {
+		gzr, err := gzip.NewReader(r)
+		require.NoError(t, err)
+		defer gzr.Close()
+
+		files := make(map[string]string)
+		tr := tar.NewReader(gzr)
+		for {
+			hd, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			if hd.Typeflag == tar.TypeDir {
+				continue
+			}
+
+			buf, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[hd.Name] = string(buf)
+		}
+		return files
+	}
+
+	rootURL := fmt.Sprintf("/api/packages/%s/arch", user.Name)
+
+	var keyring openpgp.EntityList
+
+	t.Run("RepositoryKey", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", rootURL+"/repository.key")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		assert.Equal(t, "application/pgp-keys", resp.Header().Get("Content-Type"))
+		assert.Contains(t, resp.Body.String(), "-----BEGIN PGP PUBLIC KEY BLOCK-----")
+
+		var err error
+		keyring, err = openpgp.ReadArmoredKeyRing(resp.Body)
+		assert.NoError(t, err)
+	})
+
+	verifySignature := func(t *testing.T, content []byte, url string) {
+		req := NewRequest(t, "GET", url)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		_, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(content), resp.Body, nil)
+		assert.NoError(t, err)
+	}
+
+	for _, repository := range []string{"core", "extra"} {
+		t.Run(fmt.Sprintf("[Repository:%s]", repository), func(t *testing.T) {
+			repositoryURL := fmt.Sprintf("%s/%s", rootURL, repository)
+			filename := fmt.Sprintf("%s-%s-x86_64.pkg.tar.zst", packageName, packageVersion)
+
+			t.Run("Upload", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				req := NewRequestWithBody(t, "PUT", repositoryURL, bytes.NewReader(content))
+				MakeRequest(t, req, http.StatusUnauthorized)
+
+				req = NewRequestWithBody(t, "PUT", repositoryURL, bytes.NewReader([]byte{})).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusBadRequest)
+
+				req = NewRequestWithBody(t, "PUT", repositoryURL, bytes.NewReader(content)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusCreated)
+
+				pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeArch)
+				assert.NoError(t, err)
+				assert.Len(t, pvs, 1)
+
+				pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+				assert.NoError(t, err)
+				assert.Nil(t, pd.SemVer)
+				assert.IsType(t, &arch_module.VersionMetadata{}, pd.Metadata)
+				assert.Equal(t, packageName, pd.Package.Name)
+				assert.Equal(t, packageVersion, pd.Version.Version)
+
+				pfs, err := packages.GetFilesByVersionID(db.DefaultContext, pvs[0].ID)
+				assert.NoError(t, err)
+				assert.Condition(t, func() bool {
+					for _, pf := range pfs {
+						if pf.Name == filename && pf.CompositeKey == repository+"|x86_64" {
+							assert.True(t, pf.IsLead)
+							return true
+						}
+					}
+					return false
+				})
+
+				req = NewRequestWithBody(t, "PUT", repositoryURL, bytes.NewReader(content)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusConflict)
+			})
+
+			t.Run("Download", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s", repositoryURL, filename))
+				resp := MakeRequest(t, req, http.StatusOK)
+				assert.Equal(t, content, resp.Body.Bytes())
+
+				verifySignature(t, content, fmt.Sprintf("%s/x86_64/%s.sig", repositoryURL, filename))
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/aarch64/%s", repositoryURL, filename))
+				MakeRequest(t, req, http.StatusNotFound)
+			})
+
+			t.Run("Database", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				for _, name := range []string{repository + ".db", repository + ".db.tar.gz"} {
+					req := NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s", repositoryURL, name))
+					resp := MakeRequest(t, req, http.StatusOK)
+					data := resp.Body.Bytes()
+
+					verifySignature(t, data, fmt.Sprintf("%s/x86_64/%s.sig", repositoryURL, name))
+
+					files := readDatabase(t, bytes.NewReader(data))
+					assert.Len(t, files, 1)
+
+					desc := files[fmt.Sprintf("%s-%s/desc", packageName, packageVersion)]
+					assert.Contains(t, desc, "%FILENAME%\n"+filename+"\n\n")
+					assert.Contains(t, desc, "%NAME%\n"+packageName+"\n\n")
+					assert.Contains(t, desc, "%VERSION%\n"+packageVersion+"\n\n")
+					assert.Contains(t, desc, "%DESC%\nGitea Test Package\n\n")
+					assert.Contains(t, desc, fmt.Sprintf("%%CSIZE%%\n%d\n\n", len(content)))
+					assert.Contains(t, desc, "%ISIZE%\n4096\n\n")
+					assert.Contains(t, desc, "%URL%\nhttps://gitea.io/\n\n")
+					assert.Contains(t, desc, "%LICENSE%\nMIT\n\n")
+					assert.Contains(t, desc, "%ARCH%\nx86_64\n\n")
+					assert.Contains(t, desc, "%BUILDDATE%\n1679498030\n\n")
+					assert.Contains(t, desc, "%PACKAGER%\nKN4CK3R <kn4ck3r@gitea.io>\n\n")
+					assert.Contains(t, desc, "%DEPENDS%\nglibc\n\n")
+					assert.Contains(t, desc, "%PGPSIG%\n")
+				}
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s.files", repositoryURL, repository))
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				files := readDatabase(t, resp.Body)
+				assert.Len(t, files, 2)
+				assert.Equal(t, "%FILES%\nusr/bin/"+packageName+"\n\n", files[fmt.Sprintf("%s-%s/files", packageName, packageVersion)])
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/aarch64/%s.db", repositoryURL, repository))
+				MakeRequest(t, req, http.StatusNotFound)
+			})
+
+			t.Run("AnyArch", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				req := NewRequestWithBody(t, "PUT", repositoryURL, bytes.NewReader(anyContent)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusCreated)
+
+				anyFilename := fmt.Sprintf("%s-any-%s-any.pkg.tar.zst", packageName, packageVersion)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s", repositoryURL, anyFilename))
+				MakeRequest(t, req, http.StatusOK)
+
+				for _, architecture := range []string{"x86_64", "aarch64"} {
+					req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s.db", repositoryURL, architecture, repository))
+					resp := MakeRequest(t, req, http.StatusOK)
+
+					files := readDatabase(t, resp.Body)
+					_, hasAny := files[fmt.Sprintf("%s-any-%s/desc", packageName, packageVersion)]
+					assert.True(t, hasAny)
+					_, hasX86 := files[fmt.Sprintf("%s-%s/desc", packageName, packageVersion)]
+					assert.Equal(t, architecture == "x86_64", hasX86)
+				}
+
+				req = NewRequest(t, "DELETE", fmt.Sprintf("%s/any/%s", repositoryURL, anyFilename)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusNoContent)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s.db", repositoryURL, repository))
+				resp := MakeRequest(t, req, http.StatusOK)
+				assert.Len(t, readDatabase(t, resp.Body), 1)
+			})
+
+			t.Run("Delete", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				req := NewRequest(t, "DELETE", fmt.Sprintf("%s/x86_64/%s", repositoryURL, filename))
+				MakeRequest(t, req, http.StatusUnauthorized)
+
+				req = NewRequest(t, "DELETE", fmt.Sprintf("%s/x86_64/%s", repositoryURL, filename)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusNoContent)
+
+				req = NewRequest(t, "DELETE", fmt.Sprintf("%s/x86_64/%s", repositoryURL, filename)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusNotFound)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/x86_64/%s.db", repositoryURL, repository))
+				MakeRequest(t, req, http.StatusNotFound)
+
+				pvs, err := packages.GetVersionsByPackageType(db.DefaultContext, user.ID, packages.TypeArch)
+				assert.NoError(t, err)
+				assert.Empty(t, pvs)
+			})
+		})
+	}
+
+	t.Run("InvalidPackage", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", rootURL+"/core", strings.NewReader("not a package")).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,321 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	container_service "code.gitea.io/gitea/services/packages/container"
+	"code.gitea.io/gitea/tests"
+
+	oci "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+// testUpstreamRegistry is a minimal registry serving manifests and blobs for the pull-through cache
+type testUpstreamRegistry struct {
+	sync.Mutex
+	manifests map[string]string
+	blobs     map[string]string
+	requests  map[string]int
+	broken    bool
+}
+
+func (r *testUpstreamRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.requests[req.Method+" "+req.URL.Path]++
+
+	if r.broken {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if image, reference, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/v2/"), "/manifests/"); ok {
+		content, ok := r.manifests[image+":"+reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", oci.MediaTypeImageManifest)
+		if strings.Contains(content, `"manifests"`) {
+			w.Header().Set("Content-Type", oci.MediaTypeImageIndex)
+		}
+		w.Header().Set("Docker-Content-Digest", fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content))))
+		if req.Method == http.MethodGet {
+			_, _ = w.Write([]byte(content))
+		}
+		return
+	}
+	if _, d, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/v2/"), "/blobs/"); ok {
+		content, ok := r.blobs[d]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+func (r *testUpstreamRegistry) addBlob(content string) string {
+	d := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+	r.blobs[d] = content
+	return d
+}
+
+func (r *testUpstreamRegistry) addManifest(image, tag, content string) string {
+	d := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(content)))
+	r.manifests[image+":"+d] = content
+	if tag != "" {
+		r.manifests[image+":"+tag] = content
+	}
+	return d
+}
+
+func (r *testUpstreamRegistry) count(method, path string) int {
+	r.Lock()
+	defer r.Unlock()
+	return r.requests[method+" "+path]
+}
+
+func TestPackageContainerProxy(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+	org := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 3})
+
+	upstream := &testUpstreamRegistry{
+		manifests: map[string]string{},
+		blobs:     map[string]string{},
+		requests:  map[string]int{},
+	}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	defer test.MockVariableValue(&setting.Packages.ContainerProxyUpstreams, []*setting.ContainerProxyUpstream{
+		{Name: "upstream", URL: server.URL},
+		{Name: "credentialed", URL: server.URL, Username: "user", Password: "secret", AllowedOwners: []string{"org17"}},
+	})()
+	defer test.MockVariableValue(&setting.Packages.ContainerProxyTagTTL, time.Hour)()
+
+	createManifest := func(layer string) (string, string, string) {
+		configDigest := upstream.addBlob(`{"architecture":"amd64","os":"linux"}`)
+		layerDigest := upstream.addBlob(layer)
+		content := `{"schemaVersion":2,"mediaType":"` + oci.MediaTypeImageManifest + `","config":{"mediaType":"` + oci.MediaTypeImageConfig + `","digest":"` + configDigest + `","size":37},"layers":[{"mediaType":"` + oci.MediaTypeImageLayerGzip + `","digest":"` + layerDigest + `","size":` + fmt.Sprint(len(layer)) + `}]}`
+		return content, configDigest, layerDigest
+	}
+
+	manifestContent, _, layerDigest := createManifest("layer-1")
+	manifestDigest := upstream.addManifest("library/alpine", "latest", manifestContent)
+	indexContent := `{"schemaVersion":2,"mediaType":"` + oci.MediaTypeImageIndex + `","manifests":[{"mediaType":"` + oci.MediaTypeImageManifest + `","digest":"` + manifestDigest + `","size":` + fmt.Sprint(len(manifestContent)) + `,"platform":{"os":"linux","architecture":"amd64"}}]}`
+	indexDigest := upstream.addManifest("library/alpine", "multi", indexContent)
+	blobOnlyDigest := upstream.addBlob("blob-only")
+
+	url := fmt.Sprintf("/v2/%s/upstream/library/alpine", org.Name)
+
+	t.Run("NotAllowed", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", url+"/manifests/latest").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/v2/%s/upstream/library/alpine/manifests/latest", user.Name)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		assert.Zero(t, upstream.count("GET", "/v2/library/alpine/manifests/latest"))
+	})
+
+	t.Run("Settings", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		session := loginUser(t, user.Name)
+		settingsURL := fmt.Sprintf("/org/%s/settings/packages", org.Name)
+
+		resp := session.MakeRequest(t, NewRequest(t, "GET", settingsURL), http.StatusOK)
+		assert.Contains(t, resp.Body.String(), server.URL)
+		// the upstreams with credentials are only listed for the owners allowed by the administrator
+		assert.NotContains(t, resp.Body.String(), "credentialed")
+
+		req := NewRequestWithValues(t, "POST", settingsURL+"/container_proxy", map[string]string{
+			"_csrf":     GetCSRF(t, session, settingsURL),
+			"upstreams": "credentialed",
+		})
+		session.MakeRequest(t, req, http.StatusSeeOther)
+
+		allowed, err := container_service.GetAllowedProxyUpstreams(db.DefaultContext, org)
+		assert.NoError(t, err)
+		assert.Empty(t, allowed)
+
+		req = NewRequestWithValues(t, "POST", settingsURL+"/container_proxy", map[string]string{
+			"_csrf":     GetCSRF(t, session, settingsURL),
+			"upstreams": "upstream",
+		})
+		session.MakeRequest(t, req, http.StatusSeeOther)
+
+		allowed, err = container_service.GetAllowedProxyUpstreams(db.DefaultContext, org)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"upstream"}, allowed)
+	})
+
+	t.Run("PullManifest", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		for i := 0; i < 2; i++ {
+			req := NewRequest(t, "GET", url+"/manifests/latest").
+				AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+			assert.Equal(t, manifestContent, resp.Body.String())
+		}
+
+		// the second pull is served from the cache
+		assert.Equal(t, 1, upstream.count("GET", "/v2/library/alpine/manifests/latest"))
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/blobs/%s", url, layerDigest)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "layer-1", resp.Body.String())
+		assert.Equal(t, 1, upstream.count("GET", "/v2/library/alpine/blobs/"+layerDigest))
+
+		pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, org.ID, packages_model.TypeContainer, "upstream/library/alpine", "latest")
+		assert.NoError(t, err)
+
+		pd, err := packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+		assert.NoError(t, err)
+		assert.Len(t, pd.VersionProperties.GetByName(container_module.PropertyProxyValidated), 10)
+		assert.Equal(t, "linux/amd64", pd.Metadata.(*container_module.Metadata).Platform)
+		assert.Len(t, pd.Files, 3)
+
+		req = NewRequest(t, "GET", url+"/manifests/unknown").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("PushRejected", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "POST", url+"/blobs/uploads").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		shadowContent, _, _ := createManifest("shadow")
+		req = NewRequestWithBody(t, "PUT", url+"/manifests/latest", strings.NewReader(shadowContent)).
+			AddBasicAuth(user.Name).
+			SetHeader("Content-Type", oci.MediaTypeImageManifest)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", url+"/manifests/latest").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+	})
+
+	t.Run("PullIndex", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "HEAD", url+"/manifests/multi").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, indexDigest, resp.Header().Get("Docker-Content-Digest"))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/manifests/%s", url, indexDigest)).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, indexContent, resp.Body.String())
+
+		// the referenced manifest was cached by the first pull
+		assert.Zero(t, upstream.count("GET", "/v2/library/alpine/manifests/"+manifestDigest))
+		assert.Zero(t, upstream.count("GET", "/v2/library/alpine/manifests/"+indexDigest))
+	})
+
+	t.Run("PullBlob", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/blobs/%s", url, blobOnlyDigest)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "blob-only", resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/blobs/%s", url, blobOnlyDigest)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, 1, upstream.count("GET", "/v2/library/alpine/blobs/"+blobOnlyDigest))
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/blobs/sha256:%x", url, sha256.Sum256([]byte("unknown")))).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("PullBlobLimit", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		largeDigest := upstream.addBlob("large-blob")
+
+		// the blobs exceeding the limits of the owner aren't downloaded
+		defer test.MockVariableValue(&setting.Packages.LimitSizeContainer, 5)()
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/blobs/%s", url, largeDigest)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		defer test.MockVariableValue(&setting.Packages.LimitSizeContainer, -1)()
+		defer test.MockVariableValue(&setting.Packages.LimitTotalOwnerSize, 5)()
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/blobs/%s", url, largeDigest)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusForbidden)
+	})
+
+	t.Run("Revalidate", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		defer test.MockVariableValue(&setting.Packages.ContainerProxyTagTTL, 0)()
+
+		req := NewRequest(t, "GET", url+"/manifests/latest").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		assert.Equal(t, 1, upstream.count("HEAD", "/v2/library/alpine/manifests/latest"))
+		assert.Equal(t, 1, upstream.count("GET", "/v2/library/alpine/manifests/latest"))
+
+		upstream.Lock()
+		newManifestContent, _, _ := createManifest("layer-2")
+		newManifestDigest := upstream.addManifest("library/alpine", "latest", newManifestContent)
+		upstream.Unlock()
+
+		req = NewRequest(t, "GET", url+"/manifests/latest").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, newManifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		assert.Equal(t, newManifestContent, resp.Body.String())
+
+		upstream.Lock()
+		upstream.broken = true
+		upstream.Unlock()
+
+		// stale content is served if the upstream registry fails
+		req = NewRequest(t, "GET", url+"/manifests/latest").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, newManifestDigest, resp.Header().Get("Docker-Content-Digest"))
+	})
+}
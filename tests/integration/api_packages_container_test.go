@@ -77,6 +77,17 @@ func TestPackageContainer(t *testing.T) {
 	indexManifestDigest := "sha256:bab112d6efb9e7f221995caaaa880352feb5bd8b1faf52fae8d12c113aa123ec"
 	indexManifestContent := `{"schemaVersion":2,"mediaType":"` + oci.MediaTypeImageIndex + `","manifests":[{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"` + manifestDigest + `","platform":{"os":"linux","architecture":"arm","variant":"v7"}},{"mediaType":"` + oci.MediaTypeImageManifest + `","digest":"` + untaggedManifestDigest + `","platform":{"os":"linux","architecture":"arm64","variant":"v8"}}]}`
 
+	emptyConfigDigest := "sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a"
+	emptyConfigContent := `{}`
+
+	sbomDigest := "sha256:d4f269605ffe72fbe7a3021d68284798ec364111376ee2eace17688bb52a9e1d"
+	sbomContent := `{"spdxVersion":"SPDX-2.3"}`
+
+	sbomReferrerContent := `{"schemaVersion":2,"mediaType":"` + oci.MediaTypeImageManifest + `","artifactType":"application/spdx+json","config":{"mediaType":"` + oci.MediaTypeEmptyJSON + `","digest":"` + emptyConfigDigest + `","size":2},"layers":[{"mediaType":"application/spdx+json","digest":"` + sbomDigest + `","size":26}],"subject":{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"` + manifestDigest + `","size":` + fmt.Sprint(len(manifestContent)) + `},"annotations":{"org.opencontainers.image.created":"2024-01-01T00:00:00Z"}}`
+	sbomReferrerDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(sbomReferrerContent)))
+	signatureReferrerContent := `{"schemaVersion":2,"mediaType":"` + oci.MediaTypeImageManifest + `","config":{"mediaType":"application/vnd.dev.cosign.artifact.sig.v1+json","digest":"` + emptyConfigDigest + `","size":2},"layers":[{"mediaType":"application/spdx+json","digest":"` + sbomDigest + `","size":26}],"subject":{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","digest":"` + manifestDigest + `","size":` + fmt.Sprint(len(manifestContent)) + `}}`
+	signatureReferrerDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(signatureReferrerContent)))
+
 	anonymousToken := ""
 	userToken := ""
 	readToken := ""
@@ -684,6 +695,100 @@ func TestPackageContainer(t *testing.T) {
 				assert.Len(t, apiPackages, 4) // "latest", "main", "multi", "sha256:..."
 			})
 
+			t.Run("Referrers", func(t *testing.T) {
+				defer tests.PrintCurrentTest(t)()
+
+				for digest, content := range map[string]string{emptyConfigDigest: emptyConfigContent, sbomDigest: sbomContent} {
+					req := NewRequestWithBody(t, "POST", fmt.Sprintf("%s/blobs/uploads?digest=%s", url, digest), strings.NewReader(content)).
+						AddTokenAuth(userToken)
+					MakeRequest(t, req, http.StatusCreated)
+				}
+
+				for digest, content := range map[string]string{sbomReferrerDigest: sbomReferrerContent, signatureReferrerDigest: signatureReferrerContent} {
+					req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/manifests/%s", url, digest), strings.NewReader(content)).
+						AddTokenAuth(userToken).
+						SetHeader("Content-Type", oci.MediaTypeImageManifest)
+					resp := MakeRequest(t, req, http.StatusCreated)
+
+					assert.Equal(t, digest, resp.Header().Get("Docker-Content-Digest"))
+					assert.Equal(t, manifestDigest, resp.Header().Get("OCI-Subject"))
+				}
+
+				pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeContainer, image, sbomReferrerDigest)
+				assert.NoError(t, err)
+
+				pd, err := packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+				assert.NoError(t, err)
+				assert.Equal(t, []string{manifestDigest}, getAllByName(pd.VersionProperties, container_module.PropertyManifestSubject))
+				assert.Equal(t, []string{"application/spdx+json"}, getAllByName(pd.VersionProperties, container_module.PropertyArtifactType))
+				metadata := pd.Metadata.(*container_module.Metadata)
+				assert.Equal(t, manifestDigest, metadata.Subject)
+				assert.Empty(t, metadata.Platform)
+
+				type referrers struct {
+					Manifests []oci.Descriptor `json:"manifests"`
+				}
+
+				req := NewRequest(t, "GET", fmt.Sprintf("%s/referrers/%s", url, manifestDigest)).
+					AddTokenAuth(anonymousToken)
+				resp := MakeRequest(t, req, http.StatusOK)
+
+				assert.Equal(t, oci.MediaTypeImageIndex, resp.Header().Get("Content-Type"))
+				assert.Empty(t, resp.Header().Get("OCI-Filters-Applied"))
+
+				var index referrers
+				DecodeJSON(t, resp, &index)
+				assert.Len(t, index.Manifests, 2)
+				for _, d := range index.Manifests {
+					assert.Equal(t, oci.MediaTypeImageManifest, d.MediaType)
+					switch string(d.Digest) {
+					case sbomReferrerDigest:
+						assert.EqualValues(t, len(sbomReferrerContent), d.Size)
+						assert.Equal(t, "application/spdx+json", d.ArtifactType)
+						assert.Equal(t, map[string]string{"org.opencontainers.image.created": "2024-01-01T00:00:00Z"}, d.Annotations)
+					case signatureReferrerDigest:
+						assert.EqualValues(t, len(signatureReferrerContent), d.Size)
+						assert.Equal(t, "application/vnd.dev.cosign.artifact.sig.v1+json", d.ArtifactType)
+						assert.Empty(t, d.Annotations)
+					default:
+						assert.Fail(t, "unexpected referrer", d.Digest)
+					}
+				}
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/referrers/%s?artifactType=%s", url, manifestDigest, "application/spdx%2Bjson")).
+					AddTokenAuth(anonymousToken)
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				assert.Equal(t, "artifactType", resp.Header().Get("OCI-Filters-Applied"))
+
+				index = referrers{}
+				DecodeJSON(t, resp, &index)
+				assert.Len(t, index.Manifests, 1)
+				assert.EqualValues(t, sbomReferrerDigest, index.Manifests[0].Digest)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/referrers/%s", url, unknownDigest)).
+					AddTokenAuth(anonymousToken)
+				resp = MakeRequest(t, req, http.StatusOK)
+
+				index = referrers{}
+				DecodeJSON(t, resp, &index)
+				assert.Empty(t, index.Manifests)
+
+				req = NewRequest(t, "GET", fmt.Sprintf("%s/referrers/invalid", url)).
+					AddTokenAuth(anonymousToken)
+				MakeRequest(t, req, http.StatusBadRequest)
+
+				pv, err = packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeContainer, image, tags[0])
+				assert.NoError(t, err)
+				pd, err = packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+				assert.NoError(t, err)
+
+				req = NewRequest(t, "GET", pd.VersionWebLink())
+				resp = MakeRequest(t, req, http.StatusOK)
+				assert.Contains(t, resp.Body.String(), sbomReferrerDigest)
+				assert.Contains(t, resp.Body.String(), signatureReferrerDigest)
+			})
+
 			t.Run("Delete", func(t *testing.T) {
 				t.Run("Blob", func(t *testing.T) {
 					defer tests.PrintCurrentTest(t)()
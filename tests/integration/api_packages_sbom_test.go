@@ -0,0 +1,230 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	sbom_module "code.gitea.io/gitea/modules/packages/sbom"
+	api "code.gitea.io/gitea/modules/structs"
+	packages_sbom_service "code.gitea.io/gitea/services/packages/sbom"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageSBOM(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	token := getUserToken(t, user.Name, auth_model.AccessTokenScopeWritePackage)
+
+	packageName := "sbom-test-package"
+	packageVersion := "1.0.0"
+
+	cycloneDX := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"metadata": {"component": {"name": "` + packageName + `", "version": "` + packageVersion + `", "purl": "pkg:npm/` + packageName + `@` + packageVersion + `"}},
+		"components": [{"name": "lodash", "version": "4.17.0", "purl": "pkg:npm/lodash@4.17.0"}]
+	}`
+	spdx := `{
+		"spdxVersion": "SPDX-2.3",
+		"packages": [{"name": "minimist", "versionInfo": "1.2.0", "externalRefs": [{"referenceType": "purl", "referenceLocator": "pkg:npm/minimist@1.2.0"}]}]
+	}`
+
+	var tarball bytes.Buffer
+	gw := gzip.NewWriter(&tarball)
+	tw := tar.NewWriter(gw)
+	for _, file := range []struct{ Name, Content string }{
+		{"package/package.json", `{"name": "` + packageName + `", "version": "` + packageVersion + `"}`},
+		{"package/bom.json", cycloneDX},
+	} {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: file.Name, Mode: 0o600, Size: int64(len(file.Content))}))
+		_, err := tw.Write([]byte(file.Content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+
+	integrity := sha512.Sum512(tarball.Bytes())
+
+	upload := `{
+		"_id": "` + packageName + `",
+		"name": "` + packageName + `",
+		"dist-tags": {"latest": "` + packageVersion + `"},
+		"versions": {
+			"` + packageVersion + `": {
+				"name": "` + packageName + `",
+				"version": "` + packageVersion + `",
+				"dist": {"integrity": "sha512-` + base64.StdEncoding.EncodeToString(integrity[:]) + `"}
+			}
+		},
+		"_attachments": {
+			"` + packageName + `-` + packageVersion + `.tgz": {"data": "` + base64.StdEncoding.EncodeToString(tarball.Bytes()) + `"}
+		}
+	}`
+
+	req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, packageName), strings.NewReader(upload)).
+		AddBasicAuth(user.Name)
+	MakeRequest(t, req, http.StatusCreated)
+
+	pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeNpm, packageName, packageVersion)
+	assert.NoError(t, err)
+
+	root := fmt.Sprintf("/api/v1/packages/%s/npm/%s/%s", user.Name, packageName, packageVersion)
+
+	t.Run("ExtractedFromUpload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", root+"/sboms").
+			AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var sboms []*api.PackageSBOM
+		DecodeJSON(t, resp, &sboms)
+		assert.Len(t, sboms, 1)
+		assert.Equal(t, sbom_module.FormatCycloneDX, sboms[0].Format)
+		assert.EqualValues(t, len(cycloneDX), sboms[0].Size)
+
+		// the documents are not listed as files of the package
+		req = NewRequest(t, "GET", root+"/files").
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var files []*api.PackageFile
+		DecodeJSON(t, resp, &files)
+		assert.Len(t, files, 1)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequestWithBody(t, "PUT", root+"/sboms", strings.NewReader(`{"invalid": true}`)).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusBadRequest)
+
+		req = NewRequestWithBody(t, "PUT", root+"/sboms", strings.NewReader(spdx))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", root+"/sboms", strings.NewReader(spdx)).
+			AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusCreated)
+
+		var sbom *api.PackageSBOM
+		DecodeJSON(t, resp, &sbom)
+		assert.Equal(t, sbom_module.FormatSPDX, sbom.Format)
+
+		req = NewRequest(t, "GET", root+"/sboms").
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var sboms []*api.PackageSBOM
+		DecodeJSON(t, resp, &sboms)
+		assert.Len(t, sboms, 2)
+
+		req = NewRequest(t, "GET", root+"/sboms/spdx").
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, spdx, resp.Body.String())
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/%s/-/packages/npm/%s/%s/sboms/cyclonedx", user.Name, packageName, packageVersion)).
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, cycloneDX, resp.Body.String())
+	})
+
+	t.Run("Vulnerabilities", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"GHSA-self.json":      `{"id": "GHSA-self", "summary": "package is affected", "aliases": ["CVE-2024-0001"], "database_specific": {"severity": "HIGH"}, "affected": [{"package": {"ecosystem": "npm", "name": "` + packageName + `"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.1.0"}]}]}]}`,
+			"GHSA-lodash.json":    `{"id": "GHSA-lodash", "summary": "lodash is affected", "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]}]}`,
+			"GHSA-minimist.json":  `{"id": "GHSA-minimist", "summary": "minimist is affected", "affected": [{"package": {"ecosystem": "npm", "name": "minimist"}, "versions": ["1.2.0"]}]}`,
+			"GHSA-fixed.json":     `{"id": "GHSA-fixed", "summary": "old versions are affected", "affected": [{"package": {"ecosystem": "npm", "name": "` + packageName + `"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.9.0"}]}]}]}`,
+			"GHSA-withdrawn.json": `{"id": "GHSA-withdrawn", "withdrawn": "2024-01-01T00:00:00Z", "affected": [{"package": {"ecosystem": "npm", "name": "` + packageName + `"}, "versions": ["1.0.0"]}]}`,
+			"PYSEC-other.json":    `{"id": "PYSEC-other", "affected": [{"package": {"ecosystem": "PyPI", "name": "` + packageName + `"}, "versions": ["1.0.0"]}]}`,
+		} {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+		}
+
+		assert.NoError(t, packages_sbom_service.ImportDatabase(db.DefaultContext, dir))
+
+		req := NewRequest(t, "GET", root).
+			AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var p *api.Package
+		DecodeJSON(t, resp, &p)
+		assert.EqualValues(t, 3, p.VulnerabilityCount)
+
+		req = NewRequest(t, "GET", root+"/vulnerabilities").
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+
+		var vulnerabilities []*api.PackageVulnerability
+		DecodeJSON(t, resp, &vulnerabilities)
+		assert.Len(t, vulnerabilities, 3)
+		assert.Equal(t, "GHSA-lodash", vulnerabilities[0].ID)
+		assert.Equal(t, "lodash@4.17.0", vulnerabilities[0].Component)
+		assert.Equal(t, "GHSA-minimist", vulnerabilities[1].ID)
+		assert.Equal(t, "minimist@1.2.0", vulnerabilities[1].Component)
+		assert.Equal(t, "GHSA-self", vulnerabilities[2].ID)
+		assert.Empty(t, vulnerabilities[2].Component)
+		assert.Equal(t, "HIGH", vulnerabilities[2].Severity)
+		assert.Equal(t, []string{"CVE-2024-0001"}, vulnerabilities[2].Aliases)
+		assert.Equal(t, "https://osv.dev/vulnerability/GHSA-self", vulnerabilities[2].URL)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/%s/-/packages/npm/%s/%s", user.Name, packageName, packageVersion)).
+			AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "GHSA-self")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "DELETE", root+"/sboms/spdx").
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "DELETE", root+"/sboms/spdx").
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// the components of the deleted document are not matched anymore
+		vulnerabilities, err := packages_model.GetVersionVulnerabilities(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, vulnerabilities, 2)
+
+		req = NewRequest(t, "DELETE", root).
+			AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		vulnerabilities, err = packages_model.GetVersionVulnerabilities(db.DefaultContext, pv.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, vulnerabilities)
+
+		docs, err := packages_sbom_service.GetDocuments(db.DefaultContext, pv)
+		assert.NoError(t, err)
+		assert.Empty(t, docs)
+	})
+}
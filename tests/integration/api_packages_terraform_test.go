@@ -0,0 +1,320 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	"code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	terraform_module "code.gitea.io/gitea/modules/packages/terraform"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageTerraform(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	t.Run("ServiceDiscovery", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		req := NewRequest(t, "GET", "/.well-known/terraform.json")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result map[string]string
+		DecodeJSON(t, resp, &result)
+		assert.Equal(t, setting.AppSubURL+"/api/packages/terraform/modules/v1/", result["modules.v1"])
+		assert.Equal(t, setting.AppSubURL+"/api/packages/terraform/providers/v1/", result["providers.v1"])
+	})
+
+	t.Run("Module", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		var buf bytes.Buffer
+		gzw := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gzw)
+		content := []byte(`variable "name" {}`)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "main.tf", Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+		require.NoError(t, gzw.Close())
+		archive := buf.Bytes()
+
+		uploadURL := fmt.Sprintf("/api/packages/%s/terraform/modules/vpc/aws", user.Name)
+		registryURL := fmt.Sprintf("/api/packages/terraform/modules/v1/%s/vpc/aws", user.Name)
+
+		t.Run("Upload", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequestWithBody(t, "PUT", uploadURL+"/1.0.0", bytes.NewReader(archive))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/invalid", bytes.NewReader(archive)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/1.0.0", strings.NewReader("not an archive")).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			for _, version := range []string{"1.0.0", "1.10.0", "1.2.0"} {
+				req = NewRequestWithBody(t, "PUT", uploadURL+"/"+version, bytes.NewReader(archive)).
+					AddBasicAuth(user.Name)
+				MakeRequest(t, req, http.StatusCreated)
+			}
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/1.0.0", bytes.NewReader(archive)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+
+			pvs, err := packages.GetVersionsByPackageName(db.DefaultContext, user.ID, packages.TypeTerraform, "vpc/aws")
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 3)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+			assert.NoError(t, err)
+			assert.NotNil(t, pd.SemVer)
+			assert.IsType(t, &terraform_module.Metadata{}, pd.Metadata)
+			assert.Equal(t, terraform_module.KindModule, pd.Metadata.(*terraform_module.Metadata).Kind)
+			assert.Len(t, pd.Files, 1)
+			assert.Equal(t, fmt.Sprintf("vpc-aws-%s.tar.gz", pd.Version.Version), pd.Files[0].File.Name)
+		})
+
+		t.Run("Versions", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", registryURL+"/versions")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type moduleVersions struct {
+				Modules []struct {
+					Versions []struct {
+						Version string `json:"version"`
+					} `json:"versions"`
+				} `json:"modules"`
+			}
+
+			var result moduleVersions
+			DecodeJSON(t, resp, &result)
+			require.Len(t, result.Modules, 1)
+			versions := make([]string, 0, 3)
+			for _, v := range result.Modules[0].Versions {
+				versions = append(versions, v.Version)
+			}
+			assert.Equal(t, []string{"1.0.0", "1.2.0", "1.10.0"}, versions)
+
+			req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/terraform/modules/v1/%s/unknown/aws/versions", user.Name))
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+
+		t.Run("Download", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", registryURL+"/1.2.0/download")
+			resp := MakeRequest(t, req, http.StatusNoContent)
+
+			location := resp.Header().Get("X-Terraform-Get")
+			assert.Equal(t, fmt.Sprintf("%sapi/packages/%s/terraform/modules/vpc/aws/1.2.0/vpc-aws-1.2.0.tar.gz", setting.AppURL, user.Name), location)
+
+			req = NewRequest(t, "GET", strings.TrimPrefix(location, setting.AppURL[:len(setting.AppURL)-1]))
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, archive, resp.Body.Bytes())
+
+			req = NewRequest(t, "GET", registryURL+"/9.9.9/download")
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "DELETE", uploadURL+"/1.0.0")
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequest(t, "DELETE", uploadURL+"/1.0.0").
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequest(t, "DELETE", uploadURL+"/1.0.0").
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+	})
+
+	t.Run("Provider", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		createProvider := func(t *testing.T, os, arch string) []byte {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			w, err := zw.Create("terraform-provider-random_v2.0.0_x5")
+			require.NoError(t, err)
+			_, err = w.Write([]byte(os + "/" + arch))
+			require.NoError(t, err)
+			require.NoError(t, zw.Close())
+			return buf.Bytes()
+		}
+
+		linuxPackage := createProvider(t, "linux", "amd64")
+		darwinPackage := createProvider(t, "darwin", "arm64")
+
+		uploadURL := fmt.Sprintf("/api/packages/%s/terraform/providers/random/2.0.0", user.Name)
+		registryURL := fmt.Sprintf("/api/packages/terraform/providers/v1/%s/random", user.Name)
+
+		t.Run("Upload", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequestWithBody(t, "PUT", uploadURL+"/linux/amd64", bytes.NewReader(linuxPackage))
+			MakeRequest(t, req, http.StatusUnauthorized)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/linux/amd64?protocols=5", bytes.NewReader(linuxPackage)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/linux/amd64", strings.NewReader("not a zip")).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusBadRequest)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/linux/amd64?protocols=5.0,6.0", bytes.NewReader(linuxPackage)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/darwin/arm64", bytes.NewReader(darwinPackage)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusCreated)
+
+			req = NewRequestWithBody(t, "PUT", uploadURL+"/darwin/arm64", bytes.NewReader(darwinPackage)).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusConflict)
+
+			pvs, err := packages.GetVersionsByPackageName(db.DefaultContext, user.ID, packages.TypeTerraform, "random")
+			assert.NoError(t, err)
+			assert.Len(t, pvs, 1)
+
+			pd, err := packages.GetPackageDescriptor(db.DefaultContext, pvs[0])
+			assert.NoError(t, err)
+			metadata := pd.Metadata.(*terraform_module.Metadata)
+			assert.Equal(t, terraform_module.KindProvider, metadata.Kind)
+			assert.Equal(t, []string{"5.0", "6.0"}, metadata.Protocols)
+			assert.Len(t, pd.Files, 2)
+		})
+
+		t.Run("Versions", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", registryURL+"/versions")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type providerVersions struct {
+				Versions []struct {
+					Version   string   `json:"version"`
+					Protocols []string `json:"protocols"`
+					Platforms []struct {
+						OS   string `json:"os"`
+						Arch string `json:"arch"`
+					} `json:"platforms"`
+				} `json:"versions"`
+			}
+
+			var result providerVersions
+			DecodeJSON(t, resp, &result)
+			require.Len(t, result.Versions, 1)
+			assert.Equal(t, "2.0.0", result.Versions[0].Version)
+			assert.Equal(t, []string{"5.0", "6.0"}, result.Versions[0].Protocols)
+			platforms := make([]string, 0, 2)
+			for _, p := range result.Versions[0].Platforms {
+				platforms = append(platforms, p.OS+"_"+p.Arch)
+			}
+			assert.ElementsMatch(t, []string{"linux_amd64", "darwin_arm64"}, platforms)
+		})
+
+		t.Run("Download", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "GET", registryURL+"/2.0.0/download/linux/amd64")
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			type providerPackage struct {
+				Protocols           []string `json:"protocols"`
+				OS                  string   `json:"os"`
+				Arch                string   `json:"arch"`
+				Filename            string   `json:"filename"`
+				DownloadURL         string   `json:"download_url"`
+				SHASumsURL          string   `json:"shasums_url"`
+				SHASumsSignatureURL string   `json:"shasums_signature_url"`
+				SHASum              string   `json:"shasum"`
+				SigningKeys         struct {
+					GPGPublicKeys []struct {
+						KeyID      string `json:"key_id"`
+						ASCIIArmor string `json:"ascii_armor"`
+					} `json:"gpg_public_keys"`
+				} `json:"signing_keys"`
+			}
+
+			var result providerPackage
+			DecodeJSON(t, resp, &result)
+
+			linuxHash := sha256.Sum256(linuxPackage)
+			darwinHash := sha256.Sum256(darwinPackage)
+
+			assert.Equal(t, []string{"5.0", "6.0"}, result.Protocols)
+			assert.Equal(t, "linux", result.OS)
+			assert.Equal(t, "amd64", result.Arch)
+			assert.Equal(t, "terraform-provider-random_2.0.0_linux_amd64.zip", result.Filename)
+			assert.Equal(t, hex.EncodeToString(linuxHash[:]), result.SHASum)
+			require.Len(t, result.SigningKeys.GPGPublicKeys, 1)
+
+			keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(result.SigningKeys.GPGPublicKeys[0].ASCIIArmor))
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToUpper(keyring[0].PrimaryKey.KeyIdString()), result.SigningKeys.GPGPublicKeys[0].KeyID)
+
+			appURL := setting.AppURL[:len(setting.AppURL)-1]
+
+			req = NewRequest(t, "GET", strings.TrimPrefix(result.DownloadURL, appURL))
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, linuxPackage, resp.Body.Bytes())
+
+			req = NewRequest(t, "GET", strings.TrimPrefix(result.SHASumsURL, appURL))
+			resp = MakeRequest(t, req, http.StatusOK)
+			shasums := resp.Body.Bytes()
+			assert.Equal(t, fmt.Sprintf("%s  terraform-provider-random_2.0.0_darwin_arm64.zip\n%s  terraform-provider-random_2.0.0_linux_amd64.zip\n", hex.EncodeToString(darwinHash[:]), hex.EncodeToString(linuxHash[:])), string(shasums))
+
+			req = NewRequest(t, "GET", strings.TrimPrefix(result.SHASumsSignatureURL, appURL))
+			resp = MakeRequest(t, req, http.StatusOK)
+			_, err = openpgp.CheckDetachedSignature(keyring, bytes.NewReader(shasums), resp.Body, nil)
+			assert.NoError(t, err)
+
+			req = NewRequest(t, "GET", registryURL+"/2.0.0/download/windows/amd64")
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+
+		t.Run("Delete", func(t *testing.T) {
+			defer tests.PrintCurrentTest(t)()
+
+			req := NewRequest(t, "DELETE", uploadURL).
+				AddBasicAuth(user.Name)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequest(t, "GET", registryURL+"/versions")
+			MakeRequest(t, req, http.StatusNotFound)
+		})
+	})
+}
@@ -0,0 +1,384 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"code.gitea.io/gitea/models/db"
+	packages_model "code.gitea.io/gitea/models/packages"
+	"code.gitea.io/gitea/models/unittest"
+	user_model "code.gitea.io/gitea/models/user"
+	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/test"
+	"code.gitea.io/gitea/tests"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testUpstreamFiles is a minimal registry serving static files
+type testUpstreamFiles struct {
+	sync.Mutex
+	files    map[string]string
+	requests map[string]int
+}
+
+func (r *testUpstreamFiles) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.requests[req.URL.EscapedPath()]++
+
+	content, ok := r.files[req.URL.EscapedPath()]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_, _ = w.Write([]byte(content))
+}
+
+func (r *testUpstreamFiles) count(path string) int {
+	r.Lock()
+	defer r.Unlock()
+	return r.requests[path]
+}
+
+func TestPackageUpstream(t *testing.T) {
+	defer tests.PrepareTestEnv(t)()
+
+	user := unittest.AssertExistsAndLoadBean(t, &user_model.User{ID: 2})
+
+	upstream := &testUpstreamFiles{
+		files:    map[string]string{},
+		requests: map[string]int{},
+	}
+	server := httptest.NewServer(upstream)
+	defer server.Close()
+
+	newUpstream := func(path string, allowed, blocked []string) *setting.PackageUpstream {
+		u := &setting.PackageUpstream{URL: server.URL + path, MaxFileSize: 1024}
+		for _, p := range allowed {
+			g, err := setting.GlobMatcherCompile(p)
+			assert.NoError(t, err)
+			u.AllowedPatterns = append(u.AllowedPatterns, g)
+		}
+		for _, p := range blocked {
+			g, err := setting.GlobMatcherCompile(p)
+			assert.NoError(t, err)
+			u.BlockedPatterns = append(u.BlockedPatterns, g)
+		}
+		return u
+	}
+
+	defer test.MockVariableValue(&setting.Packages.Upstreams, map[string]*setting.PackageUpstream{
+		"npm":   newUpstream("/npm", nil, []string{"@internal/*"}),
+		"pypi":  newUpstream("/pypi/simple", nil, nil),
+		"maven": newUpstream("/maven", []string{"com.example-*"}, nil),
+	})()
+
+	t.Run("Npm", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		packageName := "upstream-package"
+		tarball := "npm-tarball"
+		sha512Sum := sha512.Sum512([]byte(tarball))
+
+		upstream.Lock()
+		upstream.files["/npm/"+packageName] = `{
+			"name": "` + packageName + `",
+			"dist-tags": {"latest": "1.0.0"},
+			"versions": {
+				"1.0.0": {
+					"name": "` + packageName + `",
+					"version": "1.0.0",
+					"description": "Upstream Description",
+					"license": "MIT",
+					"dependencies": {"left-pad": "^1.0.0"},
+					"dist": {
+						"integrity": "sha512-` + base64.StdEncoding.EncodeToString(sha512Sum[:]) + `",
+						"shasum": "` + fmt.Sprintf("%x", sha1.Sum([]byte(tarball))) + `",
+						"tarball": "` + server.URL + `/files/` + packageName + `-1.0.0.tgz"
+					}
+				},
+				"0.9.0": {
+					"name": "` + packageName + `",
+					"version": "0.9.0",
+					"dist": {
+						"shasum": "0000000000000000000000000000000000000000",
+						"tarball": "` + server.URL + `/files/` + packageName + `-0.9.0.tgz"
+					}
+				},
+				"0.1.0": {
+					"name": "` + packageName + `",
+					"version": "0.1.0",
+					"license": {"type": "MIT"}
+				}
+			}
+		}`
+		upstream.files["/files/"+packageName+"-1.0.0.tgz"] = tarball
+		upstream.files["/files/"+packageName+"-0.9.0.tgz"] = tarball
+		upstream.files["/npm/@internal%2fsecret"] = `{}`
+		upstream.Unlock()
+
+		root := fmt.Sprintf("/api/packages/%s/npm", user.Name)
+		tarballURL := fmt.Sprintf("%s%s/%s/-/1.0.0/%s-1.0.0.tgz", setting.AppURL, root[1:], packageName, packageName)
+
+		req := NewRequest(t, "GET", root+"/"+packageName).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result npm_module.PackageMetadata
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, packageName, result.Name)
+		assert.Equal(t, "Upstream Description", result.Description)
+		assert.Equal(t, map[string]string{"latest": "1.0.0"}, result.DistTags)
+		assert.Len(t, result.Versions, 2)
+		assert.Equal(t, tarballURL, result.Versions["1.0.0"].Dist.Tarball)
+
+		for i := 0; i < 2; i++ {
+			req = NewRequest(t, "GET", tarballURL).
+				AddBasicAuth(user.Name)
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, tarball, resp.Body.String())
+		}
+		assert.Equal(t, 1, upstream.count("/files/"+packageName+"-1.0.0.tgz"))
+
+		pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeNpm, packageName, "1.0.0")
+		assert.NoError(t, err)
+		pd, err := packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+		assert.NoError(t, err)
+		assert.EqualValues(t, user_model.GhostUserID, pd.Creator.ID)
+		assert.Equal(t, map[string]string{"left-pad": "^1.0.0"}, pd.Metadata.(*npm_module.Metadata).Dependencies)
+
+		// the cached version is merged with the upstream versions
+		req = NewRequest(t, "GET", root+"/"+packageName).
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		result = npm_module.PackageMetadata{}
+		DecodeJSON(t, resp, &result)
+		assert.Len(t, result.Versions, 2)
+		assert.Equal(t, tarballURL, result.Versions["1.0.0"].Dist.Tarball)
+
+		// the integrity of upstream files is validated
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/-/0.9.0/%s-0.9.0.tgz", root, packageName, packageName)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusInternalServerError)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/-/2.0.0/%s-2.0.0.tgz", root, packageName, packageName)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", root+"/@internal/secret").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+		assert.Zero(t, upstream.count("/npm/@internal%2fsecret"))
+	})
+
+	t.Run("NpmLimits", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		packageName := "upstream-limits"
+		tarball := strings.Repeat("a", 2048)
+		// the upstream server is also reachable by another host name which isn't allowed
+		otherHost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+		upstream.Lock()
+		upstream.files["/npm/"+packageName] = `{
+			"name": "` + packageName + `",
+			"versions": {
+				"1.0.0": {
+					"name": "` + packageName + `",
+					"version": "1.0.0",
+					"dist": {
+						"shasum": "` + fmt.Sprintf("%x", sha1.Sum([]byte(tarball))) + `",
+						"tarball": "` + server.URL + `/files/` + packageName + `-1.0.0.tgz"
+					}
+				},
+				"2.0.0": {
+					"name": "` + packageName + `",
+					"version": "2.0.0",
+					"dist": {
+						"shasum": "` + fmt.Sprintf("%x", sha1.Sum([]byte("tarball"))) + `",
+						"tarball": "` + otherHost + `/files/` + packageName + `-2.0.0.tgz"
+					}
+				}
+			}
+		}`
+		upstream.files["/files/"+packageName+"-1.0.0.tgz"] = tarball
+		upstream.files["/files/"+packageName+"-2.0.0.tgz"] = "tarball"
+		upstream.Unlock()
+
+		root := fmt.Sprintf("/api/packages/%s/npm", user.Name)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/-/1.0.0/%s-1.0.0.tgz", root, packageName, packageName)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/-/2.0.0/%s-2.0.0.tgz", root, packageName, packageName)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusInternalServerError)
+		assert.Zero(t, upstream.count("/files/"+packageName+"-2.0.0.tgz"))
+
+		_, err := packages_model.GetPackageByName(db.DefaultContext, user.ID, packages_model.TypeNpm, packageName)
+		assert.ErrorIs(t, err, packages_model.ErrPackageNotExist)
+
+		defer test.MockVariableValue(&setting.Packages.Upstreams["npm"].AllowedHosts, "localhost")()
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/%s/-/2.0.0/%s-2.0.0.tgz", root, packageName, packageName)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "tarball", resp.Body.String())
+	})
+
+	t.Run("PyPI", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		packageName := "upstream-package"
+		filename := "upstream_package-1.0.0.tar.gz"
+		content := "pypi-sdist"
+
+		upstream.Lock()
+		upstream.files["/pypi/simple/"+packageName+"/"] = `<!DOCTYPE html>
+<html><body>
+<a href="../../files/` + filename + `#sha256=` + fmt.Sprintf("%x", sha256.Sum256([]byte(content))) + `" data-requires-python="&gt;=3.8">` + filename + `</a><br>
+<a href="` + server.URL + `/files/upstream_package-1.0.0-py3.8.egg">upstream_package-1.0.0-py3.8.egg</a><br>
+</body></html>`
+		upstream.files["/pypi/files/"+filename] = content
+		upstream.Unlock()
+
+		root := fmt.Sprintf("/api/packages/%s/pypi", user.Name)
+		fileURL := fmt.Sprintf("%s/files/%s/1.0.0/%s", root, packageName, filename)
+
+		checkSimplePage := func(t *testing.T) {
+			req := NewRequest(t, "GET", root+"/simple/"+packageName).
+				AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+
+			htmlDoc := NewHTMLParser(t, resp.Body)
+			links := htmlDoc.Find("a")
+			assert.Equal(t, 1, links.Length())
+			href, _ := links.Attr("href")
+			assert.True(t, strings.HasSuffix(href, fileURL+"#sha256="+fmt.Sprintf("%x", sha256.Sum256([]byte(content)))), href)
+			requiresPython, _ := links.Attr("data-requires-python")
+			assert.Equal(t, ">=3.8", requiresPython)
+		}
+
+		checkSimplePage(t)
+
+		for i := 0; i < 2; i++ {
+			req := NewRequest(t, "GET", fileURL).
+				AddBasicAuth(user.Name)
+			resp := MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, content, resp.Body.String())
+		}
+		assert.Equal(t, 1, upstream.count("/pypi/files/"+filename))
+
+		// the cached file is listed once
+		checkSimplePage(t)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/files/%s/2.0.0/upstream_package-2.0.0.tar.gz", root, packageName)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", root+"/simple/unknown-package").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("Maven", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		jarContent := "maven-jar"
+		pomContent := `<?xml version="1.0"?>
+<project>
+  <groupId>com.example</groupId>
+  <artifactId>library</artifactId>
+  <version>1.0</version>
+  <description>Upstream Description</description>
+</project>`
+
+		upstream.Lock()
+		upstream.files["/maven/com/example/library/maven-metadata.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata><groupId>com.example</groupId><artifactId>library</artifactId><versioning><release>1.0</release><latest>1.0</latest><versions><version>0.9</version><version>1.0</version></versions></versioning></metadata>`
+		upstream.files["/maven/com/example/library/1.0/library-1.0.jar"] = jarContent
+		upstream.files["/maven/com/example/library/1.0/library-1.0.jar.sha1"] = fmt.Sprintf("%x", sha1.Sum([]byte(jarContent)))
+		upstream.files["/maven/com/example/library/1.0/library-1.0.pom"] = pomContent
+		upstream.files["/maven/org/other/library/1.0/library-1.0.jar"] = jarContent
+		upstream.Unlock()
+
+		root := fmt.Sprintf("/api/packages/%s/maven", user.Name)
+
+		req := NewRequest(t, "GET", root+"/com/example/library/maven-metadata.xml").
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "<versions><version>0.9</version><version>1.0</version></versions>")
+
+		for i := 0; i < 2; i++ {
+			req = NewRequest(t, "GET", root+"/com/example/library/1.0/library-1.0.jar").
+				AddBasicAuth(user.Name)
+			resp = MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, jarContent, resp.Body.String())
+		}
+		assert.Equal(t, 1, upstream.count("/maven/com/example/library/1.0/library-1.0.jar"))
+
+		req = NewRequest(t, "GET", root+"/com/example/library/1.0/library-1.0.jar.sha1").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, fmt.Sprintf("%x", sha1.Sum([]byte(jarContent))), resp.Body.String())
+
+		req = NewRequest(t, "HEAD", root+"/com/example/library/1.0/library-1.0.pom").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		pv, err := packages_model.GetVersionByNameAndVersion(db.DefaultContext, user.ID, packages_model.TypeMaven, "com.example-library", "1.0")
+		assert.NoError(t, err)
+		pd, err := packages_model.GetPackageDescriptor(db.DefaultContext, pv)
+		assert.NoError(t, err)
+		assert.Equal(t, "Upstream Description", pd.Metadata.(*maven_module.Metadata).Description)
+		assert.Len(t, pd.Files, 2)
+
+		// the cached version is merged with the upstream versions
+		req = NewRequest(t, "GET", root+"/com/example/library/maven-metadata.xml").
+			AddBasicAuth(user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "<release>1.0</release><latest>1.0</latest><versions><version>0.9</version><version>1.0</version></versions>")
+
+		req = NewRequest(t, "GET", root+"/org/other/library/1.0/library-1.0.jar").
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusNotFound)
+		assert.Zero(t, upstream.count("/maven/org/other/library/1.0/library-1.0.jar"))
+	})
+
+	t.Run("UpstreamNotAvailable", func(t *testing.T) {
+		defer tests.PrintCurrentTest(t)()
+
+		server.Close()
+
+		// cached packages are served without the upstream registry
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/npm/upstream-package", user.Name)).
+			AddBasicAuth(user.Name)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result npm_module.PackageMetadata
+		DecodeJSON(t, resp, &result)
+		assert.Len(t, result.Versions, 1)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/maven/com/example/library/1.0/library-1.0.jar", user.Name)).
+			AddBasicAuth(user.Name)
+		MakeRequest(t, req, http.StatusOK)
+	})
+
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	"code.gitea.io/gitea/models/db"
+	quota_model "code.gitea.io/gitea/models/quota"
+	repo_model "code.gitea.io/gitea/models/repo"
+	"code.gitea.io/gitea/models/unittest"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIQuota(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		defer test.MockVariableValue(&setting.Quota.Enabled, true)()
+		defer test.MockVariableValue(&setting.LFS.StartServer, true)()
+
+		adminToken := getTokenForLoggedInUser(t, loginUser(t, "user1"), auth_model.AccessTokenScopeWriteAdmin)
+		session := loginUser(t, "user2")
+		userToken := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeReadUser)
+
+		setRule := func(path, subject string, limit int64, expectedStatus int) {
+			req := NewRequestWithJSON(t, "PUT", "/api/v1/admin/quota/"+path+"/rules/"+subject, &api.SetQuotaRuleOption{Limit: limit}).AddTokenAuth(adminToken)
+			MakeRequest(t, req, expectedStatus)
+		}
+		uploadPackage := func(version string, expectedStatus int) {
+			req := NewRequestWithBody(t, "PUT", fmt.Sprintf("/api/packages/user2/generic/quota-package/%s/file.bin", version), bytes.NewReader([]byte{1})).
+				AddBasicAuth("user2")
+			MakeRequest(t, req, expectedStatus)
+		}
+
+		t.Run("Groups", func(t *testing.T) {
+			createGroup := func(expectedStatus int) {
+				req := NewRequestWithJSON(t, "POST", "/api/v1/admin/quota/groups", &api.CreateQuotaGroupOption{Name: "limited"}).AddTokenAuth(adminToken)
+				MakeRequest(t, req, expectedStatus)
+			}
+			createGroup(http.StatusCreated)
+			createGroup(http.StatusConflict)
+
+			// only the site administrators could manage the quotas
+			req := NewRequest(t, "GET", "/api/v1/admin/quota/groups").AddTokenAuth(userToken)
+			MakeRequest(t, req, http.StatusForbidden)
+
+			setRule("groups/limited", "packages", 0, http.StatusOK)
+			setRule("groups/limited", "unknown", 0, http.StatusUnprocessableEntity)
+			setRule("groups/missing", "packages", 0, http.StatusNotFound)
+
+			req = NewRequest(t, "PUT", "/api/v1/admin/quota/groups/limited/members/user2").AddTokenAuth(adminToken)
+			MakeRequest(t, req, http.StatusNoContent)
+
+			req = NewRequest(t, "GET", "/api/v1/admin/quota/groups/limited").AddTokenAuth(adminToken)
+			resp := MakeRequest(t, req, http.StatusOK)
+			var group api.QuotaGroup
+			DecodeJSON(t, resp, &group)
+			assert.Equal(t, "limited", group.Name)
+			assert.Equal(t, []string{"user2"}, group.Members)
+			assert.Equal(t, []*api.QuotaRule{{Subject: "packages", Limit: 0}}, group.Rules)
+
+			req = NewRequest(t, "GET", "/api/v1/user/quota").AddTokenAuth(userToken)
+			resp = MakeRequest(t, req, http.StatusOK)
+			var info api.QuotaInfo
+			DecodeJSON(t, resp, &info)
+			assert.Equal(t, []string{"limited"}, info.Groups)
+			assert.Equal(t, map[string]int64{"packages": 0}, info.Limits)
+			assert.Len(t, info.Used, len(quota_model.Subjects))
+
+			uploadPackage("1.0", http.StatusForbidden)
+
+			// the rules of the user override the rules of its groups
+			setRule("users/user2", "packages", -1, http.StatusOK)
+			uploadPackage("1.0", http.StatusCreated)
+
+			req = NewRequest(t, "DELETE", "/api/v1/admin/quota/users/user2/rules/packages").AddTokenAuth(adminToken)
+			MakeRequest(t, req, http.StatusNoContent)
+			uploadPackage("1.1", http.StatusForbidden)
+
+			req = NewRequest(t, "DELETE", "/api/v1/admin/quota/groups/limited").AddTokenAuth(adminToken)
+			MakeRequest(t, req, http.StatusNoContent)
+			unittest.AssertNotExistsBean(t, &quota_model.GroupMember{})
+			uploadPackage("1.1", http.StatusCreated)
+		})
+
+		t.Run("Attachment", func(t *testing.T) {
+			setRule("users/user2", "attachments", 10, http.StatusOK)
+			defer quota_model.DeleteOwnerQuota(db.DefaultContext, 2)
+
+			createAttachment(t, session, "/user2/repo1", "image.png", generateImg(), http.StatusRequestEntityTooLarge)
+		})
+
+		t.Run("LFS", func(t *testing.T) {
+			setRule("users/user2", "lfs", 10, http.StatusOK)
+			defer quota_model.DeleteOwnerQuota(db.DefaultContext, 2)
+
+			req := NewRequestWithJSON(t, "POST", "/user2/repo1.git/info/lfs/objects/batch", &lfs.BatchRequest{
+				Operation: "upload",
+				Objects:   []lfs.Pointer{{Oid: "fb8f7d8435968c4f82a726a92395be4d16f2f63116caf36c8ad35c60831ab042", Size: 11}},
+			}).
+				SetHeader("Accept", lfs.AcceptHeader).
+				SetHeader("Content-Type", lfs.MediaType)
+			session.MakeRequest(t, req, http.StatusRequestEntityTooLarge)
+		})
+
+		t.Run("Push", func(t *testing.T) {
+			// the pushed objects are not counted before the push, so only a used up quota rejects it
+			require.NoError(t, repo_model.UpdateRepoSize(db.DefaultContext, 1, 2048, 0))
+			setRule("users/user2", "git", 1024, http.StatusOK)
+			defer quota_model.DeleteOwnerQuota(db.DefaultContext, 2)
+
+			dstPath := t.TempDir()
+			cloneURL, _ := url.Parse(u.String())
+			cloneURL.Path = "/user2/repo1.git"
+			cloneURL.User = url.UserPassword("user2", userPassword)
+			doGitClone(dstPath, cloneURL)(t)
+
+			_, err := generateCommitWithNewData(littleSize, dstPath, "user2@example.com", "User Two", "quota-")
+			require.NoError(t, err)
+			doGitPushTestRepositoryFail(dstPath, "origin", "master")(t)
+
+			setRule("users/user2", "git", -1, http.StatusOK)
+			doGitPushTestRepository(dstPath, "origin", "master")(t)
+		})
+	})
+}
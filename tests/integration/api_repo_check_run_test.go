@@ -0,0 +1,136 @@
+// Copyright 2024 The Gitea Authors. All rights reserved.
+// SPDX-License-Identifier: MIT
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	auth_model "code.gitea.io/gitea/models/auth"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoCheckRuns(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		const headSHA = "1978192d98bb1b65e11c2cf37da854fbf94bffd6"
+		session := loginUser(t, "user2")
+		token := getTokenForLoggedInUser(t, session, auth_model.AccessTokenScopeWriteRepository)
+		readToken := getTokenForLoggedInUser(t, loginUser(t, "user4"), auth_model.AccessTokenScopeWriteRepository)
+		urlPrefix := "/api/v1/repos/user2/commitsonpr"
+
+		newAnnotation := func(path string, line int64, message string) *api.CheckRunAnnotation {
+			return &api.CheckRunAnnotation{
+				Path:            path,
+				StartLine:       line,
+				AnnotationLevel: "failure",
+				Title:           "Lint",
+				Message:         message,
+			}
+		}
+
+		// invalid requests
+		for _, opt := range []*api.CreateCheckRunOption{
+			{Name: "lint", HeadSHA: "0000000000000000000000000000000000000000"},
+			{Name: "lint", HeadSHA: headSHA, Status: "completed"},
+			{Name: "lint", HeadSHA: headSHA, Status: "in_progress", Conclusion: "success"},
+			{Name: "lint", HeadSHA: headSHA, Output: &api.CheckRunOutputOption{
+				Annotations: []*api.CheckRunAnnotation{newAnnotation("", 1, "no path")},
+			}},
+			{Name: "lint", HeadSHA: headSHA, Output: &api.CheckRunOutputOption{
+				Annotations: []*api.CheckRunAnnotation{{Path: "test1.txt", StartLine: 1, AnnotationLevel: "error", Message: "invalid level"}},
+			}},
+		} {
+			req := NewRequestWithJSON(t, "POST", urlPrefix+"/check-runs", opt).AddTokenAuth(token)
+			MakeRequest(t, req, http.StatusUnprocessableEntity)
+		}
+
+		// users without write permission can't create check runs
+		req := NewRequestWithJSON(t, "POST", urlPrefix+"/check-runs", &api.CreateCheckRunOption{Name: "lint", HeadSHA: headSHA}).AddTokenAuth(readToken)
+		MakeRequest(t, req, http.StatusForbidden)
+
+		// create a check run with a short sha
+		req = NewRequestWithJSON(t, "POST", urlPrefix+"/check-runs", &api.CreateCheckRunOption{
+			Name:       "lint",
+			HeadSHA:    headSHA[:10],
+			ExternalID: "ext-1",
+			Status:     "in_progress",
+			Output: &api.CheckRunOutputOption{
+				Title:       "Lint report",
+				Summary:     "Found **1** problem",
+				Annotations: []*api.CheckRunAnnotation{newAnnotation("test1.txt", 1, "first-annotation-message")},
+			},
+		}).AddTokenAuth(token)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		var checkRun api.CheckRun
+		DecodeJSON(t, resp, &checkRun)
+		assert.Equal(t, headSHA, checkRun.HeadSHA)
+		assert.Equal(t, "in_progress", checkRun.Status)
+		assert.Empty(t, checkRun.Conclusion)
+		assert.NotNil(t, checkRun.StartedAt)
+		assert.Equal(t, "Lint report", checkRun.Output.Title)
+		assert.EqualValues(t, 1, checkRun.Output.AnnotationsCount)
+		assert.Equal(t, "user2", checkRun.Creator.UserName)
+
+		// complete the check run and append an annotation
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("%s/check-runs/%d", urlPrefix, checkRun.ID), &api.UpdateCheckRunOption{
+			Conclusion: &[]string{"failure"}[0],
+			Output: &api.CheckRunOutputOption{
+				Title:       "Lint report",
+				Summary:     "Found **2** problems",
+				Annotations: []*api.CheckRunAnnotation{newAnnotation("test10.txt", 1, "second-annotation-message")},
+			},
+		}).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &checkRun)
+		assert.Equal(t, "completed", checkRun.Status)
+		assert.Equal(t, "failure", checkRun.Conclusion)
+		assert.NotNil(t, checkRun.CompletedAt)
+		assert.Equal(t, "Found **2** problems", checkRun.Output.Summary)
+		assert.EqualValues(t, 2, checkRun.Output.AnnotationsCount)
+
+		req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("%s/check-runs/%d", urlPrefix, checkRun.ID), &api.UpdateCheckRunOption{
+			Status: &[]string{"completed"}[0],
+			Name:   &[]string{""}[0],
+		}).AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/check-runs/%d/annotations", urlPrefix, checkRun.ID)).AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var annotations []*api.CheckRunAnnotation
+		DecodeJSON(t, resp, &annotations)
+		if assert.Len(t, annotations, 2) {
+			assert.Equal(t, "test1.txt", annotations[0].Path)
+			assert.EqualValues(t, 1, annotations[0].EndLine)
+			assert.Equal(t, "second-annotation-message", annotations[1].Message)
+		}
+
+		req = NewRequest(t, "GET", urlPrefix+"/commits/branch1/check-runs?check_name=lint").AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		var checkRuns []*api.CheckRun
+		DecodeJSON(t, resp, &checkRuns)
+		if assert.Len(t, checkRuns, 1) {
+			assert.Equal(t, checkRun.ID, checkRuns[0].ID)
+			assert.Equal(t, "ext-1", checkRuns[0].ExternalID)
+		}
+		req = NewRequest(t, "GET", urlPrefix+"/commits/branch1/check-runs?status=queued").AddTokenAuth(token)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &checkRuns)
+		assert.Empty(t, checkRuns)
+
+		req = NewRequest(t, "GET", urlPrefix+"/check-runs/999999").AddTokenAuth(token)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		// the annotations are rendered inline in the diff of the pull request
+		req = NewRequest(t, "GET", "/user2/commitsonpr/pulls/1/files")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		doc := NewHTMLParser(t, resp.Body)
+		assert.Equal(t, 2, doc.Find(".diff-annotation").Length())
+		assert.Equal(t, 1, doc.Find(`.file-content[data-old-filename="test1.txt"] .diff-annotation-failure`).Length())
+		assert.Contains(t, doc.Find(".diff-annotations").Text(), "first-annotation-message")
+	})
+}
@@ -248,6 +248,8 @@ func PrepareTestEnv(t testing.TB, skip ...int) func() {
 		&packages_model.PackageProperty{},
 		&packages_model.PackageBlobUpload{},
 		&packages_model.PackageCleanupRule{},
+		&packages_model.PackageAccess{},
+		&packages_model.PackageRepository{},
 	))
 	assert.NoError(t, storage.Clean(storage.Packages))
 